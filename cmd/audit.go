@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/macosta/tdd-ai/internal/audit"
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the append-only audit log",
+	Long: `Sessions started with 'tdd-ai init --audit' append every history event to
+.tdd-ai.audit.jsonl, separate from the mutable session file. Each line includes
+the hash of the previous line, so any edit, deletion, or reordering is detectable.`,
+	Example: `  tdd-ai init --audit
+  tdd-ai audit verify`,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the audit log's hash chain for tampering",
+	Long: `Recomputes every entry's hash and checks it links to the previous entry, and
+that the log ends at the entry count and hash recorded in the session file, so
+removing the last lines is detected too.
+
+Returns exit code 0 when the chain is intact, 1 when tampering is detected or
+no audit log exists.`,
+	Example: `  tdd-ai audit verify
+  tdd-ai audit verify --format json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		if _, err := os.Stat(audit.Path(dir)); os.IsNotExist(err) {
+			return fmt.Errorf("no audit log found. Start a session with 'tdd-ai init --audit' to record one")
+		}
+
+		var head audit.Head
+		if session.Exists(dir) {
+			s, err := session.Load(dir)
+			if err != nil {
+				return err
+			}
+			head = audit.Head{Entries: s.AuditEntries, Hash: s.AuditHash}
+		}
+		entries, verifyErr := audit.Verify(dir, head)
+		out := struct {
+			Intact  bool   `json:"intact"`
+			Entries int    `json:"entries"`
+			Error   string `json:"error,omitempty"`
+		}{Intact: verifyErr == nil, Entries: entries}
+		if verifyErr != nil {
+			out.Error = verifyErr.Error()
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding audit result: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if out.Intact {
+				fmt.Fprintf(cmd.OutOrStdout(), "Audit log intact: %d entries verified\n", entries)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Audit log TAMPERED: %d entries verified before the chain breaks\n", entries)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return verifyErr
+	},
+}
+
+func init() {
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
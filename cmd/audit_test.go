@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/audit"
+)
+
+func TestAuditVerify(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { auditFlag = false }()
+
+	if _, _, err := executePhaseCmd(t, "audit", "verify", "--format", "text"); err == nil {
+		t.Error("audit verify should fail without an audit log")
+	}
+
+	if _, _, err := executePhaseCmd(t, "init", "--audit", "--format", "text"); err != nil {
+		t.Fatalf("init --audit failed: %v", err)
+	}
+	if _, err := executeSpecCmd(t, "spec", "add", "feature", "--format", "text"); err != nil {
+		t.Fatalf("spec add failed: %v", err)
+	}
+
+	out, _, err := executePhaseCmd(t, "audit", "verify", "--format", "text")
+	if err != nil {
+		t.Fatalf("audit verify failed: %v", err)
+	}
+	if !strings.Contains(out, "Audit log intact: 2 entries verified") {
+		t.Errorf("should verify init and spec_add, got:\n%s", out)
+	}
+
+	data, _ := os.ReadFile(audit.Path(dir))
+	tampered := strings.Replace(string(data), `"spec_add"`, `"spec_done"`, 1)
+	if err := os.WriteFile(audit.Path(dir), []byte(tampered), 0644); err != nil {
+		t.Fatalf("failed to tamper with audit log: %v", err)
+	}
+
+	out, _, err = executePhaseCmd(t, "audit", "verify", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "line 2: hash mismatch") {
+		t.Errorf("audit verify should detect tampering, got: %v", err)
+	}
+	if !strings.Contains(out, "TAMPERED") {
+		t.Errorf("should report tampering, got:\n%s", out)
+	}
+}
+
+func TestAuditVerifyDetectsTruncation(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { auditFlag = false }()
+
+	if _, _, err := executePhaseCmd(t, "init", "--audit", "--format", "text"); err != nil {
+		t.Fatalf("init --audit failed: %v", err)
+	}
+	if _, err := executeSpecCmd(t, "spec", "add", "feature", "--format", "text"); err != nil {
+		t.Fatalf("spec add failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(audit.Path(dir))
+	first, _, _ := strings.Cut(string(data), "\n")
+	if err := os.WriteFile(audit.Path(dir), []byte(first+"\n"), 0644); err != nil {
+		t.Fatalf("failed to truncate audit log: %v", err)
+	}
+
+	_, _, err := executePhaseCmd(t, "audit", "verify", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "entries were removed") {
+		t.Errorf("audit verify should detect the removed entry, got: %v", err)
+	}
+}
@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/audit"
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/spectemplate"
+	"github.com/macosta/tdd-ai/internal/testrun"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// batchCommand is one entry of the JSON array read by tdd-ai batch.
+type batchCommand struct {
+	Cmd  string   `json:"cmd"`
+	Args []string `json:"args,omitempty"`
+}
+
+// batchResult reports how one batch command went. Output holds the command's
+// JSON output, or a JSON string when it printed something else.
+type batchResult struct {
+	Cmd      string          `json:"cmd"`
+	Args     []string        `json:"args,omitempty"`
+	Status   string          `json:"status"`
+	ExitCode int             `json:"exit_code"`
+	Output   json.RawMessage `json:"output,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Batch result statuses.
+const (
+	batchOK      = "ok"
+	batchFailed  = "failed"
+	batchSkipped = "skipped"
+)
+
+// batchExcluded are commands that cannot run inside a batch: they block, read
+// stdin themselves, would nest batches, or have effects a failed batch cannot
+// roll back (the per-user secret store, the installed binary, a background
+// test worker).
+var batchExcluded = []string{"batch", "secret set", "secret delete", "self-update", "serve", "simulate", "test worker", "tutorial", "wait"}
+
+// batchExcludedFlags are flags whose effects a failed batch cannot roll back:
+// --async starts a detached test worker and --out writes files anywhere.
+var batchExcludedFlags = []string{"async", "out"}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Run a JSON array of commands from stdin as one transaction",
+	Long: `Reads a JSON array of commands from stdin and runs them in order in a single
+process, printing one result per command:
+
+  [{"cmd": "spec add", "args": ["login works"]}, {"cmd": "spec pick", "args": ["1"]}]
+
+Each "cmd" is a command path as typed after 'tdd-ai' and "args" are its arguments
+and flags. Commands run with --format json so each result embeds the command's
+JSON output.
+
+The batch is transactional: the first failing command stops it, later commands
+are reported as skipped, and the session file, audit log, spec archive, spec
+templates, sessions in .tdd-ai.trash, and background test runs in .tdd-ai.runs
+are restored to their state before the batch. Commands whose effects cannot be
+rolled back are refused: 'secret set', 'secret delete', 'self-update', and any
+command given --async or --out. The exit code is that of the failing
+command.`,
+	Example: `  echo '[{"cmd":"spec add","args":["a","b"]},{"cmd":"spec pick","args":["1"]}]' | tdd-ai batch
+  tdd-ai batch --format text < commands.json`,
+	Args: cobra.NoArgs,
+	// A failing command's error is already in the results; usage adds nothing.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		var commands []batchCommand
+		if err := json.NewDecoder(cmd.InOrStdin()).Decode(&commands); err != nil {
+			return invalidInputError(fmt.Errorf("reading batch from stdin: %w", err))
+		}
+		for i, c := range commands {
+			if err := validateBatchCommand(c); err != nil {
+				return invalidInputError(fmt.Errorf("command %d: %w", i+1, err))
+			}
+		}
+
+		f := formatter.Format(formatFlag)
+		if f != formatter.FormatJSON && f != formatter.FormatText {
+			return unknownFormatError(f)
+		}
+
+		dir := getWorkDir()
+		snap, err := snapshotBatchFiles(dir)
+		if err != nil {
+			return err
+		}
+
+		out, errOut := cmd.OutOrStdout(), cmd.ErrOrStderr()
+		results, failure := runBatch(commands)
+		rootCmd.SetOut(out)
+		rootCmd.SetErr(errOut)
+		rootCmd.SetIn(nil)
+		formatFlag = string(f)
+
+		if failure != nil {
+			if err := snap.restore(); err != nil {
+				return fmt.Errorf("rolling back batch: %w", err)
+			}
+		}
+
+		if err := writeBatchResults(out, results, f); err != nil {
+			return err
+		}
+		if failure != nil {
+			return failure
+		}
+		return nil
+	},
+}
+
+// validateBatchCommand rejects commands that are unknown or cannot run in a batch.
+func validateBatchCommand(c batchCommand) error {
+	if err := validateInProcessCommand(c.Cmd, batchExcluded, "a batch"); err != nil {
+		return err
+	}
+	for _, arg := range c.Args {
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if strings.HasPrefix(arg, "--") && slices.Contains(batchExcludedFlags, name) {
+			return fmt.Errorf("%q with --%s cannot run inside a batch", c.Cmd, name)
+		}
+	}
+	return nil
+}
+
+// validateInProcessCommand rejects a command path that is unknown or that,
+// or whose top-level command, is excluded from running inside where.
+func validateInProcessCommand(path string, excluded []string, where string) error {
+	fields := strings.Fields(path)
+	if len(fields) == 0 {
+		return errors.New(`missing "cmd"`)
+	}
+	target, rest, err := rootCmd.Find(fields)
+	if err != nil || len(rest) > 0 || target == rootCmd {
+		return fmt.Errorf("unknown command %q", path)
+	}
+	name := strings.TrimPrefix(target.CommandPath(), rootCmd.Name()+" ")
+	if slices.Contains(excluded, fields[0]) || slices.Contains(excluded, name) {
+		return fmt.Errorf("%q cannot run inside %s", path, where)
+	}
+	return nil
+}
+
+// runBatch executes commands in order until one fails, returning a result for
+// every command and the failing command's error.
+func runBatch(commands []batchCommand) ([]batchResult, error) {
+	results := make([]batchResult, 0, len(commands))
+	var failure error
+	for _, c := range commands {
+		r := batchResult{Cmd: c.Cmd, Args: c.Args}
+		if failure != nil {
+			r.Status = batchSkipped
+			results = append(results, r)
+			continue
+		}
+
+		out, err := executeInProcess(strings.Fields(c.Cmd), append(c.Args, "--format", string(formatter.FormatJSON)))
+		r.Output = batchOutput(out)
+		r.ExitCode = ExitCode(err)
+		if err != nil {
+			r.Status = batchFailed
+			r.Error = err.Error()
+			failure = fmt.Errorf("batch stopped at %q: %w", c.Cmd, err)
+		} else {
+			r.Status = batchOK
+		}
+		results = append(results, r)
+	}
+	return results, failure
+}
+
+// executeInProcess runs the command at path with args in this process, with
+// every flag back at its default and no stdin, and returns what it printed to
+// stdout. The caller restores rootCmd's output streams afterwards.
+func executeInProcess(path, args []string) ([]byte, error) {
+	target, _, _ := rootCmd.Find(path)
+	resetFlags(rootCmd.PersistentFlags())
+	resetFlags(target.Flags())
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(io.Discard)
+	rootCmd.SetIn(strings.NewReader(""))
+	rootCmd.SetArgs(append(append([]string(nil), path...), args...))
+	silenced := target.SilenceUsage
+	target.SilenceUsage = true
+	err := rootCmd.Execute()
+	target.SilenceUsage = silenced
+	return buf.Bytes(), err
+}
+
+// resetFlags returns every flag in fs to its default, so values given to one
+// batch command do not leak into the next.
+func resetFlags(fs *pflag.FlagSet) {
+	fs.VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
+// batchOutput embeds a command's output as JSON, quoting it when it is not JSON.
+func batchOutput(data []byte) json.RawMessage {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	if json.Valid(data) {
+		return data
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
+
+func writeBatchResults(w io.Writer, results []batchResult, f formatter.Format) error {
+	if f == formatter.FormatJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding batch results: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+	for _, r := range results {
+		line := strings.TrimSpace(r.Cmd + " " + strings.Join(r.Args, " "))
+		switch r.Status {
+		case batchFailed:
+			fmt.Fprintf(w, "%-7s %s: %s\n", r.Status, line, r.Error)
+		default:
+			fmt.Fprintf(w, "%-7s %s\n", r.Status, line)
+		}
+	}
+	return nil
+}
+
+// batchFiles holds the on-disk state a batch may need to roll back. A nil
+// entry in files means the file did not exist.
+type batchFiles struct {
+	files map[string][]byte
+	// dirs records the side directories and every directory below them that
+	// existed, so files and directories commands added can be removed.
+	dirs  map[string]bool
+	roots []string
+}
+
+// batchDirs are the side directories a batch restores file by file: sessions
+// and their side files moved to the trash by 'reset', and background test
+// runs collected by 'test status'.
+func batchDirs(dir string) []string {
+	return []string{session.TrashDir(dir), testrun.Dir(dir)}
+}
+
+func snapshotBatchFiles(dir string) (batchFiles, error) {
+	snap := batchFiles{files: map[string][]byte{}, dirs: map[string]bool{}, roots: batchDirs(dir)}
+	paths := []string{session.FilePath(dir), filepath.Join(dir, audit.FileName), session.ArchivePath(dir), spectemplate.Path(dir)}
+	for _, root := range snap.roots {
+		files, dirs, err := walkBatchDir(root)
+		if err != nil {
+			return batchFiles{}, err
+		}
+		paths = append(paths, files...)
+		for _, d := range dirs {
+			snap.dirs[d] = true
+		}
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return batchFiles{}, fmt.Errorf("snapshotting %s: %w", filepath.Base(path), err)
+		}
+		snap.files[path] = data
+	}
+	return snap, nil
+}
+
+// walkBatchDir lists the regular files and the directories, root included,
+// under root. A missing root has neither.
+func walkBatchDir(root string) (files, dirs []string, err error) {
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir():
+			dirs = append(dirs, path)
+		case d.Type().IsRegular():
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("snapshotting %s: %w", filepath.Base(root), err)
+	}
+	return files, dirs, nil
+}
+
+func (b batchFiles) restore() error {
+	for path, data := range b.files {
+		if data == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	var added []string
+	for _, root := range b.roots {
+		files, dirs, err := walkBatchDir(root)
+		if err != nil {
+			return err
+		}
+		for _, path := range files {
+			if _, ok := b.files[path]; !ok {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+			}
+		}
+		for _, d := range dirs {
+			if !b.dirs[d] {
+				added = append(added, d)
+			}
+		}
+	}
+	for _, d := range added {
+		if err := os.RemoveAll(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/spectemplate"
+	"github.com/macosta/tdd-ai/internal/testrun"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func executeBatch(t *testing.T, input string) ([]batchResult, error) {
+	t.Helper()
+	rootCmd.SetIn(strings.NewReader(input))
+	defer rootCmd.SetIn(nil)
+	out, _, err := executePhaseCmd(t, "batch", "--format", "json")
+	var results []batchResult
+	if jsonErr := json.Unmarshal([]byte(out), &results); jsonErr != nil {
+		t.Fatalf("batch output is not a JSON array: %v\n%s", jsonErr, out)
+	}
+	return results, err
+}
+
+func TestBatchRunsCommandsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	results, err := executeBatch(t, `[
+		{"cmd": "spec add", "args": ["first", "second"]},
+		{"cmd": "spec pick", "args": ["2"]},
+		{"cmd": "phase"}
+	]`)
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("want 3 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Status != batchOK || r.ExitCode != ExitOK {
+			t.Errorf("%s: status %q exit %d, want ok", r.Cmd, r.Status, r.ExitCode)
+		}
+	}
+	if !json.Valid(results[2].Output) || !strings.Contains(string(results[2].Output), "red") {
+		t.Errorf("phase output should be embedded JSON, got %s", results[2].Output)
+	}
+
+	s, _ := session.Load(dir)
+	if len(s.Specs) != 2 || s.CurrentSpecID == nil || *s.CurrentSpecID != 2 {
+		t.Errorf("batch should add two specs and pick spec 2, got %+v", s)
+	}
+}
+
+func TestBatchRollsBackOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	results, err := executeBatch(t, `[
+		{"cmd": "spec add", "args": ["first"]},
+		{"cmd": "spec pick", "args": ["99"]},
+		{"cmd": "spec add", "args": ["never"]}
+	]`)
+	if err == nil {
+		t.Fatal("batch should fail when a command fails")
+	}
+	want := []string{batchOK, batchFailed, batchSkipped}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("result %d status = %q, want %q", i, r.Status, want[i])
+		}
+	}
+	if results[1].Error == "" {
+		t.Error("failed command should report its error")
+	}
+
+	s, _ := session.Load(dir)
+	if len(s.Specs) != 0 {
+		t.Errorf("session should be rolled back, got specs %+v", s.Specs)
+	}
+}
+
+func TestBatchRejectsUnknownCommands(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	rootCmd.SetIn(strings.NewReader(`[{"cmd": "serve"}]`))
+	defer rootCmd.SetIn(nil)
+	_, _, err := executePhaseCmd(t, "batch")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("serve inside a batch should be invalid input, got %v", err)
+	}
+}
+
+func TestBatchRollsBackReset(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := session.AppendArchive(dir, []types.Spec{{ID: 1, Description: "archived"}}); err != nil {
+		t.Fatalf("failed to archive spec: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, err := executeBatch(t, `[
+		{"cmd": "reset"},
+		{"cmd": "spec pick", "args": ["99"]}
+	]`)
+	if err == nil {
+		t.Fatal("batch should fail when a command fails")
+	}
+
+	if !session.Exists(dir) {
+		t.Error("session file should be restored after rollback")
+	}
+	if archived, _ := session.LoadArchive(dir); len(archived) != 1 {
+		t.Errorf("spec archive should be restored after rollback, got %+v", archived)
+	}
+	if _, err := os.Stat(session.TrashDir(dir)); !os.IsNotExist(err) {
+		t.Errorf("trash directory created by the batch should be removed, got %v", err)
+	}
+}
+
+func TestBatchRollsBackSpecTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, err := executeBatch(t, `[
+		{"cmd": "spec template save", "args": ["errors", "returns 400 for missing {field}"]},
+		{"cmd": "spec pick", "args": ["99"]}
+	]`)
+	if err == nil {
+		t.Fatal("batch should fail when a command fails")
+	}
+
+	if _, err := os.Stat(spectemplate.Path(dir)); !os.IsNotExist(err) {
+		t.Errorf("spec templates saved by the batch should be rolled back, got %v", err)
+	}
+}
+
+func TestBatchRefusesCommandsItCannotRollBack(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	tests := []struct {
+		name, cmd string
+	}{
+		{"secret set", `{"cmd": "secret set", "args": ["TOKEN"]}`},
+		{"secret delete", `{"cmd": "secret delete", "args": ["TOKEN"]}`},
+		{"self-update", `{"cmd": "self-update"}`},
+		{"test --async", `{"cmd": "test", "args": ["--async"]}`},
+		{"test worker", `{"cmd": "test worker", "args": ["abc"]}`},
+		{"export --out", `{"cmd": "export specs", "args": ["--out=specs.md"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd.SetIn(strings.NewReader(`[{"cmd": "spec add", "args": ["first"]}, ` + tt.cmd + `]`))
+			defer rootCmd.SetIn(nil)
+			_, _, err := executePhaseCmd(t, "batch")
+			if ExitCode(err) != ExitInvalidInput || !strings.Contains(err.Error(), "cannot run inside a batch") {
+				t.Errorf("want an invalid input error, got %v", err)
+			}
+			if s, _ := session.Load(dir); len(s.Specs) != 0 {
+				t.Errorf("a refused batch should run nothing, got specs %+v", s.Specs)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "specs.md")); !os.IsNotExist(err) {
+		t.Errorf("export --out should not have written a file, got %v", err)
+	}
+}
+
+func TestBatchRollsBackCollectedTestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	run, err := testrun.New(dir, "go test ./...", "", types.PhaseRed, 0, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	run.Finish("fail", time.Now())
+	if err := testrun.Save(dir, run); err != nil {
+		t.Fatal(err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, err := executeBatch(t, `[
+		{"cmd": "test status", "args": ["`+run.ID+`"]},
+		{"cmd": "spec pick", "args": ["99"]}
+	]`); err == nil {
+		t.Fatal("batch should fail when a command fails")
+	}
+
+	loaded, err := testrun.Load(dir, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Collected {
+		t.Error("the run should be uncollected again so its result can still be recorded")
+	}
+	if s, _ := session.Load(dir); s.LastTestResult != "" {
+		t.Errorf("session should be rolled back, got last test result %q", s.LastTestResult)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/macosta/tdd-ai/internal/phase"
 	"github.com/macosta/tdd-ai/internal/session"
 	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/macosta/tdd-ai/internal/verify"
 	"github.com/spf13/cobra"
 )
 
@@ -21,9 +22,14 @@ type blockersOutput struct {
 var blockersCmd = &cobra.Command{
 	Use:   "blockers",
 	Short: "Show what's preventing phase advancement",
-	Long:  "Returns the current blockers that must be resolved before advancing to the next phase.",
+	Long: `Returns the current blockers that must be resolved before advancing to the next phase.
+
+Use --format gha in GitHub Actions to annotate the pull request with TDD
+adherence problems: blockers and compliance violations become errors, and
+incomplete specs become notices.`,
 	Example: `  tdd-ai blockers
-  tdd-ai blockers --format json`,
+  tdd-ai blockers --format json
+  tdd-ai blockers --format gha`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
@@ -31,6 +37,11 @@ var blockersCmd = &cobra.Command{
 			return err
 		}
 
+		if s.Phase == types.PhaseGreen {
+			if err := checkTestFiles(dir, s); err != nil {
+				return err
+			}
+		}
 		blockers := phase.GetBlockers(s)
 		out := blockersOutput{
 			Phase:      s.Phase,
@@ -58,13 +69,39 @@ var blockersCmd = &cobra.Command{
 				}
 				fmt.Fprint(cmd.OutOrStdout(), b.String())
 			}
+		case formatter.FormatGHA:
+			fmt.Fprint(cmd.OutOrStdout(), formatter.FormatAnnotations(ciAnnotations(s, blockers)))
+		case formatter.FormatPorcelain:
+			fmt.Fprint(cmd.OutOrStdout(), formatter.PorcelainBlockers(s.Phase, blockers))
 		default:
-			return fmt.Errorf("unknown format: %q", f)
+			return unknownFormatError(f)
 		}
 		return nil
 	},
 }
 
+// ciAnnotations collects every TDD adherence problem worth surfacing on a pull
+// request: phase blockers, compliance violations, and specs still open.
+func ciAnnotations(s *types.Session, blockers []string) []formatter.Annotation {
+	var annotations []formatter.Annotation
+	for _, bl := range blockers {
+		annotations = append(annotations, formatter.Annotation{
+			Level:   formatter.AnnotationError,
+			Title:   fmt.Sprintf("TDD blocker (%s phase)", s.Phase),
+			Message: bl,
+		})
+	}
+	annotations = append(annotations, verifyAnnotations(verify.Analyze(s))...)
+	for _, spec := range s.ActiveSpecs() {
+		annotations = append(annotations, formatter.Annotation{
+			Level:   formatter.AnnotationNotice,
+			Title:   "Incomplete spec",
+			Message: fmt.Sprintf("[%d] %s", spec.ID, spec.Description),
+		})
+	}
+	return annotations
+}
+
 func init() {
 	rootCmd.AddCommand(blockersCmd)
 }
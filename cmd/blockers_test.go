@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestBlockersGHAFormat(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature A")
+	s.AddEvent("phase_set", func(e *types.Event) { e.From = "red"; e.To = "green" })
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "blockers", "--format", "gha")
+	if err != nil {
+		t.Fatalf("blockers --format gha failed: %v", err)
+	}
+	for _, want := range []string{
+		"::error title=TDD blocker (red phase)::No spec selected",
+		"::error title=TDD violation (no_phase_set)::",
+		"::notice title=Incomplete spec::[1] feature A",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestBlockersGHAFormatQuietWhenClean(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature A")
+	_ = s.SetCurrentSpec(1)
+	s.LastTestResult = "fail"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "blockers", "--format", "gha")
+	if err != nil {
+		t.Fatalf("blockers --format gha failed: %v", err)
+	}
+	if strings.Contains(out, "::error") {
+		t.Errorf("should emit no errors without blockers or violations, got:\n%s", out)
+	}
+}
+
+func TestBlockersPorcelainFlag(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature A")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { porcelainFlag = false }()
+
+	out, _, err := executePhaseCmd(t, "blockers", "--porcelain")
+	if err != nil {
+		t.Fatalf("blockers --porcelain failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "red\tNo spec selected") {
+		t.Errorf("expected tab-separated blocker record, got: %q", out)
+	}
+}
+
+func TestQuietSuppressesOutput(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature A")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "blockers", "--quiet", "--format", "text")
+	quietFlag = false
+	if err != nil {
+		t.Fatalf("blockers --quiet failed: %v", err)
+	}
+	if out != "" {
+		t.Errorf("--quiet should suppress output, got: %q", out)
+	}
+
+	out, _, _ = executePhaseCmd(t, "blockers", "--format", "text")
+	if !strings.Contains(out, "No spec selected") {
+		t.Errorf("output should return once --quiet is dropped, got: %q", out)
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// gitBranch returns the branch checked out in dir, or "" outside a git
+// repository or on a detached HEAD.
+func gitBranch(dir string) string {
+	c := exec.Command("git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// branchGuard returns the save hook keeping a session on the branch it was
+// initialized on. Saving with another branch checked out warns once per run,
+// or fails when the session is strict about its branch.
+func branchGuard(cmd *cobra.Command) func(string, *types.Session) error {
+	warned := false
+	return func(dir string, s *types.Session) error {
+		if s.Branch == "" {
+			return nil
+		}
+		current := gitBranch(dir)
+		if !s.OffBranch(current) {
+			return nil
+		}
+		if s.StrictBranch {
+			return blockedError(fmt.Errorf("session is pinned to branch %q but %q is checked out. Switch back, or run 'tdd-ai config branch pin' to move the session to this branch", s.Branch, current))
+		}
+		if !warned {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: session is pinned to branch %q but %q is checked out (run 'tdd-ai config branch pin' to move it)\n", s.Branch, current)
+			warned = true
+		}
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+)
+
+func TestSessionPinnedToBranch(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	checkout := func(branch string) {
+		t.Helper()
+		c := exec.Command("git", "checkout", "-q", "-B", branch)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git checkout %s: %v\n%s", branch, err, out)
+		}
+	}
+	checkout("main")
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	resetFlags(initCmd.Flags())
+	defer resetFlags(initCmd.Flags())
+
+	out, _, err := executePhaseCmd(t, "init", "--no-detect", "--format", "text")
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !strings.Contains(out, "Pinned to branch: main") {
+		t.Errorf("init should report the pinned branch, got:\n%s", out)
+	}
+
+	checkout("feature")
+	_, errOut, err := executePhaseCmd(t, "spec", "add", "adds numbers", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec add off the pinned branch should only warn, got %v", err)
+	}
+	if !strings.Contains(errOut, `pinned to branch "main" but "feature" is checked out`) {
+		t.Errorf("spec add should warn about the branch, got stderr:\n%s", errOut)
+	}
+
+	if _, _, err := executePhaseCmd(t, "config", "branch", "strict"); ExitCode(err) != ExitBlocked {
+		t.Errorf("making the session strict off its branch should be blocked, got %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "config", "branch", "pin"); err != nil {
+		t.Fatalf("config branch pin failed: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "config", "branch", "strict"); err != nil {
+		t.Fatalf("config branch strict failed: %v", err)
+	}
+	s, _ := session.Load(dir)
+	if s.Branch != "feature" || !s.StrictBranch {
+		t.Fatalf("session should be strictly pinned to feature, got %q (strict %v)", s.Branch, s.StrictBranch)
+	}
+	if last := s.History[len(s.History)-1]; last.Action != "branch_pin" || last.From != "main" || last.To != "feature" {
+		t.Errorf("pinning should record a branch_pin event, got %+v", last)
+	}
+
+	checkout("main")
+	if _, _, err := executePhaseCmd(t, "spec", "add", "subtracts numbers", "--format", "text"); ExitCode(err) != ExitBlocked {
+		t.Errorf("spec add off the branch of a strict session should be blocked, got %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "status", "--format", "text"); err != nil {
+		t.Errorf("read-only commands should run off the branch, got %v", err)
+	}
+	s, _ = session.Load(dir)
+	if len(s.Specs) != 1 {
+		t.Errorf("a refused command must not change the session, got %d specs", len(s.Specs))
+	}
+}
+
+func TestInitStrictBranchNeedsBranch(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	resetFlags(initCmd.Flags())
+	defer resetFlags(initCmd.Flags())
+
+	if _, _, err := executePhaseCmd(t, "init", "--strict-branch", "--no-detect"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("--strict-branch outside a git repository should be invalid input, got %v", err)
+	}
+	if session.Exists(dir) {
+		t.Error("init should not create a session when --strict-branch is refused")
+	}
+}
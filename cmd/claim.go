@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var claimCmd = &cobra.Command{
+	Use:   "claim [path...]",
+	Short: "Register files this agent is editing (lists claims when called without paths)",
+	Long: `Registers which files the agent named by TDD_AI_AGENT_ID is editing, so sub-agents
+working on different specs in parallel do not step on each other.
+
+Claiming a file held by another agent fails and is recorded in the history;
+'tdd-ai verify' reports these conflicts, and uncommitted changes to files
+claimed by another agent, as warnings. Call claim before editing a
+file (for example from an editor hook), and 'tdd-ai release' when done.`,
+	Example: `  TDD_AI_AGENT_ID=worker-1 tdd-ai claim internal/auth/login.go internal/auth/login_test.go
+  tdd-ai claim`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		if len(args) == 0 {
+			s, err := session.LoadOrFail(dir)
+			if err != nil {
+				return err
+			}
+			return printClaims(cmd, s)
+		}
+
+		// Agents claim files in parallel; the lock keeps their updates from
+		// overwriting each other.
+		unlock, err := session.Lock(dir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		agentID := types.CurrentAgentID()
+		paths := normalizeClaimPaths(dir, args)
+
+		if conflicts := s.ClaimConflicts(agentID, paths); len(conflicts) > 0 && agentID != "" {
+			var holders []string
+			for _, p := range conflicts {
+				holders = append(holders, s.ClaimHolder(p))
+			}
+			s.AddEvent("claim_conflict", func(e *types.Event) {
+				e.Files = conflicts
+				e.Result = strings.Join(holders, ", ")
+			})
+			if err := session.Save(dir, s); err != nil {
+				return err
+			}
+		}
+
+		if err := s.ClaimFiles(agentID, paths); err != nil {
+			return blockedError(err)
+		}
+		s.AddEvent("claim", func(e *types.Event) {
+			e.Files = paths
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s claimed %d file(s)\n", agentID, len(paths))
+		return nil
+	},
+}
+
+var releaseAllFlag bool
+
+var releaseCmd = &cobra.Command{
+	Use:   "release [path...]",
+	Short: "Release file claims held by this agent",
+	Long:  "Release this agent's claims on the given files, or on every file it holds with --all.",
+	Example: `  TDD_AI_AGENT_ID=worker-1 tdd-ai release internal/auth/login.go
+  TDD_AI_AGENT_ID=worker-1 tdd-ai release --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if releaseAllFlag && len(args) > 0 {
+			return fmt.Errorf("cannot use --all with specific paths")
+		}
+		if !releaseAllFlag && len(args) == 0 {
+			return fmt.Errorf("provide at least one path, or use --all")
+		}
+
+		dir := getWorkDir()
+		unlock, err := session.Lock(dir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		agentID := types.CurrentAgentID()
+		if agentID == "" {
+			return fmt.Errorf("agent ID required to release claims (set %s)", types.AgentIDEnv)
+		}
+
+		paths := normalizeClaimPaths(dir, args)
+		released := s.ReleaseFiles(agentID, paths)
+		s.AddEvent("release", func(e *types.Event) {
+			e.Files = paths
+			e.SpecCount = released
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s released %d claim(s)\n", agentID, released)
+		return nil
+	},
+}
+
+// claimsTouchedByOthers returns the claims held by agents other than the
+// current one whose files have uncommitted changes.
+func claimsTouchedByOthers(dir string, s *types.Session) []types.Claim {
+	if len(s.Claims) == 0 {
+		return nil
+	}
+	changed := make(map[string]bool)
+	for _, f := range changedFiles(dir) {
+		changed[f] = true
+	}
+	agentID := types.CurrentAgentID()
+	var touched []types.Claim
+	for _, c := range s.Claims {
+		if c.Holder != agentID && changed[c.Path] {
+			touched = append(touched, c)
+		}
+	}
+	return touched
+}
+
+// normalizeClaimPaths makes paths relative to the session directory so the same
+// file is always claimed under the same name.
+func normalizeClaimPaths(dir string, args []string) []string {
+	paths := make([]string, 0, len(args))
+	for _, p := range args {
+		if filepath.IsAbs(p) {
+			if rel, err := filepath.Rel(dir, p); err == nil {
+				p = rel
+			}
+		}
+		paths = append(paths, filepath.ToSlash(filepath.Clean(p)))
+	}
+	return paths
+}
+
+func printClaims(cmd *cobra.Command, s *types.Session) error {
+	f := formatter.Format(formatFlag)
+	switch f {
+	case formatter.FormatJSON:
+		claims := s.Claims
+		if claims == nil {
+			claims = []types.Claim{}
+		}
+		data, err := json.MarshalIndent(claims, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding claims: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	case formatter.FormatText:
+		if len(s.Claims) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No files claimed.")
+			return nil
+		}
+		for _, c := range s.Claims {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s (%s since %s)\n", c.Path, c.Holder, c.ClaimedAt)
+		}
+	default:
+		return unknownFormatError(f)
+	}
+	return nil
+}
+
+func init() {
+	releaseCmd.Flags().BoolVar(&releaseAllFlag, "all", false, "release every claim held by this agent")
+	rootCmd.AddCommand(claimCmd)
+	rootCmd.AddCommand(releaseCmd)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestClaimConflictIsRecordedAndReportedByVerify(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	t.Setenv(types.AgentIDEnv, "worker-1")
+	if _, _, err := executePhaseCmd(t, "claim", "auth.go", filepath.Join(dir, "auth_test.go"), "--format", "text"); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+
+	t.Setenv(types.AgentIDEnv, "worker-2")
+	_, _, err := executePhaseCmd(t, "claim", "./auth_test.go", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "worker-1") {
+		t.Fatalf("claim should fail naming the holder, got %v", err)
+	}
+
+	out, _, err := executePhaseCmd(t, "verify", "--format", "text")
+	if err != nil {
+		t.Fatalf("claim conflicts should not fail verify: %v", err)
+	}
+	if !strings.Contains(out, "worker-2 touched auth_test.go claimed by worker-1") {
+		t.Errorf("verify should warn about the conflict, got:\n%s", out)
+	}
+}
+
+func TestReleaseDropsOwnClaims(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	_ = s.ClaimFiles("worker-1", []string{"a.go", "b.go"})
+	_ = s.ClaimFiles("worker-2", []string{"c.go"})
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { releaseAllFlag = false }()
+
+	t.Setenv(types.AgentIDEnv, "worker-1")
+	out, _, err := executePhaseCmd(t, "release", "--all", "--format", "text")
+	if err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if !strings.Contains(out, "released 2 claim(s)") {
+		t.Errorf("should report released claims, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if len(loaded.Claims) != 1 || loaded.Claims[0].Holder != "worker-2" {
+		t.Errorf("Claims = %+v, want only worker-2's claim", loaded.Claims)
+	}
+}
+
+func TestVerifyWarnsOnChangedFileClaimedByAnotherAgent(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	s := types.NewSession()
+	s.Claims = []types.Claim{{Path: "migrations/001.sql", Holder: "worker-1"}}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "migrations", "001.sql"), []byte("drop table a;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	t.Setenv(types.AgentIDEnv, "worker-1")
+	out, _, err := executePhaseCmd(t, "verify", "--format", "text")
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if strings.Contains(out, "claimed_file_modified") {
+		t.Errorf("the claim holder's own changes should not be reported, got:\n%s", out)
+	}
+
+	t.Setenv(types.AgentIDEnv, "worker-2")
+	out, _, err = executePhaseCmd(t, "verify", "--format", "text")
+	if err != nil {
+		t.Fatalf("claimed file changes should not fail verify: %v", err)
+	}
+	if !strings.Contains(out, "claimed_file_modified: migrations/001.sql has uncommitted changes but is claimed by worker-1") {
+		t.Errorf("verify should warn about the claimed file, got:\n%s", out)
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/macosta/tdd-ai/internal/formatter"
@@ -15,15 +16,26 @@ import (
 type commandFlag struct {
 	Name        string `json:"name"`
 	Type        string `json:"type"`
+	JSONType    string `json:"json_type,omitempty"`
 	Default     string `json:"default"`
 	Description string `json:"description"`
 }
 
+// commandArg describes a positional argument, derived from the command's usage line.
+type commandArg struct {
+	Name     string   `json:"name"`
+	JSONType string   `json:"json_type"`
+	Required bool     `json:"required"`
+	Variadic bool     `json:"variadic,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+}
+
 // commandEntry describes a single CLI command (flattened, e.g. "spec add").
 type commandEntry struct {
 	Name        string        `json:"name"`
 	Description string        `json:"description"`
 	Usage       string        `json:"usage"`
+	Args        []commandArg  `json:"args,omitempty"`
 	Flags       []commandFlag `json:"flags"`
 }
 
@@ -46,20 +58,53 @@ type commandsOutput struct {
 	Session     *sessionSummary `json:"session"`
 }
 
+var (
+	commandsTopicFlag  string
+	commandsSchemaFlag bool
+)
+
 var commandsCmd = &cobra.Command{
 	Use:   "commands",
 	Short: "Show all commands, flags, and workflow in one call",
 	Long: `Dumps the entire CLI reference: all commands with their flags, the recommended
 workflow, global flags, and current session state (if any).
 
-Designed for AI agents to learn the full API in a single call.`,
+Designed for AI agents to learn the full API in a single call.
+
+Use --topic to show only one command group (e.g. "spec" or "phase"), and
+--schema to include JSON types for positional arguments and flags.
+
+Use --format openai-tools or --format anthropic-tools to emit function-calling
+tool definitions instead, one tool per command (e.g. "tdd_ai_spec_add"), so an
+agent harness can register the CLI as callable tools. Positional arguments
+become properties named after their usage placeholder and flags keep their
+flag names.`,
 	Example: `  tdd-ai commands
-  tdd-ai commands --format json`,
+  tdd-ai commands --format json
+  tdd-ai commands --topic spec --schema --format json
+  tdd-ai commands --format anthropic-tools > tools.json`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		output := buildCommandsOutput()
 
+		if commandsTopicFlag != "" {
+			filtered, err := filterCommandsByTopic(output.Commands, commandsTopicFlag)
+			if err != nil {
+				return err
+			}
+			output.Commands = filtered
+		}
 		f := formatter.Format(formatFlag)
+		if commandsSchemaFlag || f == formatOpenAITools || f == formatAnthropicTools {
+			addCommandSchema(output.Commands)
+		}
+
 		switch f {
+		case formatOpenAITools, formatAnthropicTools:
+			data, err := json.MarshalIndent(toolDefinitions(output.Commands, f), "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding tools: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
 		case formatter.FormatJSON:
 			data, err := json.MarshalIndent(output, "", "  ")
 			if err != nil {
@@ -69,13 +114,15 @@ Designed for AI agents to learn the full API in a single call.`,
 		case formatter.FormatText:
 			fmt.Fprint(cmd.OutOrStdout(), formatCommandsText(output))
 		default:
-			return fmt.Errorf("unknown format: %q", f)
+			return unknownFormatError(f)
 		}
 		return nil
 	},
 }
 
 func init() {
+	commandsCmd.Flags().StringVar(&commandsTopicFlag, "topic", "", "only show commands in this group (e.g. spec, phase, test)")
+	commandsCmd.Flags().BoolVar(&commandsSchemaFlag, "schema", false, "include JSON types for arguments and flags")
 	rootCmd.AddCommand(commandsCmd)
 }
 
@@ -213,3 +260,199 @@ func formatCommandsText(output commandsOutput) string {
 
 	return b.String()
 }
+
+// commandTopic returns the group a command belongs to: its first word.
+func commandTopic(name string) string {
+	return strings.Fields(name)[0]
+}
+
+// filterCommandsByTopic keeps the commands in the given group, or returns an
+// error listing the known topics.
+func filterCommandsByTopic(cmds []commandEntry, topic string) ([]commandEntry, error) {
+	var filtered []commandEntry
+	var topics []string
+	seen := make(map[string]bool)
+	for _, c := range cmds {
+		t := commandTopic(c.Name)
+		if t == topic {
+			filtered = append(filtered, c)
+		}
+		if !seen[t] {
+			seen[t] = true
+			topics = append(topics, t)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("unknown topic %q (available: %s)", topic, strings.Join(topics, ", "))
+	}
+	return filtered, nil
+}
+
+// usageArgPattern matches positional argument placeholders in a usage line:
+// <required>, [optional], and "quoted" values.
+var usageArgPattern = regexp.MustCompile(`<[^>]+>|\[[^\]]+\]|"[^"]+"`)
+
+// addCommandSchema fills in JSON types for each command's flags and positional arguments.
+func addCommandSchema(cmds []commandEntry) {
+	for i := range cmds {
+		for j := range cmds[i].Flags {
+			cmds[i].Flags[j].JSONType = flagJSONType(cmds[i].Flags[j].Type)
+		}
+		argsPart := strings.TrimPrefix(cmds[i].Usage, "tdd-ai "+cmds[i].Name)
+		for _, token := range usageArgPattern.FindAllString(argsPart, -1) {
+			cmds[i].Args = append(cmds[i].Args, parseUsageArg(token))
+		}
+	}
+}
+
+// parseUsageArg describes a single usage placeholder such as <id>, [id...], or <pass|fail>.
+func parseUsageArg(token string) commandArg {
+	arg := commandArg{JSONType: "string", Required: !strings.HasPrefix(token, "[")}
+	name := strings.Trim(token, `<>[]"`)
+	if strings.HasSuffix(name, "...") {
+		arg.Variadic = true
+		name = strings.TrimSuffix(name, "...")
+	}
+	if strings.Contains(name, "|") {
+		arg.Enum = strings.Split(name, "|")
+	}
+	if name == "" {
+		name = "args"
+	}
+	arg.Name = name
+	return arg
+}
+
+// flagJSONType maps a pflag value type to its JSON schema type.
+func flagJSONType(pflagType string) string {
+	switch pflagType {
+	case "bool":
+		return "boolean"
+	case "int", "int64", "uint", "count":
+		return "integer"
+	case "float64", "float32":
+		return "number"
+	case "stringArray", "stringSlice":
+		return "array"
+	default:
+		return "string"
+	}
+}
+
+// Function-calling tool definition formats accepted by tdd-ai commands.
+const (
+	formatOpenAITools    formatter.Format = "openai-tools"
+	formatAnthropicTools formatter.Format = "anthropic-tools"
+)
+
+// toolSchema is the JSON schema of a tool's input: one property per positional
+// argument and flag.
+type toolSchema struct {
+	Type       string                  `json:"type"`
+	Properties map[string]toolProperty `json:"properties"`
+	Required   []string                `json:"required,omitempty"`
+}
+
+type toolProperty struct {
+	Type        string        `json:"type"`
+	Description string        `json:"description,omitempty"`
+	Enum        []string      `json:"enum,omitempty"`
+	Items       *toolProperty `json:"items,omitempty"`
+}
+
+// openAITool is a function tool in the OpenAI chat completions format.
+type openAITool struct {
+	Type     string       `json:"type"`
+	Function openAIToolFn `json:"function"`
+}
+
+type openAIToolFn struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Parameters  toolSchema `json:"parameters"`
+}
+
+// anthropicTool is a tool in the Anthropic Messages API format.
+type anthropicTool struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	InputSchema toolSchema `json:"input_schema"`
+}
+
+// toolDefinitions turns commands, with their schema filled in, into tool
+// definitions in the given format.
+func toolDefinitions(cmds []commandEntry, f formatter.Format) any {
+	openAI := make([]openAITool, 0, len(cmds))
+	anthropic := make([]anthropicTool, 0, len(cmds))
+	for _, c := range cmds {
+		name := toolName(c.Name)
+		desc := fmt.Sprintf("%s. Usage: %s", c.Description, c.Usage)
+		schema := commandToolSchema(c)
+		openAI = append(openAI, openAITool{Type: "function", Function: openAIToolFn{Name: name, Description: desc, Parameters: schema}})
+		anthropic = append(anthropic, anthropicTool{Name: name, Description: desc, InputSchema: schema})
+	}
+	if f == formatOpenAITools {
+		return openAI
+	}
+	return anthropic
+}
+
+// toolName turns a command path such as "lint-session" or "spec add" into a
+// tool name such as "tdd_ai_lint_session" or "tdd_ai_spec_add".
+func toolName(command string) string {
+	return "tdd_ai_" + toolIdentifier(command)
+}
+
+// toolIdentifier lowercases s and replaces every run of characters other than
+// letters and digits with a single underscore.
+func toolIdentifier(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+func commandToolSchema(c commandEntry) toolSchema {
+	schema := toolSchema{Type: "object", Properties: map[string]toolProperty{}}
+	for _, arg := range c.Args {
+		name := toolIdentifier(arg.Name)
+		if len(arg.Enum) > 0 || name == "" {
+			name = "value"
+		}
+		if existing, ok := schema.Properties[name]; ok {
+			// A repeated placeholder, as in "KEY [KEY...]", continues the first.
+			if arg.Variadic && existing.Type != "array" {
+				item := existing
+				item.Description = ""
+				existing = toolProperty{Type: "array", Description: existing.Description, Items: &item}
+				schema.Properties[name] = existing
+			}
+			continue
+		}
+		prop := toolProperty{Type: arg.JSONType, Description: "positional argument " + arg.Name, Enum: arg.Enum}
+		if arg.Variadic {
+			prop = toolProperty{Type: "array", Description: prop.Description, Items: &toolProperty{Type: arg.JSONType}}
+		}
+		schema.Properties[name] = prop
+		if arg.Required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	for _, fl := range c.Flags {
+		name := strings.TrimPrefix(fl.Name, "--")
+		prop := toolProperty{Type: fl.JSONType, Description: fl.Description}
+		if prop.Type == "array" {
+			prop.Items = &toolProperty{Type: "string"}
+		}
+		schema.Properties[name] = prop
+	}
+	return schema
+}
@@ -214,3 +214,132 @@ func TestFormatFlagOverridesTTYDetection(t *testing.T) {
 		t.Errorf("explicit --format text should produce text even in non-TTY, got:\n%s", out)
 	}
 }
+
+func TestCommandsTopicFiltersToGroup(t *testing.T) {
+	defer func() { commandsTopicFlag = "" }()
+	out := executeCommands(t, "--topic", "phase", "--format", "json")
+
+	var parsed commandsOutput
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("output is not valid JSON: %v\nraw output:\n%s", err, out)
+	}
+	if len(parsed.Commands) == 0 {
+		t.Fatal("topic phase should include commands")
+	}
+	for _, c := range parsed.Commands {
+		if c.Name != "phase" && !strings.HasPrefix(c.Name, "phase ") {
+			t.Errorf("topic phase should not include %q", c.Name)
+		}
+	}
+}
+
+func TestCommandsTopicUnknown(t *testing.T) {
+	if _, err := filterCommandsByTopic(buildCommandsOutput().Commands, "nope"); err == nil {
+		t.Error("unknown topic should be an error")
+	}
+}
+
+func TestCommandsSchemaDescribesArgsAndFlags(t *testing.T) {
+	cmds, err := filterCommandsByTopic(buildCommandsOutput().Commands, "phase")
+	if err != nil {
+		t.Fatalf("filterCommandsByTopic() error: %v", err)
+	}
+	addCommandSchema(cmds)
+
+	var set, next *commandEntry
+	for i := range cmds {
+		switch cmds[i].Name {
+		case "phase set":
+			set = &cmds[i]
+		case "phase next":
+			next = &cmds[i]
+		}
+	}
+	if set == nil || next == nil {
+		t.Fatal("phase set and phase next should be present")
+	}
+
+	if len(set.Args) != 1 || !set.Args[0].Required || len(set.Args[0].Enum) != 4 {
+		t.Errorf("phase set args = %+v, want one required enum of 4 phases", set.Args)
+	}
+	for _, f := range next.Flags {
+		if f.Name == "--force" && f.JSONType != "boolean" {
+			t.Errorf("--force json_type = %q, want boolean", f.JSONType)
+		}
+		if f.Name == "--test-result" && f.JSONType != "string" {
+			t.Errorf("--test-result json_type = %q, want string", f.JSONType)
+		}
+	}
+}
+
+func TestParseUsageArg(t *testing.T) {
+	tests := []struct {
+		token string
+		want  commandArg
+	}{
+		{"<id>", commandArg{Name: "id", JSONType: "string", Required: true}},
+		{"[id...]", commandArg{Name: "id", JSONType: "string", Variadic: true}},
+		{`"description"`, commandArg{Name: "description", JSONType: "string", Required: true}},
+		{"[...]", commandArg{Name: "args", JSONType: "string", Variadic: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			got := parseUsageArg(tt.token)
+			if got.Name != tt.want.Name || got.Required != tt.want.Required || got.Variadic != tt.want.Variadic || got.JSONType != tt.want.JSONType {
+				t.Errorf("parseUsageArg(%q) = %+v, want %+v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandsToolFormats(t *testing.T) {
+	defer func() { commandsTopicFlag = "" }()
+
+	out := executeCommands(t, "--topic", "phase", "--format", "openai-tools")
+	var openAI []openAITool
+	if err := json.Unmarshal([]byte(out), &openAI); err != nil {
+		t.Fatalf("invalid openai-tools JSON: %v", err)
+	}
+	var set *openAITool
+	for i := range openAI {
+		if openAI[i].Type != "function" {
+			t.Errorf("tool type = %q, want function", openAI[i].Type)
+		}
+		if openAI[i].Function.Name == "tdd_ai_phase_set" {
+			set = &openAI[i]
+		}
+	}
+	if set == nil {
+		t.Fatalf("want a tdd_ai_phase_set tool, got:\n%s", out)
+	}
+	params := set.Function.Parameters
+	if params.Properties["value"].Type != "string" || len(params.Properties["value"].Enum) != 4 {
+		t.Errorf("phase set value = %+v, want a string enum of 4 phases", params.Properties["value"])
+	}
+	if params.Properties["force"].Type != "boolean" || len(params.Required) != 1 || params.Required[0] != "value" {
+		t.Errorf("phase set parameters = %+v, want boolean force and required value", params)
+	}
+
+	out = executeCommands(t, "--topic", "config", "--format", "anthropic-tools")
+	var anthropic []anthropicTool
+	if err := json.Unmarshal([]byte(out), &anthropic); err != nil {
+		t.Fatalf("invalid anthropic-tools JSON: %v", err)
+	}
+	if len(anthropic) == 0 || anthropic[0].InputSchema.Type != "object" || !strings.HasPrefix(anthropic[0].Name, "tdd_ai_config_") {
+		t.Errorf("anthropic tools = %+v, want config tools with object input schemas", anthropic)
+	}
+}
+
+func TestToolIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"spec add":     "spec_add",
+		"lint-session": "lint_session",
+		"KEY=VALUE":    "key_value",
+		"child 1":      "child_1",
+	}
+	for in, want := range tests {
+		if got := toolIdentifier(in); got != want {
+			t.Errorf("toolIdentifier(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
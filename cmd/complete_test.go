@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/macosta/tdd-ai/internal/reflection"
 	"github.com/macosta/tdd-ai/internal/session"
@@ -222,3 +225,145 @@ func TestCompleteFromRedPhaseNotBlockedByReflections(t *testing.T) {
 		t.Fatalf("complete from red should not be blocked by reflections: %v", err)
 	}
 }
+
+func TestCompleteReportsFailedDoneGates(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.AddSpec("feature")
+	s.DoneCriteria = &types.DoneCriteria{MinCoverage: 80, CoverageFile: "cover.out", FreshPassWithin: "30m"}
+	s.History = append(s.History, types.Event{Action: "test_run", Result: "pass", Timestamp: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)})
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	profile := "mode: set\nexample.com/m/a.go:1.1,2.2 1 1\nexample.com/m/a.go:3.1,4.2 1 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "cover.out"), []byte(profile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeCompleteCmd(t, "complete", "--test-result", "pass", "--format", "json")
+	if ExitCode(err) != ExitBlocked {
+		t.Fatalf("complete should be blocked by done gates, got %v", err)
+	}
+	var report struct {
+		Gates []doneGate `json:"gates"`
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON gate report: %v\n%s", err, out)
+	}
+	if len(report.Gates) != 2 || report.Gates[0].Gate != "coverage" || report.Gates[0].Passed || report.Gates[1].Passed {
+		t.Errorf("want failed coverage and fresh_pass gates, got %+v", report.Gates)
+	}
+
+	profile = "mode: set\nexample.com/m/a.go:1.1,2.2 1 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "cover.out"), []byte(profile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _ := session.Load(dir)
+	loaded.AddEvent("test_run", func(e *types.Event) { e.Result = "pass" })
+	if err := session.Save(dir, loaded); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	if _, err := executeCompleteCmd(t, "complete", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Fatalf("complete should pass every gate, got %v", err)
+	}
+}
+
+func TestCompleteDryRunPreviewsPlanWithoutSaving(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.AddSpec("adds numbers")
+	s.AddSpec("subtracts numbers")
+	if err := s.AddSpecCriteria(2, []string{"handles negatives"}); err != nil {
+		t.Fatal(err)
+	}
+	s.LastTestResult = "pass"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	resetFlags(completeCmd.Flags())
+	defer resetFlags(completeCmd.Flags())
+
+	out, err := executeCompleteCmd(t, "complete", "--dry-run", "--format", "json")
+	if ExitCode(err) != ExitBlocked {
+		t.Fatalf("dry run with unchecked criteria should exit blocked, got %v", err)
+	}
+	var plan completePlan
+	if err := json.Unmarshal([]byte(out), &plan); err != nil {
+		t.Fatalf("invalid JSON plan: %v\n%s", err, out)
+	}
+	if plan.WouldComplete || len(plan.Blockers) != 1 || !strings.Contains(plan.Blockers[0], "acceptance criteria") {
+		t.Errorf("want one criteria blocker, got %+v", plan.Blockers)
+	}
+	if plan.TestSource != "session" || plan.TestResult != "pass" {
+		t.Errorf("test source = %q/%q, want session/pass", plan.TestSource, plan.TestResult)
+	}
+	want := []phaseStep{{types.PhaseGreen, types.PhaseRefactor}, {types.PhaseRefactor, types.PhaseDone}}
+	if len(plan.Phases) != 2 || plan.Phases[0] != want[0] || plan.Phases[1] != want[1] {
+		t.Errorf("phases = %+v, want %+v", plan.Phases, want)
+	}
+	if len(plan.Specs) != 2 || plan.Specs[1].Waived {
+		t.Errorf("specs = %+v, want both active specs, none waived", plan.Specs)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.Phase != types.PhaseGreen || len(loaded.ActiveSpecs()) != 2 {
+		t.Errorf("dry run changed the session: phase %s, %d active specs", loaded.Phase, len(loaded.ActiveSpecs()))
+	}
+
+	out, err = executeCompleteCmd(t, "complete", "--dry-run", "--waive", "covered by review", "--format", "json")
+	if err != nil {
+		t.Fatalf("dry run with --waive should not be blocked: %v\n%s", err, out)
+	}
+	plan = completePlan{}
+	if err := json.Unmarshal([]byte(out), &plan); err != nil {
+		t.Fatalf("invalid JSON plan: %v\n%s", err, out)
+	}
+	if !plan.WouldComplete || !plan.Specs[1].Waived {
+		t.Errorf("want a completable plan waiving spec 2, got %+v", plan)
+	}
+	loaded, _ = session.Load(dir)
+	if loaded.Specs[1].Waiver != "" {
+		t.Errorf("dry run recorded a waiver: %+v", loaded.Specs[1])
+	}
+}
+
+func TestCompleteDryRunReportsTheSameChecks(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.AddSpec("adds numbers")
+	s.RequireReview = true
+	s.LastTestResult = "pass"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	resetFlags(completeCmd.Flags())
+	defer resetFlags(completeCmd.Flags())
+
+	out, _ := executeCompleteCmd(t, "complete", "--dry-run", "--format", "json")
+	var plan completePlan
+	if err := json.Unmarshal([]byte(out), &plan); err != nil {
+		t.Fatalf("invalid JSON plan: %v\n%s", err, out)
+	}
+	resetFlags(completeCmd.Flags())
+
+	_, err := executeCompleteCmd(t, "complete", "--format", "text")
+	if err == nil || len(plan.Blockers) != 1 || plan.Blockers[0] != err.Error() {
+		t.Errorf("dry run blockers %q should match complete's error %v", plan.Blockers, err)
+	}
+}
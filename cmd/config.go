@@ -0,0 +1,1044 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/guide"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/testoutput"
+	"github.com/macosta/tdd-ai/internal/triage"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Change session settings after init",
+}
+
+var configEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage environment variables for test commands",
+	Long: `Environment variables stored in the session are added to the environment of
+the test command run by 'tdd-ai test' (including --async), 'tdd-ai exec', and
+'tdd-ai complete', overriding variables of the same name. This replaces wrapper
+scripts that only exist to set variables such as DATABASE_URL.
+
+Values are stored in plain text in .tdd-ai.json; do not put secrets there.`,
+	Example: `  tdd-ai config env set DATABASE_URL=postgres://localhost/testdb
+  tdd-ai config env list
+  tdd-ai config env unset DATABASE_URL`,
+}
+
+var configEnvSetCmd = &cobra.Command{
+	Use:     "set KEY=VALUE [KEY=VALUE...]",
+	Short:   "Set environment variables for test commands",
+	Example: `  tdd-ai config env set DATABASE_URL=postgres://localhost/testdb LOG_LEVEL=warn`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		vars := make(map[string]string, len(args))
+		for _, arg := range args {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok || !validEnvKey(key) {
+				return invalidInputError(fmt.Errorf("invalid variable %q: expected KEY=VALUE with a KEY of letters, digits, and underscores", arg))
+			}
+			vars[key] = value
+		}
+		if s.TestEnv == nil {
+			s.TestEnv = make(map[string]string, len(vars))
+		}
+		for key, value := range vars {
+			s.TestEnv[key] = value
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Set %d test environment variable(s)\n", len(vars))
+		return nil
+	},
+}
+
+var configEnvUnsetCmd = &cobra.Command{
+	Use:     "unset KEY [KEY...]",
+	Short:   "Remove environment variables for test commands",
+	Example: `  tdd-ai config env unset DATABASE_URL`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range args {
+			if _, ok := s.TestEnv[key]; !ok {
+				return invalidInputError(fmt.Errorf("test environment variable %q is not set", key))
+			}
+			delete(s.TestEnv, key)
+		}
+		if len(s.TestEnv) == 0 {
+			s.TestEnv = nil
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Unset %d test environment variable(s)\n", len(args))
+		return nil
+	},
+}
+
+var configEnvListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List environment variables for test commands",
+	Example: `  tdd-ai config env list
+  tdd-ai config env list --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := session.LoadOrFail(getWorkDir())
+		if err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			env := s.TestEnv
+			if env == nil {
+				env = map[string]string{}
+			}
+			data, err := json.MarshalIndent(env, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding test environment: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(s.TestEnv) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No test environment variables set. Add one with 'tdd-ai config env set KEY=VALUE'")
+				return nil
+			}
+			for _, entry := range s.TestEnvList() {
+				fmt.Fprintln(cmd.OutOrStdout(), entry)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var (
+	configHistoryMaxEventsFlag int
+	configHistoryStrategyFlag  string
+)
+
+var configHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Limit how many events the session history keeps",
+	Long: `Caps the session history at --max-events so the session file cannot grow
+without bound in very long agent runs. The budget is applied every time the
+session is saved, using one of these strategies:
+
+  truncate-oldest  drop the oldest events (default)
+  summarize        fold the oldest events into one history_rollup event per day,
+                   counting the events it replaces by action
+  error            refuse to save once the history is over budget
+
+With the audit log enabled, events are audited before they are dropped.
+--max-events 0 removes the limit. Without flags, prints the current budget.`,
+	Example: `  tdd-ai config history --max-events 1000
+  tdd-ai config history --max-events 500 --strategy summarize
+  tdd-ai config history`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		maxChanged := cmd.Flags().Changed("max-events")
+		strategyChanged := cmd.Flags().Changed("strategy")
+		if !maxChanged && !strategyChanged {
+			if s.HistoryMaxEvents == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "History budget: none (%d events)\n", len(s.History))
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "History budget: %d events, %s (%d recorded)\n", s.HistoryMaxEvents, historyStrategy(s), len(s.History))
+			return nil
+		}
+
+		if maxChanged {
+			if configHistoryMaxEventsFlag < 0 {
+				return invalidInputError(fmt.Errorf("--max-events must be 0 or more, got %d", configHistoryMaxEventsFlag))
+			}
+			s.HistoryMaxEvents = configHistoryMaxEventsFlag
+		}
+		if strategyChanged {
+			if !slices.Contains(types.HistoryStrategies, configHistoryStrategyFlag) {
+				return invalidInputError(fmt.Errorf("invalid strategy %q. Valid strategies: %s", configHistoryStrategyFlag, strings.Join(types.HistoryStrategies, ", ")))
+			}
+			s.HistoryStrategy = configHistoryStrategyFlag
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		if s.HistoryMaxEvents == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "History budget removed")
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "History budget: %d events, %s\n", s.HistoryMaxEvents, historyStrategy(s))
+		return nil
+	},
+}
+
+var (
+	configDoneAllCriteriaFlag    bool
+	configDoneMinCoverageFlag    float64
+	configDoneCoverageFileFlag   string
+	configDoneZeroViolationsFlag bool
+	configDoneFreshPassFlag      string
+)
+
+var configDoneCmd = &cobra.Command{
+	Use:   "done",
+	Short: "Set the gates 'tdd-ai complete' checks before finishing a cycle",
+	Long: `Configures optional done gates. 'tdd-ai complete' refuses to finish the cycle
+until every enabled gate passes, and reports which one failed:
+
+  --all-criteria      every spec's acceptance criteria checked off; waivers do not count
+  --min-coverage N    coverage in --coverage-file (Go cover profile or LCOV) is at least N%
+  --zero-violations   'tdd-ai verify' finds no TDD violations
+  --fresh-pass 30m    the last full-suite 'tdd-ai test' run passed within the window
+
+Only the flags given are changed; pass --min-coverage 0, --fresh-pass "", or
+--all-criteria=false to turn a gate off. Without flags, prints the current gates.`,
+	Example: `  tdd-ai config done --all-criteria --zero-violations
+  tdd-ai config done --min-coverage 80 --coverage-file cover.out --fresh-pass 30m
+  tdd-ai config done --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		changed := false
+		for _, name := range []string{"all-criteria", "min-coverage", "coverage-file", "zero-violations", "fresh-pass"} {
+			changed = changed || flags.Changed(name)
+		}
+		if !changed {
+			return writeDoneCriteria(cmd, s.DoneCriteria)
+		}
+
+		d := types.DoneCriteria{}
+		if s.DoneCriteria != nil {
+			d = *s.DoneCriteria
+		}
+		if flags.Changed("all-criteria") {
+			d.AllCriteria = configDoneAllCriteriaFlag
+		}
+		if flags.Changed("min-coverage") {
+			if configDoneMinCoverageFlag < 0 || configDoneMinCoverageFlag > 100 {
+				return invalidInputError(fmt.Errorf("--min-coverage must be between 0 and 100, got %g", configDoneMinCoverageFlag))
+			}
+			d.MinCoverage = configDoneMinCoverageFlag
+		}
+		if flags.Changed("coverage-file") {
+			d.CoverageFile = configDoneCoverageFileFlag
+		}
+		if d.MinCoverage > 0 && d.CoverageFile == "" {
+			return invalidInputError(fmt.Errorf("--min-coverage needs --coverage-file naming the coverage report to read"))
+		}
+		if flags.Changed("zero-violations") {
+			d.ZeroViolations = configDoneZeroViolationsFlag
+		}
+		if flags.Changed("fresh-pass") {
+			if configDoneFreshPassFlag != "" {
+				if w, err := time.ParseDuration(configDoneFreshPassFlag); err != nil || w <= 0 {
+					return invalidInputError(fmt.Errorf("invalid --fresh-pass %q: expected a positive duration such as 30m", configDoneFreshPassFlag))
+				}
+			}
+			d.FreshPassWithin = configDoneFreshPassFlag
+		}
+
+		s.DoneCriteria = &d
+		if d.IsZero() {
+			s.DoneCriteria = nil
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+		return writeDoneCriteria(cmd, s.DoneCriteria)
+	},
+}
+
+func writeDoneCriteria(cmd *cobra.Command, d *types.DoneCriteria) error {
+	f := formatter.Format(formatFlag)
+	switch f {
+	case formatter.FormatJSON:
+		if d == nil {
+			d = &types.DoneCriteria{}
+		}
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding done criteria: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	case formatter.FormatText:
+		out := cmd.OutOrStdout()
+		if d.IsZero() {
+			fmt.Fprintln(out, "No done gates set. Add them with 'tdd-ai config done --help'")
+			return nil
+		}
+		fmt.Fprintln(out, "Done gates:")
+		if d.AllCriteria {
+			fmt.Fprintln(out, "  all acceptance criteria checked (no waivers)")
+		}
+		if d.MinCoverage > 0 {
+			fmt.Fprintf(out, "  coverage >= %g%% in %s\n", d.MinCoverage, d.CoverageFile)
+		}
+		if d.ZeroViolations {
+			fmt.Fprintln(out, "  zero TDD violations")
+		}
+		if d.FreshPassWithin != "" {
+			fmt.Fprintf(out, "  full-suite pass within %s\n", d.FreshPassWithin)
+		}
+	default:
+		return unknownFormatError(f)
+	}
+	return nil
+}
+
+var (
+	configRulesRedFlag      []string
+	configRulesGreenFlag    []string
+	configRulesRefactorFlag []string
+	configRulesReplaceFlag  bool
+)
+
+var configRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Set project rules shown in guide output for each phase",
+	Long: `Sets project rules that 'tdd-ai guide' lists for the RED, GREEN, and REFACTOR
+phases, such as "never mock the repository layer". Each rule has an ID:
+built-in rules have fixed IDs and project rules are numbered custom-<phase>-<n>.
+
+Project rules are appended to the built-in rules; --replace shows only the
+project rules instead. Repeat a phase flag to set several rules; a phase flag
+replaces that phase's rules, and --red "" clears them. Without flags, prints the
+rules guide uses for each phase.`,
+	Example: `  tdd-ai config rules --green "never mock the repository layer"
+  tdd-ai config rules --red "one assertion per test" --red "name tests after the spec"
+  tdd-ai config rules --replace
+  tdd-ai config rules --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		changed := false
+		for _, name := range []string{"red", "green", "refactor", "replace"} {
+			changed = changed || flags.Changed(name)
+		}
+		if !changed {
+			return writeRules(cmd, s.Rules)
+		}
+
+		r := types.PhaseRules{}
+		if s.Rules != nil {
+			r = *s.Rules
+		}
+		if flags.Changed("red") {
+			r.Red = nonEmpty(configRulesRedFlag)
+		}
+		if flags.Changed("green") {
+			r.Green = nonEmpty(configRulesGreenFlag)
+		}
+		if flags.Changed("refactor") {
+			r.Refactor = nonEmpty(configRulesRefactorFlag)
+		}
+		if flags.Changed("replace") {
+			r.Replace = configRulesReplaceFlag
+		}
+
+		s.Rules = &r
+		if r.IsZero() {
+			s.Rules = nil
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+		return writeRules(cmd, s.Rules)
+	},
+}
+
+// writeRules prints the rules guide uses for each phase.
+func writeRules(cmd *cobra.Command, r *types.PhaseRules) error {
+	phases := []types.Phase{types.PhaseRed, types.PhaseGreen, types.PhaseRefactor}
+	f := formatter.Format(formatFlag)
+	switch f {
+	case formatter.FormatJSON:
+		byPhase := make(map[types.Phase][]types.Rule, len(phases))
+		for _, p := range phases {
+			byPhase[p] = guide.Rules(r, p)
+		}
+		data, err := json.MarshalIndent(byPhase, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding rules: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	case formatter.FormatText:
+		out := cmd.OutOrStdout()
+		if r != nil && r.Replace {
+			fmt.Fprintln(out, "Built-in rules replaced by project rules")
+		}
+		for _, p := range phases {
+			fmt.Fprintf(out, "%s:\n", strings.ToUpper(p.String()))
+			rules := guide.Rules(r, p)
+			if len(rules) == 0 {
+				fmt.Fprintln(out, "  (none)")
+			}
+			for _, rule := range rules {
+				fmt.Fprintf(out, "  [%s] %s\n", rule.ID, rule.Text)
+			}
+		}
+	default:
+		return unknownFormatError(f)
+	}
+	return nil
+}
+
+// nonEmpty drops blank entries, so a flag given as "" clears its list.
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+var configRedactPatternsFlag []string
+
+var configRedactCmd = &cobra.Command{
+	Use:   "redact",
+	Short: "Set regular expressions masked in test output",
+	Long: `Sets project-specific redaction patterns: regular expressions whose matches
+are replaced with [REDACTED] in test output before it is printed, summarized,
+stored in the session, or written to background run logs. They apply on top of
+the built-in patterns for passwords, tokens, API keys, and credentials in URLs.
+
+Repeat --pattern to set several; the list replaces the previous one, and
+--pattern "" clears it. Without flags, prints the current patterns.`,
+	Example: `  tdd-ai config redact --pattern 'sk_live_[0-9a-zA-Z]{24}'
+  tdd-ai config redact --pattern 'X-Internal-Token: \S+' --pattern '\d{3}-\d{2}-\d{4}'
+  tdd-ai config redact --pattern ""`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("pattern") {
+			patterns := nonEmpty(configRedactPatternsFlag)
+			if _, err := testoutput.NewRedactor(patterns); err != nil {
+				return invalidInputError(err)
+			}
+			s.RedactPatterns = patterns
+			if err := session.Save(dir, s); err != nil {
+				return err
+			}
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			patterns := s.RedactPatterns
+			if patterns == nil {
+				patterns = []string{}
+			}
+			data, err := json.MarshalIndent(struct {
+				RedactPatterns []string `json:"redact_patterns"`
+			}{patterns}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding redact patterns: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(s.RedactPatterns) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No project redact patterns (built-in credential patterns always apply)")
+				return nil
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Redact patterns:")
+			for _, p := range s.RedactPatterns {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", p)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var configStrictnessCmd = &cobra.Command{
+	Use:   "strictness [relaxed|standard|strict]",
+	Short: "Set how strictly reflection questions are enforced",
+	Long: `Sets the session's strictness. Relaxed sessions may skip a reflection question
+with 'tdd-ai refactor reflect <id> --skip --reason "..."'; each skip is recorded
+as reflection debt. Standard sessions must answer every question. Strict sessions
+must also have no reflection debt before leaving REFACTOR or completing.
+Without an argument, prints the current strictness and reflection debt.`,
+	Example: `  tdd-ai config strictness relaxed
+  tdd-ai config strictness --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			if !slices.Contains(types.Strictnesses, args[0]) {
+				return invalidInputError(fmt.Errorf("invalid strictness %q: must be one of %s", args[0], strings.Join(types.Strictnesses, ", ")))
+			}
+			if !activePolicy.AllowsStrictness(args[0]) {
+				return blockedError(fmt.Errorf("strictness %s is below the minimum %s set by organization policy (%s)", args[0], activePolicy.Strictness, activePolicy.Path))
+			}
+			s.Strictness = args[0]
+			if s.Strictness == types.StrictnessStandard {
+				s.Strictness = ""
+			}
+			if err := session.Save(dir, s); err != nil {
+				return err
+			}
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(struct {
+				Strictness     string `json:"strictness"`
+				ReflectionDebt int    `json:"reflection_debt"`
+			}{s.GetStrictness(), len(s.ReflectionDebt)}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding strictness: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			fmt.Fprintf(cmd.OutOrStdout(), "Strictness: %s (reflection debt: %d)\n", s.GetStrictness(), len(s.ReflectionDebt))
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var configTestNamingPatternsFlag []string
+
+var configTestNamingCmd = &cobra.Command{
+	Use:   "test-naming [off|warn|block]",
+	Short: "Require the tests written in RED to reference their spec",
+	Long: `Sets a rule that at least one of the tests written in RED names the spec it
+was written for, so specs can be traced to their tests. New tests are the
+failing tests that 'tdd-ai test' and 'tdd-ai test record' report in RED and
+that were not failing in the run before; the rule is not checked when the
+test output names no failing tests.
+
+With warn, 'tdd-ai phase next' reports unreferenced tests when leaving RED;
+with block, it refuses to leave RED unless given --force. off removes the rule.
+
+Each --pattern is a naming convention in which {id} and {slug} stand for the
+spec's ID and slug. A test references the spec when its name contains one of
+them, ignoring case and punctuation: with "{slug}", TestSpecLogin404 and
+test_spec_login_404 both reference SPEC-login-404. Repeat --pattern to allow
+several; the default is "{slug}" or "spec{id}". Without arguments, prints the
+current rule.`,
+	Example: `  tdd-ai config test-naming block
+  tdd-ai config test-naming warn --pattern "{slug}" --pattern "spec{id}_"
+  tdd-ai config test-naming off`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		patternsChanged := cmd.Flags().Changed("pattern")
+		if len(args) == 1 || patternsChanged {
+			r := types.TestNamingRule{Mode: types.TestNamingWarn}
+			if s.TestNaming != nil {
+				r = *s.TestNaming
+			}
+			if len(args) == 1 {
+				switch args[0] {
+				case "off":
+					r.Mode = ""
+				case types.TestNamingWarn, types.TestNamingBlock:
+					r.Mode = args[0]
+				default:
+					return invalidInputError(fmt.Errorf("invalid test naming mode %q: must be one of off, %s, %s", args[0], types.TestNamingWarn, types.TestNamingBlock))
+				}
+			}
+			if patternsChanged {
+				patterns := nonEmpty(configTestNamingPatternsFlag)
+				for _, p := range patterns {
+					if err := types.ValidateTestNamingPattern(p); err != nil {
+						return invalidInputError(err)
+					}
+				}
+				r.Patterns = patterns
+			}
+			s.TestNaming = &r
+			if r.Mode == "" {
+				s.TestNaming = nil
+			}
+			if err := session.Save(dir, s); err != nil {
+				return err
+			}
+		}
+		return writeTestNaming(cmd, s.TestNaming)
+	},
+}
+
+func writeTestNaming(cmd *cobra.Command, r *types.TestNamingRule) error {
+	f := formatter.Format(formatFlag)
+	switch f {
+	case formatter.FormatJSON:
+		out := types.TestNamingRule{Mode: "off", Patterns: types.DefaultTestNamingPatterns}
+		if r != nil {
+			out = types.TestNamingRule{Mode: r.Mode, Patterns: r.GetPatterns()}
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding test naming rule: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	case formatter.FormatText:
+		if r == nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Test naming: off. Turn it on with 'tdd-ai config test-naming warn' or 'block'")
+			return nil
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Test naming: %s (patterns: %s)\n", r.Mode, strings.Join(r.GetPatterns(), ", "))
+	default:
+		return unknownFormatError(f)
+	}
+	return nil
+}
+
+var configBranchCmd = &cobra.Command{
+	Use:   "branch [pin|warn|strict|off]",
+	Short: "Show or change the git branch the session is pinned to",
+	Long: `A session is pinned to the git branch checked out when it was initialized.
+Commands that change the session while another branch is checked out print a
+warning; in strict sessions they are refused. Read-only commands such as status
+are unaffected, and nothing is checked outside a git repository or on a
+detached HEAD.
+
+pin moves the session to the branch checked out now, e.g. after renaming the
+branch or to carry the work over deliberately. warn and strict choose what
+happens off the pinned branch, pinning the current branch first when none is
+pinned. off unpins the session. Without an argument, prints the pinned and the
+current branch.`,
+	Example: `  tdd-ai config branch
+  tdd-ai config branch strict
+  tdd-ai config branch pin`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		current := gitBranch(dir)
+		if len(args) == 1 {
+			switch args[0] {
+			case "off":
+				s.Branch, s.StrictBranch = "", false
+			case "pin", "warn", "strict":
+				if current == "" {
+					return invalidInputError(fmt.Errorf("no git branch is checked out to pin the session to"))
+				}
+				if args[0] == "pin" || s.Branch == "" {
+					if s.Branch != current {
+						from := s.Branch
+						s.AddEvent("branch_pin", func(e *types.Event) {
+							e.From, e.To = from, current
+						})
+					}
+					s.Branch = current
+				}
+				if args[0] != "pin" {
+					s.StrictBranch = args[0] == "strict"
+				}
+			default:
+				return invalidInputError(fmt.Errorf("invalid branch setting %q: must be one of pin, warn, strict, off", args[0]))
+			}
+			if err := session.Save(dir, s); err != nil {
+				return err
+			}
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(struct {
+				Branch    string `json:"branch"`
+				Current   string `json:"current"`
+				Strict    bool   `json:"strict"`
+				OffBranch bool   `json:"off_branch"`
+			}{s.Branch, current, s.StrictBranch, s.OffBranch(current)}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding branch: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			w := cmd.OutOrStdout()
+			if s.Branch == "" {
+				fmt.Fprintln(w, "Branch: not pinned. Pin it with 'tdd-ai config branch pin'")
+				return nil
+			}
+			mode := "warn"
+			if s.StrictBranch {
+				mode = "strict"
+			}
+			fmt.Fprintf(w, "Branch: %s (%s)\n", s.Branch, mode)
+			if s.OffBranch(current) {
+				fmt.Fprintf(w, "Checked out: %s. Switch back, or run 'tdd-ai config branch pin' to move the session here\n", current)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var (
+	configProfileHeadingDepthFlag int
+	configProfileBulletFlag       string
+	configProfileCodeFenceFlag    string
+	configProfileRemoveFlag       bool
+)
+
+var configProfileCmd = &cobra.Command{
+	Use:   "profile [name]",
+	Short: "Define text profiles that lay out guide output for specific agents",
+	Long: `Defines named profiles used by 'tdd-ai guide --profile <name>' to lay out text
+output the way an agent parses it best: section titles as Markdown headings of a
+given depth (0 keeps "Title:" lines), the list bullet, and whether commands are
+wrapped in inline backticks or fenced blocks.
+
+The presets claude, cursor, and copilot are built in. Setting a flag on a profile
+starts from the project profile of that name, or else from the preset, so a
+project can adjust a preset or add its own. --remove deletes a project profile,
+restoring the preset if there is one. Without flags, prints the profile; without
+a name, prints every profile.`,
+	Example: `  tdd-ai config profile
+  tdd-ai config profile claude --heading-depth 3
+  tdd-ai config profile aider --bullet '*' --code-fence inline
+  tdd-ai config profile aider --remove`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		changed := false
+		for _, name := range []string{"heading-depth", "bullet", "code-fence", "remove"} {
+			changed = changed || flags.Changed(name)
+		}
+		if len(args) == 0 {
+			if changed {
+				return invalidInputError(fmt.Errorf("a profile name is required to change a profile"))
+			}
+			return writeProfiles(cmd, s, formatter.ProfileNames(s))
+		}
+
+		name := args[0]
+		if !changed {
+			if _, err := formatter.ResolveProfile(s, name); err != nil {
+				return invalidInputError(err)
+			}
+			return writeProfiles(cmd, s, []string{name})
+		}
+
+		if configProfileRemoveFlag {
+			if _, ok := s.TextProfiles[name]; !ok {
+				return invalidInputError(fmt.Errorf("no project profile %q", name))
+			}
+			delete(s.TextProfiles, name)
+			if len(s.TextProfiles) == 0 {
+				s.TextProfiles = nil
+			}
+		} else {
+			p, _ := formatter.ResolveProfile(s, name)
+			if flags.Changed("heading-depth") {
+				p.HeadingDepth = configProfileHeadingDepthFlag
+			}
+			if flags.Changed("bullet") {
+				p.Bullet = configProfileBulletFlag
+			}
+			if flags.Changed("code-fence") {
+				p.CodeFence = configProfileCodeFenceFlag
+			}
+			if err := p.Validate(); err != nil {
+				return invalidInputError(fmt.Errorf("profile %q: %w", name, err))
+			}
+			if s.TextProfiles == nil {
+				s.TextProfiles = make(map[string]types.TextProfile)
+			}
+			s.TextProfiles[name] = p
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		if _, err := formatter.ResolveProfile(s, name); err != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed profile %s\n", name)
+			return nil
+		}
+		return writeProfiles(cmd, s, []string{name})
+	},
+}
+
+// writeProfiles prints the named profiles as resolved for the session, marking
+// project profiles.
+func writeProfiles(cmd *cobra.Command, s *types.Session, names []string) error {
+	f := formatter.Format(formatFlag)
+	switch f {
+	case formatter.FormatJSON:
+		profiles := make(map[string]types.TextProfile, len(names))
+		for _, name := range names {
+			profiles[name], _ = formatter.ResolveProfile(s, name)
+		}
+		data, err := json.MarshalIndent(profiles, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding profiles: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	case formatter.FormatText:
+		for _, name := range names {
+			p, _ := formatter.ResolveProfile(s, name)
+			source := "preset"
+			if _, ok := s.TextProfiles[name]; ok {
+				source = "project"
+			}
+			bullet, fence := p.Bullet, p.CodeFence
+			if bullet == "" {
+				bullet = "-"
+			}
+			if fence == "" {
+				fence = types.CodeFenceNone
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s (%s): heading depth %d, bullet %s, code fence %s\n", name, source, p.HeadingDepth, bullet, fence)
+		}
+	default:
+		return unknownFormatError(f)
+	}
+	return nil
+}
+
+var (
+	configTriageMatchFlag  string
+	configTriageHintFlag   string
+	configTriageRemoveFlag bool
+)
+
+var configTriageCmd = &cobra.Command{
+	Use:   "triage [name]",
+	Short: "Define known failure signatures and their triage hints",
+	Long: `Defines named failure signatures: a regular expression matched against each
+line of a failing test run's output, and the hint to show when it matches. Hints
+are printed by 'tdd-ai test', stored on the test_run event, and shown with the
+last test output in guide, status, and resume.
+
+Signatures for a port already in use, network flakiness, snapshot mismatches,
+test timeouts, and data races are built in. Setting a flag on a pattern starts
+from the project pattern of that name, or else from the built-in one, so a
+project can adjust a built-in pattern or add its own. --remove deletes a project
+pattern, restoring the built-in one if there is one. Without flags, prints the
+pattern; without a name, prints every pattern.`,
+	Example: `  tdd-ai config triage
+  tdd-ai config triage db-locked --match 'database is locked' --hint "Another test holds the SQLite file; give each test its own database."
+  tdd-ai config triage network --hint "Start the fake API with 'make fake-api' first."
+  tdd-ai config triage db-locked --remove`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		changed := false
+		for _, name := range []string{"match", "hint", "remove"} {
+			changed = changed || flags.Changed(name)
+		}
+		if len(args) == 0 {
+			if changed {
+				return invalidInputError(fmt.Errorf("a pattern name is required to change a pattern"))
+			}
+			return writeTriagePatterns(cmd, s, triage.Names(s))
+		}
+
+		name := args[0]
+		if !changed {
+			if _, err := triage.Resolve(s, name); err != nil {
+				return invalidInputError(err)
+			}
+			return writeTriagePatterns(cmd, s, []string{name})
+		}
+
+		if configTriageRemoveFlag {
+			if _, ok := s.TriagePatterns[name]; !ok {
+				return invalidInputError(fmt.Errorf("no project triage pattern %q", name))
+			}
+			delete(s.TriagePatterns, name)
+			if len(s.TriagePatterns) == 0 {
+				s.TriagePatterns = nil
+			}
+		} else {
+			p, _ := triage.Resolve(s, name)
+			if flags.Changed("match") {
+				p.Match = configTriageMatchFlag
+			}
+			if flags.Changed("hint") {
+				p.Hint = configTriageHintFlag
+			}
+			if err := p.Validate(); err != nil {
+				return invalidInputError(fmt.Errorf("triage pattern %q: %w", name, err))
+			}
+			if s.TriagePatterns == nil {
+				s.TriagePatterns = make(map[string]types.TriagePattern)
+			}
+			s.TriagePatterns[name] = p
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		if _, err := triage.Resolve(s, name); err != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed triage pattern %s\n", name)
+			return nil
+		}
+		return writeTriagePatterns(cmd, s, []string{name})
+	},
+}
+
+// writeTriagePatterns prints the named triage patterns as resolved for the
+// session, marking project patterns.
+func writeTriagePatterns(cmd *cobra.Command, s *types.Session, names []string) error {
+	f := formatter.Format(formatFlag)
+	switch f {
+	case formatter.FormatJSON:
+		patterns := make(map[string]types.TriagePattern, len(names))
+		for _, name := range names {
+			patterns[name], _ = triage.Resolve(s, name)
+		}
+		data, err := json.MarshalIndent(patterns, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding triage patterns: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	case formatter.FormatText:
+		for _, name := range names {
+			p, _ := triage.Resolve(s, name)
+			source := "built-in"
+			if _, ok := s.TriagePatterns[name]; ok {
+				source = "project"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s (%s): /%s/\n  %s\n", name, source, p.Match, p.Hint)
+		}
+	default:
+		return unknownFormatError(f)
+	}
+	return nil
+}
+
+// historyStrategy returns the session's history budget strategy, defaulting to
+// truncate-oldest.
+func historyStrategy(s *types.Session) string {
+	if s.HistoryStrategy == "" {
+		return types.HistoryTruncateOldest
+	}
+	return s.HistoryStrategy
+}
+
+// validEnvKey reports whether key is a portable environment variable name.
+func validEnvKey(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+	for _, r := range key {
+		if r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	configEnvCmd.AddCommand(configEnvSetCmd)
+	configEnvCmd.AddCommand(configEnvUnsetCmd)
+	configEnvCmd.AddCommand(configEnvListCmd)
+	configCmd.AddCommand(configEnvCmd)
+	configCmd.AddCommand(configStrictnessCmd)
+	configTestNamingCmd.Flags().StringArrayVar(&configTestNamingPatternsFlag, "pattern", nil, "naming convention with {id} and {slug} placeholders, e.g. \"{slug}\" (repeatable)")
+	configCmd.AddCommand(configTestNamingCmd)
+	configCmd.AddCommand(configBranchCmd)
+	configRedactCmd.Flags().StringArrayVar(&configRedactPatternsFlag, "pattern", nil, "regular expression to mask in test output (repeatable; \"\" clears)")
+	configCmd.AddCommand(configRedactCmd)
+	configHistoryCmd.Flags().IntVar(&configHistoryMaxEventsFlag, "max-events", 0, "maximum number of history events to keep (0 for no limit)")
+	configHistoryCmd.Flags().StringVar(&configHistoryStrategyFlag, "strategy", types.HistoryTruncateOldest, "what to do over budget: truncate-oldest, summarize, or error")
+	configCmd.AddCommand(configHistoryCmd)
+	configDoneCmd.Flags().BoolVar(&configDoneAllCriteriaFlag, "all-criteria", false, "require every spec's acceptance criteria checked off, without waivers")
+	configDoneCmd.Flags().Float64Var(&configDoneMinCoverageFlag, "min-coverage", 0, "minimum coverage percentage (0 to disable)")
+	configDoneCmd.Flags().StringVar(&configDoneCoverageFileFlag, "coverage-file", "", "coverage report checked by --min-coverage (Go cover profile or LCOV)")
+	configDoneCmd.Flags().BoolVar(&configDoneZeroViolationsFlag, "zero-violations", false, "require 'tdd-ai verify' to find no violations")
+	configDoneCmd.Flags().StringVar(&configDoneFreshPassFlag, "fresh-pass", "", "require a full-suite pass within this duration, e.g. 30m (empty to disable)")
+	configCmd.AddCommand(configDoneCmd)
+	configRulesCmd.Flags().StringArrayVar(&configRulesRedFlag, "red", nil, "project rule for the RED phase (repeatable; \"\" clears)")
+	configRulesCmd.Flags().StringArrayVar(&configRulesGreenFlag, "green", nil, "project rule for the GREEN phase (repeatable; \"\" clears)")
+	configRulesCmd.Flags().StringArrayVar(&configRulesRefactorFlag, "refactor", nil, "project rule for the REFACTOR phase (repeatable; \"\" clears)")
+	configRulesCmd.Flags().BoolVar(&configRulesReplaceFlag, "replace", false, "show only the project rules, replacing the built-in ones")
+	configCmd.AddCommand(configRulesCmd)
+	configProfileCmd.Flags().IntVar(&configProfileHeadingDepthFlag, "heading-depth", 0, "Markdown heading level for section titles, 1-6 (0 for \"Title:\" lines)")
+	configProfileCmd.Flags().StringVar(&configProfileBulletFlag, "bullet", "-", "list marker: -, *, or +")
+	configProfileCmd.Flags().StringVar(&configProfileCodeFenceFlag, "code-fence", types.CodeFenceNone, "how commands are marked: none, inline, or block")
+	configProfileCmd.Flags().BoolVar(&configProfileRemoveFlag, "remove", false, "delete the project profile")
+	configCmd.AddCommand(configProfileCmd)
+	configTriageCmd.Flags().StringVar(&configTriageMatchFlag, "match", "", "regular expression matched against each line of failing test output")
+	configTriageCmd.Flags().StringVar(&configTriageHintFlag, "hint", "", "what to check when the pattern matches")
+	configTriageCmd.Flags().BoolVar(&configTriageRemoveFlag, "remove", false, "delete the project pattern")
+	configCmd.AddCommand(configTriageCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestConfigEnvInjectedIntoTestCommand(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.TestCmd = "sh -c env"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "config", "env", "set", "TDD_AI_TEST_DB=testdb"); err != nil {
+		t.Fatalf("config env set failed: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "config", "env", "set", "1BAD=x"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("invalid key should be invalid input, got %v", err)
+	}
+
+	out, _, err := executePhaseCmd(t, "test", "--format", "text")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if !strings.Contains(out, "TDD_AI_TEST_DB=testdb") {
+		t.Errorf("test command should see the session variable, got:\n%s", out)
+	}
+
+	if _, _, err := executePhaseCmd(t, "config", "env", "unset", "TDD_AI_TEST_DB"); err != nil {
+		t.Fatalf("config env unset failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.TestEnv != nil {
+		t.Errorf("TestEnv should be empty after unset, got %v", loaded.TestEnv)
+	}
+}
+
+func TestConfigHistoryAppliesBudgetOnSave(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	for i := 0; i < 10; i++ {
+		s.AddEvent("test_run")
+	}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "config", "history", "--max-events", "4", "--strategy", "shrink"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown strategy should be invalid input, got %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "config", "history", "--max-events", "4", "--strategy", "truncate-oldest"); err != nil {
+		t.Fatalf("config history failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if len(loaded.History) != 4 || loaded.HistoryMaxEvents != 4 {
+		t.Errorf("history = %d events with budget %d, want 4 and 4", len(loaded.History), loaded.HistoryMaxEvents)
+	}
+}
+
+func TestConfigRulesAppearInGuide(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer resetFlags(configRulesCmd.Flags())
+
+	if _, _, err := executePhaseCmd(t, "config", "rules", "--red", "one assertion per test", "--replace", "--format", "text"); err != nil {
+		t.Fatalf("config rules failed: %v", err)
+	}
+
+	out, _, err := executePhaseCmd(t, "guide", "--format", "text")
+	if err != nil {
+		t.Fatalf("guide failed: %v", err)
+	}
+	if !strings.Contains(out, "[custom-red-1] one assertion per test") {
+		t.Errorf("guide should list the project rule, got:\n%s", out)
+	}
+	if strings.Contains(out, "red-one-failing-test") {
+		t.Errorf("--replace should drop the built-in rules, got:\n%s", out)
+	}
+
+	resetFlags(configRulesCmd.Flags())
+	if _, _, err := executePhaseCmd(t, "config", "rules", "--red", "", "--replace=false", "--format", "text"); err != nil {
+		t.Fatalf("config rules failed: %v", err)
+	}
+	if loaded, _ := session.Load(dir); loaded.Rules != nil {
+		t.Errorf("clearing every rule should remove the rules section, got %+v", loaded.Rules)
+	}
+}
+
+func TestConfigRedactMasksTestOutput(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.TestCmd = "sh fail.sh"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fail.sh"), []byte("echo charging sk_live_abc123XYZ\nexit 1\n"), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer resetFlags(configRedactCmd.Flags())
+
+	if _, _, err := executePhaseCmd(t, "config", "redact", "--pattern", "(unclosed", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("invalid regex should be invalid input, got %v", err)
+	}
+	resetFlags(configRedactCmd.Flags())
+	if _, _, err := executePhaseCmd(t, "config", "redact", "--pattern", `sk_live_\w+`, "--format", "text"); err != nil {
+		t.Fatalf("config redact failed: %v", err)
+	}
+
+	out, _, _ := executePhaseCmd(t, "test", "--format", "text")
+	if strings.Contains(out, "sk_live_abc123XYZ") || !strings.Contains(out, "charging [REDACTED]") {
+		t.Errorf("streamed output should be redacted, got:\n%s", out)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.LastTestOutput == nil || strings.Contains(strings.Join(loaded.LastTestOutput.Output, "\n"), "sk_live_") {
+		t.Errorf("stored output should be redacted, got %+v", loaded.LastTestOutput)
+	}
+}
+
+func TestConfigProfileLaysOutGuide(t *testing.T) {
+	resetFlags(configProfileCmd.Flags())
+	defer resetFlags(configProfileCmd.Flags())
+	defer resetFlags(guideCmd.Flags())
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("parses input into tokens")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "guide", "--profile", "claude")
+	if err != nil {
+		t.Fatalf("guide --profile failed: %v", err)
+	}
+	if !strings.Contains(out, "## Active Specs\n\n- [1] parses input into tokens") {
+		t.Errorf("claude profile should use level-2 headings, got:\n%s", out)
+	}
+
+	if _, _, err := executePhaseCmd(t, "config", "profile", "team", "--heading-depth", "1", "--bullet", "+", "--format", "text"); err != nil {
+		t.Fatalf("config profile failed: %v", err)
+	}
+	out, _, err = executePhaseCmd(t, "guide", "--profile", "team")
+	if err != nil {
+		t.Fatalf("guide --profile team failed: %v", err)
+	}
+	if !strings.Contains(out, "# Active Specs\n\n+ [1]") {
+		t.Errorf("project profile should apply, got:\n%s", out)
+	}
+
+	if _, _, err := executePhaseCmd(t, "config", "profile", "team", "--bullet", "x"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("invalid bullet should be invalid input, got %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "guide", "--profile", "missing"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown profile should be invalid input, got %v", err)
+	}
+}
+
+func TestConfigTriageOverridesAndAddsPatterns(t *testing.T) {
+	resetFlags(configTriageCmd.Flags())
+	defer resetFlags(configTriageCmd.Flags())
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "config", "triage", "network", "--hint", "Start the fake API first.", "--format", "text"); err != nil {
+		t.Fatalf("config triage failed: %v", err)
+	}
+	resetFlags(configTriageCmd.Flags())
+	loaded, _ := session.Load(dir)
+	p := loaded.TriagePatterns["network"]
+	if p.Hint != "Start the fake API first." || p.Match == "" {
+		t.Errorf("network pattern = %+v, want the built-in match with the new hint", p)
+	}
+
+	if _, _, err := executePhaseCmd(t, "config", "triage", "db-locked", "--hint", "Use one database per test."); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("a new pattern without --match should be invalid input, got %v", err)
+	}
+	resetFlags(configTriageCmd.Flags())
+	if _, _, err := executePhaseCmd(t, "config", "triage", "db-locked", "--match", "(unclosed", "--hint", "x"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("an invalid expression should be invalid input, got %v", err)
+	}
+	resetFlags(configTriageCmd.Flags())
+
+	if _, _, err := executePhaseCmd(t, "config", "triage", "network", "--remove", "--format", "text"); err != nil {
+		t.Fatalf("config triage --remove failed: %v", err)
+	}
+	resetFlags(configTriageCmd.Flags())
+	out, _, err := executePhaseCmd(t, "config", "triage", "--format", "text")
+	if err != nil {
+		t.Fatalf("config triage failed: %v", err)
+	}
+	if !strings.Contains(out, "network (built-in)") || strings.Contains(out, "fake API") {
+		t.Errorf("removing the project pattern should restore the built-in one, got:\n%s", out)
+	}
+}
+
+func TestConfigTestNaming(t *testing.T) {
+	resetFlags(configTestNamingCmd.Flags())
+	defer resetFlags(configTestNamingCmd.Flags())
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "config", "test-naming", "block", "--pattern", "issue-{id}", "--format", "text")
+	if err != nil {
+		t.Fatalf("config test-naming failed: %v", err)
+	}
+	if !strings.Contains(out, "Test naming: block (patterns: issue-{id})") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	resetFlags(configTestNamingCmd.Flags())
+
+	if _, _, err := executePhaseCmd(t, "config", "test-naming", "warn"); err != nil {
+		t.Fatalf("config test-naming warn failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.TestNaming == nil || loaded.TestNaming.Mode != types.TestNamingWarn || len(loaded.TestNaming.Patterns) != 1 {
+		t.Errorf("changing the mode should keep the patterns, got %+v", loaded.TestNaming)
+	}
+
+	if _, _, err := executePhaseCmd(t, "config", "test-naming", "--pattern", "no placeholder"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("a pattern without {id} or {slug} should be invalid input, got %v", err)
+	}
+	resetFlags(configTestNamingCmd.Flags())
+	if _, _, err := executePhaseCmd(t, "config", "test-naming", "strict"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("an unknown mode should be invalid input, got %v", err)
+	}
+
+	if _, _, err := executePhaseCmd(t, "config", "test-naming", "off"); err != nil {
+		t.Fatalf("config test-naming off failed: %v", err)
+	}
+	if loaded, _ := session.Load(dir); loaded.TestNaming != nil {
+		t.Errorf("off should remove the rule, got %+v", loaded.TestNaming)
+	}
+}
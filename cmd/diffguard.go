@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/diffguard"
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// snapshotTestFiles records the hashes of the session's test files, clearing
+// any earlier verification result.
+func snapshotTestFiles(dir string, s *types.Session) error {
+	hashes, err := diffguard.Snapshot(dir, diffguard.Globs(s.TestGlobs))
+	if err != nil {
+		return err
+	}
+	s.TestFileHashes = hashes
+	s.TestFilesEdited = nil
+	return nil
+}
+
+// checkTestFiles compares the test files against the last snapshot and stores
+// the ones that changed in the session. It does nothing without a snapshot.
+func checkTestFiles(dir string, s *types.Session) error {
+	if s.TestFileHashes == nil {
+		return nil
+	}
+	current, err := diffguard.Snapshot(dir, diffguard.Globs(s.TestGlobs))
+	if err != nil {
+		return err
+	}
+	s.TestFilesEdited = diffguard.Changed(s.TestFileHashes, current)
+	return nil
+}
+
+var diffGuardCmd = &cobra.Command{
+	Use:   "diff-guard",
+	Short: "Guard test files against edits during GREEN",
+	Long: `Leaving RED hashes every test file (files matching 'tdd-ai init --test-glob', or
+common test file patterns by default). Leaving GREEN re-hashes them and is blocked
+if any test was edited, added, or removed: GREEN must make the tests written in RED
+pass, not change them. Blockers and guide report edited test files during GREEN.
+
+Use 'diff-guard snapshot' to take the snapshot by hand (not allowed during GREEN)
+and 'diff-guard verify' to check the test files against it.`,
+	Example: `  tdd-ai diff-guard snapshot
+  tdd-ai diff-guard verify`,
+}
+
+var diffGuardSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Hash the test files as the baseline for GREEN",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+		if s.Phase == types.PhaseGreen {
+			return blockedError(fmt.Errorf("cannot snapshot during GREEN: it would hide test edits made in this phase"))
+		}
+
+		if err := snapshotTestFiles(dir, s); err != nil {
+			return err
+		}
+		s.AddEvent("diff_guard_snapshot", func(e *types.Event) {
+			e.SpecCount = len(s.TestFileHashes)
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Snapshot of %d test file(s) recorded\n", len(s.TestFileHashes))
+		return nil
+	},
+}
+
+var diffGuardVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check test files against the snapshot",
+	Long:  "Reports test files edited, added, or removed since the snapshot. Exits with an error when any changed.",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+		if s.TestFileHashes == nil {
+			return blockedError(fmt.Errorf("no test file snapshot recorded. Run 'tdd-ai diff-guard snapshot' or leave RED with 'tdd-ai phase next'"))
+		}
+		if err := checkTestFiles(dir, s); err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(struct {
+				Edited []string `json:"edited"`
+			}{Edited: s.TestFilesEdited}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding diff-guard result: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(s.TestFilesEdited) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%d test file(s) unchanged since the snapshot\n", len(s.TestFileHashes))
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout(), "Test files changed since the snapshot:")
+				for _, path := range s.TestFilesEdited {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", path)
+				}
+			}
+		default:
+			return unknownFormatError(f)
+		}
+
+		if len(s.TestFilesEdited) > 0 {
+			return fmt.Errorf("%d test file(s) changed: %s", len(s.TestFilesEdited), strings.Join(s.TestFilesEdited, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	diffGuardCmd.AddCommand(diffGuardSnapshotCmd)
+	diffGuardCmd.AddCommand(diffGuardVerifyCmd)
+	rootCmd.AddCommand(diffGuardCmd)
+}
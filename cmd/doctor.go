@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/macosta/tdd-ai/internal/envsnap"
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+type doctorOutput struct {
+	Snapshot *types.Environment `json:"snapshot"`
+	Current  *types.Environment `json:"current"`
+	Changes  []envsnap.Change   `json:"changes"`
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Compare the current environment against the one recorded at init",
+	Long: `Re-captures the OS, architecture, and toolchain versions and compares them with
+the snapshot 'tdd-ai init' recorded in the session. A toolchain upgraded or removed
+mid-session is a common cause of test runs classified as "error"; doctor names
+what changed so it can be fixed or accepted.`,
+	Example: `  tdd-ai doctor
+  tdd-ai doctor --format json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		out := doctorOutput{Snapshot: s.Environment, Current: envsnap.Capture(s.TestCmd)}
+		if s.Environment != nil {
+			out.Changes = envsnap.Diff(s.Environment, out.Current)
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding doctor report: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			w := cmd.OutOrStdout()
+			if s.Environment == nil {
+				fmt.Fprintln(w, "No environment snapshot in this session (it predates snapshots). Nothing to compare.")
+				return nil
+			}
+			if len(out.Changes) == 0 {
+				fmt.Fprintf(w, "Environment unchanged since %s\n", s.Environment.CapturedAt)
+				return nil
+			}
+			fmt.Fprintf(w, "Warning: environment changed since init (%s):\n", s.Environment.CapturedAt)
+			for _, c := range out.Changes {
+				fmt.Fprintf(w, "  %s: %s -> %s\n", c.Item, orNone(c.Was), orNone(c.Now))
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+func orNone(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	return v
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestInitRecordsEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, err := executeInitCmd(t, "init", "--format", "text"); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.Environment == nil || loaded.Environment.OS != runtime.GOOS {
+		t.Errorf("Environment = %+v, want a snapshot for %s", loaded.Environment, runtime.GOOS)
+	}
+}
+
+func TestDoctorReportsChanges(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Environment = &types.Environment{
+		OS:         runtime.GOOS,
+		Arch:       "not-" + runtime.GOARCH,
+		CapturedAt: "2026-01-01T00:00:00Z",
+	}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "doctor", "--format", "text")
+	if err != nil {
+		t.Fatalf("doctor failed: %v", err)
+	}
+	if !strings.Contains(out, "environment changed since init") || !strings.Contains(out, "arch: not-"+runtime.GOARCH+" -> "+runtime.GOARCH) {
+		t.Errorf("doctor should report the arch change, got:\n%s", out)
+	}
+}
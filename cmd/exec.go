@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var execSummaryFlag bool
+
+var execCmd = &cobra.Command{
+	Use:   "exec -- <command> [args...]",
+	Short: "Run any command and record its exit code as the test result",
+	Long: `Runs an arbitrary command (e.g. a make target or CI script) and records the
+outcome as the session's last test result, exactly like 'tdd-ai test'.
+
+Use this when tests are invoked through wrappers the agent cannot change. A zero
+exit code records PASS; a non-zero exit code records FAIL, or ERROR when the output
+looks like an infrastructure/environment problem.`,
+	Example: `  tdd-ai exec -- make test
+  tdd-ai exec --summary -- ./scripts/ci.sh --unit`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		red, err := sessionRedactor(s)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Running: %s\n\n", strings.Join(args, " "))
+		run := runTestCommand(cmd, dir, args, testEnv(s), red, execSummaryFlag, false)
+		run.Command = strings.Join(args, " ")
+		return recordTestResult(cmd, dir, s, run)
+	},
+}
+
+func init() {
+	addSummaryFlags(execCmd, &execSummaryFlag, "command")
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
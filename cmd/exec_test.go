@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestExecRecordsResultFromExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"zero exit is pass", []string{"true"}, "pass"},
+		{"non-zero exit is fail", []string{"sh", "-c", "echo assertion failed; exit 1"}, "fail"},
+		{"missing binary is error", []string{"tdd-ai-no-such-binary"}, "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := session.Save(dir, types.NewSession()); err != nil {
+				t.Fatalf("failed to save session: %v", err)
+			}
+
+			origDir, _ := os.Getwd()
+			os.Chdir(dir)
+			defer os.Chdir(origDir)
+
+			args := append([]string{"exec", "--format", "text", "--"}, tt.args...)
+			if _, _, err := executePhaseCmd(t, args...); err != nil {
+				t.Fatalf("exec failed: %v", err)
+			}
+
+			loaded, err := session.Load(dir)
+			if err != nil {
+				t.Fatalf("failed to load session: %v", err)
+			}
+			if loaded.LastTestResult != tt.want {
+				t.Errorf("LastTestResult = %q, want %q", loaded.LastTestResult, tt.want)
+			}
+			last := loaded.History[len(loaded.History)-1]
+			if last.Action != "test_run" || last.Result != tt.want {
+				t.Errorf("should record test_run event with result %q, got %+v", tt.want, last)
+			}
+		})
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		result string
+		want   string
+	}{
+		{"pass has no category", "ok", "pass", ""},
+		{"error is infrastructure", "command not found", "error", types.FailureInfrastructure},
+		{"go build failure", "./calc_test.go:9:7: undefined: Add\nFAIL\tcalc [build failed]", "fail", types.FailureCompile},
+		{"typescript error", "src/a.test.ts(3,1): error TS2304: Cannot find name 'add'.", "fail", types.FailureCompile},
+		{"assertion failure", "--- FAIL: TestAdd\n    expected 3, got 0", "fail", types.FailureAssertion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyFailure(tt.output, tt.result); got != tt.want {
+				t.Errorf("classifyFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
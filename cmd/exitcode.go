@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes let wrapper scripts branch on the class of failure without parsing
+// error messages.
+const (
+	ExitOK           = 0
+	ExitError        = 1 // any failure not covered below
+	ExitBlocked      = 2 // a TDD guardrail refused the action
+	ExitNoSession    = 3 // no .tdd-ai.json in the working directory
+	ExitInvalidInput = 4 // bad arguments, flags, or values
+	ExitTestInfra    = 5 // the last test run was an infrastructure/environment error
+)
+
+// exitError attaches an exit code to an error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// blockedError marks err as a guardrail refusal, unless it already carries a
+// more specific exit code.
+func blockedError(err error) error {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return err
+	}
+	return &exitError{code: ExitBlocked, err: err}
+}
+
+// invalidInputError marks err as caused by bad user input.
+func invalidInputError(err error) error {
+	return &exitError{code: ExitInvalidInput, err: err}
+}
+
+// testInfraError marks err as caused by a broken test environment.
+func testInfraError(err error) error {
+	return &exitError{code: ExitTestInfra, err: err}
+}
+
+// unknownFormatError reports an unsupported --format value.
+func unknownFormatError(f any) error {
+	return invalidInputError(fmt.Errorf("unknown format: %q", f))
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	if errors.Is(err, session.ErrNoSession) {
+		return ExitNoSession
+	}
+	// Cobra reports unknown subcommands with a plain error.
+	if strings.HasPrefix(err.Error(), "unknown command") {
+		return ExitInvalidInput
+	}
+	return ExitError
+}
+
+// writeError prints err to w, as a JSON object with its exit code when the
+// output format is JSON.
+func writeError(w io.Writer, err error) {
+	if formatFlag == "json" {
+		data, _ := json.MarshalIndent(struct {
+			Error    string `json:"error"`
+			ExitCode int    `json:"exit_code"`
+		}{err.Error(), ExitCode(err)}, "", "  ")
+		fmt.Fprintln(w, string(data))
+		return
+	}
+	fmt.Fprintf(w, "Error: %v\n", err)
+}
+
+// markArgErrorsInvalid wraps every command's positional-argument validator so
+// argument errors map to ExitInvalidInput.
+func markArgErrorsInvalid(c *cobra.Command) {
+	if c.Args != nil {
+		validate := c.Args
+		c.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return invalidInputError(err)
+			}
+			return nil
+		}
+	}
+	for _, sub := range c.Commands() {
+		markArgErrorsInvalid(sub)
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain error", errors.New("boom"), ExitError},
+		{"blocked", blockedError(errors.New("no")), ExitBlocked},
+		{"no session", session.ErrNoSession, ExitNoSession},
+		{"invalid input", invalidInputError(errors.New("bad")), ExitInvalidInput},
+		{"test infra", testInfraError(errors.New("missing binary")), ExitTestInfra},
+		{"blocked keeps infra code", blockedError(testInfraError(errors.New("missing binary"))), ExitTestInfra},
+		{"unknown command", errors.New(`unknown command "x" for "tdd-ai"`), ExitInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, _, err := executePhaseCmd(t, "status", "--format", "text")
+	if got := ExitCode(err); got != ExitNoSession {
+		t.Errorf("status without session: exit code %d, want %d (err: %v)", got, ExitNoSession, err)
+	}
+
+	s := types.NewSession()
+	s.AddSpec("feature")
+	s.LastTestResult = "error"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	_, _, err = executePhaseCmd(t, "phase", "next", "--format", "text")
+	if got := ExitCode(err); got != ExitBlocked {
+		t.Errorf("phase next without a pick: exit code %d, want %d (err: %v)", got, ExitBlocked, err)
+	}
+
+	_, _, err = executePhaseCmd(t, "spec", "pick", "SPEC-nope", "--format", "text")
+	if got := ExitCode(err); got != ExitInvalidInput {
+		t.Errorf("spec pick with unknown slug: exit code %d, want %d (err: %v)", got, ExitInvalidInput, err)
+	}
+
+	if _, _, err := executePhaseCmd(t, "spec", "pick", "1", "--format", "text"); err != nil {
+		t.Fatalf("spec pick failed: %v", err)
+	}
+	_, _, err = executePhaseCmd(t, "phase", "next", "--format", "text")
+	if got := ExitCode(err); got != ExitTestInfra {
+		t.Errorf("phase next after infra error: exit code %d, want %d (err: %v)", got, ExitTestInfra, err)
+	}
+}
+
+func TestBadInputExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	s := types.NewSession()
+	s.AddSpec("feature")
+	s.SetPhase(types.PhaseRefactor)
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	// Every command refuses an unknown flag before it runs.
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+		if !c.Runnable() || c.DisableFlagParsing || c == rootCmd {
+			return
+		}
+		path := strings.Fields(strings.TrimPrefix(c.CommandPath(), rootCmd.Name()))
+		if _, _, err := executePhaseCmd(t, append(path, "--no-such-flag")...); ExitCode(err) != ExitInvalidInput {
+			t.Errorf("%s --no-such-flag: exit code %d, want %d (err: %v)", c.CommandPath(), ExitCode(err), ExitInvalidInput, err)
+		}
+	}
+	walk(rootCmd)
+
+	// Bad values are caught by the commands themselves.
+	for _, args := range [][]string{
+		{"test", "record", "maybe"},
+		{"retrofit", "gaps"},
+		{"review", "--approve", "--request-changes", "no"},
+		{"refactor", "reflect", "1"},
+		{"refactor", "reflect", "one", "--answer", "x"},
+		{"goal", "check", "first"},
+	} {
+		_, _, err := executePhaseCmd(t, append(args, "--format", "text")...)
+		if ExitCode(err) != ExitInvalidInput {
+			t.Errorf("%s: exit code %d, want %d (err: %v)", strings.Join(args, " "), ExitCode(err), ExitInvalidInput, err)
+		}
+		if c, _, err := rootCmd.Find(args); err == nil {
+			resetFlags(c.Flags())
+		}
+	}
+}
+
+func TestWriteErrorJSON(t *testing.T) {
+	defer resetFormatFlag()
+	formatFlag = "json"
+
+	var buf bytes.Buffer
+	writeError(&buf, blockedError(errors.New("cannot advance")))
+
+	var parsed struct {
+		Error    string `json:"error"`
+		ExitCode int    `json:"exit_code"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("error output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if parsed.Error != "cannot advance" || parsed.ExitCode != ExitBlocked {
+		t.Errorf("parsed = %+v, want cannot advance / %d", parsed, ExitBlocked)
+	}
+
+	formatFlag = "text"
+	buf.Reset()
+	writeError(&buf, errors.New("boom"))
+	if !strings.HasPrefix(buf.String(), "Error: boom") {
+		t.Errorf("text error = %q", buf.String())
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/explain"
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [concept]",
+	Short: "Explain a workflow concept (phases, retrofit, reflections, blockers)",
+	Long: `Prints a short built-in explanation of a TDD workflow concept, for agents
+correcting course and for people learning the workflow. Run without arguments to
+list the available concepts. Does not require a session.`,
+	Example: `  tdd-ai explain
+  tdd-ai explain red
+  tdd-ai explain blockers --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f := formatter.Format(formatFlag)
+
+		if len(args) == 0 {
+			names := explain.Names()
+			switch f {
+			case formatter.FormatJSON:
+				data, err := json.MarshalIndent(names, "", "  ")
+				if err != nil {
+					return fmt.Errorf("encoding concepts: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			case formatter.FormatText:
+				fmt.Fprintln(cmd.OutOrStdout(), "Concepts:")
+				for _, name := range names {
+					c, _ := explain.Lookup(name)
+					fmt.Fprintf(cmd.OutOrStdout(), "  %-12s %s\n", name, c.Title)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "\nRun 'tdd-ai explain <concept>' for details.")
+			default:
+				return unknownFormatError(f)
+			}
+			return nil
+		}
+
+		c, err := explain.Lookup(args[0])
+		if err != nil {
+			return invalidInputError(err)
+		}
+
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(c, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding concept: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			var b strings.Builder
+			fmt.Fprintf(&b, "%s\n\n%s\n\n", c.Title, c.Summary)
+			for _, p := range c.Points {
+				fmt.Fprintf(&b, "  - %s\n", p)
+			}
+			if len(c.SeeAlso) > 0 {
+				fmt.Fprintf(&b, "\nSee also: %s\n", strings.Join(c.SeeAlso, ", "))
+			}
+			fmt.Fprint(cmd.OutOrStdout(), b.String())
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
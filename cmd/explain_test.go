@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/explain"
+)
+
+func TestExplainConcept(t *testing.T) {
+	out, _, err := executePhaseCmd(t, "explain", "retrofit", "--format", "text")
+	if err != nil {
+		t.Fatalf("explain retrofit failed: %v", err)
+	}
+	if !strings.Contains(out, "Retrofit mode") || !strings.Contains(out, "See also: red, refactor") {
+		t.Errorf("unexpected explanation:\n%s", out)
+	}
+
+	out, _, err = executePhaseCmd(t, "explain", "blockers", "--format", "json")
+	if err != nil {
+		t.Fatalf("explain blockers failed: %v", err)
+	}
+	var c explain.Concept
+	if err := json.Unmarshal([]byte(out), &c); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if c.Name != "blockers" || len(c.Points) == 0 {
+		t.Errorf("unexpected concept: %+v", c)
+	}
+}
+
+func TestExplainListsConcepts(t *testing.T) {
+	out, _, err := executePhaseCmd(t, "explain", "--format", "text")
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	for _, name := range []string{"red", "retrofit", "reflections", "blockers"} {
+		if !strings.Contains(out, "  "+name) {
+			t.Errorf("list should include %q, got:\n%s", name, out)
+		}
+	}
+}
+
+func TestExplainUnknownConcept(t *testing.T) {
+	_, _, err := executePhaseCmd(t, "explain", "purple", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown concept should be invalid input, got: %v", err)
+	}
+}
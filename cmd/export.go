@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var exportOutFlag string
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export specs or history for spreadsheets and BI tools",
+	Long: `Writes session data as CSV (default), TSV, or JSON to stdout, or to --out.
+
+'export specs' includes status, created/picked/completed timestamps, and cycle time,
+covering specs moved out by 'tdd-ai spec archive'.
+'export history' includes one row per recorded event.`,
+	Example: `  tdd-ai export specs --format csv > specs.csv
+  tdd-ai export history --format tsv
+  tdd-ai export history --format jsonl --out events.jsonl`,
+}
+
+var exportSpecsCmd = &cobra.Command{
+	Use:   "specs",
+	Short: "Export specs with timestamps and cycle time",
+	Long:  "Export every spec with status, created/picked/completed timestamps, and cycle time in seconds.",
+	Example: `  tdd-ai export specs
+  tdd-ai export specs --format tsv`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runExport(cmd, formatter.ExportSpecs)
+	},
+}
+
+var exportHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Export the session event history",
+	Long: `Export every recorded event with timestamp, action, transition, result, and spec references.
+
+--format jsonl writes one record per line with the full event, the phase,
+iteration, and spec it happened in, and derived features: time since the
+previous event and since the phase began, test attempt numbers, and, on phase
+transitions, the duration of the phase left with its test runs, failed runs,
+retries, and blocked attempts. --format otlp writes the same records as an
+OpenTelemetry logs export (OTLP/JSON) for log pipelines and collectors.`,
+	Example: `  tdd-ai export history
+  tdd-ai export history --format csv > history.csv
+  tdd-ai export history --format jsonl --out events.jsonl
+  tdd-ai history export --format otlp --out events.otlp.json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runExport(cmd, formatter.ExportHistory)
+	},
+}
+
+// historyCmd groups history commands; 'history export' is 'export history'.
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Work with the session event history",
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   exportHistoryCmd.Short,
+	Long:    exportHistoryCmd.Long,
+	Example: exportHistoryCmd.Example,
+	RunE:    exportHistoryCmd.RunE,
+}
+
+// runExport loads the session and writes it with the given exporter. CSV is the
+// default unless --format is given explicitly.
+func runExport(cmd *cobra.Command, export func(*types.Session, formatter.Format) (string, error)) error {
+	dir := getWorkDir()
+	s, err := session.LoadOrFail(dir)
+	if err != nil {
+		return err
+	}
+	if s, err = session.IncludeArchive(dir, s); err != nil {
+		return err
+	}
+
+	f := formatter.FormatCSV
+	if cmd.Flags().Changed("format") {
+		f = formatter.Format(formatFlag)
+	}
+
+	out, err := export(s, f)
+	if err != nil {
+		return err
+	}
+	if exportOutFlag == "" {
+		fmt.Fprint(cmd.OutOrStdout(), out)
+		return nil
+	}
+	if err := os.WriteFile(exportOutFlag, []byte(out), 0644); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s\n", exportOutFlag)
+	return nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{exportSpecsCmd, exportHistoryCmd, historyExportCmd} {
+		c.Flags().StringVar(&exportOutFlag, "out", "", "write to this file instead of stdout")
+	}
+	exportCmd.AddCommand(exportSpecsCmd)
+	exportCmd.AddCommand(exportHistoryCmd)
+	rootCmd.AddCommand(exportCmd)
+	historyCmd.AddCommand(historyExportCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestExportSpecsWritesCSV(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "export", "specs", "--format", "csv")
+	if err != nil {
+		t.Fatalf("export specs failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "id,description,status") {
+		t.Errorf("should start with CSV header, got:\n%s", out)
+	}
+	if !strings.Contains(out, "1,feature,active") {
+		t.Errorf("should include spec row, got:\n%s", out)
+	}
+}
+
+func TestHistoryExportWritesJSONLToFile(t *testing.T) {
+	defer resetFlags(historyExportCmd.Flags())
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddEvent("init", func(*types.Event) {})
+	s.AddEvent("phase_next", func(e *types.Event) { e.From, e.To = "red", "green" })
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "history", "export", "--format", "jsonl", "--out", "events.jsonl")
+	if err != nil {
+		t.Fatalf("history export failed: %v", err)
+	}
+	if out != "" {
+		t.Errorf("--out should keep stdout empty, got:\n%s", out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "events.jsonl"))
+	if err != nil {
+		t.Fatalf("export file not written: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"action":"phase_next"`) || !strings.Contains(lines[1], `"phase_duration_seconds"`) {
+		t.Errorf("want one record per event with derived features, got:\n%s", data)
+	}
+}
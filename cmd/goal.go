@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var goalCmd = &cobra.Command{
+	Use:   "goal",
+	Short: "Show or manage the session goal and definition of done",
+	Long: `The session goal is a stable north star shown by status, resume, and guide.
+Optional done criteria can be checked off as the work progresses.`,
+	Example: `  tdd-ai goal
+  tdd-ai goal set "Ship password reset flow" --criterion "Reset email is sent" --criterion "Token expires after 1h"
+  tdd-ai goal check 1`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(s.Goal, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding goal: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if s.Goal == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "No goal set. Use 'tdd-ai goal set \"description\"' to define one.")
+				return nil
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatter.FormatGoalText(s.Goal))
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var goalCriteriaFlag []string
+
+var goalSetCmd = &cobra.Command{
+	Use:   "set \"description\"",
+	Short: "Set the session goal and optional done criteria",
+	Long:  "Set (or replace) the session goal. Repeat --criterion to add definition-of-done items.",
+	Example: `  tdd-ai goal set "Ship password reset flow"
+  tdd-ai goal set "Ship password reset flow" --criterion "Reset email is sent" --criterion "Token expires after 1h"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(args[0]) == "" {
+			return fmt.Errorf("goal description cannot be empty")
+		}
+
+		s.SetGoal(args[0], goalCriteriaFlag)
+		s.AddEvent("goal_set", func(e *types.Event) {
+			e.SpecCount = len(goalCriteriaFlag)
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Goal set: %s\n", s.Goal.Description)
+		if len(s.Goal.Criteria) > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "%d done criteria recorded\n", len(s.Goal.Criteria))
+		}
+		return nil
+	},
+}
+
+var goalUncheckFlag bool
+
+var goalCheckCmd = &cobra.Command{
+	Use:   "check <criterion-number>",
+	Short: "Check off a goal done criterion",
+	Long:  "Mark a definition-of-done criterion as met. Use --undo to mark it unmet again.",
+	Example: `  tdd-ai goal check 1
+  tdd-ai goal check 2 --undo`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return invalidInputError(fmt.Errorf("criterion number must be an integer, got %q", args[0]))
+		}
+
+		if err := s.CheckCriterion(id, !goalUncheckFlag); err != nil {
+			return err
+		}
+		s.AddEvent("goal_check", func(e *types.Event) {
+			e.Result = fmt.Sprintf("c%d", id)
+			if goalUncheckFlag {
+				e.Result += " undone"
+			}
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		unmet := len(s.Goal.UnmetCriteria())
+		fmt.Fprintf(cmd.OutOrStdout(), "Criterion %d updated. %d of %d criteria still open.\n", id, unmet, len(s.Goal.Criteria))
+		return nil
+	},
+}
+
+func init() {
+	goalSetCmd.Flags().StringArrayVar(&goalCriteriaFlag, "criterion", nil, "definition-of-done item (repeatable)")
+	goalCheckCmd.Flags().BoolVar(&goalUncheckFlag, "undo", false, "mark the criterion as not met")
+	goalCmd.AddCommand(goalSetCmd)
+	goalCmd.AddCommand(goalCheckCmd)
+	rootCmd.AddCommand(goalCmd)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestGoalSetCheckAndStatus(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { goalCriteriaFlag = nil }()
+
+	_, _, err := executePhaseCmd(t, "goal", "set", "Ship password reset", "--criterion", "email sent", "--criterion", "token expires", "--format", "text")
+	if err != nil {
+		t.Fatalf("goal set failed: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "goal", "check", "1", "--format", "text"); err != nil {
+		t.Fatalf("goal check failed: %v", err)
+	}
+
+	out, _, err := executePhaseCmd(t, "status", "--format", "text")
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if !strings.Contains(out, "Goal: Ship password reset") {
+		t.Errorf("status should show the goal, got:\n%s", out)
+	}
+	if !strings.Contains(out, "[x] 1. email sent") || !strings.Contains(out, "[ ] 2. token expires") {
+		t.Errorf("status should show criteria checklist, got:\n%s", out)
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/phase"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var graphTrajectoryFlag bool
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Render the phase machine as a Graphviz or Mermaid diagram",
+	Long: `Renders the phase transitions configured for the session: its mode decides
+which phases exist, and each edge is labeled with the test result its test
+policy expects before leaving the source phase. Without a session, the default
+greenfield machine is shown.
+
+Use --trajectory to overlay the phase changes the session actually took, from
+its history, as dashed edges numbered in order and stamped with their time.
+
+Use --format dot for Graphviz, --format mermaid (also used for text) for
+Markdown-embeddable diagrams, or --format json for the raw edges.`,
+	Example: `  tdd-ai graph --format mermaid
+  tdd-ai graph --format dot --trajectory | dot -Tsvg > tdd.svg`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s := types.NewSession()
+		if session.Exists(dir) {
+			var err error
+			if s, err = session.Load(dir); err != nil {
+				return err
+			}
+		}
+
+		g := formatter.Graph{Transitions: phase.Transitions(s)}
+		if graphTrajectoryFlag {
+			g.Trajectory = formatter.Trajectory(s.History)
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatDOT:
+			fmt.Fprint(cmd.OutOrStdout(), formatter.GraphDOT(g))
+		case formatter.FormatMermaid, formatter.FormatText:
+			fmt.Fprint(cmd.OutOrStdout(), formatter.GraphMermaid(g))
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(g, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding graph: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+func init() {
+	graphCmd.Flags().BoolVar(&graphTrajectoryFlag, "trajectory", false, "overlay the phase changes recorded in the session history")
+	rootCmd.AddCommand(graphCmd)
+}
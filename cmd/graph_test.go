@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestGraphWithoutSession(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "graph", "--format", "dot")
+	if err != nil {
+		t.Fatalf("graph failed: %v", err)
+	}
+	if !strings.Contains(out, `red -> green [label="expect fail"]`) || !strings.Contains(out, "refactor -> red") {
+		t.Errorf("graph should show the default machine, got:\n%s", out)
+	}
+}
+
+func TestGraphTrajectory(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Mode = types.ModeRetrofit
+	s.AddEvent("phase_next", func(e *types.Event) {
+		e.From = "red"
+		e.To = "refactor"
+	})
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { graphTrajectoryFlag = false }()
+
+	out, _, err := executePhaseCmd(t, "graph", "--format", "mermaid", "--trajectory")
+	if err != nil {
+		t.Fatalf("graph failed: %v", err)
+	}
+	if strings.Contains(out, "green") {
+		t.Errorf("retrofit machine should skip green, got:\n%s", out)
+	}
+	if !strings.Contains(out, "red -.->|1. phase_next ") {
+		t.Errorf("graph should overlay the trajectory, got:\n%s", out)
+	}
+}
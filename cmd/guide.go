@@ -5,10 +5,17 @@ import (
 
 	"github.com/macosta/tdd-ai/internal/formatter"
 	"github.com/macosta/tdd-ai/internal/guide"
+	"github.com/macosta/tdd-ai/internal/reflection"
 	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
 	"github.com/spf13/cobra"
 )
 
+var (
+	guidePhaseFlag   string
+	guideProfileFlag string
+)
+
 var guideCmd = &cobra.Command{
 	Use:   "guide",
 	Short: "Show current TDD state: phase, specs, blockers, and expected test result",
@@ -16,9 +23,20 @@ var guideCmd = &cobra.Command{
 expected test result, blockers preventing advancement, and reflections.
 
 Use --format json for machine-readable output that AI agents can parse.
-Use --format text (default) for human-readable output.`,
+Use --format text (default) for human-readable output.
+
+Use --phase to preview the guidance for another phase, e.g. the REFACTOR
+rules while still in GREEN. The preview is generated from a copy of the
+session and never changes it.
+
+Use --profile to lay out text output for a specific agent: claude, cursor, and
+copilot are built in, and 'tdd-ai config profile' adjusts them or adds more.
+--profile implies text output unless --format is given.`,
 	Example: `  tdd-ai guide
-  tdd-ai guide --format json`,
+  tdd-ai guide --format json
+  tdd-ai guide --phase refactor
+  tdd-ai guide --profile claude
+  tdd-ai guide --template '{{.Phase}} {{with .CurrentSpec}}{{.Description}}{{end}}'`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
@@ -26,11 +44,50 @@ Use --format text (default) for human-readable output.`,
 			return err
 		}
 
-		g := guide.Generate(s)
-		out, err := formatter.FormatGuidance(g, formatter.Format(formatFlag))
+		var preview types.Phase
+		if guidePhaseFlag != "" {
+			preview = types.Phase(guidePhaseFlag)
+			if !preview.IsValid() {
+				return invalidInputError(fmt.Errorf("invalid phase %q. Valid phases: red, green, refactor, done", guidePhaseFlag))
+			}
+		}
+
+		var profile *types.TextProfile
+		if guideProfileFlag != "" {
+			p, err := formatter.ResolveProfile(s, guideProfileFlag)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			profile = &p
+		}
+
+		if s.Phase == types.PhaseGreen {
+			if err := checkTestFiles(dir, s); err != nil {
+				return err
+			}
+		}
+		var g types.Guidance
+		if preview != "" && preview != s.Phase {
+			g = guide.Preview(s, preview, activePolicy.Questions(reflection.Questions(s.ReflectionSet)))
+		} else {
+			g = guide.Generate(s)
+		}
+		f := formatter.Format(formatFlag)
+		if profile != nil && !cmd.Flags().Changed("format") {
+			f = formatter.FormatText
+		}
+		var out string
+		if templateFlag != "" {
+			out, err = formatter.TemplateGuidance(g, templateFlag)
+		} else {
+			out, err = formatter.FormatGuidance(g, f)
+		}
 		if err != nil {
 			return err
 		}
+		if profile != nil && templateFlag == "" && f == formatter.FormatText {
+			out = formatter.ApplyProfile(out, *profile)
+		}
 
 		fmt.Fprint(cmd.OutOrStdout(), out)
 		return nil
@@ -38,5 +95,8 @@ Use --format text (default) for human-readable output.`,
 }
 
 func init() {
+	guideCmd.Flags().StringVar(&guidePhaseFlag, "phase", "", "Preview the guidance for another phase (red, green, refactor, done) without changing the session")
+	guideCmd.Flags().StringVar(&guideProfileFlag, "profile", "", "lay out text output for an agent: claude, cursor, copilot, or a project profile")
+	addTemplateFlag(guideCmd)
 	rootCmd.AddCommand(guideCmd)
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var heartbeatCmd = &cobra.Command{
+	Use:   "heartbeat",
+	Short: "Record that the agent driving the session is still alive",
+	Long: `Records a heartbeat in the session without adding a history event. Agent
+wrappers call it periodically, e.g. every minute, while the agent works.
+
+'tdd-ai status' reports the session's last_activity (the latest event or
+heartbeat) and sets stalled: true once nothing has happened for longer than the
+stall window (init --stall-after, default 30m), so orchestrators can detect dead
+or wandering agents. 'tdd-ai serve' reports the same fields for each session.`,
+	Example: `  tdd-ai heartbeat
+  while sleep 60; do tdd-ai heartbeat -q; done &`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		s.Heartbeat()
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Heartbeat recorded at %s\n", s.LastHeartbeat)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(heartbeatCmd)
+}
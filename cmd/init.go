@@ -2,8 +2,19 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/macosta/tdd-ai/internal/envsnap"
+	"github.com/macosta/tdd-ai/internal/phase"
 	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/template"
+	"github.com/macosta/tdd-ai/internal/testdetect"
+	"github.com/macosta/tdd-ai/internal/testoutput"
 	"github.com/macosta/tdd-ai/internal/types"
 	"github.com/spf13/cobra"
 )
@@ -12,6 +23,32 @@ var (
 	retrofitFlag bool
 	testCmdFlag  string
 	agentFlag    bool
+	reviewFlag   bool
+	auditFlag    bool
+
+	maxIterationsPerSpecFlag int
+	testPolicyFlag           []string
+	staleAfterFlag           time.Duration
+	refactorTimeboxFlag      time.Duration
+	stallAfterFlag           time.Duration
+	testSuitesFlag           []string
+	testShardsFlag           []string
+	testAreasFlag            []string
+	requireSuitesFlag        []string
+	outputLinesFlag          int
+	protectFlag              []string
+	testGlobFlag             []string
+
+	mutationCmdFlag       string
+	mutationThresholdFlag float64
+
+	fromTemplateFlag string
+	nestedFlag       bool
+	strictnessFlag   string
+	noTestCacheFlag  bool
+	redactFlag       []string
+	noDetectFlag     bool
+	strictBranchFlag bool
 )
 
 var initCmd = &cobra.Command{
@@ -23,17 +60,186 @@ Use --retrofit when adding tests to existing code. In retrofit mode, the RED pha
 expects tests to PASS (since implementation exists) and the GREEN phase is skipped.
 
 Use --test-cmd to configure the project's test command. This enables the 'tdd-ai test'
-command and auto-populates the test result for 'phase next'.`,
+command and auto-populates the test result for 'phase next'.
+
+Without --test-cmd (from the flag or a template), init looks for the project's
+conventional test command and records it: a Makefile test target (make test),
+a package.json test script (npm, yarn, pnpm, or bun test), go.mod (go test ./...),
+Cargo.toml, pytest configuration, .rspec, mix.exs, pom.xml, Gradle builds, PHPUnit
+configuration, or .NET projects, in that order. The session's test_cmd_source
+records where the command came from. Use --no-detect to leave it unset.
+
+Use --test-suite name="command" (repeatable) to configure named test suites such as
+unit and integration, run with 'tdd-ai test --suite <name>'. Use --require-suites
+phase=suite,... to require suites to pass before leaving a phase, e.g.
+refactor=unit,integration.
+
+Use --test-shard "command" (repeatable) to split a huge suite into shards, run
+together with 'tdd-ai test --shards'.
+
+Use --test-area path="command" (repeatable) in a monorepo to map a directory to
+its own test command, run from that directory. 'tdd-ai test' then runs the areas
+the current specs are tagged with, or all of them.
+
+Use --no-test-cache to add -count=1 to every 'go test' command the session runs,
+so passes are never served from Go's test cache.
+
+Use --output-lines to change how many trailing lines of a failing test run's output
+are kept in the session (default 20, secrets redacted) for guide, resume, and status.
+Use --redact-pattern (repeatable) to mask project-specific secrets in test output
+as well, e.g. 'sk_live_[0-9a-zA-Z]{24}'.
+
+Use --protect to declare paths that must not be modified during the cycle, as
+globs where ** matches any number of directories (e.g. migrations/**). 'phase next'
+is blocked and 'verify' reports a violation while a protected file has
+uncommitted changes.
+
+Use --test-glob to declare which files are tests (globs as for --protect). Test
+files are snapshotted when leaving RED and must not change during GREEN; without
+--test-glob, common test file patterns such as **/*_test.go are used.
+
+Use --strictness relaxed|standard|strict to choose how strictly reflections are
+enforced. Relaxed sessions may skip a reflection question with 'refactor reflect
+--skip', recording reflection debt; strict sessions cannot leave REFACTOR while
+any debt is outstanding. The default is standard.
+
+Use --mutation-cmd to configure an optional mutation testing tool. During REFACTOR,
+'tdd-ai mutation run' executes it and blocks advancement when the mutation score is
+below --mutation-threshold.
+
+Use --require-review to require a human approval via 'tdd-ai review' before
+'tdd-ai complete' can finish an iteration.
+
+Use --audit to keep an append-only, hash-chained audit log of every event in
+.tdd-ai.audit.jsonl, checked with 'tdd-ai audit verify'.
+
+Use --max-iterations-per-spec to cap how many red-green-refactor passes a spec may
+take. Once a spec reaches the limit, leaving RED is blocked with a suggestion to
+split it into smaller specs.
+
+Use --test-policy to change the test result a phase expects, as [mode:]phase=result
+where result is pass, fail, or any. For example, refactor=any tolerates a
+temporarily failing contract test during REFACTOR. Unlisted phases keep the
+default (RED expects fail, except in retrofit mode; GREEN and REFACTOR expect pass).
+
+Use --stale-after to change how long an active spec may go untouched before
+status and guide flag it as stale (default 48h).
+
+Use --refactor-timebox to limit how long REFACTOR may run. Once exceeded, guide
+asks to finish or record remaining ideas as new specs and advance, and its JSON
+output sets timebox_exceeded so orchestrators can escalate.
+
+Use --stall-after to change how long the session may go without events or
+'tdd-ai heartbeat' calls before status reports it as stalled (default 30m).
+
+The OS, architecture, and go/node/python and test runner versions found at init
+are recorded in the session; 'tdd-ai doctor' reports when they change mid-session.
+
+The git branch checked out at init is recorded too, pinning the session to it.
+Commands that change the session while another branch is checked out print a
+warning, so a session does not silently span unrelated branches after a
+checkout; with --strict-branch they are refused instead. 'tdd-ai config branch'
+shows the pinned branch and can move the session to the current one.
+
+init refuses to create a session inside a directory tree that already has one in
+a parent directory, which would split the work across two sessions. Use --nested
+to create one anyway. Other commands only use the session in the working
+directory unless --search-parents (or TDD_AI_SEARCH_PARENTS=1) is given, in
+which case they use the nearest session above it, like git.
+
+Use --from-template to bootstrap the session from a shared template: a local
+directory or git URL containing a tdd-ai.template.json file. A template can set
+any of the options above plus a custom reflection question set, project-specific
+guide instructions, and starting specs. Flags given on the command line take
+precedence over the template:
+
+  {
+    "test_cmd": "go test ./...",
+    "test_policy": ["refactor=any"],
+    "protect": ["migrations/**"],
+    "reflections": ["Is the public API still backwards compatible?"],
+    "instructions": ["Use table-driven tests."],
+    "specs": ["health endpoint returns 200"]
+  }`,
 	Example: `  tdd-ai init
   tdd-ai init --retrofit
   tdd-ai init --test-cmd "go test ./..."
-  tdd-ai init --retrofit --test-cmd "dotnet test MyProject.Tests"`,
+  tdd-ai init --retrofit --test-cmd "dotnet test MyProject.Tests"
+  tdd-ai init --test-cmd "npm test" --mutation-cmd "npx stryker run" --mutation-threshold 70
+  tdd-ai init --test-policy refactor=any --test-policy retrofit:red=any
+  tdd-ai init --strict-branch
+  tdd-ai init --from-template https://github.com/acme/tdd-templates.git
+  tdd-ai init --test-cmd "go test -short ./..." --test-suite unit="go test -short ./..." --test-suite integration="go test -run Integration ./..." --require-suites refactor=unit,integration`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		dir := getWorkDir()
+		// Always the working directory: --search-parents must not redirect init
+		dir := currentDir()
 
 		if session.Exists(dir) {
 			return fmt.Errorf("TDD session already exists. Use 'tdd-ai reset' to start over")
 		}
+		if parent, ok := session.FindRoot(filepath.Dir(dir)); ok {
+			if !nestedFlag {
+				return blockedError(fmt.Errorf("a TDD session already exists in parent directory %s. Work from there (or pass --search-parents), or use --nested to start a separate session here", parent))
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: creating a session nested inside the session in %s (--nested)\n", parent)
+		}
+
+		var tpl *template.Template
+		if fromTemplateFlag != "" {
+			var err error
+			if tpl, err = loadTemplate(fromTemplateFlag); err != nil {
+				return err
+			}
+			if err := applyTemplateDefaults(cmd, tpl); err != nil {
+				return invalidInputError(err)
+			}
+		}
+
+		if staleAfterFlag < 0 {
+			return invalidInputError(fmt.Errorf("--stale-after must not be negative"))
+		}
+		if refactorTimeboxFlag < 0 {
+			return invalidInputError(fmt.Errorf("--refactor-timebox must not be negative"))
+		}
+		if stallAfterFlag < 0 {
+			return invalidInputError(fmt.Errorf("--stall-after must not be negative"))
+		}
+		if outputLinesFlag < 0 {
+			return invalidInputError(fmt.Errorf("--output-lines must not be negative"))
+		}
+		if maxIterationsPerSpecFlag < 0 {
+			return invalidInputError(fmt.Errorf("--max-iterations-per-spec must not be negative"))
+		}
+		if strictnessFlag != "" && !slices.Contains(types.Strictnesses, strictnessFlag) {
+			return invalidInputError(fmt.Errorf("invalid --strictness %q: must be one of %s", strictnessFlag, strings.Join(types.Strictnesses, ", ")))
+		}
+
+		branch := gitBranch(dir)
+		if strictBranchFlag && branch == "" {
+			return invalidInputError(fmt.Errorf("--strict-branch needs a git branch checked out"))
+		}
+
+		if _, err := testoutput.NewRedactor(redactFlag); err != nil {
+			return invalidInputError(err)
+		}
+
+		policy, err := phase.ParseTestPolicy(testPolicyFlag)
+		if err != nil {
+			return invalidInputError(err)
+		}
+
+		suites, err := parseTestSuites(testSuitesFlag)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		required, err := parseRequiredSuites(requireSuitesFlag, suites)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		areas, err := parseTestAreas(testAreasFlag)
+		if err != nil {
+			return invalidInputError(err)
+		}
 
 		mode := types.ModeGreenfield
 		if retrofitFlag {
@@ -45,14 +251,76 @@ command and auto-populates the test result for 'phase next'.`,
 			return err
 		}
 
-		if testCmdFlag != "" {
-			s.TestCmd = testCmdFlag
+		var detected bool
+		switch {
+		case cmd.Flags().Changed("test-cmd"):
+			s.TestCmd, s.TestCmdSource = testCmdFlag, "flag"
+		case testCmdFlag != "":
+			s.TestCmd, s.TestCmdSource = testCmdFlag, "template"
+		case !noDetectFlag:
+			if d, ok := testdetect.Detect(dir); ok {
+				s.TestCmd, s.TestCmdSource = d.Command, "detected:"+d.Source
+				detected = true
+			}
+		}
+		if s.TestCmd == "" {
+			s.TestCmdSource = ""
 		}
 
 		if agentFlag {
 			s.AgentMode = true
 		}
 
+		if reviewFlag {
+			s.RequireReview = true
+		}
+
+		if auditFlag {
+			s.AuditLog = true
+		}
+
+		s.MaxIterationsPerSpec = maxIterationsPerSpecFlag
+		s.TestPolicy = policy
+		s.TestCmds = suites
+		s.TestShards = testShardsFlag
+		s.TestAreas = areas
+		s.OutputLines = outputLinesFlag
+		s.ProtectedPaths = protectFlag
+		s.TestGlobs = testGlobFlag
+		s.RequiredSuites = required
+		s.Strictness = strictnessFlag
+		s.NoTestCache = noTestCacheFlag
+		s.RedactPatterns = redactFlag
+		s.PhaseFiles = takeFileSnapshot(dir)
+		s.Branch = branch
+		s.StrictBranch = strictBranchFlag
+		if staleAfterFlag > 0 {
+			s.StaleAfter = staleAfterFlag.String()
+		}
+		if refactorTimeboxFlag > 0 {
+			s.RefactorTimebox = refactorTimeboxFlag.String()
+		}
+		if stallAfterFlag > 0 {
+			s.StallAfter = stallAfterFlag.String()
+		}
+
+		if mutationCmdFlag != "" {
+			s.MutationCmd = mutationCmdFlag
+			s.MutationThreshold = mutationThresholdFlag
+		}
+
+		if tpl != nil {
+			s.ReflectionSet = tpl.Reflections
+			s.Instructions = tpl.Instructions
+			for _, desc := range tpl.Specs {
+				s.AddSpec(desc)
+			}
+		}
+
+		s.Environment = envsnap.Capture(s.TestCmd)
+
+		activePolicy.Apply(s)
+
 		s.AddEvent("init", func(e *types.Event) {
 			e.Result = string(s.GetMode())
 		})
@@ -66,16 +334,218 @@ command and auto-populates the test result for 'phase next'.`,
 			modeStr += ", agent"
 		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Session initialized (phase: %s, mode: %s)\n", s.Phase, modeStr)
-		if s.TestCmd != "" {
+		if detected {
+			fmt.Fprintf(cmd.OutOrStdout(), "Test command: %s (detected from %s)\n", s.TestCmd, strings.TrimPrefix(s.TestCmdSource, "detected:"))
+		} else if s.TestCmd != "" {
 			fmt.Fprintf(cmd.OutOrStdout(), "Test command: %s\n", s.TestCmd)
 		}
+		for _, name := range s.SuiteNames() {
+			fmt.Fprintf(cmd.OutOrStdout(), "Test suite %s: %s\n", name, s.TestCmds[name])
+		}
+		for _, area := range s.AreaNames() {
+			fmt.Fprintf(cmd.OutOrStdout(), "Test area %s: %s\n", area, s.TestAreas[area])
+		}
+		if s.Branch != "" {
+			strict := ""
+			if s.StrictBranch {
+				strict = " (strict)"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Pinned to branch: %s%s\n", s.Branch, strict)
+		}
+		if s.MaxIterationsPerSpec > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Max iterations per spec: %d\n", s.MaxIterationsPerSpec)
+		}
+		if s.MutationCmd != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Mutation command: %s (threshold %.0f%%)\n", s.MutationCmd, s.GetMutationThreshold())
+		}
+		if tpl != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "Template: %s (%d spec(s) added)\n", fromTemplateFlag, len(tpl.Specs))
+		}
 		return nil
 	},
 }
 
+// loadTemplate reads the template from a local directory, or from a shallow
+// clone when source is a git URL.
+func loadTemplate(source string) (*template.Template, error) {
+	if !template.IsRemote(source) {
+		return template.Load(source)
+	}
+	dir, err := os.MkdirTemp("", "tdd-ai-template-")
+	if err != nil {
+		return nil, fmt.Errorf("creating template checkout: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if out, err := exec.Command("git", "clone", "--depth", "1", "--quiet", source, dir).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cloning template %s: %w: %s", source, err, strings.TrimSpace(string(out)))
+	}
+	return template.Load(dir)
+}
+
+// applyTemplateDefaults fills in init flags from the template wherever the
+// flag was not given explicitly.
+func applyTemplateDefaults(cmd *cobra.Command, t *template.Template) error {
+	unset := func(name string) bool { return !cmd.Flags().Changed(name) }
+	if unset("test-cmd") && t.TestCmd != "" {
+		testCmdFlag = t.TestCmd
+	}
+	if unset("test-suite") && len(t.TestSuites) > 0 {
+		testSuitesFlag = t.SuiteEntries()
+	}
+	if unset("test-shard") && len(t.TestShards) > 0 {
+		testShardsFlag = t.TestShards
+	}
+	if unset("test-area") && len(t.TestAreas) > 0 {
+		testAreasFlag = t.AreaEntries()
+	}
+	if unset("require-suites") && len(t.RequireSuites) > 0 {
+		requireSuitesFlag = t.RequireSuiteEntries()
+	}
+	if unset("test-policy") && len(t.TestPolicy) > 0 {
+		testPolicyFlag = t.TestPolicy
+	}
+	if unset("protect") && len(t.Protect) > 0 {
+		protectFlag = t.Protect
+	}
+	if unset("test-glob") && len(t.TestGlobs) > 0 {
+		testGlobFlag = t.TestGlobs
+	}
+	if unset("output-lines") && t.OutputLines != 0 {
+		outputLinesFlag = t.OutputLines
+	}
+	if unset("mutation-cmd") && t.MutationCmd != "" {
+		mutationCmdFlag = t.MutationCmd
+	}
+	if unset("mutation-threshold") && t.MutationThreshold != 0 {
+		mutationThresholdFlag = t.MutationThreshold
+	}
+	if unset("max-iterations-per-spec") && t.MaxIterationsPerSpec != 0 {
+		maxIterationsPerSpecFlag = t.MaxIterationsPerSpec
+	}
+	if unset("stale-after") && t.StaleAfter != "" {
+		d, err := time.ParseDuration(t.StaleAfter)
+		if err != nil {
+			return fmt.Errorf("template stale_after %q: %w", t.StaleAfter, err)
+		}
+		staleAfterFlag = d
+	}
+	if unset("refactor-timebox") && t.RefactorTimebox != "" {
+		d, err := time.ParseDuration(t.RefactorTimebox)
+		if err != nil {
+			return fmt.Errorf("template refactor_timebox %q: %w", t.RefactorTimebox, err)
+		}
+		refactorTimeboxFlag = d
+	}
+	if unset("stall-after") && t.StallAfter != "" {
+		d, err := time.ParseDuration(t.StallAfter)
+		if err != nil {
+			return fmt.Errorf("template stall_after %q: %w", t.StallAfter, err)
+		}
+		stallAfterFlag = d
+	}
+	if unset("strictness") && t.Strictness != "" {
+		strictnessFlag = t.Strictness
+	}
+	if unset("redact-pattern") && len(t.RedactPatterns) > 0 {
+		redactFlag = t.RedactPatterns
+	}
+	if unset("no-test-cache") && t.NoTestCache {
+		noTestCacheFlag = true
+	}
+	return nil
+}
+
+// parseTestSuites parses name=command entries into named test suites.
+func parseTestSuites(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	suites := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, command, ok := strings.Cut(entry, "=")
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if !ok || name == "" || command == "" {
+			return nil, fmt.Errorf("invalid test suite %q: expected name=\"command\"", entry)
+		}
+		suites[name] = command
+	}
+	return suites, nil
+}
+
+// parseTestAreas parses path="command" entries into test areas keyed by clean,
+// slash-separated paths relative to the project root.
+func parseTestAreas(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	areas := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		path, command, ok := strings.Cut(entry, "=")
+		path, command = strings.TrimSpace(path), strings.TrimSpace(command)
+		if !ok || path == "" || command == "" {
+			return nil, fmt.Errorf("invalid test area %q: expected path=\"command\"", entry)
+		}
+		path = filepath.ToSlash(filepath.Clean(path))
+		if filepath.IsAbs(path) || path == "." || path == ".." || strings.HasPrefix(path, "../") {
+			return nil, fmt.Errorf("invalid test area %q: the path must be a directory inside the project", entry)
+		}
+		areas[path] = command
+	}
+	return areas, nil
+}
+
+// parseRequiredSuites parses phase=suite,... entries, checking every suite is configured.
+func parseRequiredSuites(entries []string, suites map[string]string) (map[types.Phase][]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	required := make(map[types.Phase][]string, len(entries))
+	for _, entry := range entries {
+		p, list, ok := strings.Cut(entry, "=")
+		if !ok || list == "" {
+			return nil, fmt.Errorf("invalid required suites %q: expected phase=suite,...", entry)
+		}
+		ph := types.Phase(p)
+		if ph != types.PhaseRed && ph != types.PhaseGreen && ph != types.PhaseRefactor {
+			return nil, fmt.Errorf("invalid required suites %q: phase must be red, green, or refactor", entry)
+		}
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := suites[name]; !ok {
+				return nil, fmt.Errorf("invalid required suites %q: suite %q is not configured with --test-suite", entry, name)
+			}
+			required[ph] = append(required[ph], name)
+		}
+	}
+	return required, nil
+}
+
 func init() {
 	initCmd.Flags().BoolVar(&retrofitFlag, "retrofit", false, "use retrofit mode for testing existing code")
 	initCmd.Flags().StringVar(&testCmdFlag, "test-cmd", "", "test command to run (e.g. 'go test ./...', 'npm test')")
+	initCmd.Flags().BoolVar(&noDetectFlag, "no-detect", false, "do not detect the test command from the project's build files")
 	initCmd.Flags().BoolVar(&agentFlag, "agent", false, "enable agent mode (stricter enforcement: disables phase set, requires --force for complete)")
+	initCmd.Flags().BoolVar(&reviewFlag, "require-review", false, "require an approving 'tdd-ai review' before complete")
+	initCmd.Flags().BoolVar(&auditFlag, "audit", false, "append every event to a hash-chained audit log (.tdd-ai.audit.jsonl)")
+	initCmd.Flags().IntVar(&maxIterationsPerSpecFlag, "max-iterations-per-spec", 0, "maximum red-green-refactor passes per spec before splitting is required (0 = no limit)")
+	initCmd.Flags().DurationVar(&staleAfterFlag, "stale-after", 0, "flag active specs untouched for longer than this as stale (default 48h)")
+	initCmd.Flags().DurationVar(&refactorTimeboxFlag, "refactor-timebox", 0, "how long REFACTOR may run before guide suggests advancing (0 = no limit)")
+	initCmd.Flags().DurationVar(&stallAfterFlag, "stall-after", 0, "report the session as stalled after this long without events or heartbeats (default 30m)")
+	initCmd.Flags().StringArrayVar(&testSuitesFlag, "test-suite", nil, "named test suite as name=\"command\" (repeatable)")
+	initCmd.Flags().StringArrayVar(&testAreasFlag, "test-area", nil, "monorepo test area as path=\"command\", run from that directory (repeatable)")
+	initCmd.Flags().StringArrayVar(&testShardsFlag, "test-shard", nil, "command running one shard of the suite, run by 'tdd-ai test --shards' (repeatable)")
+	initCmd.Flags().StringArrayVar(&requireSuitesFlag, "require-suites", nil, "suites that must pass before leaving a phase, as phase=suite,... (repeatable)")
+	initCmd.Flags().StringArrayVar(&protectFlag, "protect", nil, "glob of paths that must not be modified during the cycle, e.g. 'migrations/**' (repeatable)")
+	initCmd.Flags().StringArrayVar(&testGlobFlag, "test-glob", nil, "glob of test files frozen during GREEN, e.g. 'tests/**/*.py' (repeatable; default: common test file patterns)")
+	initCmd.Flags().StringArrayVar(&redactFlag, "redact-pattern", nil, "regular expression to mask in test output, on top of the built-in credential patterns (repeatable)")
+	initCmd.Flags().IntVar(&outputLinesFlag, "output-lines", 0, "trailing lines of failing test output to keep in the session (default 20)")
+	initCmd.Flags().StringArrayVar(&testPolicyFlag, "test-policy", nil, "expected test result override as [mode:]phase=pass|fail|any (repeatable)")
+	initCmd.Flags().StringVar(&mutationCmdFlag, "mutation-cmd", "", "mutation testing command to run during refactor (e.g. 'npx stryker run')")
+	initCmd.Flags().Float64Var(&mutationThresholdFlag, "mutation-threshold", types.DefaultMutationThreshold, "minimum mutation score (percent) required to leave refactor")
+	initCmd.Flags().StringVar(&fromTemplateFlag, "from-template", "", "bootstrap the session from a template directory or git URL containing "+template.FileName)
+	initCmd.Flags().BoolVar(&noTestCacheFlag, "no-test-cache", false, "add -count=1 to 'go test' commands so Go's test cache never serves results")
+	initCmd.Flags().StringVar(&strictnessFlag, "strictness", "", "reflection enforcement: relaxed, standard, or strict (default standard)")
+	initCmd.Flags().BoolVar(&strictBranchFlag, "strict-branch", false, "refuse to change the session while a branch other than the current one is checked out")
+	initCmd.Flags().BoolVar(&nestedFlag, "nested", false, "allow creating a session below a directory that already has one")
 	rootCmd.AddCommand(initCmd)
 }
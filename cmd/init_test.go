@@ -3,10 +3,14 @@ package cmd
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/pflag"
 )
 
 func executeInitCmd(t *testing.T, args ...string) (string, error) {
@@ -68,3 +72,257 @@ func TestInitWithoutAgentModeIsDefault(t *testing.T) {
 		t.Error("AgentMode should be false when --agent is not used")
 	}
 }
+
+func TestInitMaxIterationsPerSpec(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { maxIterationsPerSpecFlag = 0 }()
+
+	out, err := executeInitCmd(t, "init", "--max-iterations-per-spec", "3", "--format", "text")
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !strings.Contains(out, "Max iterations per spec: 3") {
+		t.Errorf("output should mention the limit, got:\n%s", out)
+	}
+
+	loaded, err := session.Load(dir)
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if loaded.MaxIterationsPerSpec != 3 {
+		t.Errorf("MaxIterationsPerSpec = %d, want 3", loaded.MaxIterationsPerSpec)
+	}
+}
+
+func TestInitTestPolicy(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testPolicyFlag = nil }()
+
+	_, err := executeInitCmd(t, "init", "--test-policy", "bogus=pass", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Fatalf("invalid policy should be rejected as invalid input, got: %v", err)
+	}
+	if session.Exists(dir) {
+		t.Fatal("invalid policy should not create a session")
+	}
+
+	testPolicyFlag = nil
+	if _, err := executeInitCmd(t, "init", "--test-policy", "refactor=any", "--format", "text"); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.TestPolicy["refactor"] != "any" {
+		t.Errorf("TestPolicy = %v, want refactor=any", loaded.TestPolicy)
+	}
+}
+
+func TestInitStaleAfter(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { staleAfterFlag = 0 }()
+
+	if _, err := executeInitCmd(t, "init", "--stale-after", "72h", "--format", "text"); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if got := loaded.GetStaleAfter(); got != 72*time.Hour {
+		t.Errorf("GetStaleAfter() = %s, want 72h", got)
+	}
+}
+
+func TestInitTestSuites(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testSuitesFlag, requireSuitesFlag = nil, nil }()
+
+	_, err := executeInitCmd(t, "init", "--test-suite", "unit=go test ./...", "--require-suites", "refactor=unit,e2e", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Fatalf("requiring an unconfigured suite should be invalid input, got: %v", err)
+	}
+
+	testSuitesFlag, requireSuitesFlag = nil, nil
+	out, err := executeInitCmd(t, "init", "--test-suite", "unit=go test ./...", "--require-suites", "refactor=unit", "--format", "text")
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !strings.Contains(out, "Test suite unit: go test ./...") {
+		t.Errorf("output should list suites, got:\n%s", out)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.TestCmds["unit"] != "go test ./..." || len(loaded.RequiredSuites[types.PhaseRefactor]) != 1 {
+		t.Errorf("suites not stored: %v / %v", loaded.TestCmds, loaded.RequiredSuites)
+	}
+}
+
+func TestInitFromTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tplDir := t.TempDir()
+	tpl := `{
+  "test_cmd": "go test ./...",
+  "protect": ["migrations/**"],
+  "reflections": ["Is the public API still backwards compatible?"],
+  "instructions": ["Use table-driven tests."],
+  "specs": ["health endpoint returns 200", "readiness waits for the database"]
+}`
+	if err := os.WriteFile(filepath.Join(tplDir, "tdd-ai.template.json"), []byte(tpl), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	initCmd.Flags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+	defer func() { fromTemplateFlag, testCmdFlag, protectFlag = "", "", nil }()
+
+	out, err := executeInitCmd(t, "init", "--from-template", tplDir, "--test-cmd", "make test", "--format", "text")
+	if err != nil {
+		t.Fatalf("init --from-template failed: %v", err)
+	}
+	if !strings.Contains(out, "2 spec(s) added") {
+		t.Errorf("output should report the template, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.TestCmd != "make test" {
+		t.Errorf("TestCmd = %q, want the explicit flag to win over the template", loaded.TestCmd)
+	}
+	if len(loaded.ProtectedPaths) != 1 || loaded.ProtectedPaths[0] != "migrations/**" {
+		t.Errorf("ProtectedPaths = %v, want the template's", loaded.ProtectedPaths)
+	}
+	if len(loaded.Specs) != 2 || loaded.Specs[1].Description != "readiness waits for the database" {
+		t.Errorf("Specs = %+v, want the template's spec skeletons", loaded.Specs)
+	}
+	if len(loaded.ReflectionSet) != 1 || len(loaded.Instructions) != 1 {
+		t.Errorf("reflection set %v / instructions %v not stored", loaded.ReflectionSet, loaded.Instructions)
+	}
+}
+
+func TestInitFromTemplateMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { fromTemplateFlag = "" }()
+
+	if _, err := executeInitCmd(t, "init", "--from-template", t.TempDir(), "--format", "text"); err == nil {
+		t.Fatal("init should fail when the template file is missing")
+	}
+	if session.Exists(dir) {
+		t.Error("no session should be created when the template cannot be loaded")
+	}
+}
+
+func TestInitRefusesNestedSessionUnlessAllowed(t *testing.T) {
+	root := t.TempDir()
+	if err := session.Save(root, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	sub := filepath.Join(root, "pkg", "api")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(sub)
+	defer os.Chdir(origDir)
+	defer func() { nestedFlag = false }()
+
+	_, err := executeInitCmd(t, "init", "--format", "text")
+	if ExitCode(err) != ExitBlocked || !strings.Contains(err.Error(), "parent directory") {
+		t.Fatalf("init below an existing session should be blocked, got: %v", err)
+	}
+	if session.Exists(sub) {
+		t.Fatal("no nested session should be created without --nested")
+	}
+
+	if _, err := executeInitCmd(t, "init", "--nested", "--format", "text"); err != nil {
+		t.Fatalf("init --nested failed: %v", err)
+	}
+	if !session.Exists(sub) {
+		t.Error("init --nested should create the nested session")
+	}
+}
+
+func TestSearchParentsUsesNearestSession(t *testing.T) {
+	root := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("found from below")
+	if err := session.Save(root, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	sub := filepath.Join(root, "internal", "deep")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(sub)
+	defer os.Chdir(origDir)
+	defer func() { searchParentsFlag = false }()
+
+	if _, err := executeInitCmd(t, "spec", "list", "--format", "text"); ExitCode(err) != ExitNoSession {
+		t.Errorf("without --search-parents only the working directory is used, got: %v", err)
+	}
+
+	t.Setenv(searchParentsEnvVar, "1")
+	out, err := executeInitCmd(t, "spec", "list", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec list with %s failed: %v", searchParentsEnvVar, err)
+	}
+	if !strings.Contains(out, "found from below") {
+		t.Errorf("should use the parent session, got:\n%s", out)
+	}
+}
+
+func TestInitDetectsTestCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Makefile"), []byte("build:\n\tgo build\n\ntest: build\n\tgo test ./...\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	resetFlags(initCmd.Flags())
+	defer resetFlags(initCmd.Flags())
+
+	out, err := executeInitCmd(t, "init", "--format", "text")
+	if err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if !strings.Contains(out, "Test command: make test (detected from Makefile)") {
+		t.Errorf("output should report the detected command, got:\n%s", out)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.TestCmd != "make test" || loaded.TestCmdSource != "detected:Makefile" {
+		t.Errorf("TestCmd = %q from %q, want make test detected from Makefile", loaded.TestCmd, loaded.TestCmdSource)
+	}
+
+	os.Remove(session.FilePath(dir))
+	if _, err := executeInitCmd(t, "init", "--test-cmd", "go test -short ./...", "--format", "text"); err != nil {
+		t.Fatalf("init --test-cmd failed: %v", err)
+	}
+	loaded, _ = session.Load(dir)
+	if loaded.TestCmd != "go test -short ./..." || loaded.TestCmdSource != "flag" {
+		t.Errorf("TestCmd = %q from %q, want the flag", loaded.TestCmd, loaded.TestCmdSource)
+	}
+
+	resetFlags(initCmd.Flags())
+	os.Remove(session.FilePath(dir))
+	if _, err := executeInitCmd(t, "init", "--no-detect", "--format", "text"); err != nil {
+		t.Fatalf("init --no-detect failed: %v", err)
+	}
+	loaded, _ = session.Load(dir)
+	if loaded.TestCmd != "" || loaded.TestCmdSource != "" {
+		t.Errorf("TestCmd = %q from %q, want none with --no-detect", loaded.TestCmd, loaded.TestCmdSource)
+	}
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var leaseCmd = &cobra.Command{
+	Use:   "lease",
+	Short: "Coordinate phase advancement between concurrent agents",
+	Long: `Leases give one agent exclusive rights to advance phases for a limited time.
+
+Agents identify themselves with the TDD_AI_AGENT_ID environment variable. While a
+lease is held, 'phase next', 'phase set', and 'complete' are rejected for every
+other agent until the lease expires, is released, or is broken.`,
+	Example: `  TDD_AI_AGENT_ID=worker-1 tdd-ai lease acquire --ttl 10m
+  tdd-ai lease status
+  TDD_AI_AGENT_ID=worker-1 tdd-ai lease release
+  tdd-ai lease break`,
+}
+
+var leaseTTLFlag time.Duration
+
+var leaseAcquireCmd = &cobra.Command{
+	Use:   "acquire",
+	Short: "Acquire or renew the session lease for this agent",
+	Long:  "Acquire the session lease for the agent named by TDD_AI_AGENT_ID. Re-acquiring renews the expiry.",
+	Example: `  TDD_AI_AGENT_ID=worker-1 tdd-ai lease acquire
+  TDD_AI_AGENT_ID=worker-1 tdd-ai lease acquire --ttl 30m`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if leaseTTLFlag <= 0 {
+			return invalidInputError(fmt.Errorf("--ttl must be positive, got %s", leaseTTLFlag))
+		}
+
+		dir := getWorkDir()
+		// Without the lock two agents acquiring at once could both win.
+		unlock, err := session.Lock(dir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		agentID := types.CurrentAgentID()
+		if err := s.AcquireLease(agentID, leaseTTLFlag, time.Now()); err != nil {
+			return err
+		}
+		s.AddEvent("lease_acquire", func(e *types.Event) {
+			e.Result = leaseTTLFlag.String()
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Lease held by %s until %s\n", s.Lease.Holder, s.Lease.ExpiresAt)
+		return nil
+	},
+}
+
+var leaseReleaseCmd = &cobra.Command{
+	Use:     "release",
+	Short:   "Release the lease held by this agent",
+	Long:    "Release the session lease. Only the agent holding the lease can release it; use 'lease break' otherwise.",
+	Example: `  TDD_AI_AGENT_ID=worker-1 tdd-ai lease release`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		unlock, err := session.Lock(dir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if s.Lease == nil {
+			return fmt.Errorf("no lease is held")
+		}
+		if s.Lease.Holder != types.CurrentAgentID() {
+			return fmt.Errorf("lease is held by agent %q. Use 'tdd-ai lease break' to force release", s.Lease.Holder)
+		}
+
+		s.Lease = nil
+		s.AddEvent("lease_release")
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "Lease released")
+		return nil
+	},
+}
+
+var leaseBreakCmd = &cobra.Command{
+	Use:     "break",
+	Short:   "Forcibly remove the current lease",
+	Long:    "Remove the session lease regardless of holder, e.g. when the holding agent has crashed.",
+	Example: `  tdd-ai lease break`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		unlock, err := session.Lock(dir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if s.Lease == nil {
+			return fmt.Errorf("no lease is held")
+		}
+
+		holder := s.Lease.Holder
+		s.Lease = nil
+		s.AddEvent("lease_break", func(e *types.Event) {
+			e.Result = holder
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Lease held by %s broken\n", holder)
+		return nil
+	},
+}
+
+var leaseStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who holds the session lease",
+	Long:  "Display the current lease holder and expiry, if any.",
+	Example: `  tdd-ai lease status
+  tdd-ai lease status --format json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		type leaseStatusOutput struct {
+			Held    bool         `json:"held"`
+			Expired bool         `json:"expired,omitempty"`
+			Lease   *types.Lease `json:"lease,omitempty"`
+		}
+
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		out := leaseStatusOutput{Lease: s.Lease}
+		if s.Lease != nil {
+			out.Expired = s.Lease.Expired(time.Now())
+			out.Held = !out.Expired
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding lease status: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			switch {
+			case s.Lease == nil:
+				fmt.Fprintln(cmd.OutOrStdout(), "No lease held")
+			case out.Expired:
+				fmt.Fprintf(cmd.OutOrStdout(), "Lease held by %s expired at %s\n", s.Lease.Holder, s.Lease.ExpiresAt)
+			default:
+				fmt.Fprintf(cmd.OutOrStdout(), "Lease held by %s until %s\n", s.Lease.Holder, s.Lease.ExpiresAt)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+// checkLease returns an error when another agent holds an unexpired lease.
+func checkLease(s *types.Session) error {
+	if err := s.CheckLease(types.CurrentAgentID(), time.Now()); err != nil {
+		return blockedError(fmt.Errorf("cannot advance: %w", err))
+	}
+	return nil
+}
+
+func init() {
+	leaseAcquireCmd.Flags().DurationVar(&leaseTTLFlag, "ttl", 10*time.Minute, "how long the lease is held before it expires")
+	leaseCmd.AddCommand(leaseAcquireCmd)
+	leaseCmd.AddCommand(leaseReleaseCmd)
+	leaseCmd.AddCommand(leaseBreakCmd)
+	leaseCmd.AddCommand(leaseStatusCmd)
+	rootCmd.AddCommand(leaseCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestLeaseBlocksPhaseNextForOtherAgents(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { leaseTTLFlag = 10 * time.Minute }()
+
+	t.Setenv(types.AgentIDEnv, "worker-1")
+	out, _, err := executePhaseCmd(t, "lease", "acquire", "--ttl", "5m", "--format", "text")
+	if err != nil {
+		t.Fatalf("lease acquire failed: %v", err)
+	}
+	if !strings.Contains(out, "Lease held by worker-1") {
+		t.Errorf("should confirm lease holder, got:\n%s", out)
+	}
+
+	t.Setenv(types.AgentIDEnv, "worker-2")
+	_, _, err = executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if err == nil {
+		t.Fatal("phase next should be blocked while another agent holds the lease")
+	}
+	if !strings.Contains(err.Error(), "worker-1") {
+		t.Errorf("error should name the lease holder, got: %v", err)
+	}
+
+	_, _, err = executePhaseCmd(t, "lease", "release", "--format", "text")
+	if err == nil {
+		t.Error("lease release should fail for a non-holder")
+	}
+
+	if _, _, err = executePhaseCmd(t, "lease", "break", "--format", "text"); err != nil {
+		t.Fatalf("lease break failed: %v", err)
+	}
+	if _, _, err = executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Errorf("phase next should succeed after the lease is broken: %v", err)
+	}
+
+	loaded, err := session.Load(dir)
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	last := loaded.History[len(loaded.History)-1]
+	if last.AgentID != "worker-2" {
+		t.Errorf("phase_next event AgentID = %q, want %q", last.AgentID, "worker-2")
+	}
+}
+
+func TestLeaseStatusWithoutLease(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "lease", "status", "--format", "text")
+	if err != nil {
+		t.Fatalf("lease status failed: %v", err)
+	}
+	if !strings.Contains(out, "No lease held") {
+		t.Errorf("should report no lease, got:\n%s", out)
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/sessionlint"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+type lintSessionOutput struct {
+	Issues []sessionlint.Issue `json:"issues"`
+	Fixed  bool                `json:"fixed"`
+}
+
+var lintSessionFixFlag bool
+
+var lintSessionCmd = &cobra.Command{
+	Use:   "lint-session",
+	Short: "Check the session file for inconsistent state (--fix to repair)",
+	Long: `Detects states that hand-edited or merged session files drift into:
+
+  duplicate_spec_id             two specs share an ID
+  next_id_behind                next_id would reuse an existing spec ID
+  current_spec_not_active       the current spec is missing, completed, or superseded
+  reflections_outside_refactor  unanswered reflection questions outside REFACTOR
+  done_with_active_specs        the phase is done while specs are still active
+
+Exits 1 when issues are found. With --fix, each issue is repaired (see the fix
+column), a "session_repaired" event is recorded, and the command exits 0.`,
+	Example: `  tdd-ai lint-session
+  tdd-ai lint-session --fix --format json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		if f != formatter.FormatJSON && f != formatter.FormatText {
+			return unknownFormatError(f)
+		}
+
+		out := lintSessionOutput{Issues: sessionlint.Check(s)}
+		if lintSessionFixFlag && len(out.Issues) > 0 {
+			out.Issues = sessionlint.Fix(s)
+			out.Fixed = true
+			var rules []string
+			for _, issue := range out.Issues {
+				rules = append(rules, issue.Rule)
+			}
+			s.AddEvent("session_repaired", func(e *types.Event) {
+				e.Reason = strings.Join(rules, ", ")
+			})
+			if err := session.Save(dir, s); err != nil {
+				return err
+			}
+		}
+		if out.Issues == nil {
+			out.Issues = []sessionlint.Issue{}
+		}
+
+		w := cmd.OutOrStdout()
+		if f == formatter.FormatJSON {
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding lint-session result: %w", err)
+			}
+			fmt.Fprintln(w, string(data))
+		} else {
+			if len(out.Issues) == 0 {
+				fmt.Fprintln(w, "Session is consistent.")
+			}
+			for _, issue := range out.Issues {
+				fmt.Fprintf(w, "  %s: %s\n", issue.Rule, issue.Message)
+				if out.Fixed {
+					fmt.Fprintf(w, "      fixed: %s\n", issue.Fix)
+				} else {
+					fmt.Fprintf(w, "      -> %s (run with --fix)\n", issue.Fix)
+				}
+			}
+		}
+
+		if len(out.Issues) > 0 && !out.Fixed {
+			return fmt.Errorf("%d session issue(s) found", len(out.Issues))
+		}
+		return nil
+	},
+}
+
+func init() {
+	lintSessionCmd.Flags().BoolVar(&lintSessionFixFlag, "fix", false, "repair the issues found")
+	rootCmd.AddCommand(lintSessionCmd)
+}
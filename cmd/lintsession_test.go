@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestLintSessionFixRepairsAndRecordsEvent(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("adds two numbers")
+	s.Phase = types.PhaseDone
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "lint-session", "--format", "text")
+	if err == nil {
+		t.Fatal("lint-session should fail when issues are found")
+	}
+	if !strings.Contains(out, "done_with_active_specs") {
+		t.Errorf("output should name the issue, got:\n%s", out)
+	}
+
+	if _, _, err := executePhaseCmd(t, "lint-session", "--fix", "--format", "text"); err != nil {
+		t.Fatalf("lint-session --fix failed: %v", err)
+	}
+	lintSessionFixFlag = false
+
+	s, _ = session.Load(dir)
+	if s.Phase != types.PhaseRed {
+		t.Errorf("phase = %s, want red after fix", s.Phase)
+	}
+	last := s.History[len(s.History)-1]
+	if last.Action != "session_repaired" || last.Reason != "done_with_active_specs" {
+		t.Errorf("last event = %+v, want session_repaired", last)
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/merge"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeTheirsFlag string
+	mergePhaseFlag  string
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Merge another branch's session file into this one",
+	Long: `Performs a semantic merge of two session files instead of leaving a conflicted
+.tdd-ai.json to be fixed by hand.
+
+Specs are unioned: specs with the same slug or description are treated as one,
+and the rest of theirs are appended with new IDs. History events missing from
+this session are appended with their spec IDs remapped. Phase state (phase,
+working spec, iteration, reflections) comes from whichever session saw activity
+most recently; the command reports which side won, and --phase ours|theirs
+overrides the choice.
+
+During a conflicted git merge, keep your side and merge theirs:
+  git show MERGE_HEAD:.tdd-ai.json > /tmp/theirs.json
+  git checkout --ours .tdd-ai.json
+  tdd-ai merge --theirs /tmp/theirs.json`,
+	Example: `  tdd-ai merge --theirs /tmp/theirs.json
+  tdd-ai merge --theirs /tmp/theirs.json --phase ours
+  tdd-ai merge --theirs /tmp/theirs.json --format json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+		if mergeTheirsFlag == "" {
+			return invalidInputError(fmt.Errorf("--theirs is required"))
+		}
+		side, err := merge.ParseSide(mergePhaseFlag)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		theirs, err := session.LoadFile(mergeTheirsFlag)
+		if err != nil {
+			return invalidInputError(err)
+		}
+
+		res := merge.Merge(s, theirs, side)
+		s.AddEvent("session_merge", func(e *types.Event) {
+			e.SpecCount = res.SpecsAdded
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding merge result: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Merged %s: %d spec(s) added, %d shared, %d event(s) added\n",
+				mergeTheirsFlag, res.SpecsAdded, res.SpecsShared, res.EventsAdded)
+			if len(res.Remapped) > 0 {
+				var pairs []string
+				for _, sp := range theirs.Specs {
+					if to, ok := res.Remapped[sp.ID]; ok {
+						pairs = append(pairs, fmt.Sprintf("%d->%d", sp.ID, to))
+					}
+				}
+				fmt.Fprintf(w, "Remapped spec IDs: %s\n", strings.Join(pairs, ", "))
+			}
+			fmt.Fprintf(w, "Phase: %s (from %s)\n", strings.ToUpper(string(res.Phase)), res.PhaseFrom)
+			if side == "" {
+				other := merge.Theirs
+				if res.PhaseFrom == merge.Theirs {
+					other = merge.Ours
+				}
+				fmt.Fprintf(w, "Chosen by latest activity. To take %s phase instead, re-run with --phase %s.\n", other, other)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+func init() {
+	mergeCmd.Flags().StringVar(&mergeTheirsFlag, "theirs", "", "path to the other branch's session file")
+	mergeCmd.Flags().StringVar(&mergePhaseFlag, "phase", "", "take phase state from ours or theirs instead of the most recent side")
+	rootCmd.AddCommand(mergeCmd)
+}
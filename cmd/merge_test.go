@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestMergeCombinesSessionFiles(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("ours")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	theirsDir := t.TempDir()
+	theirs := types.NewSession()
+	theirs.AddSpec("theirs")
+	if err := session.Save(theirsDir, theirs); err != nil {
+		t.Fatalf("failed to save theirs: %v", err)
+	}
+	theirsFile := filepath.Join(theirsDir, session.DefaultFileName)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { mergeTheirsFlag = ""; mergePhaseFlag = "" }()
+
+	out, _, err := executePhaseCmd(t, "merge", "--theirs", theirsFile, "--format", "text")
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if !strings.Contains(out, "1 spec(s) added") || !strings.Contains(out, "1->2") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if len(loaded.Specs) != 2 || loaded.Specs[1].ID != 2 || loaded.Specs[1].Description != "theirs" {
+		t.Errorf("expected theirs spec merged as ID 2, got %+v", loaded.Specs)
+	}
+}
+
+func TestMergeRejectsInvalidPhaseSide(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { mergeTheirsFlag = ""; mergePhaseFlag = "" }()
+
+	_, _, err := executePhaseCmd(t, "merge", "--theirs", "x.json", "--phase", "mine", "--format", "text")
+	if err == nil || ExitCode(err) != ExitInvalidInput {
+		t.Errorf("expected invalid input error, got: %v", err)
+	}
+}
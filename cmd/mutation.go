@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/mutation"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var mutationCmd = &cobra.Command{
+	Use:   "mutation",
+	Short: "Run mutation testing during the refactor phase",
+	Long: `Mutation testing checks that tests actually detect behavior changes, not just
+that they stay green. Configure it with 'tdd-ai init --mutation-cmd'.`,
+	Example: `  tdd-ai mutation run
+  tdd-ai mutation run --summary`,
+}
+
+var mutationSummaryFlag bool
+
+var mutationRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the configured mutation command and record the score",
+	Long: `Runs the mutation command configured via 'tdd-ai init --mutation-cmd', parses the
+mutation score from its output, and stores it in the session. The score is read
+from lines labelled "score" or "MSI", whatever the command's exit code, since
+tools exit non-zero when the score is below their own threshold. A command that
+cannot start or prints no score fails the run.
+
+'tdd-ai phase next' out of REFACTOR is blocked until a score has been recorded
+in the phase and it meets the configured threshold; strengthen assertions and
+repeat the run to raise it.`,
+	Example: `  tdd-ai mutation run
+  tdd-ai mutation run --summary`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if s.MutationCmd == "" {
+			return fmt.Errorf("no mutation command configured. Use 'tdd-ai init --mutation-cmd \"your mutation command\"' to set one")
+		}
+		if s.Phase != types.PhaseRefactor {
+			return fmt.Errorf("mutation testing runs during the refactor phase (current: %s)", s.Phase)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Running: %s\n\n", s.MutationCmd)
+
+		parts := strings.Fields(s.MutationCmd)
+		c := exec.Command(parts[0], parts[1:]...)
+		c.Dir = dir
+		output, runErr := c.CombinedOutput()
+
+		if len(output) > 0 {
+			printTestOutput(cmd, string(output), mutationSummaryFlag)
+		}
+		// Tools such as Stryker and Infection exit non-zero when the score is
+		// below their own threshold, so the score counts whatever the exit code.
+		if processExitCode(runErr) == -1 {
+			return fmt.Errorf("mutation command failed to start: %w", runErr)
+		}
+
+		score, ok := mutation.ParseScore(string(output))
+		if !ok {
+			if runErr != nil {
+				return fmt.Errorf("mutation command failed (%v) and printed no mutation score", runErr)
+			}
+			return fmt.Errorf("could not find a mutation score in the command output")
+		}
+
+		s.MutationScore = &score
+		s.AddEvent("mutation_run", func(e *types.Event) {
+			e.Result = fmt.Sprintf("%.1f", score)
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		threshold := s.GetMutationThreshold()
+		fmt.Fprintf(cmd.OutOrStdout(), "\nMutation score: %.1f%% (threshold %.1f%%)\n", score, threshold)
+		if score < threshold {
+			fmt.Fprintln(cmd.OutOrStdout(), "Below threshold: strengthen assertions so surviving mutants are killed, then re-run 'tdd-ai mutation run'.")
+		} else {
+			fmt.Fprintln(cmd.OutOrStdout(), "Next: run 'tdd-ai phase next' once reflections are answered")
+		}
+		return nil
+	},
+}
+
+func init() {
+	addSummaryFlags(mutationRunCmd, &mutationSummaryFlag, "mutation")
+	mutationCmd.AddCommand(mutationRunCmd)
+	rootCmd.AddCommand(mutationCmd)
+}
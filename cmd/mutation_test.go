@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestMutationRunStoresScoreAndBlocksPhaseNext(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.MutationCmd = "echo Mutation score: 60%"
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "mutation", "run", "--format", "text")
+	if err != nil {
+		t.Fatalf("mutation run failed: %v", err)
+	}
+	if !strings.Contains(out, "Mutation score: 60.0% (threshold 80.0%)") {
+		t.Errorf("should report score and threshold, got:\n%s", out)
+	}
+
+	loaded, err := session.Load(dir)
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if loaded.MutationScore == nil || *loaded.MutationScore != 60 {
+		t.Fatalf("MutationScore = %v, want 60", loaded.MutationScore)
+	}
+
+	_, _, err = executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if err == nil {
+		t.Fatal("phase next should be blocked by a low mutation score")
+	}
+	if !strings.Contains(err.Error(), "mutation score") {
+		t.Errorf("error should mention mutation score, got: %v", err)
+	}
+}
+
+func TestMutationRunRequiresRefactorPhase(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.MutationCmd = "echo score: 90%"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, _, err := executePhaseCmd(t, "mutation", "run", "--format", "text")
+	if err == nil {
+		t.Fatal("mutation run should fail outside the refactor phase")
+	}
+	if !strings.Contains(err.Error(), "refactor phase") {
+		t.Errorf("error should mention refactor phase, got: %v", err)
+	}
+}
+
+func TestMutationRunFailsWhenCommandFails(t *testing.T) {
+	for _, command := range []string{"false", "tdd-ai-no-such-mutation-tool"} {
+		t.Run(command, func(t *testing.T) {
+			dir := t.TempDir()
+			s := types.NewSession()
+			s.Phase = types.PhaseRefactor
+			s.MutationCmd = command
+			if err := session.Save(dir, s); err != nil {
+				t.Fatalf("failed to save session: %v", err)
+			}
+
+			origDir, _ := os.Getwd()
+			os.Chdir(dir)
+			defer os.Chdir(origDir)
+
+			_, _, err := executePhaseCmd(t, "mutation", "run", "--format", "text")
+			if err == nil || !strings.Contains(err.Error(), "mutation command failed") {
+				t.Fatalf("mutation run should fail, got: %v", err)
+			}
+			if loaded, _ := session.Load(dir); loaded.MutationScore != nil {
+				t.Errorf("a failed run should not record a score, got %v", *loaded.MutationScore)
+			}
+		})
+	}
+}
+
+func TestMutationRunRecordsScoreFromFailingCommand(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "mutate.sh")
+	if err := os.WriteFile(script, []byte("echo 'Mutation score: 55%'\nexit 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.MutationCmd = "sh " + script
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "mutation", "run", "--format", "text"); err != nil {
+		t.Fatalf("a below-threshold exit should still record the score: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.MutationScore == nil || *loaded.MutationScore != 55 {
+		t.Fatalf("MutationScore = %v, want 55", loaded.MutationScore)
+	}
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "mutation score") {
+		t.Errorf("phase next should be blocked by the low score, got: %v", err)
+	}
+}
+
+func TestPhaseNextRequiresMutationRun(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.MutationCmd = "echo Mutation score: 95%"
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if ExitCode(err) != ExitBlocked || !strings.Contains(err.Error(), "no mutation score recorded") {
+		t.Fatalf("phase next should be blocked until mutation run, got: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "mutation", "run", "--format", "text"); err != nil {
+		t.Fatalf("mutation run failed: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Errorf("phase next should pass after a passing mutation run, got: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var pairCmd = &cobra.Command{
+	Use:   "pair",
+	Short: "Show or manage pair mode, where two agents alternate driver roles (experimental)",
+	Long: `Pair mode assigns two agent identities to alternating roles: the tester may only
+act during RED (writing tests), the implementer only during GREEN (making them pass).
+REFACTOR is open to both.
+
+Agents identify themselves with the TDD_AI_AGENT_ID environment variable. While
+pair mode is on, 'spec pick', 'test', 'test record', 'phase next', and 'complete'
+are rejected for the agent that does not drive the current phase.
+
+This mode is experimental.`,
+	Example: `  tdd-ai pair start alice bob
+  tdd-ai pair
+  tdd-ai pair stop`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		type pairStatusOutput struct {
+			Pair       *types.Pair `json:"pair,omitempty"`
+			Phase      types.Phase `json:"phase"`
+			DriverRole string      `json:"driver_role,omitempty"`
+			Driver     string      `json:"driver,omitempty"`
+		}
+
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		out := pairStatusOutput{Pair: s.Pair, Phase: s.Phase}
+		if s.Pair != nil {
+			out.DriverRole, out.Driver = s.Pair.Driver(s.Phase)
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding pair status: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if s.Pair == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Pair mode is off. Use 'tdd-ai pair start <tester> <implementer>' to enable it.")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Tester: %s\nImplementer: %s\n", s.Pair.Tester, s.Pair.Implementer)
+			if out.Driver != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Driving %s: %s (%s)\n", s.Phase, out.Driver, out.DriverRole)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Driving %s: either agent\n", s.Phase)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var pairStartCmd = &cobra.Command{
+	Use:     "start <tester-id> <implementer-id>",
+	Short:   "Enable pair mode with a tester and an implementer",
+	Long:    "Enable pair mode. The tester drives RED and the implementer drives GREEN. The two agent IDs must differ.",
+	Example: `  tdd-ai pair start alice bob`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tester, implementer := args[0], args[1]
+		if tester == "" || implementer == "" {
+			return invalidInputError(fmt.Errorf("tester and implementer agent IDs cannot be empty"))
+		}
+		if tester == implementer {
+			return invalidInputError(fmt.Errorf("tester and implementer must be different agents"))
+		}
+
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		s.Pair = &types.Pair{Tester: tester, Implementer: implementer}
+		s.AddEvent("pair_start", func(e *types.Event) {
+			e.Result = tester + ", " + implementer
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Pair mode on: %s writes tests (RED), %s implements (GREEN)\n", tester, implementer)
+		return nil
+	},
+}
+
+var pairStopCmd = &cobra.Command{
+	Use:     "stop",
+	Short:   "Disable pair mode",
+	Long:    "Disable pair mode so any agent may act in any phase again.",
+	Example: `  tdd-ai pair stop`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if s.Pair == nil {
+			return fmt.Errorf("pair mode is not on")
+		}
+
+		s.Pair = nil
+		s.AddEvent("pair_stop")
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "Pair mode off")
+		return nil
+	},
+}
+
+// checkPairRole returns an error when pair mode is on and the current agent does
+// not drive the current phase.
+func checkPairRole(s *types.Session) error {
+	if err := s.CheckPairRole(types.CurrentAgentID()); err != nil {
+		return blockedError(err)
+	}
+	return nil
+}
+
+func init() {
+	pairCmd.AddCommand(pairStartCmd)
+	pairCmd.AddCommand(pairStopCmd)
+	rootCmd.AddCommand(pairCmd)
+}
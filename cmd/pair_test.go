@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestPairStartAndStop(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "pair", "start", "alice", "alice", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("same tester and implementer should be invalid input, got: %v", err)
+	}
+
+	if _, _, err := executePhaseCmd(t, "pair", "start", "alice", "bob", "--format", "text"); err != nil {
+		t.Fatalf("pair start failed: %v", err)
+	}
+	out, _, err := executePhaseCmd(t, "pair", "--format", "text")
+	if err != nil {
+		t.Fatalf("pair failed: %v", err)
+	}
+	if !strings.Contains(out, "Driving red: alice (tester)") {
+		t.Errorf("should show the tester driving RED, got:\n%s", out)
+	}
+
+	if _, _, err := executePhaseCmd(t, "pair", "stop", "--format", "text"); err != nil {
+		t.Fatalf("pair stop failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.Pair != nil {
+		t.Error("pair stop should clear pair mode")
+	}
+}
+
+func TestPairModeRejectsWrongRole(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.Pair = &types.Pair{Tester: "alice", Implementer: "bob"}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	t.Setenv(types.AgentIDEnv, "bob")
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text")
+	if err == nil {
+		t.Fatal("the implementer should not be able to leave RED")
+	}
+	if !strings.Contains(err.Error(), `belongs to the tester "alice"`) || ExitCode(err) != ExitBlocked {
+		t.Errorf("error should name the tester and be blocked, got: %v (exit %d)", err, ExitCode(err))
+	}
+
+	t.Setenv(types.AgentIDEnv, "alice")
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text"); err != nil {
+		t.Fatalf("the tester should be able to leave RED: %v", err)
+	}
+
+	if _, _, err := executePhaseCmd(t, "test", "record", "pass", "--format", "text"); err == nil {
+		t.Error("the tester should not record results during GREEN")
+	}
+}
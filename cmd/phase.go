@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/macosta/tdd-ai/internal/formatter"
 	"github.com/macosta/tdd-ai/internal/phase"
+	"github.com/macosta/tdd-ai/internal/policy"
 	"github.com/macosta/tdd-ai/internal/reflection"
 	"github.com/macosta/tdd-ai/internal/session"
 	"github.com/macosta/tdd-ai/internal/types"
@@ -24,21 +27,45 @@ var phaseCmd = &cobra.Command{
 			return err
 		}
 
+		if formatter.Format(formatFlag) == formatter.FormatPorcelain {
+			fmt.Fprint(cmd.OutOrStdout(), formatter.PorcelainPhase(s))
+			return nil
+		}
 		fmt.Fprintln(cmd.OutOrStdout(), s.Phase)
 		return nil
 	},
 }
 
-var testResultFlag string
+var (
+	testResultFlag       string
+	phaseNextForceFlag   bool
+	phaseNextJustifyFlag string
+	phaseNextWaiveFlag   string
+)
 
 var phaseNextCmd = &cobra.Command{
 	Use:   "next",
 	Short: "Advance to the next TDD phase",
 	Long: `Advance to the next phase in the TDD cycle: red -> green -> refactor -> done.
 
-Use --test-result to validate that tests are in the expected state before advancing.`,
+Use --test-result to validate that tests are in the expected state before advancing.
+
+Leaving RED is blocked when the last test run reports no more tests than existed
+when the spec was picked. Use --force to override when the count is misleading.
+
+Leaving GREEN or REFACTOR is blocked when tests disappeared between runs, which
+usually means failing tests were deleted to get green. Use --justify to record
+why the tests were removed (for example, merged into a table-driven test).
+
+Leaving RED snapshots the test files; leaving GREEN is blocked if any of them
+were edited since (see 'tdd-ai diff-guard').
+
+Leaving REFACTOR is blocked while the current spec has unchecked acceptance
+criteria. Check them off with 'tdd-ai spec criteria check', or use --waive to
+complete the spec anyway, recording why.`,
 	Example: `  tdd-ai phase next
-  tdd-ai phase next --test-result fail`,
+  tdd-ai phase next --test-result fail
+  tdd-ai phase next --justify "merged duplicate cases into one table test"`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
@@ -46,18 +73,60 @@ Use --test-result to validate that tests are in the expected state before advanc
 			return err
 		}
 
+		if err := checkLease(s); err != nil {
+			return err
+		}
+		if err := checkPairRole(s); err != nil {
+			return err
+		}
+
 		current := s.Phase
+
+		// Record blocked attempts so guide/resume can detect an agent thrashing
+		blocked := func(err error) error {
+			s.AddEvent("phase_next_blocked", func(e *types.Event) {
+				e.From = string(current)
+				e.Result = err.Error()
+			})
+			if saveErr := session.Save(dir, s); saveErr != nil {
+				return saveErr
+			}
+			return blockedError(err)
+		}
+
+		// Overrides and justifications take effect only once every check has
+		// passed, so a blocked attempt saves none of them
+		var overrides []func()
+
 		if current == types.PhaseRed && len(s.ActiveSpecs()) == 0 {
-			return fmt.Errorf("cannot advance: no active specs")
+			return blocked(fmt.Errorf("cannot advance: no active specs"))
 		}
 
 		// Require a spec to be picked before leaving RED
 		if current == types.PhaseRed && s.CurrentSpecID == nil {
-			return fmt.Errorf("cannot advance: no spec selected")
+			return blocked(fmt.Errorf("cannot advance: no spec selected"))
+		}
+
+		// Hard-block while files the project declared off-limits are modified
+		if touched := modifiedProtectedPaths(dir, s); len(touched) > 0 {
+			return blocked(fmt.Errorf("cannot advance: protected path(s) modified: %s. Revert these changes; protected paths must not be edited during the TDD cycle", strings.Join(touched, ", ")))
+		}
+
+		// Hard-block leaving GREEN when the tests frozen at RED exit were edited
+		if current == types.PhaseGreen {
+			if err := checkTestFiles(dir, s); err != nil {
+				return err
+			}
+			if len(s.TestFilesEdited) > 0 {
+				s.AddEvent("test_files_edited", func(e *types.Event) {
+					e.Files = s.TestFilesEdited
+				})
+				return blocked(fmt.Errorf("cannot advance: test files edited during GREEN: %s. Revert them; GREEN must make the RED tests pass without changing them", strings.Join(s.TestFilesEdited, ", ")))
+			}
 		}
 
 		mode := s.GetMode()
-		expected := phase.ExpectedTestResult(current, mode)
+		expected := phase.ExpectedTestResultFor(s, current)
 
 		// Determine the test result: explicit flag > session's last_test_result > warning
 		effectiveResult := testResultFlag
@@ -68,37 +137,140 @@ Use --test-result to validate that tests are in the expected state before advanc
 
 		if effectiveResult != "" {
 			if effectiveResult == "error" {
-				return fmt.Errorf("cannot advance: last test run was an infrastructure/environment error (not a test failure). Fix the environment and re-run 'tdd-ai test'")
+				return blocked(testInfraError(fmt.Errorf("cannot advance: last test run was an infrastructure/environment error (not a test failure). Fix the environment and re-run 'tdd-ai test'")))
 			}
 			if effectiveResult != "pass" && effectiveResult != "fail" {
-				return fmt.Errorf("--test-result must be 'pass' or 'fail', got %q", effectiveResult)
+				return invalidInputError(fmt.Errorf("--test-result must be 'pass' or 'fail', got %q", effectiveResult))
 			}
-			if effectiveResult != expected {
-				return fmt.Errorf("cannot advance: %s phase expects tests to %s, but got test result %s", current, expected, effectiveResult)
+			if !phase.ResultMatches(expected, effectiveResult) {
+				return blocked(fmt.Errorf("cannot advance: %s phase expects tests to %s, but got test result %s", current, expected, effectiveResult))
 			}
-		} else {
+		} else if expected != phase.ResultAny {
 			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: advancing without test result. The %s phase expects tests to %s.\n", current, expected)
 		}
 
+		// Block until every suite required for this phase has passed during it
+		if missing := s.MissingSuites(current); len(missing) > 0 {
+			return blocked(fmt.Errorf("cannot advance: %s phase requires test suite(s) %s to pass. Run 'tdd-ai test --suite %s'", current, strings.Join(missing, ", "), missing[0]))
+		}
+
+		// Block leaving RED when the test run shows no new tests since the spec was picked
+		if current == types.PhaseRed && s.NoNewTests() {
+			if !phaseNextForceFlag {
+				return blocked(fmt.Errorf("cannot advance: no new tests detected for this spec (%d test(s) before, %d now). Write a test for the spec, or use --force if the count is misleading", *s.BaselineTestCount, *s.LastTestCount))
+			}
+			if err := checkForceAllowed(policy.ForcePhaseNext); err != nil {
+				return blocked(err)
+			}
+			overrides = append(overrides, func() {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Warning: advancing with no new tests detected (--force)")
+				s.AddEvent("no_new_tests_override", func(e *types.Event) {
+					e.SpecID = *s.CurrentSpecID
+				})
+			})
+		}
+
+		// Warn, or block when the project asks, when no test written in RED names its spec
+		if current == types.PhaseRed {
+			if b := phase.TestNamingBlocker(s); b != "" {
+				switch {
+				case s.TestNaming.Mode != types.TestNamingBlock:
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", b)
+				case !phaseNextForceFlag:
+					return blocked(fmt.Errorf("cannot advance: %s%s, or use --force", strings.ToLower(b[:1]), b[1:]))
+				default:
+					if err := checkForceAllowed(policy.ForcePhaseNext); err != nil {
+						return blocked(err)
+					}
+					overrides = append(overrides, func() {
+						fmt.Fprintf(cmd.ErrOrStderr(), "Warning: advancing with unreferenced new tests (--force): %s\n", b)
+						s.AddEvent("test_naming_override", func(e *types.Event) {
+							e.SpecID = *s.CurrentSpecID
+						})
+					})
+				}
+			}
+		}
+
+		// Block leaving GREEN/REFACTOR when tests vanished between runs, unless justified
+		if s.DisappearedTests > 0 {
+			if strings.TrimSpace(phaseNextJustifyFlag) == "" {
+				return blocked(fmt.Errorf("cannot advance: %d tests disappeared since last run. Restore them, or explain the removal with --justify <reason>", s.DisappearedTests))
+			}
+			overrides = append(overrides, func() {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: advancing with %d removed test(s) (--justify)\n", s.DisappearedTests)
+				removed := s.JustifyDisappearedTests()
+				s.AddEvent("tests_removed_justified", func(e *types.Event) {
+					e.From = string(current)
+					e.Result = fmt.Sprintf("%d", removed)
+					e.Reason = phaseNextJustifyFlag
+				})
+			})
+		}
+
+		// Block starting another pass on a spec that has used up its iterations
+		if current == types.PhaseRed {
+			if over := s.IterationLimitReached(); len(over) > 0 {
+				return blocked(fmt.Errorf("cannot advance: spec %d reached the iteration limit (%d/%d). Split it with 'tdd-ai spec split %d'", over[0].ID, over[0].Iterations, s.MaxIterationsPerSpec, over[0].ID))
+			}
+		}
+
 		// Block advancing from refactor when reflection questions are unanswered
 		if current == types.PhaseRefactor && len(s.Reflections) > 0 && !s.AllReflectionsAnswered() {
 			pending := s.PendingReflections()
-			return fmt.Errorf("cannot advance: %d reflection question(s) unanswered", len(pending))
+			return blocked(fmt.Errorf("cannot advance: %d reflection question(s) unanswered", len(pending)))
+		}
+		if current == types.PhaseRefactor {
+			if err := checkReflectionDebt(s); err != nil {
+				return blocked(fmt.Errorf("cannot advance: %w", err))
+			}
+		}
+
+		// Block advancing from refactor until a mutation run scores at or above the threshold
+		if current == types.PhaseRefactor && s.MutationCmd != "" && s.MutationScore == nil {
+			return blocked(fmt.Errorf("cannot advance: no mutation score recorded in this refactor phase. Run 'tdd-ai mutation run'"))
+		}
+		if current == types.PhaseRefactor && s.MutationScore != nil && *s.MutationScore < s.GetMutationThreshold() {
+			return blocked(fmt.Errorf("cannot advance: mutation score %.1f%% is below threshold %.1f%%. Strengthen assertions and re-run 'tdd-ai mutation run'", *s.MutationScore, s.GetMutationThreshold()))
+		}
+
+		// Block leaving refactor on a high-risk spec until its extra rules are met
+		if current == types.PhaseRefactor {
+			if b := phase.HighRiskBlockers(s, s.CurrentSpecIDs()); len(b) > 0 {
+				return blocked(fmt.Errorf("cannot advance: %s", strings.Join(b, "; ")))
+			}
+		}
+
+		// Block auto-completing a spec whose acceptance criteria are not signed off
+		if current == types.PhaseRefactor && s.CurrentSpecID != nil {
+			if err := checkCriteriaSignOff(s, s.CurrentSpecIDs(), phaseNextWaiveFlag); err != nil {
+				return blocked(err)
+			}
+		}
+
+		for _, apply := range overrides {
+			apply()
 		}
 
 		// Auto-complete current spec when leaving refactor
 		if current == types.PhaseRefactor && s.CurrentSpecID != nil {
-			completedID := *s.CurrentSpecID
+			completedIDs := s.CurrentSpecIDs()
 			if err := s.CompleteCurrentSpec(); err != nil {
 				return fmt.Errorf("completing current spec: %w", err)
 			}
 			s.Iteration++
-			fmt.Fprintf(cmd.OutOrStdout(), "Completed spec [%d], iteration %d done\n", completedID, s.Iteration)
+			if len(completedIDs) > 1 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Completed specs %v, iteration %d done\n", completedIDs, s.Iteration)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Completed spec [%d], iteration %d done\n", completedIDs[0], s.Iteration)
+			}
 		}
 
 		// Clear last test result after consuming it
 		if s.LastTestResult != "" {
 			s.LastTestResult = ""
+			s.LastFailureCategory = ""
+			s.LastTestOutput = nil
 		}
 
 		// Use loop-aware transition from refactor
@@ -108,26 +280,45 @@ Use --test-result to validate that tests are in the expected state before advanc
 			return err
 		}
 
-		s.Phase = next
+		if current == types.PhaseRed {
+			s.StartIteration()
+		}
+		// Freeze the tests written in RED so GREEN can be checked against them
+		if next == types.PhaseGreen {
+			if err := snapshotTestFiles(dir, s); err != nil {
+				return err
+			}
+		}
+		s.SetPhase(next)
+		s.SuiteResults = nil
 		if next == types.PhaseRefactor {
-			s.Reflections = reflection.DefaultQuestions()
+			s.Reflections = activePolicy.Questions(reflection.Questions(s.ReflectionSet))
+			s.MutationScore = nil
 		}
 		// Clear current spec when entering RED via loop (agent must pick next)
 		if next == types.PhaseRed {
-			s.CurrentSpecID = nil
+			s.ClearCurrentSpec()
 		}
+		files := filesChangedInPhase(dir, s)
 		s.AddEvent("phase_next", func(e *types.Event) {
 			e.From = string(current)
 			e.To = string(next)
+			e.Files = files
 			if effectiveResult != "" {
 				e.Result = effectiveResult
 			}
 		})
+		if next == types.PhaseDone {
+			recordSummary(s)
+		}
 		if err := session.Save(dir, s); err != nil {
 			return err
 		}
 
 		fmt.Fprintf(cmd.OutOrStdout(), "Phase: %s -> %s\n", current, next)
+		if next == types.PhaseDone {
+			writeSummary(cmd.OutOrStdout(), s)
+		}
 
 		if next == types.PhaseRed && hasRemaining {
 			remaining := s.ActiveSpecs()
@@ -140,16 +331,22 @@ Use --test-result to validate that tests are in the expected state before advanc
 	},
 }
 
-var phaseSetForceFlag bool
+var (
+	phaseSetForceFlag  bool
+	phaseSetReasonFlag string
+)
 
 var phaseSetCmd = &cobra.Command{
 	Use:   "set <red|green|refactor|done>",
 	Short: "Manually set the TDD phase (requires --force)",
-	Long: `Override the current phase. Requires --force because this bypasses TDD guardrails.
-Prefer 'tdd-ai phase next' for normal phase advancement.`,
-	Example: `  tdd-ai phase set red --force
-  tdd-ai phase set green --force
-  tdd-ai phase set refactor --force`,
+	Long: `Override the current phase. Requires --force because this bypasses TDD guardrails,
+and --reason to record why. Prefer 'tdd-ai phase next' for normal phase advancement.
+
+The guardrails 'phase next' would have enforced are printed and recorded with
+the override, and status and verify report the session's overrides_count so
+overrides stand out during review.`,
+	Example: `  tdd-ai phase set red --force --reason "restarting the spec after a wrong pick"
+  tdd-ai phase set refactor --force --reason "test runner is down; verified manually"`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir := getWorkDir()
@@ -160,43 +357,104 @@ Prefer 'tdd-ai phase next' for normal phase advancement.`,
 
 		p := types.Phase(args[0])
 		if !p.IsValid() {
-			return fmt.Errorf("invalid phase %q. Valid phases: red, green, refactor, done", args[0])
+			return invalidInputError(fmt.Errorf("invalid phase %q. Valid phases: red, green, refactor, done", args[0]))
 		}
 
 		if s.AgentMode {
-			return fmt.Errorf("phase set is disabled in agent mode. Use 'tdd-ai phase next' for phase advancement")
+			return blockedError(fmt.Errorf("phase set is disabled in agent mode. Use 'tdd-ai phase next' for phase advancement"))
 		}
 
 		if !phaseSetForceFlag {
-			return fmt.Errorf("phase set bypasses TDD guardrails; use --force to override, or prefer 'tdd-ai phase next'")
+			return blockedError(fmt.Errorf("phase set bypasses TDD guardrails; use --force to override, or prefer 'tdd-ai phase next'"))
+		}
+		if err := checkForceAllowed(policy.ForcePhaseSet); err != nil {
+			return err
+		}
+		reason := strings.TrimSpace(phaseSetReasonFlag)
+		if reason == "" {
+			return invalidInputError(fmt.Errorf("phase set requires --reason explaining why the guardrails are bypassed"))
+		}
+
+		if err := checkLease(s); err != nil {
+			return err
 		}
 
 		old := s.Phase
-		s.Phase = p
+		bypassed := bypassedGuardrails(dir, s, p)
+		s.SetPhase(p)
+		s.SuiteResults = nil
 		if p == types.PhaseRefactor && len(s.Reflections) == 0 {
-			s.Reflections = reflection.DefaultQuestions()
+			s.Reflections = activePolicy.Questions(reflection.Questions(s.ReflectionSet))
 		}
 		if p == types.PhaseRed {
-			s.CurrentSpecID = nil
+			s.ClearCurrentSpec()
 		}
+		files := filesChangedInPhase(dir, s)
 		s.AddEvent("phase_set", func(e *types.Event) {
 			e.From = string(old)
 			e.To = string(p)
+			e.Files = files
 			e.Result = "forced_override"
+			e.Reason = reason
+			e.Override = true
+			e.Bypassed = bypassed
 		})
+		if p == types.PhaseDone {
+			recordSummary(s)
+		}
 		if err := session.Save(dir, s); err != nil {
 			return err
 		}
 
 		fmt.Fprintf(cmd.OutOrStdout(), "Phase set to: %s\n", p)
+		if len(bypassed) > 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: bypassed guardrail(s): %s\n", strings.Join(bypassed, ", "))
+		} else {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Warning: phase changed outside 'phase next'; recorded as an override")
+		}
+		if p == types.PhaseDone {
+			writeSummary(cmd.OutOrStdout(), s)
+		}
 		return nil
 	},
 }
 
 func init() {
 	phaseNextCmd.Flags().StringVar(&testResultFlag, "test-result", "", "test outcome: 'pass' or 'fail'")
+	phaseNextCmd.Flags().BoolVar(&phaseNextForceFlag, "force", false, "advance from RED even when no new tests were detected")
+	phaseNextCmd.Flags().StringVar(&phaseNextWaiveFlag, "waive", "", "complete the current spec with unchecked acceptance criteria, recording this reason")
+	phaseNextCmd.Flags().StringVar(&phaseNextJustifyFlag, "justify", "", "reason tests disappeared since the last run (required to advance after a drop)")
 	phaseSetCmd.Flags().BoolVar(&phaseSetForceFlag, "force", false, "override TDD guardrails and force phase change")
+	phaseSetCmd.Flags().StringVar(&phaseSetReasonFlag, "reason", "", "why the guardrails are bypassed (required, recorded in the history)")
 	phaseCmd.AddCommand(phaseNextCmd)
 	phaseCmd.AddCommand(phaseSetCmd)
 	rootCmd.AddCommand(phaseCmd)
 }
+
+// bypassedGuardrails lists the checks 'phase next' would have failed, or that a
+// move to target skips, had the phase not been set by hand.
+func bypassedGuardrails(dir string, s *types.Session, target types.Phase) []string {
+	current := s.Phase
+	var bypassed []string
+	add := func(failed bool, name string) {
+		if failed {
+			bypassed = append(bypassed, name)
+		}
+	}
+	if next, err := phase.NextInLoop(current, s.GetMode(), len(s.RemainingSpecs()) > 0); err != nil || next != target {
+		bypassed = append(bypassed, fmt.Sprintf("phase order (%s -> %s)", current, target))
+	}
+	add(current == types.PhaseRed && s.CurrentSpecID == nil, "spec selected")
+	add(len(modifiedProtectedPaths(dir, s)) > 0, "protected paths")
+	add(current == types.PhaseGreen && len(s.TestFilesEdited) > 0, "test files unchanged in green")
+	add(s.LastTestResult != "" && !phase.ResultMatches(phase.ExpectedTestResultFor(s, current), s.LastTestResult), "test result")
+	add(len(s.MissingSuites(current)) > 0, "required suites")
+	add(current == types.PhaseRed && s.NoNewTests(), "new tests")
+	add(current == types.PhaseRed && s.TestNaming != nil && s.TestNaming.Mode == types.TestNamingBlock && phase.TestNamingBlocker(s) != "", "test names reference spec")
+	add(s.DisappearedTests > 0, "disappeared tests")
+	add(current == types.PhaseRefactor && !s.AllReflectionsAnswered(), "reflections")
+	add(current == types.PhaseRefactor && checkReflectionDebt(s) != nil, "reflection debt")
+	add(current == types.PhaseRefactor && phase.MutationBlocker(s) != "", "mutation threshold")
+	add(current == types.PhaseRefactor && len(phase.HighRiskBlockers(s, s.CurrentSpecIDs())) > 0, "high-risk rules")
+	return bypassed
+}
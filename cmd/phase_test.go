@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -173,6 +175,40 @@ func TestPhaseNextFromRefactorLoopsBackToRedWhenSpecsRemain(t *testing.T) {
 	}
 }
 
+func TestPhaseNextFromRefactorAfterGroupMemberDone(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.AddSpec("first")
+	s.AddSpec("second")
+	s.AddSpec("third")
+	_ = s.SetPickGroup([]int{1, 2})
+	s.Reflections = reflection.DefaultQuestions()
+	for i := range s.Reflections {
+		s.Reflections[i].Answer = "This reflection is answered with enough words"
+	}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testResultFlag = "" }()
+
+	if _, _, err := executePhaseCmd(t, "spec", "done", "2", "--format", "text"); err != nil {
+		t.Fatalf("spec done failed: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Fatalf("phase next after finishing a pick group member failed: %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.Phase != types.PhaseRed || loaded.Specs[0].Status != types.SpecStatusCompleted {
+		t.Errorf("phase = %s, spec 1 = %s; want red with spec 1 completed", loaded.Phase, loaded.Specs[0].Status)
+	}
+}
+
 func TestPhaseNextFromRefactorGoesToDoneWhenNoSpecsRemain(t *testing.T) {
 	dir := t.TempDir()
 	s := types.NewSession()
@@ -271,7 +307,7 @@ func TestPhaseSetToRefactorLoadsReflections(t *testing.T) {
 	os.Chdir(dir)
 	defer os.Chdir(origDir)
 
-	_, _, err := executePhaseCmd(t, "phase", "set", "refactor", "--force", "--format", "text")
+	_, _, err := executePhaseCmd(t, "phase", "set", "refactor", "--force", "--reason", "resuming refactor", "--format", "text")
 	if err != nil {
 		t.Fatalf("phase set refactor failed: %v", err)
 	}
@@ -320,10 +356,13 @@ func TestPhaseSetWithForceSucceedsAndLogsForcedOverride(t *testing.T) {
 	os.Chdir(dir)
 	defer os.Chdir(origDir)
 
-	out, _, err := executePhaseCmd(t, "phase", "set", "green", "--force", "--format", "text")
+	out, errOut, err := executePhaseCmd(t, "phase", "set", "green", "--force", "--reason", "runner is down", "--format", "text")
 	if err != nil {
 		t.Fatalf("phase set --force should succeed: %v", err)
 	}
+	if !strings.Contains(errOut, "bypassed guardrail(s): spec selected") {
+		t.Errorf("should print the bypassed guardrails, got:\n%s", errOut)
+	}
 	if !strings.Contains(out, "Phase set to: green") {
 		t.Errorf("should confirm phase change, got:\n%s", out)
 	}
@@ -340,12 +379,39 @@ func TestPhaseSetWithForceSucceedsAndLogsForcedOverride(t *testing.T) {
 	for _, ev := range loaded.History {
 		if ev.Action == "phase_set" && ev.Result == "forced_override" {
 			found = true
+			if !ev.Override || ev.Reason != "runner is down" || len(ev.Bypassed) == 0 {
+				t.Errorf("phase_set event should be an override with reason and bypassed guardrails, got %+v", ev)
+			}
 			break
 		}
 	}
 	if !found {
 		t.Error("should log phase_set event with Result=forced_override")
 	}
+	if loaded.OverridesCount() != 1 {
+		t.Errorf("OverridesCount() = %d, want 1", loaded.OverridesCount())
+	}
+}
+
+func TestPhaseSetRequiresReason(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { phaseSetForceFlag = false }()
+
+	phaseSetReasonFlag = ""
+	_, _, err := executePhaseCmd(t, "phase", "set", "green", "--force", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput || !strings.Contains(err.Error(), "--reason") {
+		t.Fatalf("phase set without --reason should be rejected as invalid input, got: %v", err)
+	}
+	if loaded, _ := session.Load(dir); loaded.Phase != types.PhaseRed {
+		t.Errorf("phase should stay red, got %s", loaded.Phase)
+	}
 }
 
 func TestPhaseSetDisabledInAgentMode(t *testing.T) {
@@ -385,7 +451,7 @@ func TestPhaseSetToRefactorDoesNotOverwriteExisting(t *testing.T) {
 	os.Chdir(dir)
 	defer os.Chdir(origDir)
 
-	_, _, err := executePhaseCmd(t, "phase", "set", "refactor", "--force", "--format", "text")
+	_, _, err := executePhaseCmd(t, "phase", "set", "refactor", "--force", "--reason", "resuming refactor", "--format", "text")
 	if err != nil {
 		t.Fatalf("phase set refactor failed: %v", err)
 	}
@@ -398,3 +464,355 @@ func TestPhaseSetToRefactorDoesNotOverwriteExisting(t *testing.T) {
 		t.Error("should not overwrite existing reflections")
 	}
 }
+
+func TestPhaseNextBlockedWhenNoNewTests(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.LastTestResult = "fail"
+	before, after := 3, 3
+	s.BaselineTestCount = &before
+	s.LastTestCount = &after
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { phaseNextForceFlag = false }()
+
+	phaseNextForceFlag = false
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text")
+	if err == nil {
+		t.Fatal("phase next should be blocked when no new tests were detected")
+	}
+	if !strings.Contains(err.Error(), "no new tests detected") {
+		t.Errorf("error should mention missing new tests, got: %v", err)
+	}
+
+	_, errOut, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--force", "--format", "text")
+	if err != nil {
+		t.Fatalf("phase next --force should override the guard: %v", err)
+	}
+	if !strings.Contains(errOut, "no new tests detected") {
+		t.Errorf("should warn when overriding, got:\n%s", errOut)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.Phase != types.PhaseGreen {
+		t.Errorf("phase = %s, want green", loaded.Phase)
+	}
+}
+
+func TestPhaseNextChecksTestNaming(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("login returns 404")
+	_ = s.SetCurrentSpec(1)
+	s.TestNaming = &types.TestNamingRule{Mode: types.TestNamingBlock}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { phaseNextForceFlag = false }()
+	phaseNextForceFlag = false
+
+	output := filepath.Join(t.TempDir(), "out.txt")
+	record := func(name string) {
+		t.Helper()
+		os.WriteFile(output, []byte("--- FAIL: "+name+" (0.00s)\nFAIL\n"), 0644)
+		if _, _, err := executePhaseCmd(t, "test", "record", "fail", "--output-file", output); err != nil {
+			t.Fatalf("test record failed: %v", err)
+		}
+	}
+
+	record("TestLoginMissingUser")
+	_, _, err := executePhaseCmd(t, "phase", "next", "--format", "text")
+	if ExitCode(err) != ExitBlocked || !strings.Contains(err.Error(), "new test(s) TestLoginMissingUser do not reference spec 1 (SPEC-login-404)") {
+		t.Fatalf("phase next should be blocked by unreferenced tests, got: %v", err)
+	}
+
+	s, _ = session.Load(dir)
+	s.TestNaming.Mode = types.TestNamingWarn
+	session.Save(dir, s)
+	_, errOut, err := executePhaseCmd(t, "phase", "next", "--format", "text")
+	if err != nil {
+		t.Fatalf("warn mode should not block: %v", err)
+	}
+	if !strings.Contains(errOut, "Warning: New test(s) TestLoginMissingUser") {
+		t.Errorf("warn mode should report the tests, got:\n%s", errOut)
+	}
+
+	s, _ = session.Load(dir)
+	s.SetPhase(types.PhaseRed)
+	s.TestNaming.Mode = types.TestNamingBlock
+	session.Save(dir, s)
+	record("TestLoginMissingUser")
+	record("TestSpecLogin404MissingUser")
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--format", "text"); err != nil {
+		t.Fatalf("a test named after the spec should satisfy the rule: %v", err)
+	}
+}
+
+func TestPhaseNextBlockedWhenTestsDisappeared(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.LastTestResult = "pass"
+	s.DisappearedTests = 2
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { phaseNextJustifyFlag = "" }()
+
+	phaseNextJustifyFlag = ""
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "2 tests disappeared since last run") {
+		t.Fatalf("phase next should be blocked by disappeared tests, got: %v", err)
+	}
+
+	_, errOut, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--justify", "merged into table test", "--format", "text")
+	if err != nil {
+		t.Fatalf("phase next --justify should advance: %v", err)
+	}
+	if !strings.Contains(errOut, "2 removed test(s)") {
+		t.Errorf("should warn when justifying, got:\n%s", errOut)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.Phase != types.PhaseRefactor || loaded.DisappearedTests != 0 {
+		t.Errorf("phase = %s, disappeared = %d; want refactor, 0", loaded.Phase, loaded.DisappearedTests)
+	}
+	var justified *types.Event
+	for i := range loaded.History {
+		if loaded.History[i].Action == "tests_removed_justified" {
+			justified = &loaded.History[i]
+		}
+	}
+	if justified == nil || justified.Reason != "merged into table test" {
+		t.Errorf("history should record the justification, got %+v", loaded.History)
+	}
+}
+
+func TestPhaseNextBlockedAttemptKeepsJustification(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.Reflections = reflection.DefaultQuestions()
+	s.TestCounts = map[string]int{"": 3}
+	s.TestDrops = map[string]int{"": 1}
+	s.DisappearedTests = 1
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { phaseNextJustifyFlag = "" }()
+
+	_, errOut, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--justify", "merged into table test", "--format", "text")
+	if ExitCode(err) != ExitBlocked {
+		t.Fatalf("unanswered reflections should block, got %v", err)
+	}
+	if strings.Contains(errOut, "removed test(s)") {
+		t.Errorf("a blocked attempt should not report the justification, got:\n%s", errOut)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.DisappearedTests != 1 {
+		t.Errorf("DisappearedTests = %d, want the drop kept after a blocked attempt", loaded.DisappearedTests)
+	}
+	for _, ev := range loaded.History {
+		if ev.Action == "tests_removed_justified" {
+			t.Errorf("a blocked attempt should not record the justification, got %+v", ev)
+		}
+	}
+}
+
+func TestPhaseNextIgnoresSmallerSuiteRuns(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.TestCmds = map[string]string{"unit": "go test -short ./..."}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer resetFlags(testRecordCmd.Flags())
+	record := func(suite string, tests ...string) {
+		t.Helper()
+		resetFlags(testRecordCmd.Flags())
+		var out strings.Builder
+		for _, name := range tests {
+			fmt.Fprintf(&out, "=== RUN   %s\n--- PASS: %s (0.00s)\n", name, name)
+		}
+		if err := os.WriteFile("out.txt", []byte(out.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+		args := []string{"test", "record", "pass", "--output-file", "out.txt", "--format", "text"}
+		if suite != "" {
+			args = append(args, "--suite", suite)
+		}
+		if _, _, err := executePhaseCmd(t, args...); err != nil {
+			t.Fatalf("test record failed: %v", err)
+		}
+	}
+
+	record("", "TestA", "TestB")
+	record("unit", "TestA")
+	record("", "TestA", "TestB")
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--format", "text"); err != nil {
+		t.Fatalf("a smaller suite run between full runs should not block, got %v", err)
+	}
+}
+
+func TestPhaseNextRecordsBlockedAttempt(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--format", "text"); err == nil {
+		t.Fatal("phase next should be blocked without a picked spec")
+	}
+
+	loaded, _ := session.Load(dir)
+	last := loaded.History[len(loaded.History)-1]
+	if last.Action != "phase_next_blocked" || last.From != "red" || !strings.Contains(last.Result, "no spec selected") {
+		t.Errorf("should record blocked attempt, got %+v", last)
+	}
+}
+
+func TestPhaseNextCountsSpecIterations(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.MaxIterationsPerSpec = 2
+	s.Specs[0].Iterations = 1
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text"); err != nil {
+		t.Fatalf("phase next failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.Specs[0].Iterations != 2 {
+		t.Errorf("Iterations = %d, want 2 after leaving red", loaded.Specs[0].Iterations)
+	}
+
+	loaded.Phase = types.PhaseRed
+	if err := session.Save(dir, loaded); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text")
+	if err == nil {
+		t.Fatal("phase next should be blocked once the spec reaches its iteration limit")
+	}
+	if !strings.Contains(err.Error(), "iteration limit (2/2)") || !strings.Contains(err.Error(), "spec split 1") {
+		t.Errorf("error should suggest splitting the spec, got: %v", err)
+	}
+	if ExitCode(err) != ExitBlocked {
+		t.Errorf("ExitCode = %d, want %d", ExitCode(err), ExitBlocked)
+	}
+}
+
+func TestPhaseNextHonorsTestPolicy(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.Phase = types.PhaseGreen
+	s.TestPolicy = map[string]string{"green": "any"}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text"); err != nil {
+		t.Fatalf("phase next should accept any result under the policy: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.Phase != types.PhaseRefactor {
+		t.Errorf("phase = %s, want refactor", loaded.Phase)
+	}
+}
+
+func TestPhaseNextBlocksTestEditsDuringGreen(t *testing.T) {
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "calc_test.go")
+	if err := os.WriteFile(testFile, []byte("package calc // red"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	s := types.NewSession()
+	s.AddSpec("adds numbers")
+	_ = s.SetCurrentSpec(1)
+	s.LastTestResult = "fail"
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text"); err != nil {
+		t.Fatalf("leaving RED failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if len(loaded.TestFileHashes) != 1 {
+		t.Fatalf("leaving RED should snapshot the test file, got %v", loaded.TestFileHashes)
+	}
+
+	if err := os.WriteFile(testFile, []byte("package calc // weakened"), 0644); err != nil {
+		t.Fatalf("failed to edit test file: %v", err)
+	}
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if ExitCode(err) != ExitBlocked || !strings.Contains(err.Error(), "calc_test.go") {
+		t.Fatalf("leaving GREEN after editing a test should be blocked, got: %v", err)
+	}
+
+	out, _, _ := executePhaseCmd(t, "blockers", "--format", "text")
+	if !strings.Contains(out, "Test files edited during GREEN: calc_test.go") {
+		t.Errorf("blockers should report the edited test, got:\n%s", out)
+	}
+
+	if err := os.WriteFile(testFile, []byte("package calc // red"), 0644); err != nil {
+		t.Fatalf("failed to revert test file: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Errorf("leaving GREEN after reverting should succeed: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// gitHead returns the commit checked out in dir, or "" outside a git
+// repository or before the first commit.
+func gitHead(dir string) string {
+	c := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD")
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// fileHash returns the content hash of a file relative to dir; a missing file
+// hashes to "".
+func fileHash(dir, path string) string {
+	data, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// takeFileSnapshot records the commit checked out in dir and the hashes of the
+// files that differ from it. Returns nil outside a git repository.
+func takeFileSnapshot(dir string) *types.FileSnapshot {
+	head := gitHead(dir)
+	if head == "" {
+		return nil
+	}
+	snap := &types.FileSnapshot{Head: head}
+	for _, path := range changedFiles(dir) {
+		if snap.Dirty == nil {
+			snap.Dirty = make(map[string]string)
+		}
+		snap.Dirty[path] = fileHash(dir, path)
+	}
+	return snap
+}
+
+// filesChangedInPhase returns the files changed since the session's last phase
+// transition, sorted, and takes the snapshot the next transition compares
+// against. Files are compared with the commit checked out at the last
+// transition, so work committed during the phase still counts; a file that was
+// already modified then counts only if its content changed since. Without an
+// earlier snapshot, every file differing from HEAD counts. Returns nil outside
+// a git repository.
+func filesChangedInPhase(dir string, s *types.Session) []string {
+	prev := s.PhaseFiles
+	s.PhaseFiles = takeFileSnapshot(dir)
+	if s.PhaseFiles == nil {
+		return nil
+	}
+	if prev == nil {
+		return sortedUnique(changedFiles(dir))
+	}
+
+	candidates := untrackedFiles(dir)
+	c := exec.Command("git", "diff", "--name-only", "--relative", prev.Head)
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		// The earlier commit is gone, e.g. after a rebase.
+		return sortedUnique(changedFiles(dir))
+	}
+	candidates = append(candidates, nonEmptyLines(string(out))...)
+
+	var changed []string
+	seen := make(map[string]bool)
+	for _, path := range candidates {
+		seen[path] = true
+		if hash, ok := prev.Dirty[path]; ok && hash == fileHash(dir, path) {
+			continue
+		}
+		changed = append(changed, path)
+	}
+	// Modified at the last transition but matching that commit again: reverted.
+	for path := range prev.Dirty {
+		if !seen[path] {
+			changed = append(changed, path)
+		}
+	}
+	return sortedUnique(changed)
+}
+
+// untrackedFiles lists files git does not track and does not ignore.
+func untrackedFiles(dir string) []string {
+	c := exec.Command("git", "ls-files", "--others", "--exclude-standard")
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return nil
+	}
+	return nonEmptyLines(string(out))
+}
+
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// sortedUnique sorts paths and drops duplicates and tdd-ai's own files, such
+// as the session file, which change on every transition.
+func sortedUnique(paths []string) []string {
+	sort.Strings(paths)
+	var out []string
+	for i, p := range paths {
+		if (i > 0 && p == paths[i-1]) || isToolFile(p) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func isToolFile(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if strings.HasPrefix(part, ".tdd-ai") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestFilesChangedInPhase(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := types.NewSession()
+	s.PhaseFiles = takeFileSnapshot(dir)
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	// RED: only a test is written.
+	write("calc_test.go", "package calc\n")
+	if got := filesChangedInPhase(dir, s); !reflect.DeepEqual(got, []string{"calc_test.go"}) {
+		t.Errorf("RED files = %v, want [calc_test.go]", got)
+	}
+
+	// GREEN: the test stays as it was, the implementation is added and committed.
+	write("calc.go", "package calc\n")
+	for _, args := range [][]string{
+		{"add", "calc.go"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "impl"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if got := filesChangedInPhase(dir, s); !reflect.DeepEqual(got, []string{"calc.go"}) {
+		t.Errorf("GREEN files = %v, want [calc.go]", got)
+	}
+
+	// REFACTOR: nothing changes.
+	if got := filesChangedInPhase(dir, s); len(got) != 0 {
+		t.Errorf("REFACTOR files = %v, want none", got)
+	}
+}
+
+func TestPhaseNextRecordsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	s := types.NewSession()
+	s.AddSpec("adds numbers")
+	id := 1
+	s.CurrentSpecID = &id
+	s.PhaseFiles = takeFileSnapshot(dir)
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "calc_test.go"), []byte("package calc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testResultFlag = "" }()
+
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "fail", "--format", "text"); err != nil {
+		t.Fatalf("phase next failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	last := loaded.History[len(loaded.History)-1]
+	if last.Action != "phase_next" || !reflect.DeepEqual(last.Files, []string{"calc_test.go"}) {
+		t.Errorf("phase_next event = %+v, want files [calc_test.go]", last)
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show the organization policy in effect",
+	Long: `Platform teams can standardize agent behavior with a JSON policy file, named by
+--policy or the TDD_AI_POLICY environment variable. Settings the policy turns on
+are applied to every session and cannot be turned off locally:
+
+  {
+    "agent_mode": true,
+    "require_review": true,
+    "audit_log": true,
+    "strictness": "standard",
+    "reflections": ["Did this change need a migration note?"],
+    "banned_force": ["phase set", "complete"],
+    "high_risk": {"integration_suite": "e2e", "require_review": true}
+  }
+
+strictness is the lowest reflection strictness allowed: sessions below it are
+raised to it, and 'config strictness' refuses to go lower.
+reflections are asked in every REFACTOR phase after the default questions.
+banned_force disables --force on "phase next", "phase set", or "complete".
+high_risk replaces the rules for specs marked --risk high (by default the
+"integration" suite must pass and a human review must approve); an omitted
+field turns that rule off.`,
+	Example: `  TDD_AI_POLICY=/etc/tdd-ai/policy.json tdd-ai policy
+  tdd-ai policy --policy policy.json --format json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			out := struct {
+				Path   string         `json:"path,omitempty"`
+				Policy *policy.Policy `json:"policy"`
+			}{Policy: activePolicy}
+			if activePolicy != nil {
+				out.Path = activePolicy.Path
+			}
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding policy: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			w := cmd.OutOrStdout()
+			if activePolicy == nil {
+				fmt.Fprintf(w, "No organization policy. Set %s or pass --policy to apply one.\n", policy.EnvVar)
+				return nil
+			}
+			p := activePolicy
+			fmt.Fprintf(w, "Policy: %s\n", p.Path)
+			fmt.Fprintf(w, "  agent mode: %s\n", lockedLabel(p.AgentMode))
+			fmt.Fprintf(w, "  require review: %s\n", lockedLabel(p.RequireReview))
+			fmt.Fprintf(w, "  audit log: %s\n", lockedLabel(p.AuditLog))
+			if p.Strictness != "" {
+				fmt.Fprintf(w, "  strictness: at least %s\n", p.Strictness)
+			}
+			for _, q := range p.Reflections {
+				fmt.Fprintf(w, "  reflection: %s\n", q)
+			}
+			if len(p.BannedForce) > 0 {
+				fmt.Fprintf(w, "  --force banned on: %s\n", strings.Join(p.BannedForce, ", "))
+			}
+			if r := p.HighRisk; r != nil {
+				fmt.Fprintf(w, "  high-risk specs: integration suite %q, require review %t\n", r.IntegrationSuite, r.RequireReview)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+func lockedLabel(on bool) string {
+	if on {
+		return "locked on"
+	}
+	return "not enforced"
+}
+
+// checkForceAllowed returns a blocked error when the organization policy
+// disables --force for the command.
+func checkForceAllowed(command string) error {
+	if activePolicy.ForceBanned(command) {
+		return blockedError(fmt.Errorf("--force is disabled for '%s' by organization policy (%s)", command, activePolicy.Path))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+}
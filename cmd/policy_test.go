@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func writePolicyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPolicyLocksAgentModeOverSession(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AgentMode = false
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	t.Setenv("TDD_AI_POLICY", writePolicyFile(t, `{"agent_mode": true}`))
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { phaseSetForceFlag = false }()
+
+	_, _, err := executePhaseCmd(t, "phase", "set", "green", "--force", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "agent mode") {
+		t.Errorf("policy agent mode should disable phase set, got: %v", err)
+	}
+}
+
+func TestPolicyBansForce(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	path := writePolicyFile(t, `{"banned_force": ["phase set"]}`)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { phaseSetForceFlag = false; policyFlag = "" }()
+
+	_, _, err := executePhaseCmd(t, "phase", "set", "green", "--force", "--policy", path, "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "organization policy") {
+		t.Fatalf("expected --force to be banned by policy, got: %v", err)
+	}
+	if ExitCode(err) != ExitBlocked {
+		t.Errorf("exit code = %d, want %d", ExitCode(err), ExitBlocked)
+	}
+	if loaded, _ := session.Load(dir); loaded.Phase != types.PhaseRed {
+		t.Errorf("phase should stay red, got %s", loaded.Phase)
+	}
+}
+
+func TestPolicyAppliedOnInitAndAddsReflections(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TDD_AI_POLICY", writePolicyFile(t, `{"require_review": true, "reflections": ["Was the changelog updated?"]}`))
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, err := executeInitCmd(t, "init", "--format", "text"); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	s, _ := session.Load(dir)
+	if !s.RequireReview {
+		t.Error("policy should lock require_review on at init")
+	}
+
+	s.Phase = types.PhaseGreen
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Fatalf("phase next failed: %v", err)
+	}
+	s, _ = session.Load(dir)
+	last := s.Reflections[len(s.Reflections)-1]
+	if last.Question != "Was the changelog updated?" {
+		t.Errorf("policy reflection should be asked last, got %+v", s.Reflections)
+	}
+}
+
+func TestInvalidPolicyIsInvalidInput(t *testing.T) {
+	t.Setenv("TDD_AI_POLICY", filepath.Join(t.TempDir(), "missing.json"))
+	_, _, err := executePhaseCmd(t, "policy", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("expected invalid input for a missing policy file, got: %v", err)
+	}
+}
+
+func TestPolicyMinimumStrictnessBlocksConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	path := writePolicyFile(t, `{"strictness": "standard"}`)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { policyFlag = "" }()
+
+	_, _, err := executePhaseCmd(t, "config", "strictness", "relaxed", "--policy", path, "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "organization policy") {
+		t.Fatalf("expected relaxed strictness to be refused by policy, got: %v", err)
+	}
+	if ExitCode(err) != ExitBlocked {
+		t.Errorf("exit code = %d, want %d", ExitCode(err), ExitBlocked)
+	}
+	if loaded, _ := session.Load(dir); loaded.GetStrictness() != types.StrictnessStandard {
+		t.Errorf("strictness should stay standard, got %s", loaded.GetStrictness())
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/protect"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// changedFiles lists files modified, added, or untracked since HEAD, relative to
+// dir. Returns nil when dir is not in a git repository or git is unavailable.
+func changedFiles(dir string) []string {
+	var files []string
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.Output()
+		if err != nil {
+			return nil
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line != "" {
+				files = append(files, line)
+			}
+		}
+	}
+	return files
+}
+
+// modifiedProtectedPaths returns the changed files that match the session's
+// protected path patterns.
+func modifiedProtectedPaths(dir string, s *types.Session) []string {
+	if len(s.ProtectedPaths) == 0 {
+		return nil
+	}
+	return protect.Violations(s.ProtectedPaths, changedFiles(dir))
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// initGitRepo creates a git repository in dir with one committed protected file.
+func initGitRepo(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "migrations"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "migrations", "001.sql"), []byte("create table a;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestPhaseNextBlockedByModifiedProtectedPath(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.ProtectedPaths = []string{"migrations/**"}
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "migrations", "001.sql"), []byte("drop table a;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if err == nil {
+		t.Fatal("expected phase next to be blocked by protected path")
+	}
+	if !strings.Contains(err.Error(), "migrations/001.sql") {
+		t.Errorf("error should name the protected file, got: %v", err)
+	}
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != ExitBlocked {
+		t.Errorf("expected blocked exit code, got: %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.Phase != types.PhaseGreen {
+		t.Errorf("phase should stay green, got %s", loaded.Phase)
+	}
+}
+
+func TestPhaseNextAllowedWhenProtectedPathUntouched(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.ProtectedPaths = []string{"migrations/**"}
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Fatalf("phase next should succeed, got: %v", err)
+	}
+}
+
+func TestVerifyReportsModifiedProtectedPath(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+
+	s := types.NewSession()
+	s.ProtectedPaths = []string{"migrations/**"}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "migrations", "002.sql"), []byte("alter table a;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _ := executeVerifyCmd(t, "verify", "--format", "json")
+	if !strings.Contains(out, "protected_path_modified") || !strings.Contains(out, "migrations/002.sql") {
+		t.Errorf("expected protected path violation, got: %s", out)
+	}
+}
@@ -3,7 +3,10 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/macosta/tdd-ai/internal/formatter"
 	"github.com/macosta/tdd-ai/internal/reflection"
@@ -53,14 +56,43 @@ var refactorCmd = &cobra.Command{
 	},
 }
 
-var reflectAnswerFlag string
+var (
+	reflectAnswerFlag     string
+	reflectAnswerFileFlag string
+	reflectEvidenceFlag   []string
+	reflectSkipFlag       bool
+	reflectReasonFlag     string
+)
 
 var reflectCmd = &cobra.Command{
 	Use:   "reflect <question-number>",
 	Short: "Answer a reflection question",
-	Long:  "Answer one of the 6 structured reflection questions required to exit the refactor phase.",
+	Long: `Answer one of the 6 structured reflection questions required to exit the refactor phase.
+
+Use --evidence (repeatable) to point the answer at the code it is about, as
+path or path:line relative to the working directory. Each reference must name
+an existing file and, with a line, a line within it. Evidence is shown next to
+the answer by 'refactor status', 'guide', and 'review'.
+
+Use --answer-file instead of --answer for long answers that do not fit
+comfortably in shell quoting; "-" reads the answer from stdin. The whole text
+must meet the minimum length. When it runs over one line or 280 characters,
+an excerpt of its first paragraph is shown wherever answers are listed, and
+the full text is kept as full_answer ('refactor status --format json') and
+used by 'tdd-ai reflections export' and 'search'.
+
+In relaxed sessions ('tdd-ai config strictness relaxed'), --skip --reason marks a
+question as skipped instead of answered. It no longer blocks advancing, but is
+recorded as reflection debt, reported by status and verify and listed by
+'tdd-ai refactor debt'. Answering the question later in the same phase, or
+'tdd-ai refactor debt pay', clears the debt. Strict sessions cannot leave
+REFACTOR while any debt is outstanding.`,
 	Example: `  tdd-ai refactor reflect 1 --answer "Tests are already descriptive and clear enough"
-  tdd-ai refactor reflect 3 --answer "Each test uses its own fixture data"`,
+  tdd-ai refactor reflect 3 --answer "Each test uses its own fixture data"
+  tdd-ai refactor reflect 4 --answer "Extracted parsing into its own helper" --evidence internal/parse/parse.go:42
+  tdd-ai refactor reflect 5 --answer-file notes.md
+  cat notes.md | tdd-ai refactor reflect 5 --answer-file -
+  tdd-ai refactor reflect 5 --skip --reason "spike code, thrown away after the demo"`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir := getWorkDir()
@@ -75,18 +107,40 @@ var reflectCmd = &cobra.Command{
 
 		num, err := strconv.Atoi(args[0])
 		if err != nil {
-			return fmt.Errorf("invalid question number %q: must be an integer", args[0])
+			return invalidInputError(fmt.Errorf("invalid question number %q: must be an integer", args[0]))
 		}
 
-		if reflectAnswerFlag == "" {
-			return fmt.Errorf("--answer is required")
+		if reflectSkipFlag {
+			return skipReflection(cmd, dir, s, num)
 		}
 
-		if err := reflection.ValidateAnswer(reflectAnswerFlag); err != nil {
+		answer := reflectAnswerFlag
+		if reflectAnswerFileFlag != "" {
+			if answer != "" {
+				return invalidInputError(fmt.Errorf("--answer and --answer-file cannot be combined"))
+			}
+			if answer, err = readAnswerFile(cmd, reflectAnswerFileFlag); err != nil {
+				return err
+			}
+		}
+		if answer == "" {
+			return invalidInputError(fmt.Errorf("--answer or --answer-file is required"))
+		}
+
+		if err := reflection.ValidateAnswer(answer); err != nil {
 			return err
 		}
 
-		if err := s.AnswerReflection(num, reflectAnswerFlag); err != nil {
+		evidence := make([]types.Evidence, 0, len(reflectEvidenceFlag))
+		for _, ref := range reflectEvidenceFlag {
+			ev, err := reflection.ParseEvidence(dir, ref)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			evidence = append(evidence, ev)
+		}
+
+		if err := s.AnswerReflectionExcerpt(num, reflection.Excerpt(answer), answer, evidence...); err != nil {
 			return err
 		}
 
@@ -104,6 +158,145 @@ var reflectCmd = &cobra.Command{
 	},
 }
 
+// maxAnswerFileSize caps answers read with --answer-file, which are stored in
+// the session file.
+const maxAnswerFileSize = 64 << 10
+
+// readAnswerFile reads a reflection answer from path, or from stdin for "-".
+func readAnswerFile(cmd *cobra.Command, path string) (string, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", invalidInputError(fmt.Errorf("reading answer file: %w", err))
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxAnswerFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("reading answer: %w", err)
+	}
+	if len(data) > maxAnswerFileSize {
+		return "", invalidInputError(fmt.Errorf("answer is larger than %d KiB; keep the details in the file and cite it with --evidence", maxAnswerFileSize>>10))
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// skipReflection marks question num as skipped, adding reflection debt.
+func skipReflection(cmd *cobra.Command, dir string, s *types.Session, num int) error {
+	if reflectAnswerFlag != "" || reflectAnswerFileFlag != "" {
+		return invalidInputError(fmt.Errorf("--skip and --answer cannot be combined"))
+	}
+	reason := strings.TrimSpace(reflectReasonFlag)
+	if reason == "" {
+		return invalidInputError(fmt.Errorf("--skip requires --reason explaining why the question is skipped"))
+	}
+	if s.GetStrictness() != types.StrictnessRelaxed {
+		return blockedError(fmt.Errorf("reflection questions can only be skipped in relaxed sessions (strictness: %s). Answer it, or run 'tdd-ai config strictness relaxed'", s.GetStrictness()))
+	}
+	if err := s.SkipReflection(num, reason); err != nil {
+		return err
+	}
+	s.AddEvent("reflection_skip", func(e *types.Event) {
+		e.Result = fmt.Sprintf("q%d", num)
+		e.Reason = reason
+	})
+	if err := session.Save(dir, s); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Skipped question %d. %d remaining; reflection debt: %d\n", num, len(s.PendingReflections()), len(s.ReflectionDebt))
+	return nil
+}
+
+// checkReflectionDebt returns an error when a strict session still has
+// reflection debt.
+func checkReflectionDebt(s *types.Session) error {
+	if s.GetStrictness() != types.StrictnessStrict || len(s.ReflectionDebt) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d skipped reflection(s) outstanding as reflection debt. Pay it with 'tdd-ai refactor debt pay <n> --answer \"...\"'", len(s.ReflectionDebt))
+}
+
+var refactorDebtCmd = &cobra.Command{
+	Use:   "debt",
+	Short: "List reflection debt: skipped questions not answered since",
+	Example: `  tdd-ai refactor debt
+  tdd-ai refactor debt --format json
+  tdd-ai refactor debt pay 1 --answer "Naming was fine; the helper is used once"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := session.LoadOrFail(getWorkDir())
+		if err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			debt := s.ReflectionDebt
+			if debt == nil {
+				debt = []types.ReflectionDebt{}
+			}
+			data, err := json.MarshalIndent(debt, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding reflection debt: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(s.ReflectionDebt) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No reflection debt.")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Reflection debt (%d):\n", len(s.ReflectionDebt))
+			for i, d := range s.ReflectionDebt {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %d. %s\n     skipped %s: %s\n", i+1, d.Question, d.At, d.Reason)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var refactorDebtPayAnswerFlag string
+
+var refactorDebtPayCmd = &cobra.Command{
+	Use:   "pay <n>",
+	Short: "Answer a skipped reflection question, clearing its debt",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return invalidInputError(fmt.Errorf("invalid debt number %q: must be an integer", args[0]))
+		}
+		if err := reflection.ValidateAnswer(refactorDebtPayAnswerFlag); err != nil {
+			return invalidInputError(err)
+		}
+		paid, err := s.PayReflectionDebt(n)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		s.AddEvent("reflection_debt_paid", func(e *types.Event) {
+			e.Result = paid.Question
+			e.Reason = refactorDebtPayAnswerFlag
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Paid reflection debt %d. %d outstanding.\n", n, len(s.ReflectionDebt))
+		return nil
+	},
+}
+
 var refactorStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show all reflection questions with status",
@@ -128,7 +321,7 @@ var refactorStatusCmd = &cobra.Command{
 		case formatter.FormatText:
 			return renderRefactorStatusText(cmd, s)
 		default:
-			return fmt.Errorf("unknown format: %q", f)
+			return unknownFormatError(f)
 		}
 	},
 }
@@ -137,15 +330,19 @@ func renderRefactorStatusJSON(cmd *cobra.Command, s *types.Session) error {
 	type refactorStatusOutput struct {
 		Total       int                        `json:"total"`
 		Answered    int                        `json:"answered"`
+		Skipped     int                        `json:"skipped"`
 		Pending     int                        `json:"pending"`
 		AllAnswered bool                       `json:"all_answered"`
 		Reflections []types.ReflectionQuestion `json:"reflections"`
 	}
 
-	answered := 0
+	answered, skipped := 0, 0
 	for _, r := range s.Reflections {
-		if r.Answer != "" {
+		switch {
+		case r.Answer != "":
 			answered++
+		case r.Skipped:
+			skipped++
 		}
 	}
 	total := len(s.Reflections)
@@ -153,7 +350,8 @@ func renderRefactorStatusJSON(cmd *cobra.Command, s *types.Session) error {
 	out := refactorStatusOutput{
 		Total:       total,
 		Answered:    answered,
-		Pending:     total - answered,
+		Skipped:     skipped,
+		Pending:     len(s.PendingReflections()),
 		AllAnswered: s.AllReflectionsAnswered(),
 		Reflections: s.Reflections,
 	}
@@ -177,21 +375,33 @@ func renderRefactorStatusText(cmd *cobra.Command, s *types.Session) error {
 
 	fmt.Fprintf(cmd.OutOrStdout(), "Reflections (%d/%d answered):\n\n", answered, total)
 	for _, r := range s.Reflections {
-		status := "pending"
-		if r.Answer != "" {
-			status = "answered"
-		}
-		fmt.Fprintf(cmd.OutOrStdout(), "  [%d] (%s) %s\n", r.ID, status, r.Question)
+		fmt.Fprintf(cmd.OutOrStdout(), "  [%d] (%s) %s\n", r.ID, r.Status(), r.Question)
 		if r.Answer != "" {
 			fmt.Fprintf(cmd.OutOrStdout(), "      -> %q\n", r.Answer)
 		}
+		if r.FullAnswer != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "         (excerpt of a %d-word answer; see --format json)\n", len(strings.Fields(r.FullAnswer)))
+		}
+		if r.Skipped {
+			fmt.Fprintf(cmd.OutOrStdout(), "      skipped: %s\n", r.SkipReason)
+		}
+		for _, ev := range r.Evidence {
+			fmt.Fprintf(cmd.OutOrStdout(), "         see %s\n", ev)
+		}
 	}
 	return nil
 }
 
 func init() {
 	reflectCmd.Flags().StringVar(&reflectAnswerFlag, "answer", "", "your answer to the reflection question (min 5 words)")
+	reflectCmd.Flags().StringVar(&reflectAnswerFileFlag, "answer-file", "", "read the answer from a file, or stdin with \"-\", for long answers")
+	reflectCmd.Flags().StringArrayVar(&reflectEvidenceFlag, "evidence", nil, "file the answer refers to, as path or path:line (repeatable)")
+	reflectCmd.Flags().BoolVar(&reflectSkipFlag, "skip", false, "skip the question, recording reflection debt (relaxed sessions only)")
+	reflectCmd.Flags().StringVar(&reflectReasonFlag, "reason", "", "why the question is skipped (required with --skip)")
 	refactorCmd.AddCommand(reflectCmd)
+	refactorDebtPayCmd.Flags().StringVar(&refactorDebtPayAnswerFlag, "answer", "", "your answer to the skipped question (min 5 words)")
+	refactorDebtCmd.AddCommand(refactorDebtPayCmd)
+	refactorCmd.AddCommand(refactorDebtCmd)
 	refactorCmd.AddCommand(refactorStatusCmd)
 	rootCmd.AddCommand(refactorCmd)
 }
@@ -206,3 +206,206 @@ func TestRefactorStatusJSON(t *testing.T) {
 		t.Errorf("all_answered = %v, want false", parsed["all_answered"])
 	}
 }
+
+func TestRefactorReflectStoresEvidence(t *testing.T) {
+	dir, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	defer func() { reflectEvidenceFlag = nil }()
+	if err := os.WriteFile("calc.go", []byte("package calc\n\nfunc Add() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := executeRefactorCmd(t, "refactor", "reflect", "2", "--answer", "Extracted the addition into its own helper", "--evidence", "calc.go:9", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Fatalf("evidence past the end of the file should be invalid input, got %v", err)
+	}
+
+	reflectEvidenceFlag = nil
+	if _, err := executeRefactorCmd(t, "refactor", "reflect", "2", "--answer", "Extracted the addition into its own helper", "--evidence", "calc.go:3", "--format", "text"); err != nil {
+		t.Fatalf("refactor reflect --evidence failed: %v", err)
+	}
+	s, _ := session.Load(dir)
+	if got := s.Reflections[1].Evidence; len(got) != 1 || got[0].String() != "calc.go:3" {
+		t.Errorf("evidence = %+v, want calc.go:3", got)
+	}
+
+	out, err := executeRefactorCmd(t, "refactor", "status", "--format", "text")
+	if err != nil || !strings.Contains(out, "see calc.go:3") {
+		t.Errorf("refactor status should show the evidence, got %v:\n%s", err, out)
+	}
+}
+
+func TestRefactorReflectAnswerFile(t *testing.T) {
+	dir, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	resetFlags(reflectCmd.Flags())
+	defer resetFlags(reflectCmd.Flags())
+	notes := "# Duplication\n\nMerged the two validators into one helper.\n\nThe second only differed in its error message, which is now a parameter.\n"
+	if err := os.WriteFile("notes.md", []byte(notes), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executeRefactorCmd(t, "refactor", "reflect", "2", "--answer-file", "notes.md", "--format", "text"); err != nil {
+		t.Fatalf("refactor reflect --answer-file failed: %v", err)
+	}
+	s, _ := session.Load(dir)
+	r := s.Reflections[1]
+	if r.Answer != "Merged the two validators into one helper. …" {
+		t.Errorf("answer = %q, want an excerpt of the first paragraph", r.Answer)
+	}
+	if r.FullAnswer != strings.TrimSpace(notes) {
+		t.Errorf("full_answer = %q, want the file contents", r.FullAnswer)
+	}
+
+	resetFlags(reflectCmd.Flags())
+	rootCmd.SetIn(strings.NewReader("Each test builds its own fixture data\n"))
+	defer rootCmd.SetIn(nil)
+	if _, err := executeRefactorCmd(t, "refactor", "reflect", "3", "--answer-file", "-", "--format", "text"); err != nil {
+		t.Fatalf("refactor reflect --answer-file - failed: %v", err)
+	}
+	s, _ = session.Load(dir)
+	if r := s.Reflections[2]; r.Answer != "Each test builds its own fixture data" || r.FullAnswer != "" {
+		t.Errorf("reflection 3 = %+v, want the stdin answer stored as is", r)
+	}
+}
+
+func TestRefactorReflectAnswerFileValidation(t *testing.T) {
+	_, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	resetFlags(reflectCmd.Flags())
+	defer resetFlags(reflectCmd.Flags())
+	if err := os.WriteFile("short.md", []byte("\nlooks fine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executeRefactorCmd(t, "refactor", "reflect", "1", "--answer-file", "short.md", "--format", "text"); err == nil {
+		t.Error("a file answer under the minimum length should be rejected")
+	}
+
+	resetFlags(reflectCmd.Flags())
+	_, err := executeRefactorCmd(t, "refactor", "reflect", "1", "--answer", "Tests are already descriptive and clear enough", "--answer-file", "short.md", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("--answer with --answer-file should be invalid input, got %v", err)
+	}
+
+	resetFlags(reflectCmd.Flags())
+	_, err = executeRefactorCmd(t, "refactor", "reflect", "1", "--answer-file", "missing.md", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("a missing answer file should be invalid input, got %v", err)
+	}
+}
+
+func resetReflectSkipFlags() {
+	resetFlags(reflectCmd.Flags())
+}
+
+func TestRefactorReflectSkipRefusedInStandard(t *testing.T) {
+	_, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	resetReflectSkipFlags()
+	defer resetReflectSkipFlags()
+
+	_, err := executeRefactorCmd(t, "refactor", "reflect", "1", "--skip", "--reason", "spike code", "--format", "text")
+	if ExitCode(err) != ExitBlocked {
+		t.Fatalf("skip in a standard session should be blocked, got %v", err)
+	}
+}
+
+func TestRefactorReflectSkipRequiresReason(t *testing.T) {
+	dir, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	resetReflectSkipFlags()
+	defer resetReflectSkipFlags()
+	s, _ := session.Load(dir)
+	s.Strictness = types.StrictnessRelaxed
+	session.Save(dir, s)
+
+	_, err := executeRefactorCmd(t, "refactor", "reflect", "1", "--skip", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Fatalf("skip without --reason should be invalid input, got %v", err)
+	}
+}
+
+func TestRefactorReflectSkipRecordsDebt(t *testing.T) {
+	dir, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	resetReflectSkipFlags()
+	defer resetReflectSkipFlags()
+	s, _ := session.Load(dir)
+	s.Strictness = types.StrictnessRelaxed
+	session.Save(dir, s)
+
+	out, err := executeRefactorCmd(t, "refactor", "reflect", "2", "--skip", "--reason", "spike code", "--format", "text")
+	if err != nil {
+		t.Fatalf("refactor reflect --skip failed: %v", err)
+	}
+	if !strings.Contains(out, "reflection debt: 1") {
+		t.Errorf("should report reflection debt, got:\n%s", out)
+	}
+
+	s, _ = session.Load(dir)
+	if !s.Reflections[1].Skipped || s.Reflections[1].Answer != "" {
+		t.Errorf("question 2 should be skipped, not answered: %+v", s.Reflections[1])
+	}
+	if len(s.ReflectionDebt) != 1 || s.ReflectionDebt[0].Reason != "spike code" {
+		t.Errorf("ReflectionDebt = %+v, want one entry with the reason", s.ReflectionDebt)
+	}
+	if len(s.PendingReflections()) != 6 {
+		t.Errorf("skipped question should not be pending, got %d pending", len(s.PendingReflections()))
+	}
+
+	out, err = executeRefactorCmd(t, "refactor", "status", "--format", "text")
+	if err != nil {
+		t.Fatalf("refactor status failed: %v", err)
+	}
+	if !strings.Contains(out, "(skipped)") {
+		t.Errorf("status should mark the question skipped, got:\n%s", out)
+	}
+}
+
+func TestRefactorDebtPay(t *testing.T) {
+	dir, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	s, _ := session.Load(dir)
+	if err := s.SkipReflection(1, "spike code"); err != nil {
+		t.Fatal(err)
+	}
+	session.Save(dir, s)
+
+	out, err := executeRefactorCmd(t, "refactor", "debt", "--format", "text")
+	if err != nil {
+		t.Fatalf("refactor debt failed: %v", err)
+	}
+	if !strings.Contains(out, "Reflection debt (1)") {
+		t.Errorf("should list the debt, got:\n%s", out)
+	}
+
+	defer func() { refactorDebtPayAnswerFlag = "" }()
+	if _, err := executeRefactorCmd(t, "refactor", "debt", "pay", "1", "--answer", "Naming was fine for a one-off helper", "--format", "text"); err != nil {
+		t.Fatalf("refactor debt pay failed: %v", err)
+	}
+	s, _ = session.Load(dir)
+	if len(s.ReflectionDebt) != 0 {
+		t.Errorf("debt should be paid, got %+v", s.ReflectionDebt)
+	}
+}
+
+func TestStrictSessionBlockedByReflectionDebt(t *testing.T) {
+	dir, cleanup := setupRefactorSession(t)
+	defer cleanup()
+	s, _ := session.Load(dir)
+	s.Strictness = types.StrictnessStrict
+	for i := range s.Reflections {
+		s.Reflections[i].Answer = "this answer has at least five words"
+	}
+	s.ReflectionDebt = []types.ReflectionDebt{{Question: "Is naming clear?", Reason: "earlier spike"}}
+	session.Save(dir, s)
+
+	if err := checkReflectionDebt(s); err == nil {
+		t.Fatal("strict session with reflection debt should be blocked")
+	}
+	s.Strictness = types.StrictnessRelaxed
+	if err := checkReflectionDebt(s); err != nil {
+		t.Errorf("relaxed session should not be blocked by debt: %v", err)
+	}
+}
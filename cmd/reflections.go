@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/reflection"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var reflectionsAllSessionsFlag bool
+
+var reflectionsCmd = &cobra.Command{
+	Use:   "reflections",
+	Short: "Export or search answered reflections as a knowledge base",
+	Long: `Turns answered REFACTOR reflection questions into reusable learning.
+
+'reflections export' writes them as a Markdown knowledge base grouped by
+question (or JSON), and 'reflections search' finds answers mentioning a word.
+Both read the current session; --all-sessions adds every archived session
+that 'tdd-ai reset' moved to .tdd-ai.trash.`,
+	Example: `  tdd-ai reflections export --all-sessions > LEARNINGS.md
+  tdd-ai reflections search duplication --all-sessions`,
+}
+
+var reflectionsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export answered reflections as Markdown or JSON",
+	Long:  "Export answered reflections grouped by question. Markdown is the default unless --format json is given explicitly.",
+	Example: `  tdd-ai reflections export
+  tdd-ai reflections export --all-sessions --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		entries, err := collectReflections()
+		if err != nil {
+			return err
+		}
+
+		f := formatter.FormatMarkdown
+		if cmd.Flags().Changed("format") {
+			f = formatter.Format(formatFlag)
+		}
+		out, err := formatter.FormatKnowledgeBase(entries, f)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), out)
+		return nil
+	},
+}
+
+var reflectionsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Find answered reflections mentioning a word or phrase",
+	Long:  "Search the questions and answers of answered reflections, ignoring case.",
+	Example: `  tdd-ai reflections search duplication
+  tdd-ai reflections search "test isolation" --all-sessions --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := collectReflections()
+		if err != nil {
+			return err
+		}
+
+		out, err := formatter.FormatKnowledgeBase(reflection.Search(entries, args[0]), formatter.Format(formatFlag))
+		if err != nil {
+			return invalidInputError(err)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), out)
+		return nil
+	},
+}
+
+// collectReflections gathers answered reflections from the current session
+// and, with --all-sessions, from every archived session, oldest first.
+func collectReflections() ([]reflection.Entry, error) {
+	dir := getWorkDir()
+	var paths []string
+	if reflectionsAllSessionsFlag {
+		trashed, err := session.TrashedFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		paths = trashed
+	} else if !session.Exists(dir) {
+		return nil, session.ErrNoSession
+	}
+	if session.Exists(dir) {
+		paths = append(paths, session.FilePath(dir))
+	}
+
+	var entries []reflection.Entry
+	for _, path := range paths {
+		s, err := session.LoadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		source, err := filepath.Rel(dir, path)
+		if err != nil {
+			source = path
+		}
+		entries = append(entries, reflection.Collect(filepath.ToSlash(source), s)...)
+	}
+	return entries, nil
+}
+
+func init() {
+	reflectionsCmd.PersistentFlags().BoolVar(&reflectionsAllSessionsFlag, "all-sessions", false, "include every archived session in .tdd-ai.trash")
+	reflectionsCmd.AddCommand(reflectionsExportCmd)
+	reflectionsCmd.AddCommand(reflectionsSearchCmd)
+	rootCmd.AddCommand(reflectionsCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func saveReflectionSession(t *testing.T, dir, answer string) {
+	t.Helper()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.Reflections = []types.ReflectionQuestion{
+		{ID: 1, Question: "Can I reduce duplication?", Answer: answer},
+		{ID: 2, Question: "Are my tests isolated?"},
+	}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+}
+
+func TestReflectionsExportAllSessions(t *testing.T) {
+	dir := t.TempDir()
+	saveReflectionSession(t, dir, "extracted the shared tax duplication into a helper")
+	if _, err := session.Trash(dir); err != nil {
+		t.Fatalf("failed to trash session: %v", err)
+	}
+	saveReflectionSession(t, dir, "nothing left to extract after the parser change")
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { reflectionsAllSessionsFlag = false }()
+
+	out, _, err := executePhaseCmd(t, "reflections", "export", "--all-sessions", "--format", "markdown")
+	if err != nil {
+		t.Fatalf("reflections export failed: %v", err)
+	}
+	if strings.Count(out, "## Can I reduce duplication?") != 1 {
+		t.Errorf("answers should be grouped under one heading, got:\n%s", out)
+	}
+	if !strings.Contains(out, "tax duplication") || !strings.Contains(out, "parser change") {
+		t.Errorf("should include answers from the archived and current session, got:\n%s", out)
+	}
+	if strings.Contains(out, "isolated") {
+		t.Errorf("unanswered questions should be left out, got:\n%s", out)
+	}
+}
+
+func TestReflectionsSearchFiltersAnswers(t *testing.T) {
+	dir := t.TempDir()
+	saveReflectionSession(t, dir, "extracted the shared tax duplication into a helper")
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "reflections", "search", "TAX", "--format", "text")
+	if err != nil {
+		t.Fatalf("reflections search failed: %v", err)
+	}
+	if !strings.Contains(out, "tax duplication") {
+		t.Errorf("search should match case-insensitively, got:\n%s", out)
+	}
+
+	out, _, err = executePhaseCmd(t, "reflections", "search", "caching", "--format", "text")
+	if err != nil {
+		t.Fatalf("reflections search failed: %v", err)
+	}
+	if !strings.Contains(out, "No answered reflections.") {
+		t.Errorf("search without matches should say so, got:\n%s", out)
+	}
+}
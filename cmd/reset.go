@@ -3,16 +3,28 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/macosta/tdd-ai/internal/session"
 	"github.com/spf13/cobra"
 )
 
+var resetPurgeFlag bool
+
 var resetCmd = &cobra.Command{
-	Use:     "reset",
-	Short:   "Clear the current TDD session",
-	Long:    "Removes the .tdd-ai.json file, allowing you to start fresh with 'tdd-ai init'.",
-	Example: `  tdd-ai reset`,
+	Use:   "reset",
+	Short: "Clear the current TDD session",
+	Long: `Moves the .tdd-ai.json file to .tdd-ai.trash/<timestamp>.json, allowing you to start
+fresh with 'tdd-ai init'. Use 'tdd-ai restore' to bring the last reset session back.
+
+The session's side files move with it into .tdd-ai.trash/<timestamp>/, so the
+next session does not inherit them: the spec archive (.tdd-ai.archive.json),
+the audit log (.tdd-ai.audit.jsonl), background test runs (.tdd-ai.runs/), and
+spec templates (.tdd-ai.spec-templates.json).
+
+Use --purge to delete the session and its side files permanently instead.`,
+	Example: `  tdd-ai reset
+  tdd-ai reset --purge`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 
@@ -20,15 +32,50 @@ var resetCmd = &cobra.Command{
 			return fmt.Errorf("no TDD session found")
 		}
 
-		if err := os.Remove(session.FilePath(dir)); err != nil {
-			return fmt.Errorf("removing session file: %w", err)
+		if resetPurgeFlag {
+			if err := os.Remove(session.FilePath(dir)); err != nil {
+				return fmt.Errorf("removing session file: %w", err)
+			}
+			for _, path := range session.SideFiles(dir) {
+				if err := os.RemoveAll(path); err != nil {
+					return fmt.Errorf("removing %s: %w", filepath.Base(path), err)
+				}
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "TDD session permanently deleted. Run 'tdd-ai init' to start a new one.")
+			return nil
+		}
+
+		dest, err := session.Trash(dir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "TDD session moved to %s\n", dest)
+		fmt.Fprintln(cmd.OutOrStdout(), "Run 'tdd-ai init' to start a new one, or 'tdd-ai restore' to undo.")
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:     "restore",
+	Short:   "Restore the most recently reset TDD session",
+	Long:    "Moves the newest session in .tdd-ai.trash back to .tdd-ai.json, with the side files reset moved\naside. Fails if a session, or any of those side files, already exists.",
+	Example: `  tdd-ai restore`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+
+		src, err := session.Restore(dir)
+		if err != nil {
+			return err
 		}
 
-		fmt.Fprintln(cmd.OutOrStdout(), "TDD session cleared. Run 'tdd-ai init' to start a new one.")
+		fmt.Fprintf(cmd.OutOrStdout(), "TDD session restored from %s\n", src)
 		return nil
 	},
 }
 
 func init() {
+	resetCmd.Flags().BoolVar(&resetPurgeFlag, "purge", false, "permanently delete the session and its side files instead of moving them to the trash")
 	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(restoreCmd)
 }
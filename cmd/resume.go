@@ -2,12 +2,19 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/macosta/tdd-ai/internal/formatter"
 	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
 	"github.com/spf13/cobra"
 )
 
+var (
+	resumeBudgetFlag      string
+	resumeForSubagentFlag string
+)
+
 var resumeCmd = &cobra.Command{
 	Use:   "resume",
 	Short: "Show a compact checkpoint for recovering agent context after compression",
@@ -15,9 +22,24 @@ var resumeCmd = &cobra.Command{
 and the single next action to take.
 
 Designed to be run as the first command by a new agent or after context compression
-to quickly re-orient to the TDD session state without reading the full history.`,
+to quickly re-orient to the TDD session state without reading the full history.
+
+Use --budget to control the packet size: minimal keeps only the phase, working
+spec, first blocker, and next action; normal (default) adds all blockers, failing
+test evidence, the goal, and the last 5 events; full adds the active spec list and
+the last 20 events. Sections are dropped in a fixed order as the budget shrinks.
+
+Use --for-subagent <spec-id> to brief a sub-agent spawned to implement one spec:
+the packet holds that spec with its acceptance criteria, the rules of each
+phase, the test command (its test area's, when tagged), the test naming
+convention, and the next action, but nothing about the other specs, the goal,
+or the session history. The phase, blockers, and failing test evidence are
+included only while the spec is the current one. --budget does not apply.`,
 	Example: `  tdd-ai resume
-  tdd-ai resume --format json`,
+  tdd-ai resume --format json
+  tdd-ai resume --budget minimal
+  tdd-ai resume --for-subagent 3 --format json
+  tdd-ai resume --template '{{.NextAction}}'`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
@@ -25,7 +47,40 @@ to quickly re-orient to the TDD session state without reading the full history.`
 			return err
 		}
 
-		out, err := formatter.FormatResume(s, formatter.Format(formatFlag))
+		if resumeForSubagentFlag != "" {
+			id, err := s.ResolveSpecRef(resumeForSubagentFlag)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			idx := slices.IndexFunc(s.ActiveSpecs(), func(sp types.Spec) bool { return sp.ID == id })
+			if idx < 0 {
+				return invalidInputError(fmt.Errorf("spec %d is not an active spec", id))
+			}
+			spec := s.ActiveSpecs()[idx]
+			var out string
+			if templateFlag != "" {
+				out, err = formatter.TemplateSubagentResume(s, spec, templateFlag)
+			} else {
+				out, err = formatter.FormatSubagentResume(s, spec, formatter.Format(formatFlag))
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), out)
+			return nil
+		}
+
+		budget, err := formatter.ParseBudget(resumeBudgetFlag)
+		if err != nil {
+			return invalidInputError(err)
+		}
+
+		var out string
+		if templateFlag != "" {
+			out, err = formatter.TemplateResumeBudget(s, templateFlag, budget)
+		} else {
+			out, err = formatter.FormatResumeBudget(s, formatter.Format(formatFlag), budget)
+		}
 		if err != nil {
 			return err
 		}
@@ -36,5 +91,8 @@ to quickly re-orient to the TDD session state without reading the full history.`
 }
 
 func init() {
+	resumeCmd.Flags().StringVar(&resumeBudgetFlag, "budget", "normal", "packet size: minimal, normal, or full")
+	resumeCmd.Flags().StringVar(&resumeForSubagentFlag, "for-subagent", "", "scope the packet to one spec (ID or slug) for a spawned sub-agent")
+	addTemplateFlag(resumeCmd)
 	rootCmd.AddCommand(resumeCmd)
 }
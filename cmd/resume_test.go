@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestResumeForSubagent(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("login returns 404")
+	s.AddSpec("logout clears the session")
+	s.Specs[1].Status = types.SpecStatusCompleted
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { resumeForSubagentFlag = "" }()
+
+	out, _, err := executePhaseCmd(t, "resume", "--for-subagent", "SPEC-login-404", "--format", "json")
+	if err != nil {
+		t.Fatalf("resume --for-subagent failed: %v", err)
+	}
+	var packet struct {
+		Spec       types.Spec `json:"spec"`
+		NextAction string     `json:"next_action"`
+	}
+	if err := json.Unmarshal([]byte(out), &packet); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if packet.Spec.ID != 1 || packet.NextAction != "tdd-ai spec pick 1" {
+		t.Errorf("packet should scope to spec 1 by slug, got %+v", packet)
+	}
+
+	if _, _, err := executePhaseCmd(t, "resume", "--for-subagent", "2"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("a completed spec should be invalid input, got %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "resume", "--for-subagent", "nope"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("an unknown spec should be invalid input, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/macosta/tdd-ai/internal/coverage"
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var retrofitCmd = &cobra.Command{
+	Use:   "retrofit",
+	Short: "Tools for adding tests to existing code",
+	Long:  "Helpers for retrofit sessions, where tests are written for code that already exists.",
+	Example: `  tdd-ai retrofit gaps --coverage-file cover.out
+  tdd-ai retrofit gaps --coverage-file coverage/lcov.info --add-specs`,
+}
+
+var (
+	retrofitCoverageFileFlag string
+	retrofitAddSpecsFlag     bool
+)
+
+var retrofitGapsCmd = &cobra.Command{
+	Use:   "gaps",
+	Short: "List uncovered files and functions from a coverage report",
+	Long: `Parses a coverage report and lists files with no coverage and functions that
+were never executed. Go cover profiles (go test -coverprofile) and LCOV tracefiles
+are supported.
+
+Use --add-specs to turn each gap into a characterization-test spec, bootstrapping
+the test list for a retrofit session. Gaps that already have a spec are skipped.`,
+	Example: `  go test -coverprofile=cover.out ./... && tdd-ai retrofit gaps --coverage-file cover.out
+  tdd-ai retrofit gaps --coverage-file coverage/lcov.info --add-specs`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if retrofitCoverageFileFlag == "" {
+			return invalidInputError(fmt.Errorf("--coverage-file is required"))
+		}
+
+		dir := getWorkDir()
+		data, err := os.ReadFile(retrofitCoverageFileFlag)
+		if err != nil {
+			return fmt.Errorf("reading coverage file: %w", err)
+		}
+		gaps, err := coverage.Parse(data, dir)
+		if err != nil {
+			return err
+		}
+		if gaps == nil {
+			gaps = []coverage.Gap{}
+		}
+
+		var added []int
+		if retrofitAddSpecsFlag {
+			s, err := session.LoadOrFail(dir)
+			if err != nil {
+				return err
+			}
+			existing := make(map[string]bool, len(s.Specs))
+			for _, spec := range s.Specs {
+				existing[spec.Description] = true
+			}
+			for _, g := range gaps {
+				desc := g.SpecDescription()
+				if existing[desc] {
+					continue
+				}
+				existing[desc] = true
+				added = append(added, s.AddSpec(desc))
+			}
+			if len(added) > 0 {
+				s.AddEvent("spec_add", func(e *types.Event) {
+					e.SpecCount = len(added)
+					e.Result = "coverage_gaps"
+				})
+				if err := session.Save(dir, s); err != nil {
+					return err
+				}
+			}
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			out := struct {
+				Gaps       []coverage.Gap `json:"gaps"`
+				AddedSpecs []int          `json:"added_specs,omitempty"`
+			}{gaps, added}
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding coverage gaps: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(gaps) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No coverage gaps found.")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Coverage gaps (%d):\n", len(gaps))
+			for _, g := range gaps {
+				if g.Function == "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %s (file not covered)\n", g.File)
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %s:%d %s\n", g.File, g.Line, g.Function)
+				}
+			}
+			if retrofitAddSpecsFlag {
+				fmt.Fprintf(cmd.OutOrStdout(), "\nAdded %d characterization spec(s)\n", len(added))
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout(), "\nNext: re-run with --add-specs to turn each gap into a spec")
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+func init() {
+	retrofitGapsCmd.Flags().StringVar(&retrofitCoverageFileFlag, "coverage-file", "", "coverage report to analyze (Go cover profile or LCOV)")
+	retrofitGapsCmd.Flags().BoolVar(&retrofitAddSpecsFlag, "add-specs", false, "add a characterization-test spec for each gap")
+	retrofitCmd.AddCommand(retrofitGapsCmd)
+	rootCmd.AddCommand(retrofitCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+const retrofitLCOV = `SF:src/math.js
+FN:1,add
+FN:5,sub
+FNDA:3,add
+FNDA:0,sub
+DA:1,3
+DA:5,0
+end_of_record
+SF:src/unused.js
+DA:1,0
+end_of_record
+`
+
+func TestRetrofitGapsAddSpecs(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	covFile := filepath.Join(dir, "lcov.info")
+	if err := os.WriteFile(covFile, []byte(retrofitLCOV), 0644); err != nil {
+		t.Fatalf("failed to write coverage file: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() {
+		retrofitCoverageFileFlag = ""
+		retrofitAddSpecsFlag = false
+	}()
+
+	out, _, err := executePhaseCmd(t, "retrofit", "gaps", "--coverage-file", covFile, "--add-specs", "--format", "text")
+	if err != nil {
+		t.Fatalf("retrofit gaps failed: %v", err)
+	}
+	if !strings.Contains(out, "src/math.js:5 sub") || !strings.Contains(out, "src/unused.js (file not covered)") {
+		t.Errorf("should list gaps, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Added 2 characterization spec(s)") {
+		t.Errorf("should report added specs, got:\n%s", out)
+	}
+
+	// Running again does not duplicate specs
+	if _, _, err := executePhaseCmd(t, "retrofit", "gaps", "--coverage-file", covFile, "--add-specs", "--format", "text"); err != nil {
+		t.Fatalf("retrofit gaps failed: %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	if len(loaded.Specs) != 2 {
+		t.Fatalf("len(Specs) = %d, want 2", len(loaded.Specs))
+	}
+	if loaded.Specs[0].Description != "Characterize current behavior of sub in src/math.js" {
+		t.Errorf("Specs[0].Description = %q", loaded.Specs[0].Description)
+	}
+}
+
+func TestRetrofitGapsRequiresCoverageFile(t *testing.T) {
+	if _, _, err := executePhaseCmd(t, "retrofit", "gaps", "--format", "text"); err == nil {
+		t.Error("retrofit gaps should require --coverage-file")
+	}
+}
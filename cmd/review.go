@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/macosta/tdd-ai/internal/verify"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	reviewApproveFlag        bool
+	reviewRequestChangesFlag string
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review the current iteration and record an approval verdict",
+	Long: `Walks a human reviewer through the current iteration one page at a time:
+reflection answers, TDD compliance violations, phase history since the last
+spec was picked, and the working tree diff. At the end the reviewer approves
+or requests changes, and the verdict is recorded in the session.
+
+A verdict can only be given from a terminal, so an agent driving the session
+cannot approve its own work by piping an answer in. Use --approve or
+--request-changes to record a verdict without the walkthrough; agent-mode
+sessions refuse both and require the walkthrough. The review event records
+how the verdict was given, and 'tdd-ai verify' warns about approvals that did
+not come from the walkthrough.
+
+Sessions initialized with --require-review cannot be completed until the
+current iteration has been approved.`,
+	Example: `  tdd-ai review
+  tdd-ai review --approve
+  tdd-ai review --request-changes "Test names should describe behavior"`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		if reviewApproveFlag && reviewRequestChangesFlag != "" {
+			return invalidInputError(fmt.Errorf("--approve and --request-changes are mutually exclusive"))
+		}
+
+		if !isTerminal() || !stdinIsTerminal(cmd) {
+			return blockedError(fmt.Errorf("review needs a terminal: the verdict must come from a person, not from piped input"))
+		}
+		flagged := reviewApproveFlag || reviewRequestChangesFlag != ""
+		if flagged && s.AgentMode {
+			return blockedError(fmt.Errorf("--approve and --request-changes are disabled in agent mode. Run 'tdd-ai review' and give the verdict at the end of the walkthrough"))
+		}
+
+		verdict, comment := "", ""
+		confirmed := types.ConfirmedForced
+		switch {
+		case reviewApproveFlag:
+			verdict = types.ReviewApproved
+		case reviewRequestChangesFlag != "":
+			verdict, comment = types.ReviewChangesRequested, reviewRequestChangesFlag
+		default:
+			confirmed = types.ConfirmedInteractive
+			verdict, comment, err = runInteractiveReview(cmd, dir, s)
+			if err != nil {
+				return err
+			}
+			if verdict == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), "No verdict recorded.")
+				return nil
+			}
+		}
+
+		if err := s.RecordReview(verdict, comment); err != nil {
+			return err
+		}
+		s.AddEvent("review", func(e *types.Event) {
+			e.Result = verdict
+			e.Confirmed = confirmed
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		if verdict == types.ReviewApproved {
+			fmt.Fprintf(cmd.OutOrStdout(), "Iteration %d approved.\n", s.Iteration)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Changes requested for iteration %d: %s\n", s.Iteration, comment)
+		}
+		return nil
+	},
+}
+
+// stdinIsTerminal reports whether the command reads from a terminal.
+// Extracted for testability.
+var stdinIsTerminal = func(cmd *cobra.Command) bool {
+	f, ok := cmd.InOrStdin().(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// reviewPage is one screen of the interactive review walkthrough.
+type reviewPage struct {
+	Title string
+	Body  string
+}
+
+// runInteractiveReview pages through the review material and prompts for a
+// verdict. It returns an empty verdict when the reviewer skips.
+func runInteractiveReview(cmd *cobra.Command, dir string, s *types.Session) (string, string, error) {
+	out := cmd.OutOrStdout()
+	in := bufio.NewReader(cmd.InOrStdin())
+
+	pages := buildReviewPages(dir, s)
+	for i, p := range pages {
+		fmt.Fprintf(out, "=== %s (%d/%d) ===\n\n%s\n", p.Title, i+1, len(pages), p.Body)
+		if i == len(pages)-1 {
+			break
+		}
+		fmt.Fprint(out, "-- Enter for next page, q to skip to verdict -- ")
+		line, err := readLine(in)
+		if err != nil {
+			return "", "", err
+		}
+		fmt.Fprintln(out)
+		if line == "q" {
+			break
+		}
+	}
+
+	for {
+		fmt.Fprint(out, "Verdict? [a]pprove, [r]equest changes, [s]kip: ")
+		line, err := readLine(in)
+		if err != nil {
+			return "", "", err
+		}
+		switch line {
+		case "a", "approve":
+			return types.ReviewApproved, "", nil
+		case "r", "request changes":
+			fmt.Fprint(out, "What needs to change? ")
+			comment, err := readLine(in)
+			if err != nil {
+				return "", "", err
+			}
+			if comment == "" {
+				fmt.Fprintln(out, "A comment is required when requesting changes.")
+				continue
+			}
+			return types.ReviewChangesRequested, comment, nil
+		case "s", "skip":
+			return "", "", nil
+		}
+	}
+}
+
+// readLine reads a trimmed line of input. Running out of input mid-review is an
+// error so that a closed stdin never loops forever waiting for a verdict.
+func readLine(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", fmt.Errorf("aborted: no more input")
+	}
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// buildReviewPages assembles the walkthrough pages for the current iteration.
+func buildReviewPages(dir string, s *types.Session) []reviewPage {
+	return []reviewPage{
+		{Title: "Reflections", Body: reviewReflections(s)},
+		{Title: "Violations", Body: reviewViolations(s)},
+		{Title: "Phase history", Body: reviewHistory(s)},
+		{Title: "Diff", Body: reviewDiff(dir)},
+	}
+}
+
+func reviewReflections(s *types.Session) string {
+	if len(s.Reflections) == 0 {
+		return "No reflection questions in this iteration.\n"
+	}
+	var b strings.Builder
+	for _, r := range s.Reflections {
+		fmt.Fprintf(&b, "[%d] %s\n", r.ID, r.Question)
+		if r.Answer != "" {
+			fmt.Fprintf(&b, "    -> %q\n", r.Answer)
+		} else {
+			b.WriteString("    -> (unanswered)\n")
+		}
+		for _, ev := range r.Evidence {
+			fmt.Fprintf(&b, "       see %s\n", ev)
+		}
+	}
+	return b.String()
+}
+
+func reviewViolations(s *types.Session) string {
+	result := verify.Analyze(s)
+	if len(result.Violations) == 0 {
+		return "No violations found.\n"
+	}
+	var b strings.Builder
+	for _, v := range result.Violations {
+		if v.SpecID > 0 {
+			fmt.Fprintf(&b, "[spec %d] %s: %s\n", v.SpecID, v.Rule, v.Message)
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", v.Rule, v.Message)
+		}
+	}
+	return b.String()
+}
+
+// reviewHistory lists the events since the most recent spec pick, which marks
+// the start of the current iteration.
+func reviewHistory(s *types.Session) string {
+	start := 0
+	for i, e := range s.History {
+		if e.Action == "spec_picked" {
+			start = i
+		}
+	}
+	events := s.History[start:]
+	if len(events) == 0 {
+		return "No history recorded.\n"
+	}
+	var b strings.Builder
+	for _, e := range events {
+		fmt.Fprintf(&b, "%s  %s", e.Timestamp, e.Action)
+		if e.From != "" || e.To != "" {
+			fmt.Fprintf(&b, " %s -> %s", e.From, e.To)
+		}
+		if e.Result != "" {
+			fmt.Fprintf(&b, " (%s)", e.Result)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// reviewDiff returns the uncommitted changes in dir, or a note when git is
+// unavailable.
+func reviewDiff(dir string) string {
+	c := exec.Command("git", "diff", "HEAD")
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return "Diff unavailable (not a git repository or git not installed).\n"
+	}
+	if len(out) == 0 {
+		return "No uncommitted changes.\n"
+	}
+	return string(out)
+}
+
+func init() {
+	reviewCmd.Flags().BoolVar(&reviewApproveFlag, "approve", false, "approve the current iteration without the interactive walkthrough")
+	reviewCmd.Flags().StringVar(&reviewRequestChangesFlag, "request-changes", "", "request changes with the given comment without the interactive walkthrough")
+	rootCmd.AddCommand(reviewCmd)
+}
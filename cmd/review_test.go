@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// withReviewTerminal makes review believe it runs in a terminal.
+func withReviewTerminal(t *testing.T) {
+	t.Helper()
+	origIsTerminal, origStdin := isTerminal, stdinIsTerminal
+	isTerminal = func() bool { return true }
+	stdinIsTerminal = func(*cobra.Command) bool { return true }
+	t.Cleanup(func() { isTerminal, stdinIsTerminal = origIsTerminal, origStdin })
+}
+
+func TestReviewInteractiveApprove(t *testing.T) {
+	withReviewTerminal(t)
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	rootCmd.SetIn(strings.NewReader("\nq\na\n"))
+	defer rootCmd.SetIn(nil)
+
+	out, _, err := executePhaseCmd(t, "review", "--format", "text")
+	if err != nil {
+		t.Fatalf("review failed: %v", err)
+	}
+	if !strings.Contains(out, "=== Reflections (1/4) ===") {
+		t.Errorf("should page through review material, got:\n%s", out)
+	}
+	if strings.Contains(out, "=== Diff") {
+		t.Errorf("q should skip remaining pages, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if !loaded.HasApprovedReview() {
+		t.Errorf("review should be recorded as approved, got %+v", loaded.Review)
+	}
+	last := loaded.History[len(loaded.History)-1]
+	if last.Action != "review" || last.Result != types.ReviewApproved || last.Confirmed != types.ConfirmedInteractive {
+		t.Errorf("should record review event, got %+v", last)
+	}
+}
+
+func TestReviewRequestChangesFlag(t *testing.T) {
+	withReviewTerminal(t)
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { reviewRequestChangesFlag = "" }()
+
+	if _, _, err := executePhaseCmd(t, "review", "--request-changes", "rename the tests", "--format", "text"); err != nil {
+		t.Fatalf("review failed: %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.Review == nil || loaded.Review.Verdict != types.ReviewChangesRequested || loaded.Review.Comment != "rename the tests" {
+		t.Errorf("Review = %+v, want changes requested with comment", loaded.Review)
+	}
+}
+
+func TestCompleteRequiresApprovedReview(t *testing.T) {
+	withReviewTerminal(t)
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.RequireReview = true
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() {
+		completeTestResultFlag = ""
+		reviewApproveFlag = false
+	}()
+
+	_, _, err := executePhaseCmd(t, "complete", "--test-result", "pass", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "not been approved") {
+		t.Fatalf("complete should require review approval, got %v", err)
+	}
+
+	if _, _, err := executePhaseCmd(t, "review", "--approve", "--format", "text"); err != nil {
+		t.Fatalf("review failed: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "complete", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Fatalf("complete should succeed after approval: %v", err)
+	}
+}
+
+func TestReviewVerdictNeedsTerminal(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { reviewApproveFlag = false }()
+
+	rootCmd.SetIn(strings.NewReader("q\na\n"))
+	defer rootCmd.SetIn(nil)
+	if _, _, err := executePhaseCmd(t, "review", "--format", "text"); ExitCode(err) != ExitBlocked {
+		t.Errorf("a piped walkthrough verdict should be blocked, got %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "review", "--approve", "--format", "text"); ExitCode(err) != ExitBlocked {
+		t.Errorf("--approve without a terminal should be blocked, got %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.Review != nil {
+		t.Errorf("no verdict should be recorded, got %+v", loaded.Review)
+	}
+}
+
+func TestReviewFlagsRefusedInAgentMode(t *testing.T) {
+	withReviewTerminal(t)
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AgentMode = true
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { reviewApproveFlag = false }()
+
+	if _, _, err := executePhaseCmd(t, "review", "--approve", "--format", "text"); ExitCode(err) != ExitBlocked {
+		t.Errorf("--approve in agent mode should be blocked, got %v", err)
+	}
+}
+
+func TestReviewApproveFlagRecordedAsForced(t *testing.T) {
+	withReviewTerminal(t)
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { reviewApproveFlag = false }()
+
+	if _, _, err := executePhaseCmd(t, "review", "--approve", "--format", "text"); err != nil {
+		t.Fatalf("review --approve failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if last := loaded.History[len(loaded.History)-1]; last.Confirmed != types.ConfirmedForced {
+		t.Errorf("--approve should be recorded as forced, got %+v", last)
+	}
+}
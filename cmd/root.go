@@ -2,20 +2,38 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"sync"
 
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/policy"
+	"github.com/macosta/tdd-ai/internal/session"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
 var (
-	version    = "dev"
-	formatFlag string
+	version       = "dev"
+	formatFlag    string
+	templateFlag  string
+	quietFlag     bool
+	porcelainFlag bool
+	policyFlag    string
+
+	// searchParentsFlag makes commands use the nearest session in a parent
+	// directory when the working directory has none.
+	searchParentsFlag bool
+
+	// activePolicy is the organization policy in effect for this run, or nil.
+	activePolicy *policy.Policy
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "tdd-ai",
-	Short: "TDD guardrails for AI coding agents",
+	Use:           "tdd-ai",
+	SilenceErrors: true,
+	Short:         "TDD guardrails for AI coding agents",
 	Long: `tdd-ai is a TDD state machine for AI coding agents.
 
 It provides phase tracking, spec management, and phase-gating. The state
@@ -23,13 +41,34 @@ machine enforces TDD discipline — constraints are enforced, not instructed.
 
 The CLI does NOT run tests — the AI agent runs tests itself. This tool
 provides the state machine and guardrails that keep the TDD loop tight.`,
-	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 		// Auto-detect format: default to JSON when stdout is not a terminal
 		// (i.e., when an AI agent is running the CLI via pipe/redirect).
 		// Explicit --format flag always overrides.
 		if !cmd.Flags().Changed("format") && !isTerminal() {
 			formatFlag = "json"
 		}
+		if porcelainFlag {
+			formatFlag = string(formatter.FormatPorcelain)
+		}
+		// --quiet discards normal output; errors still go to stderr and the
+		// exit code reports the outcome. Undo it on the next run so it does
+		// not stick to the command between invocations.
+		if quietFlag {
+			cmd.SetOut(io.Discard)
+		} else if cmd.OutOrStdout() == io.Discard {
+			cmd.SetOut(nil)
+		}
+
+		// An organization policy locks settings over whatever the session says
+		p, err := policy.Resolve(policyFlag)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		activePolicy = p
+		session.OnLoad(p.Apply)
+		session.BeforeSave(branchGuard(cmd))
+		return nil
 	},
 }
 
@@ -39,15 +78,65 @@ var isTerminal = func() bool {
 	return term.IsTerminal(int(os.Stdout.Fd()))
 }
 
+var markArgsOnce sync.Once
+
+// Execute runs the CLI and prints any error. Use ExitCode to map the returned
+// error to a process exit code.
 func Execute() error {
-	return rootCmd.Execute()
+	markArgsOnce.Do(func() { markArgErrorsInvalid(rootCmd) })
+	err := rootCmd.Execute()
+	if err != nil {
+		writeError(rootCmd.ErrOrStderr(), err)
+	}
+	return err
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&formatFlag, "format", "text", "output format: text or json (default: json when non-interactive)")
+	rootCmd.PersistentFlags().StringVar(&formatFlag, "format", "text", "output format: text or json (default: json when non-interactive); blockers and verify also accept gha; phase, spec list, and blockers accept porcelain; commands accepts openai-tools and anthropic-tools")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "suppress normal output; rely on the exit code")
+	rootCmd.PersistentFlags().BoolVar(&porcelainFlag, "porcelain", false, "stable tab-separated output for scripts (phase, spec list, blockers)")
+	rootCmd.PersistentFlags().StringVar(&policyFlag, "policy", "", "organization policy file that locks settings (default: $TDD_AI_POLICY)")
+	rootCmd.PersistentFlags().BoolVar(&searchParentsFlag, "search-parents", false, "use the nearest session in a parent directory when the current one has none, like git (default: $TDD_AI_SEARCH_PARENTS)")
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return invalidInputError(err)
+	})
+}
+
+// addTemplateFlag registers --template on a command whose output can be rendered
+// through a Go template instead of --format.
+func addTemplateFlag(c *cobra.Command) {
+	c.Flags().StringVar(&templateFlag, "template", "", "render output with a Go template, e.g. '{{.Phase}}' (overrides --format)")
 }
 
+// searchParentsEnvVar turns on --search-parents for every command when set to
+// a true value such as 1.
+const searchParentsEnvVar = "TDD_AI_SEARCH_PARENTS"
+
+// getWorkDir returns the directory holding the session commands operate on:
+// the working directory, or with --search-parents the nearest directory above
+// it that holds a session.
 func getWorkDir() string {
+	dir := currentDir()
+	if !searchParents() || session.Exists(dir) {
+		return dir
+	}
+	if root, ok := session.FindRoot(dir); ok {
+		return root
+	}
+	return dir
+}
+
+// searchParents reports whether --search-parents or its environment variable
+// is set.
+func searchParents() bool {
+	if searchParentsFlag {
+		return true
+	}
+	on, _ := strconv.ParseBool(os.Getenv(searchParentsEnvVar))
+	return on
+}
+
+func currentDir() string {
 	dir, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: cannot determine working directory: %v\n", err)
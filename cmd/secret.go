@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/secret"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Store tokens for integrations outside the session and config files",
+	Long: `Stores tokens that integrations such as GitHub, Slack, or Jira need, so they
+never land in the plain-text session or config files that may be committed.
+
+Secrets belong to the current user, not the project. They are kept in the OS
+keychain (macOS Keychain via 'security', or the Secret Service via 'secret-tool'
+on Linux) when one is available, and otherwise encrypted with AES-256-GCM in
+the tdd-ai directory under the user's config directory, with a key file only
+the user can read. Set TDD_AI_SECRETS_DIR to move that directory and
+TDD_AI_SECRET_BACKEND=file to skip the keychain.`,
+	Example: `  tdd-ai secret set github
+  echo "$SLACK_TOKEN" | tdd-ai secret set slack
+  tdd-ai secret list
+  tdd-ai secret delete slack`,
+}
+
+var secretSetBackendFlag string
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Store a secret, replacing any earlier value",
+	Long: `Stores a secret under a name of lowercase letters, digits, '.', '_' and '-'.
+The value is never taken as an argument, where it would end up in the shell
+history: in a terminal it is prompted for without echo, otherwise it is read
+from stdin, dropping one trailing newline.`,
+	Example: `  tdd-ai secret set github
+  gh auth token | tdd-ai secret set github
+  tdd-ai secret set jira --backend file < jira-token.txt`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := secret.ValidateName(name); err != nil {
+			return invalidInputError(err)
+		}
+		switch secretSetBackendFlag {
+		case "", secret.BackendKeychain, secret.BackendFile:
+		default:
+			return invalidInputError(fmt.Errorf("invalid --backend %q (valid: %s, %s)", secretSetBackendFlag, secret.BackendKeychain, secret.BackendFile))
+		}
+
+		value, err := readSecretValue(cmd, name)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return invalidInputError(fmt.Errorf("no value given for secret %s", name))
+		}
+
+		st, err := secret.Open()
+		if err != nil {
+			return err
+		}
+		backend, err := st.Set(name, value, secretSetBackendFlag)
+		if err != nil {
+			return err
+		}
+		if backend == secret.BackendKeychain {
+			fmt.Fprintf(cmd.OutOrStdout(), "Stored secret %s in the OS keychain\n", name)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Stored secret %s encrypted in %s\n", name, st.Dir)
+		}
+		return nil
+	},
+}
+
+// readSecretValue prompts for a secret without echo when stdin is a terminal,
+// and otherwise reads it from stdin.
+func readSecretValue(cmd *cobra.Command, name string) (string, error) {
+	if f, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Value for %s: ", name)
+		value, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", fmt.Errorf("reading secret: %w", err)
+		}
+		return string(value), nil
+	}
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", fmt.Errorf("reading secret from stdin: %w", err)
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+var secretGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Print a secret's value",
+	Long: `Prints a secret's value, for scripts that pass it on to an integration. It is
+printed as is, whatever --format says.`,
+	Example: `  curl -H "Authorization: Bearer $(tdd-ai secret get github)" https://api.github.com/user`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := secret.Open()
+		if err != nil {
+			return err
+		}
+		value, err := st.Get(args[0])
+		if errors.Is(err, secret.ErrNotFound) {
+			return invalidInputError(err)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	},
+}
+
+var secretListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored secrets without their values",
+	Example: `  tdd-ai secret list
+  tdd-ai secret list --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		st, err := secret.Open()
+		if err != nil {
+			return err
+		}
+		infos, err := st.List()
+		if err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(infos, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding secrets: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(infos) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No secrets. Store one with 'tdd-ai secret set <name>'")
+				return nil
+			}
+			for _, info := range infos {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s (%s, updated %s)\n", info.Name, info.Backend, info.UpdatedAt)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var secretDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Short:   "Delete a stored secret",
+	Example: `  tdd-ai secret delete slack`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := secret.Open()
+		if err != nil {
+			return err
+		}
+		err = st.Delete(args[0])
+		if errors.Is(err, secret.ErrNotFound) {
+			return invalidInputError(err)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted secret %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	secretSetCmd.Flags().StringVar(&secretSetBackendFlag, "backend", "", "where to store the secret: keychain or file (default: keychain when available)")
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretGetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretDeleteCmd)
+	rootCmd.AddCommand(secretCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/secret"
+	"github.com/macosta/tdd-ai/internal/session"
+)
+
+func TestSecretSetGetListDelete(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(secret.EnvDir, dir)
+	t.Setenv(secret.EnvBackend, secret.BackendFile)
+	work := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(work)
+	defer os.Chdir(origDir)
+	defer rootCmd.SetIn(nil)
+
+	rootCmd.SetIn(strings.NewReader("ghp_example-token\n"))
+	out, _, err := executePhaseCmd(t, "secret", "set", "github", "--format", "text")
+	if err != nil {
+		t.Fatalf("secret set failed: %v", err)
+	}
+	if !strings.Contains(out, "Stored secret github encrypted in "+dir) {
+		t.Errorf("should confirm where the secret went, got:\n%s", out)
+	}
+
+	out, _, err = executePhaseCmd(t, "secret", "get", "github", "--format", "json")
+	if err != nil {
+		t.Fatalf("secret get failed: %v", err)
+	}
+	if out != "ghp_example-token\n" {
+		t.Errorf("secret get = %q, want the raw value without the trailing newline read from stdin", out)
+	}
+
+	out, _, err = executePhaseCmd(t, "secret", "list", "--format", "text")
+	if err != nil {
+		t.Fatalf("secret list failed: %v", err)
+	}
+	if !strings.Contains(out, "github (file, updated ") || strings.Contains(out, "ghp_") {
+		t.Errorf("list should name the secret without its value, got:\n%s", out)
+	}
+	if session.Exists(work) {
+		t.Error("secret commands should not create a session")
+	}
+
+	if _, _, err := executePhaseCmd(t, "secret", "delete", "github", "--format", "text"); err != nil {
+		t.Fatalf("secret delete failed: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "secret", "get", "github"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("getting a deleted secret should be invalid input, got %v", err)
+	}
+
+	rootCmd.SetIn(strings.NewReader(""))
+	if _, _, err := executePhaseCmd(t, "secret", "set", "github"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("an empty value should be invalid input, got %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "secret", "set", "GitHub Token"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("an invalid name should be invalid input, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/selfupdate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	selfUpdateChannelFlag string
+	selfUpdateCheckFlag   bool
+)
+
+// selfExecutable locates the running binary. Tests replace it.
+var selfExecutable = func() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// newUpdater returns the updater for official releases. Tests replace it.
+var newUpdater = selfupdate.New
+
+// selfUpdateResult reports what 'tdd-ai self-update' found or did.
+type selfUpdateResult struct {
+	Current         string `json:"current"`
+	Latest          string `json:"latest"`
+	Channel         string `json:"channel"`
+	UpdateAvailable bool   `json:"update_available"`
+	Updated         bool   `json:"updated"`
+	Path            string `json:"path,omitempty"`
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest GitHub release",
+	Long: `Checks the GitHub releases of tdd-ai and, when a newer version is published on
+the chosen channel, downloads the build for this OS and architecture and
+replaces the running binary in place.
+
+The release's checksums.txt must carry a minisign signature
+(checksums.txt.minisig) by the release key built into this binary, and the
+downloaded archive's SHA-256 must match it; otherwise nothing is written. The new binary is written next to the old one and
+renamed over it, so a failed update leaves the installed version working.
+
+--channel stable (default) only considers full releases; --channel preview also
+considers prereleases. --check reports whether an update is available without
+installing it.
+
+Set TDD_AI_UPDATE_URL to fetch releases from a GitHub Enterprise or mirror API
+instead of https://api.github.com. It must be an https URL; releases from a
+mirror are still verified with the built-in key. Installs managed by npm or another package
+manager should be updated through it instead.`,
+	Example: `  tdd-ai self-update
+  tdd-ai self-update --check --format json
+  tdd-ai self-update --channel preview`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		f := formatter.Format(formatFlag)
+		if f != formatter.FormatJSON && f != formatter.FormatText {
+			return unknownFormatError(f)
+		}
+		if !slices.Contains(selfupdate.Channels, selfUpdateChannelFlag) {
+			return invalidInputError(fmt.Errorf("invalid --channel %q (valid: %s)", selfUpdateChannelFlag, strings.Join(selfupdate.Channels, ", ")))
+		}
+
+		u, err := newUpdater()
+		if err != nil {
+			return invalidInputError(err)
+		}
+		rel, err := u.Latest(selfUpdateChannelFlag)
+		if err != nil {
+			return err
+		}
+		res := selfUpdateResult{
+			Current:         version,
+			Latest:          rel.Version(),
+			Channel:         selfUpdateChannelFlag,
+			UpdateAvailable: selfupdate.Newer(rel.Version(), version),
+		}
+
+		if res.UpdateAvailable && !selfUpdateCheckFlag {
+			exe, err := selfExecutable()
+			if err != nil {
+				return fmt.Errorf("locating the tdd-ai binary: %w", err)
+			}
+			binary, err := u.Download(rel, runtime.GOOS, runtime.GOARCH)
+			if err != nil {
+				return err
+			}
+			if err := selfupdate.Replace(exe, binary); err != nil {
+				return fmt.Errorf("replacing %s: %w", exe, err)
+			}
+			res.Updated, res.Path = true, exe
+		}
+
+		out := cmd.OutOrStdout()
+		if f == formatter.FormatJSON {
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding self-update result: %w", err)
+			}
+			fmt.Fprintln(out, string(data))
+			return nil
+		}
+		switch {
+		case res.Updated:
+			fmt.Fprintf(out, "Updated tdd-ai %s -> %s (%s)\n", res.Current, res.Latest, res.Path)
+		case res.UpdateAvailable:
+			fmt.Fprintf(out, "tdd-ai %s is available (%s channel; installed: %s). Run 'tdd-ai self-update' to install it\n", res.Latest, res.Channel, res.Current)
+		default:
+			fmt.Fprintf(out, "tdd-ai %s is up to date (latest %s release: %s)\n", res.Current, res.Channel, res.Latest)
+		}
+		return nil
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().StringVar(&selfUpdateChannelFlag, "channel", selfupdate.ChannelStable, "release channel: stable or preview")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheckFlag, "check", false, "only report whether an update is available")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/selfupdate"
+)
+
+func TestSelfUpdateCheckReportsAvailableRelease(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]selfupdate.Release{{Tag: "v1.2.0-rc.1", Prerelease: true}, {Tag: "v1.1.0"}})
+	}))
+	defer srv.Close()
+	t.Setenv(selfupdate.EnvVar, srv.URL)
+
+	origVersion, origExe, origUpdater := version, selfExecutable, newUpdater
+	version = "1.0.0"
+	selfExecutable = func() (string, error) {
+		t.Fatal("--check must not touch the installed binary")
+		return "", nil
+	}
+	newUpdater = func() (*selfupdate.Updater, error) {
+		u, err := selfupdate.New()
+		if u != nil {
+			u.Client = srv.Client()
+		}
+		return u, err
+	}
+	defer func() { version, selfExecutable, newUpdater = origVersion, origExe, origUpdater }()
+	defer resetFlags(selfUpdateCmd.Flags())
+
+	for channel, want := range map[string]string{"stable": "1.1.0", "preview": "1.2.0-rc.1"} {
+		buf := new(bytes.Buffer)
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(new(bytes.Buffer))
+		rootCmd.SetArgs([]string{"self-update", "--check", "--channel", channel, "--format", "json"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("self-update --check failed: %v", err)
+		}
+		var res selfUpdateResult
+		if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, buf)
+		}
+		if res.Latest != want || !res.UpdateAvailable || res.Updated {
+			t.Errorf("%s: result = %+v, want latest %s available but not installed", channel, res, want)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"self-update", "--channel", "nightly", "--format", "json"})
+	if err := rootCmd.Execute(); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown channel: err = %v, want invalid input", err)
+	}
+}
+
+func TestSelfUpdateRefusesPlainHTTPURL(t *testing.T) {
+	t.Setenv(selfupdate.EnvVar, "http://mirror.example")
+	defer resetFlags(selfUpdateCmd.Flags())
+
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"self-update", "--check"})
+	err := rootCmd.Execute()
+	if ExitCode(err) != ExitInvalidInput || !strings.Contains(err.Error(), "not an https URL") {
+		t.Errorf("http %s: err = %v, want invalid input", selfupdate.EnvVar, err)
+	}
+}
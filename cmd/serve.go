@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/hub"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddrFlag string
+	serveDirsFlag []string
+
+	serveWatchIntervalFlag time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve several project sessions over a local JSON HTTP API",
+	Long: `Starts a session hub: a single daemon holding the TDD sessions of several
+project directories in memory, so agents working across repositories can share
+one process. Each session has its own lock, and every change is written through
+to the project's .tdd-ai.json before it is visible to other clients.
+
+Register a project with POST /sessions {"dir": "/path/to/repo"} or --dir at
+startup. Sessions are addressed by a stable ID derived from the directory.
+
+  POST   /sessions              register {"dir": "..."}
+  GET    /sessions              list registered sessions, with last_activity and stalled
+  GET    /sessions/{id}         full session state
+  DELETE /sessions/{id}         stop serving a session
+  GET    /sessions/{id}/guide   guidance, as 'tdd-ai guide --format json'
+  POST   /sessions/{id}/specs   add specs {"descriptions": ["..."]}
+  POST   /sessions/{id}/heartbeat  record that the session's agent is alive
+  GET    /events[?session={id}]     WebSocket stream of session events
+
+POST requests must send Content-Type: application/json, even without a body,
+so that web pages cannot change sessions with cross-site form posts. Requests
+whose Host header names anything but the listen address or a loopback name
+(localhost, 127.0.0.1) at its port are refused, so a page cannot reach the hub
+by rebinding its own domain to 127.0.0.1.
+
+Connect to ws://<addr>/events to receive every session event (phase changes,
+test runs, spec updates, ...) as a JSON message the moment it happens, instead
+of polling status:
+
+  {"session_id": "...", "dir": "...", "kind": "phase", "phase": "green", "event": {...}}
+
+kind is phase, test, spec, reflection, or other. Add ?session=<id> to follow a
+single session.
+
+While a project is registered the hub owns its session file. Changes made by
+CLI commands are picked up every --watch-interval and streamed too, but prefer
+the API for changes so they cannot race with it.`,
+	Example: `  tdd-ai serve
+  tdd-ai serve --addr 127.0.0.1:7878 --dir ~/src/api --dir ~/src/web
+  websocat ws://127.0.0.1:7878/events`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		h := hub.New()
+		for _, dir := range serveDirsFlag {
+			info, err := h.Register(dir)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Registered %s as %s\n", info.Dir, info.ID)
+		}
+
+		if serveWatchIntervalFlag < 0 {
+			return invalidInputError(fmt.Errorf("--watch-interval must not be negative"))
+		}
+		if serveWatchIntervalFlag > 0 {
+			go h.Watch(cmd.Context(), serveWatchIntervalFlag)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Serving TDD sessions on http://%s (events on ws://%s/events)\n", serveAddrFlag, serveAddrFlag)
+		return http.ListenAndServe(serveAddrFlag, h.Handler())
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddrFlag, "addr", "127.0.0.1:7878", "address to listen on")
+	serveCmd.Flags().StringArrayVar(&serveDirsFlag, "dir", nil, "project directory to register at startup (repeatable)")
+	serveCmd.Flags().DurationVar(&serveWatchIntervalFlag, "watch-interval", time.Second, "how often to check session files for changes made outside the hub (0 to disable)")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/simulate"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// simulateExcluded are commands a scenario cannot run: those excluded from a
+// batch, and those reaching outside the throwaway session.
+var simulateExcluded = append(slices.Clone(batchExcluded), "secret")
+
+// simulateStep reports how one scenario step went. Output holds the command's
+// JSON output, or a JSON string when it printed something else.
+type simulateStep struct {
+	Step     int             `json:"step"`
+	Command  string          `json:"command"`
+	Status   string          `json:"status"`
+	ExitCode int             `json:"exit_code"`
+	Output   json.RawMessage `json:"output,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Failures []string        `json:"failures,omitempty"`
+}
+
+type simulateReport struct {
+	Name   string         `json:"name,omitempty"`
+	Passed bool           `json:"passed"`
+	Dir    string         `json:"dir,omitempty"`
+	Steps  []simulateStep `json:"steps"`
+}
+
+var (
+	simulateScriptFlag string
+	simulateKeepFlag   bool
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Replay a scripted scenario against a throwaway session",
+	Long: `Replays a scenario of commands and fake test results in a new temporary
+directory and checks the state after each step, so an agent harness can be
+tested without a real codebase or test suite.
+
+A scenario is YAML (or JSON). Each step is either a command, written as in a
+'tdd-ai batch' entry, or a fake test result recorded as 'tdd-ai test record'
+would, with optional test output. Scenarios usually start with 'init':
+
+  name: first red-green cycle
+  steps:
+    - cmd: init
+    - cmd: spec add
+      args: ["login works"]
+    - cmd: spec pick
+      args: [1]
+    - test: fail
+      output: "--- FAIL: TestLogin"
+    - cmd: phase next
+      expect:
+        phase: green
+    - cmd: phase next
+      expect:
+        exit_code: 2
+
+Commands run with --format json unless the scenario sets format: text or a
+step passes its own --format. A step must succeed unless its expect sets
+exit_code. Other checks are phase, current_spec (0 for none), active_specs,
+iteration, last_test_result, and output_contains, which also searches the
+error message.
+
+The first failing step stops the simulation and later steps are reported as
+skipped. The temporary directory is removed afterwards unless --keep is set.`,
+	Example: `  tdd-ai simulate --script scenario.yaml
+  tdd-ai simulate --script scenario.yaml --format json --keep
+  cat scenario.yaml | tdd-ai simulate --script -`,
+	Args: cobra.NoArgs,
+	// A failing step is already in the report; usage adds nothing.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if simulateScriptFlag == "" {
+			return invalidInputError(errors.New("--script is required"))
+		}
+		var data []byte
+		var err error
+		if simulateScriptFlag == "-" {
+			data, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			data, err = os.ReadFile(simulateScriptFlag)
+		}
+		if err != nil {
+			return fmt.Errorf("reading scenario: %w", err)
+		}
+		sc, err := simulate.Load(data)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		for i, st := range sc.Steps {
+			if st.Cmd == "" {
+				continue
+			}
+			if err := validateInProcessCommand(st.Cmd, simulateExcluded, "a simulation"); err != nil {
+				return invalidInputError(fmt.Errorf("step %d: %w", i+1, err))
+			}
+		}
+
+		f := formatter.Format(formatFlag)
+		if f != formatter.FormatJSON && f != formatter.FormatText {
+			return unknownFormatError(f)
+		}
+
+		dir, err := os.MkdirTemp("", "tdd-ai-simulate-")
+		if err != nil {
+			return fmt.Errorf("creating simulation directory: %w", err)
+		}
+		if !simulateKeepFlag {
+			defer os.RemoveAll(dir)
+		}
+
+		out, errOut := cmd.OutOrStdout(), cmd.ErrOrStderr()
+		report, err := runSimulation(sc, dir)
+		rootCmd.SetOut(out)
+		rootCmd.SetErr(errOut)
+		rootCmd.SetIn(nil)
+		formatFlag = string(f)
+		if err != nil {
+			return err
+		}
+
+		if simulateKeepFlag {
+			report.Dir = dir
+		}
+		if err := writeSimulateReport(out, report, f); err != nil {
+			return err
+		}
+		for _, st := range report.Steps {
+			if st.Status == batchFailed {
+				return fmt.Errorf("simulation failed at step %d (%s)", st.Step, st.Command)
+			}
+		}
+		return nil
+	},
+}
+
+// runSimulation replays sc with dir, which must be empty, as the working
+// directory.
+func runSimulation(sc *simulate.Scenario, dir string) (simulateReport, error) {
+	report := simulateReport{Name: sc.Name, Passed: true}
+	// Fake test output is written outside dir, so the scenario sees nothing
+	// there it did not create itself.
+	scratch, err := os.MkdirTemp("", "tdd-ai-simulate-output-")
+	if err != nil {
+		return report, fmt.Errorf("creating simulation directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return report, fmt.Errorf("cannot determine working directory: %w", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return report, err
+	}
+	defer os.Chdir(wd)
+
+	format := sc.Format
+	if format == "" {
+		format = string(formatter.FormatJSON)
+	}
+	for i, st := range sc.Steps {
+		r := simulateStep{Step: i + 1, Command: st.Label()}
+		if !report.Passed {
+			r.Status = batchSkipped
+			report.Steps = append(report.Steps, r)
+			continue
+		}
+
+		path, args := strings.Fields(st.Cmd), []string(st.Args)
+		if st.Test != "" {
+			path, args = []string{"test", "record"}, []string{st.Test}
+			if st.Output != "" {
+				file := filepath.Join(scratch, fmt.Sprintf("step-%d.txt", i+1))
+				if err := os.WriteFile(file, []byte(st.Output), 0644); err != nil {
+					return report, fmt.Errorf("writing test output: %w", err)
+				}
+				args = append(args, "--output-file", file)
+			}
+		}
+		if !slices.ContainsFunc(args, func(a string) bool { return a == "--format" || strings.HasPrefix(a, "--format=") }) {
+			args = append(args, "--format", format)
+		}
+
+		out, runErr := executeInProcess(path, args)
+		r.Output = batchOutput(out)
+		r.ExitCode = ExitCode(runErr)
+		text := string(out)
+		if runErr != nil {
+			r.Error = runErr.Error()
+			text += "\n" + r.Error
+		}
+
+		var s *types.Session
+		if session.Exists(dir) {
+			if s, err = session.Load(dir); err != nil {
+				return report, err
+			}
+		}
+		r.Failures = st.Expect.Check(r.ExitCode, text, s)
+		if len(r.Failures) > 0 {
+			r.Status = batchFailed
+			report.Passed = false
+		} else {
+			r.Status = batchOK
+		}
+		report.Steps = append(report.Steps, r)
+	}
+	return report, nil
+}
+
+func writeSimulateReport(w io.Writer, report simulateReport, f formatter.Format) error {
+	if f == formatter.FormatJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding simulation report: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+	if report.Name != "" {
+		fmt.Fprintf(w, "Scenario: %s\n", report.Name)
+	}
+	for _, st := range report.Steps {
+		fmt.Fprintf(w, "%-7s %d. %s\n", st.Status, st.Step, st.Command)
+		for _, failure := range st.Failures {
+			fmt.Fprintf(w, "          %s\n", failure)
+		}
+	}
+	passed := 0
+	for _, st := range report.Steps {
+		if st.Status == batchOK {
+			passed++
+		}
+	}
+	fmt.Fprintf(w, "%d/%d steps passed\n", passed, len(report.Steps))
+	if report.Dir != "" {
+		fmt.Fprintf(w, "Session kept in %s\n", report.Dir)
+	}
+	return nil
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulateScriptFlag, "script", "", "scenario file to replay, in YAML or JSON ('-' reads stdin)")
+	simulateCmd.Flags().BoolVar(&simulateKeepFlag, "keep", false, "keep the temporary directory holding the simulated session")
+	rootCmd.AddCommand(simulateCmd)
+}
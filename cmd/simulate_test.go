@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+)
+
+func executeSimulate(t *testing.T, script string) (simulateReport, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatalf("writing scenario: %v", err)
+	}
+	out, _, err := executePhaseCmd(t, "simulate", "--script", path, "--format", "json")
+	var report simulateReport
+	if jsonErr := json.Unmarshal([]byte(out), &report); jsonErr != nil {
+		t.Fatalf("simulate output is not a JSON report: %v\n%s", jsonErr, out)
+	}
+	return report, err
+}
+
+func TestSimulateReplaysScenario(t *testing.T) {
+	origDir, _ := os.Getwd()
+	report, err := executeSimulate(t, `name: first cycle
+steps:
+  - cmd: init
+  - cmd: spec add
+    args: ["login works"]
+    expect:
+      active_specs: 1
+  - cmd: phase next
+    expect:
+      exit_code: 2
+      output_contains: no spec selected
+  - cmd: spec pick
+    args: [1]
+    expect:
+      current_spec: 1
+  - test: fail
+    output: |
+      --- FAIL: TestLogin (0.00s)
+      FAIL
+    expect:
+      last_test_result: fail
+  - cmd: phase next
+    expect:
+      phase: green
+`)
+	if err != nil {
+		t.Fatalf("simulate failed: %v\n%+v", err, report)
+	}
+	if !report.Passed || len(report.Steps) != 6 {
+		t.Fatalf("want 6 passing steps, got %+v", report)
+	}
+	if report.Steps[2].ExitCode != ExitBlocked || report.Steps[4].Command != "test fail" {
+		t.Errorf("unexpected steps: %+v", report.Steps)
+	}
+	if wd, _ := os.Getwd(); wd != origDir {
+		t.Errorf("simulate should restore the working directory, got %s", wd)
+	}
+	if session.Exists(origDir) {
+		t.Error("simulate should not touch the session in the working directory")
+	}
+}
+
+func TestSimulateStopsAtFirstFailingStep(t *testing.T) {
+	report, err := executeSimulate(t, `steps:
+  - cmd: init
+  - cmd: phase
+    expect:
+      phase: green
+  - cmd: spec add
+    args: [never]
+`)
+	if err == nil || !strings.Contains(err.Error(), "simulation failed at step 2") {
+		t.Fatalf("want a failure at step 2, got %v", err)
+	}
+	want := []string{batchOK, batchFailed, batchSkipped}
+	for i, st := range report.Steps {
+		if st.Status != want[i] {
+			t.Errorf("step %d status = %q, want %q", i+1, st.Status, want[i])
+		}
+	}
+	if report.Passed || len(report.Steps[1].Failures) != 1 || report.Steps[1].Failures[0] != "phase red, want green" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestSimulateRejectsExcludedCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	os.WriteFile(path, []byte("steps:\n  - cmd: secret list\n"), 0644)
+	_, _, err := executePhaseCmd(t, "simulate", "--script", path)
+	if ExitCode(err) != ExitInvalidInput || !strings.Contains(err.Error(), "cannot run inside a simulation") {
+		t.Errorf("want an invalid input error, got %v", err)
+	}
+}
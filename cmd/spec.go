@@ -1,11 +1,20 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/macosta/tdd-ai/internal/formatter"
 	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/specimport"
+	"github.com/macosta/tdd-ai/internal/speclint"
+	"github.com/macosta/tdd-ai/internal/suggest"
 	"github.com/macosta/tdd-ai/internal/types"
 	"github.com/spf13/cobra"
 )
@@ -19,44 +28,197 @@ var specCmd = &cobra.Command{
   tdd-ai spec done 1`,
 }
 
+var (
+	specAddCriteriaFlag   []string
+	specAddStdinFlag      bool
+	specAddRiskFlag       string
+	specAddDiscoveredFlag string
+	specAddAreaFlag       string
+)
+
 var specAddCmd = &cobra.Command{
-	Use:   "add \"description\"",
+	Use:   "add \"description\"... | --stdin",
 	Short: "Add a new spec to implement",
-	Long:  "Add one or more specs to the current TDD session. Each argument is a separate spec description.",
+	Long: `Add one or more specs to the current TDD session. Each argument is a separate spec description.
+
+Each spec gets a numeric ID and a slug generated from its description (e.g. SPEC-login-404)
+that can be used anywhere a spec ID is accepted.
+
+Use --criterion (repeatable) to attach acceptance criteria to a single spec. A spec with
+acceptance criteria cannot be completed until each one is checked off with
+'tdd-ai spec criteria check' or the criteria are explicitly waived.
+
+Use --stdin to read specs from stdin instead of arguments, for long generated lists
+that would hit shell argument limits: either a JSON array of descriptions, or one
+description per line (blank lines are skipped). Specs given as arguments are added
+first.
+
+Use --risk high for specs touching sensitive code such as auth or payments. Guide
+then shows the high-risk rules, and the spec cannot be finished until they are met:
+by default the "integration" test suite must pass during REFACTOR and a human must
+approve the iteration with 'tdd-ai review'. An organization policy can change these
+rules (see 'tdd-ai policy').
+
+Use --discovered-from <id> for ideas that come up while working on another spec,
+typically mid-GREEN: park them as specs instead of implementing them now. The new
+specs record the spec and phase they were discovered in, shown by 'spec show'.
+
+Use --area <path> in a monorepo with test areas ('tdd-ai init --test-area') to tag
+the specs with the area containing path, so 'tdd-ai test' runs that area's command
+while they are current.`,
 	Example: `  tdd-ai spec add "User can login with email and password"
-  tdd-ai spec add "Returns 404 when not found" "Returns 400 for invalid input"`,
-	Args: cobra.MinimumNArgs(1),
+  tdd-ai spec add "Returns 404 when not found" "Returns 400 for invalid input"
+  tdd-ai spec add "Password reset" --criterion "email is sent" --criterion "token expires after 1h"
+  tdd-ai spec add "Refunds are idempotent" --risk high
+  tdd-ai spec add --discovered-from 3 "Handle an empty cart"
+  tdd-ai spec add --area services/api "Returns 404 for an unknown order"
+  generate-tests | tdd-ai spec add --stdin
+  echo '["Returns 404 when not found", "Returns 400 for invalid input"]' | tdd-ai spec add --stdin`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if specAddStdinFlag {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if specAddStdinFlag {
+			data, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("reading specs from stdin: %w", err)
+			}
+			descs, err := parseSpecList(data)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			args = append(args, descs...)
+			if len(args) == 0 {
+				return invalidInputError(fmt.Errorf("no specs on stdin"))
+			}
+		}
+		if len(specAddCriteriaFlag) > 0 && len(args) > 1 {
+			return invalidInputError(fmt.Errorf("--criterion applies to a single spec; add specs one at a time or use 'tdd-ai spec criteria add'"))
+		}
+		if specAddRiskFlag != "" && !slices.Contains(types.Risks, specAddRiskFlag) {
+			return invalidInputError(fmt.Errorf("invalid --risk %q (valid: %s)", specAddRiskFlag, strings.Join(types.Risks, ", ")))
+		}
+
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
 		if err != nil {
 			return err
 		}
 
+		var discoveredFrom int
+		if specAddDiscoveredFlag != "" {
+			if discoveredFrom, err = s.ResolveSpecRef(specAddDiscoveredFlag); err != nil {
+				return invalidInputError(fmt.Errorf("--discovered-from: %w", err))
+			}
+			if s.SpecByID(discoveredFrom) == nil {
+				return invalidInputError(fmt.Errorf("--discovered-from: spec %d not found", discoveredFrom))
+			}
+		}
+
+		if specAddAreaFlag != "" {
+			if _, err := s.ResolveArea(specAddAreaFlag); err != nil {
+				return invalidInputError(fmt.Errorf("--area: %w", err))
+			}
+		}
+
+		added := make(map[int]bool, len(args))
+		var addedIDs []int
 		for _, desc := range args {
 			id := s.AddSpec(desc)
-			fmt.Fprintf(cmd.OutOrStdout(), "Spec [%d] added: %s\n", id, desc)
+			added[id] = true
+			addedIDs = append(addedIDs, id)
+			if discoveredFrom > 0 {
+				if err := s.MarkDiscovered(id, discoveredFrom); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Spec [%d] %s added: %s\n", id, s.Specs[len(s.Specs)-1].Slug, desc)
+			if specAddRiskFlag != "" {
+				if err := s.SetSpecRisk(id, specAddRiskFlag); err != nil {
+					return err
+				}
+			}
+			if specAddAreaFlag != "" {
+				if err := s.SetSpecArea(id, specAddAreaFlag); err != nil {
+					return err
+				}
+			}
+			if len(specAddCriteriaFlag) > 0 {
+				if err := s.AddSpecCriteria(id, specAddCriteriaFlag); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%d acceptance criteria recorded\n", len(specAddCriteriaFlag))
+			}
+		}
+
+		for _, issue := range speclint.Lint(s.Specs) {
+			if added[issue.SpecID] {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: spec [%d] %s\n", issue.SpecID, issue.Message)
+			}
 		}
 
 		s.AddEvent("spec_add", func(e *types.Event) {
 			e.SpecCount = len(args)
+			if discoveredFrom > 0 {
+				e.SpecID = discoveredFrom
+				e.SpecIDs = addedIDs
+				e.Reason = fmt.Sprintf("discovered during %s", s.Phase)
+			}
 		})
 
 		if err := session.Save(dir, s); err != nil {
 			return err
 		}
 
+		if discoveredFrom > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Parked as discovered from spec [%d]; keep working on the current spec\n", discoveredFrom)
+			return nil
+		}
 		fmt.Fprintln(cmd.OutOrStdout(), "Next: run 'tdd-ai guide --format json' for phase instructions")
 		return nil
 	},
 }
 
+// parseSpecList reads spec descriptions piped to 'spec add --stdin': a JSON
+// array of strings, or one description per line with blank lines skipped.
+func parseSpecList(data []byte) ([]string, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "[") {
+		var descs []string
+		if err := json.Unmarshal([]byte(text), &descs); err != nil {
+			return nil, fmt.Errorf("parsing specs from stdin: expected a JSON array of strings: %w", err)
+		}
+		var kept []string
+		for _, d := range descs {
+			if d = strings.TrimSpace(d); d != "" {
+				kept = append(kept, d)
+			}
+		}
+		return kept, nil
+	}
+	var descs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			descs = append(descs, line)
+		}
+	}
+	return descs, nil
+}
+
+var specListArchivedFlag bool
+
 var specListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all specs",
-	Long:  "Display all specs in the current session with their status (active or done).",
+	Long: `Display all specs in the current session with their status (active or done).
+
+Use --archived to list the completed specs moved out by 'tdd-ai spec archive' instead.`,
 	Example: `  tdd-ai spec list
-  tdd-ai spec list --format json`,
+  tdd-ai spec list --format json
+  tdd-ai spec list --archived`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
@@ -64,7 +226,21 @@ var specListCmd = &cobra.Command{
 			return err
 		}
 
-		if len(s.Specs) == 0 {
+		if specListArchivedFlag {
+			archived, err := session.LoadArchive(dir)
+			if err != nil {
+				return err
+			}
+			if len(archived) == 0 && formatter.Format(formatFlag) != formatter.FormatPorcelain {
+				fmt.Fprintln(cmd.OutOrStdout(), "No archived specs. Archive completed specs with 'tdd-ai spec archive --completed'")
+				return nil
+			}
+			view := *s
+			view.Specs = archived
+			s = &view
+		}
+
+		if len(s.Specs) == 0 && formatter.Format(formatFlag) != formatter.FormatPorcelain {
 			fmt.Fprintln(cmd.OutOrStdout(), "No specs defined. Add specs with 'tdd-ai spec add \"desc1\" \"desc2\" ...'")
 			return nil
 		}
@@ -78,22 +254,296 @@ var specListCmd = &cobra.Command{
 	},
 }
 
-var specDoneAll bool
+var specShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show everything about one spec",
+	Long: `Prints a single spec by ID or slug: its description, status, iterations, the
+spec it was split from or into, acceptance criteria and any waiver, the history
+events touching it, and its time metrics (created, picked, completed, cycle
+time from first pick to completion, and lead time from creation to completion).
+
+Specs moved out by 'tdd-ai spec archive' are found in the archive by ID.`,
+	Example: `  tdd-ai spec show 3
+  tdd-ai spec show SPEC-login-404
+  tdd-ai spec show 3 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+		id, err := s.ResolveSpecRef(args[0])
+		if err != nil {
+			return invalidInputError(err)
+		}
+
+		spec, archived := s.SpecByID(id), false
+		if spec == nil {
+			specs, err := session.LoadArchive(dir)
+			if err != nil {
+				return err
+			}
+			for i := range specs {
+				if specs[i].ID == id {
+					spec, archived = &specs[i], true
+				}
+			}
+		}
+		if spec == nil {
+			return invalidInputError(fmt.Errorf("spec %d not found", id))
+		}
+
+		f := formatter.Format(formatFlag)
+		if f != formatter.FormatJSON && f != formatter.FormatText {
+			return unknownFormatError(f)
+		}
+		out, err := formatter.FormatSpecDetail(s, *spec, archived, f)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), out)
+		return nil
+	},
+}
+
+var specArchiveCompletedFlag bool
+
+var specArchiveCmd = &cobra.Command{
+	Use:   "archive --completed",
+	Short: "Move completed specs out of the session file",
+	Long: `Moves completed specs to ` + session.ArchiveFileName + ` so they no longer inflate
+guide, status, and spec list output. Archived specs keep their IDs, are listed
+with 'tdd-ai spec list --archived', and are still included by 'tdd-ai verify'
+and 'tdd-ai export specs'.`,
+	Example: `  tdd-ai spec archive --completed
+  tdd-ai spec list --archived`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if !specArchiveCompletedFlag {
+			return invalidInputError(fmt.Errorf("spec archive requires --completed"))
+		}
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		archived := s.RemoveCompletedSpecs()
+		if len(archived) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No completed specs to archive.")
+			return nil
+		}
+		if err := session.AppendArchive(dir, archived); err != nil {
+			return err
+		}
+		ids := make([]int, len(archived))
+		for i, spec := range archived {
+			ids[i] = spec.ID
+		}
+		s.AddEvent("specs_archived", func(e *types.Event) {
+			e.SpecIDs = ids
+			e.SpecCount = len(ids)
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Archived %d completed spec(s) to %s\n", len(archived), session.ArchiveFileName)
+		return nil
+	},
+}
+
+var specImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import specs from another session, a spec export, or an issue dump",
+	Long: `Adds specs from a file to the session. The file can be another session file,
+the output of 'tdd-ai export specs --format json', or a JSON array of issues such
+as 'gh issue list --json number,title,state' (closed issues import as completed).
+Use - to read from stdin.
+
+Specs whose description matches an existing spec, ignoring case and spacing, are
+not added again. The rest get the session's next IDs in order of their old IDs,
+so the same file always maps the same way. The old-to-new ID mapping is printed
+(as a "mapping" array with --format json) so external references stay resolvable.`,
+	Example: `  tdd-ai spec import ../other-service/.tdd-ai.json
+  gh issue list --json number,title,state | tdd-ai spec import - --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		if args[0] == "-" {
+			data, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return invalidInputError(fmt.Errorf("reading import file: %w", err))
+		}
+		specs, err := specimport.Parse(data)
+		if err != nil {
+			return invalidInputError(err)
+		}
+
+		res := specimport.Import(s, specs)
+		if res.Added > 0 {
+			s.AddEvent("spec_import", func(e *types.Event) {
+				e.SpecCount = res.Added
+			})
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding import result: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Imported %d spec(s): %d remapped, %d duplicate(s) skipped\n", res.Added, res.Remapped, res.Duplicates)
+			for _, m := range res.Mapping {
+				fmt.Fprintf(w, "  %d -> %d  %-9s %s\n", m.OldID, m.NewID, m.Status, m.Description)
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var specLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check active specs for vague, oversized, or duplicate descriptions",
+	Long: `Flags specs that are too vague to drive a failing test ("make it work"), specs
+that bundle several behaviors and should be split, and near-duplicate specs.
+
+Lint findings are advisory: the command always exits 0.`,
+	Example: `  tdd-ai spec lint
+  tdd-ai spec lint --format json`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		issues := speclint.Lint(s.Specs)
+		if issues == nil {
+			issues = []speclint.Issue{}
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(issues, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding lint result: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(issues) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No spec issues found.")
+				return nil
+			}
+			for _, issue := range issues {
+				fmt.Fprintf(cmd.OutOrStdout(), "  [spec %d] %s: %s\n", issue.SpecID, issue.Rule, issue.Message)
+				if issue.Suggestion != "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "      -> %s\n", issue.Suggestion)
+				}
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var specSplitCmd = &cobra.Command{
+	Use:   "split <id> \"child 1\" \"child 2\" [...]",
+	Short: "Replace a spec with smaller child specs",
+	Long: `Split an active spec into two or more child specs. The original is marked as
+superseded and linked to its children, and each child records its parent ID.
+
+If the split spec was the current pick, the pick moves to the first child.`,
+	Example: `  tdd-ai spec split 4 "Returns 400 for missing email" "Returns 400 for malformed email"`,
+	Args:    cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		id, err := s.ResolveSpecRef(args[0])
+		if err != nil {
+			return invalidInputError(err)
+		}
+
+		childIDs, err := s.SplitSpec(id, args[1:])
+		if err != nil {
+			return err
+		}
+
+		s.AddEvent("spec_split", func(e *types.Event) {
+			e.SpecID = id
+			e.SpecIDs = childIDs
+			e.SpecCount = len(childIDs)
+		})
+
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Spec [%d] split into %d specs:\n", id, len(childIDs))
+		for i, childID := range childIDs {
+			fmt.Fprintf(cmd.OutOrStdout(), "  [%d] %s\n", childID, args[i+1])
+		}
+		if cs := s.CurrentSpec(); cs != nil && cs.ParentID == id {
+			fmt.Fprintf(cmd.OutOrStdout(), "Current spec is now [%d]\n", cs.ID)
+		}
+		return nil
+	},
+}
+
+var (
+	specDoneAll       bool
+	specDoneYesFlag   bool
+	specDoneWaiveFlag string
+)
 
 var specDoneCmd = &cobra.Command{
 	Use:   "done <id> [id...]",
 	Short: "Mark a spec as completed",
-	Long:  "Mark one or more specs as completed by their ID. Use --all to mark every active spec as done.",
+	Long: `Mark one or more specs as completed by their ID or slug. Use --all to mark every active spec as done.
+
+Closing every spec at once skips the loop, so --all lists the specs and asks for
+confirmation in a terminal. Without a terminal it requires --yes. The spec_done
+event records whether it was confirmed interactively or forced.
+
+Specs with acceptance criteria can only be completed once every criterion is checked off
+with 'tdd-ai spec criteria check'. Use --waive to complete them anyway, recording why.`,
 	Example: `  tdd-ai spec done 1
   tdd-ai spec done 1 2 3
-  tdd-ai spec done --all`,
+  tdd-ai spec done --all
+  tdd-ai spec done --all --yes
+  tdd-ai spec done 4 --waive "token expiry is covered by the auth service"`,
 	Args: cobra.MinimumNArgs(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if specDoneAll && len(args) > 0 {
-			return fmt.Errorf("cannot use --all with specific spec IDs")
+			return invalidInputError(fmt.Errorf("cannot use --all with specific spec IDs"))
 		}
 		if !specDoneAll && len(args) == 0 {
-			return fmt.Errorf("provide at least one spec ID, or use --all")
+			return invalidInputError(fmt.Errorf("provide at least one spec ID, or use --all"))
 		}
 
 		dir := getWorkDir()
@@ -103,12 +553,36 @@ var specDoneCmd = &cobra.Command{
 		}
 
 		if specDoneAll {
-			count := s.CompleteAllSpecs()
-			if count == 0 {
+			var ids []int
+			for _, spec := range s.ActiveSpecs() {
+				ids = append(ids, spec.ID)
+			}
+			if len(ids) == 0 {
 				return fmt.Errorf("no active specs to mark as done")
 			}
+			if err := checkCriteriaSignOff(s, ids, specDoneWaiveFlag); err != nil {
+				return blockedError(err)
+			}
+			confirmed := types.ConfirmedForced
+			if !specDoneYesFlag {
+				if !isTerminal() {
+					return invalidInputError(fmt.Errorf("spec done --all would close %d active spec(s); pass --yes to confirm (there is no terminal to ask)", len(ids)))
+				}
+				ok, err := confirmDoneAll(cmd, s.ActiveSpecs())
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Fprintln(cmd.OutOrStdout(), "Aborted; no specs were changed.")
+					return nil
+				}
+				confirmed = types.ConfirmedInteractive
+			}
+			count := s.CompleteAllSpecs()
 			s.AddEvent("spec_done", func(e *types.Event) {
 				e.SpecCount = count
+				e.SpecIDs = ids
+				e.Confirmed = confirmed
 			})
 			if err := session.Save(dir, s); err != nil {
 				return err
@@ -118,11 +592,18 @@ var specDoneCmd = &cobra.Command{
 			return nil
 		}
 
-		for _, arg := range args {
-			id, err := strconv.Atoi(arg)
+		ids := make([]int, len(args))
+		for i, arg := range args {
+			id, err := s.ResolveSpecRef(arg)
 			if err != nil {
-				return fmt.Errorf("spec ID must be a number, got %q", arg)
+				return invalidInputError(err)
 			}
+			ids[i] = id
+		}
+		if err := checkCriteriaSignOff(s, ids, specDoneWaiveFlag); err != nil {
+			return blockedError(err)
+		}
+		for _, id := range ids {
 			if err := s.CompleteSpec(id); err != nil {
 				return err
 			}
@@ -146,14 +627,317 @@ var specDoneCmd = &cobra.Command{
 	},
 }
 
+// confirmDoneAll lists the specs 'spec done --all' is about to close and asks
+// for a y/N answer; anything but yes declines.
+func confirmDoneAll(cmd *cobra.Command, specs []types.Spec) (bool, error) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "About to mark these specs as done:")
+	for _, spec := range specs {
+		fmt.Fprintf(out, "  [%d] %s\n", spec.ID, spec.Description)
+	}
+	fmt.Fprintf(out, "Mark %d spec(s) as done? [y/N]: ", len(specs))
+	line, err := readLine(bufio.NewReader(cmd.InOrStdin()))
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(line) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// checkCriteriaSignOff returns an error naming the first spec among ids with
+// unchecked acceptance criteria. With a waiver reason, it instead records the
+// waiver on each such spec.
+func checkCriteriaSignOff(s *types.Session, ids []int, waive string) error {
+	unsigned := s.UnsignedSpecs(ids)
+	if len(unsigned) == 0 {
+		return nil
+	}
+	if strings.TrimSpace(waive) == "" {
+		spec := unsigned[0]
+		unmet := spec.UnmetCriteria()
+		return fmt.Errorf("cannot complete spec %d: %d acceptance criteria unchecked. Check them with 'tdd-ai spec criteria check %d %d', or use --waive <reason>", spec.ID, len(unmet), spec.ID, unmet[0].ID)
+	}
+	for _, spec := range unsigned {
+		if err := s.WaiveSpecCriteria(spec.ID, waive); err != nil {
+			return err
+		}
+		s.AddEvent("criteria_waived", func(e *types.Event) {
+			e.SpecID = spec.ID
+			e.Reason = waive
+		})
+	}
+	return nil
+}
+
+var specRiskCmd = &cobra.Command{
+	Use:   "risk <id> <low|medium|high>",
+	Short: "Set a spec's risk level",
+	Long: `Set how risky a spec is. High-risk specs, such as ones touching auth or payments,
+get extra rules in guide and cannot be finished until they are met: by default the
+"integration" test suite must pass during REFACTOR and a human must approve the
+iteration with 'tdd-ai review'. An organization policy can change these rules.`,
+	Example: `  tdd-ai spec risk 3 high
+  tdd-ai spec risk SPEC-refunds low`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		id, err := s.ResolveSpecRef(args[0])
+		if err != nil {
+			return invalidInputError(err)
+		}
+		if err := s.SetSpecRisk(id, args[1]); err != nil {
+			return invalidInputError(err)
+		}
+		s.AddEvent("spec_risk", func(e *types.Event) {
+			e.SpecID = id
+			e.Result = args[1]
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Spec [%d] risk set to %s\n", id, args[1])
+		return nil
+	},
+}
+
+var specAreaCmd = &cobra.Command{
+	Use:   "area <id> <path>",
+	Short: "Tag a spec with a monorepo test area",
+	Long: `Tag a spec with the test area containing path, as configured with
+'tdd-ai init --test-area'. While the spec is current, 'tdd-ai test' runs only
+that area's test command. An empty path clears the tag.`,
+	Example: `  tdd-ai spec area 3 services/api
+  tdd-ai spec area SPEC-checkout-total web/src/cart
+  tdd-ai spec area 3 ""`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		id, err := s.ResolveSpecRef(args[0])
+		if err != nil {
+			return invalidInputError(err)
+		}
+		if err := s.SetSpecArea(id, args[1]); err != nil {
+			return invalidInputError(err)
+		}
+		area := s.SpecByID(id).Area
+		s.AddEvent("spec_area", func(e *types.Event) {
+			e.SpecID = id
+			e.Result = area
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		if area == "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Spec [%d] test area cleared\n", id)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Spec [%d] test area set to %s: %s\n", id, area, s.TestAreas[area])
+		}
+		return nil
+	},
+}
+
+var (
+	specReorderBeforeFlag string
+	specReorderAfterFlag  string
+)
+
+var specReorderCmd = &cobra.Command{
+	Use:   "reorder <id> --before <id> | reorder <id>...",
+	Short: "Set the order active specs should be implemented in",
+	Long: `Make the intended implementation sequence explicit. Move one spec with --before
+or --after another, or list specs in the order they should be picked; active
+specs not listed follow in ID order. 'tdd-ai spec pick --next', the spec lists
+in guide and status, and the interactive chooser follow this order.`,
+	Example: `  tdd-ai spec reorder 5 --before 2
+  tdd-ai spec reorder SPEC-refund-total --after 3
+  tdd-ai spec reorder 4 1 3 2`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		before, after := specReorderBeforeFlag, specReorderAfterFlag
+		if before != "" && after != "" {
+			return invalidInputError(fmt.Errorf("--before and --after cannot be used together"))
+		}
+		if (before != "" || after != "") && len(args) != 1 {
+			return invalidInputError(fmt.Errorf("--before and --after move a single spec, got %d", len(args)))
+		}
+
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, 0, len(args))
+		for _, arg := range args {
+			id, err := s.ResolveSpecRef(arg)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			ids = append(ids, id)
+		}
+		if target := before + after; target != "" {
+			targetID, err := s.ResolveSpecRef(target)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			err = s.MoveSpec(ids[0], targetID, after != "")
+			if err != nil {
+				return invalidInputError(err)
+			}
+		} else if err := s.SetSpecOrder(ids); err != nil {
+			return invalidInputError(err)
+		}
+
+		// Only the specs named are recorded, so reordering does not count as
+		// work on every spec and hide stale ones.
+		s.AddEvent("spec_reorder", func(e *types.Event) {
+			e.SpecIDs = ids
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "Implementation order:")
+		for i, spec := range s.ActiveSpecs() {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %d. [%d] %s\n", i+1, spec.ID, spec.Description)
+		}
+		return nil
+	},
+}
+
+var specCriteriaCmd = &cobra.Command{
+	Use:   "criteria",
+	Short: "Manage a spec's acceptance criteria",
+	Long: `Add or check off acceptance criteria on a spec. A spec with acceptance criteria
+cannot be completed, by 'tdd-ai spec done' or by leaving REFACTOR, until every
+criterion is checked off or the criteria are waived with --waive <reason>.`,
+	Example: `  tdd-ai spec criteria add 3 "email is sent" "token expires after 1h"
+  tdd-ai spec criteria check 3 1`,
+}
+
+var specCriteriaAddCmd = &cobra.Command{
+	Use:     "add <id> \"criterion\" [...]",
+	Short:   "Add acceptance criteria to a spec",
+	Example: `  tdd-ai spec criteria add 3 "email is sent" "token expires after 1h"`,
+	Args:    cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		id, err := s.ResolveSpecRef(args[0])
+		if err != nil {
+			return invalidInputError(err)
+		}
+		if err := s.AddSpecCriteria(id, args[1:]); err != nil {
+			return err
+		}
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%d acceptance criteria added to spec [%d]\n", len(args)-1, id)
+		return nil
+	},
+}
+
+var specCriteriaUndoFlag bool
+
+var specCriteriaCheckCmd = &cobra.Command{
+	Use:   "check <id> <criterion-number>",
+	Short: "Check off a spec's acceptance criterion",
+	Long:  "Mark a spec's acceptance criterion as met. Use --undo to mark it unmet again.",
+	Example: `  tdd-ai spec criteria check 3 1
+  tdd-ai spec criteria check 3 1 --undo`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		id, err := s.ResolveSpecRef(args[0])
+		if err != nil {
+			return invalidInputError(err)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return invalidInputError(fmt.Errorf("criterion number must be an integer, got %q", args[1]))
+		}
+		if err := s.CheckSpecCriterion(id, n, !specCriteriaUndoFlag); err != nil {
+			return err
+		}
+		s.AddEvent("spec_criteria_check", func(e *types.Event) {
+			e.SpecID = id
+			e.Result = fmt.Sprintf("c%d", n)
+			if specCriteriaUndoFlag {
+				e.Result += " undone"
+			}
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		spec := s.SpecByID(id)
+		fmt.Fprintf(cmd.OutOrStdout(), "Criterion %d of spec [%d] updated. %d of %d criteria still open.\n", n, id, len(spec.UnmetCriteria()), len(spec.Criteria))
+		return nil
+	},
+}
+
+var (
+	specPickBatch    bool
+	specPickNextFlag bool
+)
+
 var specPickCmd = &cobra.Command{
-	Use:   "pick <id>",
+	Use:   "pick [id...]",
 	Short: "Pick a spec to work on in this iteration",
-	Long:  "Select an active spec to focus on for the current RED-GREEN-REFACTOR iteration.",
+	Long: `Select an active spec to focus on for the current RED-GREEN-REFACTOR iteration.
+Specs can be referenced by numeric ID or by slug (e.g. SPEC-login-404).
+
+Use --batch with several IDs to bundle trivially related specs into one pass, for
+cases where a single test naturally covers several tiny specs. All specs in the
+group are completed together when leaving REFACTOR.
+
+Use --next to pick the first remaining spec in the order set with
+'tdd-ai spec reorder', or the lowest ID when no order is set.
+
+Run in a terminal without an ID to choose from a numbered list of active specs.`,
 	Example: `  tdd-ai spec pick 1
-  tdd-ai spec pick 3`,
-	Args: cobra.ExactArgs(1),
+  tdd-ai spec pick 3
+  tdd-ai spec pick SPEC-login-404
+  tdd-ai spec pick 3 4 5 --batch
+  tdd-ai spec pick --next
+  tdd-ai spec pick`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if specPickNextFlag && len(args) > 0 {
+			return invalidInputError(fmt.Errorf("--next cannot be combined with spec IDs"))
+		}
+		if len(args) == 0 && !specPickNextFlag && !isTerminal() {
+			return invalidInputError(fmt.Errorf("spec pick requires a spec ID or slug, or --next (the interactive chooser needs a terminal)"))
+		}
+		if len(args) > 1 && !specPickBatch {
+			return invalidInputError(fmt.Errorf("picking more than one spec requires --batch"))
+		}
+
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
 		if err != nil {
@@ -161,40 +945,243 @@ var specPickCmd = &cobra.Command{
 		}
 
 		if s.Phase != types.PhaseRed {
-			return fmt.Errorf("can only pick a spec during the RED phase (current phase: %s)", s.Phase)
+			return blockedError(fmt.Errorf("can only pick a spec during the RED phase (current phase: %s)", s.Phase))
+		}
+		if err := checkPairRole(s); err != nil {
+			return err
 		}
 
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			return fmt.Errorf("spec ID must be a number, got %q", args[0])
+		if specPickNextFlag {
+			next := s.NextSpec()
+			if next == nil {
+				return blockedError(fmt.Errorf("no remaining active specs to pick. Add one with 'tdd-ai spec add'"))
+			}
+			args = []string{strconv.Itoa(next.ID)}
+		}
+		if len(args) == 0 {
+			ref, err := chooseSpec(cmd, s)
+			if err != nil {
+				return err
+			}
+			if ref == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), "No spec picked.")
+				return nil
+			}
+			args = []string{ref}
 		}
 
-		if err := s.SetCurrentSpec(id); err != nil {
+		ids := make([]int, 0, len(args))
+		for _, arg := range args {
+			id, err := s.ResolveSpecRef(arg)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			ids = append(ids, id)
+		}
+
+		if err := s.SetPickGroup(ids); err != nil {
 			return err
 		}
+		// Remember how many tests existed before work on this spec started
+		s.BaselineTestCount = s.LastTestCount
+		s.NewTestNames = nil
 
 		s.AddEvent("spec_picked", func(e *types.Event) {
-			e.SpecID = id
+			e.SpecID = ids[0]
+			if len(ids) > 1 {
+				e.SpecIDs = ids
+			}
 		})
 
 		if err := session.Save(dir, s); err != nil {
 			return err
 		}
 
-		spec := s.CurrentSpec()
 		remaining := s.RemainingSpecs()
-		fmt.Fprintf(cmd.OutOrStdout(), "Picked spec [%d]: %s\n", spec.ID, spec.Description)
+		if group := s.PickGroupSpecs(); len(group) > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Picked %d specs as a batch:\n", len(group))
+			for _, spec := range group {
+				fmt.Fprintf(cmd.OutOrStdout(), "  [%d] %s\n", spec.ID, spec.Description)
+			}
+		} else {
+			spec := s.CurrentSpec()
+			fmt.Fprintf(cmd.OutOrStdout(), "Picked spec [%d]: %s\n", spec.ID, spec.Description)
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "%d spec(s) remaining after this one\n", len(remaining))
 		fmt.Fprintln(cmd.OutOrStdout(), "Next: run 'tdd-ai guide --format json' for phase instructions")
 		return nil
 	},
 }
 
+// chooseSpec lists the active specs and prompts for one by list number or slug.
+// It returns the chosen spec as a reference for ResolveSpecRef, or "" when the
+// user cancels with q.
+func chooseSpec(cmd *cobra.Command, s *types.Session) (string, error) {
+	out := cmd.OutOrStdout()
+	active := s.ActiveSpecs()
+	if len(active) == 0 {
+		return "", blockedError(fmt.Errorf("no active specs to pick. Add one with 'tdd-ai spec add'"))
+	}
+
+	fmt.Fprintln(out, "Active specs:")
+	for i, spec := range active {
+		fmt.Fprintf(out, "  %d) [%d] %s  %s", i+1, spec.ID, spec.Slug, spec.Description)
+		if spec.Iterations > 0 {
+			fmt.Fprintf(out, "  (iteration %d)", spec.Iterations)
+		}
+		fmt.Fprintln(out)
+	}
+
+	in := bufio.NewReader(cmd.InOrStdin())
+	for {
+		fmt.Fprintf(out, "Pick a spec [1-%d], or q to cancel: ", len(active))
+		line, err := readLine(in)
+		if err != nil {
+			return "", err
+		}
+		if line == "q" {
+			return "", nil
+		}
+		if n, err := strconv.Atoi(line); err == nil {
+			if n >= 1 && n <= len(active) {
+				return strconv.Itoa(active[n-1].ID), nil
+			}
+		} else if _, err := s.ResolveSpecRef(line); err == nil {
+			return line, nil
+		}
+		fmt.Fprintf(out, "Enter a number between 1 and %d.\n", len(active))
+	}
+}
+
+var (
+	specSuggestFromFlag []string
+	specSuggestAddFlag  bool
+)
+
+var specSuggestCmd = &cobra.Command{
+	Use:   "suggest --from <glob> [file...]",
+	Short: "Propose characterization specs for exported functions in source files",
+	Long: `Parses Go source files and proposes a characterization-test spec for every
+exported function and method, plus one for the error cases of functions that
+return an error. Test files are skipped; directories expand to their .go files.
+
+Suggestions are only printed, so the agent can review them first. Re-run with
+--add to add them to the session; suggestions that already have a spec are skipped.`,
+	Example: `  tdd-ai spec suggest --from 'internal/pricing/*.go'
+  tdd-ai spec suggest --from internal/pricing --add
+  tdd-ai spec suggest --from 'internal/pricing/*.go' --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		patterns := append(append([]string{}, specSuggestFromFlag...), args...)
+		if len(patterns) == 0 {
+			return invalidInputError(fmt.Errorf("--from is required"))
+		}
+		files, err := suggest.Expand(patterns)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		suggestions, err := suggest.FromGoFiles(files)
+		if err != nil {
+			return err
+		}
+		if suggestions == nil {
+			suggestions = []suggest.Suggestion{}
+		}
+
+		var added []int
+		if specSuggestAddFlag {
+			dir := getWorkDir()
+			s, err := session.LoadOrFail(dir)
+			if err != nil {
+				return err
+			}
+			existing := make(map[string]bool, len(s.Specs))
+			for _, spec := range s.Specs {
+				existing[spec.Description] = true
+			}
+			for _, sg := range suggestions {
+				if existing[sg.Spec] {
+					continue
+				}
+				existing[sg.Spec] = true
+				added = append(added, s.AddSpec(sg.Spec))
+			}
+			if len(added) > 0 {
+				s.AddEvent("spec_add", func(e *types.Event) {
+					e.SpecCount = len(added)
+					e.Result = "suggested"
+				})
+				if err := session.Save(dir, s); err != nil {
+					return err
+				}
+			}
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			out := struct {
+				Suggestions []suggest.Suggestion `json:"suggestions"`
+				AddedSpecs  []int                `json:"added_specs,omitempty"`
+			}{suggestions, added}
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding spec suggestions: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			if len(suggestions) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No exported functions found.")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Suggested specs (%d):\n", len(suggestions))
+			for _, sg := range suggestions {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s  (%s:%d)\n", sg.Spec, sg.File, sg.Line)
+			}
+			if specSuggestAddFlag {
+				fmt.Fprintf(cmd.OutOrStdout(), "\nAdded %d spec(s)\n", len(added))
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout(), "\nNext: review the list, then re-run with --add or add chosen specs with 'tdd-ai spec add'")
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
 func init() {
 	specDoneCmd.Flags().BoolVar(&specDoneAll, "all", false, "mark all active specs as done")
+	specDoneCmd.Flags().BoolVar(&specDoneYesFlag, "yes", false, "with --all, skip the confirmation prompt (required without a terminal)")
+	specDoneCmd.Flags().StringVar(&specDoneWaiveFlag, "waive", "", "complete specs with unchecked acceptance criteria, recording this reason")
+	specAddCmd.Flags().StringArrayVar(&specAddCriteriaFlag, "criterion", nil, "acceptance criterion for the spec (repeatable)")
+	specAddCmd.Flags().BoolVar(&specAddStdinFlag, "stdin", false, "read specs from stdin: a JSON array, or one per line")
+	specAddCmd.Flags().StringVar(&specAddRiskFlag, "risk", "", "risk level of the added specs: low, medium, or high")
+	specAddCmd.Flags().StringVar(&specAddAreaFlag, "area", "", "tag the added specs with the test area containing this path")
+	specAddCmd.Flags().StringVar(&specAddDiscoveredFlag, "discovered-from", "", "ID or slug of the spec being worked on when these specs came up")
+	specCriteriaCheckCmd.Flags().BoolVar(&specCriteriaUndoFlag, "undo", false, "mark the criterion as not met")
+	specCriteriaCmd.AddCommand(specCriteriaAddCmd)
+	specCriteriaCmd.AddCommand(specCriteriaCheckCmd)
+	specCmd.AddCommand(specCriteriaCmd)
+	specPickCmd.Flags().BoolVar(&specPickBatch, "batch", false, "pick several related specs as one group")
+	specPickCmd.Flags().BoolVar(&specPickNextFlag, "next", false, "pick the first remaining spec in implementation order")
+	specReorderCmd.Flags().StringVar(&specReorderBeforeFlag, "before", "", "move the spec directly before this spec")
+	specReorderCmd.Flags().StringVar(&specReorderAfterFlag, "after", "", "move the spec directly after this spec")
+	specSuggestCmd.Flags().StringArrayVar(&specSuggestFromFlag, "from", nil, "Go source file, directory, or glob to scan (repeatable)")
+	specSuggestCmd.Flags().BoolVar(&specSuggestAddFlag, "add", false, "add the suggested specs to the session")
 	specCmd.AddCommand(specAddCmd)
+	specListCmd.Flags().BoolVar(&specListArchivedFlag, "archived", false, "list archived specs instead of the session's specs")
+	specArchiveCmd.Flags().BoolVar(&specArchiveCompletedFlag, "completed", false, "archive every completed spec")
 	specCmd.AddCommand(specListCmd)
+	specCmd.AddCommand(specShowCmd)
+	specCmd.AddCommand(specArchiveCmd)
+	specCmd.AddCommand(specImportCmd)
 	specCmd.AddCommand(specDoneCmd)
 	specCmd.AddCommand(specPickCmd)
+	specCmd.AddCommand(specLintCmd)
+	specCmd.AddCommand(specSplitCmd)
+	specCmd.AddCommand(specSuggestCmd)
+	specCmd.AddCommand(specRiskCmd)
+	specCmd.AddCommand(specAreaCmd)
+	specCmd.AddCommand(specReorderCmd)
 	rootCmd.AddCommand(specCmd)
 }
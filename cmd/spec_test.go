@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/specimport"
 	"github.com/macosta/tdd-ai/internal/types"
 )
 
@@ -68,6 +72,62 @@ func TestSpecPickSetsCurrentSpecID(t *testing.T) {
 	}
 }
 
+func TestSpecReorderAndPickNext(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("first spec")
+	s.AddSpec("second spec")
+	s.AddSpec("third spec")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer resetFlags(specReorderCmd.Flags())
+	defer resetFlags(specPickCmd.Flags())
+
+	out, err := executeSpecCmd(t, "spec", "reorder", "3", "--before", "1", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec reorder failed: %v", err)
+	}
+	if !strings.Contains(out, "1. [3] third spec") || !strings.Contains(out, "3. [2] second spec") {
+		t.Errorf("should print the new order, got:\n%s", out)
+	}
+	resetFlags(specReorderCmd.Flags())
+
+	out, err = executeSpecCmd(t, "spec", "pick", "--next", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec pick --next failed: %v", err)
+	}
+	if !strings.Contains(out, "Picked spec [3]") {
+		t.Errorf("pick --next should honor the explicit order, got:\n%s", out)
+	}
+
+	if _, err := executeSpecCmd(t, "spec", "reorder", "2", "--before", "1", "--after", "3", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("--before with --after should be invalid input, got %v", err)
+	}
+	resetFlags(specReorderCmd.Flags())
+	if _, err := executeSpecCmd(t, "spec", "pick", "--next", "2", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("--next with an ID should be invalid input, got %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	if !slices.Equal(loaded.SpecOrder, []int{3, 1, 2}) {
+		t.Errorf("SpecOrder = %v, want [3 1 2]", loaded.SpecOrder)
+	}
+	found := false
+	for _, ev := range loaded.History {
+		if ev.Action == "spec_reorder" && slices.Equal(ev.SpecIDs, []int{3}) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("should record a spec_reorder event for the moved spec")
+	}
+}
+
 func TestSpecPickRejectsInvalidID(t *testing.T) {
 	dir := t.TempDir()
 	s := types.NewSession()
@@ -150,3 +210,714 @@ func TestSpecListMarksCurrentSpec(t *testing.T) {
 		t.Errorf("spec list should mark current spec, got:\n%s", out)
 	}
 }
+
+func TestSpecPickMultipleRequiresBatch(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("first spec")
+	s.AddSpec("second spec")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	specPickBatch = false
+	_, err := executeSpecCmd(t, "spec", "pick", "1", "2", "--format", "text")
+	if err == nil {
+		t.Fatal("spec pick with several IDs should require --batch")
+	}
+	if !strings.Contains(err.Error(), "--batch") {
+		t.Errorf("error should mention --batch, got: %v", err)
+	}
+}
+
+func TestSpecPickBatchStoresPickGroup(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("first spec")
+	s.AddSpec("second spec")
+	s.AddSpec("third spec")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specPickBatch = false }()
+
+	out, err := executeSpecCmd(t, "spec", "pick", "1", "2", "--batch", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec pick --batch failed: %v", err)
+	}
+	if !strings.Contains(out, "Picked 2 specs as a batch") {
+		t.Errorf("should confirm batch pick, got:\n%s", out)
+	}
+	if !strings.Contains(out, "1 spec(s) remaining") {
+		t.Errorf("should show remaining count, got:\n%s", out)
+	}
+
+	loaded, err := session.Load(dir)
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if len(loaded.PickGroup) != 2 {
+		t.Errorf("PickGroup = %v, want [1 2]", loaded.PickGroup)
+	}
+	last := loaded.History[len(loaded.History)-1]
+	if last.Action != "spec_picked" || len(last.SpecIDs) != 2 {
+		t.Errorf("should record spec_picked event with all IDs, got %+v", last)
+	}
+}
+
+func TestSpecAddWarnsAboutVagueSpecs(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, errOut, err := executePhaseCmd(t, "spec", "add", "make it work", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec add should still succeed for vague specs: %v", err)
+	}
+	if !strings.Contains(errOut, "Warning: spec [1]") {
+		t.Errorf("should warn about vague spec on stderr, got:\n%s", errOut)
+	}
+}
+
+func TestSpecLintReportsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("Returns 404 when user is not found")
+	s.AddSpec("Returns 404 when the user is not found")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeSpecCmd(t, "spec", "lint", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec lint failed: %v", err)
+	}
+	if !strings.Contains(out, "duplicate") {
+		t.Errorf("should report duplicate specs, got:\n%s", out)
+	}
+}
+
+func TestSpecSplitRecordsEventAndListsChildren(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("validates email")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeSpecCmd(t, "spec", "split", "1", "rejects missing email", "rejects malformed email", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec split failed: %v", err)
+	}
+	if !strings.Contains(out, "Spec [1] split into 2 specs") {
+		t.Errorf("should confirm split, got:\n%s", out)
+	}
+
+	out, err = executeSpecCmd(t, "spec", "list", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec list failed: %v", err)
+	}
+	if !strings.Contains(out, "(split into 2, 3)") {
+		t.Errorf("spec list should show the split link, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	last := loaded.History[len(loaded.History)-1]
+	if last.Action != "spec_split" || last.SpecID != 1 || len(last.SpecIDs) != 2 {
+		t.Errorf("should record spec_split event, got %+v", last)
+	}
+}
+
+func TestSpecPickAcceptsSlug(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("Login returns 404 for unknown user")
+	s.AddSpec("Logout clears session")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeSpecCmd(t, "spec", "pick", "SPEC-logout-clears-session", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec pick by slug failed: %v", err)
+	}
+	if !strings.Contains(out, "Picked spec [2]") {
+		t.Errorf("should pick spec 2 by slug, got:\n%s", out)
+	}
+
+	if _, err := executeSpecCmd(t, "spec", "pick", "SPEC-nope", "--format", "text"); err == nil {
+		t.Error("spec pick should reject unknown slugs")
+	}
+}
+
+func TestSpecSuggestAddsSpecsOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	src := "package pricing\n\nfunc Total(n int) int { return n }\n"
+	if err := os.WriteFile(filepath.Join(dir, "pricing.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specSuggestFromFlag = nil; specSuggestAddFlag = false }()
+
+	out, err := executeSpecCmd(t, "spec", "suggest", "--from", "*.go", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec suggest failed: %v", err)
+	}
+	if !strings.Contains(out, "Characterize current behavior of Total in pricing.go") {
+		t.Errorf("expected suggestion for Total, got: %s", out)
+	}
+	if loaded, _ := session.Load(dir); len(loaded.Specs) != 0 {
+		t.Errorf("suggest without --add should not add specs, got %d", len(loaded.Specs))
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := executeSpecCmd(t, "spec", "suggest", "--from", "*.go", "--add", "--format", "text"); err != nil {
+			t.Fatalf("spec suggest --add failed: %v", err)
+		}
+	}
+	if loaded, _ := session.Load(dir); len(loaded.Specs) != 1 {
+		t.Errorf("expected exactly 1 spec after repeated --add, got %d", len(loaded.Specs))
+	}
+}
+
+func TestSpecSuggestRequiresFrom(t *testing.T) {
+	defer func() { specSuggestFromFlag = nil; specSuggestAddFlag = false }()
+	_, err := executeSpecCmd(t, "spec", "suggest", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("expected invalid input error, got: %v", err)
+	}
+}
+
+func TestSpecPickInteractiveChooser(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("first feature")
+	s.AddSpec("second feature")
+	_ = s.CompleteSpec(1)
+	s.AddSpec("third feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	origIsTerminal := isTerminal
+	isTerminal = func() bool { return true }
+	defer func() { isTerminal = origIsTerminal }()
+
+	rootCmd.SetIn(strings.NewReader("9\n2\n"))
+	defer rootCmd.SetIn(nil)
+
+	out, err := executeSpecCmd(t, "spec", "pick", "--format", "text")
+	if err != nil {
+		t.Fatalf("interactive spec pick failed: %v", err)
+	}
+	if strings.Contains(out, "first feature") {
+		t.Errorf("chooser should only list active specs, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Enter a number between 1 and 2") {
+		t.Errorf("out-of-range choice should re-prompt, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.CurrentSpecID == nil || *loaded.CurrentSpecID != 3 {
+		t.Errorf("expected list entry 2 (spec 3) to be picked, got %v", loaded.CurrentSpecID)
+	}
+}
+
+func TestSpecPickWithoutIDRequiresTerminal(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	origIsTerminal := isTerminal
+	isTerminal = func() bool { return false }
+	defer func() { isTerminal = origIsTerminal }()
+
+	_, err := executeSpecCmd(t, "spec", "pick", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("expected invalid input without a terminal, got: %v", err)
+	}
+}
+
+func TestSpecDoneRequiresCriteriaSignOff(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specAddCriteriaFlag, specDoneWaiveFlag = nil, "" }()
+
+	if _, err := executeSpecCmd(t, "spec", "add", "password reset", "--criterion", "email is sent", "--criterion", "token expires", "--format", "text"); err != nil {
+		t.Fatalf("spec add failed: %v", err)
+	}
+	specAddCriteriaFlag = nil
+
+	_, err := executeSpecCmd(t, "spec", "done", "1", "--format", "text")
+	if ExitCode(err) != ExitBlocked || !strings.Contains(err.Error(), "2 acceptance criteria unchecked") {
+		t.Fatalf("spec done should be blocked by unchecked criteria, got: %v", err)
+	}
+
+	out, err := executeSpecCmd(t, "spec", "criteria", "check", "1", "1", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec criteria check failed: %v", err)
+	}
+	if !strings.Contains(out, "1 of 2 criteria still open") {
+		t.Errorf("should report open criteria, got:\n%s", out)
+	}
+
+	if _, err := executeSpecCmd(t, "spec", "done", "1", "--waive", "expiry covered upstream", "--format", "text"); err != nil {
+		t.Fatalf("spec done --waive failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.Specs[0].Status != types.SpecStatusCompleted || loaded.Specs[0].Waiver != "expiry covered upstream" {
+		t.Errorf("spec should be completed with the waiver recorded, got %+v", loaded.Specs[0])
+	}
+}
+
+func TestSpecAddCriterionRequiresSingleSpec(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specAddCriteriaFlag = nil }()
+
+	_, err := executeSpecCmd(t, "spec", "add", "one", "two", "--criterion", "works", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("--criterion with several specs should be invalid input, got: %v", err)
+	}
+}
+
+func TestSpecArchiveMovesCompletedSpecs(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("adds two numbers")
+	s.AddSpec("subtracts two numbers")
+	_ = s.CompleteSpec(1)
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specArchiveCompletedFlag, specListArchivedFlag = false, false }()
+
+	if _, err := executeSpecCmd(t, "spec", "archive"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("spec archive without --completed should be invalid input, got %v", err)
+	}
+	if _, err := executeSpecCmd(t, "spec", "archive", "--completed", "--format", "text"); err != nil {
+		t.Fatalf("spec archive failed: %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	if len(loaded.Specs) != 1 || loaded.Specs[0].ID != 2 {
+		t.Errorf("session should keep only spec 2, got %+v", loaded.Specs)
+	}
+
+	out, err := executeSpecCmd(t, "spec", "list", "--format", "text")
+	if err != nil || strings.Contains(out, "adds two numbers") {
+		t.Errorf("default spec list should exclude archived specs, got %v:\n%s", err, out)
+	}
+	out, err = executeSpecCmd(t, "spec", "list", "--archived", "--format", "text")
+	if err != nil || !strings.Contains(out, "adds two numbers") || strings.Contains(out, "subtracts") {
+		t.Errorf("spec list --archived should show only archived specs, got %v:\n%s", err, out)
+	}
+}
+
+func TestSpecImportPrintsMapping(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("Login works")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	file := filepath.Join(dir, "issues.json")
+	if err := os.WriteFile(file, []byte(`[{"number": 1, "title": "Signup works"}, {"number": 7, "title": "login works"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeSpecCmd(t, "spec", "import", file, "--format", "json")
+	if err != nil {
+		t.Fatalf("spec import failed: %v", err)
+	}
+	var res specimport.Result
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(res.Mapping) != 2 || res.Mapping[0].NewID != 2 || res.Mapping[0].Status != specimport.StatusRemapped || res.Mapping[1].Status != specimport.StatusDuplicate {
+		t.Errorf("mapping = %+v, want 1->2 remapped and 7->1 duplicate", res.Mapping)
+	}
+
+	loaded, _ := session.Load(dir)
+	if len(loaded.Specs) != 2 {
+		t.Errorf("session has %d specs, want 2", len(loaded.Specs))
+	}
+}
+
+func TestSpecShowReportsCriteriaEventsAndTimes(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("login works")
+	s.AddSpec("logout works")
+	_ = s.AddSpecCriteria(1, []string{"rejects bad password"})
+	_ = s.SetCurrentSpec(1)
+	s.AddEvent("spec_picked", func(e *types.Event) { e.SpecID = 1 })
+	s.AddEvent("spec_picked", func(e *types.Event) { e.SpecID = 2 })
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeSpecCmd(t, "spec", "show", "1", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec show failed: %v", err)
+	}
+	for _, want := range []string{"login works", "Status: active (current)", "[ ] 1. rejects bad password", "Picked:", "Events (1):"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("spec show should contain %q, got:\n%s", want, out)
+		}
+	}
+
+	out, err = executeSpecCmd(t, "spec", "show", "1", "--format", "json")
+	if err != nil {
+		t.Fatalf("spec show --format json failed: %v", err)
+	}
+	var detail struct {
+		ID       int           `json:"id"`
+		Current  bool          `json:"current"`
+		PickedAt string        `json:"picked_at"`
+		Events   []types.Event `json:"events"`
+	}
+	if err := json.Unmarshal([]byte(out), &detail); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if detail.ID != 1 || !detail.Current || detail.PickedAt == "" || len(detail.Events) != 1 {
+		t.Errorf("detail = %+v, want spec 1, current, picked, with only its own event", detail)
+	}
+
+	if _, err := executeSpecCmd(t, "spec", "show", "9", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown spec should be invalid input, got: %v", err)
+	}
+}
+
+func TestSpecAddStdin(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"lines", "Returns 404 when not found\n\n  Returns 400 for invalid input  \n", []string{"Returns 404 when not found", "Returns 400 for invalid input"}},
+		{"json", `["Returns 404 when not found", "Returns 400 for invalid input"]`, []string{"Returns 404 when not found", "Returns 400 for invalid input"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := session.Save(dir, types.NewSession()); err != nil {
+				t.Fatalf("failed to save session: %v", err)
+			}
+			origDir, _ := os.Getwd()
+			os.Chdir(dir)
+			defer os.Chdir(origDir)
+			defer func() { specAddStdinFlag = false }()
+			rootCmd.SetIn(strings.NewReader(tt.input))
+			defer rootCmd.SetIn(nil)
+
+			if _, err := executeSpecCmd(t, "spec", "add", "--stdin", "--format", "text"); err != nil {
+				t.Fatalf("spec add --stdin failed: %v", err)
+			}
+			s, _ := session.Load(dir)
+			if len(s.Specs) != len(tt.want) {
+				t.Fatalf("got %d specs, want %d", len(s.Specs), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if s.Specs[i].Description != want {
+					t.Errorf("spec %d = %q, want %q", i+1, s.Specs[i].Description, want)
+				}
+			}
+			if last := s.History[len(s.History)-1]; last.Action != "spec_add" || last.SpecCount != len(tt.want) {
+				t.Errorf("last event = %+v, want one spec_add for all specs", last)
+			}
+		})
+	}
+}
+
+func TestSpecAddStdinRejectsEmptyAndBadJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specAddStdinFlag = false }()
+	defer rootCmd.SetIn(nil)
+
+	for _, input := range []string{"\n\n", `["unterminated"`} {
+		rootCmd.SetIn(strings.NewReader(input))
+		if _, err := executeSpecCmd(t, "spec", "add", "--stdin", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+			t.Errorf("input %q: err = %v, want invalid input", input, err)
+		}
+	}
+}
+
+func TestSpecRiskBlocksLeavingRefactorUntilRulesMet(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specAddRiskFlag = "" }()
+
+	if _, err := executeSpecCmd(t, "spec", "add", "Refunds are idempotent", "--risk", "high", "--format", "text"); err != nil {
+		t.Fatalf("spec add --risk failed: %v", err)
+	}
+	if _, err := executeSpecCmd(t, "spec", "add", "Typo fix", "--risk", "urgent", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("invalid --risk: err = %v, want invalid input", err)
+	}
+	specAddRiskFlag = ""
+
+	s, _ := session.Load(dir)
+	if len(s.Specs) != 1 || s.Specs[0].Risk != types.RiskHigh {
+		t.Fatalf("specs = %+v, want one high-risk spec", s.Specs)
+	}
+	id := 1
+	s.CurrentSpecID = &id
+	s.Phase = types.PhaseRefactor
+	s.TestCmds = map[string]string{"integration": "go test ./integration/..."}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	_, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text")
+	if ExitCode(err) != ExitBlocked || !strings.Contains(err.Error(), "'integration' suite") || !strings.Contains(err.Error(), "human review") {
+		t.Fatalf("phase next should be blocked by both high-risk rules, got %v", err)
+	}
+
+	s, _ = session.Load(dir)
+	s.RecordSuiteResult("integration", "pass")
+	s.RecordReview(types.ReviewApproved, "")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--test-result", "pass", "--format", "text"); err != nil {
+		t.Fatalf("phase next should pass once the rules are met: %v", err)
+	}
+}
+
+func TestSpecRiskSetsLevel(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("Refunds are idempotent")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, err := executeSpecCmd(t, "spec", "risk", "1", "high", "--format", "text"); err != nil {
+		t.Fatalf("spec risk failed: %v", err)
+	}
+	if _, err := executeSpecCmd(t, "spec", "risk", "1", "extreme", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("invalid level: err = %v, want invalid input", err)
+	}
+	out, err := executeSpecCmd(t, "spec", "list", "--format", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "(active, high risk)") {
+		t.Errorf("spec list should mark the spec high risk:\n%s", out)
+	}
+}
+
+func TestSpecAddDiscoveredFromRecordsProvenance(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("Checkout totals the cart")
+	id := 1
+	s.CurrentSpecID = &id
+	s.Phase = types.PhaseGreen
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { specAddDiscoveredFlag = "" }()
+
+	out, err := executeSpecCmd(t, "spec", "add", "--discovered-from", "1", "Handle an empty cart", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec add --discovered-from failed: %v", err)
+	}
+	if !strings.Contains(out, "discovered from spec [1]") {
+		t.Errorf("output should confirm the parked spec, got:\n%s", out)
+	}
+	loaded, _ := session.Load(dir)
+	if got := loaded.Specs[1]; got.DiscoveredFrom != 1 || got.DiscoveredIn != types.PhaseGreen {
+		t.Errorf("spec 2 = %+v, want discovered from 1 during green", got)
+	}
+	if loaded.Phase != types.PhaseGreen || *loaded.CurrentSpecID != 1 {
+		t.Errorf("adding a discovered spec changed the cycle: phase %s, current %d", loaded.Phase, *loaded.CurrentSpecID)
+	}
+	if last := loaded.History[len(loaded.History)-1]; last.SpecID != 1 || len(last.SpecIDs) != 1 || last.SpecIDs[0] != 2 {
+		t.Errorf("spec_add event = %+v, want it linked to both specs", last)
+	}
+
+	out, err = executeSpecCmd(t, "spec", "show", "2", "--format", "text")
+	if err != nil || !strings.Contains(out, "Discovered from: [1] during GREEN") {
+		t.Errorf("spec show should report the provenance (err %v):\n%s", err, out)
+	}
+
+	if _, err := executeSpecCmd(t, "spec", "add", "--discovered-from", "9", "Orphan", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown --discovered-from: err = %v, want invalid input", err)
+	}
+}
+
+func TestSpecDoneAllRequiresConfirmation(t *testing.T) {
+	resetFlags(specDoneCmd.Flags())
+	defer resetFlags(specDoneCmd.Flags())
+	origIsTerminal := isTerminal
+	defer func() { isTerminal = origIsTerminal }()
+	defer rootCmd.SetIn(nil)
+
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("first spec")
+	s.AddSpec("second spec")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	isTerminal = func() bool { return false }
+	if _, err := executeSpecCmd(t, "spec", "done", "--all", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Fatalf("--all without a terminal or --yes should be invalid input, got %v", err)
+	}
+
+	isTerminal = func() bool { return true }
+	rootCmd.SetIn(strings.NewReader("n\n"))
+	out, err := executeSpecCmd(t, "spec", "done", "--all", "--format", "text")
+	if err != nil {
+		t.Fatalf("declined spec done --all failed: %v", err)
+	}
+	if !strings.Contains(out, "[2] second spec") || !strings.Contains(out, "Aborted") {
+		t.Errorf("should list the specs and abort, got:\n%s", out)
+	}
+	if loaded, _ := session.Load(dir); len(loaded.ActiveSpecs()) != 2 {
+		t.Fatalf("declining should leave specs active, got %d", len(loaded.ActiveSpecs()))
+	}
+
+	rootCmd.SetIn(strings.NewReader("y\n"))
+	if _, err := executeSpecCmd(t, "spec", "done", "--all", "--format", "text"); err != nil {
+		t.Fatalf("confirmed spec done --all failed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	last := loaded.History[len(loaded.History)-1]
+	if len(loaded.ActiveSpecs()) != 0 || last.Confirmed != types.ConfirmedInteractive {
+		t.Errorf("want all specs done and an interactive confirmation, got %d active, %+v", len(loaded.ActiveSpecs()), last)
+	}
+
+	loaded.AddSpec("third spec")
+	session.Save(dir, loaded)
+	isTerminal = func() bool { return false }
+	if _, err := executeSpecCmd(t, "spec", "done", "--all", "--yes", "--format", "text"); err != nil {
+		t.Fatalf("spec done --all --yes failed: %v", err)
+	}
+	loaded, _ = session.Load(dir)
+	if last := loaded.History[len(loaded.History)-1]; last.Confirmed != types.ConfirmedForced {
+		t.Errorf("--yes should record a forced confirmation, got %+v", last)
+	}
+}
+
+func TestSpecTemplateSaveAndApply(t *testing.T) {
+	defer func() { specTemplateValues = nil }()
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, err := executeSpecCmd(t, "spec", "template", "save", "validation-errors", "returns 400 for missing {field}", "--format", "text"); err != nil {
+		t.Fatalf("spec template save failed: %v", err)
+	}
+	out, err := executeSpecCmd(t, "spec", "template", "apply", "validation-errors", "--field", "email", "--field=password", "--format", "text")
+	if err != nil {
+		t.Fatalf("spec template apply failed: %v", err)
+	}
+	if !strings.Contains(out, "returns 400 for missing password") {
+		t.Errorf("should report the generated specs, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if len(loaded.Specs) != 2 || loaded.Specs[0].Description != "returns 400 for missing email" {
+		t.Fatalf("want two concrete specs, got %+v", loaded.Specs)
+	}
+	if last := loaded.History[len(loaded.History)-1]; last.Reason != "from template validation-errors" || len(last.SpecIDs) != 2 {
+		t.Errorf("spec_add event should name the template, got %+v", last)
+	}
+
+	if _, err := executeSpecCmd(t, "spec", "template", "apply", "validation-errors", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("missing parameter should be invalid input, got %v", err)
+	}
+	if _, err := executeSpecCmd(t, "spec", "template", "apply", "missing", "--field", "x", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown template should be invalid input, got %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/speclint"
+	"github.com/macosta/tdd-ai/internal/spectemplate"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var specTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Save and apply reusable, parameterized spec lists",
+	Long: `Spec templates capture test lists that come up again and again, such as the
+validation errors of every endpoint. A template is a list of spec patterns with
+{param} placeholders; applying it fills them in and adds the concrete specs.
+
+Templates are stored in .tdd-ai.spec-templates.json next to the session file.
+They survive 'tdd-ai reset' and can be committed to share them with the team.`,
+	Example: `  tdd-ai spec template save validation-errors "returns 400 for missing {field}" "returns 422 for malformed {field}"
+  tdd-ai spec template apply validation-errors --field email --field password
+  tdd-ai spec template list`,
+}
+
+var specTemplateStdinFlag bool
+
+var specTemplateSaveCmd = &cobra.Command{
+	Use:   "save <name> \"pattern\"... | --stdin",
+	Short: "Save a list of spec patterns as a template",
+	Long: `Save spec patterns under a name, replacing any template of that name. Write
+{param} where a value goes; param names use lowercase letters, digits, '-' and
+'_'. Use --stdin to read the patterns as a JSON array or one per line.`,
+	Example: `  tdd-ai spec template save validation-errors "returns 400 for missing {field}"
+  tdd-ai spec template save crud "creates a {resource}" "lists {resource}s" "deletes a {resource}"
+  generate-patterns | tdd-ai spec template save api-errors --stdin`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, patterns := args[0], args[1:]
+		if specTemplateStdinFlag {
+			data, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("reading patterns from stdin: %w", err)
+			}
+			descs, err := parseSpecList(data)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			patterns = append(patterns, descs...)
+		}
+		if err := spectemplate.Validate(name, patterns); err != nil {
+			return invalidInputError(err)
+		}
+
+		dir := getWorkDir()
+		lib, err := spectemplate.Load(dir)
+		if err != nil {
+			return err
+		}
+		_, replaced := lib[name]
+		lib[name] = patterns
+		if err := spectemplate.Save(dir, lib); err != nil {
+			return err
+		}
+
+		verb := "Saved"
+		if replaced {
+			verb = "Replaced"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s template %s with %d spec(s)\n", verb, name, len(patterns))
+		if params := spectemplate.Params(patterns); len(params) > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Apply it with: tdd-ai spec template apply %s%s\n", name, paramUsage(params))
+		}
+		return nil
+	},
+}
+
+// specTemplateValues holds the --<param> values given to 'spec template apply',
+// which cobra cannot parse because the parameters depend on the template.
+var specTemplateValues map[string][]string
+
+var specTemplateApplyCmd = &cobra.Command{
+	Use:   "apply <name> [--<param> <value>]...",
+	Short: "Add the specs generated from a template",
+	Long: `Fill in a template's {param} placeholders and add the resulting specs. Pass each
+parameter as a flag named after it; repeat a flag to generate one spec per value.
+A pattern using several parameters generates a spec for every combination.`,
+	Example: `  tdd-ai spec template apply validation-errors --field email --field password
+  tdd-ai spec template apply crud --resource invoice`,
+	DisableFlagParsing: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		values, err := parseTemplateArgs(cmd, args)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		specTemplateValues = values
+		return rootCmd.PersistentPreRunE(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if help, _ := cmd.Flags().GetBool("help"); help {
+			return cmd.Help()
+		}
+		positional := cmd.Flags().Args()
+		if len(positional) != 1 {
+			return invalidInputError(fmt.Errorf("spec template apply takes one template name, got %d argument(s)", len(positional)))
+		}
+		name := positional[0]
+
+		dir := getWorkDir()
+		lib, err := spectemplate.Load(dir)
+		if err != nil {
+			return err
+		}
+		patterns, ok := lib[name]
+		if !ok {
+			return invalidInputError(fmt.Errorf("no spec template %q (saved: %s)", name, strings.Join(lib.Names(), ", ")))
+		}
+		descs, err := spectemplate.Expand(patterns, specTemplateValues)
+		if err != nil {
+			return invalidInputError(fmt.Errorf("template %s: %w", name, err))
+		}
+
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+		added := make(map[int]bool, len(descs))
+		var addedIDs []int
+		for _, desc := range descs {
+			id := s.AddSpec(desc)
+			added[id] = true
+			addedIDs = append(addedIDs, id)
+			fmt.Fprintf(cmd.OutOrStdout(), "Spec [%d] %s added: %s\n", id, s.Specs[len(s.Specs)-1].Slug, desc)
+		}
+		for _, issue := range speclint.Lint(s.Specs) {
+			if added[issue.SpecID] {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: spec [%d] %s\n", issue.SpecID, issue.Message)
+			}
+		}
+		s.AddEvent("spec_add", func(e *types.Event) {
+			e.SpecCount = len(descs)
+			e.SpecIDs = addedIDs
+			e.Reason = "from template " + name
+		})
+		if err := session.Save(dir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "Next: run 'tdd-ai guide --format json' for phase instructions")
+		return nil
+	},
+}
+
+var specTemplateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved spec templates",
+	Example: `  tdd-ai spec template list
+  tdd-ai spec template list --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		lib, err := spectemplate.Load(getWorkDir())
+		if err != nil {
+			return err
+		}
+
+		f := formatter.Format(formatFlag)
+		switch f {
+		case formatter.FormatJSON:
+			data, err := json.MarshalIndent(lib, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding spec templates: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		case formatter.FormatText:
+			out := cmd.OutOrStdout()
+			if len(lib) == 0 {
+				fmt.Fprintln(out, "No spec templates. Save one with 'tdd-ai spec template save <name> \"pattern\"...'")
+				return nil
+			}
+			for _, name := range lib.Names() {
+				fmt.Fprintf(out, "%s%s\n", name, paramUsage(spectemplate.Params(lib[name])))
+				for _, p := range lib[name] {
+					fmt.Fprintf(out, "  - %s\n", p)
+				}
+			}
+		default:
+			return unknownFormatError(f)
+		}
+		return nil
+	},
+}
+
+var specTemplateRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Short:   "Delete a spec template",
+	Example: `  tdd-ai spec template remove validation-errors`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		lib, err := spectemplate.Load(dir)
+		if err != nil {
+			return err
+		}
+		if _, ok := lib[args[0]]; !ok {
+			return invalidInputError(fmt.Errorf("no spec template %q", args[0]))
+		}
+		delete(lib, args[0])
+		if err := spectemplate.Save(dir, lib); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed template %s\n", args[0])
+		return nil
+	},
+}
+
+// paramUsage renders template parameters as the flags apply expects, e.g.
+// " --field <field>".
+func paramUsage(params []string) string {
+	var b strings.Builder
+	for _, p := range params {
+		fmt.Fprintf(&b, " --%s <%s>", p, p)
+	}
+	return b.String()
+}
+
+// parseTemplateArgs parses the arguments of 'spec template apply' by hand.
+// Flags the command knows, such as --format and --help, are parsed into its
+// flag set as usual; any other --<param> <value> or --<param>=<value> is a
+// template parameter, and may be repeated.
+func parseTemplateArgs(cmd *cobra.Command, args []string) (map[string][]string, error) {
+	cmd.InheritedFlags() // merges the persistent flags into cmd.Flags()
+	flags := cmd.Flags()
+
+	var known []string
+	values := make(map[string][]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			known = append(known, args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			known = append(known, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := flags.Lookup(name)
+		if !strings.HasPrefix(arg, "--") {
+			f = flags.ShorthandLookup(name)
+		}
+		if f != nil {
+			known = append(known, arg)
+			if !hasValue && f.NoOptDefVal == "" && i+1 < len(args) {
+				i++
+				known = append(known, args[i])
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(arg, "--") {
+			return nil, fmt.Errorf("unknown shorthand flag %q; pass template parameters as --<param> <value>", arg)
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag needs a value: %s", arg)
+			}
+			i++
+			value = args[i]
+		}
+		values[name] = append(values[name], value)
+	}
+	if err := flags.Parse(known); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func init() {
+	specTemplateSaveCmd.Flags().BoolVar(&specTemplateStdinFlag, "stdin", false, "read patterns from stdin: a JSON array or one per line")
+	specTemplateCmd.AddCommand(specTemplateSaveCmd)
+	specTemplateCmd.AddCommand(specTemplateApplyCmd)
+	specTemplateCmd.AddCommand(specTemplateListCmd)
+	specTemplateCmd.AddCommand(specTemplateRemoveCmd)
+	specCmd.AddCommand(specTemplateCmd)
+}
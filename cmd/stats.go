@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/stats"
+	"github.com/spf13/cobra"
+)
+
+var statsAggregateFlag []string
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report TDD metrics for this session or rolled up across many",
+	Long: `Reports cycles completed, average iterations per completed spec, the violation
+rate (percentage of verified specs with at least one 'tdd-ai verify' violation),
+forced phase overrides, and blocked 'phase next' attempts.
+
+Without flags, reports on the session in the current directory. Use --aggregate
+with a glob (repeatable; ** matches any number of directories) to scan many
+session files and roll them up into fleet-level metrics, for example for an
+engineering dashboard. Archived specs next to each session file are included.
+
+Output is CSV (default; the last row is the fleet total), TSV, or JSON.`,
+	Example: `  tdd-ai stats
+  tdd-ai stats --aggregate '~/projects/**/.tdd-ai.json'
+  tdd-ai stats --aggregate '~/projects/**/.tdd-ai.json' --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		paths := []string{session.FilePath(getWorkDir())}
+		if len(statsAggregateFlag) > 0 {
+			paths = nil
+			for _, pattern := range statsAggregateFlag {
+				matches, err := stats.Find(pattern)
+				if err != nil {
+					return fmt.Errorf("scanning %s: %w", pattern, err)
+				}
+				paths = append(paths, matches...)
+			}
+			if len(paths) == 0 {
+				return invalidInputError(fmt.Errorf("no session files match %v", statsAggregateFlag))
+			}
+		} else if !session.Exists(getWorkDir()) {
+			return session.ErrNoSession
+		}
+
+		projects := make([]stats.Project, 0, len(paths))
+		seen := make(map[string]bool)
+		for _, path := range paths {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			s, err := session.LoadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if s, err = session.IncludeArchive(filepath.Dir(path), s); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			projects = append(projects, stats.Summarize(path, s))
+		}
+
+		f := formatter.FormatCSV
+		if cmd.Flags().Changed("format") {
+			f = formatter.Format(formatFlag)
+		}
+		out, err := formatter.ExportStats(stats.Aggregate(projects), f)
+		if err != nil {
+			return invalidInputError(err)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), out)
+		return nil
+	},
+}
+
+func init() {
+	statsCmd.Flags().StringArrayVar(&statsAggregateFlag, "aggregate", nil, "glob of session files to roll up, e.g. '~/projects/**/.tdd-ai.json' (repeatable)")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestStatsAggregateWritesCSVWithTotal(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api", "web"} {
+		projectDir := filepath.Join(dir, name)
+		if err := os.MkdirAll(projectDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		s := types.NewSession()
+		s.Iteration = 2
+		if err := session.Save(projectDir, s); err != nil {
+			t.Fatalf("failed to save session: %v", err)
+		}
+	}
+	defer func() { statsAggregateFlag = nil }()
+
+	out, _, err := executePhaseCmd(t, "stats", "--aggregate", filepath.ToSlash(dir)+"/**/.tdd-ai.json", "--format", "csv")
+	if err != nil {
+		t.Fatalf("stats --aggregate failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "path,") {
+		t.Fatalf("want header, two projects, and a total row, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[3], "total,,4,") {
+		t.Errorf("total row should sum cycles completed, got %q", lines[3])
+	}
+}
@@ -13,7 +13,8 @@ var statusCmd = &cobra.Command{
 	Short: "Show the current TDD session status",
 	Long:  "Display a full overview of the TDD session: current phase, mode, spec summary, and recommended next action.",
 	Example: `  tdd-ai status
-  tdd-ai status --format json`,
+  tdd-ai status --format json
+  tdd-ai status --template '{{.Phase}} {{.ActiveSpecs}}/{{.TotalSpecs}}'`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
@@ -21,7 +22,12 @@ var statusCmd = &cobra.Command{
 			return err
 		}
 
-		out, err := formatter.FormatFullStatus(s, formatter.Format(formatFlag))
+		var out string
+		if templateFlag != "" {
+			out, err = formatter.TemplateFullStatus(s, templateFlag)
+		} else {
+			out, err = formatter.FormatFullStatus(s, formatter.Format(formatFlag))
+		}
 		if err != nil {
 			return err
 		}
@@ -32,5 +38,6 @@ var statusCmd = &cobra.Command{
 }
 
 func init() {
+	addTemplateFlag(statusCmd)
 	rootCmd.AddCommand(statusCmd)
 }
@@ -114,3 +114,25 @@ func TestStatusNoComplianceWithoutCompletedSpecs(t *testing.T) {
 		t.Errorf("should not show compliance when no completed specs, got:\n%s", out)
 	}
 }
+
+func TestStatusTemplateOverridesFormat(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { templateFlag = "" }()
+
+	out, _, err := executePhaseCmd(t, "status", "--format", "json", "--template", "{{.Phase}}:{{.ActiveSpecs}}")
+	if err != nil {
+		t.Fatalf("status --template failed: %v", err)
+	}
+	if out != "red:1\n" {
+		t.Errorf("status --template = %q, want %q", out, "red:1\n")
+	}
+}
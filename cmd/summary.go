@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Show the summary recorded when the session last reached done",
+	Long: `Prints the cycle summary stored in the session when it last reached the done
+phase: the specs finished since the previous summary, the iterations and time
+they took, and the reflection answers from the last REFACTOR phase.
+
+The summary is generated once, by 'tdd-ai phase next', 'tdd-ai phase set done',
+or 'tdd-ai complete', and re-printed here as stored, so it does not change when
+the history is later truncated or summarized.`,
+	Example: `  tdd-ai summary
+  tdd-ai summary --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s, err := session.LoadOrFail(getWorkDir())
+		if err != nil {
+			return err
+		}
+		if s.Summary == nil {
+			return errors.New("no cycle summary yet: one is recorded when the session reaches the done phase")
+		}
+
+		f := formatter.Format(formatFlag)
+		if f != formatter.FormatJSON && f != formatter.FormatText {
+			return unknownFormatError(f)
+		}
+		out, err := formatter.FormatSummary(s.Summary, f)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), out)
+		return nil
+	},
+}
+
+// recordSummary stores the summary of the cycle that just reached done. It is
+// called before the session is saved.
+func recordSummary(s *types.Session) {
+	sum := formatter.Summarize(s)
+	s.Summary = &sum
+}
+
+// writeSummary prints the summary recorded by recordSummary after a save.
+func writeSummary(w io.Writer, s *types.Session) {
+	out, err := formatter.FormatSummary(s.Summary, formatter.FormatText)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "\n%s", out)
+}
+
+func init() {
+	rootCmd.AddCommand(summaryCmd)
+}
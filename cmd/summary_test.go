@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/reflection"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestCompleteRecordsAndPrintsSummary(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.AddSpec("login works")
+	s.Reflections = reflection.DefaultQuestions()
+	for i := range s.Reflections {
+		s.Reflections[i].Answer = "This reflection is answered with enough words"
+	}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeCompleteCmd(t, "complete", "--test-result", "pass", "--format", "text")
+	if err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	if !strings.Contains(out, "Cycle summary") || !strings.Contains(out, "[1] login works") {
+		t.Errorf("complete should print the cycle summary, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.Summary == nil {
+		t.Fatal("complete should store the summary in the session")
+	}
+	if len(loaded.Summary.Specs) != 1 || len(loaded.Summary.Reflections) != len(s.Reflections) {
+		t.Errorf("summary = %+v, want 1 spec and every answered reflection", loaded.Summary)
+	}
+
+	out, err = executeCompleteCmd(t, "summary", "--format", "json")
+	if err != nil {
+		t.Fatalf("summary failed: %v", err)
+	}
+	var sum types.CycleSummary
+	if err := json.Unmarshal([]byte(out), &sum); err != nil {
+		t.Fatalf("summary output is not JSON: %v\n%s", err, out)
+	}
+	if sum.GeneratedAt != loaded.Summary.GeneratedAt {
+		t.Errorf("summary should re-print the stored summary, got generated_at %q want %q", sum.GeneratedAt, loaded.Summary.GeneratedAt)
+	}
+}
+
+func TestSummaryWithoutDoneFails(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	_, err := executeCompleteCmd(t, "summary", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "no cycle summary") {
+		t.Errorf("summary should fail before the session reaches done, got: %v", err)
+	}
+}
@@ -1,16 +1,37 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/testcount"
+	"github.com/macosta/tdd-ai/internal/testoutput"
+	"github.com/macosta/tdd-ai/internal/testrun"
+	"github.com/macosta/tdd-ai/internal/triage"
 	"github.com/macosta/tdd-ai/internal/types"
 	"github.com/spf13/cobra"
 )
 
-var testSummaryFlag bool
+var (
+	testSummaryFlag   bool
+	testAsyncFlag     bool
+	testSuiteFlag     string
+	testNoStreamFlag  bool
+	testRunShardsFlag bool
+	testParallelFlag  bool
+	testNoCacheFlag   bool
+	testAreaFlag      []string
+	testAllAreasFlag  bool
+)
 
 var testCmd = &cobra.Command{
 	Use:   "test",
@@ -19,55 +40,366 @@ var testCmd = &cobra.Command{
 whether tests passed or failed. The result is stored in the session and
 automatically used by 'tdd-ai phase next' when --test-result is not provided.
 
+Test output is streamed live as the suite runs; the result is classified once it
+exits. Use --no-stream to print the output only after the command finishes.
+
 Use --summary to show only the last 20 lines of test output. This is useful
-for AI agents where full output wastes context window on verbose stack traces.`,
+for AI agents where full output wastes context window on verbose stack traces.
+--summary-lines changes the window, and --summary-mode head-tail also keeps the
+first --summary-head lines (default 10), where compiler errors usually are.
+The process exit code is always printed after the output.
+
+Use --async to start the test command in the background and return immediately
+with a run ID. Poll with 'tdd-ai test status <run-id>'; once the run finishes,
+the first status call records its result in the session.
+
+Use --suite to run one of the named suites configured via 'tdd-ai init --test-suite'
+(for example unit or integration). Suites required for the current phase with
+'tdd-ai init --require-suites' must pass before 'tdd-ai phase next' advances.
+
+Use --shards to run the shard commands configured via 'tdd-ai init --test-shard'
+instead of the test command, one after another or, with --parallel, all at once.
+The run records pass only when every shard passes; the outcome of each shard is
+kept in the session (shard_results) for diagnostics.
+
+Go's test cache can report a pass for code that has not been re-run. When a 'go
+test' run reports any (cached) package result, the run is recorded with
+cached: true and guide warns that the pass may not reflect recent edits. Use
+--no-cache, or 'tdd-ai init --no-test-cache' for every run, to inject -count=1
+into 'go test' commands.
+
+In a monorepo, 'tdd-ai init --test-area path="command"' maps areas of the
+repository to their own test commands, e.g. services/api to 'go test ./...' and
+web to 'npm test'. Each command runs from its area's directory. Once areas are
+configured, 'tdd-ai test' runs the areas the current specs are tagged with
+('tdd-ai spec add --area', 'tdd-ai spec area'), or every area when none are
+tagged; the run passes only when every area passes. Use --area to run specific
+areas and --all-areas to run them all. The result of each area is kept in the
+session (area_results) and on the test_run event.`,
 	Example: `  tdd-ai test
-  tdd-ai test --summary`,
+  tdd-ai test --summary
+  tdd-ai test --summary --summary-mode head-tail --summary-lines 30
+  tdd-ai test --no-stream
+  tdd-ai test --async
+  tdd-ai test --suite integration
+  tdd-ai test --shards --parallel
+  tdd-ai test --area web
+  tdd-ai test --all-areas
+  tdd-ai test --no-cache`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
 		if err != nil {
 			return err
 		}
+		red, err := sessionRedactor(s)
+		if err != nil {
+			return err
+		}
 
-		if s.TestCmd == "" {
-			return fmt.Errorf("no test command configured. Use 'tdd-ai init --test-cmd \"your test command\"' to set one")
+		if testRunShardsFlag {
+			if testSuiteFlag != "" || testAsyncFlag || len(testAreaFlag) > 0 || testAllAreasFlag {
+				return invalidInputError(fmt.Errorf("--shards cannot be combined with --suite, --async, or test areas"))
+			}
+			if len(s.TestShards) == 0 {
+				return invalidInputError(fmt.Errorf("no test shards configured. Use 'tdd-ai init --test-shard \"command\"' for each shard"))
+			}
+			if err := checkPairRole(s); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Running %d shard(s)\n\n", len(s.TestShards))
+			shards := make([]shardCmd, len(s.TestShards))
+			for i, shard := range s.TestShards {
+				shards[i] = shardCmd{Cmd: goTestCommand(shard, s.NoTestCache || testNoCacheFlag)}
+			}
+			return recordTestResult(cmd, dir, s, runShards(cmd, dir, shards, testEnv(s), red, testParallelFlag, testSummaryFlag))
+		}
+		if testParallelFlag {
+			return invalidInputError(fmt.Errorf("--parallel requires --shards"))
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Running: %s\n\n", s.TestCmd)
+		if testSuiteFlag == "" && len(s.TestAreas) > 0 {
+			areas, err := selectTestAreas(s)
+			if err != nil {
+				return invalidInputError(err)
+			}
+			if testAsyncFlag {
+				return invalidInputError(fmt.Errorf("--async cannot run test areas; run 'tdd-ai test' without --async, or --suite <name>"))
+			}
+			if err := checkPairRole(s); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Running %d test area(s): %s\n\n", len(areas), strings.Join(areas, ", "))
+			shards := make([]shardCmd, len(areas))
+			for i, area := range areas {
+				shards[i] = shardCmd{Cmd: goTestCommand(s.TestAreas[area], s.NoTestCache || testNoCacheFlag), Area: area}
+			}
+			return recordTestResult(cmd, dir, s, runShards(cmd, dir, shards, testEnv(s), red, false, testSummaryFlag))
+		}
+		if len(testAreaFlag) > 0 || testAllAreasFlag {
+			if testSuiteFlag != "" {
+				return invalidInputError(fmt.Errorf("--area and --all-areas cannot be combined with --suite"))
+			}
+			return invalidInputError(fmt.Errorf("no test areas configured. Use 'tdd-ai init --test-area path=\"command\"'"))
+		}
 
-		// Split the command for exec
-		parts := strings.Fields(s.TestCmd)
-		c := exec.Command(parts[0], parts[1:]...)
-		c.Dir = dir
-		output, execErr := c.CombinedOutput()
+		command, err := s.SuiteCmd(testSuiteFlag)
+		if err != nil {
+			if testSuiteFlag != "" {
+				return invalidInputError(err)
+			}
+			return err
+		}
+		if err := checkPairRole(s); err != nil {
+			return err
+		}
+		command = goTestCommand(command, s.NoTestCache || testNoCacheFlag)
 
-		// Print the test output (full or summarized)
-		if len(output) > 0 {
-			printTestOutput(cmd, string(output), testSummaryFlag)
+		if testAsyncFlag {
+			return startTestRun(cmd, dir, s, command, testSuiteFlag)
 		}
 
-		// Classify result: pass, fail, or error (infrastructure failure)
-		result := classifyTestResult(string(output), execErr)
+		fmt.Fprintf(cmd.OutOrStdout(), "Running: %s\n\n", command)
 
-		// Store result and record event
-		s.LastTestResult = result
-		s.AddEvent("test_run", func(e *types.Event) {
-			e.Result = result
-		})
-		if err := session.Save(dir, s); err != nil {
-			return err
+		run := runTestCommand(cmd, dir, strings.Fields(command), testEnv(s), red, testSummaryFlag, !testSummaryFlag && !testNoStreamFlag)
+		run.Suite = testSuiteFlag
+		return recordTestResult(cmd, dir, s, run)
+	},
+}
+
+// selectTestAreas returns the test areas 'tdd-ai test' runs: those named with
+// --area, every area with --all-areas, or else the areas of the current specs,
+// falling back to every area when none is tagged.
+func selectTestAreas(s *types.Session) ([]string, error) {
+	if testAllAreasFlag && len(testAreaFlag) > 0 {
+		return nil, fmt.Errorf("--area and --all-areas cannot be combined")
+	}
+	if len(testAreaFlag) > 0 {
+		var areas []string
+		for _, path := range testAreaFlag {
+			area, err := s.ResolveArea(path)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(areas, area) {
+				areas = append(areas, area)
+			}
 		}
+		sort.Strings(areas)
+		return areas, nil
+	}
+	if !testAllAreasFlag {
+		if areas := s.SpecAreas(s.CurrentSpecIDs()); len(areas) > 0 {
+			return areas, nil
+		}
+	}
+	return s.AreaNames(), nil
+}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "\nTest result: %s\n", strings.ToUpper(result))
-		if result == "error" {
-			fmt.Fprintln(cmd.OutOrStdout(), "This looks like an infrastructure/environment error, not a test failure.")
-			fmt.Fprintln(cmd.OutOrStdout(), "Fix the environment issue and re-run 'tdd-ai test'.")
-		} else {
-			fmt.Fprintln(cmd.OutOrStdout(), "Next: run 'tdd-ai phase next' (test result stored, will be used automatically)")
+// goTestCommand returns command with -count=1 added after 'go test' when
+// noCache is set, so Go's test cache cannot serve the results. Commands that
+// are not 'go test', or already pass -count, are returned unchanged.
+func goTestCommand(command string, noCache bool) string {
+	parts := strings.Fields(command)
+	if !noCache || len(parts) < 2 || parts[0] != "go" || parts[1] != "test" {
+		return command
+	}
+	for _, p := range parts[2:] {
+		if p == "-count" || p == "--count" || strings.HasPrefix(p, "-count=") || strings.HasPrefix(p, "--count=") {
+			return command
 		}
+	}
+	return strings.Join(append([]string{"go", "test", "-count=1"}, parts[2:]...), " ")
+}
+
+// testEnv returns the environment for the session's test commands: the current
+// environment plus the variables set with 'tdd-ai config env set', which win.
+func testEnv(s *types.Session) []string {
+	if len(s.TestEnv) == 0 {
 		return nil
-	},
+	}
+	return append(os.Environ(), s.TestEnvList()...)
+}
+
+// testRun is the outcome of executing a test command.
+type testRun struct {
+	Result   string // pass, fail, or error
+	Category string // failure category; empty when Result is pass
+	Output   string
+	Suite    string // named suite that was run; empty for the default test command
+	Shards   []types.ShardResult
+	Count    *testcount.Counts // test counts summed over shards; nil parses Output
+	// Command is the command run by 'tdd-ai exec' in place of the test command.
+	Command string
+}
+
+// countKey names the kind of run, so test counts are only compared between
+// runs that cover the same tests.
+func (r testRun) countKey() string {
+	var areas []string
+	for _, shard := range r.Shards {
+		if shard.Area != "" {
+			areas = append(areas, shard.Area)
+		}
+	}
+	switch {
+	case r.Suite != "":
+		return "suite:" + r.Suite
+	case r.Command != "":
+		return "exec:" + r.Command
+	case len(areas) > 0:
+		sort.Strings(areas)
+		return "areas:" + strings.Join(areas, ",")
+	case r.Shards != nil:
+		return "shards"
+	}
+	return ""
+}
+
+// runTestCommand executes the command in dir with env (nil inherits the current
+// environment), prints its output (full or summarized), and classifies the
+// result as pass, fail, or error. With stream, output is copied to the
+// command's stdout as it is produced instead of once the command exits; summary
+// is then ignored. Output is masked by red before it is printed or returned.
+func runTestCommand(cmd *cobra.Command, dir string, parts, env []string, red *testoutput.Redactor, summary, stream bool) testRun {
+	c := exec.Command(parts[0], parts[1:]...)
+	c.Dir = dir
+	c.Env = env
+
+	var buf bytes.Buffer
+	var execErr error
+	if stream {
+		live := red.Writer(cmd.OutOrStdout())
+		w := io.MultiWriter(&buf, live)
+		c.Stdout, c.Stderr = w, w
+		execErr = c.Run()
+		_ = live.Flush()
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
+	} else {
+		c.Stdout, c.Stderr = &buf, &buf
+		execErr = c.Run()
+	}
+	output := red.Text(buf.String())
+	if !stream && output != "" {
+		printTestOutput(cmd, output, summary)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Exit code: %d\n", processExitCode(execErr))
+
+	result := classifyTestResult(output, execErr)
+	return testRun{
+		Result:   result,
+		Category: classifyFailure(output, result),
+		Output:   output,
+	}
+}
+
+// sessionRedactor returns the redactor for the session's test output: the
+// built-in patterns plus those set with 'tdd-ai config redact'.
+func sessionRedactor(s *types.Session) (*testoutput.Redactor, error) {
+	red, err := testoutput.NewRedactor(s.RedactPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("%w (fix it with 'tdd-ai config redact')", err)
+	}
+	return red, nil
+}
+
+// recordTestResult stores the result as the session's last test result, records a
+// test_run event, and prints the follow-up instructions.
+func recordTestResult(cmd *cobra.Command, dir string, s *types.Session, run testRun) error {
+	result := run.Result
+	s.LastTestResult = result
+	s.LastFailureCategory = run.Category
+	cached := testoutput.Cached(run.Output)
+	s.LastTestCached = cached
+	var count *int
+	s.LastAssertionCount = 0
+	counts, ok := testcount.Parse(run.Output)
+	if run.Shards != nil {
+		ok = run.Count != nil
+		if ok {
+			counts = *run.Count
+		}
+	}
+	if ok {
+		count = &counts.Tests
+		s.LastAssertionCount = counts.Assertions
+	}
+	s.ShardResults = run.Shards
+	var areaResults map[string]string
+	for _, shard := range run.Shards {
+		if shard.Area != "" {
+			s.RecordAreaResult(shard.Area, shard.Result)
+			if areaResults == nil {
+				areaResults = make(map[string]string)
+			}
+			areaResults[shard.Area] = shard.Result
+		}
+	}
+	s.RecordTestCount(run.countKey(), count)
+	s.RecordTestTrend(result)
+	var previous []string
+	if s.LastTestOutput != nil {
+		previous = s.LastTestOutput.FailingTests
+	}
+	s.LastTestOutput = nil
+	var hints []types.TriageHint
+	if result != "pass" && strings.TrimSpace(run.Output) != "" {
+		hints = triage.Match(s, run.Output)
+		first, message := testoutput.FirstFailure(run.Output)
+		failing := testoutput.FailingTests(run.Output)
+		s.RecordNewTests(failing, previous)
+		s.LastTestOutput = &types.TestEvidence{
+			FailingTests:   failing,
+			FirstFailure:   first,
+			FailureMessage: message,
+			TriageHints:    hints,
+			Output:         testoutput.Tail(run.Output, s.OutputLines),
+		}
+	}
+	if run.Suite != "" {
+		s.RecordSuiteResult(run.Suite, result)
+	}
+	s.AddEvent("test_run", func(e *types.Event) {
+		e.Result = result
+		e.Suite = run.Suite
+		e.Cached = cached
+		e.AreaResults = areaResults
+		e.TriageHints = hints
+	})
+	if err := session.Save(dir, s); err != nil {
+		return err
+	}
+
+	if run.Suite != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "\nTest result (%s): %s\n", run.Suite, strings.ToUpper(result))
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "\nTest result: %s\n", strings.ToUpper(result))
+	}
+	for _, h := range hints {
+		fmt.Fprintf(cmd.OutOrStdout(), "Triage hint (%s): %s\n", h.Pattern, h.Hint)
+	}
+	if cached && result == "pass" {
+		fmt.Fprintln(cmd.OutOrStdout(), "Warning: some results were (cached) by Go's test cache and may not reflect recent edits. Re-run with 'tdd-ai test --no-cache'.")
+	}
+	missing := s.MissingSuites(s.Phase)
+	switch {
+	case result == "error":
+		fmt.Fprintln(cmd.OutOrStdout(), "This looks like an infrastructure/environment error, not a test failure.")
+		fmt.Fprintln(cmd.OutOrStdout(), "Fix the environment issue and re-run 'tdd-ai test'.")
+		if s.Environment != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Run 'tdd-ai doctor' to check whether the environment changed since init.")
+		}
+	case run.Category == types.FailureCompile:
+		fmt.Fprintln(cmd.OutOrStdout(), "Failure category: compile (tests did not build).")
+		fmt.Fprintln(cmd.OutOrStdout(), "Next: run 'tdd-ai guide' for category-specific instructions")
+	case len(missing) > 0:
+		fmt.Fprintf(cmd.OutOrStdout(), "Next: run 'tdd-ai test --suite %s' (required to pass before leaving %s)\n", missing[0], s.Phase)
+	default:
+		fmt.Fprintln(cmd.OutOrStdout(), "Next: run 'tdd-ai phase next' (test result stored, will be used automatically)")
+	}
+	return nil
 }
 
 // infraErrorPatterns are substrings that indicate an infrastructure/environment
@@ -89,43 +421,349 @@ func classifyTestResult(output string, execErr error) string {
 	if execErr == nil {
 		return "pass"
 	}
+	// The command could not be started at all (e.g. binary not on PATH)
+	var startErr *exec.Error
+	if errors.As(execErr, &startErr) {
+		return "error"
+	}
+	if isInfraError(output) {
+		return "error"
+	}
+	return "fail"
+}
+
+// isInfraError reports whether the output matches a known infrastructure error.
+func isInfraError(output string) bool {
 	for _, pattern := range infraErrorPatterns {
 		if strings.Contains(output, pattern) {
-			return "error"
+			return true
 		}
 	}
-	return "fail"
+	return false
 }
 
-const summaryMaxLines = 20
+// compileErrorPatterns are substrings that indicate the tests failed to build
+// rather than failing on an assertion.
+var compileErrorPatterns = []string{
+	"[build failed]",
+	"undefined: ",
+	"cannot find symbol",
+	"compilation failed",
+	"Compilation failed",
+	"COMPILATION ERROR",
+	"SyntaxError",
+	"IndentationError",
+	"error TS",
+	"error[E",
+	"error CS",
+	"could not compile",
+}
 
-// printTestOutput prints test output, optionally truncating to the last N lines.
-func printTestOutput(cmd *cobra.Command, output string, summary bool) {
-	if !summary {
-		fmt.Fprint(cmd.OutOrStdout(), output)
-		if !strings.HasSuffix(output, "\n") {
-			fmt.Fprintln(cmd.OutOrStdout())
+// classifyFailure returns the failure category for a non-passing result.
+func classifyFailure(output, result string) string {
+	switch result {
+	case "pass":
+		return ""
+	case "error":
+		return types.FailureInfrastructure
+	}
+	for _, pattern := range compileErrorPatterns {
+		if strings.Contains(output, pattern) {
+			return types.FailureCompile
 		}
-		return
 	}
+	return types.FailureAssertion
+}
+
+// Summary windows for --summary output.
+const (
+	defaultSummaryLines = 20
+	defaultSummaryHead  = 10
+
+	summaryModeTail     = "tail"
+	summaryModeHeadTail = "head-tail"
+)
+
+// Shared by every command that registers summary flags with addSummaryFlags.
+var (
+	summaryLinesFlag int
+	summaryHeadFlag  int
+	summaryModeFlag  string
+)
+
+// addSummaryFlags registers --summary (bound to enabled) and the flags shaping
+// the summary window on c. what names the output in the help text.
+func addSummaryFlags(c *cobra.Command, enabled *bool, what string) {
+	c.Flags().BoolVar(enabled, "summary", false, fmt.Sprintf("show only the last %d lines of %s output (saves LLM context window)", defaultSummaryLines, what))
+	c.Flags().IntVar(&summaryLinesFlag, "summary-lines", defaultSummaryLines, "trailing lines of output kept by --summary")
+	c.Flags().StringVar(&summaryModeFlag, "summary-mode", summaryModeTail, "summary window: tail, or head-tail to also keep the first --summary-head lines (where compiler errors usually are)")
+	c.Flags().IntVar(&summaryHeadFlag, "summary-head", defaultSummaryHead, "leading lines of output kept by --summary-mode head-tail")
+	c.PreRunE = func(*cobra.Command, []string) error {
+		if summaryModeFlag != summaryModeTail && summaryModeFlag != summaryModeHeadTail {
+			return invalidInputError(fmt.Errorf("invalid --summary-mode %q: use tail or head-tail", summaryModeFlag))
+		}
+		if summaryLinesFlag < 1 || summaryHeadFlag < 0 {
+			return invalidInputError(fmt.Errorf("--summary-lines must be at least 1 and --summary-head not negative"))
+		}
+		return nil
+	}
+}
 
+// printTestOutput prints test output, optionally truncated to the summary
+// window: the last --summary-lines lines, preceded in head-tail mode by the
+// first --summary-head lines.
+func printTestOutput(cmd *cobra.Command, output string, summary bool) {
+	w := cmd.OutOrStdout()
 	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
-	totalLines := len(lines)
-	if totalLines <= summaryMaxLines {
-		fmt.Fprint(cmd.OutOrStdout(), output)
+	tail, head := summaryLinesFlag, 0
+	if summaryModeFlag == summaryModeHeadTail {
+		head = summaryHeadFlag
+	}
+	if !summary || len(lines) <= head+tail {
+		fmt.Fprint(w, output)
 		if !strings.HasSuffix(output, "\n") {
-			fmt.Fprintln(cmd.OutOrStdout())
+			fmt.Fprintln(w)
 		}
 		return
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "... (%d lines truncated, showing last %d) ...\n", totalLines-summaryMaxLines, summaryMaxLines)
-	for _, line := range lines[totalLines-summaryMaxLines:] {
-		fmt.Fprintln(cmd.OutOrStdout(), line)
+	for _, line := range lines[:head] {
+		fmt.Fprintln(w, line)
+	}
+	truncated := len(lines) - head - tail
+	if head > 0 {
+		fmt.Fprintf(w, "... (%d lines truncated, showing first %d and last %d) ...\n", truncated, head, tail)
+	} else {
+		fmt.Fprintf(w, "... (%d lines truncated, showing last %d) ...\n", truncated, tail)
+	}
+	for _, line := range lines[len(lines)-tail:] {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// processExitCode returns the process exit code behind a command's error: 0 on
+// success, -1 when the process could not be started.
+func processExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+var testRecordOutputFile string
+
+var testRecordCmd = &cobra.Command{
+	Use:   "record <pass|fail>",
+	Short: "Record the result of a test run the agent performed itself",
+	Long: `Records an externally observed test result as the session's last test result,
+for agents that run tests themselves instead of via 'tdd-ai test'.
+
+Use --output-file to attach the captured test output. It is summarized, classified
+(assertion, compile, or infrastructure failure), and scanned for test counts,
+exactly as if 'tdd-ai test' had run the command.`,
+	Example: `  tdd-ai test record fail
+  go test ./... > out.txt 2>&1; tdd-ai test record fail --output-file out.txt`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result := args[0]
+		if result != "pass" && result != "fail" {
+			return invalidInputError(fmt.Errorf("result must be 'pass' or 'fail', got %q", result))
+		}
+
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+		if testSuiteFlag != "" {
+			if _, err := s.SuiteCmd(testSuiteFlag); err != nil {
+				return invalidInputError(err)
+			}
+		}
+		if err := checkPairRole(s); err != nil {
+			return err
+		}
+
+		var output string
+		if testRecordOutputFile != "" {
+			data, err := os.ReadFile(testRecordOutputFile)
+			if err != nil {
+				return fmt.Errorf("reading output file: %w", err)
+			}
+			red, err := sessionRedactor(s)
+			if err != nil {
+				return err
+			}
+			output = red.Text(string(data))
+			if len(output) > 0 {
+				printTestOutput(cmd, output, true)
+			}
+			if result == "fail" && isInfraError(output) {
+				result = "error"
+			}
+		}
+
+		return recordTestResult(cmd, dir, s, testRun{
+			Result:   result,
+			Category: classifyFailure(output, result),
+			Output:   output,
+			Suite:    testSuiteFlag,
+		})
+	},
+}
+
+// startTestRun records a new background run, tied to the session's current
+// phase and iteration, and launches a detached 'tdd-ai test worker' process to
+// execute it.
+func startTestRun(cmd *cobra.Command, dir string, s *types.Session, testCmd, suite string) error {
+	env := testEnv(s)
+	run, err := testrun.New(dir, testCmd, suite, s.Phase, s.Iteration, time.Now())
+	if err != nil {
+		return err
 	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating tdd-ai executable: %w", err)
+	}
+	worker := exec.Command(exe, "test", "worker", run.ID)
+	worker.Dir = dir
+	worker.Env = env // inherited by the test command the worker runs
+	if err := worker.Start(); err != nil {
+		return fmt.Errorf("starting background test run: %w", err)
+	}
+	if err := worker.Process.Release(); err != nil {
+		return fmt.Errorf("detaching background test run: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Started test run %s: %s\n", run.ID, testCmd)
+	fmt.Fprintf(cmd.OutOrStdout(), "Next: run 'tdd-ai test status %s' to collect the result\n", run.ID)
+	return nil
+}
+
+var testWorkerCmd = &cobra.Command{
+	Use:    "worker <run-id>",
+	Short:  "Execute a background test run (used internally by 'tdd-ai test --async')",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		run, err := testrun.Load(dir, args[0])
+		if err != nil {
+			return err
+		}
+
+		parts := strings.Fields(run.Cmd)
+		c := exec.Command(parts[0], parts[1:]...)
+		c.Dir = dir
+		raw, execErr := c.CombinedOutput()
+		// Secrets must not reach the log file; an unreadable session still gets
+		// the built-in patterns.
+		var red *testoutput.Redactor
+		if s, err := session.Load(dir); err == nil {
+			red, _ = testoutput.NewRedactor(s.RedactPatterns)
+		}
+		output := red.Text(string(raw))
+		if err := os.WriteFile(testrun.LogPath(dir, run.ID), []byte(output), 0644); err != nil {
+			return fmt.Errorf("writing test output: %w", err)
+		}
+
+		run.Finish(classifyTestResult(output, execErr), time.Now())
+		return testrun.Save(dir, run)
+	},
+}
+
+var testStatusSummaryFlag bool
+
+var testStatusCmd = &cobra.Command{
+	Use:   "status <run-id>",
+	Short: "Poll a background test run started with 'tdd-ai test --async'",
+	Long: `Reports whether a background test run is still going. Once it has finished,
+prints its output and records the result in the session exactly like 'tdd-ai test'.
+The result is recorded only once; later polls just report it.
+
+A run belongs to the phase and iteration it was started in. If the session has
+moved on since, the result is stale: it is not recorded, the command exits
+with code 2, and the tests must be run again.`,
+	Example: `  tdd-ai test status k3x9a1
+  tdd-ai test status k3x9a1 --summary`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		s, err := session.LoadOrFail(dir)
+		if err != nil {
+			return err
+		}
+
+		run, err := testrun.Load(dir, args[0])
+		if errors.Is(err, testrun.ErrInvalidID) {
+			return invalidInputError(err)
+		}
+		if err != nil {
+			return err
+		}
+
+		if run.Status == testrun.StatusRunning {
+			fmt.Fprintf(cmd.OutOrStdout(), "Test run %s is still running (started %s)\n", run.ID, run.StartedAt)
+			return nil
+		}
+		if run.Collected {
+			fmt.Fprintf(cmd.OutOrStdout(), "Test run %s finished at %s: %s (already recorded)\n", run.ID, run.FinishedAt, strings.ToUpper(run.Result))
+			return nil
+		}
+		if run.Stale || !run.Matches(s) {
+			if !run.Stale {
+				run.Stale = true
+				if err := testrun.Save(dir, run); err != nil {
+					return err
+				}
+			}
+			return blockedError(fmt.Errorf("test run %s was started in %s (iteration %d) but the session is now in %s (iteration %d); its %s result is stale and was not recorded. Run 'tdd-ai test' again",
+				run.ID, strings.ToUpper(string(run.Phase)), run.Iteration, strings.ToUpper(string(s.Phase)), s.Iteration, strings.ToUpper(run.Result)))
+		}
+
+		data, err := os.ReadFile(testrun.LogPath(dir, run.ID))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("reading test output: %w", err)
+		}
+		output := string(data)
+		if len(output) > 0 {
+			printTestOutput(cmd, output, testStatusSummaryFlag)
+		}
+
+		// Only a result the session actually holds counts as collected; if
+		// the save fails, the next poll records it again.
+		if err := recordTestResult(cmd, dir, s, testRun{
+			Result:   run.Result,
+			Category: classifyFailure(output, run.Result),
+			Output:   output,
+			Suite:    run.Suite,
+		}); err != nil {
+			return err
+		}
+		run.Collected = true
+		return testrun.Save(dir, run)
+	},
 }
 
 func init() {
-	testCmd.Flags().BoolVar(&testSummaryFlag, "summary", false, "show only the last 20 lines of test output (saves LLM context window)")
+	addSummaryFlags(testStatusCmd, &testStatusSummaryFlag, "test")
+	testCmd.AddCommand(testWorkerCmd)
+	testCmd.AddCommand(testStatusCmd)
+	testRecordCmd.Flags().StringVar(&testSuiteFlag, "suite", "", "named test suite the result belongs to")
+	testRecordCmd.Flags().StringVar(&testRecordOutputFile, "output-file", "", "file containing the captured test output to classify and summarize")
+	testCmd.AddCommand(testRecordCmd)
+	addSummaryFlags(testCmd, &testSummaryFlag, "test")
+	testCmd.Flags().StringVar(&testSuiteFlag, "suite", "", "named test suite to run (see 'tdd-ai init --test-suite')")
+	testCmd.Flags().BoolVar(&testNoCacheFlag, "no-cache", false, "inject -count=1 into 'go test' commands so Go's test cache is bypassed")
+	testCmd.Flags().BoolVar(&testNoStreamFlag, "no-stream", false, "print test output only after the command exits instead of streaming it")
+	testCmd.Flags().BoolVar(&testAsyncFlag, "async", false, "start the test command in the background and return a run ID to poll")
+	testCmd.Flags().BoolVar(&testRunShardsFlag, "shards", false, "run the shard commands configured with 'tdd-ai init --test-shard' and aggregate their results")
+	testCmd.Flags().StringArrayVar(&testAreaFlag, "area", nil, "run the test area containing this path (repeatable; see 'tdd-ai init --test-area')")
+	testCmd.Flags().BoolVar(&testAllAreasFlag, "all-areas", false, "run every test area instead of the current specs' areas")
+	testCmd.Flags().BoolVar(&testParallelFlag, "parallel", false, "run the shards at the same time (with --shards)")
 	rootCmd.AddCommand(testCmd)
 }
@@ -0,0 +1,633 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/testrun"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestTestRecordStoresResult(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	testRecordOutputFile = ""
+	out, _, err := executePhaseCmd(t, "test", "record", "fail", "--format", "text")
+	if err != nil {
+		t.Fatalf("test record failed: %v", err)
+	}
+	if !strings.Contains(out, "Test result: FAIL") {
+		t.Errorf("should confirm recorded result, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.LastTestResult != "fail" {
+		t.Errorf("LastTestResult = %q, want %q", loaded.LastTestResult, "fail")
+	}
+	last := loaded.History[len(loaded.History)-1]
+	if last.Action != "test_run" || last.Result != "fail" {
+		t.Errorf("should record test_run event, got %+v", last)
+	}
+}
+
+func TestTestRecordClassifiesOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	outFile := filepath.Join(dir, "out.txt")
+	content := "=== RUN   TestAdd\n./calc_test.go:9:7: undefined: Add\nFAIL\tcalc [build failed]\n"
+	if err := os.WriteFile(outFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write output file: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testRecordOutputFile = "" }()
+
+	if _, _, err := executePhaseCmd(t, "test", "record", "fail", "--output-file", outFile, "--format", "text"); err != nil {
+		t.Fatalf("test record failed: %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.LastFailureCategory != types.FailureCompile {
+		t.Errorf("LastFailureCategory = %q, want %q", loaded.LastFailureCategory, types.FailureCompile)
+	}
+	if loaded.LastTestCount == nil || *loaded.LastTestCount != 1 {
+		t.Errorf("LastTestCount = %v, want 1", loaded.LastTestCount)
+	}
+}
+
+func TestTestRecordRejectsUnknownResult(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "test", "record", "maybe", "--format", "text"); err == nil {
+		t.Error("test record should reject results other than pass or fail")
+	}
+}
+
+func TestTestStatusCollectsFinishedRunOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	run, err := testrun.New(dir, "false", "", types.PhaseRed, 0, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, _, err := executePhaseCmd(t, "test", "status", run.ID, "--format", "text")
+	if err != nil {
+		t.Fatalf("test status failed: %v", err)
+	}
+	if !strings.Contains(out, "still running") {
+		t.Errorf("should report running run, got:\n%s", out)
+	}
+
+	if _, _, err := executePhaseCmd(t, "test", "worker", run.ID); err != nil {
+		t.Fatalf("test worker failed: %v", err)
+	}
+
+	out, _, err = executePhaseCmd(t, "test", "status", run.ID, "--format", "text")
+	if err != nil {
+		t.Fatalf("test status failed: %v", err)
+	}
+	if !strings.Contains(out, "Test result: FAIL") {
+		t.Errorf("should collect finished result, got:\n%s", out)
+	}
+
+	out, _, err = executePhaseCmd(t, "test", "status", run.ID, "--format", "text")
+	if err != nil {
+		t.Fatalf("test status failed: %v", err)
+	}
+	if !strings.Contains(out, "already recorded") {
+		t.Errorf("second poll should not record again, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.LastTestResult != "fail" {
+		t.Errorf("LastTestResult = %q, want %q", loaded.LastTestResult, "fail")
+	}
+	runs := 0
+	for _, e := range loaded.History {
+		if e.Action == "test_run" {
+			runs++
+		}
+	}
+	if runs != 1 {
+		t.Errorf("recorded %d test_run events, want 1", runs)
+	}
+}
+
+func TestTestStatusRejectsInvalidRunID(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	for _, id := range []string{"../.tdd-ai", "K3X9A1"} {
+		if _, _, err := executePhaseCmd(t, "test", "status", id); ExitCode(err) != ExitInvalidInput {
+			t.Errorf("test status %s: exit code %d (%v), want %d", id, ExitCode(err), err, ExitInvalidInput)
+		}
+	}
+}
+
+func TestTestStatusRefusesStaleRun(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	run, err := testrun.New(dir, "false", "", types.PhaseRed, 0, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+	run.Finish("fail", time.Now())
+	if err := testrun.Save(dir, run); err != nil {
+		t.Fatal(err)
+	}
+	s.SetPhase(types.PhaseGreen)
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	for range 2 {
+		_, _, err := executePhaseCmd(t, "test", "status", run.ID, "--format", "text")
+		if ExitCode(err) != ExitBlocked || !strings.Contains(err.Error(), "stale") {
+			t.Errorf("test status on a stale run = %v, want a blocked stale error", err)
+		}
+	}
+	if loaded, _ := session.Load(dir); loaded.LastTestResult != "" {
+		t.Errorf("a stale result must not be recorded, got %q", loaded.LastTestResult)
+	}
+	if loaded, _ := testrun.Load(dir, run.ID); !loaded.Stale || loaded.Collected {
+		t.Errorf("run = %+v, want stale and not collected", loaded)
+	}
+}
+
+func TestTestStatusLeavesRunUncollectedWhenSaveFails(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.HistoryMaxEvents = 1
+	s.HistoryStrategy = types.HistoryError
+	s.AddEvent("init")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	run, err := testrun.New(dir, "false", "", types.PhaseRed, 0, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+	run.Finish("fail", time.Now())
+	if err := testrun.Save(dir, run); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if _, _, err := executePhaseCmd(t, "test", "status", run.ID, "--format", "text"); err == nil {
+		t.Fatal("test status should fail when the session cannot be saved")
+	}
+	if loaded, _ := testrun.Load(dir, run.ID); loaded.Collected {
+		t.Error("a result the session did not save must not be marked collected")
+	}
+}
+
+func TestTestSuiteRequiredBeforeAdvancing(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.Phase = types.PhaseRefactor
+	s.Reflections = nil
+	s.TestCmds = map[string]string{"unit": "true", "integration": "true"}
+	s.RequiredSuites = map[types.Phase][]string{types.PhaseRefactor: {"unit", "integration"}}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testSuiteFlag = "" }()
+
+	out, _, err := executePhaseCmd(t, "test", "--suite", "unit", "--format", "text")
+	if err != nil {
+		t.Fatalf("test --suite unit failed: %v", err)
+	}
+	if !strings.Contains(out, "Test result (unit): PASS") || !strings.Contains(out, "tdd-ai test --suite integration") {
+		t.Errorf("should report the suite result and point at the missing suite, got:\n%s", out)
+	}
+
+	testSuiteFlag, testResultFlag = "", ""
+	_, _, err = executePhaseCmd(t, "phase", "next", "--format", "text")
+	if err == nil || !strings.Contains(err.Error(), "requires test suite(s) integration to pass") {
+		t.Fatalf("phase next should wait for the integration suite, got: %v", err)
+	}
+
+	if _, _, err := executePhaseCmd(t, "test", "record", "pass", "--suite", "integration", "--format", "text"); err != nil {
+		t.Fatalf("test record --suite failed: %v", err)
+	}
+	testSuiteFlag = ""
+	if _, _, err := executePhaseCmd(t, "phase", "next", "--format", "text"); err != nil {
+		t.Fatalf("phase next should advance once all suites passed: %v", err)
+	}
+	loaded, _ := session.Load(dir)
+	if loaded.SuiteResults != nil {
+		t.Errorf("SuiteResults should reset on phase change, got %v", loaded.SuiteResults)
+	}
+}
+
+func TestTestUnknownSuiteIsInvalidInput(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.TestCmds = map[string]string{"unit": "true"}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testSuiteFlag = "" }()
+
+	_, _, err := executePhaseCmd(t, "test", "--suite", "e2e", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput || !strings.Contains(err.Error(), "configured: unit") {
+		t.Errorf("unknown suite should be invalid input listing configured suites, got: %v", err)
+	}
+}
+
+func TestTestRecordStoresFailureEvidence(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.OutputLines = 3
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	outFile := filepath.Join(dir, "out.txt")
+	content := "=== RUN   TestAdd\n    calc_test.go:9: connecting with password=hunter2\n--- FAIL: TestAdd (0.00s)\nFAIL\nFAIL\tcalc\t0.01s\n"
+	if err := os.WriteFile(outFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write output file: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testRecordOutputFile = "" }()
+
+	if _, _, err := executePhaseCmd(t, "test", "record", "fail", "--output-file", outFile, "--format", "text"); err != nil {
+		t.Fatalf("test record failed: %v", err)
+	}
+
+	loaded, _ := session.Load(dir)
+	ev := loaded.LastTestOutput
+	if ev == nil || len(ev.FailingTests) != 1 || ev.FailingTests[0] != "TestAdd" {
+		t.Fatalf("LastTestOutput = %+v, want failing test TestAdd", ev)
+	}
+	if ev.FirstFailure != "TestAdd" || ev.FailureMessage != "calc_test.go:9: connecting with password=[REDACTED]" {
+		t.Errorf("first failure = %q, %q; want TestAdd with its redacted message", ev.FirstFailure, ev.FailureMessage)
+	}
+	if len(ev.Output) != 3 || ev.Output[0] != "--- FAIL: TestAdd (0.00s)" {
+		t.Errorf("Output = %q, want the last 3 lines", ev.Output)
+	}
+
+	out, _, err := executePhaseCmd(t, "resume", "--format", "text")
+	if err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if !strings.Contains(out, "Failing Tests:\n  - TestAdd") {
+		t.Errorf("resume should show the failing test, got:\n%s", out)
+	}
+
+	testRecordOutputFile = ""
+	if _, _, err := executePhaseCmd(t, "test", "record", "pass", "--format", "text"); err != nil {
+		t.Fatalf("test record failed: %v", err)
+	}
+	loaded, _ = session.Load(dir)
+	if loaded.LastTestOutput != nil {
+		t.Error("a passing run should clear the stored failure output")
+	}
+}
+
+func TestTestRecordAttachesTriageHints(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.TriagePatterns = map[string]types.TriagePattern{
+		"db-locked": {Match: `database is locked`, Hint: "Give each test its own database file."},
+	}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	outFile := filepath.Join(dir, "out.txt")
+	content := "--- FAIL: TestServe (0.00s)\n    server_test.go:14: listen tcp :8080: bind: address already in use\n    store_test.go:20: database is locked\nFAIL\n"
+	if err := os.WriteFile(outFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write output file: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testRecordOutputFile = "" }()
+
+	out, _, err := executePhaseCmd(t, "test", "record", "fail", "--output-file", outFile, "--format", "text")
+	if err != nil {
+		t.Fatalf("test record failed: %v", err)
+	}
+	if !strings.Contains(out, "Triage hint (port-in-use):") || !strings.Contains(out, "Triage hint (db-locked): Give each test its own database file.") {
+		t.Errorf("should print built-in and project hints, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	hints := loaded.LastTestOutput.TriageHints
+	if len(hints) != 2 || hints[0].Pattern != "db-locked" || hints[1].Pattern != "port-in-use" {
+		t.Fatalf("TriageHints = %+v, want db-locked and port-in-use", hints)
+	}
+	if hints[1].Line != "server_test.go:14: listen tcp :8080: bind: address already in use" {
+		t.Errorf("hint line = %q, want the matching output line", hints[1].Line)
+	}
+	if last := loaded.History[len(loaded.History)-1]; len(last.TriageHints) != 2 {
+		t.Errorf("test_run event should carry the hints, got %+v", last)
+	}
+
+	out, _, err = executePhaseCmd(t, "guide", "--format", "text")
+	if err != nil {
+		t.Fatalf("guide failed: %v", err)
+	}
+	if !strings.Contains(out, "Triage Hints:\n  - db-locked: Give each test its own database file.") {
+		t.Errorf("guide should show the hints, got:\n%s", out)
+	}
+}
+
+// signalWriter creates a file on its first write, so a test command can wait
+// for proof that its output was seen before it exits.
+type signalWriter struct {
+	strings.Builder
+	path string
+}
+
+func (w *signalWriter) Write(p []byte) (int, error) {
+	if w.Len() == 0 {
+		_ = os.WriteFile(w.path, nil, 0644)
+	}
+	return w.Builder.Write(p)
+}
+
+func TestRunTestCommandStreamsOutput(t *testing.T) {
+	dir := t.TempDir()
+	script := `echo started; for i in $(seq 300); do [ -f seen ] && { echo streamed; exit 0; }; sleep 0.01; done; echo buffered`
+	parts := []string{"sh", "-c", script}
+
+	for _, tc := range []struct {
+		stream bool
+		want   string
+	}{
+		{stream: true, want: "streamed"},
+		{stream: false, want: "buffered"},
+	} {
+		os.Remove(filepath.Join(dir, "seen"))
+		w := &signalWriter{path: filepath.Join(dir, "seen")}
+		testCmd.SetOut(w)
+		run := runTestCommand(testCmd, dir, parts, nil, nil, false, tc.stream)
+		testCmd.SetOut(nil)
+
+		if !strings.Contains(w.String(), tc.want) || !strings.Contains(run.Output, tc.want) {
+			t.Errorf("stream=%v: want %q in printed and recorded output, got %q / %q", tc.stream, tc.want, w.String(), run.Output)
+		}
+	}
+}
+
+func TestPrintTestOutputHeadTailWindow(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 50; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	output := strings.Join(lines, "\n") + "\n"
+	defer func() {
+		summaryLinesFlag, summaryHeadFlag, summaryModeFlag = defaultSummaryLines, defaultSummaryHead, summaryModeTail
+		testCmd.SetOut(nil)
+	}()
+
+	for _, tc := range []struct {
+		mode        string
+		tail, head  int
+		want, avoid []string
+	}{
+		{mode: summaryModeTail, tail: 5, head: 10, want: []string{"showing last 5", "line 46", "line 50"}, avoid: []string{"line 1\n", "line 45"}},
+		{mode: summaryModeHeadTail, tail: 5, head: 3, want: []string{"line 1\n", "line 3\n", "showing first 3 and last 5", "line 46", "line 50"}, avoid: []string{"line 4\n", "line 45"}},
+	} {
+		summaryModeFlag, summaryLinesFlag, summaryHeadFlag = tc.mode, tc.tail, tc.head
+		var buf strings.Builder
+		testCmd.SetOut(&buf)
+		printTestOutput(testCmd, output, true)
+		out := buf.String()
+		for _, w := range tc.want {
+			if !strings.Contains(out, w) {
+				t.Errorf("%s: output should contain %q, got:\n%s", tc.mode, w, out)
+			}
+		}
+		for _, a := range tc.avoid {
+			if strings.Contains(out, a) {
+				t.Errorf("%s: output should not contain %q, got:\n%s", tc.mode, a, out)
+			}
+		}
+	}
+}
+
+func TestTestRejectsUnknownSummaryMode(t *testing.T) {
+	defer func() { summaryModeFlag = summaryModeTail }()
+	_, _, err := executePhaseCmd(t, "test", "--summary-mode", "middle")
+	if ExitCode(err) != ExitInvalidInput {
+		t.Errorf("unknown --summary-mode should be invalid input, got %v", err)
+	}
+}
+
+func TestTestShardsRecordFailUnlessEveryShardPasses(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.TestShards = []string{"true", "false", "true"}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testRunShardsFlag, testParallelFlag = false, false }()
+
+	out, _, err := executePhaseCmd(t, "test", "--shards", "--parallel", "--format", "text")
+	if err != nil {
+		t.Fatalf("test --shards failed: %v", err)
+	}
+	if !strings.Contains(out, "Shards: 2/3 passed") || !strings.Contains(out, "Test result: FAIL") {
+		t.Errorf("should aggregate the shard results, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.LastTestResult != "fail" {
+		t.Errorf("LastTestResult = %q, want fail", loaded.LastTestResult)
+	}
+	if len(loaded.ShardResults) != 3 || loaded.ShardResults[1].Result != "fail" || loaded.ShardResults[1].Cmd != "false" {
+		t.Errorf("ShardResults = %+v, want the outcome of each shard in order", loaded.ShardResults)
+	}
+}
+
+func TestTestShardsRequireConfiguredShards(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testRunShardsFlag = false }()
+
+	_, _, err := executePhaseCmd(t, "test", "--shards", "--format", "text")
+	if ExitCode(err) != ExitInvalidInput || !strings.Contains(err.Error(), "--test-shard") {
+		t.Errorf("--shards without shards should be invalid input naming --test-shard, got: %v", err)
+	}
+}
+
+func TestTestRecordFlagsCachedGoResults(t *testing.T) {
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	outFile := filepath.Join(dir, "out.txt")
+	content := "ok  \texample.com/calc\t(cached)\nok  \texample.com/cart\t0.012s\n"
+	if err := os.WriteFile(outFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write output file: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testRecordOutputFile = "" }()
+
+	out, _, err := executePhaseCmd(t, "test", "record", "pass", "--output-file", outFile, "--format", "text")
+	if err != nil {
+		t.Fatalf("test record failed: %v", err)
+	}
+	if !strings.Contains(out, "(cached)") {
+		t.Errorf("should warn about cached results, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	last := loaded.History[len(loaded.History)-1]
+	if !loaded.LastTestCached || !last.Cached {
+		t.Errorf("LastTestCached = %v, event = %+v; want both cached", loaded.LastTestCached, last)
+	}
+}
+
+func TestGoTestCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		noCache bool
+		want    string
+	}{
+		{"go test ./...", true, "go test -count=1 ./..."},
+		{"go test ./...", false, "go test ./..."},
+		{"go test -count=3 ./...", true, "go test -count=3 ./..."},
+		{"go test -count 2 ./...", true, "go test -count 2 ./..."},
+		{"npm test", true, "npm test"},
+		{"go vet ./...", true, "go vet ./..."},
+	}
+	for _, tt := range tests {
+		if got := goTestCommand(tt.command, tt.noCache); got != tt.want {
+			t.Errorf("goTestCommand(%q, %v) = %q, want %q", tt.command, tt.noCache, got, tt.want)
+		}
+	}
+}
+
+func TestTestRoutesToCurrentSpecArea(t *testing.T) {
+	dir := t.TempDir()
+	for _, area := range []string{"services/api", "web"} {
+		if err := os.MkdirAll(filepath.Join(dir, area), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Each command only passes when run from its area's directory.
+	if err := os.WriteFile(filepath.Join(dir, "services/api", "api.marker"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := types.NewSession()
+	s.TestAreas = map[string]string{"services/api": "test -f api.marker", "web": "test -f web.marker"}
+	id := s.AddSpec("returns 404 for an unknown order")
+	if err := s.SetSpecArea(id, "services/api/orders"); err != nil {
+		t.Fatalf("SetSpecArea() error: %v", err)
+	}
+	if err := s.SetPickGroup([]int{id}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { testAreaFlag, testAllAreasFlag = nil, false }()
+
+	out, _, err := executePhaseCmd(t, "test", "--format", "text")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if !strings.Contains(out, "Running 1 test area(s): services/api") || !strings.Contains(out, "Test result: PASS") {
+		t.Errorf("should run only the current spec's area, got:\n%s", out)
+	}
+
+	out, _, err = executePhaseCmd(t, "test", "--all-areas", "--format", "text")
+	if err != nil {
+		t.Fatalf("test --all-areas failed: %v", err)
+	}
+	if !strings.Contains(out, "Areas: 1/2 passed") || !strings.Contains(out, "Test result: FAIL") {
+		t.Errorf("should run and aggregate every area, got:\n%s", out)
+	}
+
+	loaded, _ := session.Load(dir)
+	if loaded.AreaResults["services/api"] != "pass" || loaded.AreaResults["web"] != "fail" {
+		t.Errorf("AreaResults = %v, want the last result of each area", loaded.AreaResults)
+	}
+	last := loaded.History[len(loaded.History)-1]
+	if last.AreaResults["web"] != "fail" {
+		t.Errorf("test_run event should record per-area results, got %+v", last)
+	}
+
+	testAllAreasFlag = false
+	if _, _, err := executePhaseCmd(t, "test", "--area", "docs", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("an area outside the configured ones should be invalid input, got %v", err)
+	}
+}
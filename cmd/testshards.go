@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/testcount"
+	"github.com/macosta/tdd-ai/internal/testoutput"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// shardCmd is a command run as one shard. A shard for a test area runs from
+// the area's directory.
+type shardCmd struct {
+	Cmd  string
+	Area string
+}
+
+// shardRun is one executed shard with its full output.
+type shardRun struct {
+	types.ShardResult
+	output string
+}
+
+// runShards executes every shard command in dir, in parallel when asked, and
+// prints each shard's output in shard order once all have finished. The run
+// passes only when every shard passes; an infrastructure error in any shard
+// makes the whole run an error, since the other results cannot be trusted.
+// Test areas are run the same way, one shard per area.
+func runShards(cmd *cobra.Command, dir string, shards []shardCmd, env []string, red *testoutput.Redactor, parallel, summary bool) testRun {
+	runs := make([]shardRun, len(shards))
+	runOne := func(i int) { runs[i] = runShard(dir, i+1, shards[i], env, red) }
+	noun := "Shard"
+	if len(shards) > 0 && shards[0].Area != "" {
+		noun = "Area"
+	}
+	if parallel {
+		var wg sync.WaitGroup
+		for i := range shards {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runOne(i)
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i := range shards {
+			runOne(i)
+		}
+	}
+
+	run := testRun{Result: "pass", Count: &testcount.Counts{}}
+	var combined strings.Builder
+	passed := 0
+	for _, r := range runs {
+		if r.Area != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "--- Area %s: %s ---\n", r.Area, r.Cmd)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "--- Shard %d/%d: %s ---\n", r.Shard, len(runs), r.Cmd)
+		}
+		if r.output != "" {
+			printTestOutput(cmd, r.output, summary)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Exit code: %d (%s)\n\n", r.ExitCode, time.Duration(r.DurationMs)*time.Millisecond)
+
+		if r.Area != "" {
+			fmt.Fprintf(&combined, "=== area %s: %s ===\n%s", r.Area, r.Cmd, r.output)
+		} else {
+			fmt.Fprintf(&combined, "=== shard %d: %s ===\n%s", r.Shard, r.Cmd, r.output)
+		}
+		if counts, ok := testcount.Parse(r.output); ok {
+			run.Count.Tests += counts.Tests
+			run.Count.Assertions += counts.Assertions
+		} else {
+			run.Count = nil
+		}
+		switch {
+		case r.Result == "error":
+			run.Result = "error"
+		case r.Result == "fail" && run.Result == "pass":
+			run.Result = "fail"
+		case r.Result == "pass":
+			passed++
+		}
+		run.Shards = append(run.Shards, r.ShardResult)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%ss: %d/%d passed\n", noun, passed, len(runs))
+
+	run.Output = combined.String()
+	run.Category = classifyFailure(run.Output, run.Result)
+	return run
+}
+
+// runShard executes one shard command and classifies its result.
+func runShard(dir string, shard int, sc shardCmd, env []string, red *testoutput.Redactor) shardRun {
+	parts := strings.Fields(sc.Cmd)
+	c := exec.Command(parts[0], parts[1:]...)
+	c.Dir = filepath.Join(dir, filepath.FromSlash(sc.Area))
+	c.Env = env
+	var buf bytes.Buffer
+	c.Stdout, c.Stderr = &buf, &buf
+
+	start := time.Now()
+	err := c.Run()
+	output := red.Text(buf.String())
+	result := classifyTestResult(output, err)
+
+	r := shardRun{output: output}
+	r.ShardResult = types.ShardResult{
+		Shard:      shard,
+		Area:       sc.Area,
+		Cmd:        sc.Cmd,
+		Result:     result,
+		ExitCode:   processExitCode(err),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if result != "pass" {
+		r.FailingTests = testoutput.FailingTests(output)
+	}
+	return r
+}
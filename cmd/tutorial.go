@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/tutorial"
+	"github.com/spf13/cobra"
+)
+
+// tutorialOutput is the JSON form of the tutorial's state after a command.
+type tutorialOutput struct {
+	Step     int               `json:"step"`
+	Total    int               `json:"total"`
+	Mistakes int               `json:"mistakes"`
+	Attempt  *tutorial.Attempt `json:"attempt,omitempty"`
+	Next     *tutorial.Step    `json:"next,omitempty"`
+	Done     bool              `json:"done"`
+}
+
+var tutorialCmd = &cobra.Command{
+	Use:   "tutorial",
+	Short: "Practice the TDD loop on a sandbox spec with simulated test results",
+	Long: `Walks you through a scripted red-green-refactor cycle on a sandbox spec. Nothing
+is run and no real session is touched: each step's test output is simulated.
+
+Perform each step by prefixing the command with 'tdd-ai tutorial do'. Commands
+issued in the wrong order are rejected with an explanation of where they belong,
+so the loop's order sticks before it matters. Progress is kept in
+.tdd-ai.tutorial.json until 'tdd-ai tutorial reset'.`,
+	Example: `  tdd-ai tutorial
+  tdd-ai tutorial do spec add "Add returns the sum of two numbers"
+  tdd-ai tutorial reset`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		p, err := tutorial.Load(getWorkDir())
+		if err != nil {
+			return err
+		}
+		return writeTutorial(cmd.OutOrStdout(), p, nil)
+	},
+}
+
+var tutorialDoCmd = &cobra.Command{
+	Use:   "do <command> [args...]",
+	Short: "Perform a tutorial step",
+	Long: `Checks a tdd-ai command against the tutorial's next step. A correct command shows
+its simulated outcome and moves on; a wrong one is explained and counted as a mistake.
+Everything after 'do' is treated as the command, including flags.`,
+	Example:            `  tdd-ai tutorial do spec pick 1`,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := getWorkDir()
+		p, err := tutorial.Load(dir)
+		if err != nil {
+			return err
+		}
+		attempt := p.Try(args)
+		if err := tutorial.Save(dir, p); err != nil {
+			return err
+		}
+		return writeTutorial(cmd.OutOrStdout(), p, &attempt)
+	},
+}
+
+var tutorialResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Start the tutorial over",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if err := tutorial.Reset(getWorkDir()); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "Tutorial reset. Run 'tdd-ai tutorial' to begin.")
+		return nil
+	},
+}
+
+// writeTutorial renders the result of the last attempt, if any, and the next step.
+func writeTutorial(w io.Writer, p *tutorial.Progress, attempt *tutorial.Attempt) error {
+	f := formatter.Format(formatFlag)
+	switch f {
+	case formatter.FormatJSON:
+		data, err := json.MarshalIndent(tutorialOutput{
+			Step:     p.Step,
+			Total:    len(tutorial.Steps),
+			Mistakes: p.Mistakes,
+			Attempt:  attempt,
+			Next:     p.Current(),
+			Done:     p.Done(),
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding tutorial: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+	case formatter.FormatText:
+		if attempt != nil {
+			if attempt.Correct {
+				fmt.Fprintf(w, "Correct. Simulated output:\n%s\n\n", attempt.Outcome)
+			} else {
+				fmt.Fprintf(w, "Not yet. %s\n\n", attempt.Mistake)
+			}
+		}
+		step := p.Current()
+		if step == nil {
+			fmt.Fprintf(w, "Tutorial complete: %d steps with %d mistake(s). You are ready for a real session: 'tdd-ai init'.\n", len(tutorial.Steps), p.Mistakes)
+			return nil
+		}
+		fmt.Fprintf(w, "Step %d/%d: %s\n", p.Step+1, len(tutorial.Steps), step.Teach)
+		fmt.Fprintf(w, "Next: tdd-ai tutorial do %s\n", strings.TrimPrefix(step.Example, "tdd-ai "))
+	default:
+		return unknownFormatError(f)
+	}
+	return nil
+}
+
+func init() {
+	tutorialCmd.AddCommand(tutorialDoCmd)
+	tutorialCmd.AddCommand(tutorialResetCmd)
+	rootCmd.AddCommand(tutorialCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/session"
+)
+
+func TestTutorialRejectsOutOfOrderCommands(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	origIsTerminal := isTerminal
+	isTerminal = func() bool { return true }
+	defer func() { isTerminal = origIsTerminal }()
+
+	out, _, err := executePhaseCmd(t, "tutorial", "do", "phase", "next")
+	if err != nil {
+		t.Fatalf("tutorial do failed: %v", err)
+	}
+	if !strings.Contains(out, "Too early") || !strings.Contains(out, "Step 1/") {
+		t.Errorf("should explain the mistake and repeat step 1, got:\n%s", out)
+	}
+
+	out, _, err = executePhaseCmd(t, "tutorial", "do", "spec", "add", "Add returns the sum")
+	if err != nil {
+		t.Fatalf("tutorial do failed: %v", err)
+	}
+	if !strings.Contains(out, "Correct.") || !strings.Contains(out, "Step 2/") {
+		t.Errorf("should accept the step and move on, got:\n%s", out)
+	}
+	if session.Exists(dir) {
+		t.Error("the tutorial must not create a real session")
+	}
+}
@@ -21,17 +21,45 @@ Checks that each completed spec followed the RED-GREEN-REFACTOR cycle:
 - A failing test was recorded during RED phase (greenfield mode)
 - No phase_set usage (bypassing TDD guardrails)
 
+Files matching the session's protected paths ('tdd-ai init --protect') that
+have uncommitted changes are reported as violations.
+
+Also warns (without failing) when an agent tried to claim a file already
+claimed by another agent via 'tdd-ai claim', and when a file claimed by an
+agent other than TDD_AI_AGENT_ID has uncommitted changes.
+
+Use --format gha in GitHub Actions to annotate the pull request with each
+violation and warning.
+
 Returns exit code 0 when compliant, 1 when violations are found.`,
 	Example: `  tdd-ai verify
-  tdd-ai verify --format json`,
+  tdd-ai verify --format json
+  tdd-ai verify --format gha`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		dir := getWorkDir()
 		s, err := session.LoadOrFail(dir)
 		if err != nil {
 			return err
 		}
+		// Archived specs still count toward compliance
+		if s, err = session.IncludeArchive(dir, s); err != nil {
+			return err
+		}
 
 		result := verify.Analyze(s)
+		for _, p := range modifiedProtectedPaths(dir, s) {
+			result.Violations = append(result.Violations, verify.Violation{
+				Rule:    "protected_path_modified",
+				Message: fmt.Sprintf("%s is a protected path but has uncommitted changes", p),
+			})
+			result.Compliant = false
+		}
+		for _, c := range claimsTouchedByOthers(dir, s) {
+			result.Warnings = append(result.Warnings, verify.Violation{
+				Rule:    "claimed_file_modified",
+				Message: fmt.Sprintf("%s has uncommitted changes but is claimed by %s", c.Path, c.Holder),
+			})
+		}
 
 		f := formatter.Format(formatFlag)
 		switch f {
@@ -45,6 +73,12 @@ Returns exit code 0 when compliant, 1 when violations are found.`,
 			var b strings.Builder
 			fmt.Fprintf(&b, "TDD Compliance: %.0f%%\n", result.Score)
 			fmt.Fprintf(&b, "Specs verified: %d, compliant: %d\n", result.SpecsVerified, result.SpecsCompliant)
+			if result.OverridesCount > 0 {
+				fmt.Fprintf(&b, "Overrides: %d\n", result.OverridesCount)
+			}
+			if result.ReflectionDebt > 0 {
+				fmt.Fprintf(&b, "Reflection debt: %d\n", result.ReflectionDebt)
+			}
 
 			if len(result.Violations) > 0 {
 				b.WriteString("\nViolations:\n")
@@ -58,9 +92,17 @@ Returns exit code 0 when compliant, 1 when violations are found.`,
 			} else {
 				b.WriteString("\nNo violations found.\n")
 			}
+			if len(result.Warnings) > 0 {
+				b.WriteString("\nWarnings:\n")
+				for _, w := range result.Warnings {
+					fmt.Fprintf(&b, "  %s: %s\n", w.Rule, w.Message)
+				}
+			}
 			fmt.Fprint(cmd.OutOrStdout(), b.String())
+		case formatter.FormatGHA:
+			fmt.Fprint(cmd.OutOrStdout(), formatter.FormatAnnotations(verifyAnnotations(result)))
 		default:
-			return fmt.Errorf("unknown format: %q", formatFlag)
+			return unknownFormatError(formatFlag)
 		}
 
 		if !result.Compliant {
@@ -70,6 +112,31 @@ Returns exit code 0 when compliant, 1 when violations are found.`,
 	},
 }
 
+// verifyAnnotations turns compliance violations into CI errors and warnings
+// into CI warnings.
+func verifyAnnotations(result verify.Result) []formatter.Annotation {
+	var annotations []formatter.Annotation
+	for _, v := range result.Violations {
+		msg := v.Message
+		if v.SpecID > 0 {
+			msg = fmt.Sprintf("spec %d: %s", v.SpecID, v.Message)
+		}
+		annotations = append(annotations, formatter.Annotation{
+			Level:   formatter.AnnotationError,
+			Title:   "TDD violation (" + v.Rule + ")",
+			Message: msg,
+		})
+	}
+	for _, w := range result.Warnings {
+		annotations = append(annotations, formatter.Annotation{
+			Level:   formatter.AnnotationWarning,
+			Title:   "TDD warning (" + w.Rule + ")",
+			Message: w.Message,
+		})
+	}
+	return annotations
+}
+
 func init() {
 	rootCmd.AddCommand(verifyCmd)
 }
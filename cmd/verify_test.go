@@ -121,3 +121,24 @@ func TestVerifyJSONOutput(t *testing.T) {
 		t.Errorf("score = %v, want 100", parsed["score"])
 	}
 }
+
+func TestVerifyGHAFormatAnnotatesViolations(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddEvent("phase_set", func(e *types.Event) { e.From = "red"; e.To = "green" })
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	out, err := executeVerifyCmd(t, "verify", "--format", "gha")
+	if err == nil {
+		t.Fatal("verify should still fail for a non-compliant session")
+	}
+	if !strings.Contains(out, "::error title=TDD violation (no_phase_set)::phase_set used") {
+		t.Errorf("should emit an error annotation per violation, got:\n%s", out)
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/formatter"
+	"github.com/macosta/tdd-ai/internal/phase"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// Conditions accepted by 'tdd-ai wait --until'.
+const (
+	waitCanAdvance  = "can-advance"
+	waitPhasePrefix = "phase="
+)
+
+var (
+	waitUntilFlag    string
+	waitTimeoutFlag  time.Duration
+	waitIntervalFlag time.Duration
+	waitHubFlag      string
+	waitSessionFlag  string
+)
+
+// waitState is what wait knows about the session after one poll.
+type waitState struct {
+	Phase    types.Phase `json:"phase"`
+	Blockers []string    `json:"blockers"`
+}
+
+// waitOutput reports how a wait ended.
+type waitOutput struct {
+	Until    string      `json:"until"`
+	Met      bool        `json:"met"`
+	Waited   string      `json:"waited"`
+	Phase    types.Phase `json:"phase"`
+	Blockers []string    `json:"blockers,omitempty"`
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Block until a session condition holds, for CI jobs and orchestrators",
+	Long: `Polls the session until the --until condition holds, then exits 0. If the
+condition still does not hold after --timeout, exits with the blocked exit
+code (2) and reports what is still in the way.
+
+Conditions:
+  can-advance   no blockers remain for the current phase (see 'tdd-ai blockers')
+  phase=<name>  the session is in the named phase, e.g. phase=refactor
+
+With --hub and --session, polls a session served by 'tdd-ai serve' instead of
+the local session file, so a job can wait on another agent's project.`,
+	Example: `  tdd-ai wait --until can-advance --timeout 10m
+  tdd-ai wait --until phase=done --interval 5s
+  tdd-ai wait --until can-advance --hub http://127.0.0.1:7777 --session 3f2a`,
+	Args: cobra.NoArgs,
+	// A timeout is an expected outcome; usage adds nothing.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		met, err := waitCondition(waitUntilFlag)
+		if err != nil {
+			return err
+		}
+		if waitTimeoutFlag <= 0 || waitIntervalFlag <= 0 {
+			return invalidInputError(fmt.Errorf("--timeout and --interval must be positive durations"))
+		}
+		if (waitHubFlag == "") != (waitSessionFlag == "") {
+			return invalidInputError(fmt.Errorf("--hub and --session must be given together"))
+		}
+		f := formatter.Format(formatFlag)
+		if f != formatter.FormatJSON && f != formatter.FormatText {
+			return unknownFormatError(f)
+		}
+
+		poll := func() (waitState, error) { return localWaitState(getWorkDir()) }
+		if waitHubFlag != "" {
+			poll = func() (waitState, error) { return hubWaitState(waitHubFlag, waitSessionFlag) }
+		}
+
+		start := time.Now()
+		deadline := time.NewTimer(waitTimeoutFlag)
+		defer deadline.Stop()
+		tick := time.NewTicker(waitIntervalFlag)
+		defer tick.Stop()
+
+		for {
+			state, err := poll()
+			if err != nil {
+				return err
+			}
+			out := waitOutput{Until: waitUntilFlag, Waited: time.Since(start).Round(time.Second).String(), Phase: state.Phase, Blockers: state.Blockers}
+			if met(state) {
+				out.Met = true
+				return writeWait(cmd, out, f)
+			}
+			select {
+			case <-tick.C:
+			case <-deadline.C:
+				if err := writeWait(cmd, out, f); err != nil {
+					return err
+				}
+				return blockedError(fmt.Errorf("timed out after %s waiting for %s", waitTimeoutFlag, waitUntilFlag))
+			case <-cmd.Context().Done():
+				return cmd.Context().Err()
+			}
+		}
+	},
+}
+
+// waitCondition parses an --until value into a check on the polled state.
+func waitCondition(until string) (func(waitState) bool, error) {
+	if until == waitCanAdvance {
+		return func(st waitState) bool { return len(st.Blockers) == 0 }, nil
+	}
+	if name, ok := strings.CutPrefix(until, waitPhasePrefix); ok {
+		p := types.Phase(name)
+		if !p.IsValid() {
+			return nil, invalidInputError(fmt.Errorf("invalid phase %q in --until. Valid phases: red, green, refactor, done", name))
+		}
+		return func(st waitState) bool { return st.Phase == p }, nil
+	}
+	return nil, invalidInputError(fmt.Errorf("invalid --until %q. Valid conditions: can-advance, phase=<name>", until))
+}
+
+// localWaitState reads the session file in dir fresh on every poll, so changes
+// made by other processes are seen.
+func localWaitState(dir string) (waitState, error) {
+	s, err := session.LoadOrFail(dir)
+	if err != nil {
+		return waitState{}, err
+	}
+	if s.Phase == types.PhaseGreen {
+		if err := checkTestFiles(dir, s); err != nil {
+			return waitState{}, err
+		}
+	}
+	return waitState{Phase: s.Phase, Blockers: phase.GetBlockers(s)}, nil
+}
+
+// hubWaitState reads a served session's guidance from a 'tdd-ai serve' hub.
+func hubWaitState(hub, id string) (waitState, error) {
+	resp, err := http.Get(strings.TrimSuffix(hub, "/") + "/sessions/" + url.PathEscape(id) + "/guide")
+	if err != nil {
+		return waitState{}, fmt.Errorf("polling hub: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return waitState{}, fmt.Errorf("polling hub: session %s: %s", id, resp.Status)
+	}
+	var g types.Guidance
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		return waitState{}, fmt.Errorf("polling hub: decoding guidance: %w", err)
+	}
+	return waitState{Phase: g.Phase, Blockers: g.Blockers}, nil
+}
+
+func writeWait(cmd *cobra.Command, out waitOutput, f formatter.Format) error {
+	if f == formatter.FormatJSON {
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding wait result: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+	w := cmd.OutOrStdout()
+	if out.Met {
+		fmt.Fprintf(w, "%s: met after %s (phase %s)\n", out.Until, out.Waited, out.Phase)
+		return nil
+	}
+	fmt.Fprintf(w, "%s: not met after %s (phase %s)\n", out.Until, out.Waited, out.Phase)
+	for _, bl := range out.Blockers {
+		fmt.Fprintf(w, "  - %s\n", bl)
+	}
+	return nil
+}
+
+func init() {
+	waitCmd.Flags().StringVar(&waitUntilFlag, "until", waitCanAdvance, "condition to wait for: can-advance or phase=<name>")
+	waitCmd.Flags().DurationVar(&waitTimeoutFlag, "timeout", 10*time.Minute, "give up after this long")
+	waitCmd.Flags().DurationVar(&waitIntervalFlag, "interval", 2*time.Second, "how often to poll the session")
+	waitCmd.Flags().StringVar(&waitHubFlag, "hub", "", "base URL of a 'tdd-ai serve' hub to poll instead of the local session")
+	waitCmd.Flags().StringVar(&waitSessionFlag, "session", "", "ID of the hub session to poll (with --hub)")
+	rootCmd.AddCommand(waitCmd)
+}
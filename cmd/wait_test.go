@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/hub"
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestWaitReturnsWhenConditionHolds(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer resetFlags(waitCmd.Flags())
+
+	out, _, err := executePhaseCmd(t, "wait", "--until", "phase=refactor", "--timeout", "1s", "--format", "text")
+	if err != nil {
+		t.Fatalf("wait should succeed when the condition already holds: %v", err)
+	}
+	if !strings.Contains(out, "phase=refactor: met") {
+		t.Errorf("wait should report the condition as met, got:\n%s", out)
+	}
+}
+
+func TestWaitTimesOutWhileBlocked(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.AddSpec("feature")
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer resetFlags(waitCmd.Flags())
+
+	out, _, err := executePhaseCmd(t, "wait", "--until", "can-advance", "--timeout", "50ms", "--interval", "10ms", "--format", "text")
+	if ExitCode(err) != ExitBlocked {
+		t.Fatalf("wait should time out with the blocked exit code, got: %v", err)
+	}
+	if !strings.Contains(out, "No spec selected") {
+		t.Errorf("wait should list the remaining blockers, got:\n%s", out)
+	}
+
+	if _, _, err := executePhaseCmd(t, "wait", "--until", "soon", "--format", "text"); ExitCode(err) != ExitInvalidInput {
+		t.Errorf("an unknown condition should be invalid input, got: %v", err)
+	}
+}
+
+func TestWaitPollsHubSession(t *testing.T) {
+	dir := t.TempDir()
+	s := types.NewSession()
+	s.Phase = types.PhaseDone
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	h := hub.New()
+	info, err := h.Register(dir)
+	if err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+	defer resetFlags(waitCmd.Flags())
+
+	out, _, err := executePhaseCmd(t, "wait", "--until", "phase=done", "--hub", srv.URL, "--session", info.ID, "--timeout", "1s", "--format", "json")
+	if err != nil {
+		t.Fatalf("wait on a hub session failed: %v", err)
+	}
+	if !strings.Contains(out, `"met": true`) {
+		t.Errorf("wait should report the hub session's condition as met, got:\n%s", out)
+	}
+}
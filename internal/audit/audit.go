@@ -0,0 +1,185 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// FileName is the append-only audit log kept next to the session file.
+const FileName = ".tdd-ai.audit.jsonl"
+
+// Entry is one line of the audit log. Each entry's hash covers the line's
+// bytes up to the hash field, which include its sequence number, event, and
+// the previous entry's hash, so editing, removing, or reordering any line
+// breaks the chain from that point on.
+type Entry struct {
+	Seq      int         `json:"seq"`
+	Event    types.Event `json:"event"`
+	PrevHash string      `json:"prev_hash"`
+	Hash     string      `json:"hash"`
+}
+
+// Head identifies the end of the chain: how many entries it has and the hash
+// of the last one. The session keeps it so truncating the log is detectable.
+type Head struct {
+	Entries int
+	Hash    string
+}
+
+// hashField separates an entry's hashed body from its hash. Entries are
+// written with Hash last, so every line ends with it.
+const hashField = `,"hash":"`
+
+// Path returns the audit log path for a given directory.
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// hashBody returns the hex SHA-256 of an entry's body: its line without the
+// hash field.
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// splitLine separates a line into the body its hash covers and the hash.
+func splitLine(line []byte) (body []byte, hash string, ok bool) {
+	i := bytes.LastIndex(line, []byte(hashField))
+	if i < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, "", false
+	}
+	hash = string(line[i+len(hashField) : len(line)-2])
+	body = append(line[:i:i], '}')
+	return body, hash, true
+}
+
+// Append chains events onto the end of the audit log, creating it if needed,
+// and returns the new head. The chain continues from head, the one the
+// session recorded last; a zero head continues from the log's last entry.
+func Append(dir string, head Head, events []types.Event) (Head, error) {
+	if len(events) == 0 {
+		return head, nil
+	}
+	if head.Entries == 0 {
+		last, err := lastEntry(dir)
+		if err != nil {
+			return head, err
+		}
+		if last != nil {
+			head = Head{Entries: last.Seq, Hash: last.Hash}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, ev := range events {
+		body, err := json.Marshal(struct {
+			Seq      int         `json:"seq"`
+			Event    types.Event `json:"event"`
+			PrevHash string      `json:"prev_hash"`
+		}{head.Entries + 1, ev, head.Hash})
+		if err != nil {
+			return head, fmt.Errorf("encoding audit entry: %w", err)
+		}
+		hash := hashBody(body)
+		buf.Write(body[:len(body)-1])
+		buf.WriteString(hashField + hash + `"}` + "\n")
+		head = Head{Entries: head.Entries + 1, Hash: hash}
+	}
+
+	f, err := os.OpenFile(Path(dir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return head, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return head, fmt.Errorf("writing audit log: %w", err)
+	}
+	return head, nil
+}
+
+// Verify checks every entry's hash and its link to the previous entry, then
+// that the chain ends at want, the head recorded by the session (a zero head
+// skips that check). It returns the number of valid entries and, if the chain
+// is broken or truncated, an error naming the first bad line.
+func Verify(dir string, want Head) (int, error) {
+	lines, err := readLines(dir)
+	if err != nil {
+		return 0, err
+	}
+	prev := ""
+	for i, raw := range lines {
+		line := i + 1
+		body, hash, ok := splitLine(raw)
+		var e Entry
+		if !ok || json.Unmarshal(raw, &e) != nil {
+			return i, fmt.Errorf("audit log line %d: malformed entry", line)
+		}
+		if e.Seq != line {
+			return i, fmt.Errorf("audit log line %d: sequence %d out of order", line, e.Seq)
+		}
+		if e.PrevHash != prev {
+			return i, fmt.Errorf("audit log line %d: previous hash does not match line %d", line, i)
+		}
+		if hashBody(body) != hash {
+			return i, fmt.Errorf("audit log line %d: hash mismatch (entry was modified)", line)
+		}
+		prev = hash
+	}
+	if want.Entries > 0 {
+		if len(lines) < want.Entries {
+			return len(lines), fmt.Errorf("audit log has %d entries but the session recorded %d (entries were removed)", len(lines), want.Entries)
+		}
+		if len(lines) > want.Entries {
+			return want.Entries, fmt.Errorf("audit log line %d: entry was not recorded by the session (the session ends at line %d)", want.Entries+1, want.Entries)
+		}
+		if prev != want.Hash {
+			return len(lines) - 1, fmt.Errorf("audit log line %d: hash does not match the one recorded by the session", len(lines))
+		}
+	}
+	return len(lines), nil
+}
+
+// lastEntry returns the log's last entry, or nil when the log is empty or
+// missing.
+func lastEntry(dir string) (*Entry, error) {
+	lines, err := readLines(dir)
+	if err != nil || len(lines) == 0 {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(lines[len(lines)-1], &e); err != nil {
+		return nil, fmt.Errorf("audit log line %d: %w", len(lines), err)
+	}
+	return &e, nil
+}
+
+// readLines returns the raw lines of the audit log. A missing log has none.
+func readLines(dir string) ([][]byte, error) {
+	f, err := os.Open(Path(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		lines = append(lines, bytes.Clone(sc.Bytes()))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestAppendAndVerify(t *testing.T) {
+	dir := t.TempDir()
+
+	if n, err := Verify(dir, Head{}); err != nil || n != 0 {
+		t.Fatalf("Verify() on missing log = %d, %v; want 0, nil", n, err)
+	}
+	head, err := Append(dir, Head{}, []types.Event{{Action: "init"}, {Action: "spec_add"}})
+	if err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if head, err = Append(dir, head, []types.Event{{Action: "phase_next", From: "red", To: "green"}}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if head.Entries != 3 {
+		t.Errorf("Append() head has %d entries, want 3", head.Entries)
+	}
+
+	n, err := Verify(dir, head)
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Verify() = %d entries, want 3", n)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		want   string
+	}{
+		{
+			"modified event",
+			func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"spec_add"`, `"spec_done"`, 1)
+				return lines
+			},
+			"line 2: hash mismatch",
+		},
+		{
+			"deleted line",
+			func(lines []string) []string { return append(lines[:1], lines[2:]...) },
+			"line 2: sequence 3 out of order",
+		},
+		{
+			"field added to event",
+			func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"action":"spec_add"`, `"action":"spec_add","note":"x"`, 1)
+				return lines
+			},
+			"line 2: hash mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := Append(dir, Head{}, []types.Event{{Action: "init"}, {Action: "spec_add"}, {Action: "spec_picked"}}); err != nil {
+				t.Fatalf("Append() error: %v", err)
+			}
+			data, _ := os.ReadFile(Path(dir))
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			if err := os.WriteFile(Path(dir), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+				t.Fatalf("failed to rewrite log: %v", err)
+			}
+
+			n, err := Verify(dir, Head{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Verify() error = %v, want it to contain %q", err, tt.want)
+			}
+			if n != 1 {
+				t.Errorf("Verify() valid entries = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestVerifyDetectsTruncation(t *testing.T) {
+	dir := t.TempDir()
+	head, err := Append(dir, Head{}, []types.Event{{Action: "init"}, {Action: "spec_add"}, {Action: "spec_picked"}})
+	if err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	data, _ := os.ReadFile(Path(dir))
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if err := os.WriteFile(Path(dir), []byte(strings.Join(lines[:2], "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite log: %v", err)
+	}
+
+	if _, err := Verify(dir, Head{}); err != nil {
+		t.Fatalf("a truncated chain is still internally valid, got %v", err)
+	}
+	n, err := Verify(dir, head)
+	if err == nil || !strings.Contains(err.Error(), "entries were removed") {
+		t.Errorf("Verify() error = %v, want truncation detected", err)
+	}
+	if n != 2 {
+		t.Errorf("Verify() valid entries = %d, want 2", n)
+	}
+
+	// Appending from the session's head leaves a gap instead of hiding the cut.
+	if _, err := Append(dir, head, []types.Event{{Action: "spec_done"}}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if _, err := Verify(dir, Head{}); err == nil || !strings.Contains(err.Error(), "line 3: sequence 4 out of order") {
+		t.Errorf("Verify() error = %v, want the gap reported", err)
+	}
+}
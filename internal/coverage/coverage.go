@@ -0,0 +1,317 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Gap is an untested area found in a coverage report. Function is empty when
+// the whole file is uncovered.
+type Gap struct {
+	File     string `json:"file"`
+	Function string `json:"function,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// SpecDescription turns a gap into a characterization-test spec description.
+func (g Gap) SpecDescription() string {
+	if g.Function == "" {
+		return fmt.Sprintf("Characterize current behavior of %s (no coverage)", g.File)
+	}
+	return fmt.Sprintf("Characterize current behavior of %s in %s", g.Function, g.File)
+}
+
+// Parse reads a coverage report and returns the uncovered files and functions.
+// Go cover profiles ("mode: ..." header) and LCOV tracefiles are supported.
+// srcDir is used to locate Go sources so block coverage can be mapped to functions.
+func Parse(data []byte, srcDir string) ([]Gap, error) {
+	text := string(data)
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "mode:"):
+		return parseGoProfile(text, srcDir)
+	case strings.HasPrefix(trimmed, "TN:") || strings.HasPrefix(trimmed, "SF:"):
+		return parseLCOV(text), nil
+	default:
+		return nil, fmt.Errorf("unrecognized coverage format (expected a Go cover profile or LCOV tracefile)")
+	}
+}
+
+// block is one statement block from a Go cover profile.
+type block struct {
+	startLine, endLine int
+	count              int
+}
+
+func parseGoProfile(text, srcDir string) ([]Gap, error) {
+	files := make(map[string][]block)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// file.go:startLine.startCol,endLine.endCol numStmts count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("malformed cover profile line: %q", line)
+		}
+		name := line[:colon]
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed cover profile line: %q", line)
+		}
+		start, end, ok := strings.Cut(fields[0], ",")
+		if !ok {
+			return nil, fmt.Errorf("malformed cover profile line: %q", line)
+		}
+		startLine, err1 := strconv.Atoi(strings.Split(start, ".")[0])
+		endLine, err2 := strconv.Atoi(strings.Split(end, ".")[0])
+		count, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("malformed cover profile line: %q", line)
+		}
+		files[name] = append(files[name], block{startLine: startLine, endLine: endLine, count: count})
+	}
+
+	module := modulePath(srcDir)
+	var gaps []Gap
+	for _, name := range sortedKeys(files) {
+		blocks := files[name]
+		file := name
+		if module != "" {
+			file = strings.TrimPrefix(file, module+"/")
+		}
+		if uncovered(blocks) {
+			gaps = append(gaps, Gap{File: file})
+			continue
+		}
+		funcs, err := goFuncs(filepath.Join(srcDir, filepath.FromSlash(file)))
+		if err != nil {
+			// Source not available: only whole-file gaps can be reported.
+			continue
+		}
+		for _, fn := range funcs {
+			var inside []block
+			for _, b := range blocks {
+				if b.startLine >= fn.start && b.endLine <= fn.end {
+					inside = append(inside, b)
+				}
+			}
+			if len(inside) > 0 && uncovered(inside) {
+				gaps = append(gaps, Gap{File: file, Function: fn.name, Line: fn.start})
+			}
+		}
+	}
+	return gaps, nil
+}
+
+// uncovered reports whether no block was executed.
+func uncovered(blocks []block) bool {
+	for _, b := range blocks {
+		if b.count > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type goFunc struct {
+	name       string
+	start, end int
+}
+
+// goFuncs lists the function and method declarations in a Go source file.
+func goFuncs(path string) ([]goFunc, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	var funcs []goFunc
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+		name := fd.Name.Name
+		if fd.Recv != nil && len(fd.Recv.List) > 0 {
+			name = receiverName(fd.Recv.List[0].Type) + "." + name
+		}
+		funcs = append(funcs, goFunc{
+			name:  name,
+			start: fset.Position(fd.Pos()).Line,
+			end:   fset.Position(fd.End()).Line,
+		})
+	}
+	return funcs, nil
+}
+
+// receiverName returns the type name of a method receiver, without pointer or type parameters.
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
+
+// modulePath reads the module path from srcDir/go.mod, or "" if unavailable.
+func modulePath(srcDir string) string {
+	data, err := os.ReadFile(filepath.Join(srcDir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+func parseLCOV(text string) []Gap {
+	var gaps []Gap
+	var file string
+	var linesHit, linesFound int
+	fnLines := make(map[string]int)
+	fnHits := make(map[string]int)
+	var fnOrder []string
+
+	flush := func() {
+		if file == "" {
+			return
+		}
+		if linesFound > 0 && linesHit == 0 {
+			gaps = append(gaps, Gap{File: file})
+		} else {
+			for _, name := range fnOrder {
+				if fnHits[name] == 0 {
+					gaps = append(gaps, Gap{File: file, Function: name, Line: fnLines[name]})
+				}
+			}
+		}
+		file, linesHit, linesFound = "", 0, 0
+		fnLines = make(map[string]int)
+		fnHits = make(map[string]int)
+		fnOrder = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "SF":
+			file = value
+		case "FN":
+			lineNo, name, ok := strings.Cut(value, ",")
+			if ok {
+				n, _ := strconv.Atoi(lineNo)
+				if _, seen := fnLines[name]; !seen {
+					fnOrder = append(fnOrder, name)
+				}
+				fnLines[name] = n
+			}
+		case "FNDA":
+			hits, name, ok := strings.Cut(value, ",")
+			if ok {
+				n, _ := strconv.Atoi(hits)
+				fnHits[name] += n
+			}
+		case "DA":
+			parts := strings.Split(value, ",")
+			if len(parts) >= 2 {
+				linesFound++
+				if n, _ := strconv.Atoi(parts[1]); n > 0 {
+					linesHit++
+				}
+			}
+		case "end_of_record":
+			flush()
+		}
+	}
+	flush()
+	return gaps
+}
+
+func sortedKeys(m map[string][]block) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Percent returns the statement coverage of a Go cover profile, or the line
+// coverage of an LCOV tracefile, as a percentage. Blocks repeated across
+// packages in a Go profile count once, as covered if any run executed them.
+func Percent(data []byte) (float64, error) {
+	text := string(data)
+	trimmed := strings.TrimSpace(text)
+	var covered, total int
+	switch {
+	case strings.HasPrefix(trimmed, "mode:"):
+		stmts := make(map[string]int)
+		hit := make(map[string]bool)
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "mode:") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				return 0, fmt.Errorf("malformed cover profile line: %q", line)
+			}
+			n, err1 := strconv.Atoi(fields[1])
+			count, err2 := strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("malformed cover profile line: %q", line)
+			}
+			stmts[fields[0]] = n
+			hit[fields[0]] = hit[fields[0]] || count > 0
+		}
+		for block, n := range stmts {
+			total += n
+			if hit[block] {
+				covered += n
+			}
+		}
+	case strings.HasPrefix(trimmed, "TN:") || strings.HasPrefix(trimmed, "SF:"):
+		for _, line := range strings.Split(text, "\n") {
+			value, ok := strings.CutPrefix(strings.TrimSpace(line), "DA:")
+			if !ok {
+				continue
+			}
+			parts := strings.Split(value, ",")
+			if len(parts) < 2 {
+				continue
+			}
+			total++
+			if n, _ := strconv.Atoi(parts[1]); n > 0 {
+				covered++
+			}
+		}
+	default:
+		return 0, fmt.Errorf("unrecognized coverage format (expected a Go cover profile or LCOV tracefile)")
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return 100 * float64(covered) / float64(total), nil
+}
@@ -0,0 +1,135 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const calcSource = `package calc
+
+func Add(a, b int) int {
+	return a + b
+}
+
+type Calc struct{}
+
+func (c *Calc) Div(a, b int) int {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+`
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/calc\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "calc.go"), []byte(calcSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestParseGoProfile(t *testing.T) {
+	dir := writeModule(t)
+	profile := `mode: set
+example.com/calc/calc.go:3.24,5.2 1 1
+example.com/calc/calc.go:9.34,10.12 1 0
+example.com/calc/calc.go:10.12,12.3 1 0
+example.com/calc/calc.go:13.2,13.14 1 0
+example.com/calc/other.go:3.10,5.2 2 0
+`
+	gaps, err := Parse([]byte(profile), dir)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := []Gap{
+		{File: "calc.go", Function: "Calc.Div", Line: 9},
+		{File: "other.go"},
+	}
+	if !reflect.DeepEqual(gaps, want) {
+		t.Errorf("Parse() = %+v, want %+v", gaps, want)
+	}
+}
+
+func TestParseLCOV(t *testing.T) {
+	lcov := `TN:
+SF:src/math.js
+FN:1,add
+FN:5,sub
+FNDA:3,add
+FNDA:0,sub
+DA:1,3
+DA:5,0
+end_of_record
+SF:src/unused.js
+FN:1,noop
+FNDA:0,noop
+DA:1,0
+DA:2,0
+end_of_record
+`
+	gaps, err := Parse([]byte(lcov), "")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := []Gap{
+		{File: "src/math.js", Function: "sub", Line: 5},
+		{File: "src/unused.js"},
+	}
+	if !reflect.DeepEqual(gaps, want) {
+		t.Errorf("Parse() = %+v, want %+v", gaps, want)
+	}
+}
+
+func TestParseRejectsUnknownFormat(t *testing.T) {
+	if _, err := Parse([]byte("<coverage/>"), ""); err == nil {
+		t.Error("Parse() should reject unknown formats")
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name   string
+		report string
+		want   float64
+	}{
+		{"go profile", "mode: set\nm/a.go:1.1,2.2 3 1\nm/a.go:3.1,4.2 1 0\n", 75},
+		{"go profile repeated blocks", "mode: set\nm/a.go:1.1,2.2 2 0\nm/a.go:1.1,2.2 2 1\nm/a.go:3.1,4.2 2 0\n", 50},
+		{"lcov", "TN:\nSF:a.js\nDA:1,1\nDA:2,0\nDA:3,4\nDA:4,0\nend_of_record\n", 50},
+	}
+	for _, tt := range tests {
+		got, err := Percent([]byte(tt.report))
+		if err != nil {
+			t.Fatalf("%s: Percent() error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Percent() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, err := Percent([]byte("<coverage/>")); err == nil {
+		t.Error("Percent() should reject unknown formats")
+	}
+}
+
+func TestGapSpecDescription(t *testing.T) {
+	tests := []struct {
+		gap  Gap
+		want string
+	}{
+		{Gap{File: "a.go", Function: "Add"}, "Characterize current behavior of Add in a.go"},
+		{Gap{File: "b.go"}, "Characterize current behavior of b.go (no coverage)"},
+	}
+	for _, tt := range tests {
+		if got := tt.gap.SpecDescription(); got != tt.want {
+			t.Errorf("SpecDescription() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package diffguard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/macosta/tdd-ai/internal/protect"
+)
+
+// DefaultGlobs match test files in the languages tdd-ai commonly drives, used
+// when the session configures no test globs of its own.
+var DefaultGlobs = []string{
+	"**/*_test.go",
+	"**/test_*.py",
+	"**/*_test.py",
+	"**/*.test.*",
+	"**/*.spec.*",
+	"**/*_spec.rb",
+	"**/*Test.java",
+	"**/*Tests.cs",
+}
+
+// skipDirs are never searched for test files.
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Snapshot hashes every file under dir matching any of the globs, keyed by
+// slash-separated path relative to dir.
+func Snapshot(dir string, globs []string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchesAny(globs, rel) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hashes[rel] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hashing test files: %w", err)
+	}
+	return hashes, nil
+}
+
+func matchesAny(globs []string, name string) bool {
+	for _, g := range globs {
+		if protect.Match(g, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed returns the files edited, added, or removed between two snapshots,
+// sorted by path.
+func Changed(before, after map[string]string) []string {
+	var changed []string
+	for path, sum := range before {
+		if after[path] != sum {
+			changed = append(changed, path)
+		}
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Split separates files into test files, matching any of the globs, and the
+// rest, keeping their order.
+func Split(globs, files []string) (tests, impl []string) {
+	for _, f := range files {
+		if matchesAny(globs, filepath.ToSlash(f)) {
+			tests = append(tests, f)
+		} else {
+			impl = append(impl, f)
+		}
+	}
+	return tests, impl
+}
+
+// Globs returns the configured test globs, or DefaultGlobs when none are set.
+func Globs(configured []string) []string {
+	if len(configured) == 0 {
+		return DefaultGlobs
+	}
+	return configured
+}
@@ -0,0 +1,57 @@
+package diffguard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func TestSnapshotAndChanged(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "calc.go", "package calc")
+	writeFile(t, dir, "calc_test.go", "package calc // v1")
+	writeFile(t, dir, "pkg/util_test.go", "package pkg")
+	writeFile(t, dir, "vendor/dep/dep_test.go", "package dep")
+
+	before, err := Snapshot(dir, DefaultGlobs)
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	if len(before) != 2 || before["calc_test.go"] == "" || before["pkg/util_test.go"] == "" {
+		t.Fatalf("Snapshot() = %v, want the two test files outside vendor", before)
+	}
+
+	writeFile(t, dir, "calc.go", "package calc // implemented")
+	writeFile(t, dir, "calc_test.go", "package calc // v2")
+	writeFile(t, dir, "new_test.go", "package calc")
+	if err := os.Remove(filepath.Join(dir, "pkg/util_test.go")); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+
+	after, _ := Snapshot(dir, DefaultGlobs)
+	want := []string{"calc_test.go", "new_test.go", "pkg/util_test.go"}
+	if got := Changed(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changed() = %v, want %v", got, want)
+	}
+}
+
+func TestGlobs(t *testing.T) {
+	if got := Globs(nil); !reflect.DeepEqual(got, DefaultGlobs) {
+		t.Errorf("Globs(nil) = %v, want the defaults", got)
+	}
+	if got := Globs([]string{"spec/**"}); !reflect.DeepEqual(got, []string{"spec/**"}) {
+		t.Errorf("Globs() = %v, want the configured globs", got)
+	}
+}
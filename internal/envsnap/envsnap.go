@@ -0,0 +1,95 @@
+package envsnap
+
+import (
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// probes are the toolchains whose versions are always recorded, with the
+// command that prints each version.
+var probes = map[string][]string{
+	"go":     {"go", "version"},
+	"node":   {"node", "--version"},
+	"python": {"python3", "--version"},
+}
+
+// version runs a version command and returns the first line of its output, or
+// "" when the tool is missing or fails. Replaced in tests.
+var version = func(name string, args ...string) string {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line)
+}
+
+// Capture records the OS, architecture, and the versions of the common
+// toolchains found on PATH. The first word of testCmd is probed with
+// --version as well, to catch test runner upgrades. Tools that are not
+// installed are left out.
+func Capture(testCmd string) *types.Environment {
+	env := &types.Environment{
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Tools:      make(map[string]string),
+		CapturedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	for tool, cmd := range probes {
+		if v := version(cmd[0], cmd[1:]...); v != "" {
+			env.Tools[tool] = v
+		}
+	}
+	if fields := strings.Fields(testCmd); len(fields) > 0 && !probed(fields[0]) {
+		if v := version(fields[0], "--version"); v != "" {
+			env.Tools[fields[0]] = v
+		}
+	}
+	return env
+}
+
+func probed(bin string) bool {
+	for _, cmd := range probes {
+		if cmd[0] == bin {
+			return true
+		}
+	}
+	return false
+}
+
+// Change is one difference between a recorded and the current environment.
+// Empty Was or Now means the item was absent.
+type Change struct {
+	Item string `json:"item"`
+	Was  string `json:"was"`
+	Now  string `json:"now"`
+}
+
+// Diff returns the differences from snapshot to current, ordered by item.
+func Diff(snapshot, current *types.Environment) []Change {
+	var changes []Change
+	if snapshot.OS != current.OS {
+		changes = append(changes, Change{Item: "os", Was: snapshot.OS, Now: current.OS})
+	}
+	if snapshot.Arch != current.Arch {
+		changes = append(changes, Change{Item: "arch", Was: snapshot.Arch, Now: current.Arch})
+	}
+	var tools []Change
+	for tool, was := range snapshot.Tools {
+		if now := current.Tools[tool]; now != was {
+			tools = append(tools, Change{Item: tool, Was: was, Now: now})
+		}
+	}
+	for tool, now := range current.Tools {
+		if _, ok := snapshot.Tools[tool]; !ok {
+			tools = append(tools, Change{Item: tool, Now: now})
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Item < tools[j].Item })
+	return append(changes, tools...)
+}
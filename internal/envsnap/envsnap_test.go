@@ -0,0 +1,51 @@
+package envsnap
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestCapture(t *testing.T) {
+	orig := version
+	defer func() { version = orig }()
+	version = func(name string, _ ...string) string {
+		switch name {
+		case "go":
+			return "go version go1.25.7 linux/amd64"
+		case "pytest":
+			return "pytest 8.2.0"
+		}
+		return ""
+	}
+
+	env := Capture("pytest -q")
+
+	if env.OS != runtime.GOOS || env.Arch != runtime.GOARCH {
+		t.Errorf("OS/Arch = %s/%s, want %s/%s", env.OS, env.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	want := map[string]string{"go": "go version go1.25.7 linux/amd64", "pytest": "pytest 8.2.0"}
+	if !reflect.DeepEqual(env.Tools, want) {
+		t.Errorf("Tools = %v, want %v", env.Tools, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	snapshot := &types.Environment{OS: "linux", Arch: "amd64", Tools: map[string]string{"go": "go1.24", "node": "v20"}}
+	current := &types.Environment{OS: "linux", Arch: "arm64", Tools: map[string]string{"go": "go1.25", "python": "3.12"}}
+
+	want := []Change{
+		{Item: "arch", Was: "amd64", Now: "arm64"},
+		{Item: "go", Was: "go1.24", Now: "go1.25"},
+		{Item: "node", Was: "v20"},
+		{Item: "python", Now: "3.12"},
+	}
+	if got := Diff(snapshot, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if got := Diff(snapshot, snapshot); len(got) != 0 {
+		t.Errorf("Diff() of identical environments = %+v, want none", got)
+	}
+}
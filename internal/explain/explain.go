@@ -0,0 +1,120 @@
+package explain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Concept is a short teaching snippet about one part of the workflow.
+type Concept struct {
+	Name    string   `json:"name"`
+	Title   string   `json:"title"`
+	Summary string   `json:"summary"`
+	Points  []string `json:"points"`
+	SeeAlso []string `json:"see_also,omitempty"`
+}
+
+var concepts = map[string]Concept{
+	"red": {
+		Name:    "red",
+		Title:   "RED phase: write a failing test",
+		Summary: "Pick one spec and write the smallest test that expresses it. The test must fail before any implementation exists.",
+		Points: []string{
+			"Pick a spec first with 'tdd-ai spec pick <id>'; leaving RED without one is blocked.",
+			"Write tests only. Do not touch implementation code beyond stubs needed to compile.",
+			"Run the tests and confirm they fail on an assertion, not a compile or environment error.",
+			"Advance with 'tdd-ai phase next' once a failing result is recorded.",
+		},
+		SeeAlso: []string{"green", "specs", "blockers"},
+	},
+	"green": {
+		Name:    "green",
+		Title:   "GREEN phase: make the test pass",
+		Summary: "Write the minimum implementation that makes the failing test pass, and nothing more.",
+		Points: []string{
+			"Do not change the tests written in RED to make them pass.",
+			"Prefer the simplest code that works; design improvements belong in REFACTOR.",
+			"Run the tests and confirm they pass before 'tdd-ai phase next'.",
+		},
+		SeeAlso: []string{"red", "refactor"},
+	},
+	"refactor": {
+		Name:    "refactor",
+		Title:   "REFACTOR phase: improve the design with tests green",
+		Summary: "Clean up test and implementation code without changing behavior, then answer the reflection questions.",
+		Points: []string{
+			"Keep tests passing after every change; a failing run blocks advancement.",
+			"Remove duplication and improve names in both tests and implementation.",
+			"Answer every reflection question before leaving the phase.",
+			"Leaving REFACTOR completes the spec and returns to RED if specs remain.",
+		},
+		SeeAlso: []string{"reflections", "green"},
+	},
+	"reflections": {
+		Name:    "reflections",
+		Title:   "Reflection questions",
+		Summary: "Seven questions asked in REFACTOR that make the agent review test quality and design before moving on.",
+		Points: []string{
+			"List them with 'tdd-ai refactor status'.",
+			"Answer with 'tdd-ai refactor reflect <n> --answer \"...\"'; answers need at least 5 words.",
+			"Unanswered questions are a blocker for leaving REFACTOR.",
+			"The last question asks whether new specs should be added; add them with 'tdd-ai spec add'.",
+		},
+		SeeAlso: []string{"refactor", "blockers"},
+	},
+	"blockers": {
+		Name:    "blockers",
+		Title:   "Blockers",
+		Summary: "Conditions that stop 'tdd-ai phase next' from advancing. They are the CLI's guardrails, not errors to work around.",
+		Points: []string{
+			"Run 'tdd-ai blockers' to see what is preventing advancement in the current phase.",
+			"Typical blockers: no spec picked, a test result that does not match the phase, unanswered reflections.",
+			"Resolve each blocker by doing the TDD work it describes, then run 'tdd-ai phase next' again.",
+			"Repeatedly retrying without resolving blockers is detected as a loop.",
+		},
+		SeeAlso: []string{"red", "reflections"},
+	},
+	"retrofit": {
+		Name:    "retrofit",
+		Title:   "Retrofit mode: tests for existing code",
+		Summary: "For code that already exists, RED expects tests to pass because they characterize current behavior. GREEN is skipped.",
+		Points: []string{
+			"Start with 'tdd-ai init --retrofit'.",
+			"Write tests that pin down what the code does today; do not change the implementation.",
+			"The cycle is RED (tests pass) then REFACTOR.",
+			"Use 'tdd-ai retrofit gaps --coverage-file <file>' to find untested code to cover.",
+		},
+		SeeAlso: []string{"red", "refactor"},
+	},
+	"specs": {
+		Name:    "specs",
+		Title:   "Specs: the test list",
+		Summary: "Specs are the behaviors still to be test-driven. Each RED-GREEN-REFACTOR pass works on one picked spec.",
+		Points: []string{
+			"Add them with 'tdd-ai spec add \"description\"'; one observable behavior per spec.",
+			"Pick the next one in RED with 'tdd-ai spec pick <id>'.",
+			"Split a spec that turns out too large with 'tdd-ai spec split'.",
+		},
+		SeeAlso: []string{"red"},
+	},
+}
+
+// Names returns every concept name in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(concepts))
+	for name := range concepts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the named concept. Names are case-insensitive.
+func Lookup(name string) (Concept, error) {
+	c, ok := concepts[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Concept{}, fmt.Errorf("unknown concept %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return c, nil
+}
@@ -0,0 +1,34 @@
+package explain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	c, err := Lookup("RED")
+	if err != nil {
+		t.Fatalf("Lookup(RED) error: %v", err)
+	}
+	if c.Name != "red" || len(c.Points) == 0 {
+		t.Errorf("Lookup(RED) = %+v", c)
+	}
+
+	if _, err := Lookup("purple"); err == nil || !strings.Contains(err.Error(), "available: blockers") {
+		t.Errorf("Lookup(purple) error = %v, want it to list available concepts", err)
+	}
+}
+
+func TestConceptsAreComplete(t *testing.T) {
+	for _, name := range Names() {
+		c, _ := Lookup(name)
+		if c.Name != name || c.Title == "" || c.Summary == "" || len(c.Points) == 0 {
+			t.Errorf("concept %q is incomplete: %+v", name, c)
+		}
+		for _, ref := range c.SeeAlso {
+			if _, err := Lookup(ref); err != nil {
+				t.Errorf("concept %q refers to unknown concept %q", name, ref)
+			}
+		}
+	}
+}
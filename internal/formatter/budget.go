@@ -0,0 +1,42 @@
+package formatter
+
+import "fmt"
+
+// Budget controls how much detail the resume checkpoint includes.
+type Budget string
+
+const (
+	BudgetMinimal Budget = "minimal"
+	BudgetNormal  Budget = "normal"
+	BudgetFull    Budget = "full"
+)
+
+// budgetLimits caps each section of the resume packet. Sections are trimmed in
+// a fixed order as the budget shrinks: recent events first, then test output
+// evidence, then blockers, then goal and spec details. The phase, working spec,
+// and next action are always kept.
+type budgetLimits struct {
+	Events      int
+	TestOutput  bool
+	Blockers    int // -1 keeps every blocker
+	SpecDetails bool
+	SpecList    bool
+}
+
+var budgets = map[Budget]budgetLimits{
+	BudgetMinimal: {Events: 0, TestOutput: false, Blockers: 1, SpecDetails: false},
+	BudgetNormal:  {Events: 5, TestOutput: true, Blockers: -1, SpecDetails: true},
+	BudgetFull:    {Events: 20, TestOutput: true, Blockers: -1, SpecDetails: true, SpecList: true},
+}
+
+// ParseBudget validates a budget level. An empty string selects BudgetNormal.
+func ParseBudget(v string) (Budget, error) {
+	if v == "" {
+		return BudgetNormal, nil
+	}
+	b := Budget(v)
+	if _, ok := budgets[b]; !ok {
+		return "", fmt.Errorf("unknown budget: %q (valid: minimal, normal, full)", v)
+	}
+	return b, nil
+}
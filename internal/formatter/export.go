@@ -0,0 +1,187 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/stats"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Tabular export formats for spreadsheets and BI tools.
+const (
+	FormatCSV Format = "csv"
+	FormatTSV Format = "tsv"
+)
+
+// specExportRow is one spec with derived timing columns.
+type specExportRow struct {
+	ID               int    `json:"id"`
+	Description      string `json:"description"`
+	Status           string `json:"status"`
+	CreatedAt        string `json:"created_at,omitempty"`
+	PickedAt         string `json:"picked_at,omitempty"`
+	CompletedAt      string `json:"completed_at,omitempty"`
+	UpdatedAt        string `json:"updated_at,omitempty"`
+	Stale            bool   `json:"stale,omitempty"`
+	CycleTimeSeconds *int64 `json:"cycle_time_seconds,omitempty"`
+}
+
+// ExportSpecs renders every spec with status, timestamps, and cycle time.
+// Cycle time runs from the first pick (or creation, if never picked) to completion.
+// Active specs left untouched past the staleness window are marked stale.
+func ExportSpecs(s *types.Session, f Format) (string, error) {
+	stale := make(map[int]bool)
+	for _, spec := range s.StaleSpecs(time.Now()) {
+		stale[spec.ID] = true
+	}
+	rows := make([]specExportRow, 0, len(s.Specs))
+	for _, spec := range sortSpecsByID(s.Specs) {
+		row := specExportRow{
+			ID:          spec.ID,
+			Description: spec.Description,
+			Status:      string(spec.Status),
+			CreatedAt:   spec.CreatedAt,
+			PickedAt:    firstPickedAt(s, spec.ID),
+			CompletedAt: spec.CompletedAt,
+			UpdatedAt:   spec.LastTouched(),
+			Stale:       stale[spec.ID],
+		}
+		start := row.PickedAt
+		if start == "" {
+			start = row.CreatedAt
+		}
+		row.CycleTimeSeconds = secondsBetween(start, row.CompletedAt)
+		rows = append(rows, row)
+	}
+
+	if f == FormatJSON {
+		return exportJSON(rows)
+	}
+
+	records := [][]string{{"id", "description", "status", "created_at", "picked_at", "completed_at", "cycle_time_seconds", "updated_at", "stale"}}
+	for _, r := range rows {
+		cycle := ""
+		if r.CycleTimeSeconds != nil {
+			cycle = strconv.FormatInt(*r.CycleTimeSeconds, 10)
+		}
+		records = append(records, []string{
+			strconv.Itoa(r.ID), r.Description, r.Status, r.CreatedAt, r.PickedAt, r.CompletedAt, cycle,
+			r.UpdatedAt, strconv.FormatBool(r.Stale),
+		})
+	}
+	return exportTable(records, f)
+}
+
+// ExportHistory renders the session event history, one row per event. jsonl
+// and otlp carry each full event with the state it happened in and derived
+// features such as phase durations and test retries.
+func ExportHistory(s *types.Session, f Format) (string, error) {
+	switch f {
+	case FormatJSON:
+		history := s.History
+		if history == nil {
+			history = []types.Event{}
+		}
+		return exportJSON(history)
+	case FormatJSONL:
+		return exportJSONL(HistoryRecords(s))
+	case FormatOTLP:
+		return exportOTLP(HistoryRecords(s))
+	}
+
+	records := [][]string{{"timestamp", "action", "from", "to", "result", "spec_id", "spec_count", "agent_id"}}
+	for _, ev := range s.History {
+		specID, specCount := "", ""
+		if ev.SpecID > 0 {
+			specID = strconv.Itoa(ev.SpecID)
+		}
+		if ev.SpecCount > 0 {
+			specCount = strconv.Itoa(ev.SpecCount)
+		}
+		records = append(records, []string{
+			ev.Timestamp, ev.Action, ev.From, ev.To, ev.Result, specID, specCount, ev.AgentID,
+		})
+	}
+	return exportTable(records, f)
+}
+
+// ExportStats renders fleet metrics, one row per project followed by a
+// "total" row with the fleet rollup.
+func ExportStats(fleet stats.Fleet, f Format) (string, error) {
+	if f == FormatJSON {
+		return exportJSON(fleet)
+	}
+
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	records := [][]string{{"path", "phase", "cycles_completed", "specs_total", "specs_completed", "avg_iterations_per_spec", "specs_verified", "violations", "violation_rate", "forced_overrides", "blocked_attempts", "last_activity"}}
+	for _, p := range fleet.Projects {
+		records = append(records, []string{
+			p.Path, p.Phase, strconv.Itoa(p.CyclesCompleted), strconv.Itoa(p.SpecsTotal), strconv.Itoa(p.SpecsCompleted),
+			num(p.AvgIterations), strconv.Itoa(p.SpecsVerified), strconv.Itoa(p.Violations), num(p.ViolationRate),
+			strconv.Itoa(p.ForcedOverrides), strconv.Itoa(p.BlockedAttempts), p.LastActivityTime,
+		})
+	}
+	records = append(records, []string{
+		"total", "", strconv.Itoa(fleet.CyclesCompleted), strconv.Itoa(fleet.SpecsTotal), strconv.Itoa(fleet.SpecsCompleted),
+		num(fleet.AvgIterations), strconv.Itoa(fleet.SpecsVerified), strconv.Itoa(fleet.Violations), num(fleet.ViolationRate),
+		strconv.Itoa(fleet.ForcedOverrides), strconv.Itoa(fleet.BlockedAttempts), "",
+	})
+	return exportTable(records, f)
+}
+
+func exportJSON(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding export: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+func exportTable(records [][]string, f Format) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	switch f {
+	case FormatCSV:
+	case FormatTSV:
+		w.Comma = '\t'
+	default:
+		return "", fmt.Errorf("unknown export format: %q (use csv, tsv, or json)", f)
+	}
+	if err := w.WriteAll(records); err != nil {
+		return "", fmt.Errorf("writing export: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// firstPickedAt returns the timestamp of the first spec_picked event covering the spec.
+func firstPickedAt(s *types.Session, specID int) string {
+	for _, ev := range s.History {
+		if ev.Action == "spec_picked" && ev.CoversSpec(specID) {
+			return ev.Timestamp
+		}
+	}
+	return ""
+}
+
+// secondsBetween returns the whole seconds from start to end, or nil if either is
+// missing or unparseable.
+func secondsBetween(start, end string) *int64 {
+	if start == "" || end == "" {
+		return nil
+	}
+	t0, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return nil
+	}
+	t1, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return nil
+	}
+	secs := int64(t1.Sub(t0).Seconds())
+	return &secs
+}
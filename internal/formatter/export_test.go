@@ -0,0 +1,183 @@
+package formatter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestExportSpecsCSV(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("first, with comma")
+	s.AddSpec("second")
+	s.Specs[0].CreatedAt = "2026-01-01T10:00:00Z"
+	s.Specs[0].Status = types.SpecStatusCompleted
+	s.Specs[0].CompletedAt = "2026-01-01T10:05:00Z"
+	s.Specs[0].UpdatedAt = "2026-01-01T10:05:00Z"
+	s.History = []types.Event{
+		{Action: "spec_picked", SpecID: 1, Timestamp: "2026-01-01T10:01:00Z"},
+	}
+
+	out, err := ExportSpecs(s, FormatCSV)
+	if err != nil {
+		t.Fatalf("ExportSpecs() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("ExportSpecs() produced %d lines, want 3:\n%s", len(lines), out)
+	}
+	if lines[0] != "id,description,status,created_at,picked_at,completed_at,cycle_time_seconds,updated_at,stale" {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	want := `1,"first, with comma",completed,2026-01-01T10:00:00Z,2026-01-01T10:01:00Z,2026-01-01T10:05:00Z,240,2026-01-01T10:05:00Z,false`
+	if lines[1] != want {
+		t.Errorf("row 1 = %s, want %s", lines[1], want)
+	}
+	if !strings.Contains(lines[2], ",active,"+s.Specs[1].CreatedAt+",,,,") {
+		t.Errorf("active spec should have empty completion columns, got %s", lines[2])
+	}
+}
+
+func TestExportSpecsMarksStaleSpecs(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("old")
+	s.AddSpec("fresh")
+	s.Specs[0].UpdatedAt = "2026-01-01T10:00:00Z"
+
+	out, err := ExportSpecs(s, FormatCSV)
+	if err != nil {
+		t.Fatalf("ExportSpecs() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if !strings.HasSuffix(lines[1], ",2026-01-01T10:00:00Z,true") {
+		t.Errorf("untouched active spec should be stale, got %s", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], ",false") {
+		t.Errorf("fresh spec should not be stale, got %s", lines[2])
+	}
+}
+
+func TestExportHistoryTSV(t *testing.T) {
+	s := types.NewSession()
+	s.History = []types.Event{
+		{Action: "phase_next", From: "red", To: "green", Result: "fail", Timestamp: "2026-01-01T10:00:00Z"},
+	}
+
+	out, err := ExportHistory(s, FormatTSV)
+	if err != nil {
+		t.Fatalf("ExportHistory() error: %v", err)
+	}
+	if !strings.Contains(out, "2026-01-01T10:00:00Z\tphase_next\tred\tgreen\tfail\t\t\t") {
+		t.Errorf("ExportHistory() TSV row not found:\n%s", out)
+	}
+}
+
+func TestExportRejectsUnknownFormat(t *testing.T) {
+	if _, err := ExportHistory(types.NewSession(), FormatText); err == nil {
+		t.Error("ExportHistory() should reject text format")
+	}
+}
+
+func TestExportHistoryJSONLDerivesPhaseFeatures(t *testing.T) {
+	s := types.NewSession()
+	s.History = []types.Event{
+		{Action: "init", Timestamp: "2026-01-01T10:00:00Z"},
+		{Action: "spec_picked", SpecID: 1, Timestamp: "2026-01-01T10:00:10Z"},
+		{Action: "test_run", Result: "pass", Timestamp: "2026-01-01T10:00:20Z"},
+		{Action: "phase_next_blocked", From: "red", To: "green", Timestamp: "2026-01-01T10:00:25Z"},
+		{Action: "test_run", Result: "fail", Timestamp: "2026-01-01T10:00:30Z"},
+		{Action: "phase_next", From: "red", To: "green", Timestamp: "2026-01-01T10:01:00Z"},
+		{Action: "phase_next", From: "green", To: "refactor", Timestamp: "2026-01-01T10:02:00Z"},
+		{Action: "phase_next", From: "refactor", To: "red", Timestamp: "2026-01-01T10:02:30Z"},
+	}
+
+	out, err := ExportHistory(s, FormatJSONL)
+	if err != nil {
+		t.Fatalf("ExportHistory() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != len(s.History) {
+		t.Fatalf("want one line per event, got %d:\n%s", len(lines), out)
+	}
+	var records []HistoryRecord
+	for _, line := range lines {
+		var r HistoryRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("line is not JSON: %v\n%s", err, line)
+		}
+		records = append(records, r)
+	}
+
+	if got := records[4].Features.TestAttempt; got != 2 {
+		t.Errorf("second test run should be attempt 2, got %d", got)
+	}
+	leaveRed := records[5]
+	if leaveRed.Phase != types.PhaseRed || leaveRed.SpecID != 1 {
+		t.Errorf("leaving red should happen in red on spec 1, got %s on %d", leaveRed.Phase, leaveRed.SpecID)
+	}
+	f := leaveRed.Features
+	if f.PhaseDurationSeconds == nil || *f.PhaseDurationSeconds != 60 {
+		t.Errorf("red should have lasted 60s, got %v", f.PhaseDurationSeconds)
+	}
+	if f.TestRuns != 2 || f.FailedTestRuns != 1 || f.Retries != 1 || f.BlockedAttempts != 1 {
+		t.Errorf("unexpected red features: %+v", f)
+	}
+	if records[6].Phase != types.PhaseGreen || records[6].Features.TestRuns != 0 {
+		t.Errorf("counters should reset on entering green, got %+v", records[6])
+	}
+	if records[7].Iteration != 0 || records[7].Event.To != "red" {
+		t.Errorf("leaving refactor should be recorded in iteration 0, got %d", records[7].Iteration)
+	}
+}
+
+func TestExportHistoryOTLP(t *testing.T) {
+	s := types.NewSession()
+	s.History = []types.Event{
+		{Action: "phase_next", From: "red", To: "green", Result: "fail", Timestamp: "2026-01-01T10:00:00Z"},
+	}
+
+	out, err := ExportHistory(s, FormatOTLP)
+	if err != nil {
+		t.Fatalf("ExportHistory() error: %v", err)
+	}
+	var req struct {
+		ResourceLogs []struct {
+			ScopeLogs []struct {
+				LogRecords []struct {
+					TimeUnixNano string `json:"timeUnixNano"`
+					EventName    string `json:"eventName"`
+					Body         struct {
+						StringValue string `json:"stringValue"`
+					} `json:"body"`
+					Attributes []struct {
+						Key string `json:"key"`
+					} `json:"attributes"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	if err := json.Unmarshal([]byte(out), &req); err != nil {
+		t.Fatalf("output is not OTLP/JSON: %v\n%s", err, out)
+	}
+	logs := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(logs) != 1 {
+		t.Fatalf("want 1 log record, got %d", len(logs))
+	}
+	if logs[0].TimeUnixNano != "1767261600000000000" || logs[0].EventName != "tdd.phase_next" {
+		t.Errorf("unexpected log record: %+v", logs[0])
+	}
+	if !strings.Contains(logs[0].Body.StringValue, `"result":"fail"`) {
+		t.Errorf("body should carry the full event, got %s", logs[0].Body.StringValue)
+	}
+	keys := make(map[string]bool)
+	for _, a := range logs[0].Attributes {
+		keys[a.Key] = true
+	}
+	for _, k := range []string{"tdd.action", "tdd.phase", "tdd.from", "tdd.to"} {
+		if !keys[k] {
+			t.Errorf("missing attribute %s in %v", k, keys)
+		}
+	}
+}
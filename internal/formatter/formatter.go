@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/macosta/tdd-ai/internal/loopdetect"
 	"github.com/macosta/tdd-ai/internal/phase"
 	"github.com/macosta/tdd-ai/internal/types"
 	"github.com/macosta/tdd-ai/internal/verify"
@@ -21,6 +24,163 @@ func sortSpecsByID(specs []types.Spec) []types.Spec {
 	return sorted
 }
 
+// specStatusLabel returns the short status shown in text spec listings.
+func specStatusLabel(spec types.Spec) string {
+	if spec.Risk == types.RiskHigh {
+		return specStatus(spec) + ", high risk"
+	}
+	return specStatus(spec)
+}
+
+func specStatus(spec types.Spec) string {
+	switch spec.Status {
+	case types.SpecStatusCompleted:
+		return "done"
+	case types.SpecStatusSuperseded:
+		return fmt.Sprintf("split into %s", joinIDs(spec.SplitInto))
+	default:
+		return "active"
+	}
+}
+
+// specRef renders a spec's numeric ID, followed by its slug when it has one.
+func specRef(spec types.Spec) string {
+	if spec.Slug == "" {
+		return fmt.Sprintf("[%d]", spec.ID)
+	}
+	return fmt.Sprintf("[%d] %s", spec.ID, spec.Slug)
+}
+
+// writeTestEvidence shows why the last test run did not pass: the failing test
+// names, hints for known failure patterns, and the tail of its output.
+func writeTestEvidence(b *strings.Builder, ev *types.TestEvidence) {
+	if len(ev.FailingTests) > 0 {
+		b.WriteString("Failing Tests:\n")
+		for _, name := range ev.FailingTests {
+			fmt.Fprintf(b, "  - %s\n", name)
+		}
+	}
+	if len(ev.TriageHints) > 0 {
+		b.WriteString("Triage Hints:\n")
+		for _, h := range ev.TriageHints {
+			fmt.Fprintf(b, "  - %s: %s\n", h.Pattern, h.Hint)
+		}
+	}
+	if len(ev.Output) > 0 {
+		fmt.Fprintf(b, "Last Test Output (last %d lines):\n", len(ev.Output))
+		for _, line := range ev.Output {
+			fmt.Fprintf(b, "  | %s\n", line)
+		}
+	}
+	b.WriteString("\n")
+}
+
+// elapsedInPhase returns the time spent in the current phase as a duration
+// string, or "" when the session predates phase entry tracking.
+func elapsedInPhase(s *types.Session) string {
+	if d, ok := s.ElapsedInPhase(time.Now()); ok {
+		return d.String()
+	}
+	return ""
+}
+
+// writeElapsedInPhase prints "You have been in GREEN for 23m" from a duration
+// string produced by elapsedInPhase.
+func writeElapsedInPhase(b *strings.Builder, p types.Phase, elapsed string) {
+	d, err := time.ParseDuration(elapsed)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(b, "You have been in %s for %s\n", strings.ToUpper(string(p)), humanDuration(d))
+}
+
+// humanDuration rounds a duration to the unit a person cares about: "<1m",
+// "23m", "2h5m", or "3d4h".
+func humanDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		h := int(d / time.Hour)
+		m := int((d % time.Hour) / time.Minute)
+		if m == 0 {
+			return fmt.Sprintf("%dh", h)
+		}
+		return fmt.Sprintf("%dh%dm", h, m)
+	default:
+		days := int(d / (24 * time.Hour))
+		h := int((d % (24 * time.Hour)) / time.Hour)
+		if h == 0 {
+			return fmt.Sprintf("%dd", days)
+		}
+		return fmt.Sprintf("%dd%dh", days, h)
+	}
+}
+
+// writeTestTrend renders recent test results as a one-line sparkline, oldest
+// first: ✓ pass, ✗ fail, ! infrastructure error.
+func writeTestTrend(b *strings.Builder, trend []types.TestPoint) {
+	b.WriteString("Test trend: ")
+	for _, p := range trend {
+		switch p.Result {
+		case "pass":
+			b.WriteString("✓")
+		case "fail":
+			b.WriteString("✗")
+		default:
+			b.WriteString("!")
+		}
+	}
+	fmt.Fprintf(b, " (last %d)\n", len(trend))
+}
+
+// writeStaleSpecs lists active specs nobody has touched within the staleness
+// window, with when each was last worked on.
+func writeStaleSpecs(b *strings.Builder, specs []types.Spec) {
+	b.WriteString("Stale Specs:\n")
+	for _, spec := range specs {
+		fmt.Fprintf(b, "  [%d] %s (last touched %s)\n", spec.ID, spec.Description, spec.LastTouched())
+	}
+	b.WriteString("\n")
+}
+
+// specIterationLabel describes how many passes a spec has taken, against the
+// per-spec limit when one is set.
+func specIterationLabel(spec types.Spec, max int) string {
+	switch {
+	case max > 0:
+		return fmt.Sprintf(" (iteration %d/%d)", spec.Iterations, max)
+	case spec.Iterations > 0:
+		return fmt.Sprintf(" (iteration %d)", spec.Iterations)
+	}
+	return ""
+}
+
+// joinIDs renders spec IDs as a comma-separated list.
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatGoalText renders the session goal with its done criteria as a checklist.
+func FormatGoalText(g *types.Goal) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Goal: %s\n", g.Description)
+	for _, c := range g.Criteria {
+		mark := " "
+		if c.Met {
+			mark = "x"
+		}
+		fmt.Fprintf(&b, "  [%s] %d. %s\n", mark, c.ID, c.Description)
+	}
+	return b.String()
+}
+
 // Format specifies the output format.
 type Format string
 
@@ -53,15 +213,32 @@ func formatText(g types.Guidance) string {
 	var b strings.Builder
 
 	fmt.Fprintf(&b, "Phase: %s\n", strings.ToUpper(g.Phase.String()))
+	if g.PreviewFrom != "" {
+		fmt.Fprintf(&b, "Preview: the session is still in %s; nothing was changed\n", strings.ToUpper(g.PreviewFrom.String()))
+	}
+	if g.ElapsedInPhase != "" {
+		writeElapsedInPhase(&b, g.Phase, g.ElapsedInPhase)
+	}
 	fmt.Fprintf(&b, "Mode: %s\n", g.Mode)
 	if g.NextPhase != "" {
 		fmt.Fprintf(&b, "Next Phase: %s\n", strings.ToUpper(g.NextPhase.String()))
 	}
+	if g.Goal != nil {
+		b.WriteString(FormatGoalText(g.Goal))
+	}
 	if g.TestCmd != "" {
 		fmt.Fprintf(&b, "Test Command: %s\n", g.TestCmd)
 	}
-	if g.CurrentSpec != nil {
-		fmt.Fprintf(&b, "Current Spec: [%d] %s\n", g.CurrentSpec.ID, g.CurrentSpec.Description)
+	if len(g.TestSuites) > 0 {
+		fmt.Fprintf(&b, "Test Suites: %s\n", strings.Join(g.TestSuites, ", "))
+	}
+	if len(g.PickGroup) > 0 {
+		b.WriteString("Current Specs (batch):\n")
+		for _, s := range g.PickGroup {
+			fmt.Fprintf(&b, "  [%d] %s\n", s.ID, s.Description)
+		}
+	} else if g.CurrentSpec != nil {
+		fmt.Fprintf(&b, "Current Spec: [%d] %s%s\n", g.CurrentSpec.ID, g.CurrentSpec.Description, specIterationLabel(*g.CurrentSpec, g.MaxIterationsPerSpec))
 	}
 	if g.Iteration > 0 {
 		fmt.Fprintf(&b, "Iteration: %d\n", g.Iteration)
@@ -87,6 +264,42 @@ func formatText(g types.Guidance) string {
 		b.WriteString("\n")
 	}
 
+	if len(g.StaleSpecs) > 0 {
+		writeStaleSpecs(&b, g.StaleSpecs)
+	}
+
+	if g.LoopDetected != nil {
+		fmt.Fprintf(&b, "LOOP DETECTED: %s\n\n", g.LoopDetected.Message)
+	}
+
+	if g.LastTestOutput != nil {
+		writeTestEvidence(&b, g.LastTestOutput)
+	}
+
+	if len(g.Rules) > 0 {
+		b.WriteString("Rules:\n")
+		for _, r := range g.Rules {
+			fmt.Fprintf(&b, "  - [%s] %s\n", r.ID, r.Text)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(g.Instructions) > 0 {
+		if g.FailureCategory != "" {
+			fmt.Fprintf(&b, "Instructions (%s failure):\n", g.FailureCategory)
+		} else {
+			b.WriteString("Instructions:\n")
+		}
+		for _, in := range g.Instructions {
+			fmt.Fprintf(&b, "  - %s\n", in)
+		}
+		b.WriteString("\n")
+	}
+
+	if g.MutationScore != nil {
+		fmt.Fprintf(&b, "Mutation Score: %.1f%%\n\n", *g.MutationScore)
+	}
+
 	if len(g.Reflections) > 0 {
 		answered := 0
 		for _, r := range g.Reflections {
@@ -96,14 +309,16 @@ func formatText(g types.Guidance) string {
 		}
 		fmt.Fprintf(&b, "Reflections (%d/%d answered):\n", answered, len(g.Reflections))
 		for _, r := range g.Reflections {
-			status := "pending"
-			if r.Answer != "" {
-				status = "answered"
-			}
-			fmt.Fprintf(&b, "  [%d] (%s) %s\n", r.ID, status, r.Question)
+			fmt.Fprintf(&b, "  [%d] (%s) %s\n", r.ID, r.Status(), r.Question)
 			if r.Answer != "" {
 				fmt.Fprintf(&b, "      -> %q\n", r.Answer)
 			}
+			if r.Skipped {
+				fmt.Fprintf(&b, "      skipped: %s\n", r.SkipReason)
+			}
+			for _, ev := range r.Evidence {
+				fmt.Fprintf(&b, "         see %s\n", ev)
+			}
 		}
 		b.WriteString("\n")
 	}
@@ -111,24 +326,40 @@ func formatText(g types.Guidance) string {
 	return b.String()
 }
 
-// FormatFullStatus renders a rich session overview.
-func FormatFullStatus(s *types.Session, f Format) (string, error) {
-	type fullStatusOutput struct {
-		Phase           types.Phase   `json:"phase"`
-		Mode            string        `json:"mode"`
-		TestCmd         string        `json:"test_cmd,omitempty"`
-		CurrentSpecID   *int          `json:"current_spec_id,omitempty"`
-		Iteration       int           `json:"iteration,omitempty"`
-		TotalSpecs      int           `json:"total_specs"`
-		ActiveSpecs     int           `json:"active_specs"`
-		DoneSpecs       int           `json:"done_specs"`
-		ComplianceScore *float64      `json:"compliance_score,omitempty"`
-		Specs           []types.Spec  `json:"specs"`
-		History         []types.Event `json:"history,omitempty"`
-	}
+// fullStatusOutput is the data rendered by FormatFullStatus.
+type fullStatusOutput struct {
+	Phase                types.Phase         `json:"phase"`
+	ElapsedInPhase       string              `json:"elapsed_in_phase,omitempty"`
+	EnteredPhaseAt       string              `json:"entered_phase_at,omitempty"`
+	LastTestAt           string              `json:"last_test_at,omitempty"`
+	SessionStartedAt     string              `json:"session_started_at,omitempty"`
+	Mode                 string              `json:"mode"`
+	TestCmd              string              `json:"test_cmd,omitempty"`
+	TestCmdSource        string              `json:"test_cmd_source,omitempty"`
+	CurrentSpecID        *int                `json:"current_spec_id,omitempty"`
+	CurrentSpec          *types.Spec         `json:"current_spec,omitempty"`
+	Iteration            int                 `json:"iteration,omitempty"`
+	MaxIterationsPerSpec int                 `json:"max_iterations_per_spec,omitempty"`
+	TotalSpecs           int                 `json:"total_specs"`
+	ActiveSpecs          int                 `json:"active_specs"`
+	DoneSpecs            int                 `json:"done_specs"`
+	ComplianceScore      *float64            `json:"compliance_score,omitempty"`
+	LastTestOutput       *types.TestEvidence `json:"last_test_output,omitempty"`
+	TestTrend            []types.TestPoint   `json:"test_trend,omitempty"`
+	LastActivity         string              `json:"last_activity,omitempty"`
+	Stalled              bool                `json:"stalled"`
+	OverridesCount       int                 `json:"overrides_count"`
+	Strictness           string              `json:"strictness"`
+	ReflectionDebt       int                 `json:"reflection_debt"`
+	Goal                 *types.Goal         `json:"goal,omitempty"`
+	StaleSpecs           []types.Spec        `json:"stale_specs,omitempty"`
+	Specs                []types.Spec        `json:"specs"`
+	History              []types.Event       `json:"history,omitempty"`
+}
 
+// buildFullStatus collects the session overview shown by status.
+func buildFullStatus(s *types.Session) fullStatusOutput {
 	active := s.ActiveSpecs()
-	mode := s.GetMode()
 	doneSpecs := len(s.Specs) - len(active)
 
 	// Compute compliance score if there are completed specs
@@ -138,19 +369,42 @@ func FormatFullStatus(s *types.Session, f Format) (string, error) {
 		complianceScore = &result.Score
 	}
 
-	out := fullStatusOutput{
-		Phase:           s.Phase,
-		Mode:            string(mode),
-		TestCmd:         s.TestCmd,
-		CurrentSpecID:   s.CurrentSpecID,
-		Iteration:       s.Iteration,
-		TotalSpecs:      len(s.Specs),
-		ActiveSpecs:     len(active),
-		DoneSpecs:       doneSpecs,
-		ComplianceScore: complianceScore,
-		Specs:           s.Specs,
-		History:         s.History,
+	return fullStatusOutput{
+		Phase:                s.Phase,
+		ElapsedInPhase:       elapsedInPhase(s),
+		EnteredPhaseAt:       s.PhaseEnteredAt,
+		LastTestAt:           s.LastTestAt(),
+		SessionStartedAt:     s.SessionStartedAt(),
+		Mode:                 string(s.GetMode()),
+		TestCmd:              s.TestCmd,
+		TestCmdSource:        s.TestCmdSource,
+		CurrentSpecID:        s.CurrentSpecID,
+		CurrentSpec:          s.CurrentSpec(),
+		Iteration:            s.Iteration,
+		MaxIterationsPerSpec: s.MaxIterationsPerSpec,
+		TotalSpecs:           len(s.Specs),
+		ActiveSpecs:          len(active),
+		DoneSpecs:            doneSpecs,
+		ComplianceScore:      complianceScore,
+		LastTestOutput:       s.LastTestOutput,
+		TestTrend:            s.TestTrend,
+		LastActivity:         s.LastActivity(),
+		Stalled:              s.Stalled(time.Now()),
+		OverridesCount:       s.OverridesCount(),
+		Strictness:           s.GetStrictness(),
+		ReflectionDebt:       len(s.ReflectionDebt),
+		Goal:                 s.Goal,
+		Specs:                s.Specs,
+		StaleSpecs:           s.StaleSpecs(time.Now()),
+		History:              s.History,
 	}
+}
+
+// FormatFullStatus renders a rich session overview.
+func FormatFullStatus(s *types.Session, f Format) (string, error) {
+	out := buildFullStatus(s)
+	mode := s.GetMode()
+	complianceScore := out.ComplianceScore
 
 	switch f {
 	case FormatJSON:
@@ -162,12 +416,22 @@ func FormatFullStatus(s *types.Session, f Format) (string, error) {
 	case FormatText:
 		var b strings.Builder
 		fmt.Fprintf(&b, "Phase: %s\n", strings.ToUpper(string(s.Phase)))
+		if out.ElapsedInPhase != "" {
+			writeElapsedInPhase(&b, s.Phase, out.ElapsedInPhase)
+		}
 		fmt.Fprintf(&b, "Mode: %s\n", mode)
 		if s.TestCmd != "" {
-			fmt.Fprintf(&b, "Test Command: %s\n", s.TestCmd)
+			if detected, ok := strings.CutPrefix(s.TestCmdSource, "detected:"); ok {
+				fmt.Fprintf(&b, "Test Command: %s (detected from %s)\n", s.TestCmd, detected)
+			} else {
+				fmt.Fprintf(&b, "Test Command: %s\n", s.TestCmd)
+			}
+		}
+		if s.Goal != nil {
+			b.WriteString(FormatGoalText(s.Goal))
 		}
 		if cs := s.CurrentSpec(); cs != nil {
-			fmt.Fprintf(&b, "Current Spec: [%d] %s\n", cs.ID, cs.Description)
+			fmt.Fprintf(&b, "Current Spec: [%d] %s%s\n", cs.ID, cs.Description, specIterationLabel(*cs, s.MaxIterationsPerSpec))
 		}
 		if s.Iteration > 0 {
 			fmt.Fprintf(&b, "Iteration: %d\n", s.Iteration)
@@ -176,17 +440,35 @@ func FormatFullStatus(s *types.Session, f Format) (string, error) {
 		if complianceScore != nil {
 			fmt.Fprintf(&b, "Compliance: %.0f%%\n", *complianceScore)
 		}
+		if len(out.TestTrend) > 0 {
+			writeTestTrend(&b, out.TestTrend)
+		}
+		if out.LastActivity != "" {
+			fmt.Fprintf(&b, "Last activity: %s\n", out.LastActivity)
+		}
+		if out.Stalled {
+			fmt.Fprintf(&b, "STALLED: no events or heartbeats for over %s\n", s.GetStallAfter())
+		}
+		if out.OverridesCount > 0 {
+			fmt.Fprintf(&b, "Overrides: %d manual phase change(s) bypassed guardrails\n", out.OverridesCount)
+		}
+		if out.ReflectionDebt > 0 {
+			fmt.Fprintf(&b, "Reflection debt: %d skipped question(s) (strictness: %s)\n", out.ReflectionDebt, out.Strictness)
+		}
 		b.WriteString("\n")
 		for _, spec := range sortSpecsByID(s.Specs) {
-			status := "active"
-			if spec.Status == types.SpecStatusCompleted {
-				status = "done"
-			}
-			fmt.Fprintf(&b, "  [%d] (%s) %s\n", spec.ID, status, spec.Description)
+			status := specStatusLabel(spec)
+			fmt.Fprintf(&b, "  %s (%s) %s\n", specRef(spec), status, spec.Description)
 		}
 		if len(s.Specs) > 0 {
 			b.WriteString("\n")
 		}
+		if len(out.StaleSpecs) > 0 {
+			writeStaleSpecs(&b, out.StaleSpecs)
+		}
+		if out.LastTestOutput != nil {
+			writeTestEvidence(&b, out.LastTestOutput)
+		}
 		if len(s.History) > 0 {
 			b.WriteString("History:\n")
 			for _, ev := range s.History {
@@ -200,6 +482,9 @@ func FormatFullStatus(s *types.Session, f Format) (string, error) {
 				if ev.SpecCount > 0 {
 					line += fmt.Sprintf(" (%d specs)", ev.SpecCount)
 				}
+				if (ev.To != "" || ev.Action == "complete") && len(ev.Files) > 0 {
+					line += fmt.Sprintf(" touched %s", describeFiles(s, ev.Files))
+				}
 				fmt.Fprintln(&b, line)
 			}
 			b.WriteString("\n")
@@ -210,35 +495,56 @@ func FormatFullStatus(s *types.Session, f Format) (string, error) {
 	}
 }
 
-// resumeNextAction returns the single most important next action for context recovery.
-func resumeNextAction(s *types.Session) string {
+// NextAction is the structured form of resume's next action: a command path as
+// typed after 'tdd-ai' (as in a 'tdd-ai batch' entry), its arguments, and why
+// it is the next step.
+type NextAction struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Reason  string   `json:"reason"`
+}
+
+// resumeNextAction returns the single most important next action for context
+// recovery, both as a shell string and in structured form.
+func resumeNextAction(s *types.Session) (string, NextAction) {
+	runTests := NextAction{Command: "test", Reason: "run the tests, then advance with 'tdd-ai phase next'"}
+	if expected := phase.ExpectedTestResultFor(s, s.Phase); expected != phase.ResultAny {
+		runTests.Reason += fmt.Sprintf("; the %s phase expects them to %s", s.Phase, expected)
+	}
 	if len(s.Specs) == 0 {
-		return `tdd-ai spec add "desc1" "desc2" ...`
+		return `tdd-ai spec add "desc1" "desc2" ...`,
+			NextAction{Command: "spec add", Reason: "the session has no specs; add spec descriptions as arguments"}
 	}
 	switch s.Phase {
 	case types.PhaseDone:
 		if len(s.ActiveSpecs()) > 0 {
-			return "tdd-ai spec done --all"
+			return "tdd-ai spec done --all --yes",
+				NextAction{Command: "spec done", Args: []string{"--all", "--yes"}, Reason: "the cycle is done but specs are still active"}
 		}
-		return `All specs complete. Add more specs: tdd-ai spec add "desc1" ...`
+		return `All specs complete. Add more specs: tdd-ai spec add "desc1" ...`,
+			NextAction{Command: "spec add", Reason: "all specs are complete; add more to start another cycle"}
 	case types.PhaseRed:
 		if s.CurrentSpecID == nil {
 			active := s.ActiveSpecs()
 			if len(active) > 0 {
-				return fmt.Sprintf("tdd-ai spec pick %d", active[0].ID)
+				id := strconv.Itoa(active[0].ID)
+				return "tdd-ai spec pick " + id,
+					NextAction{Command: "spec pick", Args: []string{id}, Reason: "no spec is selected; pick the next active spec before writing a test"}
 			}
 		}
-		return "tdd-ai test && tdd-ai phase next"
+		return "tdd-ai test && tdd-ai phase next", runTests
 	case types.PhaseGreen:
-		return "tdd-ai test && tdd-ai phase next"
+		return "tdd-ai test && tdd-ai phase next", runTests
 	case types.PhaseRefactor:
 		pending := s.PendingReflections()
 		if len(pending) > 0 {
-			return fmt.Sprintf(`tdd-ai refactor reflect %d --answer "your answer here"`, pending[0].ID)
+			id := strconv.Itoa(pending[0].ID)
+			return fmt.Sprintf(`tdd-ai refactor reflect %s --answer "your answer here"`, id),
+				NextAction{Command: "refactor reflect", Args: []string{id, "--answer"}, Reason: fmt.Sprintf("reflection question %s is unanswered; append the answer as the last argument", id)}
 		}
-		return "tdd-ai test && tdd-ai phase next"
+		return "tdd-ai test && tdd-ai phase next", runTests
 	}
-	return "tdd-ai guide"
+	return "tdd-ai guide", NextAction{Command: "guide", Reason: "show guidance for the current phase"}
 }
 
 // recentHistory returns the last n events from the session history.
@@ -249,39 +555,85 @@ func recentHistory(s *types.Session, n int) []types.Event {
 	return s.History[len(s.History)-n:]
 }
 
+// resumeOutput is the data rendered by FormatResume.
+type resumeOutput struct {
+	Budget           Budget              `json:"budget"`
+	Phase            types.Phase         `json:"phase"`
+	ElapsedInPhase   string              `json:"elapsed_in_phase,omitempty"`
+	Mode             types.Mode          `json:"mode"`
+	TestCmd          string              `json:"test_cmd,omitempty"`
+	Iteration        int                 `json:"iteration,omitempty"`
+	Goal             *types.Goal         `json:"goal,omitempty"`
+	CurrentSpec      *types.Spec         `json:"current_spec,omitempty"`
+	RemainingSpecs   int                 `json:"remaining_specs"`
+	Specs            []types.Spec        `json:"specs,omitempty"`
+	Blockers         []string            `json:"blockers,omitempty"`
+	OmittedBlockers  int                 `json:"omitted_blockers,omitempty"`
+	LastTestOutput   *types.TestEvidence `json:"last_test_output,omitempty"`
+	TestTrend        []types.TestPoint   `json:"test_trend,omitempty"`
+	LoopDetected     *types.Loop         `json:"loop_detected,omitempty"`
+	NextAction       string              `json:"next_action"`
+	NextActionDetail NextAction          `json:"next_action_detail"`
+	RecentEvents     []types.Event       `json:"recent_events,omitempty"`
+}
+
+// buildResume collects the compact checkpoint shown by resume, trimmed to the
+// given budget.
+func buildResume(s *types.Session, budget Budget) resumeOutput {
+	limits, ok := budgets[budget]
+	if !ok {
+		budget, limits = BudgetNormal, budgets[BudgetNormal]
+	}
+	nextAction, nextDetail := resumeNextAction(s)
+	out := resumeOutput{
+		Budget:           budget,
+		Phase:            s.Phase,
+		ElapsedInPhase:   elapsedInPhase(s),
+		Mode:             s.GetMode(),
+		TestCmd:          s.TestCmd,
+		Iteration:        s.Iteration,
+		Goal:             s.Goal,
+		CurrentSpec:      s.CurrentSpec(),
+		RemainingSpecs:   len(s.RemainingSpecs()),
+		Blockers:         phase.GetBlockers(s),
+		LoopDetected:     loopdetect.Detect(s.History),
+		LastTestOutput:   s.LastTestOutput,
+		TestTrend:        s.TestTrend,
+		NextAction:       nextAction,
+		NextActionDetail: nextDetail,
+		RecentEvents:     recentHistory(s, limits.Events),
+	}
+	if !limits.TestOutput {
+		out.LastTestOutput = nil
+		out.TestTrend = nil
+	}
+	if limits.Blockers >= 0 && len(out.Blockers) > limits.Blockers {
+		out.OmittedBlockers = len(out.Blockers) - limits.Blockers
+		out.Blockers = out.Blockers[:limits.Blockers]
+	}
+	if !limits.SpecDetails {
+		out.Goal = nil
+		out.TestCmd = ""
+	}
+	if limits.SpecList {
+		out.Specs = s.ActiveSpecs()
+	}
+	return out
+}
+
 // FormatResume renders a compact session checkpoint for agent context recovery.
 // Designed to be run after context compression or by a new sub-agent to quickly
 // re-orient to the current TDD session state without reading the full history.
 func FormatResume(s *types.Session, f Format) (string, error) {
-	type resumeOutput struct {
-		Phase          types.Phase   `json:"phase"`
-		Mode           types.Mode    `json:"mode"`
-		TestCmd        string        `json:"test_cmd,omitempty"`
-		Iteration      int           `json:"iteration,omitempty"`
-		CurrentSpec    *types.Spec   `json:"current_spec,omitempty"`
-		RemainingSpecs int           `json:"remaining_specs"`
-		Blockers       []string      `json:"blockers,omitempty"`
-		NextAction     string        `json:"next_action"`
-		RecentEvents   []types.Event `json:"recent_events,omitempty"`
-	}
-
-	remaining := len(s.RemainingSpecs())
-	blockers := phase.GetBlockers(s)
-	recent := recentHistory(s, 5)
+	return FormatResumeBudget(s, f, BudgetNormal)
+}
 
-	out := resumeOutput{
-		Phase:          s.Phase,
-		Mode:           s.GetMode(),
-		TestCmd:        s.TestCmd,
-		Iteration:      s.Iteration,
-		RemainingSpecs: remaining,
-		Blockers:       blockers,
-		NextAction:     resumeNextAction(s),
-		RecentEvents:   recent,
-	}
-	if cs := s.CurrentSpec(); cs != nil {
-		out.CurrentSpec = cs
-	}
+// FormatResumeBudget renders the resume checkpoint trimmed to the given budget.
+func FormatResumeBudget(s *types.Session, f Format, budget Budget) (string, error) {
+	out := buildResume(s, budget)
+	remaining := out.RemainingSpecs
+	blockers := out.Blockers
+	recent := out.RecentEvents
 
 	switch f {
 	case FormatJSON:
@@ -298,6 +650,12 @@ func FormatResume(s *types.Session, f Format) (string, error) {
 			fmt.Fprintf(&b, " | Iteration: %d", s.Iteration)
 		}
 		b.WriteString("\n")
+		if out.ElapsedInPhase != "" {
+			writeElapsedInPhase(&b, s.Phase, out.ElapsedInPhase)
+		}
+		if out.Goal != nil {
+			b.WriteString(FormatGoalText(out.Goal))
+		}
 		if cs := s.CurrentSpec(); cs != nil {
 			fmt.Fprintf(&b, "Working on: [%d] %s\n", cs.ID, cs.Description)
 		} else if s.Phase == types.PhaseRed && len(s.ActiveSpecs()) > 0 {
@@ -306,15 +664,30 @@ func FormatResume(s *types.Session, f Format) (string, error) {
 		if remaining > 0 {
 			fmt.Fprintf(&b, "Remaining specs: %d\n", remaining)
 		}
+		if len(out.TestTrend) > 0 {
+			writeTestTrend(&b, out.TestTrend)
+		}
+		for _, sp := range out.Specs {
+			fmt.Fprintf(&b, "  [%d] %s\n", sp.ID, sp.Description)
+		}
 		b.WriteString("\n")
 		if len(blockers) > 0 {
 			b.WriteString("BLOCKERS:\n")
 			for _, bl := range blockers {
 				fmt.Fprintf(&b, "  - %s\n", bl)
 			}
+			if out.OmittedBlockers > 0 {
+				fmt.Fprintf(&b, "  (%d more; run 'tdd-ai blockers')\n", out.OmittedBlockers)
+			}
 			b.WriteString("\n")
 		}
-		fmt.Fprintf(&b, "NEXT ACTION:\n  %s\n", out.NextAction)
+		if out.LastTestOutput != nil {
+			writeTestEvidence(&b, out.LastTestOutput)
+		}
+		if out.LoopDetected != nil {
+			fmt.Fprintf(&b, "INTERVENTION:\n  %s\n  %s\n\n", out.LoopDetected.Message, loopdetect.Intervention)
+		}
+		fmt.Fprintf(&b, "NEXT ACTION:\n  %s\n  (%s)\n", out.NextAction, out.NextActionDetail.Reason)
 		if len(recent) > 0 {
 			b.WriteString("\nRecent events:\n")
 			for _, ev := range recent {
@@ -365,21 +738,20 @@ func FormatStatus(s *types.Session, f Format) (string, error) {
 		fmt.Fprintf(&b, "Phase: %s\n", strings.ToUpper(string(s.Phase)))
 		fmt.Fprintf(&b, "Specs: %d total, %d active, %d done\n\n", out.TotalSpecs, out.ActiveSpecs, out.DoneSpecs)
 		for _, spec := range sortSpecsByID(s.Specs) {
-			status := "active"
-			if spec.Status == types.SpecStatusCompleted {
-				status = "done"
-			}
-			isCurrent := s.CurrentSpecID != nil && spec.ID == *s.CurrentSpecID
+			status := specStatusLabel(spec)
+			isCurrent := s.IsCurrentSpec(spec.ID)
 			if isCurrent {
-				fmt.Fprintf(&b, "→ [%d] (%s) %s (current)\n", spec.ID, status, spec.Description)
+				fmt.Fprintf(&b, "→ %s (%s) %s (current)\n", specRef(spec), status, spec.Description)
 			} else {
-				fmt.Fprintf(&b, "  [%d] (%s) %s\n", spec.ID, status, spec.Description)
+				fmt.Fprintf(&b, "  %s (%s) %s\n", specRef(spec), status, spec.Description)
 			}
 		}
 		if len(s.Specs) > 0 {
 			b.WriteString("\n")
 		}
 		return b.String(), nil
+	case FormatPorcelain:
+		return PorcelainSpecs(s), nil
 	default:
 		return "", fmt.Errorf("unknown format: %q", f)
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/macosta/tdd-ai/internal/types"
 )
@@ -408,6 +409,35 @@ func TestFormatFullStatusText(t *testing.T) {
 	}
 }
 
+func TestFormatFullStatusShowsTestTrend(t *testing.T) {
+	s := types.NewSession()
+	for _, r := range []string{"fail", "fail", "pass", "error", "pass"} {
+		s.RecordTestTrend(r)
+	}
+
+	out, err := FormatFullStatus(s, FormatText)
+	if err != nil {
+		t.Fatalf("FormatFullStatus() error: %v", err)
+	}
+	if !strings.Contains(out, "Test trend: ✗✗✓!✓ (last 5)") {
+		t.Errorf("should contain trend sparkline, got:\n%s", out)
+	}
+
+	out, err = FormatFullStatus(s, FormatJSON)
+	if err != nil {
+		t.Fatalf("FormatFullStatus() error: %v", err)
+	}
+	var parsed struct {
+		TestTrend []types.TestPoint `json:"test_trend"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(parsed.TestTrend) != 5 || parsed.TestTrend[0].Result != "fail" {
+		t.Errorf("test_trend = %+v, want 5 points starting with fail", parsed.TestTrend)
+	}
+}
+
 func TestFormatFullStatusJSON(t *testing.T) {
 	s := types.NewSession()
 	s.AddSpec("feature A")
@@ -429,6 +459,24 @@ func TestFormatFullStatusJSON(t *testing.T) {
 	}
 }
 
+func TestFormatFullStatusFlagsStaleSpecs(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("forgotten spec")
+	s.AddSpec("fresh spec")
+	s.Specs[0].UpdatedAt = "2026-01-01T10:00:00Z"
+
+	out, err := FormatFullStatus(s, FormatText)
+	if err != nil {
+		t.Fatalf("FormatFullStatus() error: %v", err)
+	}
+	if !strings.Contains(out, "Stale Specs:\n  [1] forgotten spec (last touched 2026-01-01T10:00:00Z)") {
+		t.Errorf("text output should flag the stale spec, got:\n%s", out)
+	}
+	if strings.Contains(out, "[2] fresh spec (last touched") {
+		t.Errorf("fresh spec should not be flagged, got:\n%s", out)
+	}
+}
+
 func TestFormatFullStatusShowsTestCmd(t *testing.T) {
 	s := types.NewSession()
 	s.TestCmd = "go test ./..."
@@ -606,6 +654,25 @@ func TestFormatGuidanceTextShowsCurrentSpec(t *testing.T) {
 	}
 }
 
+func TestFormatGuidanceTextShowsSpecIterationLimit(t *testing.T) {
+	g := types.Guidance{
+		Phase:                types.PhaseRed,
+		Mode:                 types.ModeGreenfield,
+		ExpectedTestResult:   "fail",
+		CurrentSpec:          &types.Spec{ID: 2, Description: "my current spec", Status: types.SpecStatusActive, Iterations: 1},
+		MaxIterationsPerSpec: 3,
+	}
+
+	out, err := FormatGuidance(g, FormatText)
+	if err != nil {
+		t.Fatalf("FormatGuidance() error: %v", err)
+	}
+
+	if !strings.Contains(out, "Current Spec: [2] my current spec (iteration 1/3)") {
+		t.Errorf("text output should show spec iterations against the limit, got:\n%s", out)
+	}
+}
+
 func TestFormatGuidanceJSONIncludesCurrentSpec(t *testing.T) {
 	g := types.Guidance{
 		Phase:              types.PhaseRed,
@@ -728,6 +795,31 @@ func TestFormatResumeJSONStructure(t *testing.T) {
 	}
 }
 
+func TestFormatResumeJSONNextActionDetail(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("feature A")
+	s.AddSpec("feature B")
+
+	out, err := FormatResume(s, FormatJSON)
+	if err != nil {
+		t.Fatalf("FormatResume() error: %v", err)
+	}
+	var parsed struct {
+		NextAction       string     `json:"next_action"`
+		NextActionDetail NextAction `json:"next_action_detail"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if parsed.NextAction != "tdd-ai spec pick 1" {
+		t.Errorf("next_action = %q, want the shell string kept", parsed.NextAction)
+	}
+	d := parsed.NextActionDetail
+	if d.Command != "spec pick" || len(d.Args) != 1 || d.Args[0] != "1" || d.Reason == "" {
+		t.Errorf("next_action_detail = %+v, want spec pick [1] with a reason", d)
+	}
+}
+
 func TestFormatResumeTextOutput(t *testing.T) {
 	s := types.NewSession()
 	s.AddSpec("user can login")
@@ -882,6 +974,154 @@ func TestFormatResumeTextShowsRecentEvents(t *testing.T) {
 	}
 }
 
+func TestFormatSubagentResumeScopesToSpec(t *testing.T) {
+	s := types.NewSession()
+	s.TestCmd = "go test ./..."
+	s.TestAreas = map[string]string{"services/api": "go test ./api/..."}
+	s.Rules = &types.PhaseRules{Green: []string{"never mock the repository layer"}}
+	s.TestNaming = &types.TestNamingRule{Mode: types.TestNamingWarn}
+	s.AddSpec("login returns 404")
+	s.AddSpec("logout clears the session")
+	_ = s.SetSpecArea(2, "services/api")
+	_ = s.SetCurrentSpec(1)
+	s.AddEvent("spec_picked", func(e *types.Event) { e.SpecID = 1 })
+
+	out, err := FormatSubagentResume(s, *s.SpecByID(2), FormatJSON)
+	if err != nil {
+		t.Fatalf("FormatSubagentResume() error: %v", err)
+	}
+	var got subagentOutput
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if got.Spec.ID != 2 || got.Current || got.Phase != "" || got.Blockers != nil {
+		t.Errorf("packet should describe spec 2 without the session's cycle, got %+v", got)
+	}
+	if got.TestCmd != "go test ./api/..." || got.TestArea != "services/api" {
+		t.Errorf("test command = %q in %q, want the spec's area command", got.TestCmd, got.TestArea)
+	}
+	if strings.Join(got.TestNames, ",") != "SPEC-logout-clears-session,spec2" {
+		t.Errorf("test names = %v", got.TestNames)
+	}
+	if rules := got.Rules[types.PhaseGreen]; len(rules) == 0 || rules[len(rules)-1].Text != "never mock the repository layer" {
+		t.Errorf("green rules should include project rules, got %v", rules)
+	}
+	if got.NextAction != "tdd-ai spec pick 2" {
+		t.Errorf("next action = %q, want spec pick 2", got.NextAction)
+	}
+	for _, leak := range []string{"login returns 404", "spec_picked", "remaining_specs"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("packet should say nothing about other specs or history, found %q", leak)
+		}
+	}
+
+	text, err := FormatSubagentResume(s, *s.SpecByID(1), FormatText)
+	if err != nil {
+		t.Fatalf("FormatSubagentResume() error: %v", err)
+	}
+	for _, want := range []string{"Spec [1] SPEC-login-404", "Phase: RED", "Test command: go test ./...", "GREEN rules:", "NEXT ACTION:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text packet for the current spec should contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "logout") {
+		t.Errorf("text packet should not mention other specs, got:\n%s", text)
+	}
+}
+
+func TestFormatResumeBudgetMinimalDropsDetail(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.Goal = &types.Goal{Description: "ship it"}
+	s.LastTestOutput = &types.TestEvidence{FailingTests: []string{"TestFeature"}}
+	s.AddEvent("phase_next", func(e *types.Event) {
+		e.From = "red"
+		e.To = "green"
+	})
+
+	out, err := FormatResumeBudget(s, FormatText, BudgetMinimal)
+	if err != nil {
+		t.Fatalf("FormatResumeBudget() error: %v", err)
+	}
+	for _, unwanted := range []string{"Recent events:", "TestFeature", "ship it"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("minimal budget should omit %q, got:\n%s", unwanted, out)
+		}
+	}
+	for _, want := range []string{"Working on: [1] feature", "NEXT ACTION:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("minimal budget should keep %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatResumeBudgetMinimalTruncatesBlockers(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseRed
+	s.AddSpec("a")
+	s.AddSpec("b")
+
+	out, err := FormatResumeBudget(s, FormatJSON, BudgetMinimal)
+	if err != nil {
+		t.Fatalf("FormatResumeBudget() error: %v", err)
+	}
+	var parsed struct {
+		Budget          string   `json:"budget"`
+		Blockers        []string `json:"blockers"`
+		OmittedBlockers int      `json:"omitted_blockers"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if parsed.Budget != "minimal" {
+		t.Errorf("budget = %q, want minimal", parsed.Budget)
+	}
+	if len(parsed.Blockers) > 1 {
+		t.Errorf("minimal budget should keep at most 1 blocker, got %v", parsed.Blockers)
+	}
+}
+
+func TestFormatResumeBudgetFullListsSpecsAndMoreEvents(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+	for i := 0; i < 8; i++ {
+		s.AddEvent("test_run")
+	}
+
+	out, err := FormatResumeBudget(s, FormatJSON, BudgetFull)
+	if err != nil {
+		t.Fatalf("FormatResumeBudget() error: %v", err)
+	}
+	var parsed struct {
+		Specs        []types.Spec  `json:"specs"`
+		RecentEvents []types.Event `json:"recent_events"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(parsed.Specs) != 2 {
+		t.Errorf("full budget should list 2 active specs, got %d", len(parsed.Specs))
+	}
+	if len(parsed.RecentEvents) <= 5 {
+		t.Errorf("full budget should include more than 5 events, got %d", len(parsed.RecentEvents))
+	}
+}
+
+func TestParseBudget(t *testing.T) {
+	if b, err := ParseBudget(""); err != nil || b != BudgetNormal {
+		t.Errorf("ParseBudget(\"\") = %q, %v; want normal", b, err)
+	}
+	if b, err := ParseBudget("full"); err != nil || b != BudgetFull {
+		t.Errorf("ParseBudget(full) = %q, %v", b, err)
+	}
+	if _, err := ParseBudget("huge"); err == nil {
+		t.Error("ParseBudget(huge) should fail")
+	}
+}
+
 func TestFormatResumeJSONIncludesBlockers(t *testing.T) {
 	s := types.NewSession()
 	s.AddSpec("feature A")
@@ -979,3 +1219,99 @@ func TestFormatStatusTextSortsByID(t *testing.T) {
 		t.Errorf("specs should be sorted by ID, got:\n%s", out)
 	}
 }
+
+func TestFormatResumeShowsLoopIntervention(t *testing.T) {
+	s := types.NewSession()
+	for _, to := range []string{"green", "red", "green", "red"} {
+		s.AddEvent("phase_set", func(e *types.Event) { e.To = to })
+	}
+
+	out, err := FormatResume(s, FormatText)
+	if err != nil {
+		t.Fatalf("FormatResume() error: %v", err)
+	}
+	if !strings.Contains(out, "INTERVENTION:") || !strings.Contains(out, "alternating between green and red") {
+		t.Errorf("text output should contain loop intervention, got:\n%s", out)
+	}
+
+	out, err = FormatResume(s, FormatJSON)
+	if err != nil {
+		t.Fatalf("FormatResume() error: %v", err)
+	}
+	if !strings.Contains(out, `"loop_detected"`) {
+		t.Errorf("JSON output should contain loop_detected, got:\n%s", out)
+	}
+}
+
+func TestFormatGuidanceTextShowsTestEvidence(t *testing.T) {
+	g := types.Guidance{
+		Phase: types.PhaseGreen,
+		Mode:  types.ModeGreenfield,
+		LastTestOutput: &types.TestEvidence{
+			FailingTests: []string{"TestAdd"},
+			Output:       []string{"--- FAIL: TestAdd (0.00s)", "FAIL"},
+		},
+	}
+
+	out, err := FormatGuidance(g, FormatText)
+	if err != nil {
+		t.Fatalf("FormatGuidance() error: %v", err)
+	}
+	if !strings.Contains(out, "Failing Tests:\n  - TestAdd") {
+		t.Errorf("text output should list failing tests, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Last Test Output (last 2 lines):\n  | --- FAIL: TestAdd (0.00s)\n  | FAIL") {
+		t.Errorf("text output should show the output tail, got:\n%s", out)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "<1m"},
+		{23 * time.Minute, "23m"},
+		{2*time.Hour + 5*time.Minute, "2h5m"},
+		{3 * time.Hour, "3h"},
+		{76 * time.Hour, "3d4h"},
+	}
+	for _, tt := range tests {
+		if got := humanDuration(tt.d); got != tt.want {
+			t.Errorf("humanDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatResumeShowsTimeInPhase(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.PhaseEnteredAt = time.Now().Add(-23 * time.Minute).UTC().Format(time.RFC3339)
+
+	out, err := FormatResume(s, FormatText)
+	if err != nil {
+		t.Fatalf("FormatResume() error: %v", err)
+	}
+	if !strings.Contains(out, "You have been in GREEN for 23m") {
+		t.Errorf("text output should show time in phase, got:\n%s", out)
+	}
+
+	data, err := FormatFullStatus(s, FormatJSON)
+	if err != nil {
+		t.Fatalf("FormatFullStatus() error: %v", err)
+	}
+	var parsed struct {
+		ElapsedInPhase   string `json:"elapsed_in_phase"`
+		EnteredPhaseAt   string `json:"entered_phase_at"`
+		SessionStartedAt string `json:"session_started_at"`
+	}
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if d, err := time.ParseDuration(parsed.ElapsedInPhase); err != nil || d < 23*time.Minute {
+		t.Errorf("elapsed_in_phase = %q, want a duration of at least 23m", parsed.ElapsedInPhase)
+	}
+	if parsed.EnteredPhaseAt != s.PhaseEnteredAt || parsed.SessionStartedAt != s.StartedAt {
+		t.Errorf("entered_phase_at = %q, session_started_at = %q, want the session's timestamps", parsed.EnteredPhaseAt, parsed.SessionStartedAt)
+	}
+}
@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatGHA emits GitHub Actions workflow commands so CI runs annotate pull
+// requests directly.
+const FormatGHA Format = "gha"
+
+// Annotation levels understood by GitHub Actions.
+const (
+	AnnotationError   = "error"
+	AnnotationWarning = "warning"
+	AnnotationNotice  = "notice"
+)
+
+// Annotation is a single GitHub Actions workflow command.
+type Annotation struct {
+	Level   string
+	Title   string
+	Message string
+}
+
+// FormatAnnotations renders annotations as GitHub Actions workflow commands,
+// one per line.
+func FormatAnnotations(annotations []Annotation) string {
+	var b strings.Builder
+	for _, a := range annotations {
+		if a.Title != "" {
+			fmt.Fprintf(&b, "::%s title=%s::%s\n", a.Level, escapeGHAProperty(a.Title), escapeGHAData(a.Message))
+		} else {
+			fmt.Fprintf(&b, "::%s::%s\n", a.Level, escapeGHAData(a.Message))
+		}
+	}
+	return b.String()
+}
+
+var (
+	ghaDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	ghaPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// escapeGHAData escapes a workflow command message.
+func escapeGHAData(s string) string {
+	return ghaDataEscaper.Replace(s)
+}
+
+// escapeGHAProperty escapes a workflow command property value.
+func escapeGHAProperty(s string) string {
+	return ghaPropertyEscaper.Replace(s)
+}
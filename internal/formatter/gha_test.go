@@ -0,0 +1,35 @@
+package formatter
+
+import "testing"
+
+func TestFormatAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []Annotation
+		want string
+	}{
+		{"empty", nil, ""},
+		{
+			"with title",
+			[]Annotation{{Level: AnnotationError, Title: "TDD blocker", Message: "No spec selected"}},
+			"::error title=TDD blocker::No spec selected\n",
+		},
+		{
+			"without title",
+			[]Annotation{{Level: AnnotationNotice, Message: "2 specs incomplete"}},
+			"::notice::2 specs incomplete\n",
+		},
+		{
+			"escapes message and title",
+			[]Annotation{{Level: AnnotationWarning, Title: "a:b,c", Message: "100%\nnext"}},
+			"::warning title=a%3Ab%2Cc::100%25%0Anext\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatAnnotations(tt.in); got != tt.want {
+				t.Errorf("FormatAnnotations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
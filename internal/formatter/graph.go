@@ -0,0 +1,78 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/phase"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Graph output formats for 'tdd-ai graph'.
+const (
+	FormatDOT     Format = "dot"
+	FormatMermaid Format = "mermaid"
+)
+
+// Step is one phase change the session actually took.
+type Step struct {
+	From   types.Phase `json:"from"`
+	To     types.Phase `json:"to"`
+	Action string      `json:"action"`
+	At     string      `json:"at"`
+}
+
+// Graph is the phase machine and, optionally, the session's trajectory
+// through it.
+type Graph struct {
+	Transitions []phase.Transition `json:"transitions"`
+	Trajectory  []Step             `json:"trajectory,omitempty"`
+}
+
+// Trajectory returns the phase changes recorded in the session history, in
+// order.
+func Trajectory(history []types.Event) []Step {
+	var steps []Step
+	for _, e := range history {
+		if e.From == "" || e.To == "" {
+			continue
+		}
+		steps = append(steps, Step{From: types.Phase(e.From), To: types.Phase(e.To), Action: e.Action, At: e.Timestamp})
+	}
+	return steps
+}
+
+// GraphDOT renders the graph in Graphviz DOT. Machine transitions are solid
+// edges labeled with the expected test result; trajectory steps are dashed
+// edges labeled with their sequence number and timestamp.
+func GraphDOT(g Graph) string {
+	var b strings.Builder
+	b.WriteString("digraph tdd {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, t := range g.Transitions {
+		fmt.Fprintf(&b, "  %s -> %s [label=%q];\n", t.From, t.To, "expect "+t.Expect)
+	}
+	for i, s := range g.Trajectory {
+		fmt.Fprintf(&b, "  %s -> %s [style=dashed, label=%q];\n", s.From, s.To, stepLabel(i, s))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// GraphMermaid renders the graph as a Mermaid flowchart, with the same edge
+// conventions as GraphDOT.
+func GraphMermaid(g Graph) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, t := range g.Transitions {
+		fmt.Fprintf(&b, "  %s -->|expect %s| %s\n", t.From, t.Expect, t.To)
+	}
+	for i, s := range g.Trajectory {
+		fmt.Fprintf(&b, "  %s -.->|%s| %s\n", s.From, stepLabel(i, s), s.To)
+	}
+	return b.String()
+}
+
+func stepLabel(i int, s Step) string {
+	return fmt.Sprintf("%d. %s %s", i+1, s.Action, s.At)
+}
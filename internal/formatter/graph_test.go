@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/phase"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func testGraph() Graph {
+	return Graph{
+		Transitions: []phase.Transition{
+			{From: types.PhaseRed, To: types.PhaseGreen, Expect: "fail"},
+		},
+		Trajectory: Trajectory([]types.Event{
+			{Action: "init", Timestamp: "2026-01-01T10:00:00Z"},
+			{Action: "phase_next", From: "red", To: "green", Timestamp: "2026-01-01T10:05:00Z"},
+		}),
+	}
+}
+
+func TestGraphDOT(t *testing.T) {
+	want := `digraph tdd {
+  rankdir=LR;
+  red -> green [label="expect fail"];
+  red -> green [style=dashed, label="1. phase_next 2026-01-01T10:05:00Z"];
+}
+`
+	if got := GraphDOT(testGraph()); got != want {
+		t.Errorf("GraphDOT() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGraphMermaid(t *testing.T) {
+	want := `flowchart LR
+  red -->|expect fail| green
+  red -.->|1. phase_next 2026-01-01T10:05:00Z| green
+`
+	if got := GraphMermaid(testGraph()); got != want {
+		t.Errorf("GraphMermaid() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,269 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Event-level export formats for log pipelines and agent evaluation datasets.
+const (
+	// FormatJSONL writes one history record per line.
+	FormatJSONL Format = "jsonl"
+	// FormatOTLP writes the history as an OpenTelemetry logs export request in
+	// OTLP/JSON, one request per line as the collector's file exporter does.
+	FormatOTLP Format = "otlp"
+)
+
+// HistoryRecord is one event with the session state it happened in and
+// features derived from the events before it.
+type HistoryRecord struct {
+	Seq       int             `json:"seq"`
+	Phase     types.Phase     `json:"phase"`
+	Iteration int             `json:"iteration"`
+	SpecID    int             `json:"current_spec_id,omitempty"`
+	Event     types.Event     `json:"event"`
+	Features  HistoryFeatures `json:"features"`
+}
+
+// HistoryFeatures are derived from the history up to an event. Durations are
+// nil when a timestamp is missing or unparseable.
+type HistoryFeatures struct {
+	// SincePrevSeconds is the time since the previous event.
+	SincePrevSeconds *int64 `json:"since_prev_seconds,omitempty"`
+	// PhaseElapsedSeconds is the time since the current phase was entered.
+	PhaseElapsedSeconds *int64 `json:"phase_elapsed_seconds,omitempty"`
+	// TestAttempt numbers a test run within its phase; retries are attempts
+	// after the first.
+	TestAttempt int `json:"test_attempt,omitempty"`
+	// The rest describe the phase a transition leaves.
+	PhaseDurationSeconds *int64 `json:"phase_duration_seconds,omitempty"`
+	TestRuns             int    `json:"test_runs,omitempty"`
+	FailedTestRuns       int    `json:"failed_test_runs,omitempty"`
+	Retries              int    `json:"retries,omitempty"`
+	BlockedAttempts      int    `json:"blocked_attempts,omitempty"`
+}
+
+// HistoryRecords replays the history, tracking the phase, iteration, and
+// current spec each event happened in.
+func HistoryRecords(s *types.Session) []HistoryRecord {
+	records := make([]HistoryRecord, 0, len(s.History))
+	phase := types.PhaseRed
+	iteration, specID := 0, 0
+	var prevAt, enteredAt string
+	var runs, failed, blocked int
+
+	enter := func(p types.Phase, at string) {
+		phase, enteredAt = p, at
+		runs, failed, blocked = 0, 0, 0
+	}
+
+	for i, ev := range s.History {
+		r := HistoryRecord{Seq: i + 1, Phase: phase, Iteration: iteration, SpecID: specID, Event: ev}
+		r.Features.SincePrevSeconds = secondsBetween(prevAt, ev.Timestamp)
+		r.Features.PhaseElapsedSeconds = secondsBetween(enteredAt, ev.Timestamp)
+		prevAt = ev.Timestamp
+
+		switch ev.Action {
+		case "init":
+			enter(types.PhaseRed, ev.Timestamp)
+			r.Phase, r.Features.PhaseElapsedSeconds = phase, nil
+		case "test_run":
+			if ev.Suite == "" {
+				runs++
+				r.Features.TestAttempt = runs
+				if ev.Result != "pass" {
+					failed++
+				}
+			}
+		case "phase_next_blocked":
+			blocked++
+		case "spec_picked":
+			specID = ev.SpecID
+		case "phase_next", "phase_set", "complete":
+			r.Features.PhaseDurationSeconds = r.Features.PhaseElapsedSeconds
+			r.Features.TestRuns, r.Features.FailedTestRuns, r.Features.BlockedAttempts = runs, failed, blocked
+			if runs > 1 {
+				r.Features.Retries = runs - 1
+			}
+			to := types.Phase(ev.To)
+			if ev.Action == "complete" {
+				to = types.PhaseDone
+			}
+			if ev.Action == "phase_next" && phase == types.PhaseRefactor {
+				iteration++
+				specID = 0
+			}
+			enter(to, ev.Timestamp)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func exportJSONL(records []HistoryRecord) (string, error) {
+	var b strings.Builder
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return "", fmt.Errorf("encoding export: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// OTLP/JSON shapes, as in opentelemetry-proto's logs ExportLogsServiceRequest.
+type (
+	otlpRequest struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeLogs struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpLogRecord struct {
+		TimeUnixNano         string         `json:"timeUnixNano"`
+		ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+		SeverityNumber       int            `json:"severityNumber"`
+		SeverityText         string         `json:"severityText"`
+		EventName            string         `json:"eventName"`
+		Body                 otlpAnyValue   `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	// otlpAnyValue sets exactly one field. OTLP/JSON encodes 64-bit integers
+	// as strings.
+	otlpAnyValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+		BoolValue   *bool   `json:"boolValue,omitempty"`
+	}
+)
+
+// OTLP severity numbers for INFO and WARN.
+const (
+	otlpSeverityInfo = 9
+	otlpSeverityWarn = 13
+)
+
+func otlpString(key, v string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &v}}
+}
+
+func otlpInt(key string, v int64) otlpKeyValue {
+	s := strconv.FormatInt(v, 10)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &s}}
+}
+
+// exportOTLP renders each record as a log record named after its action, with
+// the full event JSON as the body and the state and features as tdd.*
+// attributes.
+func exportOTLP(records []HistoryRecord) (string, error) {
+	logs := make([]otlpLogRecord, 0, len(records))
+	for _, r := range records {
+		body, err := json.Marshal(r.Event)
+		if err != nil {
+			return "", fmt.Errorf("encoding export: %w", err)
+		}
+		ts := "0"
+		if at, err := time.Parse(time.RFC3339, r.Event.Timestamp); err == nil {
+			ts = strconv.FormatInt(at.UnixNano(), 10)
+		}
+		severity, severityText := otlpSeverityInfo, "INFO"
+		if strings.HasSuffix(r.Event.Action, "_blocked") || r.Event.Override {
+			severity, severityText = otlpSeverityWarn, "WARN"
+		}
+
+		attrs := []otlpKeyValue{
+			otlpInt("tdd.seq", int64(r.Seq)),
+			otlpString("tdd.action", r.Event.Action),
+			otlpString("tdd.phase", string(r.Phase)),
+			otlpInt("tdd.iteration", int64(r.Iteration)),
+		}
+		for _, kv := range []struct{ key, v string }{
+			{"tdd.from", r.Event.From},
+			{"tdd.to", r.Event.To},
+			{"tdd.result", r.Event.Result},
+			{"tdd.suite", r.Event.Suite},
+			{"tdd.reason", r.Event.Reason},
+			{"tdd.agent_id", r.Event.AgentID},
+		} {
+			if kv.v != "" {
+				attrs = append(attrs, otlpString(kv.key, kv.v))
+			}
+		}
+		if r.SpecID > 0 {
+			attrs = append(attrs, otlpInt("tdd.current_spec_id", int64(r.SpecID)))
+		}
+		if r.Event.SpecID > 0 {
+			attrs = append(attrs, otlpInt("tdd.spec_id", int64(r.Event.SpecID)))
+		}
+		f := r.Features
+		for _, kv := range []struct {
+			key string
+			v   *int64
+		}{
+			{"tdd.since_prev_seconds", f.SincePrevSeconds},
+			{"tdd.phase_elapsed_seconds", f.PhaseElapsedSeconds},
+			{"tdd.phase_duration_seconds", f.PhaseDurationSeconds},
+		} {
+			if kv.v != nil {
+				attrs = append(attrs, otlpInt(kv.key, *kv.v))
+			}
+		}
+		for _, kv := range []struct {
+			key string
+			v   int
+		}{
+			{"tdd.test_attempt", f.TestAttempt},
+			{"tdd.test_runs", f.TestRuns},
+			{"tdd.failed_test_runs", f.FailedTestRuns},
+			{"tdd.retries", f.Retries},
+			{"tdd.blocked_attempts", f.BlockedAttempts},
+		} {
+			if kv.v > 0 {
+				attrs = append(attrs, otlpInt(kv.key, int64(kv.v)))
+			}
+		}
+
+		bodyText := string(body)
+		logs = append(logs, otlpLogRecord{
+			TimeUnixNano:         ts,
+			ObservedTimeUnixNano: ts,
+			SeverityNumber:       severity,
+			SeverityText:         severityText,
+			EventName:            "tdd." + r.Event.Action,
+			Body:                 otlpAnyValue{StringValue: &bodyText},
+			Attributes:           attrs,
+		})
+	}
+
+	req := otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  otlpResource{Attributes: []otlpKeyValue{otlpString("service.name", "tdd-ai")}},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: "tdd-ai"}, LogRecords: logs}},
+	}}}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("encoding export: %w", err)
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/reflection"
+)
+
+// FormatMarkdown renders output as a Markdown document.
+const FormatMarkdown Format = "markdown"
+
+// FormatKnowledgeBase renders answered reflections as Markdown (also used for
+// text), grouped by question in first-seen order, or as a JSON array.
+func FormatKnowledgeBase(entries []reflection.Entry, f Format) (string, error) {
+	switch f {
+	case FormatJSON:
+		if entries == nil {
+			entries = []reflection.Entry{}
+		}
+		return exportJSON(entries)
+	case FormatMarkdown, FormatText:
+		return knowledgeBaseMarkdown(entries), nil
+	default:
+		return "", fmt.Errorf("unknown format: %q (use markdown, text, or json)", f)
+	}
+}
+
+func knowledgeBaseMarkdown(entries []reflection.Entry) string {
+	var b strings.Builder
+	b.WriteString("# Reflection Knowledge Base\n")
+	if len(entries) == 0 {
+		b.WriteString("\nNo answered reflections.\n")
+		return b.String()
+	}
+
+	var questions []string
+	byQuestion := make(map[string][]reflection.Entry)
+	for _, e := range entries {
+		if _, ok := byQuestion[e.Question]; !ok {
+			questions = append(questions, e.Question)
+		}
+		byQuestion[e.Question] = append(byQuestion[e.Question], e)
+	}
+	for _, q := range questions {
+		fmt.Fprintf(&b, "\n## %s\n\n", q)
+		for _, e := range byQuestion[q] {
+			fmt.Fprintf(&b, "- %s (%s", e.Answer, e.Source)
+			for _, ev := range e.Evidence {
+				fmt.Fprintf(&b, "; see %s", ev)
+			}
+			b.WriteString(")\n")
+		}
+	}
+	return b.String()
+}
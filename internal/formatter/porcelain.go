@@ -0,0 +1,66 @@
+package formatter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// FormatPorcelain emits one tab-separated record per line for shell scripts.
+// Porcelain output is a stable interface: fields are only ever appended, never
+// reordered or removed, so scripts can rely on column positions across versions.
+const FormatPorcelain Format = "porcelain"
+
+// porcelainField makes a value safe for a tab-separated record by replacing
+// tabs and newlines with spaces.
+func porcelainField(v string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(v)
+}
+
+// porcelainLine joins fields into a single record terminated by a newline.
+func porcelainLine(fields ...string) string {
+	for i, f := range fields {
+		fields[i] = porcelainField(f)
+	}
+	return strings.Join(fields, "\t") + "\n"
+}
+
+// PorcelainPhase renders the phase as a single record:
+//
+//	<phase> TAB <mode> TAB <iteration> TAB <current spec ID, 0 if none>
+func PorcelainPhase(s *types.Session) string {
+	current := 0
+	if s.CurrentSpecID != nil {
+		current = *s.CurrentSpecID
+	}
+	return porcelainLine(string(s.Phase), string(s.GetMode()), strconv.Itoa(s.Iteration), strconv.Itoa(current))
+}
+
+// PorcelainSpecs renders one record per spec, ordered by ID:
+//
+//	<id> TAB <status> TAB <current: 1 or 0> TAB <slug> TAB <description>
+func PorcelainSpecs(s *types.Session) string {
+	var b strings.Builder
+	for _, spec := range sortSpecsByID(s.Specs) {
+		current := "0"
+		if s.IsCurrentSpec(spec.ID) {
+			current = "1"
+		}
+		b.WriteString(porcelainLine(strconv.Itoa(spec.ID), string(spec.Status), current, spec.Slug, spec.Description))
+	}
+	return b.String()
+}
+
+// PorcelainBlockers renders one record per blocker:
+//
+//	<phase> TAB <blocker message>
+//
+// No output means the phase can advance.
+func PorcelainBlockers(p types.Phase, blockers []string) string {
+	var b strings.Builder
+	for _, bl := range blockers {
+		b.WriteString(porcelainLine(string(p), bl))
+	}
+	return b.String()
+}
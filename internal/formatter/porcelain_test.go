@@ -0,0 +1,52 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestPorcelainSpecs(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("returns 404\twhen missing")
+	s.AddSpec("second")
+	_ = s.SetCurrentSpec(2)
+	s.Specs[0].Status = types.SpecStatusCompleted
+
+	got := PorcelainSpecs(s)
+	want := "1\tcompleted\t0\t" + s.Specs[0].Slug + "\treturns 404 when missing\n" +
+		"2\tactive\t1\t" + s.Specs[1].Slug + "\tsecond\n"
+	if got != want {
+		t.Errorf("PorcelainSpecs() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPorcelainPhase(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.Iteration = 3
+	if got := PorcelainPhase(s); got != "green\tgreenfield\t3\t0\n" {
+		t.Errorf("PorcelainPhase() = %q", got)
+	}
+}
+
+func TestPorcelainBlockersEmpty(t *testing.T) {
+	if got := PorcelainBlockers(types.PhaseRed, nil); got != "" {
+		t.Errorf("PorcelainBlockers(nil) = %q, want empty", got)
+	}
+	if got := PorcelainBlockers(types.PhaseRed, []string{"no spec picked"}); got != "red\tno spec picked\n" {
+		t.Errorf("PorcelainBlockers() = %q", got)
+	}
+}
+
+func TestFormatStatusPorcelain(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("only")
+	out, err := FormatStatus(s, FormatPorcelain)
+	if err != nil {
+		t.Fatalf("FormatStatus() error: %v", err)
+	}
+	if out != PorcelainSpecs(s) {
+		t.Errorf("FormatStatus(porcelain) = %q", out)
+	}
+}
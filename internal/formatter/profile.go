@@ -0,0 +1,112 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Profiles are the built-in text profiles for agents that parse guide output
+// differently. A project profile of the same name replaces the preset.
+var Profiles = map[string]types.TextProfile{
+	// Claude reads Markdown well; sections become headings and commands are
+	// fenced so they can be copied verbatim.
+	"claude": {HeadingDepth: 2, Bullet: "-", CodeFence: types.CodeFenceBlock},
+	// Cursor renders guide output in chat, where deeper headings stay compact.
+	"cursor": {HeadingDepth: 3, Bullet: "*", CodeFence: types.CodeFenceInline},
+	// Copilot handles flat lists best, with commands marked inline.
+	"copilot": {Bullet: "-", CodeFence: types.CodeFenceInline},
+}
+
+// ProfileNames returns the names of the built-in and project profiles, sorted.
+func ProfileNames(s *types.Session) []string {
+	var names []string
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	for name := range s.TextProfiles {
+		if _, ok := Profiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveProfile returns the project profile called name, or else the
+// built-in one.
+func ResolveProfile(s *types.Session, name string) (types.TextProfile, error) {
+	if p, ok := s.TextProfiles[name]; ok {
+		return p, nil
+	}
+	if p, ok := Profiles[name]; ok {
+		return p, nil
+	}
+	return types.TextProfile{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(ProfileNames(s), ", "))
+}
+
+// quotedCommand matches a tdd-ai command quoted in guide prose, e.g.
+// 'tdd-ai phase next'.
+var quotedCommand = regexp.MustCompile(`'(tdd-ai [^']+)'`)
+
+// ApplyProfile relayouts text produced by the text formatters: "Title:" lines
+// become headings, indented items become list entries with the profile's
+// bullet, and commands are wrapped in code fences.
+func ApplyProfile(text string, p types.TextProfile) string {
+	bullet := p.Bullet
+	if bullet == "" {
+		bullet = "-"
+	}
+	markdown := p.HeadingDepth > 0
+	fence := p.CodeFence == types.CodeFenceInline || p.CodeFence == types.CodeFenceBlock
+
+	var b strings.Builder
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if fence {
+			line = quotedCommand.ReplaceAllString(line, "`$1`")
+		}
+
+		switch {
+		case strings.HasPrefix(line, "  | "):
+			// Captured test output is kept verbatim, fenced as a block.
+			if p.CodeFence != types.CodeFenceBlock {
+				b.WriteString(line + "\n")
+				continue
+			}
+			b.WriteString("```\n")
+			for ; i < len(lines) && strings.HasPrefix(lines[i], "  | "); i++ {
+				b.WriteString(strings.TrimPrefix(lines[i], "  | ") + "\n")
+			}
+			i--
+			b.WriteString("```\n")
+		case strings.HasPrefix(line, "Test Command: ") && fence:
+			cmd := strings.TrimPrefix(line, "Test Command: ")
+			if p.CodeFence == types.CodeFenceBlock {
+				fmt.Fprintf(&b, "Test Command:\n```sh\n%s\n```\n", cmd)
+			} else {
+				fmt.Fprintf(&b, "Test Command: `%s`\n", cmd)
+			}
+		case markdown && line != "" && !strings.HasPrefix(line, " ") && strings.HasSuffix(line, ":"):
+			fmt.Fprintf(&b, "%s %s\n\n", strings.Repeat("#", p.HeadingDepth), strings.TrimSuffix(line, ":"))
+		case strings.HasPrefix(line, "  - "):
+			indent := "  "
+			if markdown {
+				indent = ""
+			}
+			fmt.Fprintf(&b, "%s%s %s\n", indent, bullet, strings.TrimPrefix(line, "  - "))
+		case markdown && strings.HasPrefix(line, "  ["):
+			fmt.Fprintf(&b, "%s %s\n", bullet, strings.TrimPrefix(line, "  "))
+		case markdown && strings.HasPrefix(line, "    "):
+			// Continuation lines of an item, indented under its text.
+			b.WriteString(strings.TrimPrefix(line, "  ") + "\n")
+		default:
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
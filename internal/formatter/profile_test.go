@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestApplyProfileZeroValueKeepsText(t *testing.T) {
+	text := "Phase: RED\n\nBlockers:\n  - No spec selected\n\n"
+	if got := ApplyProfile(text, types.TextProfile{}); got != text {
+		t.Errorf("zero profile should not change text, got:\n%s", got)
+	}
+}
+
+func TestApplyProfileMarkdown(t *testing.T) {
+	text := "Phase: GREEN\nTest Command: go test ./...\n\nRules:\n  - [r1] Run 'tdd-ai phase next' when green.\n\nLast Test Output (last 2 lines):\n  | --- FAIL: TestX\n  | FAIL\n\n"
+	got := ApplyProfile(text, types.TextProfile{HeadingDepth: 2, Bullet: "*", CodeFence: types.CodeFenceBlock})
+
+	for _, want := range []string{
+		"Phase: GREEN\n",
+		"Test Command:\n```sh\ngo test ./...\n```\n",
+		"## Rules\n\n* [r1] Run `tdd-ai phase next` when green.\n",
+		"## Last Test Output (last 2 lines)\n\n```\n--- FAIL: TestX\nFAIL\n```\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestResolveProfilePrefersProjectProfile(t *testing.T) {
+	s := types.NewSession()
+	s.TextProfiles = map[string]types.TextProfile{"claude": {Bullet: "+"}}
+
+	p, err := ResolveProfile(s, "claude")
+	if err != nil || p.Bullet != "+" {
+		t.Errorf("ResolveProfile() = %+v, %v; want the project profile", p, err)
+	}
+	if _, err := ResolveProfile(s, "unknown"); err == nil {
+		t.Error("ResolveProfile() should reject an unknown profile")
+	}
+}
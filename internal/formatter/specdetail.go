@@ -0,0 +1,141 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// specDetail is everything 'tdd-ai spec show' reports about one spec.
+type specDetail struct {
+	types.Spec
+	Current          bool          `json:"current"`
+	Archived         bool          `json:"archived,omitempty"`
+	Stale            bool          `json:"stale,omitempty"`
+	PickedAt         string        `json:"picked_at,omitempty"`
+	CycleTimeSeconds *int64        `json:"cycle_time_seconds,omitempty"`
+	LeadTimeSeconds  *int64        `json:"lead_time_seconds,omitempty"`
+	Events           []types.Event `json:"events"`
+}
+
+// FormatSpecDetail renders one spec of s with its acceptance criteria, related
+// specs, the history events touching it, and its time metrics: cycle time runs
+// from the first pick to completion, lead time from creation to completion.
+// archived marks a spec read from the spec archive rather than the session.
+func FormatSpecDetail(s *types.Session, spec types.Spec, archived bool, f Format) (string, error) {
+	d := specDetail{
+		Spec:     spec,
+		Archived: archived,
+		PickedAt: firstPickedAt(s, spec.ID),
+		Events:   []types.Event{},
+	}
+	for _, id := range s.CurrentSpecIDs() {
+		d.Current = d.Current || id == spec.ID
+	}
+	for _, stale := range s.StaleSpecs(time.Now()) {
+		d.Stale = d.Stale || stale.ID == spec.ID
+	}
+	d.CycleTimeSeconds = secondsBetween(d.PickedAt, spec.CompletedAt)
+	d.LeadTimeSeconds = secondsBetween(spec.CreatedAt, spec.CompletedAt)
+	for _, ev := range s.History {
+		if ev.CoversSpec(spec.ID) {
+			d.Events = append(d.Events, ev)
+		}
+	}
+
+	switch f {
+	case FormatJSON:
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("encoding spec: %w", err)
+		}
+		return string(data) + "\n", nil
+	case FormatText:
+		return specDetailText(d), nil
+	default:
+		return "", fmt.Errorf("unknown format: %q", f)
+	}
+}
+
+func specDetailText(d specDetail) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", specRef(d.Spec), d.Description)
+
+	status := specStatusLabel(d.Spec)
+	var notes []string
+	if d.Current {
+		notes = append(notes, "current")
+	}
+	if d.Archived {
+		notes = append(notes, "archived")
+	}
+	if d.Stale {
+		notes = append(notes, "stale")
+	}
+	if len(notes) > 0 {
+		status += " (" + strings.Join(notes, ", ") + ")"
+	}
+	fmt.Fprintf(&b, "Status: %s\n", status)
+	if d.Iterations > 0 {
+		fmt.Fprintf(&b, "Iterations: %d\n", d.Iterations)
+	}
+	if d.ParentID > 0 {
+		fmt.Fprintf(&b, "Split from: [%d]\n", d.ParentID)
+	}
+	if d.DiscoveredFrom > 0 {
+		fmt.Fprintf(&b, "Discovered from: [%d] during %s\n", d.DiscoveredFrom, strings.ToUpper(string(d.DiscoveredIn)))
+	}
+	if d.Area != "" {
+		fmt.Fprintf(&b, "Test area: %s\n", d.Area)
+	}
+
+	if len(d.Criteria) > 0 {
+		b.WriteString("\nAcceptance criteria:\n")
+		for _, c := range d.Criteria {
+			mark := " "
+			if c.Met {
+				mark = "x"
+			}
+			fmt.Fprintf(&b, "  [%s] %d. %s\n", mark, c.ID, c.Description)
+		}
+		if d.Waiver != "" {
+			fmt.Fprintf(&b, "  Waived: %s\n", d.Waiver)
+		}
+	}
+
+	b.WriteString("\nTimes:\n")
+	for _, t := range []struct{ label, at string }{
+		{"Created", d.CreatedAt},
+		{"Picked", d.PickedAt},
+		{"Updated", d.UpdatedAt},
+		{"Completed", d.CompletedAt},
+	} {
+		if t.at != "" {
+			fmt.Fprintf(&b, "  %-10s %s\n", t.label+":", t.at)
+		}
+	}
+	if d.CycleTimeSeconds != nil {
+		fmt.Fprintf(&b, "  Cycle time: %s\n", time.Duration(*d.CycleTimeSeconds)*time.Second)
+	}
+	if d.LeadTimeSeconds != nil {
+		fmt.Fprintf(&b, "  Lead time:  %s\n", time.Duration(*d.LeadTimeSeconds)*time.Second)
+	}
+
+	if len(d.Events) > 0 {
+		fmt.Fprintf(&b, "\nEvents (%d):\n", len(d.Events))
+		for _, ev := range d.Events {
+			line := fmt.Sprintf("  %s %s", ev.Timestamp, ev.Action)
+			if ev.Result != "" {
+				line += ": " + ev.Result
+			}
+			if ev.Reason != "" {
+				line += fmt.Sprintf(" (%s)", ev.Reason)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
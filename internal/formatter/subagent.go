@@ -0,0 +1,155 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/guide"
+	"github.com/macosta/tdd-ai/internal/phase"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// subagentOutput is the resume packet for a sub-agent working on one spec. It
+// leaves out the other specs, the goal, and the session history, so a spawned
+// agent's context holds only its own part of the list.
+type subagentOutput struct {
+	Spec    types.Spec `json:"spec"`
+	Current bool       `json:"current"`
+	// Phase, Blockers, and LastTestOutput describe the session's cycle and are
+	// set only while the spec is the one being worked on.
+	Phase          types.Phase                  `json:"phase,omitempty"`
+	Mode           types.Mode                   `json:"mode"`
+	TestCmd        string                       `json:"test_cmd,omitempty"`
+	TestArea       string                       `json:"test_area,omitempty"`
+	TestNames      []string                     `json:"test_names,omitempty"`
+	Rules          map[types.Phase][]types.Rule `json:"rules"`
+	Instructions   []string                     `json:"instructions,omitempty"`
+	Blockers       []string                     `json:"blockers,omitempty"`
+	LastTestOutput *types.TestEvidence          `json:"last_test_output,omitempty"`
+	NextAction     string                       `json:"next_action"`
+	// NextActionDetail is the next action in structured form.
+	NextActionDetail NextAction `json:"next_action_detail"`
+}
+
+// subagentPhases are the phases whose rules a sub-agent needs for a cycle.
+var subagentPhases = []types.Phase{types.PhaseRed, types.PhaseGreen, types.PhaseRefactor}
+
+// buildSubagentResume collects the resume packet scoped to spec.
+func buildSubagentResume(s *types.Session, spec types.Spec) subagentOutput {
+	out := subagentOutput{
+		Spec:         spec,
+		Mode:         s.GetMode(),
+		TestCmd:      s.TestCmd,
+		Rules:        make(map[types.Phase][]types.Rule, len(subagentPhases)),
+		Instructions: s.Instructions,
+	}
+	if cmd, ok := s.TestAreas[spec.Area]; ok && spec.Area != "" {
+		out.TestCmd, out.TestArea = cmd, spec.Area
+	}
+	if s.TestNaming != nil {
+		out.TestNames = s.TestNaming.For(spec)
+	}
+	high := len(s.HighRiskSpecs([]int{spec.ID})) > 0
+	for _, p := range subagentPhases {
+		out.Rules[p] = guide.Rules(s.Rules, p)
+		if high {
+			out.Rules[p] = append(out.Rules[p], guide.HighRiskRules(s.GetHighRiskRules(), spec.ID)...)
+		}
+	}
+	for _, id := range s.CurrentSpecIDs() {
+		out.Current = out.Current || id == spec.ID
+	}
+
+	if out.Current {
+		out.Phase = s.Phase
+		out.Blockers = phase.GetBlockers(s)
+		out.LastTestOutput = s.LastTestOutput
+		out.NextAction, out.NextActionDetail = resumeNextAction(s)
+		return out
+	}
+	id := strconv.Itoa(spec.ID)
+	out.NextAction = "tdd-ai spec pick " + id
+	out.NextActionDetail = NextAction{Command: "spec pick", Args: []string{id}, Reason: fmt.Sprintf("start the RED phase for spec %s", id)}
+	return out
+}
+
+// FormatSubagentResume renders the resume packet for a sub-agent spawned to
+// implement a single spec: the spec with its acceptance criteria, the rules
+// of each phase, the test command, and the next action, with nothing about
+// the other specs or the session history.
+func FormatSubagentResume(s *types.Session, spec types.Spec, f Format) (string, error) {
+	out := buildSubagentResume(s, spec)
+
+	switch f {
+	case FormatJSON:
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case FormatText:
+		var b strings.Builder
+		fmt.Fprintf(&b, "=== Sub-agent Packet: Spec %s ===\n", specRef(spec))
+		fmt.Fprintf(&b, "%s\n", spec.Description)
+		if out.Current {
+			fmt.Fprintf(&b, "Phase: %s | Mode: %s\n", strings.ToUpper(string(out.Phase)), out.Mode)
+		} else {
+			fmt.Fprintf(&b, "Mode: %s (not started)\n", out.Mode)
+		}
+		if len(spec.Criteria) > 0 {
+			b.WriteString("\nAcceptance criteria:\n")
+			for _, c := range spec.Criteria {
+				mark := " "
+				if c.Met {
+					mark = "x"
+				}
+				fmt.Fprintf(&b, "  [%s] %d. %s\n", mark, c.ID, c.Description)
+			}
+		}
+
+		b.WriteString("\n")
+		switch {
+		case out.TestArea != "":
+			fmt.Fprintf(&b, "Test command: %s (in %s; run 'tdd-ai test')\n", out.TestCmd, out.TestArea)
+		case out.TestCmd != "":
+			fmt.Fprintf(&b, "Test command: %s (run 'tdd-ai test')\n", out.TestCmd)
+		}
+		if len(out.TestNames) > 0 {
+			fmt.Fprintf(&b, "Name new tests after the spec: %s\n", strings.Join(out.TestNames, " or "))
+		}
+
+		for _, p := range subagentPhases {
+			if len(out.Rules[p]) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n%s rules:\n", strings.ToUpper(string(p)))
+			for _, rule := range out.Rules[p] {
+				fmt.Fprintf(&b, "  - %s\n", rule.Text)
+			}
+		}
+		if len(out.Instructions) > 0 {
+			b.WriteString("\nProject instructions:\n")
+			for _, in := range out.Instructions {
+				fmt.Fprintf(&b, "  - %s\n", in)
+			}
+		}
+
+		b.WriteString("\n")
+		if len(out.Blockers) > 0 {
+			b.WriteString("BLOCKERS:\n")
+			for _, bl := range out.Blockers {
+				fmt.Fprintf(&b, "  - %s\n", bl)
+			}
+			b.WriteString("\n")
+		}
+		if out.LastTestOutput != nil {
+			writeTestEvidence(&b, out.LastTestOutput)
+		}
+		fmt.Fprintf(&b, "NEXT ACTION:\n  %s\n  (%s)\n", out.NextAction, out.NextActionDetail.Reason)
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("unknown format: %q", f)
+	}
+}
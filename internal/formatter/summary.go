@@ -0,0 +1,188 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/diffguard"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Summarize builds the summary of the cycle ending now: specs completed since
+// the previous summary, with their iterations and cycle times, how long the
+// cycle took, and the answered reflection questions of its last REFACTOR.
+func Summarize(s *types.Session) types.CycleSummary {
+	sum := types.CycleSummary{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Iteration:   s.Iteration,
+		Iterations:  s.Iteration,
+		Specs:       []types.SummarySpec{},
+	}
+	if prev := s.Summary; prev != nil {
+		sum.StartedAt = prev.GeneratedAt
+		sum.Iterations = s.Iteration - prev.Iteration
+	} else if len(s.History) > 0 {
+		sum.StartedAt = s.History[0].Timestamp
+	}
+	sum.DurationSeconds = secondsBetween(sum.StartedAt, sum.GeneratedAt)
+
+	for _, spec := range sortSpecsByID(s.Specs) {
+		if spec.Status != types.SpecStatusCompleted || spec.CompletedAt < sum.StartedAt {
+			continue
+		}
+		start := firstPickedAt(s, spec.ID)
+		if start == "" {
+			start = spec.CreatedAt
+		}
+		sum.Specs = append(sum.Specs, types.SummarySpec{
+			ID:               spec.ID,
+			Description:      spec.Description,
+			Iterations:       spec.Iterations,
+			CycleTimeSeconds: secondsBetween(start, spec.CompletedAt),
+		})
+	}
+	for _, r := range s.Reflections {
+		if r.Answer != "" {
+			sum.Reflections = append(sum.Reflections, r)
+		}
+	}
+	sum.FilesByPhase = filesByPhase(s, sum.StartedAt)
+	return sum
+}
+
+// filesByPhase collects the files recorded on the phase transitions since
+// start, keyed by the phase left ('complete' when it skipped ahead).
+func filesByPhase(s *types.Session, start string) map[string]types.FileTouches {
+	globs := diffguard.Globs(s.TestGlobs)
+	seen := make(map[string]map[string]bool)
+	var byPhase map[string]types.FileTouches
+	for _, ev := range s.History {
+		if len(ev.Files) == 0 || ev.Timestamp < start || (ev.From == "" && ev.Action != "complete") {
+			continue
+		}
+		key := ev.From
+		if key == "" {
+			key = ev.Action
+		}
+		if seen[key] == nil {
+			seen[key] = make(map[string]bool)
+		}
+		var fresh []string
+		for _, f := range ev.Files {
+			if !seen[key][f] {
+				seen[key][f] = true
+				fresh = append(fresh, f)
+			}
+		}
+		tests, impl := diffguard.Split(globs, fresh)
+		if byPhase == nil {
+			byPhase = make(map[string]types.FileTouches)
+		}
+		t := byPhase[key]
+		t.Tests = append(t.Tests, tests...)
+		t.Impl = append(t.Impl, impl...)
+		byPhase[key] = t
+	}
+	return byPhase
+}
+
+// describeFiles summarizes the files of a phase transition, e.g.
+// "2 test file(s), 1 impl file(s)".
+func describeFiles(s *types.Session, files []string) string {
+	tests, impl := diffguard.Split(diffguard.Globs(s.TestGlobs), files)
+	return touchesText(types.FileTouches{Tests: tests, Impl: impl})
+}
+
+func touchesText(t types.FileTouches) string {
+	var parts []string
+	if len(t.Tests) > 0 {
+		parts = append(parts, fmt.Sprintf("%d test file(s)", len(t.Tests)))
+	}
+	if len(t.Impl) > 0 {
+		parts = append(parts, fmt.Sprintf("%d impl file(s)", len(t.Impl)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatSummary renders a stored cycle summary.
+func FormatSummary(sum *types.CycleSummary, f Format) (string, error) {
+	switch f {
+	case FormatJSON:
+		data, err := json.MarshalIndent(sum, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("encoding summary: %w", err)
+		}
+		return string(data) + "\n", nil
+	case FormatText:
+		return summaryText(sum), nil
+	default:
+		return "", fmt.Errorf("unknown format: %q", f)
+	}
+}
+
+// phaseOrder sorts phase names in cycle order, anything else last.
+func phaseOrder(p string) int {
+	for i, name := range []types.Phase{types.PhaseRed, types.PhaseGreen, types.PhaseRefactor, types.PhaseDone} {
+		if p == string(name) {
+			return i
+		}
+	}
+	return 4
+}
+
+func summaryText(sum *types.CycleSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cycle summary (%s)\n", sum.GeneratedAt)
+	if sum.DurationSeconds != nil {
+		fmt.Fprintf(&b, "  Duration: %s over %d iteration(s)\n", time.Duration(*sum.DurationSeconds)*time.Second, sum.Iterations)
+	} else {
+		fmt.Fprintf(&b, "  Iterations: %d\n", sum.Iterations)
+	}
+	fmt.Fprintf(&b, "  Specs completed: %d\n", len(sum.Specs))
+	for _, spec := range sum.Specs {
+		var details []string
+		if spec.Iterations > 0 {
+			details = append(details, fmt.Sprintf("%d iteration(s)", spec.Iterations))
+		}
+		if spec.CycleTimeSeconds != nil {
+			details = append(details, (time.Duration(*spec.CycleTimeSeconds) * time.Second).String())
+		}
+		fmt.Fprintf(&b, "    [%d] %s", spec.ID, spec.Description)
+		if len(details) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(details, ", "))
+		}
+		b.WriteString("\n")
+	}
+	if len(sum.FilesByPhase) > 0 {
+		b.WriteString("  Files changed:\n")
+		keys := make([]string, 0, len(sum.FilesByPhase))
+		for k := range sum.FilesByPhase {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return phaseOrder(keys[i]) < phaseOrder(keys[j]) })
+		for _, k := range keys {
+			t := sum.FilesByPhase[k]
+			fmt.Fprintf(&b, "    %s touched %s\n", strings.ToUpper(k), touchesText(t))
+			for _, f := range t.Tests {
+				fmt.Fprintf(&b, "      test %s\n", f)
+			}
+			for _, f := range t.Impl {
+				fmt.Fprintf(&b, "      impl %s\n", f)
+			}
+		}
+	}
+	if len(sum.Reflections) > 0 {
+		b.WriteString("  Reflection highlights:\n")
+		for _, r := range sum.Reflections {
+			fmt.Fprintf(&b, "    Q: %s\n", r.Question)
+			fmt.Fprintf(&b, "       A: %s\n", r.Answer)
+			for _, ev := range r.Evidence {
+				fmt.Fprintf(&b, "          see %s\n", ev)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestSummarizeCoversSpecsSincePreviousSummary(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("old")
+	s.AddSpec("new")
+	s.Specs[0].Status = types.SpecStatusCompleted
+	s.Specs[0].CompletedAt = "2026-01-01T10:00:00Z"
+	s.Specs[1].Status = types.SpecStatusCompleted
+	s.Specs[1].CompletedAt = "2026-01-02T10:00:00Z"
+	s.Iteration = 3
+	s.Summary = &types.CycleSummary{GeneratedAt: "2026-01-01T12:00:00Z", Iteration: 1}
+
+	sum := Summarize(s)
+	if len(sum.Specs) != 1 || sum.Specs[0].Description != "new" {
+		t.Errorf("Specs = %+v, want only the spec completed after the previous summary", sum.Specs)
+	}
+	if sum.Iterations != 2 {
+		t.Errorf("Iterations = %d, want 2", sum.Iterations)
+	}
+	if sum.StartedAt != "2026-01-01T12:00:00Z" {
+		t.Errorf("StartedAt = %q, want the previous summary's time", sum.StartedAt)
+	}
+}
+
+func TestSummarizeGroupsFilesByPhase(t *testing.T) {
+	s := types.NewSession()
+	s.History = []types.Event{
+		{Action: "phase_next", From: "red", To: "green", Timestamp: "2026-01-01T10:00:00Z", Files: []string{"calc_test.go"}},
+		{Action: "phase_next", From: "green", To: "refactor", Timestamp: "2026-01-01T10:05:00Z", Files: []string{"calc.go"}},
+		{Action: "complete", Timestamp: "2026-01-01T10:10:00Z", Files: []string{"calc.go", "calc_test.go"}},
+	}
+
+	sum := Summarize(s)
+	if got := sum.FilesByPhase["red"]; len(got.Tests) != 1 || len(got.Impl) != 0 {
+		t.Errorf("red = %+v, want one test file", got)
+	}
+	if got := sum.FilesByPhase["green"]; len(got.Tests) != 0 || len(got.Impl) != 1 {
+		t.Errorf("green = %+v, want one impl file", got)
+	}
+	if got := sum.FilesByPhase["complete"]; len(got.Tests) != 1 || len(got.Impl) != 1 {
+		t.Errorf("complete = %+v, want one file of each", got)
+	}
+
+	out, err := FormatSummary(&sum, FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "RED touched 1 test file(s)") || !strings.Contains(out, "GREEN touched 1 impl file(s)") {
+		t.Errorf("summary text missing per-phase files:\n%s", out)
+	}
+}
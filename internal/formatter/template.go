@@ -0,0 +1,55 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// renderTemplate executes a Go text/template against data, mirroring the
+// --template flag of kubectl and docker. Field names are the Go field names of the
+// JSON output (e.g. {{.Phase}}, {{.CurrentSpec.Description}}).
+func renderTemplate(tmpl string, data any) (string, error) {
+	t, err := template.New("output").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	out := b.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return out, nil
+}
+
+// TemplateGuidance renders guidance through a user-supplied template.
+func TemplateGuidance(g types.Guidance, tmpl string) (string, error) {
+	return renderTemplate(tmpl, g)
+}
+
+// TemplateFullStatus renders the status overview through a user-supplied template.
+func TemplateFullStatus(s *types.Session, tmpl string) (string, error) {
+	return renderTemplate(tmpl, buildFullStatus(s))
+}
+
+// TemplateResume renders the resume checkpoint through a user-supplied template.
+func TemplateResume(s *types.Session, tmpl string) (string, error) {
+	return TemplateResumeBudget(s, tmpl, BudgetNormal)
+}
+
+// TemplateResumeBudget renders the resume checkpoint, trimmed to the given
+// budget, through a user-supplied template.
+func TemplateResumeBudget(s *types.Session, tmpl string, budget Budget) (string, error) {
+	return renderTemplate(tmpl, buildResume(s, budget))
+}
+
+// TemplateSubagentResume renders the sub-agent resume packet for spec through
+// a user-supplied template.
+func TemplateSubagentResume(s *types.Session, spec types.Spec, tmpl string) (string, error) {
+	return renderTemplate(tmpl, buildSubagentResume(s, spec))
+}
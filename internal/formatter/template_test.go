@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestTemplateGuidance(t *testing.T) {
+	spec := types.Spec{ID: 1, Description: "adds numbers"}
+	g := types.Guidance{Phase: types.PhaseRed, CurrentSpec: &spec}
+
+	out, err := TemplateGuidance(g, "{{.Phase}} {{.CurrentSpec.Description}}")
+	if err != nil {
+		t.Fatalf("TemplateGuidance() error: %v", err)
+	}
+	if out != "red adds numbers\n" {
+		t.Errorf("TemplateGuidance() = %q, want %q", out, "red adds numbers\n")
+	}
+}
+
+func TestTemplateFullStatusAndResume(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+
+	out, err := TemplateFullStatus(s, "{{.ActiveSpecs}}/{{.TotalSpecs}}")
+	if err != nil {
+		t.Fatalf("TemplateFullStatus() error: %v", err)
+	}
+	if out != "2/2\n" {
+		t.Errorf("TemplateFullStatus() = %q, want %q", out, "2/2\n")
+	}
+
+	out, err = TemplateResume(s, "{{.NextAction}}")
+	if err != nil {
+		t.Fatalf("TemplateResume() error: %v", err)
+	}
+	if !strings.Contains(out, "tdd-ai spec pick 1") {
+		t.Errorf("TemplateResume() = %q, want next action to pick spec 1", out)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	s := types.NewSession()
+	if _, err := TemplateResume(s, "{{.Phase"); err == nil {
+		t.Error("TemplateResume() should reject a malformed template")
+	}
+	if _, err := TemplateResume(s, "{{.NoSuchField}}"); err == nil {
+		t.Error("TemplateResume() should reject unknown fields")
+	}
+}
@@ -1,6 +1,11 @@
 package guide
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/loopdetect"
 	"github.com/macosta/tdd-ai/internal/phase"
 	"github.com/macosta/tdd-ai/internal/types"
 )
@@ -10,18 +15,22 @@ func Generate(s *types.Session) types.Guidance {
 	mode := s.GetMode()
 
 	g := types.Guidance{
-		Phase:      s.Phase,
-		Mode:       mode,
-		TestCmd:    s.TestCmd,
-		Specs:      s.ActiveSpecs(),
-		Iteration:  s.Iteration,
-		TotalSpecs: len(s.Specs),
+		Phase:                s.Phase,
+		Mode:                 mode,
+		TestCmd:              s.TestCmd,
+		TestSuites:           s.SuiteNames(),
+		Specs:                s.ActiveSpecs(),
+		Iteration:            s.Iteration,
+		TotalSpecs:           len(s.Specs),
+		MaxIterationsPerSpec: s.MaxIterationsPerSpec,
+		Goal:                 s.Goal,
 	}
 
 	// Populate current spec if one is selected
 	if cs := s.CurrentSpec(); cs != nil {
 		g.CurrentSpec = cs
 	}
+	g.PickGroup = s.PickGroupSpecs()
 
 	// Compute next phase from the state machine (ignore error for done/invalid)
 	if next, err := phase.NextWithMode(s.Phase, mode); err == nil {
@@ -30,16 +39,195 @@ func Generate(s *types.Session) types.Guidance {
 
 	// Expected test result for the current phase
 	if s.Phase != types.PhaseDone {
-		g.ExpectedTestResult = phase.ExpectedTestResult(s.Phase, mode)
+		g.ExpectedTestResult = phase.ExpectedTestResultFor(s, s.Phase)
 	}
 
 	// Blockers preventing advancement
 	g.Blockers = phase.GetBlockers(s)
 
+	// Flag active specs that have been left untouched too long
+	g.StaleSpecs = s.StaleSpecs(time.Now())
+	if d, ok := s.ElapsedInPhase(time.Now()); ok {
+		g.ElapsedInPhase = d.String()
+	}
+	g.EnteredPhaseAt = s.PhaseEnteredAt
+	g.LastTestAt = s.LastTestAt()
+	g.SessionStartedAt = s.SessionStartedAt()
+
+	g.Rules = Rules(s.Rules, s.Phase)
+	if high := s.HighRiskSpecs(s.CurrentSpecIDs()); len(high) > 0 {
+		g.Rules = append(g.Rules, HighRiskRules(s.GetHighRiskRules(), high[0].ID)...)
+	}
+
 	// Include reflections during refactor phase
 	if s.Phase == types.PhaseRefactor {
 		g.Reflections = s.Reflections
+		g.MutationScore = s.MutationScore
+	}
+
+	// Nudge a REFACTOR that has run past its timebox toward advancing
+	if s.RefactorTimeboxExceeded(time.Now()) {
+		g.TimeboxExceeded = true
+		g.Instructions = append(g.Instructions, TimeboxInstruction)
+	}
+
+	// In DONE, point at definition-of-done criteria that are still open
+	if s.Phase == types.PhaseDone && s.Goal != nil {
+		for _, c := range s.Goal.UnmetCriteria() {
+			g.Instructions = append(g.Instructions,
+				fmt.Sprintf("Goal criterion %d not yet met: %s (check it off with 'tdd-ai goal check %d')", c.ID, c.Description, c.ID))
+		}
+	}
+
+	// Remind the agent which paths are off-limits
+	if len(s.ProtectedPaths) > 0 {
+		g.Instructions = append(g.Instructions,
+			fmt.Sprintf("Do not modify protected paths: %s. Changes to them block phase advancement.", strings.Join(s.ProtectedPaths, ", ")))
 	}
 
+	// Project-specific guidance, e.g. from an init template
+	g.Instructions = append(g.Instructions, s.Instructions...)
+
+	// In pair mode, name the agent expected to drive this phase
+	if s.Pair != nil {
+		if role, driver := s.Pair.Driver(s.Phase); driver != "" {
+			g.Instructions = append(g.Instructions,
+				fmt.Sprintf("Pair mode: the %s phase is driven by the %s %q; other agents are rejected until the phase changes.", s.Phase, role, driver))
+		}
+	}
+
+	// Category-specific instructions when the last test run did not pass
+	if s.LastTestResult != "" && s.LastTestResult != "pass" && s.LastFailureCategory != "" {
+		g.FailureCategory = s.LastFailureCategory
+		g.LastTestOutput = s.LastTestOutput
+		g.Instructions = append(g.Instructions, failureInstructions(s.LastFailureCategory, s.Phase)...)
+		if s.Phase == types.PhaseGreen && s.LastTestOutput != nil && s.LastTestOutput.FirstFailure != "" {
+			g.Instructions = append(g.Instructions, firstFailureInstruction(s.LastTestOutput))
+		}
+	}
+
+	// A cached 'go test' pass may predate the edits it is meant to check
+	if s.LastTestResult == "pass" && s.LastTestCached {
+		g.TestCached = true
+		g.Instructions = append(g.Instructions, CachedPassInstruction)
+	}
+
+	// Intervene when the history shows the agent going in circles
+	if loop := loopdetect.Detect(s.History); loop != nil {
+		g.LoopDetected = loop
+		g.Instructions = append([]string{loopdetect.Intervention}, g.Instructions...)
+	}
+
+	return g
+}
+
+// Preview returns the guidance the session would get in phase p, without
+// changing s. The copy has no phase entry time, so no elapsed time or timebox
+// applies. questions stand in for the reflection questions when previewing
+// REFACTOR before any have been asked.
+func Preview(s *types.Session, p types.Phase, questions []types.ReflectionQuestion) types.Guidance {
+	preview := *s
+	preview.Phase = p
+	preview.PhaseEnteredAt = ""
+	if p == types.PhaseRefactor && len(preview.Reflections) == 0 {
+		preview.Reflections = questions
+	}
+	g := Generate(&preview)
+	g.PreviewFrom = s.Phase
 	return g
 }
+
+// BuiltinRules are the rules guide shows for each phase unless the project
+// replaces them with 'tdd-ai config rules --replace'.
+var BuiltinRules = map[types.Phase][]types.Rule{
+	types.PhaseRed: {
+		{ID: "red-one-failing-test", Text: "Write one failing test for the current spec before any production code."},
+		{ID: "red-fail-for-the-right-reason", Text: "Run the tests and confirm the new test fails for the expected reason, not a compile or setup error."},
+	},
+	types.PhaseGreen: {
+		{ID: "green-simplest-code", Text: "Write the simplest production code that makes the failing test pass."},
+		{ID: "green-tests-frozen", Text: "Do not edit the tests written in RED."},
+		{ID: "green-park-discoveries", Text: "Do not gold-plate: park new ideas or edge cases as specs with 'tdd-ai spec add --discovered-from <current-spec-id>' instead of implementing them now."},
+	},
+	types.PhaseRefactor: {
+		{ID: "refactor-keep-behavior", Text: "Improve the structure without changing behavior; the tests must stay green after every change."},
+		{ID: "refactor-no-new-features", Text: "Do not add functionality; record new ideas as specs with 'tdd-ai spec add --discovered-from <current-spec-id>'."},
+	},
+}
+
+// Rules returns the rules for phase p: the built-in rules followed by the
+// project rules, or only the project rules when they replace the built-ins.
+// Project rules get the IDs custom-<phase>-<n>, numbered from 1.
+func Rules(custom *types.PhaseRules, p types.Phase) []types.Rule {
+	var rules []types.Rule
+	if custom == nil || !custom.Replace {
+		rules = append(rules, BuiltinRules[p]...)
+	}
+	for i, text := range custom.For(p) {
+		rules = append(rules, types.Rule{ID: fmt.Sprintf("custom-%s-%d", p, i+1), Text: text})
+	}
+	return rules
+}
+
+// HighRiskRules returns the extra rules shown while working on high-risk spec
+// id, whatever the phase.
+func HighRiskRules(r types.RiskRules, id int) []types.Rule {
+	var rules []types.Rule
+	if r.IntegrationSuite != "" {
+		rules = append(rules, types.Rule{ID: "high-risk-integration-suite",
+			Text: fmt.Sprintf("Spec %d is high-risk: the '%s' test suite must pass during REFACTOR ('tdd-ai test --suite %s') before the spec can be finished.", id, r.IntegrationSuite, r.IntegrationSuite)})
+	}
+	if r.RequireReview {
+		rules = append(rules, types.Rule{ID: "high-risk-human-review",
+			Text: fmt.Sprintf("Spec %d is high-risk: a human must approve the iteration with 'tdd-ai review' before it is done.", id)})
+	}
+	return rules
+}
+
+// TimeboxInstruction is added to guidance once REFACTOR exceeds the session's
+// refactor timebox.
+const TimeboxInstruction = "Refactor timebox exceeded; either finish or record remaining ideas as new specs with 'tdd-ai spec add' and advance."
+
+// CachedPassInstruction is added to guidance when the last passing run was
+// served, at least in part, from Go's test cache.
+const CachedPassInstruction = "The last test run passed with (cached) results from Go's test cache, which may not reflect recent edits; re-run with 'tdd-ai test --no-cache' before relying on it."
+
+// firstFailureInstruction names the first failing test of the last run and,
+// when known, its failure message.
+func firstFailureInstruction(ev *types.TestEvidence) string {
+	if ev.FailureMessage == "" {
+		return fmt.Sprintf("Start with the first failing test: %s.", ev.FirstFailure)
+	}
+	return fmt.Sprintf("Start with the first failing test: %s (%s).", ev.FirstFailure, ev.FailureMessage)
+}
+
+// failureInstructions returns guidance for the given failure category and phase.
+func failureInstructions(category string, p types.Phase) []string {
+	switch category {
+	case types.FailureInfrastructure:
+		return []string{
+			"The last test run failed for environmental reasons (missing binary, dependency, or permissions), not because of a test.",
+			"Fix the environment and re-run 'tdd-ai test'; this result does not count as RED or GREEN.",
+		}
+	case types.FailureCompile:
+		if p == types.PhaseRed {
+			return []string{
+				"The tests do not compile. Fix the compile error before treating this as RED.",
+				"Add the minimal stubs (types, function signatures) needed for the test to build, then confirm it fails on an assertion.",
+			}
+		}
+		return []string{
+			"The build is broken. Fix the compile error before judging the implementation.",
+		}
+	case types.FailureAssertion:
+		if p == types.PhaseRed {
+			return []string{
+				"Tests fail on assertions, as expected in RED. Confirm the failure message matches the behavior of the current spec.",
+			}
+		}
+		return []string{
+			"Assertions are failing. Make the smallest implementation change that satisfies them; do not weaken the tests.",
+		}
+	}
+	return nil
+}
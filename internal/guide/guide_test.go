@@ -1,8 +1,12 @@
 package guide
 
 import (
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/macosta/tdd-ai/internal/loopdetect"
 	"github.com/macosta/tdd-ai/internal/types"
 )
 
@@ -26,6 +30,24 @@ func TestGenerateRedPhase(t *testing.T) {
 	}
 }
 
+func TestGenerateIncludesTimestamps(t *testing.T) {
+	s := types.NewSession()
+	s.SetPhase(types.PhaseGreen)
+	s.RecordTestTrend("fail")
+
+	g := Generate(s)
+
+	if g.EnteredPhaseAt != s.PhaseEnteredAt || g.EnteredPhaseAt == "" {
+		t.Errorf("entered_phase_at = %q, want %q", g.EnteredPhaseAt, s.PhaseEnteredAt)
+	}
+	if g.LastTestAt != s.TestTrend[0].At {
+		t.Errorf("last_test_at = %q, want %q", g.LastTestAt, s.TestTrend[0].At)
+	}
+	if g.SessionStartedAt != s.StartedAt {
+		t.Errorf("session_started_at = %q, want %q", g.SessionStartedAt, s.StartedAt)
+	}
+}
+
 func TestGenerateGreenPhase(t *testing.T) {
 	s := types.NewSession()
 	s.AddSpec("calculate shipping cost")
@@ -55,6 +77,28 @@ func TestGenerateRefactorPhase(t *testing.T) {
 	}
 }
 
+func TestPreviewDoesNotChangeSession(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("calculate shipping cost")
+	s.SetPhase(types.PhaseGreen)
+	questions := []types.ReflectionQuestion{{ID: 1, Question: "Can names be clearer?"}}
+
+	g := Preview(s, types.PhaseRefactor, questions)
+
+	if g.Phase != types.PhaseRefactor || g.PreviewFrom != types.PhaseGreen {
+		t.Errorf("phase = %q, preview_from = %q, want refactor from green", g.Phase, g.PreviewFrom)
+	}
+	if len(g.Reflections) != 1 {
+		t.Errorf("reflections = %v, want the previewed questions", g.Reflections)
+	}
+	if g.ElapsedInPhase != "" || g.EnteredPhaseAt != "" {
+		t.Errorf("elapsed_in_phase = %q, entered_phase_at = %q, want empty for a preview", g.ElapsedInPhase, g.EnteredPhaseAt)
+	}
+	if s.Phase != types.PhaseGreen || s.Reflections != nil {
+		t.Errorf("session changed: phase %q, reflections %v", s.Phase, s.Reflections)
+	}
+}
+
 func TestGenerateDonePhase(t *testing.T) {
 	s := types.NewSession()
 	s.Phase = types.PhaseDone
@@ -270,3 +314,229 @@ func TestGenerateDonePhaseHasBlocker(t *testing.T) {
 		t.Errorf("DONE guidance should have 'Cannot advance past done' blocker, got %v", g.Blockers)
 	}
 }
+
+func TestGenerateCompileFailureInstructions(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("feature")
+	s.LastTestResult = "fail"
+	s.LastFailureCategory = types.FailureCompile
+
+	g := Generate(s)
+
+	if g.FailureCategory != types.FailureCompile {
+		t.Errorf("failure_category = %q, want %q", g.FailureCategory, types.FailureCompile)
+	}
+	if len(g.Instructions) == 0 || !strings.Contains(g.Instructions[0], "before treating this as RED") {
+		t.Errorf("instructions should tell the agent to fix compilation first, got %v", g.Instructions)
+	}
+}
+
+func TestGenerateInfrastructureFailureInstructions(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.LastTestResult = "error"
+	s.LastFailureCategory = types.FailureInfrastructure
+
+	g := Generate(s)
+
+	if len(g.Instructions) == 0 || !strings.Contains(g.Instructions[0], "environmental") {
+		t.Errorf("instructions should mention the environment, got %v", g.Instructions)
+	}
+}
+
+func TestGenerateGreenNamesFirstFailingTest(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.LastTestResult = "fail"
+	s.LastFailureCategory = types.FailureAssertion
+	s.LastTestOutput = &types.TestEvidence{
+		FailingTests:   []string{"TestTotal", "TestTax"},
+		FirstFailure:   "TestTotal",
+		FailureMessage: "cart_test.go:12: expected 3, got 2",
+	}
+
+	g := Generate(s)
+
+	want := "Start with the first failing test: TestTotal (cart_test.go:12: expected 3, got 2)."
+	if len(g.Instructions) == 0 || g.Instructions[len(g.Instructions)-1] != want {
+		t.Errorf("instructions should end with %q, got %v", want, g.Instructions)
+	}
+
+	s.Phase = types.PhaseRed
+	for _, inst := range Generate(s).Instructions {
+		if strings.Contains(inst, "first failing test") {
+			t.Errorf("RED guidance should not name the first failing test, got %q", inst)
+		}
+	}
+}
+
+func TestGenerateNoInstructionsWhenPassing(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.LastTestResult = "pass"
+
+	g := Generate(s)
+
+	if g.FailureCategory != "" || len(g.Instructions) != 0 {
+		t.Errorf("passing run should not add failure instructions, got %q %v", g.FailureCategory, g.Instructions)
+	}
+}
+
+func TestGenerateDoneListsUnmetGoalCriteria(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseDone
+	s.SetGoal("Ship reset flow", []string{"email sent", "token expires"})
+	_ = s.CheckCriterion(1, true)
+
+	g := Generate(s)
+
+	if g.Goal == nil || g.Goal.Description != "Ship reset flow" {
+		t.Fatalf("guidance should include the goal, got %+v", g.Goal)
+	}
+	if len(g.Instructions) != 1 || !strings.Contains(g.Instructions[0], "token expires") {
+		t.Errorf("instructions should list the unmet criterion, got %v", g.Instructions)
+	}
+}
+
+func TestGenerateEmitsInterventionOnLoop(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("feature")
+	for i := 0; i < loopdetect.BlockedThreshold; i++ {
+		s.AddEvent("phase_next_blocked")
+	}
+
+	g := Generate(s)
+
+	if g.LoopDetected == nil || g.LoopDetected.Rule != "repeated_blocked_advance" {
+		t.Fatalf("LoopDetected = %+v, want repeated_blocked_advance", g.LoopDetected)
+	}
+	if len(g.Instructions) == 0 || g.Instructions[0] != loopdetect.Intervention {
+		t.Errorf("first instruction should be the intervention, got %v", g.Instructions)
+	}
+}
+
+func TestGenerateFlagsStaleSpecs(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("forgotten")
+	s.AddSpec("fresh")
+	s.Specs[0].UpdatedAt = "2026-01-01T10:00:00Z"
+
+	g := Generate(s)
+
+	if len(g.StaleSpecs) != 1 || g.StaleSpecs[0].ID != 1 {
+		t.Errorf("StaleSpecs = %+v, want only spec 1", g.StaleSpecs)
+	}
+}
+
+func TestGenerateNamesPairDriver(t *testing.T) {
+	s := types.NewSession()
+	s.Pair = &types.Pair{Tester: "alice", Implementer: "bob"}
+	s.Phase = types.PhaseGreen
+
+	g := Generate(s)
+
+	found := false
+	for _, in := range g.Instructions {
+		if strings.Contains(in, `implementer "bob"`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("instructions should name the implementer as driver, got %v", g.Instructions)
+	}
+}
+
+func TestGenerateIncludesElapsedInPhase(t *testing.T) {
+	s := types.NewSession()
+	if g := Generate(s); g.ElapsedInPhase == "" {
+		t.Error("guidance should include elapsed_in_phase for a fresh session")
+	}
+	s.PhaseEnteredAt = ""
+	if g := Generate(s); g.ElapsedInPhase != "" {
+		t.Errorf("elapsed_in_phase should be empty without an entry time, got %q", g.ElapsedInPhase)
+	}
+}
+
+func TestGenerateFlagsExceededRefactorTimebox(t *testing.T) {
+	s := types.NewSession()
+	s.SetPhase(types.PhaseRefactor)
+	s.RefactorTimebox = "15m"
+	if g := Generate(s); g.TimeboxExceeded {
+		t.Error("timebox should not be exceeded right after entering REFACTOR")
+	}
+
+	s.PhaseEnteredAt = time.Now().Add(-20 * time.Minute).UTC().Format(time.RFC3339)
+	g := Generate(s)
+	if !g.TimeboxExceeded {
+		t.Fatal("timebox_exceeded should be set 20m into a 15m timebox")
+	}
+	if !slices.Contains(g.Instructions, TimeboxInstruction) {
+		t.Errorf("instructions should include the timebox nudge, got %v", g.Instructions)
+	}
+}
+
+func TestGenerateMergesProjectRules(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.Rules = &types.PhaseRules{Green: []string{"never mock the repository layer"}}
+
+	g := Generate(s)
+	if len(g.Rules) != len(BuiltinRules[types.PhaseGreen])+1 {
+		t.Fatalf("Rules = %+v, want the built-in rules plus the project rule", g.Rules)
+	}
+	last := g.Rules[len(g.Rules)-1]
+	if last.ID != "custom-green-1" || last.Text != "never mock the repository layer" {
+		t.Errorf("project rule = %+v, want custom-green-1", last)
+	}
+
+	s.Rules.Replace = true
+	if g := Generate(s); len(g.Rules) != 1 || g.Rules[0].ID != "custom-green-1" {
+		t.Errorf("with replace, Rules = %+v, want only the project rule", g.Rules)
+	}
+}
+
+func TestGenerateWarnsAboutCachedPass(t *testing.T) {
+	s := types.NewSession()
+	s.LastTestResult = "pass"
+	if g := Generate(s); g.TestCached || slices.Contains(g.Instructions, CachedPassInstruction) {
+		t.Error("an uncached pass should not warn")
+	}
+
+	s.LastTestCached = true
+	g := Generate(s)
+	if !g.TestCached || !slices.Contains(g.Instructions, CachedPassInstruction) {
+		t.Errorf("a cached pass should warn, got test_cached=%v instructions %v", g.TestCached, g.Instructions)
+	}
+}
+
+func TestGenerateAddsHighRiskRules(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("Refunds are idempotent")
+	id := 1
+	s.CurrentSpecID = &id
+	s.Phase = types.PhaseGreen
+	base := len(Generate(s).Rules)
+
+	s.Specs[0].Risk = types.RiskHigh
+	g := Generate(s)
+	if len(g.Rules) != base+2 || g.Rules[base].ID != "high-risk-integration-suite" || g.Rules[base+1].ID != "high-risk-human-review" {
+		t.Errorf("Rules = %+v, want both default high-risk rules appended", g.Rules)
+	}
+
+	s.HighRiskRules = &types.RiskRules{RequireReview: true}
+	if g := Generate(s); len(g.Rules) != base+1 || g.Rules[base].ID != "high-risk-human-review" {
+		t.Errorf("with review-only rules, Rules = %+v, want only the review rule", g.Rules)
+	}
+}
+
+func TestGenerateGreenDirectsDiscoveriesToSpecs(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	g := Generate(s)
+	for _, r := range g.Rules {
+		if r.ID == "green-park-discoveries" && strings.Contains(r.Text, "--discovered-from") {
+			return
+		}
+	}
+	t.Errorf("GREEN rules should direct new ideas to 'spec add --discovered-from', got %+v", g.Rules)
+}
@@ -0,0 +1,176 @@
+package hub
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Message is one session event as broadcast to subscribers. Kind groups the
+// event's action into phase, test, spec, reflection, or other, so dashboards
+// can filter without knowing every action name.
+type Message struct {
+	SessionID string      `json:"session_id"`
+	Dir       string      `json:"dir"`
+	Kind      string      `json:"kind"`
+	Phase     types.Phase `json:"phase"`
+	Event     types.Event `json:"event"`
+}
+
+// subscriberBuffer is how many messages a subscriber may fall behind before
+// further messages to it are dropped.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	id string
+	ch chan Message
+}
+
+// broker fans messages out to subscribers. A subscriber that does not keep up
+// loses messages rather than blocking the session that produced them.
+type broker struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// Subscribe returns a channel receiving the events of session id, or of every
+// session when id is empty. Call cancel to stop receiving; it closes the
+// channel.
+func (h *Hub) Subscribe(id string) (msgs <-chan Message, cancel func()) {
+	sub := &subscriber{id: id, ch: make(chan Message, subscriberBuffer)}
+	h.events.mu.Lock()
+	if h.events.subs == nil {
+		h.events.subs = make(map[*subscriber]struct{})
+	}
+	h.events.subs[sub] = struct{}{}
+	h.events.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			h.events.mu.Lock()
+			delete(h.events.subs, sub)
+			h.events.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// publish broadcasts events of the session in e to its subscribers.
+func (h *Hub) publish(id string, e *entry, events []types.Event) {
+	if len(events) == 0 {
+		return
+	}
+	h.events.mu.Lock()
+	defer h.events.mu.Unlock()
+	for _, ev := range events {
+		msg := Message{SessionID: id, Dir: e.dir, Kind: eventKind(ev.Action), Phase: e.s.Phase, Event: ev}
+		for sub := range h.events.subs {
+			if sub.id != "" && sub.id != id {
+				continue
+			}
+			select {
+			case sub.ch <- msg:
+			default:
+			}
+		}
+	}
+}
+
+// eventKind groups an event action for subscribers.
+func eventKind(action string) string {
+	switch {
+	case strings.HasPrefix(action, "phase_"), action == "complete", action == "init":
+		return "phase"
+	case strings.HasPrefix(action, "test"), action == "mutation_run":
+		return "test"
+	case strings.HasPrefix(action, "spec"), strings.HasPrefix(action, "criteria_"):
+		return "spec"
+	case strings.HasPrefix(action, "reflection_"):
+		return "reflection"
+	}
+	return "other"
+}
+
+// newEvents returns the events of next that were not yet in prev. History only
+// grows by appending, but a history budget may drop or fold old events, so the
+// last known event is looked up rather than assumed to stay at its index.
+func newEvents(prev, next []types.Event) []types.Event {
+	if len(prev) == 0 {
+		return next
+	}
+	last := prev[len(prev)-1]
+	for i := len(next) - 1; i >= 0; i-- {
+		if reflect.DeepEqual(next[i], last) {
+			return next[i+1:]
+		}
+	}
+	var added []types.Event
+	for _, ev := range next {
+		if ev.Timestamp > last.Timestamp {
+			added = append(added, ev)
+		}
+	}
+	return added
+}
+
+// Refresh reloads a session whose file was changed outside the hub, such as by
+// a CLI command, and broadcasts the events it gained.
+func (h *Hub) Refresh(id string) error {
+	e := h.get(id)
+	if e == nil {
+		return ErrNotFound
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return h.refresh(id, e)
+}
+
+// refresh is Refresh with e's lock held.
+func (h *Hub) refresh(id string, e *entry) error {
+	mod := fileModTime(e.dir)
+	if mod.Equal(e.modTime) {
+		return nil
+	}
+	s, err := session.LoadOrFail(e.dir)
+	if err != nil {
+		return err
+	}
+	prev := e.s.History
+	e.s, e.modTime = s, mod
+	h.publish(id, e, newEvents(prev, s.History))
+	return nil
+}
+
+// Watch refreshes every registered session each interval until ctx is done,
+// so changes made through the CLI reach subscribers too.
+func (h *Hub) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, info := range h.List() {
+				_ = h.Refresh(info.ID)
+			}
+		}
+	}
+}
+
+// fileModTime returns the modification time of the session file in dir, or
+// the zero time when it cannot be read.
+func fileModTime(dir string) time.Time {
+	info, err := os.Stat(session.FilePath(dir))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
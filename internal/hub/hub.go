@@ -0,0 +1,201 @@
+package hub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// ErrNotFound is returned for session IDs that are not registered.
+var ErrNotFound = errors.New("session not registered")
+
+// Hub holds the sessions of several project directories in memory. Each
+// session has its own lock, so agents working in different repositories never
+// wait on each other, and every change is written through to the project's
+// session file before it becomes visible.
+type Hub struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	events  broker
+}
+
+type entry struct {
+	mu  sync.Mutex
+	dir string
+	s   *types.Session
+	// modTime is the session file's modification time when it was last
+	// loaded or saved by the hub; a different time means it changed outside.
+	modTime time.Time
+}
+
+// Info identifies a registered session. Stalled is set when the session has
+// seen no event or heartbeat within its stall window.
+type Info struct {
+	ID           string      `json:"id"`
+	Dir          string      `json:"dir"`
+	Phase        types.Phase `json:"phase"`
+	LastActivity string      `json:"last_activity,omitempty"`
+	Stalled      bool        `json:"stalled"`
+}
+
+// New returns an empty hub.
+func New() *Hub {
+	return &Hub{entries: make(map[string]*entry)}
+}
+
+// ID returns the stable session ID for a project directory.
+func ID(dir string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(dir)))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Register loads the session in dir and starts serving it. Registering a
+// directory again returns the existing entry without reloading it.
+func (h *Hub) Register(dir string) (Info, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return Info{}, fmt.Errorf("resolving %s: %w", dir, err)
+	}
+	id := ID(abs)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if e, ok := h.entries[id]; ok {
+		return e.info(id), nil
+	}
+	s, err := session.LoadOrFail(abs)
+	if err != nil {
+		return Info{}, fmt.Errorf("%s: %w", abs, err)
+	}
+	e := &entry{dir: abs, s: s, modTime: fileModTime(abs)}
+	h.entries[id] = e
+	return e.info(id), nil
+}
+
+// Unregister stops serving a session. The session file is left as is.
+func (h *Hub) Unregister(id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.entries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(h.entries, id)
+	return nil
+}
+
+// List returns the registered sessions ordered by directory.
+func (h *Hub) List() []Info {
+	h.mu.RLock()
+	ids := make([]string, 0, len(h.entries))
+	for id := range h.entries {
+		ids = append(ids, id)
+	}
+	h.mu.RUnlock()
+
+	infos := make([]Info, 0, len(ids))
+	for _, id := range ids {
+		if e := h.get(id); e != nil {
+			e.mu.Lock()
+			infos = append(infos, e.info(id))
+			e.mu.Unlock()
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Dir < infos[j].Dir })
+	return infos
+}
+
+// Info returns the identity and liveness of one registered session.
+func (h *Hub) Info(id string) (Info, error) {
+	e := h.get(id)
+	if e == nil {
+		return Info{}, ErrNotFound
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.info(id), nil
+}
+
+// View calls fn with the session under its lock. fn must not retain or
+// modify the session.
+func (h *Hub) View(id string, fn func(*types.Session)) error {
+	e := h.get(id)
+	if e == nil {
+		return ErrNotFound
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn(e.s)
+	return nil
+}
+
+// Update applies fn to a copy of the session under its lock and, if fn
+// succeeds, saves the copy to the project's session file before replacing the
+// in-memory session. A failed fn or save leaves the session unchanged. The
+// session is first reloaded if its file changed outside the hub, and the
+// events fn adds are broadcast to subscribers.
+func (h *Hub) Update(id string, fn func(*types.Session) error) error {
+	e := h.get(id)
+	if e == nil {
+		return ErrNotFound
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := h.refresh(id, e); err != nil {
+		return err
+	}
+	next, err := clone(e.s)
+	if err != nil {
+		return err
+	}
+	if err := fn(next); err != nil {
+		return err
+	}
+	added := slices.Clone(newEvents(e.s.History, next.History))
+	if err := session.Save(e.dir, next); err != nil {
+		return err
+	}
+	e.s, e.modTime = next, fileModTime(e.dir)
+	h.publish(id, e, added)
+	return nil
+}
+
+func (h *Hub) get(id string) *entry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.entries[id]
+}
+
+func (e *entry) info(id string) Info {
+	return Info{
+		ID:           id,
+		Dir:          e.dir,
+		Phase:        e.s.Phase,
+		LastActivity: e.s.LastActivity(),
+		Stalled:      e.s.Stalled(time.Now()),
+	}
+}
+
+// clone deep-copies a session through its JSON form, the same form it is
+// persisted in.
+func clone(s *types.Session) (*types.Session, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("encoding session: %w", err)
+	}
+	var c types.Session
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decoding session: %w", err)
+	}
+	return &c, nil
+}
@@ -0,0 +1,459 @@
+package hub
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/session"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func newProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := session.Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	return dir
+}
+
+func TestRegister(t *testing.T) {
+	h := New()
+	dir := newProject(t)
+
+	info, err := h.Register(dir)
+	if err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if info.ID != ID(dir) || info.Phase != types.PhaseRed {
+		t.Errorf("Register() = %+v, want ID %s in red", info, ID(dir))
+	}
+	if again, _ := h.Register(dir); again.ID != info.ID || len(h.List()) != 1 {
+		t.Errorf("registering twice should reuse the entry, got %+v", h.List())
+	}
+	if _, err := h.Register(t.TempDir()); err == nil {
+		t.Error("registering a directory without a session should fail")
+	}
+}
+
+func TestUpdateConcurrentWritesThrough(t *testing.T) {
+	h := New()
+	dirs := []string{newProject(t), newProject(t)}
+	for _, dir := range dirs {
+		if _, err := h.Register(dir); err != nil {
+			t.Fatalf("Register() error: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, dir := range dirs {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				if err := h.Update(id, func(s *types.Session) error {
+					s.AddSpec("spec")
+					return nil
+				}); err != nil {
+					t.Errorf("Update() error: %v", err)
+				}
+			}(ID(dir))
+		}
+	}
+	wg.Wait()
+
+	for _, dir := range dirs {
+		loaded, err := session.Load(dir)
+		if err != nil {
+			t.Fatalf("failed to load session: %v", err)
+		}
+		if len(loaded.Specs) != 20 {
+			t.Errorf("%s has %d specs on disk, want 20", dir, len(loaded.Specs))
+		}
+	}
+}
+
+func TestUpdateFailureLeavesSessionUnchanged(t *testing.T) {
+	h := New()
+	dir := newProject(t)
+	info, _ := h.Register(dir)
+
+	err := h.Update(info.ID, func(s *types.Session) error {
+		s.AddSpec("half-done")
+		return errors.New("boom")
+	})
+	if err == nil {
+		t.Fatal("Update() should return fn's error")
+	}
+	_ = h.View(info.ID, func(s *types.Session) {
+		if len(s.Specs) != 0 {
+			t.Errorf("failed update leaked into memory: %+v", s.Specs)
+		}
+	})
+	if err := h.Update("missing", func(*types.Session) error { return nil }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update() on unknown ID = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := New()
+	dir := newProject(t)
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/sessions", "application/json", strings.NewReader(`{"dir": "`+dir+`"}`))
+	if err != nil {
+		t.Fatalf("register request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("register status = %d, want 201", resp.StatusCode)
+	}
+
+	id := ID(dir)
+	resp, err = http.Post(srv.URL+"/sessions/"+id+"/specs", "application/json", strings.NewReader(`{"descriptions": ["returns 404"]}`))
+	if err != nil {
+		t.Fatalf("add specs request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("add specs status = %d, want 201", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/sessions/" + id + "/guide")
+	if err != nil {
+		t.Fatalf("guide request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"returns 404"`) {
+		t.Errorf("guide should list the added spec, got:\n%s", body)
+	}
+
+	resp, err = http.Get(srv.URL + "/sessions/unknown")
+	if err != nil {
+		t.Fatalf("get request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHeartbeatClearsStall(t *testing.T) {
+	h := New()
+	dir := newProject(t)
+	s, _ := session.Load(dir)
+	s.StallAfter = "1m"
+	s.History = []types.Event{{Action: "spec_add", Timestamp: "2020-01-01T00:00:00Z"}}
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	id := ID(dir)
+	if _, err := h.Register(dir); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if info := h.List()[0]; !info.Stalled || info.LastActivity != "2020-01-01T00:00:00Z" {
+		t.Errorf("List() = %+v, want a stalled session", info)
+	}
+
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/sessions/"+id+"/heartbeat", "application/json", nil)
+	if err != nil {
+		t.Fatalf("heartbeat request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"stalled": false`) {
+		t.Errorf("heartbeat = %d %s, want 200 and not stalled", resp.StatusCode, body)
+	}
+	if saved, _ := session.Load(dir); saved.LastHeartbeat == "" {
+		t.Error("heartbeat should be written through to the session file")
+	}
+}
+
+// dialEvents opens a WebSocket to path on srv and returns the connection and a
+// reader positioned after the handshake.
+func dialEvents(t *testing.T, srv *httptest.Server, path string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", path, srv.Listener.Addr())
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("reading handshake failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Sec-WebSocket-Accept = %q", got)
+	}
+	return conn, r
+}
+
+// readMessage reads one unmasked text frame sent by the server.
+func readMessage(t *testing.T, conn net.Conn, r *bufio.Reader) Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		t.Fatalf("reading frame failed: %v", err)
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		ext := make([]byte, 2)
+		io.ReadFull(r, ext)
+		n = int(ext[0])<<8 | int(ext[1])
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("reading payload failed: %v", err)
+	}
+	var msg Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		t.Fatalf("decoding message %q: %v", payload, err)
+	}
+	return msg
+}
+
+func TestEventsStream(t *testing.T) {
+	h := New()
+	dir := newProject(t)
+	id := ID(dir)
+	if _, err := h.Register(dir); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+
+	conn, r := dialEvents(t, srv, "/events?session="+id)
+	// The subscription is made after the handshake; wait until it exists.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		h.events.mu.Lock()
+		n := len(h.events.subs)
+		h.events.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	resp, err := http.Post(srv.URL+"/sessions/"+id+"/specs", "application/json", strings.NewReader(`{"descriptions": ["returns 404"]}`))
+	if err != nil {
+		t.Fatalf("add specs request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	msg := readMessage(t, conn, r)
+	if msg.SessionID != id || msg.Kind != "spec" || msg.Event.Action != "spec_add" {
+		t.Errorf("message = %+v, want a spec_add event of %s", msg, id)
+	}
+}
+
+func TestEventsUnknownSession(t *testing.T) {
+	srv := httptest.NewServer(New().Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/events?session=unknown")
+	if err != nil {
+		t.Fatalf("events request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestRefreshPublishesExternalChanges(t *testing.T) {
+	h := New()
+	dir := newProject(t)
+	id := ID(dir)
+	if _, err := h.Register(dir); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	msgs, cancel := h.Subscribe("")
+	defer cancel()
+
+	// A CLI command changes the file behind the hub's back.
+	s, _ := session.Load(dir)
+	s.SetPhase(types.PhaseGreen)
+	s.AddEvent("phase_next", func(e *types.Event) { e.From, e.To = "red", "green" })
+	if err := session.Save(dir, s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	os.Chtimes(session.FilePath(dir), time.Now(), time.Now().Add(time.Second))
+
+	if err := h.Refresh(id); err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	select {
+	case msg := <-msgs:
+		if msg.Kind != "phase" || msg.Phase != types.PhaseGreen || msg.Event.To != "green" {
+			t.Errorf("message = %+v, want the phase change to green", msg)
+		}
+	default:
+		t.Fatal("Refresh() should publish the new event")
+	}
+	if info, _ := h.Info(id); info.Phase != types.PhaseGreen {
+		t.Errorf("Info().Phase = %s, want the reloaded green", info.Phase)
+	}
+}
+
+func TestNewEvents(t *testing.T) {
+	a := types.Event{Action: "init", Timestamp: "2024-01-01T00:00:00Z"}
+	b := types.Event{Action: "spec_add", Timestamp: "2024-01-01T00:00:01Z"}
+	c := types.Event{Action: "spec_picked", Timestamp: "2024-01-01T00:00:02Z"}
+
+	if got := newEvents([]types.Event{a, b}, []types.Event{a, b, c}); len(got) != 1 || got[0].Action != "spec_picked" {
+		t.Errorf("appended: newEvents() = %+v, want [spec_picked]", got)
+	}
+	if got := newEvents([]types.Event{a, b}, []types.Event{b, c}); len(got) != 1 || got[0].Action != "spec_picked" {
+		t.Errorf("truncated: newEvents() = %+v, want [spec_picked]", got)
+	}
+	if got := newEvents([]types.Event{a, b}, []types.Event{a, b}); len(got) != 0 {
+		t.Errorf("unchanged: newEvents() = %+v, want none", got)
+	}
+}
+
+func TestEventsRejectsCrossOriginUpgrade(t *testing.T) {
+	srv := httptest.NewServer(New().Handler())
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	for origin, want := range map[string]int{
+		"https://evil.example":        http.StatusForbidden,
+		"http://evil.example:" + port: http.StatusForbidden,
+		"http://localhost":            http.StatusForbidden,
+		"file://":                     http.StatusForbidden,
+		"null":                        http.StatusForbidden,
+		srv.URL:                       http.StatusSwitchingProtocols,
+		"http://localhost:" + port:    http.StatusSwitchingProtocols,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("events request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Origin %s: status = %d, want %d", origin, resp.StatusCode, want)
+		}
+	}
+}
+
+// TestEventsRejectsRebindingOrigin covers a page whose name was rebound to the
+// hub's address: its Origin and Host agree, but neither is the hub's.
+func TestEventsRejectsRebindingOrigin(t *testing.T) {
+	h := New()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://evil.example:7878/events", nil)
+	req.Header.Set("Origin", "http://evil.example:7878")
+	local, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:7878")
+	req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, local))
+	if sameOrigin(req) {
+		t.Error("sameOrigin() should refuse an Origin that only matches the Host header")
+	}
+	h.handleEvents(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("events status = %d, want 403", rec.Code)
+	}
+}
+
+func TestEventsClosesOnUnmaskedFrame(t *testing.T) {
+	srv := httptest.NewServer(New().Handler())
+	defer srv.Close()
+
+	conn, r := dialEvents(t, srv, "/events")
+	// A ping without the mask bit every client frame must carry.
+	if _, err := conn.Write([]byte{0x89, 0x00}); err != nil {
+		t.Fatalf("writing frame failed: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	frame := make([]byte, 4)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		t.Fatalf("reading close frame failed: %v", err)
+	}
+	if frame[0] != 0x88 || frame[2] != 0x03 || frame[3] != 0xEA {
+		t.Errorf("frame = % x, want a close with status 1002", frame)
+	}
+}
+
+func TestMutatingEndpointsRequireJSON(t *testing.T) {
+	h := New()
+	dir := newProject(t)
+	id := ID(dir)
+	if _, err := h.Register(dir); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+
+	for path, body := range map[string]string{
+		"/sessions":                      `{"dir": "` + t.TempDir() + `"}`,
+		"/sessions/" + id + "/specs":     `{"descriptions": ["returns 404"]}`,
+		"/sessions/" + id + "/heartbeat": "",
+	} {
+		resp, err := http.Post(srv.URL+path, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnsupportedMediaType {
+			t.Errorf("POST %s as text/plain: status = %d, want 415", path, resp.StatusCode)
+		}
+	}
+	if s, _ := session.Load(dir); len(s.Specs) != 0 {
+		t.Errorf("a refused request must not add specs, got %d", len(s.Specs))
+	}
+}
+
+func TestHandlerRejectsForeignHost(t *testing.T) {
+	srv := httptest.NewServer(New().Handler())
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	for host, want := range map[string]int{
+		"127.0.0.1:" + port:              http.StatusOK,
+		"localhost:" + port:              http.StatusOK,
+		"LOCALHOST:" + port:              http.StatusOK,
+		"evil.example:" + port:           http.StatusForbidden,
+		"evil.example":                   http.StatusForbidden,
+		"localhost:1":                    http.StatusForbidden,
+		"localhost":                      http.StatusForbidden,
+		"127.0.0.1.evil.example:" + port: http.StatusForbidden,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/sessions", nil)
+		req.Host = host
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request with Host %s failed: %v", host, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Host %s: status = %d, want %d", host, resp.StatusCode, want)
+		}
+	}
+}
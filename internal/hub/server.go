@@ -0,0 +1,211 @@
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/guide"
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Handler returns the hub's JSON HTTP API:
+//
+//	POST   /sessions              register {"dir": "..."}
+//	GET    /sessions              list registered sessions
+//	GET    /sessions/{id}         full session state
+//	DELETE /sessions/{id}         stop serving a session
+//	GET    /sessions/{id}/guide   guidance, as 'tdd-ai guide --format json'
+//	POST   /sessions/{id}/specs   add specs {"descriptions": ["..."]}
+//	POST   /sessions/{id}/heartbeat  record that the session's agent is alive
+//	GET    /events[?session={id}] WebSocket stream of session events
+//
+// POST requests must be sent as application/json, and every request must be
+// addressed to the hub itself; see allowHost.
+func (h *Hub) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sessions", requireJSON(h.handleRegister))
+	mux.HandleFunc("GET /sessions", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, h.List())
+	})
+	mux.HandleFunc("GET /sessions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		h.view(w, r, func(s *types.Session) any { return s })
+	})
+	mux.HandleFunc("DELETE /sessions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := h.Unregister(r.PathValue("id")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /sessions/{id}/guide", func(w http.ResponseWriter, r *http.Request) {
+		h.view(w, r, func(s *types.Session) any { return guide.Generate(s) })
+	})
+	mux.HandleFunc("POST /sessions/{id}/specs", requireJSON(h.handleAddSpecs))
+	mux.HandleFunc("POST /sessions/{id}/heartbeat", requireJSON(func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		err := h.Update(id, func(s *types.Session) error {
+			s.Heartbeat()
+			return nil
+		})
+		var info Info
+		if err == nil {
+			info, err = h.Info(id)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	}))
+	mux.HandleFunc("GET /events", h.handleEvents)
+	return allowHost(mux)
+}
+
+// allowHost refuses requests whose Host header names anything other than the
+// address the connection was accepted on or a loopback name at its port. A
+// page on another site can rebind its own host name to 127.0.0.1 and then
+// talk to the hub as a same-origin page, sending JSON and reading replies;
+// its requests still carry that site's name in Host, which is what gives it
+// away.
+func allowHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(r, r.Host, "80") {
+			writeJSON(w, http.StatusForbidden, errorBody(fmt.Sprintf("Host %q is not allowed", r.Host)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether host, with defaultPort when it has none, names
+// the local address r arrived on: its IP or a loopback name, at its port.
+func allowedHost(r *http.Request, host, defaultPort string) bool {
+	local, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return false
+	}
+	localHost, localPort, err := net.SplitHostPort(local.String())
+	if err != nil {
+		return false
+	}
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = strings.Trim(host, "[]"), defaultPort
+	}
+	if port != localPort {
+		return false
+	}
+	if strings.EqualFold(name, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(name)
+	return ip != nil && (ip.IsLoopback() || ip.Equal(net.ParseIP(localHost)))
+}
+
+// requireJSON refuses requests whose body is not declared as JSON. A web page
+// can send form or text/plain POSTs to another site without asking, but must
+// ask before sending JSON, which the hub never allows; so a page on another
+// origin cannot register directories or write to sessions. That says nothing
+// about a page that rebinds its name to the hub's address and so shares its
+// origin; allowHost turns those away.
+func requireJSON(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+			writeJSON(w, http.StatusUnsupportedMediaType, errorBody("Content-Type must be application/json"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *Hub) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Dir string `json:"dir"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Dir == "" {
+		writeJSON(w, http.StatusBadRequest, errorBody(`request body must be {"dir": "<project directory>"}`))
+		return
+	}
+	info, err := h.Register(req.Dir)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorBody(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusCreated, info)
+}
+
+func (h *Hub) handleAddSpecs(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Descriptions []string `json:"descriptions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Descriptions) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorBody(`request body must be {"descriptions": ["..."]}`))
+		return
+	}
+	var added []types.Spec
+	err := h.Update(r.PathValue("id"), func(s *types.Session) error {
+		for _, desc := range req.Descriptions {
+			s.AddSpec(desc)
+			added = append(added, s.Specs[len(s.Specs)-1])
+		}
+		s.AddEvent("spec_add", func(e *types.Event) {
+			e.SpecCount = len(req.Descriptions)
+		})
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, added)
+}
+
+// view renders part of a session, chosen by pick, under the session's lock.
+func (h *Hub) view(w http.ResponseWriter, r *http.Request, pick func(*types.Session) any) {
+	var data []byte
+	var encErr error
+	err := h.View(r.PathValue("id"), func(s *types.Session) {
+		data, encErr = json.MarshalIndent(pick(s), "", "  ")
+	})
+	if err == nil {
+		err = encErr
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(data, '\n'))
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func errorBody(msg string) errorResponse {
+	return errorResponse{Error: msg}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, errorBody(err.Error()))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encoding response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
@@ -0,0 +1,298 @@
+package hub
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The subset of RFC 6455 the event stream needs: the server only sends text
+// frames, and reads client frames just to answer pings and notice closes.
+// Client frames are still checked against the protocol: they must be masked,
+// use no reserved bits or opcodes, keep control frames whole, and continue
+// only a fragmented message. A violation closes the connection with status
+// 1002.
+
+// websocketGUID is appended to the client key to derive Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// errProtocol marks a client frame that breaks RFC 6455.
+var errProtocol = errors.New("websocket protocol error")
+
+// closeProtocolError is the close status sent when a client breaks the
+// protocol.
+const closeProtocolError = 1002
+
+// maxControlPayload is the largest payload RFC 6455 allows a control frame.
+const maxControlPayload = 125
+
+// maxClientFrame bounds the payload of frames read from clients, which only
+// ever need to send control frames.
+const maxClientFrame = 1 << 16
+
+// writeTimeout bounds how long a send to a stalled client may block.
+const writeTimeout = 10 * time.Second
+
+// handleEvents upgrades the request to a WebSocket and streams the events of
+// every session, or of the session named by ?session=<id>, as JSON messages
+// until the client goes away.
+func (h *Hub) handleEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("session")
+	if id != "" && h.get(id) == nil {
+		writeError(w, ErrNotFound)
+		return
+	}
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	msgs, cancel := h.Subscribe(id)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.readControl()
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			if err := conn.writeFrame(opText, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsConn is a server-side WebSocket connection.
+type wsConn struct {
+	net.Conn
+	r *bufio.Reader
+	// wmu serializes writes from the event loop and the control reader.
+	wmu sync.Mutex
+}
+
+// upgradeWebSocket performs the opening handshake and takes over the
+// connection. On failure it has already written an error response.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !sameOrigin(r) {
+		writeJSON(w, http.StatusForbidden, errorBody("cross-origin WebSocket requests are not allowed"))
+		return nil, errors.New("cross-origin websocket request")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		writeJSON(w, http.StatusBadRequest, errorBody("expected a WebSocket upgrade request"))
+		return nil, errors.New("not a websocket request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeJSON(w, http.StatusUpgradeRequired, errorBody("unsupported WebSocket version"))
+		return nil, errors.New("unsupported websocket version")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorBody("connection cannot be upgraded"))
+		return nil, errors.New("response writer cannot hijack")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{Conn: conn, r: rw.Reader}, nil
+}
+
+// sameOrigin reports whether the request comes from a page served by the hub
+// at one of the hosts allowHost accepts, or from a client that is not a
+// browser and sends no Origin. Browsers do not apply CORS to WebSockets, so
+// without this check any web page could read the event stream of a hub
+// listening on localhost. Comparing Origin with the Host header instead would
+// let a page that rebinds its own name to the hub pass, as both carry it.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "http":
+		return allowedHost(r, u.Host, "80")
+	case "https":
+		return allowedHost(r, u.Host, "443")
+	}
+	return false
+}
+
+// headerContains reports whether the comma-separated header name lists token,
+// ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeFrame sends one unfragmented, unmasked frame.
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	header := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := c.Write(append(header, payload...))
+	return err
+}
+
+// readControl reads client frames until the connection closes, answering
+// pings and echoing a close. Data frames from the client are ignored.
+func (c *wsConn) readControl() {
+	fragmented := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err == nil {
+			switch {
+			case op == opContinuation && !fragmented:
+				err = fmt.Errorf("%w: continuation frame outside a fragmented message", errProtocol)
+			case (op == opText || op == opBinary) && fragmented:
+				err = fmt.Errorf("%w: new message before the fragmented one ended", errProtocol)
+			}
+		}
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				c.closeWith(closeProtocolError)
+			}
+			return
+		}
+		if op < opClose {
+			fragmented = !fin
+		}
+		switch op {
+		case opPing:
+			if c.writeFrame(opPong, payload) != nil {
+				return
+			}
+		case opClose:
+			_ = c.writeFrame(opClose, payload)
+			return
+		}
+	}
+}
+
+// readFrame reads one client frame, unmasking its payload. Frames breaking
+// the protocol return an error wrapping errProtocol.
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0F
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: reserved bits set", errProtocol)
+	}
+	switch op {
+	case opContinuation, opText, opBinary, opClose, opPing, opPong:
+	default:
+		return false, 0, nil, fmt.Errorf("%w: unknown opcode %#x", errProtocol, op)
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, fmt.Errorf("%w: client frame is not masked", errProtocol)
+	}
+	n := uint64(head[1] & 0x7F)
+	if op >= opClose && (!fin || n > maxControlPayload) {
+		return false, 0, nil, fmt.Errorf("%w: control frame is fragmented or too long", errProtocol)
+	}
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxClientFrame {
+		return false, 0, nil, fmt.Errorf("%w: client frame of %d bytes exceeds %d", errProtocol, n, maxClientFrame)
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// closeWith sends a close frame with status code.
+func (c *wsConn) closeWith(code uint16) {
+	_ = c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, code))
+}
@@ -0,0 +1,83 @@
+package loopdetect
+
+import (
+	"fmt"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// BlockedThreshold is how many blocked 'phase next' attempts in a row count as a loop.
+const BlockedThreshold = 10
+
+// FlipThreshold is how many consecutive 'phase set' calls bouncing between the
+// same two phases count as a loop.
+const FlipThreshold = 4
+
+// Intervention is the instruction emitted when a loop is detected.
+const Intervention = "Stop and re-read the spec; consider splitting it with 'tdd-ai spec split' before trying again."
+
+// Detect scans the session history for agent thrash and returns the first loop
+// found, or nil.
+func Detect(history []types.Event) *types.Loop {
+	if n := trailingBlocked(history); n >= BlockedThreshold {
+		return &types.Loop{
+			Rule:    "repeated_blocked_advance",
+			Message: fmt.Sprintf("'phase next' was blocked %d times in a row", n),
+		}
+	}
+	if a, b, ok := trailingFlips(history); ok {
+		return &types.Loop{
+			Rule:    "phase_set_flip_flop",
+			Message: fmt.Sprintf("'phase set' keeps alternating between %s and %s", a, b),
+		}
+	}
+	return nil
+}
+
+// progress reports whether an event represents forward progress that ends a streak.
+func progress(e types.Event) bool {
+	switch e.Action {
+	case "phase_next", "spec_picked", "complete", "init":
+		return true
+	}
+	return false
+}
+
+// trailingBlocked counts phase_next_blocked events since the last progress event.
+func trailingBlocked(history []types.Event) int {
+	n := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		e := history[i]
+		if progress(e) {
+			break
+		}
+		if e.Action == "phase_next_blocked" {
+			n++
+		}
+	}
+	return n
+}
+
+// trailingFlips reports whether the most recent phase_set events since the last
+// progress event alternate between two phases, returning those phases.
+func trailingFlips(history []types.Event) (string, string, bool) {
+	var targets []string
+	for i := len(history) - 1; i >= 0 && len(targets) < FlipThreshold; i-- {
+		e := history[i]
+		if progress(e) {
+			break
+		}
+		if e.Action == "phase_set" {
+			targets = append(targets, e.To)
+		}
+	}
+	if len(targets) < FlipThreshold || targets[0] == targets[1] {
+		return "", "", false
+	}
+	for i := 2; i < len(targets); i++ {
+		if targets[i] != targets[i-2] {
+			return "", "", false
+		}
+	}
+	return targets[1], targets[0], true
+}
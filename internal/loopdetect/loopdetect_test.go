@@ -0,0 +1,70 @@
+package loopdetect
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func events(actions ...string) []types.Event {
+	var h []types.Event
+	for _, a := range actions {
+		h = append(h, types.Event{Action: a})
+	}
+	return h
+}
+
+func repeat(action string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = action
+	}
+	return out
+}
+
+func phaseSets(targets ...string) []types.Event {
+	var h []types.Event
+	for _, to := range targets {
+		h = append(h, types.Event{Action: "phase_set", To: to})
+	}
+	return h
+}
+
+func TestDetect(t *testing.T) {
+	blocked := repeat("phase_next_blocked", BlockedThreshold)
+	// interrupted returns the blocked streak with action inserted halfway through.
+	interrupted := func(action string) []types.Event {
+		h := events(blocked[:BlockedThreshold/2]...)
+		h = append(h, types.Event{Action: action})
+		return append(h, events(blocked[BlockedThreshold/2:]...)...)
+	}
+
+	tests := []struct {
+		name     string
+		history  []types.Event
+		wantRule string
+	}{
+		{"empty history", nil, ""},
+		{"blocked streak at threshold", events(append([]string{"spec_picked"}, blocked...)...), "repeated_blocked_advance"},
+		{"test runs do not break a blocked streak", interrupted("test_run"), "repeated_blocked_advance"},
+		{"blocked streak below threshold", events(repeat("phase_next_blocked", BlockedThreshold-1)...), ""},
+		{"successful advance resets streak", interrupted("phase_next"), ""},
+		{"red/green flip-flop", phaseSets("green", "red", "green", "red"), "phase_set_flip_flop"},
+		{"phase set moving forward", phaseSets("green", "refactor", "done", "red"), ""},
+		{"too few phase sets", phaseSets("green", "red", "green"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.history)
+			if tt.wantRule == "" {
+				if got != nil {
+					t.Errorf("Detect() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Rule != tt.wantRule {
+				t.Errorf("Detect() = %+v, want rule %q", got, tt.wantRule)
+			}
+		})
+	}
+}
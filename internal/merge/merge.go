@@ -0,0 +1,161 @@
+package merge
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Side names which session a merged value was taken from.
+type Side string
+
+const (
+	Ours   Side = "ours"
+	Theirs Side = "theirs"
+)
+
+// Result summarizes what a merge changed.
+type Result struct {
+	SpecsAdded  int         `json:"specs_added"`
+	SpecsShared int         `json:"specs_shared"`
+	EventsAdded int         `json:"events_added"`
+	Remapped    map[int]int `json:"remapped_ids,omitempty"`
+	Phase       types.Phase `json:"phase"`
+	PhaseFrom   Side        `json:"phase_from"`
+}
+
+// ParseSide validates a --phase override. An empty string means no override.
+func ParseSide(v string) (Side, error) {
+	switch Side(v) {
+	case "", Ours, Theirs:
+		return Side(v), nil
+	}
+	return "", fmt.Errorf("invalid side %q: must be ours or theirs", v)
+}
+
+// Merge folds theirs into ours in place. Specs are unioned: a spec of theirs
+// with the same slug or description as one of ours is treated as shared, and
+// the rest are appended with fresh IDs. Their history events not already in
+// ours are appended, with spec IDs remapped. Phase state comes from whichever
+// session saw activity last, unless phaseFrom names a side explicitly.
+func Merge(ours, theirs *types.Session, phaseFrom Side) Result {
+	var res Result
+	ids := make(map[int]int, len(theirs.Specs))
+
+	if phaseFrom == "" {
+		phaseFrom = Ours
+		if lastActivity(theirs) > lastActivity(ours) {
+			phaseFrom = Theirs
+		}
+	}
+
+	for _, ts := range theirs.Specs {
+		if sp := findShared(ours, ts); sp != nil {
+			ids[ts.ID] = sp.ID
+			res.SpecsShared++
+			// A spec closed on either branch stays closed after the merge
+			if sp.Status == types.SpecStatusActive && ts.Status != types.SpecStatusActive {
+				sp.Status = ts.Status
+				sp.CompletedAt = ts.CompletedAt
+			}
+			continue
+		}
+		ids[ts.ID] = ours.NextID
+		if ts.ID != ours.NextID {
+			if res.Remapped == nil {
+				res.Remapped = map[int]int{}
+			}
+			res.Remapped[ts.ID] = ours.NextID
+		}
+		ours.NextID++
+	}
+
+	for _, ts := range theirs.Specs {
+		if ids[ts.ID] == 0 || containsID(ours, ids[ts.ID]) {
+			continue
+		}
+		ts.ID = ids[ts.ID]
+		ts.ParentID = ids[ts.ParentID]
+		ts.DiscoveredFrom = ids[ts.DiscoveredFrom]
+		ts.SplitInto = remapAll(ids, ts.SplitInto)
+		ours.Specs = append(ours.Specs, ts)
+		res.SpecsAdded++
+	}
+
+	for _, ev := range theirs.History {
+		ev.SpecID = ids[ev.SpecID]
+		ev.SpecIDs = remapAll(ids, ev.SpecIDs)
+		if hasEvent(ours.History, ev) {
+			continue
+		}
+		ours.History = append(ours.History, ev)
+		res.EventsAdded++
+	}
+
+	if phaseFrom == Theirs {
+		ours.Phase = theirs.Phase
+		ours.PhaseEnteredAt = theirs.PhaseEnteredAt
+		ours.Iteration = theirs.Iteration
+		ours.Reflections = theirs.Reflections
+		ours.LastTestResult = theirs.LastTestResult
+		ours.CurrentSpecID = nil
+		if theirs.CurrentSpecID != nil {
+			id := ids[*theirs.CurrentSpecID]
+			ours.CurrentSpecID = &id
+		}
+		ours.PickGroup = remapAll(ids, theirs.PickGroup)
+	}
+	res.Phase = ours.Phase
+	res.PhaseFrom = phaseFrom
+	return res
+}
+
+// findShared returns the spec in s that matches ts by slug or description.
+func findShared(s *types.Session, ts types.Spec) *types.Spec {
+	for i := range s.Specs {
+		sp := &s.Specs[i]
+		if (ts.Slug != "" && sp.Slug == ts.Slug) || sp.Description == ts.Description {
+			return sp
+		}
+	}
+	return nil
+}
+
+func containsID(s *types.Session, id int) bool {
+	for _, sp := range s.Specs {
+		if sp.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func remapAll(ids map[int]int, in []int) []int {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]int, len(in))
+	for i, id := range in {
+		out[i] = ids[id]
+	}
+	return out
+}
+
+func hasEvent(history []types.Event, ev types.Event) bool {
+	for _, h := range history {
+		if reflect.DeepEqual(h, ev) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastActivity returns the timestamp of the session's latest event. Timestamps
+// are RFC 3339 in UTC, so they compare correctly as strings.
+func lastActivity(s *types.Session) string {
+	if len(s.History) == 0 {
+		return ""
+	}
+	return s.History[len(s.History)-1].Timestamp
+}
@@ -0,0 +1,107 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func event(action, at string, specID int) types.Event {
+	return types.Event{Action: action, Timestamp: at, SpecID: specID}
+}
+
+func TestMergeUnionsSpecsAndRemapsIDs(t *testing.T) {
+	ours := types.NewSession()
+	ours.AddSpec("shared")
+	ours.AddSpec("ours only")
+
+	theirs := types.NewSession()
+	theirs.AddSpec("shared")
+	theirs.AddSpec("theirs only")
+	theirs.History = []types.Event{event("spec_pick", "2026-01-02T00:00:00Z", 2)}
+
+	res := Merge(ours, theirs, "")
+
+	if res.SpecsAdded != 1 || res.SpecsShared != 1 {
+		t.Fatalf("added=%d shared=%d, want 1 and 1", res.SpecsAdded, res.SpecsShared)
+	}
+	if len(ours.Specs) != 3 || ours.Specs[2].Description != "theirs only" || ours.Specs[2].ID != 3 {
+		t.Fatalf("expected theirs-only spec appended as ID 3, got %+v", ours.Specs)
+	}
+	if res.Remapped[2] != 3 {
+		t.Errorf("remapped = %v, want 2->3", res.Remapped)
+	}
+	if ours.NextID != 4 {
+		t.Errorf("NextID = %d, want 4", ours.NextID)
+	}
+	if ours.History[0].SpecID != 3 {
+		t.Errorf("event spec ID should be remapped to 3, got %d", ours.History[0].SpecID)
+	}
+}
+
+func TestMergeSkipsSharedHistory(t *testing.T) {
+	common := event("init", "2026-01-01T00:00:00Z", 0)
+	ours := types.NewSession()
+	ours.History = []types.Event{common, event("spec_add", "2026-01-02T00:00:00Z", 0)}
+	theirs := types.NewSession()
+	theirs.History = []types.Event{common, event("phase_next", "2026-01-03T00:00:00Z", 0)}
+
+	res := Merge(ours, theirs, "")
+
+	if res.EventsAdded != 1 || len(ours.History) != 3 {
+		t.Fatalf("expected one new event, got added=%d history=%d", res.EventsAdded, len(ours.History))
+	}
+}
+
+func TestMergeClosedSpecStaysClosed(t *testing.T) {
+	ours := types.NewSession()
+	ours.AddSpec("feature")
+	theirs := types.NewSession()
+	theirs.AddSpec("feature")
+	theirs.Specs[0].Status = types.SpecStatusCompleted
+
+	Merge(ours, theirs, "")
+
+	if ours.Specs[0].Status != types.SpecStatusCompleted {
+		t.Errorf("status = %s, want completed", ours.Specs[0].Status)
+	}
+}
+
+func TestMergePhaseFromLatestActivity(t *testing.T) {
+	ours := types.NewSession()
+	ours.History = []types.Event{event("phase_next", "2026-01-01T00:00:00Z", 0)}
+	theirs := types.NewSession()
+	theirs.Phase = types.PhaseRefactor
+	theirs.Iteration = 2
+	theirs.History = []types.Event{event("phase_next", "2026-01-05T00:00:00Z", 0)}
+
+	res := Merge(ours, theirs, "")
+
+	if res.PhaseFrom != Theirs || ours.Phase != types.PhaseRefactor || ours.Iteration != 2 {
+		t.Errorf("expected theirs' refactor phase to win, got from=%s phase=%s iteration=%d", res.PhaseFrom, ours.Phase, ours.Iteration)
+	}
+}
+
+func TestMergePhaseOverride(t *testing.T) {
+	ours := types.NewSession()
+	theirs := types.NewSession()
+	theirs.Phase = types.PhaseGreen
+	theirs.History = []types.Event{event("phase_next", "2026-01-05T00:00:00Z", 0)}
+
+	res := Merge(ours, theirs, Ours)
+
+	if res.PhaseFrom != Ours || ours.Phase != types.PhaseRed {
+		t.Errorf("override should keep our red phase, got from=%s phase=%s", res.PhaseFrom, ours.Phase)
+	}
+}
+
+func TestParseSide(t *testing.T) {
+	for _, v := range []string{"", "ours", "theirs"} {
+		if _, err := ParseSide(v); err != nil {
+			t.Errorf("ParseSide(%q) error: %v", v, err)
+		}
+	}
+	if _, err := ParseSide("mine"); err == nil {
+		t.Error("ParseSide(mine) should fail")
+	}
+}
@@ -0,0 +1,37 @@
+package mutation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// percentPattern matches a percentage such as "85%" or "72.4 %".
+var percentPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+
+// labelPattern matches the words mutation tools use to label their score.
+var labelPattern = regexp.MustCompile(`(?i)\b(?:score|msi)\b`)
+
+// ParseScore extracts a mutation score (0-100) from mutation tool output. Only
+// percentages on lines labelled "score" or "MSI" count, so coverage or progress
+// figures are never mistaken for the score; the last such line wins. Returns
+// false when no score can be found.
+func ParseScore(output string) (float64, bool) {
+	var raw string
+	for _, line := range strings.Split(output, "\n") {
+		if !labelPattern.MatchString(line) {
+			continue
+		}
+		if m := percentPattern.FindAllStringSubmatch(line, -1); len(m) > 0 {
+			raw = m[len(m)-1][1]
+		}
+	}
+	if raw == "" {
+		return 0, false
+	}
+	score, err := strconv.ParseFloat(raw, 64)
+	if err != nil || score > 100 {
+		return 0, false
+	}
+	return score, true
+}
@@ -0,0 +1,29 @@
+package mutation
+
+import "testing"
+
+func TestParseScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   float64
+		ok     bool
+	}{
+		{"stryker style", "Ran 120 mutants\nMutation score: 82.35%\n", 82.35, true},
+		{"infection MSI", "Mutation Score Indicator (MSI): 67%\nCovered Code MSI: 90%\n", 90, true},
+		{"score line wins over later percentage", "Mutation score: 75%\nCoverage: 98%\n", 75, true},
+		{"unlabelled percentage ignored", "killed 40 of 50 (80%)\n", 0, false},
+		{"label must be a word", "transmission 80%\nscoreboard 70%\n", 0, false},
+		{"no percentage", "all mutants killed\n", 0, false},
+		{"out of range", "score: 150%\n", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseScore(tt.output)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseScore() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -2,18 +2,19 @@ package phase
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/macosta/tdd-ai/internal/types"
 )
 
 // checkTestResult returns a blocker if the test result is missing or doesn't match
-// the expected result for the given phase and mode.
-func checkTestResult(s *types.Session, phase types.Phase, mode types.Mode) []string {
+// the expected result for the given phase under the session's test policy.
+func checkTestResult(s *types.Session, phase types.Phase) []string {
 	if s.LastTestResult == "" {
 		return []string{"No test result recorded"}
 	}
-	expected := ExpectedTestResult(phase, mode)
-	if s.LastTestResult != expected {
+	expected := ExpectedTestResultFor(s, phase)
+	if !ResultMatches(expected, s.LastTestResult) {
 		return []string{
 			fmt.Sprintf("Test result '%s' does not match expected '%s'", s.LastTestResult, expected),
 		}
@@ -21,10 +22,78 @@ func checkTestResult(s *types.Session, phase types.Phase, mode types.Mode) []str
 	return nil
 }
 
+// checkDisappearedTests returns a blocker if tests vanished between runs and
+// the drop has not been justified.
+func checkDisappearedTests(s *types.Session) []string {
+	if s.DisappearedTests == 0 {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("%d tests disappeared since last run; justify with 'tdd-ai phase next --justify <reason>'", s.DisappearedTests),
+	}
+}
+
+// HighRiskBlockers returns the high-risk rules not yet met for the specs in
+// ids, which must hold before leaving REFACTOR or completing the cycle.
+func HighRiskBlockers(s *types.Session, ids []int) []string {
+	high := s.HighRiskSpecs(ids)
+	if len(high) == 0 {
+		return nil
+	}
+	rules := s.GetHighRiskRules()
+	var blockers []string
+	if suite := rules.IntegrationSuite; suite != "" {
+		if _, ok := s.TestCmds[suite]; !ok {
+			blockers = append(blockers,
+				fmt.Sprintf("High-risk spec %d requires the '%s' test suite; configure it with 'tdd-ai init --test-suite %s=<command>'", high[0].ID, suite, suite),
+			)
+		} else if s.SuiteResults[suite] != "pass" {
+			blockers = append(blockers,
+				fmt.Sprintf("High-risk spec %d requires the '%s' suite to pass; run 'tdd-ai test --suite %s'", high[0].ID, suite, suite),
+			)
+		}
+	}
+	if rules.RequireReview && !s.HasApprovedReview() {
+		blockers = append(blockers,
+			fmt.Sprintf("High-risk spec %d requires an approving human review; run 'tdd-ai review'", high[0].ID),
+		)
+	}
+	return blockers
+}
+
+// TestNamingBlocker describes the tests written in RED when the test naming
+// rule is set and none of them references the current spec, or returns "".
+func TestNamingBlocker(s *types.Session) string {
+	names := s.UnreferencedNewTests()
+	spec := s.CurrentSpec()
+	if len(names) == 0 || spec == nil {
+		return ""
+	}
+	ref := fmt.Sprintf("spec %d", spec.ID)
+	if spec.Slug != "" {
+		ref += " (" + spec.Slug + ")"
+	}
+	return fmt.Sprintf("New test(s) %s do not reference %s; name one after it using %s",
+		strings.Join(names, ", "), ref, strings.Join(s.TestNaming.GetPatterns(), " or "))
+}
+
+// MutationBlocker describes why the mutation gate keeps the session in
+// REFACTOR: a mutation command is configured but no score was recorded since
+// entering the phase, or the recorded score is below the threshold. Returns ""
+// when the gate passes.
+func MutationBlocker(s *types.Session) string {
+	switch {
+	case s.MutationScore != nil && *s.MutationScore < s.GetMutationThreshold():
+		return fmt.Sprintf("Mutation score %.1f%% is below threshold %.1f%%", *s.MutationScore, s.GetMutationThreshold())
+	case s.MutationScore == nil && s.MutationCmd != "":
+		return "No mutation score recorded in this REFACTOR phase; run 'tdd-ai mutation run'"
+	}
+	return ""
+}
+
 // GetBlockers returns conditions preventing advancement from the current phase.
 func GetBlockers(s *types.Session) []string {
 	var blockers []string
-	mode := s.GetMode()
 
 	switch s.Phase {
 	case types.PhaseRed:
@@ -34,20 +103,60 @@ func GetBlockers(s *types.Session) []string {
 		if s.CurrentSpecID == nil && len(s.ActiveSpecs()) > 0 {
 			blockers = append(blockers, "No spec selected")
 		}
-		blockers = append(blockers, checkTestResult(s, s.Phase, mode)...)
+		blockers = append(blockers, checkTestResult(s, s.Phase)...)
+		if s.CurrentSpecID != nil && s.NoNewTests() {
+			blockers = append(blockers, "No new tests detected for this spec")
+		}
+		if s.TestNaming != nil && s.TestNaming.Mode == types.TestNamingBlock {
+			if b := TestNamingBlocker(s); b != "" {
+				blockers = append(blockers, b)
+			}
+		}
+		for _, spec := range s.IterationLimitReached() {
+			blockers = append(blockers,
+				fmt.Sprintf("Spec %d reached the iteration limit (%d/%d); consider splitting it with 'tdd-ai spec split %d'", spec.ID, spec.Iterations, s.MaxIterationsPerSpec, spec.ID),
+			)
+		}
 	case types.PhaseGreen:
-		blockers = append(blockers, checkTestResult(s, s.Phase, mode)...)
+		blockers = append(blockers, checkTestResult(s, s.Phase)...)
+		blockers = append(blockers, checkDisappearedTests(s)...)
+		if len(s.TestFilesEdited) > 0 {
+			blockers = append(blockers,
+				fmt.Sprintf("Test files edited during GREEN: %s; revert them, tests are frozen after RED", strings.Join(s.TestFilesEdited, ", ")),
+			)
+		}
 	case types.PhaseRefactor:
-		blockers = append(blockers, checkTestResult(s, s.Phase, mode)...)
+		blockers = append(blockers, checkTestResult(s, s.Phase)...)
+		blockers = append(blockers, checkDisappearedTests(s)...)
 		pending := s.PendingReflections()
 		if len(pending) > 0 {
 			blockers = append(blockers,
 				fmt.Sprintf("%d reflection questions unanswered", len(pending)),
 			)
 		}
+		if s.GetStrictness() == types.StrictnessStrict && len(s.ReflectionDebt) > 0 {
+			blockers = append(blockers,
+				fmt.Sprintf("%d skipped reflection(s) outstanding as reflection debt; strict sessions must pay it with 'tdd-ai refactor debt pay'", len(s.ReflectionDebt)),
+			)
+		}
+		for _, spec := range s.UnsignedSpecs(s.CurrentSpecIDs()) {
+			blockers = append(blockers,
+				fmt.Sprintf("Spec %d has %d unchecked acceptance criteria; check them with 'tdd-ai spec criteria check %d <n>' or waive them", spec.ID, len(spec.UnmetCriteria()), spec.ID),
+			)
+		}
+		if b := MutationBlocker(s); b != "" {
+			blockers = append(blockers, b)
+		}
+		blockers = append(blockers, HighRiskBlockers(s, s.CurrentSpecIDs())...)
 	case types.PhaseDone:
 		blockers = append(blockers, "Cannot advance past done")
 	}
 
+	if s.Phase != types.PhaseDone {
+		for _, name := range s.MissingSuites(s.Phase) {
+			blockers = append(blockers, fmt.Sprintf("Test suite '%s' has not passed in this phase", name))
+		}
+	}
+
 	return blockers
 }
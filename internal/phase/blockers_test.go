@@ -74,6 +74,22 @@ func TestGetBlockersRedRetrofitExpectsPass(t *testing.T) {
 	assertContains(t, blockers, "does not match expected 'pass'")
 }
 
+func TestGetBlockersRedNoNewTests(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.LastTestResult = "fail"
+	before, after := 4, 4
+	s.BaselineTestCount = &before
+	s.LastTestCount = &after
+	blockers := GetBlockers(s)
+	assertContains(t, blockers, "No new tests detected")
+
+	after = 5
+	blockers = GetBlockers(s)
+	assertNotContains(t, blockers, "No new tests detected")
+}
+
 func TestGetBlockersGreenNoTestResult(t *testing.T) {
 	s := types.NewSession()
 	s.Phase = types.PhaseGreen
@@ -105,6 +121,17 @@ func TestGetBlockersGreenCorrectTestResult(t *testing.T) {
 	}
 }
 
+func TestGetBlockersGreenDisappearedTests(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.LastTestResult = "pass"
+	s.DisappearedTests = 2
+
+	blockers := GetBlockers(s)
+
+	assertContains(t, blockers, "2 tests disappeared since last run")
+}
+
 func TestGetBlockersRefactorNoTestResult(t *testing.T) {
 	s := types.NewSession()
 	s.Phase = types.PhaseRefactor
@@ -166,6 +193,26 @@ func TestGetBlockersRefactorNoReflections(t *testing.T) {
 	}
 }
 
+func TestGetBlockersRefactorLowMutationScore(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.LastTestResult = "pass"
+	s.MutationThreshold = 70
+	score := 65.0
+	s.MutationScore = &score
+	blockers := GetBlockers(s)
+	assertContains(t, blockers, "Mutation score 65.0% is below threshold 70.0%")
+
+	score = 75.0
+	blockers = GetBlockers(s)
+	assertNotContains(t, blockers, "Mutation score")
+
+	s.MutationCmd = "npx stryker run"
+	s.MutationScore = nil
+	blockers = GetBlockers(s)
+	assertContains(t, blockers, "No mutation score recorded")
+}
+
 func TestGetBlockersDone(t *testing.T) {
 	s := types.NewSession()
 	s.Phase = types.PhaseDone
@@ -194,3 +241,43 @@ func assertNotContains(t *testing.T, blockers []string, substr string) {
 		}
 	}
 }
+
+func TestGetBlockersRedIterationLimitReached(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("feature")
+	_ = s.SetCurrentSpec(1)
+	s.LastTestResult = "fail"
+	s.MaxIterationsPerSpec = 2
+	s.Specs[0].Iterations = 2
+
+	blockers := GetBlockers(s)
+
+	assertContains(t, blockers, "reached the iteration limit (2/2)")
+	assertContains(t, blockers, "tdd-ai spec split 1")
+}
+
+func TestGetBlockersRequiredSuites(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseGreen
+	s.LastTestResult = "pass"
+	s.RequiredSuites = map[types.Phase][]string{types.PhaseGreen: {"integration"}}
+
+	assertContains(t, GetBlockers(s), "Test suite 'integration' has not passed")
+
+	s.RecordSuiteResult("integration", "pass")
+	assertNotContains(t, GetBlockers(s), "Test suite")
+}
+
+func TestGetBlockersRefactorUncheckedCriteria(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.LastTestResult = "pass"
+	s.AddSpec("password reset")
+	_ = s.AddSpecCriteria(1, []string{"email is sent"})
+	_ = s.SetCurrentSpec(1)
+
+	assertContains(t, GetBlockers(s), "Spec 1 has 1 unchecked acceptance criteria")
+
+	_ = s.CheckSpecCriterion(1, 1, true)
+	assertNotContains(t, GetBlockers(s), "acceptance criteria")
+}
@@ -74,3 +74,31 @@ func CanTransition(from, to types.Phase) bool {
 	}
 	return false
 }
+
+// Transition is one edge of the phase machine, labeled with the test result
+// expected before leaving its source phase.
+type Transition struct {
+	From   types.Phase `json:"from"`
+	To     types.Phase `json:"to"`
+	Expect string      `json:"expect"`
+}
+
+// Transitions returns the session's phase machine as edges in cycle order,
+// starting from RED and including the REFACTOR -> RED loop taken while specs
+// remain. Expected results honor the session's mode and test policy.
+func Transitions(s *types.Session) []Transition {
+	var edges []Transition
+	for p := types.PhaseRed; p != types.PhaseDone; {
+		next, err := NextWithMode(p, s.GetMode())
+		if err != nil {
+			break
+		}
+		expect := ExpectedTestResultFor(s, p)
+		if p == types.PhaseRefactor {
+			edges = append(edges, Transition{From: p, To: types.PhaseRed, Expect: expect})
+		}
+		edges = append(edges, Transition{From: p, To: next, Expect: expect})
+		p = next
+	}
+	return edges
+}
@@ -1,6 +1,7 @@
 package phase
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/macosta/tdd-ai/internal/types"
@@ -204,3 +205,30 @@ func TestNextInLoopRetrofitRefactorWithRemaining(t *testing.T) {
 		t.Errorf("NextInLoop(refactor, retrofit, true) = %q, want %q", got, types.PhaseRed)
 	}
 }
+
+func TestTransitions(t *testing.T) {
+	s := types.NewSession()
+	want := []Transition{
+		{From: types.PhaseRed, To: types.PhaseGreen, Expect: "fail"},
+		{From: types.PhaseGreen, To: types.PhaseRefactor, Expect: "pass"},
+		{From: types.PhaseRefactor, To: types.PhaseRed, Expect: "pass"},
+		{From: types.PhaseRefactor, To: types.PhaseDone, Expect: "pass"},
+	}
+	if got := Transitions(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("Transitions() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTransitionsRetrofitWithPolicy(t *testing.T) {
+	s := types.NewSession()
+	s.Mode = types.ModeRetrofit
+	s.TestPolicy = map[string]string{"refactor": ResultAny}
+	want := []Transition{
+		{From: types.PhaseRed, To: types.PhaseRefactor, Expect: "pass"},
+		{From: types.PhaseRefactor, To: types.PhaseRed, Expect: ResultAny},
+		{From: types.PhaseRefactor, To: types.PhaseDone, Expect: ResultAny},
+	}
+	if got := Transitions(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("Transitions() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package phase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// ResultAny is a policy value that accepts either a passing or failing test run.
+const ResultAny = "any"
+
+// ExpectedTestResultFor returns the test outcome expected before leaving the
+// given phase, honoring the session's test policy. A "mode:phase" entry wins
+// over a plain "phase" entry; without either, ExpectedTestResult applies.
+func ExpectedTestResultFor(s *types.Session, p types.Phase) string {
+	mode := s.GetMode()
+	if r, ok := s.TestPolicy[string(mode)+":"+string(p)]; ok {
+		return r
+	}
+	if r, ok := s.TestPolicy[string(p)]; ok {
+		return r
+	}
+	return ExpectedTestResult(p, mode)
+}
+
+// ResultMatches reports whether a test result satisfies an expected result.
+func ResultMatches(expected, result string) bool {
+	return expected == ResultAny || expected == result
+}
+
+// ParseTestPolicy parses entries of the form "[mode:]phase=result" into a test
+// policy, where result is pass, fail, or any.
+func ParseTestPolicy(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	policy := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, result, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid test policy %q: expected [mode:]phase=result", entry)
+		}
+		p := key
+		if mode, rest, hasMode := strings.Cut(key, ":"); hasMode {
+			if types.Mode(mode) != types.ModeGreenfield && types.Mode(mode) != types.ModeRetrofit {
+				return nil, fmt.Errorf("invalid test policy %q: unknown mode %q", entry, mode)
+			}
+			p = rest
+		}
+		switch types.Phase(p) {
+		case types.PhaseRed, types.PhaseGreen, types.PhaseRefactor:
+		default:
+			return nil, fmt.Errorf("invalid test policy %q: phase must be red, green, or refactor", entry)
+		}
+		switch result {
+		case "pass", "fail", ResultAny:
+		default:
+			return nil, fmt.Errorf("invalid test policy %q: result must be pass, fail, or any", entry)
+		}
+		policy[key] = result
+	}
+	return policy, nil
+}
@@ -0,0 +1,67 @@
+package phase
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestExpectedTestResultFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   types.Mode
+		policy map[string]string
+		phase  types.Phase
+		want   string
+	}{
+		{"default red", types.ModeGreenfield, nil, types.PhaseRed, "fail"},
+		{"default retrofit red", types.ModeRetrofit, nil, types.PhaseRed, "pass"},
+		{"phase override", types.ModeGreenfield, map[string]string{"refactor": "any"}, types.PhaseRefactor, "any"},
+		{"mode override wins", types.ModeRetrofit, map[string]string{"red": "fail", "retrofit:red": "any"}, types.PhaseRed, "any"},
+		{"other mode ignored", types.ModeGreenfield, map[string]string{"retrofit:red": "any"}, types.PhaseRed, "fail"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := types.NewSession()
+			s.Mode = tt.mode
+			s.TestPolicy = tt.policy
+			if got := ExpectedTestResultFor(s, tt.phase); got != tt.want {
+				t.Errorf("ExpectedTestResultFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultMatches(t *testing.T) {
+	if !ResultMatches("any", "fail") || !ResultMatches("pass", "pass") {
+		t.Error("ResultMatches() should accept any result for 'any' and equal results")
+	}
+	if ResultMatches("pass", "fail") {
+		t.Error("ResultMatches() should reject a mismatched result")
+	}
+}
+
+func TestParseTestPolicy(t *testing.T) {
+	policy, err := ParseTestPolicy([]string{"refactor=any", "retrofit:red=fail"})
+	if err != nil {
+		t.Fatalf("ParseTestPolicy() error: %v", err)
+	}
+	if policy["refactor"] != "any" || policy["retrofit:red"] != "fail" {
+		t.Errorf("ParseTestPolicy() = %v", policy)
+	}
+
+	for _, bad := range []string{"refactor", "done=pass", "legacy:red=pass", "red=maybe"} {
+		if _, err := ParseTestPolicy([]string{bad}); err == nil {
+			t.Errorf("ParseTestPolicy(%q) should fail", bad)
+		}
+	}
+}
+
+func TestGetBlockersHonorsTestPolicy(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseRefactor
+	s.LastTestResult = "fail"
+	s.TestPolicy = map[string]string{"refactor": "any"}
+
+	assertNotContains(t, GetBlockers(s), "does not match")
+}
@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// EnvVar names the environment variable pointing to an organization policy file.
+const EnvVar = "TDD_AI_POLICY"
+
+// Force-overridable commands that a policy can ban.
+const (
+	ForcePhaseNext = "phase next"
+	ForcePhaseSet  = "phase set"
+	ForceComplete  = "complete"
+)
+
+// Policy is an organization-managed set of locked settings. Whatever it turns
+// on is applied to every session it governs and cannot be turned off locally.
+type Policy struct {
+	// Path is the file the policy was loaded from.
+	Path string `json:"-"`
+
+	AgentMode     bool `json:"agent_mode,omitempty"`
+	RequireReview bool `json:"require_review,omitempty"`
+	AuditLog      bool `json:"audit_log,omitempty"`
+	// Strictness is the lowest strictness a session may use; sessions set
+	// below it are raised to it.
+	Strictness string `json:"strictness,omitempty"`
+	// Reflections are extra questions asked in every REFACTOR phase, after the
+	// default ones.
+	Reflections []string `json:"reflections,omitempty"`
+	// BannedForce lists commands whose --force override is disabled.
+	BannedForce []string `json:"banned_force,omitempty"`
+	// HighRisk replaces the default rules for specs marked high-risk.
+	HighRisk *types.RiskRules `json:"high_risk,omitempty"`
+}
+
+// Load reads and validates a policy file.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing policy file %s: %w", path, err)
+	}
+	for _, c := range p.BannedForce {
+		switch c {
+		case ForcePhaseNext, ForcePhaseSet, ForceComplete:
+		default:
+			return nil, fmt.Errorf("policy file %s: unknown banned_force command %q (valid: %q, %q, %q)", path, c, ForcePhaseNext, ForcePhaseSet, ForceComplete)
+		}
+	}
+	if p.Strictness != "" && !slices.Contains(types.Strictnesses, p.Strictness) {
+		return nil, fmt.Errorf("policy file %s: invalid strictness %q (valid: %s)", path, p.Strictness, strings.Join(types.Strictnesses, ", "))
+	}
+	p.Path = path
+	return &p, nil
+}
+
+// Resolve loads the policy named by flagPath, falling back to the TDD_AI_POLICY
+// environment variable. It returns nil when neither is set.
+func Resolve(flagPath string) (*Policy, error) {
+	path := flagPath
+	if path == "" {
+		path = os.Getenv(EnvVar)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Apply forces the policy's locked settings onto a session. It is safe to call
+// on a nil policy.
+func (p *Policy) Apply(s *types.Session) {
+	if p == nil {
+		return
+	}
+	if p.AgentMode {
+		s.AgentMode = true
+	}
+	if p.RequireReview {
+		s.RequireReview = true
+	}
+	if p.AuditLog {
+		s.AuditLog = true
+	}
+	if p.HighRisk != nil {
+		rules := *p.HighRisk
+		s.HighRiskRules = &rules
+	}
+	if !p.AllowsStrictness(s.GetStrictness()) {
+		s.Strictness = p.Strictness
+	}
+}
+
+// AllowsStrictness reports whether level is at or above the policy's minimum
+// strictness. It is safe to call on a nil policy.
+func (p *Policy) AllowsStrictness(level string) bool {
+	if p == nil || p.Strictness == "" {
+		return true
+	}
+	return slices.Index(types.Strictnesses, level) >= slices.Index(types.Strictnesses, p.Strictness)
+}
+
+// ForceBanned reports whether the policy disables --force for the command.
+func (p *Policy) ForceBanned(command string) bool {
+	if p == nil {
+		return false
+	}
+	for _, c := range p.BannedForce {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+// Questions appends the policy's reflection questions to base, continuing its
+// sequential IDs.
+func (p *Policy) Questions(base []types.ReflectionQuestion) []types.ReflectionQuestion {
+	if p == nil {
+		return base
+	}
+	next := len(base) + 1
+	for _, q := range p.Reflections {
+		base = append(base, types.ReflectionQuestion{ID: next, Question: q})
+		next++
+	}
+	return base
+}
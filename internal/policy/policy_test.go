@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAndApply(t *testing.T) {
+	path := writePolicy(t, `{"agent_mode": true, "require_review": true, "banned_force": ["phase set"]}`)
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	s := types.NewSession()
+	p.Apply(s)
+	if !s.AgentMode || !s.RequireReview || s.AuditLog {
+		t.Errorf("Apply() = agent %v, review %v, audit %v; want true, true, false", s.AgentMode, s.RequireReview, s.AuditLog)
+	}
+	if !p.ForceBanned(ForcePhaseSet) || p.ForceBanned(ForceComplete) {
+		t.Error("only phase set should have --force banned")
+	}
+}
+
+func TestLoadRejectsUnknownForceCommand(t *testing.T) {
+	path := writePolicy(t, `{"banned_force": ["reset"]}`)
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for unknown banned_force command")
+	}
+}
+
+func TestResolvePrefersFlagOverEnv(t *testing.T) {
+	envPath := writePolicy(t, `{"agent_mode": true}`)
+	flagPath := writePolicy(t, `{"audit_log": true}`)
+	t.Setenv(EnvVar, envPath)
+
+	p, err := Resolve(flagPath)
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if p.Path != flagPath {
+		t.Errorf("Resolve() loaded %s, want %s", p.Path, flagPath)
+	}
+
+	p, err = Resolve("")
+	if err != nil || p == nil || p.Path != envPath {
+		t.Errorf("Resolve(\"\") = %v, %v; want policy from %s", p, err, envPath)
+	}
+
+	t.Setenv(EnvVar, "")
+	if p, err := Resolve(""); p != nil || err != nil {
+		t.Errorf("Resolve() without a policy = %v, %v; want nil, nil", p, err)
+	}
+}
+
+func TestNilPolicyIsNoop(t *testing.T) {
+	var p *Policy
+	s := types.NewSession()
+	p.Apply(s)
+	if s.AgentMode || p.ForceBanned(ForceComplete) {
+		t.Error("nil policy should change nothing")
+	}
+	if got := p.Questions(nil); len(got) != 0 {
+		t.Errorf("nil policy Questions() = %v", got)
+	}
+}
+
+func TestQuestionsContinuesIDs(t *testing.T) {
+	p := &Policy{Reflections: []string{"Did you update the changelog?"}}
+	got := p.Questions([]types.ReflectionQuestion{{ID: 1, Question: "a"}, {ID: 2, Question: "b"}})
+	if len(got) != 3 || got[2].ID != 3 || got[2].Question != "Did you update the changelog?" {
+		t.Errorf("Questions() = %+v", got)
+	}
+}
+
+func TestApplySetsHighRiskRules(t *testing.T) {
+	p, err := Load(writePolicy(t, `{"high_risk": {"integration_suite": "e2e"}}`))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	s := types.NewSession()
+	if got := s.GetHighRiskRules(); got != types.DefaultHighRiskRules {
+		t.Errorf("without a policy, rules = %+v, want the defaults", got)
+	}
+	p.Apply(s)
+	if got := s.GetHighRiskRules(); got != (types.RiskRules{IntegrationSuite: "e2e"}) {
+		t.Errorf("rules = %+v, want only the e2e suite", got)
+	}
+}
+
+func TestApplyRaisesStrictnessToMinimum(t *testing.T) {
+	p, err := Load(writePolicy(t, `{"strictness": "standard"}`))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	s := types.NewSession()
+	s.Strictness = types.StrictnessRelaxed
+	p.Apply(s)
+	if s.GetStrictness() != types.StrictnessStandard {
+		t.Errorf("Apply() strictness = %s, want %s", s.GetStrictness(), types.StrictnessStandard)
+	}
+
+	s.Strictness = types.StrictnessStrict
+	p.Apply(s)
+	if s.Strictness != types.StrictnessStrict {
+		t.Errorf("Apply() lowered strictness to %s", s.Strictness)
+	}
+	if p.AllowsStrictness(types.StrictnessRelaxed) || !p.AllowsStrictness(types.StrictnessStrict) {
+		t.Error("AllowsStrictness should reject relaxed and allow strict")
+	}
+}
+
+func TestLoadRejectsInvalidStrictness(t *testing.T) {
+	if _, err := Load(writePolicy(t, `{"strictness": "lenient"}`)); err == nil {
+		t.Error("expected error for invalid strictness")
+	}
+}
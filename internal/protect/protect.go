@@ -0,0 +1,50 @@
+package protect
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether a slash-separated file path matches a glob pattern.
+// Besides the path.Match syntax, a "**" segment matches any number of
+// directories, so "migrations/**" covers everything under migrations/.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Violations returns the files matching any of the protected patterns, in the
+// order given.
+func Violations(patterns, files []string) []string {
+	var hits []string
+	for _, f := range files {
+		for _, p := range patterns {
+			if Match(p, f) {
+				hits = append(hits, f)
+				break
+			}
+		}
+	}
+	return hits
+}
@@ -0,0 +1,40 @@
+package protect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"migrations/**", "migrations/001_init.sql", true},
+		{"migrations/**", "migrations/2024/001.sql", true},
+		{"migrations/**", "db/migrations/001.sql", false},
+		{"**/generated/*.go", "internal/api/generated/types.go", true},
+		{"**/generated/*.go", "generated/types.go", true},
+		{"vendor/**", "vendorized/x.go", false},
+		{"*.lock", "Cargo.lock", true},
+		{"*.lock", "sub/Cargo.lock", false},
+		{"go.sum", "go.sum", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestViolations(t *testing.T) {
+	got := Violations(
+		[]string{"migrations/**", "vendor/**"},
+		[]string{"main.go", "vendor/lib/a.go", "migrations/001.sql"},
+	)
+	want := []string{"vendor/lib/a.go", "migrations/001.sql"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations() = %v, want %v", got, want)
+	}
+}
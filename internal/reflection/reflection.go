@@ -2,7 +2,11 @@ package reflection
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/macosta/tdd-ai/internal/types"
 )
@@ -23,6 +27,19 @@ func DefaultQuestions() []types.ReflectionQuestion {
 	}
 }
 
+// Questions returns the custom reflection questions with sequential IDs, or the
+// default questions when custom is empty.
+func Questions(custom []string) []types.ReflectionQuestion {
+	if len(custom) == 0 {
+		return DefaultQuestions()
+	}
+	questions := make([]types.ReflectionQuestion, len(custom))
+	for i, q := range custom {
+		questions[i] = types.ReflectionQuestion{ID: i + 1, Question: q}
+	}
+	return questions
+}
+
 // ValidateAnswer checks that an answer has at least MinAnswerWords words.
 func ValidateAnswer(answer string) error {
 	words := len(strings.Fields(answer))
@@ -31,3 +48,116 @@ func ValidateAnswer(answer string) error {
 	}
 	return nil
 }
+
+// MaxExcerptLength is the longest answer, in characters, stored without an
+// excerpt.
+const MaxExcerptLength = 280
+
+// Excerpt shortens a long or multi-line answer, such as one written in a
+// file, to its first paragraph on one line, skipping Markdown headings, and
+// clipped to MaxExcerptLength characters at a word boundary. An ellipsis marks
+// text left out. Short single-line answers are returned unchanged.
+func Excerpt(answer string) string {
+	trimmed := strings.TrimSpace(answer)
+	if !strings.Contains(trimmed, "\n") && utf8.RuneCountInString(trimmed) <= MaxExcerptLength {
+		return answer
+	}
+
+	whole := strings.Join(strings.Fields(trimmed), " ")
+	text := strings.Join(strings.Fields(firstParagraph(trimmed)), " ")
+	if text == "" {
+		text = whole
+	}
+	if runes := []rune(text); len(runes) > MaxExcerptLength {
+		cut := string(runes[:MaxExcerptLength])
+		if i := strings.LastIndex(cut, " "); i > 0 {
+			cut = cut[:i]
+		}
+		return cut + " …"
+	}
+	if text != whole {
+		return text + " …"
+	}
+	return text
+}
+
+// firstParagraph returns the lines of text up to the first blank line, after
+// any leading Markdown headings.
+func firstParagraph(text string) string {
+	var para []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") && len(para) == 0 {
+			if len(para) > 0 {
+				break
+			}
+			continue
+		}
+		para = append(para, line)
+	}
+	return strings.Join(para, "\n")
+}
+
+// ParseEvidence resolves a path or path:line reference relative to dir,
+// checking that the file exists and, when given, that the line is within it.
+func ParseEvidence(dir, ref string) (types.Evidence, error) {
+	ev := types.Evidence{Path: ref}
+	if i := strings.LastIndex(ref, ":"); i >= 0 {
+		if n, err := strconv.Atoi(ref[i+1:]); err == nil {
+			if n < 1 {
+				return ev, fmt.Errorf("invalid evidence %q: line must be at least 1", ref)
+			}
+			ev = types.Evidence{Path: ref[:i], Line: n}
+		}
+	}
+	if ev.Path == "" {
+		return ev, fmt.Errorf("invalid evidence %q: missing path", ref)
+	}
+
+	full := ev.Path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(dir, full)
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return ev, fmt.Errorf("evidence %s: %w", ref, err)
+	}
+	if lines := strings.Count(strings.TrimSuffix(string(data), "\n"), "\n") + 1; ev.Line > lines {
+		return ev, fmt.Errorf("evidence %s: %s has only %d line(s)", ref, ev.Path, lines)
+	}
+	return ev, nil
+}
+
+// Entry is one answered reflection in the knowledge base, with the session
+// file it came from.
+type Entry struct {
+	Source   string           `json:"source"`
+	Question string           `json:"question"`
+	Answer   string           `json:"answer"`
+	Evidence []types.Evidence `json:"evidence,omitempty"`
+}
+
+// Collect returns the answered reflections of s as knowledge base entries.
+func Collect(source string, s *types.Session) []Entry {
+	var entries []Entry
+	for _, r := range s.Reflections {
+		if r.Answer == "" {
+			continue
+		}
+		entries = append(entries, Entry{Source: source, Question: r.Question, Answer: r.FullText(), Evidence: r.Evidence})
+	}
+	return entries
+}
+
+// Search returns the entries whose question or answer contains query,
+// ignoring case.
+func Search(entries []Entry, query string) []Entry {
+	query = strings.ToLower(query)
+	var found []Entry
+	for _, e := range entries {
+		if strings.Contains(strings.ToLower(e.Question), query) || strings.Contains(strings.ToLower(e.Answer), query) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
@@ -1,8 +1,12 @@
 package reflection
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
 )
 
 func TestDefaultQuestionsReturns7(t *testing.T) {
@@ -92,3 +96,82 @@ func TestValidateAnswerAcceptsEnough(t *testing.T) {
 		})
 	}
 }
+
+func TestQuestionsCustomSet(t *testing.T) {
+	if got := Questions(nil); len(got) != len(DefaultQuestions()) {
+		t.Errorf("Questions(nil) should return the defaults, got %d questions", len(got))
+	}
+	got := Questions([]string{"Is the API compatible?", "Are docs updated?"})
+	if len(got) != 2 || got[1].ID != 2 || got[1].Question != "Are docs updated?" {
+		t.Errorf("Questions(custom) = %+v, want the custom set with sequential IDs", got)
+	}
+}
+
+func TestParseEvidence(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "calc.go"), []byte("package calc\n\nfunc Add() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := ParseEvidence(dir, "calc.go:3")
+	if err != nil || ev != (types.Evidence{Path: "calc.go", Line: 3}) {
+		t.Errorf("ParseEvidence(calc.go:3) = %+v, %v", ev, err)
+	}
+	if ev, err := ParseEvidence(dir, "calc.go"); err != nil || ev.Line != 0 {
+		t.Errorf("ParseEvidence(calc.go) = %+v, %v; want whole-file reference", ev, err)
+	}
+
+	for _, ref := range []string{"calc.go:4", "calc.go:0", "missing.go", "missing.go:1", ""} {
+		if _, err := ParseEvidence(dir, ref); err == nil {
+			t.Errorf("ParseEvidence(%q) should fail", ref)
+		}
+	}
+}
+
+func TestCollectAndSearch(t *testing.T) {
+	s := types.NewSession()
+	s.Reflections = []types.ReflectionQuestion{
+		{ID: 1, Question: "Can I reduce duplication?", Answer: "Merged the two Duplicate validators"},
+		{ID: 2, Question: "Are my tests isolated?"},
+	}
+
+	entries := Collect(".tdd-ai.json", s)
+	if len(entries) != 1 || entries[0].Source != ".tdd-ai.json" {
+		t.Fatalf("Collect() = %+v, want only the answered reflection", entries)
+	}
+	if got := Search(entries, "duplicate"); len(got) != 1 {
+		t.Errorf("Search(duplicate) = %+v, want a case-insensitive match", got)
+	}
+	if got := Search(entries, "isolated"); len(got) != 0 {
+		t.Errorf("Search(isolated) = %+v, want no match", got)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("word ", 80)
+	tests := []struct {
+		name, answer, want string
+	}{
+		{"short answer unchanged", "Tests are already descriptive and clear enough", "Tests are already descriptive and clear enough"},
+		{"first paragraph", "# Notes\n\nSplit the parser\ninto two helpers.\n\nMore detail here.", "Split the parser into two helpers. …"},
+		{"single paragraph over lines", "Split the parser\ninto two helpers.", "Split the parser into two helpers."},
+		{"clipped at a word", long, strings.TrimSpace(strings.Repeat("word ", 56)) + " …"},
+	}
+	for _, tt := range tests {
+		if got := Excerpt(tt.answer); got != tt.want {
+			t.Errorf("%s: Excerpt() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollectUsesFullAnswer(t *testing.T) {
+	s := types.NewSession()
+	s.Reflections = []types.ReflectionQuestion{
+		{ID: 1, Question: "Can I reduce duplication?", Answer: "Merged the validators …", FullAnswer: "Merged the validators\n\nThe second one only differed in its error message."},
+	}
+
+	entries := Collect(".tdd-ai.json", s)
+	if got := Search(entries, "error message"); len(got) != 1 {
+		t.Errorf("Search(error message) = %+v, want a match in the full answer", got)
+	}
+}
@@ -0,0 +1,91 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// service is the name secrets are filed under in the OS keychain.
+const service = "tdd-ai"
+
+// osKeychain returns the platform's keychain, driven through its command-line
+// tool, or nil when none is installed.
+func osKeychain() keychain {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macKeychain{}
+		}
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return secretService{}
+		}
+	}
+	return nil
+}
+
+// macKeychain stores secrets as generic passwords in the login keychain.
+// security only accepts the value as an argument; it is visible to the user's
+// own processes for the moment the command runs.
+type macKeychain struct{}
+
+func (macKeychain) set(name, value string) error {
+	_, err := run(nil, "security", "add-generic-password", "-U", "-s", service, "-a", name, "-w", value)
+	return err
+}
+
+func (macKeychain) get(name string) (string, error) {
+	out, err := run(nil, "security", "find-generic-password", "-s", service, "-a", name, "-w")
+	return strings.TrimSuffix(out, "\n"), err
+}
+
+func (macKeychain) remove(name string) error {
+	_, err := run(nil, "security", "delete-generic-password", "-s", service, "-a", name)
+	return err
+}
+
+// secretService stores secrets through the freedesktop Secret Service, such as
+// GNOME Keyring or KWallet. The value is passed on stdin so it never shows up
+// in the process list.
+type secretService struct{}
+
+func (secretService) set(name, value string) error {
+	_, err := run(strings.NewReader(value), "secret-tool", "store", "--label", service+" "+name, "service", service, "name", name)
+	return err
+}
+
+func (secretService) get(name string) (string, error) {
+	out, err := run(nil, "secret-tool", "lookup", "service", service, "name", name)
+	if err == nil && out == "" {
+		return "", fmt.Errorf("%w in the keychain: %s", ErrNotFound, name)
+	}
+	return out, err
+}
+
+func (secretService) remove(name string) error {
+	_, err := run(nil, "secret-tool", "clear", "service", service, "name", name)
+	return err
+}
+
+// run executes a keychain tool, returning its stdout. Errors carry the tool's
+// stderr, which never contains the secret value.
+func run(stdin *strings.Reader, name string, args ...string) (string, error) {
+	c := exec.Command(name, args...)
+	if stdin != nil {
+		c.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,326 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Secrets are kept per user, outside any project, so tokens never end up in a
+// session or config file that might be committed.
+
+// EnvDir names the environment variable overriding the directory of the secret
+// store, by default tdd-ai under the user's config directory.
+const EnvDir = "TDD_AI_SECRETS_DIR"
+
+// EnvBackend names the environment variable choosing the backend new secrets
+// are stored in, overriding the default of the OS keychain when available.
+const EnvBackend = "TDD_AI_SECRET_BACKEND"
+
+// Backends a secret can be stored in.
+const (
+	BackendKeychain = "keychain"
+	BackendFile     = "file"
+)
+
+// Files of the store. The index lists every secret and holds the encrypted
+// values of file-backed ones; the key encrypts them.
+const (
+	indexFile = "secrets.json"
+	keyFile   = "secret.key"
+)
+
+// ErrNotFound is returned for a secret that is not in the store.
+var ErrNotFound = errors.New("secret not found")
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// ValidateName reports whether name can name a secret: lowercase letters,
+// digits, '.', '_' and '-', e.g. "github" or "slack.webhook".
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (use lowercase letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+// Info describes a stored secret without revealing its value.
+type Info struct {
+	Name      string `json:"name"`
+	Backend   string `json:"backend"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// entry is a secret in the index. Nonce and Data are set for file-backed
+// secrets only; a keychain-backed secret's value lives in the keychain.
+type entry struct {
+	Backend   string `json:"backend"`
+	UpdatedAt string `json:"updated_at"`
+	Nonce     string `json:"nonce,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// keychain is an OS credential store.
+type keychain interface {
+	set(name, value string) error
+	get(name string) (string, error)
+	remove(name string) error
+}
+
+// Store is the current user's secret store.
+type Store struct {
+	// Dir holds the index and key files.
+	Dir string
+	// keychain is nil when no OS keychain is available.
+	keychain keychain
+}
+
+// Open returns the user's secret store, using the OS keychain when one is
+// available.
+func Open() (*Store, error) {
+	dir := os.Getenv(EnvDir)
+	if dir == "" {
+		config, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("locating the secret store: %w (set %s)", err, EnvDir)
+		}
+		dir = filepath.Join(config, "tdd-ai")
+	}
+	return &Store{Dir: dir, keychain: osKeychain()}, nil
+}
+
+// DefaultBackend returns the backend new secrets are stored in: the one named
+// by TDD_AI_SECRET_BACKEND, else the keychain when available, else the file.
+func (st *Store) DefaultBackend() (string, error) {
+	switch b := os.Getenv(EnvBackend); b {
+	case "":
+	case BackendKeychain, BackendFile:
+		return b, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q (valid: %s, %s)", EnvBackend, b, BackendKeychain, BackendFile)
+	}
+	if st.keychain != nil {
+		return BackendKeychain, nil
+	}
+	return BackendFile, nil
+}
+
+// Set stores value under name in backend ("" for the default), replacing any
+// earlier value, and returns the backend used. When the keychain was chosen by
+// default but cannot be reached, such as on a headless Linux machine without
+// a D-Bus session, the value goes to the encrypted file instead.
+func (st *Store) Set(name, value, backend string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", fmt.Errorf("secret value is empty")
+	}
+	explicit := backend != ""
+	if !explicit {
+		var err error
+		if backend, err = st.DefaultBackend(); err != nil {
+			return "", err
+		}
+	}
+
+	index, err := st.readIndex()
+	if err != nil {
+		return "", err
+	}
+	prev, existed := index[name]
+	e := entry{Backend: backend, UpdatedAt: time.Now().UTC().Format(time.RFC3339)}
+	switch backend {
+	case BackendKeychain:
+		if st.keychain == nil {
+			return "", fmt.Errorf("no OS keychain available (use the %s backend)", BackendFile)
+		}
+		if err := st.keychain.set(name, value); err != nil {
+			if explicit {
+				return "", fmt.Errorf("storing %s in the keychain: %w", name, err)
+			}
+			return st.Set(name, value, BackendFile)
+		}
+	case BackendFile:
+		if e.Nonce, e.Data, err = st.encrypt(name, value); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("invalid backend %q (valid: %s, %s)", backend, BackendKeychain, BackendFile)
+	}
+	index[name] = e
+	if err := st.writeIndex(index); err != nil {
+		return "", err
+	}
+	// Moving a secret out of the keychain must not leave the old copy behind.
+	if existed && prev.Backend == BackendKeychain && backend != BackendKeychain && st.keychain != nil {
+		_ = st.keychain.remove(name)
+	}
+	return backend, nil
+}
+
+// Get returns the value stored under name.
+func (st *Store) Get(name string) (string, error) {
+	index, err := st.readIndex()
+	if err != nil {
+		return "", err
+	}
+	e, ok := index[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if e.Backend == BackendKeychain {
+		if st.keychain == nil {
+			return "", fmt.Errorf("secret %s is in the OS keychain, which is not available here", name)
+		}
+		return st.keychain.get(name)
+	}
+	return st.decrypt(name, e)
+}
+
+// Delete removes the secret stored under name.
+func (st *Store) Delete(name string) error {
+	index, err := st.readIndex()
+	if err != nil {
+		return err
+	}
+	e, ok := index[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if e.Backend == BackendKeychain && st.keychain != nil {
+		if err := st.keychain.remove(name); err != nil {
+			return fmt.Errorf("removing %s from the keychain: %w", name, err)
+		}
+	}
+	delete(index, name)
+	return st.writeIndex(index)
+}
+
+// List describes every stored secret, sorted by name.
+func (st *Store) List() ([]Info, error) {
+	index, err := st.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]Info, 0, len(index))
+	for name, e := range index {
+		infos = append(infos, Info{Name: name, Backend: e.Backend, UpdatedAt: e.UpdatedAt})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+func (st *Store) readIndex() (map[string]entry, error) {
+	index := make(map[string]entry)
+	data, err := os.ReadFile(filepath.Join(st.Dir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading secret store: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("parsing secret store %s: %w", filepath.Join(st.Dir, indexFile), err)
+	}
+	return index, nil
+}
+
+func (st *Store) writeIndex(index map[string]entry) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding secret store: %w", err)
+	}
+	if err := os.MkdirAll(st.Dir, 0700); err != nil {
+		return fmt.Errorf("creating secret store: %w", err)
+	}
+	path := filepath.Join(st.Dir, indexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing secret store: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// key returns the key encrypting file-backed secrets, creating it on first
+// use when create is set.
+func (st *Store) key(create bool) ([]byte, error) {
+	path := filepath.Join(st.Dir, keyFile)
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("secret key %s is corrupt", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, fmt.Errorf("reading secret key: %w", err)
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating secret key: %w", err)
+	}
+	if err := os.MkdirAll(st.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating secret store: %w", err)
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("writing secret key: %w", err)
+	}
+	return key, nil
+}
+
+// gcm returns an AES-256-GCM cipher keyed by the store's key.
+func (st *Store) gcm(create bool) (cipher.AEAD, error) {
+	key, err := st.key(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt seals value, binding it to name so entries cannot be swapped.
+func (st *Store) encrypt(name, value string) (nonce, data string, err error) {
+	aead, err := st.gcm(true)
+	if err != nil {
+		return "", "", err
+	}
+	n := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(n); err != nil {
+		return "", "", fmt.Errorf("generating nonce: %w", err)
+	}
+	sealed := aead.Seal(nil, n, []byte(value), []byte(name))
+	return base64.StdEncoding.EncodeToString(n), base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (st *Store) decrypt(name string, e entry) (string, error) {
+	aead, err := st.gcm(false)
+	if err != nil {
+		return "", err
+	}
+	n, err := base64.StdEncoding.DecodeString(e.Nonce)
+	if err != nil || len(n) != aead.NonceSize() {
+		return "", fmt.Errorf("secret %s is corrupt", name)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		return "", fmt.Errorf("secret %s is corrupt", name)
+	}
+	value, err := aead.Open(nil, n, sealed, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("decrypting secret %s: the key or store was changed", name)
+	}
+	return string(value), nil
+}
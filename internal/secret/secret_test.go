@@ -0,0 +1,152 @@
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeKeychain is an in-memory keychain; fail makes every call fail, like a
+// Secret Service without a D-Bus session.
+type fakeKeychain struct {
+	values map[string]string
+	fail   bool
+}
+
+func (k *fakeKeychain) set(name, value string) error {
+	if k.fail {
+		return errors.New("no keychain session")
+	}
+	k.values[name] = value
+	return nil
+}
+
+func (k *fakeKeychain) get(name string) (string, error) {
+	v, ok := k.values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (k *fakeKeychain) remove(name string) error {
+	delete(k.values, name)
+	return nil
+}
+
+func TestFileBackendEncryptsValues(t *testing.T) {
+	t.Setenv(EnvBackend, "")
+	st := &Store{Dir: t.TempDir()}
+
+	backend, err := st.Set("github", "ghp_s3cret-value", "")
+	if err != nil || backend != BackendFile {
+		t.Fatalf("Set() = %q, %v; want the file backend without a keychain", backend, err)
+	}
+	if got, err := st.Get("github"); err != nil || got != "ghp_s3cret-value" {
+		t.Errorf("Get() = %q, %v; want the stored value", got, err)
+	}
+
+	for _, file := range []string{indexFile, keyFile} {
+		path := filepath.Join(st.Dir, file)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("%s not written: %v", file, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s permissions = %o, want 600", file, perm)
+		}
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), "s3cret") {
+			t.Errorf("%s contains the plain-text value", file)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(st.Dir, keyFile), make([]byte, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Get("github"); err == nil {
+		t.Error("Get() should fail when the key no longer matches")
+	}
+}
+
+func TestKeychainBackend(t *testing.T) {
+	t.Setenv(EnvBackend, "")
+	kc := &fakeKeychain{values: map[string]string{}}
+	st := &Store{Dir: t.TempDir(), keychain: kc}
+
+	backend, err := st.Set("slack", "xoxb-token", "")
+	if err != nil || backend != BackendKeychain {
+		t.Fatalf("Set() = %q, %v; want the keychain by default", backend, err)
+	}
+	if kc.values["slack"] != "xoxb-token" {
+		t.Errorf("keychain = %v, want the value stored there", kc.values)
+	}
+	data, _ := os.ReadFile(filepath.Join(st.Dir, indexFile))
+	if strings.Contains(string(data), "xoxb") {
+		t.Error("the index should not hold keychain-backed values")
+	}
+	if got, _ := st.Get("slack"); got != "xoxb-token" {
+		t.Errorf("Get() = %q, want the keychain value", got)
+	}
+
+	// Moving the secret to the file drops the keychain copy.
+	if _, err := st.Set("slack", "xoxb-new", BackendFile); err != nil {
+		t.Fatalf("Set(file) error: %v", err)
+	}
+	if _, ok := kc.values["slack"]; ok {
+		t.Error("moving a secret to the file should remove it from the keychain")
+	}
+
+	kc.fail = true
+	if backend, err := st.Set("jira", "jira-token", ""); err != nil || backend != BackendFile {
+		t.Errorf("Set() = %q, %v; want a fallback to the file when the keychain fails", backend, err)
+	}
+	if _, err := st.Set("jira", "jira-token", BackendKeychain); err == nil {
+		t.Error("an explicit keychain backend should not fall back")
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	t.Setenv(EnvBackend, BackendFile)
+	st := &Store{Dir: t.TempDir(), keychain: &fakeKeychain{values: map[string]string{}}}
+	for _, name := range []string{"slack", "github"} {
+		if backend, err := st.Set(name, "v-"+name, ""); err != nil || backend != BackendFile {
+			t.Fatalf("Set(%s) = %q, %v; want the backend from %s", name, backend, err, EnvBackend)
+		}
+	}
+
+	infos, err := st.List()
+	if err != nil || len(infos) != 2 || infos[0].Name != "github" || infos[1].Name != "slack" {
+		t.Fatalf("List() = %+v, %v; want github and slack", infos, err)
+	}
+	if err := st.Delete("github"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := st.Get("github"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() = %v, want ErrNotFound", err)
+	}
+	if err := st.Delete("github"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSetRejectsInvalidInput(t *testing.T) {
+	st := &Store{Dir: t.TempDir()}
+	for _, name := range []string{"", "GitHub", "../escape", "-dash"} {
+		if _, err := st.Set(name, "v", BackendFile); err == nil {
+			t.Errorf("Set(%q) should reject the name", name)
+		}
+	}
+	if _, err := st.Set("github", "", BackendFile); err == nil {
+		t.Error("Set() should reject an empty value")
+	}
+	if _, err := st.Set("github", "v", BackendKeychain); err == nil {
+		t.Error("Set() should fail for the keychain backend without a keychain")
+	}
+	t.Setenv(EnvBackend, "vault")
+	if _, err := st.Set("github", "v", ""); err == nil {
+		t.Errorf("Set() should reject an unknown %s", EnvBackend)
+	}
+}
@@ -0,0 +1,73 @@
+package selfupdate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Signatures use the minisign format. Only legacy, non-prehashed signatures
+// ("Ed", made with 'minisign -S -l') are accepted: prehashed ones ("ED") need
+// BLAKE2b, which the standard library lacks.
+
+// Minisign algorithm identifiers.
+const (
+	algEd25519   = "Ed"
+	algPrehashed = "ED"
+)
+
+// minisignKey is a parsed minisign public key.
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// parseMinisignKey reads a public key as printed by 'minisign -G': the base64
+// line of minisign.pub, optionally preceded by its untrusted comment.
+func parseMinisignKey(s string) (*minisignKey, error) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != algEd25519 {
+		return nil, fmt.Errorf("invalid minisign public key")
+	}
+	k := &minisignKey{key: ed25519.PublicKey(raw[10:])}
+	copy(k.id[:], raw[2:10])
+	return k, nil
+}
+
+// verify checks a minisign signature file over data: the signature of data
+// itself and the global signature binding the trusted comment to it.
+func (k *minisignKey) verify(data, sigFile []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(bytes.ReplaceAll(sigFile, []byte("\r\n"), []byte("\n")))), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("malformed signature file")
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("malformed signature")
+	}
+	switch string(sig[:2]) {
+	case algEd25519:
+	case algPrehashed:
+		return fmt.Errorf("prehashed signatures are not supported; sign with 'minisign -S -l'")
+	default:
+		return fmt.Errorf("unknown signature algorithm %q", sig[:2])
+	}
+	if !bytes.Equal(sig[2:10], k.id[:]) {
+		return fmt.Errorf("signed by key %X, want %X", sig[2:10], k.id)
+	}
+	if !ed25519.Verify(k.key, data, sig[10:]) {
+		return fmt.Errorf("signature does not match")
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed trusted comment signature")
+	}
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(k.key, append(sig[10:], trusted...), global) {
+		return fmt.Errorf("trusted comment signature does not match")
+	}
+	return nil
+}
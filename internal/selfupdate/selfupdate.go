@@ -0,0 +1,417 @@
+// Package selfupdate replaces the running tdd-ai binary with the latest
+// GitHub release, verifying the signature of the release's checksums.txt and
+// the downloaded archive against it before anything is written.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvVar names the environment variable overriding the GitHub API base URL,
+// for mirrors and GitHub Enterprise.
+const EnvVar = "TDD_AI_UPDATE_URL"
+
+const (
+	// DefaultAPI is the GitHub API the releases are fetched from.
+	DefaultAPI = "https://api.github.com"
+	// DefaultRepo is the repository publishing tdd-ai releases.
+	DefaultRepo = "mauricioTechDev/tdd-ai"
+	// ChecksumsAsset is the release asset listing each archive's SHA-256, as
+	// written by GoReleaser.
+	ChecksumsAsset = "checksums.txt"
+	// SignatureAsset is the minisign signature of ChecksumsAsset.
+	SignatureAsset = ChecksumsAsset + ".minisig"
+)
+
+// PublicKey is the minisign public key release checksums must be signed
+// with, pinned into release builds with -ldflags -X. A build without one
+// refuses to install updates.
+var PublicKey = ""
+
+// Release channels.
+const (
+	ChannelStable  = "stable"
+	ChannelPreview = "preview"
+)
+
+// Channels lists the valid release channels.
+var Channels = []string{ChannelStable, ChannelPreview}
+
+// maxDownload bounds the size of a downloaded asset.
+const maxDownload = 200 << 20
+
+// Release is a GitHub release as returned by the releases API.
+type Release struct {
+	Tag        string  `json:"tag_name"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release's version without the tag's "v" prefix.
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+func (r *Release) asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// Updater fetches releases of Repo from a GitHub-compatible API. Every URL
+// it fetches must be https, and downloads are verified with PublicKey.
+type Updater struct {
+	API       string
+	Repo      string
+	PublicKey string
+	Client    *http.Client
+}
+
+// New returns an Updater for the official releases, honoring TDD_AI_UPDATE_URL,
+// which must be an https URL.
+func New() (*Updater, error) {
+	api := os.Getenv(EnvVar)
+	if api == "" {
+		api = DefaultAPI
+	}
+	if err := requireHTTPS(api); err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvVar, err)
+	}
+	client := &http.Client{
+		Timeout: 2 * time.Minute,
+		// Release downloads redirect to a CDN; never follow one off https.
+		CheckRedirect: func(req *http.Request, _ []*http.Request) error {
+			return requireHTTPS(req.URL.String())
+		},
+	}
+	return &Updater{API: strings.TrimSuffix(api, "/"), Repo: DefaultRepo, PublicKey: PublicKey, Client: client}, nil
+}
+
+// requireHTTPS refuses URLs that are not https, which anyone on the network
+// path could rewrite.
+func requireHTTPS(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q is not an https URL", rawURL)
+	}
+	return nil
+}
+
+// Latest returns the newest published release on channel: stable skips
+// prereleases, preview takes whichever release is newest.
+func (u *Updater) Latest(channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelPreview {
+		return nil, fmt.Errorf("unknown channel %q (valid: %s)", channel, strings.Join(Channels, ", "))
+	}
+	data, err := u.get(fmt.Sprintf("%s/repos/%s/releases?per_page=30", u.API, u.Repo))
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	var releases []Release
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("parsing releases: %w", err)
+	}
+	var latest *Release
+	for i, r := range releases {
+		if r.Draft || (r.Prerelease && channel == ChannelStable) {
+			continue
+		}
+		if latest == nil || Newer(r.Version(), latest.Version()) {
+			latest = &releases[i]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no %s release of %s found", channel, u.Repo)
+	}
+	return latest, nil
+}
+
+// Download fetches the release archive for goos/goarch, verifies the
+// signature of the release's checksums.txt and the archive's SHA-256 against
+// it, and returns the tdd-ai binary inside.
+func (u *Updater) Download(r *Release, goos, goarch string) ([]byte, error) {
+	if u.PublicKey == "" {
+		return nil, fmt.Errorf("this build has no release signing key; refusing to install an unverified binary")
+	}
+	key, err := parseMinisignKey(u.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	name := AssetName(r.Version(), goos, goarch)
+	archive := r.asset(name)
+	if archive == nil {
+		return nil, fmt.Errorf("release %s has no build for %s/%s (%s)", r.Tag, goos, goarch, name)
+	}
+	sums := r.asset(ChecksumsAsset)
+	if sums == nil {
+		return nil, fmt.Errorf("release %s has no %s; refusing to install an unverified binary", r.Tag, ChecksumsAsset)
+	}
+	sig := r.asset(SignatureAsset)
+	if sig == nil {
+		return nil, fmt.Errorf("release %s has no %s; refusing to install an unverified binary", r.Tag, SignatureAsset)
+	}
+
+	sumData, err := u.get(sums.URL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", ChecksumsAsset, err)
+	}
+	sigData, err := u.get(sig.URL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", SignatureAsset, err)
+	}
+	if err := key.verify(sumData, sigData); err != nil {
+		return nil, fmt.Errorf("verifying %s: %w", ChecksumsAsset, err)
+	}
+	want, err := checksumFor(sumData, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := u.get(archive.URL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return extractBinary(name, data)
+}
+
+func (u *Updater) get(url string) ([]byte, error) {
+	if err := requireHTTPS(url); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownload {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", url, maxDownload)
+	}
+	return data, nil
+}
+
+// AssetName returns the archive name GoReleaser gives a build, e.g.
+// tdd-ai_0.5.0_linux_amd64.tar.gz.
+func AssetName(version, goos, goarch string) string {
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("tdd-ai_%s_%s_%s.%s", version, goos, goarch, ext)
+}
+
+// checksumFor finds name's SHA-256 in a checksums file of "<hex>  <name>" lines.
+func checksumFor(sums []byte, name string) (string, error) {
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", ChecksumsAsset, name)
+}
+
+// extractBinary returns the tdd-ai executable inside a .tar.gz or .zip archive.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	isBinary := func(path string) bool {
+		base := filepath.Base(path)
+		return base == "tdd-ai" || base == "tdd-ai.exe"
+	}
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if !isBinary(f.Name) {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxDownload))
+		}
+		return nil, fmt.Errorf("%s does not contain a tdd-ai binary", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s does not contain a tdd-ai binary", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && isBinary(hdr.Name) {
+			return io.ReadAll(io.LimitReader(tr, maxDownload))
+		}
+	}
+}
+
+// Replace swaps the executable at exe for binary. The new file is written next
+// to exe and renamed over it, so a failure leaves the old binary in place. The
+// old binary is moved aside first because Windows cannot overwrite a running
+// executable; the leftover .old file is removed where the OS allows it.
+func Replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".tdd-ai-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	old := exe + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return fmt.Errorf("moving %s aside: %w", exe, err)
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		_ = os.Rename(old, exe)
+		return fmt.Errorf("installing new binary: %w", err)
+	}
+	_ = os.Remove(old)
+	return nil
+}
+
+// Newer reports whether version a is newer than b. Versions are compared as
+// semver, with a prerelease ("0.6.0-rc.1") older than its release. A
+// non-numeric version such as "dev" is older than any release.
+func Newer(a, b string) bool {
+	return compareVersions(strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")) > 0
+}
+
+func compareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(a, "-")
+	bCore, bPre, _ := strings.Cut(b, "-")
+	aNums, aOK := parseCore(aCore)
+	bNums, bOK := parseCore(bCore)
+	switch {
+	case !aOK && !bOK:
+		return 0
+	case !aOK:
+		return -1
+	case !bOK:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if aNums[i] != bNums[i] {
+			if aNums[i] > bNums[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return comparePrerelease(aPre, bPre)
+}
+
+func parseCore(core string) ([3]int, bool) {
+	var nums [3]int
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return nums, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nums, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// comparePrerelease compares dot-separated prerelease identifiers, numerically
+// where both are numbers.
+func comparePrerelease(a, b string) int {
+	ap, bp := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an > bn {
+				return 1
+			}
+			return -1
+		case aErr != nil || bErr != nil:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(ap) - len(bp)
+}
@@ -0,0 +1,290 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarGz builds a release archive holding a tdd-ai binary with content.
+func tarGz(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct{ name, body string }{{"README.md", "readme"}, {"tdd-ai", content}} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o755, Size: int64(len(f.body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// testKeyID is the minisign key ID of the keys signing test releases.
+var testKeyID = []byte("tdd-test")
+
+// newTestKey returns a signing key and its public key in minisign format.
+func newTestKey(t *testing.T) (ed25519.PrivateKey, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := append(append([]byte(algEd25519), testKeyID...), pub...)
+	return priv, "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+}
+
+// minisign signs data as 'minisign -S -l' would.
+func minisign(priv ed25519.PrivateKey, data []byte, trusted string) []byte {
+	sig := append(append([]byte(algEd25519), testKeyID...), ed25519.Sign(priv, data)...)
+	global := ed25519.Sign(priv, append(ed25519.Sign(priv, data), trusted...))
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(sig), trusted, base64.StdEncoding.EncodeToString(global)))
+}
+
+// releaseServer serves over TLS a releases API listing a stable 0.5.0 and a
+// preview 0.6.0-rc.1, each with a linux/amd64 archive and a checksums.txt
+// signed by priv.
+func releaseServer(t *testing.T, priv ed25519.PrivateKey, tamper bool) *httptest.Server {
+	t.Helper()
+	files := make(map[string][]byte)
+	mux := http.NewServeMux()
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+
+	var releases []Release
+	for _, r := range []struct {
+		tag        string
+		prerelease bool
+	}{{"v0.6.0-rc.1", true}, {"v0.5.0", false}, {"v0.4.2", false}} {
+		version := strings.TrimPrefix(r.tag, "v")
+		name := AssetName(version, "linux", "amd64")
+		archive := tarGz(t, "binary "+version)
+		sum := sha256.Sum256(archive)
+		if tamper {
+			archive = tarGz(t, "tampered")
+		}
+		sums := []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), name))
+		files[r.tag+"/"+name] = archive
+		files[r.tag+"/"+ChecksumsAsset] = sums
+		files[r.tag+"/"+SignatureAsset] = minisign(priv, sums, "timestamp:1760000000\tfile:checksums.txt")
+		releases = append(releases, Release{Tag: r.tag, Prerelease: r.prerelease, Assets: []Asset{
+			{Name: name, URL: srv.URL + "/download/" + r.tag + "/" + name},
+			{Name: ChecksumsAsset, URL: srv.URL + "/download/" + r.tag + "/" + ChecksumsAsset},
+			{Name: SignatureAsset, URL: srv.URL + "/download/" + r.tag + "/" + SignatureAsset},
+		}})
+	}
+	releases = append(releases, Release{Tag: "v9.9.9", Draft: true})
+
+	mux.HandleFunc("/repos/"+DefaultRepo+"/releases", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(releases)
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	})
+	return srv
+}
+
+// testUpdater returns an Updater for srv that trusts pub.
+func testUpdater(srv *httptest.Server, pub string) *Updater {
+	return &Updater{API: srv.URL, Repo: DefaultRepo, PublicKey: pub, Client: srv.Client()}
+}
+
+func TestLatestHonorsChannel(t *testing.T) {
+	priv, pub := newTestKey(t)
+	u := testUpdater(releaseServer(t, priv, false), pub)
+
+	for channel, want := range map[string]string{ChannelStable: "0.5.0", ChannelPreview: "0.6.0-rc.1"} {
+		rel, err := u.Latest(channel)
+		if err != nil {
+			t.Fatalf("Latest(%s) error: %v", channel, err)
+		}
+		if rel.Version() != want {
+			t.Errorf("Latest(%s) = %s, want %s", channel, rel.Version(), want)
+		}
+	}
+	if _, err := u.Latest("nightly"); err == nil {
+		t.Error("Latest should reject an unknown channel")
+	}
+}
+
+func TestDownloadVerifiesChecksum(t *testing.T) {
+	priv, pub := newTestKey(t)
+	u := testUpdater(releaseServer(t, priv, false), pub)
+	rel, err := u.Latest(ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary, err := u.Download(rel, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if string(binary) != "binary 0.5.0" {
+		t.Errorf("binary = %q, want the archived tdd-ai", binary)
+	}
+	if _, err := u.Download(rel, "plan9", "amd64"); err == nil || !strings.Contains(err.Error(), "no build") {
+		t.Errorf("missing platform: err = %v, want no build", err)
+	}
+
+	tampered := testUpdater(releaseServer(t, priv, true), pub)
+	rel, err = tampered.Latest(ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tampered.Download(rel, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("tampered archive: err = %v, want checksum mismatch", err)
+	}
+}
+
+func TestDownloadVerifiesSignature(t *testing.T) {
+	priv, pub := newTestKey(t)
+	otherPriv, otherPub := newTestKey(t)
+	srv := releaseServer(t, priv, false)
+	rel, err := testUpdater(srv, pub).Latest(ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		u    *Updater
+		rel  *Release
+		want string
+	}{
+		{"other key", testUpdater(srv, otherPub), rel, "signature does not match"},
+		{"no key", testUpdater(srv, ""), rel, "no release signing key"},
+		{"bad key", testUpdater(srv, "not a key"), rel, "invalid minisign public key"},
+		{"unsigned release", testUpdater(srv, pub), &Release{Tag: rel.Tag, Assets: rel.Assets[:2]}, "no " + SignatureAsset},
+	}
+	for _, tt := range tests {
+		if _, err := tt.u.Download(tt.rel, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Checksums re-signed by an attacker's key do not verify either.
+	forged := releaseServer(t, otherPriv, true)
+	rel, err = testUpdater(forged, pub).Latest(ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testUpdater(forged, pub).Download(rel, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "verifying checksums.txt") {
+		t.Errorf("forged checksums: err = %v, want a verification failure", err)
+	}
+}
+
+func TestMinisignVerify(t *testing.T) {
+	priv, pub := newTestKey(t)
+	key, err := parseMinisignKey(pub)
+	if err != nil {
+		t.Fatalf("parseMinisignKey() error: %v", err)
+	}
+	data := []byte("checksums")
+	good := minisign(priv, data, "file:checksums.txt")
+	if err := key.verify(data, good); err != nil {
+		t.Fatalf("verify() error: %v", err)
+	}
+
+	lines := strings.Split(string(good), "\n")
+	prehashed, _ := base64.StdEncoding.DecodeString(lines[1])
+	copy(prehashed, algPrehashed)
+	tests := map[string]struct {
+		data, sig []byte
+		want      string
+	}{
+		"modified data":    {[]byte("checksumz"), good, "signature does not match"},
+		"modified comment": {data, []byte(strings.Replace(string(good), "file:", "FILE:", 1)), "trusted comment signature"},
+		"prehashed":        {data, []byte(strings.Join([]string{lines[0], base64.StdEncoding.EncodeToString(prehashed), lines[2], lines[3]}, "\n")), "prehashed"},
+		"truncated":        {data, []byte(lines[0] + "\n" + lines[1]), "malformed signature file"},
+		"not a signature":  {data, []byte("<html>404</html>"), "malformed signature file"},
+	}
+	for name, tt := range tests {
+		if err := key.verify(tt.data, tt.sig); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: verify() error = %v, want it to contain %q", name, err, tt.want)
+		}
+	}
+}
+
+func TestNewRequiresHTTPS(t *testing.T) {
+	for api, ok := range map[string]bool{
+		"":                           true,
+		"https://ghe.example/api/v3": true,
+		"http://ghe.example/api/v3":  false,
+		"ghe.example":                false,
+		"file:///tmp/releases":       false,
+	} {
+		t.Setenv(EnvVar, api)
+		u, err := New()
+		if (err == nil) != ok {
+			t.Errorf("New() with %s=%q: err = %v, want ok %v", EnvVar, api, err, ok)
+		}
+		if err == nil && !strings.HasPrefix(u.API, "https://") {
+			t.Errorf("New() API = %q, want https", u.API)
+		}
+	}
+
+	u := &Updater{API: "http://127.0.0.1:1", Repo: DefaultRepo}
+	if _, err := u.Latest(ChannelStable); err == nil || !strings.Contains(err.Error(), "not an https URL") {
+		t.Errorf("Latest() over http: err = %v, want it refused", err)
+	}
+}
+
+func TestReplaceSwapsBinary(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "tdd-ai")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(exe, []byte("new")); err != nil {
+		t.Fatalf("Replace() error: %v", err)
+	}
+	data, _ := os.ReadFile(exe)
+	info, _ := os.Stat(exe)
+	if string(data) != "new" || info.Mode().Perm()&0o111 == 0 {
+		t.Errorf("binary = %q mode %v, want new and executable", data, info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("Replace left files behind: %v", entries)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"0.6.0", "0.5.9", true},
+		{"v0.10.0", "0.9.0", true},
+		{"0.5.0", "0.5.0", false},
+		{"0.6.0", "0.6.0-rc.1", true},
+		{"0.6.0-rc.2", "0.6.0-rc.10", false},
+		{"0.5.0", "dev", true},
+		{"dev", "0.5.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,28 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/macosta/tdd-ai/internal/audit"
+	"github.com/macosta/tdd-ai/internal/spectemplate"
+	"github.com/macosta/tdd-ai/internal/testrun"
 	"github.com/macosta/tdd-ai/internal/types"
 )
 
 const DefaultFileName = ".tdd-ai.json"
 
+// ErrNoSession is returned by LoadOrFail when the directory has no session file.
+var ErrNoSession = errors.New("no TDD session found. Run 'tdd-ai init' first")
+
+// TrashDirName is the directory where reset sessions are kept for restore.
+const TrashDirName = ".tdd-ai.trash"
+
 // FilePath returns the session file path for a given directory.
 func FilePath(dir string) string {
 	return filepath.Join(dir, DefaultFileName)
@@ -22,6 +35,22 @@ func Exists(dir string) bool {
 	return err == nil
 }
 
+// FindRoot returns the nearest directory at or above dir that holds a session
+// file, the way git finds the repository root, and false when there is none.
+func FindRoot(dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for {
+		if Exists(dir) {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
 // Create initializes a new session and saves it to disk.
 func Create(dir string) (*types.Session, error) {
 	return CreateWithMode(dir, types.ModeGreenfield)
@@ -39,7 +68,12 @@ func CreateWithMode(dir string, mode types.Mode) (*types.Session, error) {
 
 // Load reads a session from the given directory.
 func Load(dir string) (*types.Session, error) {
-	data, err := os.ReadFile(FilePath(dir))
+	return LoadFile(FilePath(dir))
+}
+
+// LoadFile reads a session from an explicit file path.
+func LoadFile(path string) (*types.Session, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading session file: %w", err)
 	}
@@ -51,22 +85,295 @@ func Load(dir string) (*types.Session, error) {
 	return &s, nil
 }
 
-// Save writes the session state to disk.
+// Save writes the session state to disk. A hook registered with BeforeSave
+// can refuse the write, as can the history budget. When the audit log is
+// enabled, history events not yet audited are appended to it only once nothing
+// can refuse the save, so events the history budget then drops are still
+// audited, and the session records the log's new head only once it is
+// written.
 func Save(dir string, s *types.Session) error {
+	if saveHook != nil {
+		if err := saveHook(dir, s); err != nil {
+			return err
+		}
+	}
+	var pending []types.Event
+	if s.AuditLog && s.AuditedEvents < len(s.History) {
+		pending = append(pending, s.History[s.AuditedEvents:]...)
+	}
+	if err := s.EnforceHistoryBudget(); err != nil {
+		return err
+	}
+
+	audited, entries, hash := s.AuditedEvents, s.AuditEntries, s.AuditHash
+	if len(pending) > 0 {
+		head, err := audit.Append(dir, audit.Head{Entries: s.AuditEntries, Hash: s.AuditHash}, pending)
+		if err != nil {
+			return err
+		}
+		s.AuditEntries, s.AuditHash = head.Entries, head.Hash
+	}
+	if s.AuditLog {
+		s.AuditedEvents = len(s.History)
+	}
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
+		s.AuditedEvents, s.AuditEntries, s.AuditHash = audited, entries, hash
 		return fmt.Errorf("encoding session: %w", err)
 	}
-	if err := os.WriteFile(FilePath(dir), data, 0644); err != nil {
+	if err := writeFileAtomic(FilePath(dir), data); err != nil {
+		s.AuditedEvents, s.AuditEntries, s.AuditHash = audited, entries, hash
 		return fmt.Errorf("writing session file: %w", err)
 	}
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers never see a partly written file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LockFileName is the lock file held while a command updates the session.
+const LockFileName = ".tdd-ai.lock"
+
+// Lock timing: how often a held lock is retried, how long to wait for it, and
+// how old a lock file must be to count as left behind by a crashed process.
+const (
+	lockRetry    = 10 * time.Millisecond
+	lockTimeout  = 10 * time.Second
+	lockStaleAge = 30 * time.Second
+)
+
+// Lock takes an exclusive lock on the session in dir, for commands that load,
+// change, and save it while other agents may do the same. It waits for a lock
+// held by another process and returns a function that releases it.
+func Lock(dir string) (func(), error) {
+	path := filepath.Join(dir, LockFileName)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("locking session: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStaleAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("session is locked by another tdd-ai process; remove %s if none is running", path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// loadHook runs on every session returned by LoadOrFail.
+var loadHook func(*types.Session)
+
+// OnLoad registers fn to run on every session LoadOrFail returns, e.g. to apply
+// organization-locked settings over what is stored locally. Pass nil to clear it.
+func OnLoad(fn func(*types.Session)) {
+	loadHook = fn
+}
+
+// saveHook runs before every Save.
+var saveHook func(dir string, s *types.Session) error
+
+// BeforeSave registers fn to run before every Save, e.g. to check the session
+// is changed only where it belongs. An error from fn aborts the save and is
+// returned by Save. Pass nil to clear it.
+func BeforeSave(fn func(dir string, s *types.Session) error) {
+	saveHook = fn
+}
+
 // LoadOrFail loads a session and returns a user-friendly error if none exists.
 func LoadOrFail(dir string) (*types.Session, error) {
 	if !Exists(dir) {
-		return nil, fmt.Errorf("no TDD session found. Run 'tdd-ai init' first")
+		return nil, ErrNoSession
+	}
+	s, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if loadHook != nil {
+		loadHook(s)
+	}
+	return s, nil
+}
+
+// TrashDir returns the trash directory path for a given directory.
+func TrashDir(dir string) string {
+	return filepath.Join(dir, TrashDirName)
+}
+
+// SideFiles returns the files and directories in dir that belong to the
+// session and move with it to the trash and back: the spec archive, the audit
+// log, background test runs, and spec templates.
+func SideFiles(dir string) []string {
+	return []string{ArchivePath(dir), filepath.Join(dir, audit.FileName), testrun.Dir(dir), spectemplate.Path(dir)}
+}
+
+// Trash moves the session file into the trash directory under a timestamped name
+// and returns the new path. Its side files move into a directory of the same
+// name without the .json extension.
+func Trash(dir string) (string, error) {
+	if err := os.MkdirAll(TrashDir(dir), 0755); err != nil {
+		return "", fmt.Errorf("creating trash directory: %w", err)
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + ".json"
+	dest := filepath.Join(TrashDir(dir), name)
+	side := strings.TrimSuffix(dest, ".json")
+	for _, path := range SideFiles(dir) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.MkdirAll(side, 0755); err != nil {
+			return "", fmt.Errorf("creating trash directory: %w", err)
+		}
+		if err := os.Rename(path, filepath.Join(side, filepath.Base(path))); err != nil {
+			return "", fmt.Errorf("moving %s to trash: %w", filepath.Base(path), err)
+		}
+	}
+	if err := os.Rename(FilePath(dir), dest); err != nil {
+		return "", fmt.Errorf("moving session to trash: %w", err)
+	}
+	return dest, nil
+}
+
+// Restore moves the most recently trashed session and its side files back
+// into place and returns the trash path it was restored from. Fails if a
+// session, or any of the side files, already exists.
+func Restore(dir string) (string, error) {
+	if Exists(dir) {
+		return "", fmt.Errorf("a TDD session already exists. Run 'tdd-ai reset' before restoring")
+	}
+	trashed, err := TrashedFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(trashed) == 0 {
+		return "", fmt.Errorf("no trashed sessions to restore")
+	}
+
+	src := trashed[len(trashed)-1]
+	side := strings.TrimSuffix(src, ".json")
+	entries, err := os.ReadDir(side)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading trash directory: %w", err)
+	}
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(dir, e.Name())); err == nil {
+			return "", fmt.Errorf("cannot restore: %s already exists. Move it aside first", e.Name())
+		}
+	}
+	for _, e := range entries {
+		if err := os.Rename(filepath.Join(side, e.Name()), filepath.Join(dir, e.Name())); err != nil {
+			return "", fmt.Errorf("restoring %s: %w", e.Name(), err)
+		}
+	}
+	if err := os.Rename(src, FilePath(dir)); err != nil {
+		return "", fmt.Errorf("restoring session: %w", err)
+	}
+	if err := os.Remove(side); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("removing trash directory: %w", err)
+	}
+	return src, nil
+}
+
+// TrashedFiles returns the paths of the sessions in the trash directory,
+// oldest first.
+func TrashedFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(TrashDir(dir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading trash directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(TrashDir(dir), name)
+	}
+	return paths, nil
+}
+
+// ArchiveFileName is the side file completed specs are moved to by
+// 'tdd-ai spec archive', keeping them out of the session file.
+const ArchiveFileName = ".tdd-ai.archive.json"
+
+// ArchivePath returns the spec archive path for a given directory.
+func ArchivePath(dir string) string {
+	return filepath.Join(dir, ArchiveFileName)
+}
+
+// LoadArchive reads the archived specs in dir. A missing archive holds no specs.
+func LoadArchive(dir string) ([]types.Spec, error) {
+	data, err := os.ReadFile(ArchivePath(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading spec archive: %w", err)
+	}
+	var specs []types.Spec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("parsing spec archive: %w", err)
+	}
+	return specs, nil
+}
+
+// AppendArchive adds specs to the archive in dir.
+func AppendArchive(dir string, specs []types.Spec) error {
+	archived, err := LoadArchive(dir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(append(archived, specs...), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding spec archive: %w", err)
+	}
+	if err := os.WriteFile(ArchivePath(dir), data, 0644); err != nil {
+		return fmt.Errorf("writing spec archive: %w", err)
+	}
+	return nil
+}
+
+// IncludeArchive returns a copy of s whose specs include the archived ones, for
+// reports that should cover every spec ever completed.
+func IncludeArchive(dir string, s *types.Session) (*types.Session, error) {
+	archived, err := LoadArchive(dir)
+	if err != nil || len(archived) == 0 {
+		return s, err
 	}
-	return Load(dir)
+	full := *s
+	full.Specs = append(append([]types.Spec(nil), archived...), s.Specs...)
+	return &full, nil
 }
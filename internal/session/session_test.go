@@ -1,9 +1,17 @@
 package session
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/macosta/tdd-ai/internal/audit"
+	"github.com/macosta/tdd-ai/internal/testrun"
 	"github.com/macosta/tdd-ai/internal/types"
 )
 
@@ -171,3 +179,365 @@ func TestLoadCorruptedFile(t *testing.T) {
 		t.Error("Load() should return error for corrupted file")
 	}
 }
+
+func TestTrashAndRestore(t *testing.T) {
+	dir := tempDir(t)
+	s := types.NewSession()
+	s.AddSpec("keep me")
+	if err := Save(dir, s); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	dest, err := Trash(dir)
+	if err != nil {
+		t.Fatalf("Trash() error: %v", err)
+	}
+	if Exists(dir) {
+		t.Error("session file should be gone after Trash()")
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("trashed file should exist at %s: %v", dest, err)
+	}
+
+	if _, err := Restore(dir); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() after restore error: %v", err)
+	}
+	if len(loaded.Specs) != 1 || loaded.Specs[0].Description != "keep me" {
+		t.Errorf("restored session specs = %+v, want the trashed spec", loaded.Specs)
+	}
+}
+
+func TestRestorePicksNewestTrashedSession(t *testing.T) {
+	dir := tempDir(t)
+	for _, desc := range []string{"older", "newer"} {
+		s := types.NewSession()
+		s.AddSpec(desc)
+		if err := Save(dir, s); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+		if _, err := Trash(dir); err != nil {
+			t.Fatalf("Trash() error: %v", err)
+		}
+	}
+
+	if _, err := Restore(dir); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	loaded, _ := Load(dir)
+	if loaded.Specs[0].Description != "newer" {
+		t.Errorf("Restore() should bring back the newest session, got %q", loaded.Specs[0].Description)
+	}
+}
+
+func TestTrashMovesSideFilesWithSession(t *testing.T) {
+	dir := tempDir(t)
+	if err := Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if err := AppendArchive(dir, []types.Spec{{ID: 1, Description: "archived"}}); err != nil {
+		t.Fatalf("AppendArchive() error: %v", err)
+	}
+	if err := os.MkdirAll(testrun.Dir(dir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(testrun.LogPath(dir, "abc"), []byte("ok\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Trash(dir); err != nil {
+		t.Fatalf("Trash() error: %v", err)
+	}
+	for _, path := range SideFiles(dir) {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should move to the trash with the session, got %v", filepath.Base(path), err)
+		}
+	}
+	if archived, _ := LoadArchive(dir); len(archived) != 0 {
+		t.Errorf("a new session should not inherit the archive, got %+v", archived)
+	}
+
+	if _, err := Restore(dir); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if archived, _ := LoadArchive(dir); len(archived) != 1 || archived[0].Description != "archived" {
+		t.Errorf("Restore() should bring the archive back, got %+v", archived)
+	}
+	if _, err := os.Stat(testrun.LogPath(dir, "abc")); err != nil {
+		t.Errorf("Restore() should bring background runs back: %v", err)
+	}
+	if entries, _ := os.ReadDir(TrashDir(dir)); len(entries) != 0 {
+		t.Errorf("trash should be empty after restore, got %d entries", len(entries))
+	}
+}
+
+func TestRestoreRefusesToOverwriteSideFiles(t *testing.T) {
+	dir := tempDir(t)
+	if err := Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if err := AppendArchive(dir, []types.Spec{{ID: 1, Description: "old"}}); err != nil {
+		t.Fatalf("AppendArchive() error: %v", err)
+	}
+	if _, err := Trash(dir); err != nil {
+		t.Fatalf("Trash() error: %v", err)
+	}
+	if err := AppendArchive(dir, []types.Spec{{ID: 1, Description: "new"}}); err != nil {
+		t.Fatalf("AppendArchive() error: %v", err)
+	}
+
+	if _, err := Restore(dir); err == nil || !strings.Contains(err.Error(), ArchiveFileName) {
+		t.Errorf("Restore() error = %v, want it to refuse overwriting %s", err, ArchiveFileName)
+	}
+	if Exists(dir) {
+		t.Error("a refused restore should leave the session in the trash")
+	}
+	if archived, _ := LoadArchive(dir); len(archived) != 1 || archived[0].Description != "new" {
+		t.Errorf("a refused restore should leave the existing archive alone, got %+v", archived)
+	}
+}
+
+func TestRestoreFailsWhenSessionExists(t *testing.T) {
+	dir := tempDir(t)
+	if _, err := Create(dir); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if _, err := Restore(dir); err == nil {
+		t.Error("Restore() should fail when a session already exists")
+	}
+}
+
+func TestRestoreFailsWithEmptyTrash(t *testing.T) {
+	dir := tempDir(t)
+	if _, err := Restore(dir); err == nil {
+		t.Error("Restore() should fail when nothing is trashed")
+	}
+}
+
+func TestSaveAppendsToAuditLog(t *testing.T) {
+	dir := tempDir(t)
+	s := types.NewSession()
+	s.AddEvent("init")
+	if err := Save(dir, s); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := os.Stat(audit.Path(dir)); !os.IsNotExist(err) {
+		t.Fatal("Save() should not write an audit log unless enabled")
+	}
+
+	s.AuditLog = true
+	s.AddEvent("spec_add")
+	if err := Save(dir, s); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if err := Save(dir, s); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	n, err := audit.Verify(dir, audit.Head{Entries: s.AuditEntries, Hash: s.AuditHash})
+	if err != nil {
+		t.Fatalf("audit.Verify() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("audit log has %d entries, want 2 (each event once)", n)
+	}
+	if s.AuditedEvents != 2 || s.AuditEntries != 2 || s.AuditHash == "" {
+		t.Errorf("AuditedEvents = %d, AuditEntries = %d, AuditHash = %q; want 2, 2, the last hash", s.AuditedEvents, s.AuditEntries, s.AuditHash)
+	}
+}
+
+func TestArchiveAppendsAndIncludes(t *testing.T) {
+	dir := tempDir(t)
+	if specs, err := LoadArchive(dir); err != nil || specs != nil {
+		t.Fatalf("LoadArchive() without a file = %v, %v; want nil, nil", specs, err)
+	}
+
+	s := types.NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+	_ = s.CompleteSpec(1)
+	if err := AppendArchive(dir, s.RemoveCompletedSpecs()); err != nil {
+		t.Fatalf("AppendArchive() error: %v", err)
+	}
+	if len(s.Specs) != 1 || s.Specs[0].ID != 2 {
+		t.Fatalf("session should keep only the active spec, got %+v", s.Specs)
+	}
+
+	full, err := IncludeArchive(dir, s)
+	if err != nil {
+		t.Fatalf("IncludeArchive() error: %v", err)
+	}
+	if len(full.Specs) != 2 || full.Specs[0].ID != 1 {
+		t.Errorf("IncludeArchive() specs = %+v, want archived spec 1 then spec 2", full.Specs)
+	}
+	if len(s.Specs) != 1 {
+		t.Error("IncludeArchive() must not modify the session it was given")
+	}
+}
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	if _, err := Create(root); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	got, ok := FindRoot(sub)
+	if !ok || got != root {
+		t.Errorf("FindRoot(%q) = %q, %v; want %q, true", sub, got, ok, root)
+	}
+	if got, ok := FindRoot(root); !ok || got != root {
+		t.Errorf("FindRoot(root) = %q, %v; want the directory itself", got, ok)
+	}
+}
+
+func TestBeforeSaveCanRefuse(t *testing.T) {
+	dir := tempDir(t)
+	s, _ := Create(dir)
+	defer BeforeSave(nil)
+
+	BeforeSave(func(_ string, s *types.Session) error {
+		if s.Phase != types.PhaseRed {
+			return errors.New("refused")
+		}
+		return nil
+	})
+	s.Phase = types.PhaseGreen
+	if err := Save(dir, s); err == nil || err.Error() != "refused" {
+		t.Fatalf("Save() error = %v, want the hook's error", err)
+	}
+	if loaded, _ := Load(dir); loaded.Phase != types.PhaseRed {
+		t.Errorf("a refused save must not write the session, got phase %q", loaded.Phase)
+	}
+}
+
+func TestLockKeepsConcurrentClaims(t *testing.T) {
+	dir := tempDir(t)
+	if err := Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	const agents = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, agents)
+	for i := range agents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(dir)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer unlock()
+			s, err := LoadOrFail(dir)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err := s.ClaimFiles(fmt.Sprintf("a%d", i), []string{fmt.Sprintf("f%d.go", i)}); err != nil {
+				errs <- err
+				return
+			}
+			errs <- Save(dir, s)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("claim failed: %v", err)
+		}
+	}
+
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(s.Claims) != agents {
+		t.Errorf("%d claims saved, want %d", len(s.Claims), agents)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.Name() != DefaultFileName {
+			t.Errorf("left behind %s", e.Name())
+		}
+	}
+}
+
+func TestLockLetsOneAgentAcquireLease(t *testing.T) {
+	dir := tempDir(t)
+	if err := Save(dir, types.NewSession()); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	const agents = 10
+	var wg sync.WaitGroup
+	won := make(chan string, agents)
+	for i := range agents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(dir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+			s, err := LoadOrFail(dir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			agent := fmt.Sprintf("a%d", i)
+			if s.AcquireLease(agent, time.Minute, time.Now()) != nil {
+				return
+			}
+			if err := Save(dir, s); err != nil {
+				t.Error(err)
+				return
+			}
+			won <- agent
+		}()
+	}
+	wg.Wait()
+	close(won)
+	if n := len(won); n != 1 {
+		t.Errorf("%d agents acquired the lease, want 1", n)
+	}
+}
+
+func TestSaveRefusedByHistoryBudgetLeavesAuditLogIntact(t *testing.T) {
+	dir := tempDir(t)
+	s := types.NewSession()
+	s.AuditLog = true
+	s.HistoryMaxEvents = 3
+	s.HistoryStrategy = types.HistoryError
+	for range 3 {
+		s.AddEvent("spec_add")
+		if err := Save(dir, s); err != nil {
+			t.Fatalf("Save() within the budget error: %v", err)
+		}
+	}
+
+	s.AddEvent("spec_add")
+	for range 2 {
+		if err := Save(dir, s); err == nil {
+			t.Fatal("Save() over the budget should be refused")
+		}
+	}
+
+	n, err := audit.Verify(dir, audit.Head{Entries: s.AuditEntries, Hash: s.AuditHash})
+	if err != nil {
+		t.Fatalf("refused saves should not touch the audit log: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("audit log has %d entries, want 3", n)
+	}
+}
@@ -0,0 +1,155 @@
+// Package sessionlint finds states a session can only reach through hand edits
+// or merges, such as a current spec that is already completed, and repairs them.
+package sessionlint
+
+import (
+	"fmt"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Issue is a single inconsistency found in a session.
+type Issue struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fix     string `json:"fix"`
+}
+
+// rule checks for one kind of inconsistency and knows how to repair it.
+type rule struct {
+	name  string
+	check func(s *types.Session) (message string, found bool)
+	fix   string
+	apply func(s *types.Session)
+}
+
+// rules run in order; duplicate IDs are repaired first because the other
+// rules look specs up by ID.
+var rules = []rule{
+	{
+		name: "duplicate_spec_id",
+		check: func(s *types.Session) (string, bool) {
+			dups := duplicateIDs(s)
+			return fmt.Sprintf("spec IDs used more than once: %v", dups), len(dups) > 0
+		},
+		fix:   "give every repeated spec after the first a new ID",
+		apply: renumberDuplicates,
+	},
+	{
+		name: "next_id_behind",
+		check: func(s *types.Session) (string, bool) {
+			highest := maxID(s)
+			return fmt.Sprintf("next_id is %d but spec %d already exists", s.NextID, highest), s.NextID <= highest
+		},
+		fix:   "set next_id past the highest spec ID",
+		apply: func(s *types.Session) { s.NextID = maxID(s) + 1 },
+	},
+	{
+		name: "current_spec_not_active",
+		check: func(s *types.Session) (string, bool) {
+			for _, id := range s.CurrentSpecIDs() {
+				spec := s.SpecByID(id)
+				if spec == nil {
+					return fmt.Sprintf("current spec %d does not exist", id), true
+				}
+				if spec.Status != types.SpecStatusActive {
+					return fmt.Sprintf("current spec %d is %s", id, spec.Status), true
+				}
+			}
+			return "", false
+		},
+		fix:   "clear the current spec selection",
+		apply: func(s *types.Session) { s.ClearCurrentSpec() },
+	},
+	{
+		name: "reflections_outside_refactor",
+		check: func(s *types.Session) (string, bool) {
+			pending := len(s.PendingReflections())
+			return fmt.Sprintf("%d unanswered reflection question(s) in the %s phase", pending, s.Phase),
+				s.Phase != types.PhaseRefactor && pending > 0
+		},
+		fix:   "drop the unanswered reflection questions",
+		apply: dropPendingReflections,
+	},
+	{
+		name: "done_with_active_specs",
+		check: func(s *types.Session) (string, bool) {
+			active := len(s.ActiveSpecs())
+			return fmt.Sprintf("phase is done but %d spec(s) are still active", active),
+				s.Phase == types.PhaseDone && active > 0
+		},
+		fix:   "move back to the red phase so the active specs can be worked",
+		apply: func(s *types.Session) { s.SetPhase(types.PhaseRed) },
+	},
+}
+
+// Check returns every inconsistency in s without changing it.
+func Check(s *types.Session) []Issue {
+	var issues []Issue
+	for _, r := range rules {
+		if msg, found := r.check(s); found {
+			issues = append(issues, Issue{Rule: r.name, Message: msg, Fix: r.fix})
+		}
+	}
+	return issues
+}
+
+// Fix repairs every inconsistency in s and returns the issues found before the
+// repair. A rule whose issue an earlier repair already resolved is skipped.
+func Fix(s *types.Session) []Issue {
+	issues := Check(s)
+	for _, r := range rules {
+		if _, found := r.check(s); found {
+			r.apply(s)
+		}
+	}
+	return issues
+}
+
+func duplicateIDs(s *types.Session) []int {
+	seen := make(map[int]int)
+	var dups []int
+	for _, spec := range s.Specs {
+		seen[spec.ID]++
+		if seen[spec.ID] == 2 {
+			dups = append(dups, spec.ID)
+		}
+	}
+	return dups
+}
+
+func maxID(s *types.Session) int {
+	highest := 0
+	for _, spec := range s.Specs {
+		if spec.ID > highest {
+			highest = spec.ID
+		}
+	}
+	return highest
+}
+
+func renumberDuplicates(s *types.Session) {
+	next := max(s.NextID, maxID(s)+1)
+	seen := make(map[int]bool)
+	for i := range s.Specs {
+		if seen[s.Specs[i].ID] {
+			s.Specs[i].ID = next
+			next++
+		}
+		seen[s.Specs[i].ID] = true
+	}
+	s.NextID = next
+}
+
+func dropPendingReflections(s *types.Session) {
+	kept := s.Reflections[:0]
+	for _, r := range s.Reflections {
+		if r.Answer != "" || r.Skipped {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	s.Reflections = kept
+}
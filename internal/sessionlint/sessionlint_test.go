@@ -0,0 +1,64 @@
+package sessionlint
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func ruleNames(issues []Issue) []string {
+	var names []string
+	for _, issue := range issues {
+		names = append(names, issue.Rule)
+	}
+	return names
+}
+
+func TestCheckConsistentSession(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("adds two numbers")
+	if err := s.SetCurrentSpec(1); err != nil {
+		t.Fatal(err)
+	}
+	if issues := Check(s); len(issues) != 0 {
+		t.Errorf("fresh session should be consistent, got %v", issues)
+	}
+}
+
+func TestCheckAndFixDriftedSession(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("adds two numbers")
+	s.AddSpec("subtracts two numbers")
+	s.Specs[1].ID = 1 // merged file with a clashing ID
+	s.NextID = 1
+	done := 1
+	s.Specs[0].Status = types.SpecStatusCompleted
+	s.CurrentSpecID = &done
+	s.Reflections = []types.ReflectionQuestion{{ID: 1, Question: "q1"}, {ID: 2, Question: "q2", Answer: "a"}}
+	s.Phase = types.PhaseDone
+
+	want := []string{"duplicate_spec_id", "next_id_behind", "current_spec_not_active", "reflections_outside_refactor", "done_with_active_specs"}
+	if got := ruleNames(Check(s)); len(got) != len(want) {
+		t.Fatalf("Check() rules = %v, want %v", got, want)
+	}
+
+	fixed := Fix(s)
+	if got := ruleNames(fixed); len(got) != len(want) {
+		t.Errorf("Fix() rules = %v, want %v", got, want)
+	}
+	if issues := Check(s); len(issues) != 0 {
+		t.Errorf("session should be consistent after Fix, got %v", issues)
+	}
+	if s.Specs[1].ID != 2 || s.NextID != 3 {
+		t.Errorf("duplicate should be renumbered to 2 with next_id 3, got id %d next %d", s.Specs[1].ID, s.NextID)
+	}
+	if s.CurrentSpecID != nil {
+		t.Error("current spec should be cleared")
+	}
+	if len(s.Reflections) != 1 || s.Reflections[0].Answer != "a" {
+		t.Errorf("only answered reflections should remain, got %+v", s.Reflections)
+	}
+	if s.Phase != types.PhaseRed {
+		t.Errorf("phase = %s, want red", s.Phase)
+	}
+}
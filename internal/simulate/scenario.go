@@ -0,0 +1,197 @@
+package simulate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a scripted sequence of commands and fake test results, replayed
+// against a throwaway session by 'tdd-ai simulate'.
+type Scenario struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Format is the --format every command runs with unless its args set one:
+	// json (the default, as an agent harness would read it) or text.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	Steps  []Step `json:"steps" yaml:"steps"`
+}
+
+// Step is one entry of a scenario: a command, or a fake test result recorded
+// as 'tdd-ai test record' would, followed by the state expected afterwards.
+type Step struct {
+	// Cmd is a command path as typed after 'tdd-ai', e.g. "spec add", with
+	// Args its arguments and flags, as in a 'tdd-ai batch' entry.
+	Cmd  string     `json:"cmd,omitempty" yaml:"cmd,omitempty"`
+	Args stringList `json:"args,omitempty" yaml:"args,omitempty"`
+	// Test is a fake test result, pass or fail; Output is the test output it
+	// is recorded with, so failing tests and triage hints are derived from it.
+	Test   string `json:"test,omitempty" yaml:"test,omitempty"`
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+	Expect Expect `json:"expect" yaml:"expect"`
+}
+
+// Expect is the state a step must leave behind. Unset fields are not checked,
+// except ExitCode: a step must succeed unless it expects to fail.
+type Expect struct {
+	ExitCode       int    `json:"exit_code,omitempty" yaml:"exit_code,omitempty"`
+	Phase          string `json:"phase,omitempty" yaml:"phase,omitempty"`
+	CurrentSpec    *int   `json:"current_spec,omitempty" yaml:"current_spec,omitempty"`
+	ActiveSpecs    *int   `json:"active_specs,omitempty" yaml:"active_specs,omitempty"`
+	Iteration      *int   `json:"iteration,omitempty" yaml:"iteration,omitempty"`
+	LastTestResult string `json:"last_test_result,omitempty" yaml:"last_test_result,omitempty"`
+	OutputContains string `json:"output_contains,omitempty" yaml:"output_contains,omitempty"`
+}
+
+// stringList accepts a list of scalars, so unquoted numbers such as spec IDs
+// can be written as arguments.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var items []any
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("args must be a list: %w", err)
+	}
+	*l = make([]string, len(items))
+	for i, item := range items {
+		switch v := item.(type) {
+		case string:
+			(*l)[i] = v
+		case float64, bool:
+			(*l)[i] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("args[%d] must be a scalar", i)
+		}
+	}
+	return nil
+}
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: args must be a list", node.Line)
+	}
+	*l = make([]string, len(node.Content))
+	for i, item := range node.Content {
+		if item.Kind != yaml.ScalarNode || item.Tag == "!!null" {
+			return fmt.Errorf("line %d: args[%d] must be a scalar", item.Line, i)
+		}
+		(*l)[i] = item.Value
+	}
+	return nil
+}
+
+// Load parses a scenario script written in YAML, or in JSON.
+func Load(data []byte) (*Scenario, error) {
+	var sc Scenario
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&sc); err != nil {
+			return nil, fmt.Errorf("invalid scenario: %w", err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&sc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("invalid scenario: %w", err)
+		}
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// Validate reports the first malformed step.
+func (sc *Scenario) Validate() error {
+	switch sc.Format {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("invalid format %q (valid: json, text)", sc.Format)
+	}
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+	for i, st := range sc.Steps {
+		if err := st.validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (st Step) validate() error {
+	switch {
+	case st.Cmd != "" && st.Test != "":
+		return fmt.Errorf("set either cmd or test, not both")
+	case st.Cmd == "" && st.Test == "":
+		return fmt.Errorf("missing cmd or test")
+	case st.Test != "" && st.Test != "pass" && st.Test != "fail":
+		return fmt.Errorf("test must be pass or fail, got %q", st.Test)
+	case st.Test == "" && st.Output != "":
+		return fmt.Errorf("output only applies to test steps")
+	case st.Expect.Phase != "" && !types.Phase(st.Expect.Phase).IsValid():
+		return fmt.Errorf("invalid expected phase %q", st.Expect.Phase)
+	}
+	return nil
+}
+
+// Label describes the step for reports, e.g. "spec pick 1" or "test fail".
+func (st Step) Label() string {
+	if st.Test != "" {
+		return "test " + st.Test
+	}
+	return strings.TrimSpace(st.Cmd + " " + strings.Join(st.Args, " "))
+}
+
+// Check compares the outcome of a step with what it expects: the exit code
+// and output of its command, and the session it left behind (nil when there
+// is none). It returns one message per mismatch.
+func (e Expect) Check(exitCode int, output string, s *types.Session) []string {
+	var failures []string
+	if exitCode != e.ExitCode {
+		failures = append(failures, fmt.Sprintf("exit code %d, want %d", exitCode, e.ExitCode))
+	}
+	if e.OutputContains != "" && !strings.Contains(output, e.OutputContains) {
+		failures = append(failures, fmt.Sprintf("output does not contain %q", e.OutputContains))
+	}
+	if !e.checksSession() {
+		return failures
+	}
+	if s == nil {
+		return append(failures, "no session to check")
+	}
+	if e.Phase != "" && string(s.Phase) != e.Phase {
+		failures = append(failures, fmt.Sprintf("phase %s, want %s", s.Phase, e.Phase))
+	}
+	if e.CurrentSpec != nil {
+		current := 0
+		if s.CurrentSpecID != nil {
+			current = *s.CurrentSpecID
+		}
+		if current != *e.CurrentSpec {
+			failures = append(failures, fmt.Sprintf("current spec %d, want %d", current, *e.CurrentSpec))
+		}
+	}
+	if e.ActiveSpecs != nil {
+		if n := len(s.ActiveSpecs()); n != *e.ActiveSpecs {
+			failures = append(failures, fmt.Sprintf("%d active spec(s), want %d", n, *e.ActiveSpecs))
+		}
+	}
+	if e.Iteration != nil && s.Iteration != *e.Iteration {
+		failures = append(failures, fmt.Sprintf("iteration %d, want %d", s.Iteration, *e.Iteration))
+	}
+	if e.LastTestResult != "" && s.LastTestResult != e.LastTestResult {
+		failures = append(failures, fmt.Sprintf("last test result %q, want %q", s.LastTestResult, e.LastTestResult))
+	}
+	return failures
+}
+
+func (e Expect) checksSession() bool {
+	return e.Phase != "" || e.CurrentSpec != nil || e.ActiveSpecs != nil || e.Iteration != nil || e.LastTestResult != ""
+}
@@ -0,0 +1,120 @@
+package simulate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestLoad(t *testing.T) {
+	yaml := "steps:\n  - cmd: spec pick\n    args: [1]\n    expect:\n      current_spec: 1\n"
+	json := `{"steps": [{"cmd": "spec pick", "args": [1], "expect": {"current_spec": 1}}]}`
+	for name, src := range map[string]string{"yaml": yaml, "json": json} {
+		sc, err := Load([]byte(src))
+		if err != nil {
+			t.Fatalf("%s: Load() error: %v", name, err)
+		}
+		st := sc.Steps[0]
+		if st.Label() != "spec pick 1" || st.Expect.CurrentSpec == nil || *st.Expect.CurrentSpec != 1 {
+			t.Errorf("%s: Load() step = %+v", name, st)
+		}
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	src := `# scenario
+name: "first cycle" # trailing comment
+format: text
+steps:
+  - cmd: spec add
+    args: [login works, "a, b", 'it''s', 42, true]
+  - test: fail
+    output: |
+      --- FAIL: TestLogin
+        indented # kept
+    expect: {exit_code: 2, iteration: 0}
+  -
+    cmd: phase
+`
+	sc, err := Load([]byte(src))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	zero := 0
+	want := &Scenario{
+		Name:   "first cycle",
+		Format: "text",
+		Steps: []Step{
+			{Cmd: "spec add", Args: stringList{"login works", "a, b", "it's", "42", "true"}},
+			{Test: "fail", Output: "--- FAIL: TestLogin\n  indented # kept\n", Expect: Expect{ExitCode: 2, Iteration: &zero}},
+			{Cmd: "phase"},
+		},
+	}
+	if !reflect.DeepEqual(sc, want) {
+		t.Errorf("Load() = %+v\nwant %+v", sc, want)
+	}
+}
+
+func TestLoadRejectsInvalidScenarios(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"empty", "", "no steps"},
+		{"yaml no steps", "name: empty", "no steps"},
+		{"yaml unknown field", "steps:\n  - cmd: init\n    exepct:\n      phase: red", "not found in type"},
+		{"yaml bad args", "steps:\n  - cmd: init\n    args: {a: 1}", "args must be a list"},
+		{"yaml null arg", "steps:\n  - cmd: init\n    args: [~]", "args[0] must be a scalar"},
+		{"yaml cmd and test", "steps:\n  - cmd: init\n    test: pass", "step 1: set either cmd or test"},
+		{"yaml duplicate key", "name: a\nname: b\nsteps:\n  - cmd: init", "already defined"},
+		{"yaml tab indent", "steps:\n\t- cmd: init", "invalid scenario"},
+		{"no steps", `{"name": "empty"}`, "no steps"},
+		{"unknown field", `{"steps": [{"cmd": "init", "exepct": {"phase": "red"}}]}`, "unknown field"},
+		{"cmd and test", `{"steps": [{"cmd": "init", "test": "pass"}]}`, "step 1: set either cmd or test"},
+		{"bad result", `{"steps": [{"test": "flaky"}]}`, `step 1: test must be pass or fail`},
+		{"output on cmd", `{"steps": [{"cmd": "init", "output": "x"}]}`, "step 1: output only applies"},
+		{"bad phase", `{"steps": [{"cmd": "init", "expect": {"phase": "blue"}}]}`, `invalid expected phase "blue"`},
+		{"bad format", `{"format": "xml", "steps": [{"cmd": "init"}]}`, `invalid format "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load([]byte(tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpectCheck(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("login works")
+	one, zero := 1, 0
+
+	if got := (Expect{}).Check(0, "", nil); len(got) != 0 {
+		t.Errorf("empty expectation should pass, got %v", got)
+	}
+	if got := (Expect{}).Check(2, "", nil); len(got) != 1 || got[0] != "exit code 2, want 0" {
+		t.Errorf("a failing step should fail unless expected, got %v", got)
+	}
+	if got := (Expect{Phase: "red"}).Check(0, "", nil); len(got) != 1 || got[0] != "no session to check" {
+		t.Errorf("session checks without a session should fail, got %v", got)
+	}
+
+	e := Expect{ExitCode: 2, Phase: "red", CurrentSpec: &zero, ActiveSpecs: &one, OutputContains: "blocked"}
+	if got := e.Check(2, "cannot advance: blocked", s); len(got) != 0 {
+		t.Errorf("matching state should pass, got %v", got)
+	}
+	e = Expect{Phase: "green", CurrentSpec: &one, ActiveSpecs: &zero, LastTestResult: "fail", OutputContains: "ok"}
+	want := []string{
+		`output does not contain "ok"`,
+		"phase red, want green",
+		"current spec 0, want 1",
+		"1 active spec(s), want 0",
+		`last test result "", want "fail"`,
+	}
+	if got := e.Check(0, "", s); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+// Package specimport adds specs from another session, a spec export, or an
+// issue tracker dump to a session, remapping their IDs so external references
+// to the old IDs stay resolvable.
+package specimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// Mapping statuses.
+const (
+	StatusAdded     = "added"
+	StatusRemapped  = "remapped"
+	StatusDuplicate = "duplicate"
+)
+
+// Mapping records where one imported spec ended up. Status is added when the
+// spec kept its ID, remapped when it got a new one, and duplicate when it
+// matched an existing spec's description and was not added.
+type Mapping struct {
+	OldID       int    `json:"old_id"`
+	NewID       int    `json:"new_id"`
+	Status      string `json:"status"`
+	Description string `json:"description"`
+}
+
+// Result summarizes an import.
+type Result struct {
+	Added      int       `json:"added"`
+	Remapped   int       `json:"remapped"`
+	Duplicates int       `json:"duplicates"`
+	Mapping    []Mapping `json:"mapping"`
+}
+
+// entry is one spec as found in an import file. Issue dumps such as
+// 'gh issue list --json number,title,state' use number, title, and state.
+type entry struct {
+	ID          int               `json:"id"`
+	Number      int               `json:"number"`
+	Description string            `json:"description"`
+	Title       string            `json:"title"`
+	Status      string            `json:"status"`
+	State       string            `json:"state"`
+	ParentID    int               `json:"parent_id"`
+	SplitInto   []int             `json:"split_into"`
+	Criteria    []types.Criterion `json:"criteria"`
+}
+
+// Parse reads specs from a session file (an object with "specs") or a JSON
+// array of specs or issues. Closed issues import as completed specs.
+func Parse(data []byte) ([]types.Spec, error) {
+	var entries []entry
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		var file struct {
+			Specs []entry `json:"specs"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parsing import file: %w", err)
+		}
+		entries = file.Specs
+	} else if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing import file: expected a session file or a JSON array of specs: %w", err)
+	}
+
+	specs := make([]types.Spec, 0, len(entries))
+	for i, e := range entries {
+		sp := types.Spec{
+			ID:          e.ID,
+			Description: strings.TrimSpace(e.Description),
+			Status:      types.SpecStatusActive,
+			ParentID:    e.ParentID,
+			SplitInto:   e.SplitInto,
+			Criteria:    e.Criteria,
+		}
+		if sp.ID == 0 {
+			sp.ID = e.Number
+		}
+		if sp.Description == "" {
+			sp.Description = strings.TrimSpace(e.Title)
+		}
+		if sp.Description == "" {
+			return nil, fmt.Errorf("import entry %d has no description or title", i+1)
+		}
+		if e.Status == string(types.SpecStatusCompleted) || strings.EqualFold(e.State, "closed") {
+			sp.Status = types.SpecStatusCompleted
+		}
+		specs = append(specs, sp)
+	}
+	return specs, nil
+}
+
+// Import adds specs to s in order of their old IDs, so the same file always
+// produces the same mapping. A spec whose description matches one already in s,
+// or one imported before it, ignoring case and spacing, is a duplicate and maps
+// to that spec. The rest get the session's next IDs in turn, so an old ID that
+// collides with an existing spec is always remapped.
+func Import(s *types.Session, specs []types.Spec) Result {
+	sorted := append([]types.Spec(nil), specs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	byDescription := make(map[string]int, len(s.Specs))
+	for _, sp := range s.Specs {
+		byDescription[normalize(sp.Description)] = sp.ID
+	}
+
+	res := Result{Mapping: []Mapping{}}
+	ids := make(map[int]int, len(sorted))
+	var added []int
+	for _, in := range sorted {
+		m := Mapping{OldID: in.ID, Description: in.Description}
+		if id, ok := byDescription[normalize(in.Description)]; ok {
+			m.NewID, m.Status = id, StatusDuplicate
+			res.Duplicates++
+		} else {
+			m.NewID = s.AddSpec(in.Description)
+			byDescription[normalize(in.Description)] = m.NewID
+			added = append(added, m.NewID)
+			sp := s.SpecByID(m.NewID)
+			sp.Criteria = in.Criteria
+			sp.ParentID, sp.SplitInto = in.ParentID, in.SplitInto
+			if in.Status == types.SpecStatusCompleted {
+				_ = s.CompleteSpec(m.NewID)
+			}
+			m.Status = StatusAdded
+			res.Added++
+			if m.NewID != in.ID {
+				m.Status = StatusRemapped
+				res.Remapped++
+			}
+		}
+		if _, seen := ids[in.ID]; !seen {
+			ids[in.ID] = m.NewID
+		}
+		res.Mapping = append(res.Mapping, m)
+	}
+
+	// Links between imported specs follow them to their new IDs; links to
+	// specs outside the import are dropped.
+	for _, id := range added {
+		sp := s.SpecByID(id)
+		sp.ParentID = ids[sp.ParentID]
+		var split []int
+		for _, child := range sp.SplitInto {
+			if to, ok := ids[child]; ok {
+				split = append(split, to)
+			}
+		}
+		sp.SplitInto = split
+	}
+	return res
+}
+
+func normalize(description string) string {
+	return strings.ToLower(strings.Join(strings.Fields(description), " "))
+}
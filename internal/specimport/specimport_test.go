@@ -0,0 +1,74 @@
+package specimport
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestParseIssueDump(t *testing.T) {
+	specs, err := Parse([]byte(`[{"number": 12, "title": "Login works", "state": "OPEN"}, {"number": 9, "title": "Logout works", "state": "CLOSED"}]`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(specs) != 2 || specs[0].ID != 12 || specs[0].Description != "Login works" {
+		t.Fatalf("Parse() = %+v, want issues as specs", specs)
+	}
+	if specs[0].Status != types.SpecStatusActive || specs[1].Status != types.SpecStatusCompleted {
+		t.Errorf("statuses = %q, %q, want open active and closed completed", specs[0].Status, specs[1].Status)
+	}
+
+	if _, err := Parse([]byte(`[{"number": 1}]`)); err == nil {
+		t.Error("Parse() should reject entries without a description")
+	}
+}
+
+func TestParseSessionFile(t *testing.T) {
+	specs, err := Parse([]byte(`{"phase": "red", "specs": [{"id": 3, "description": "Parses dates", "status": "completed"}]}`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(specs) != 1 || specs[0].ID != 3 || specs[0].Status != types.SpecStatusCompleted {
+		t.Errorf("Parse() = %+v, want the session's spec", specs)
+	}
+}
+
+func TestImportRemapsCollisionsAndSkipsDuplicates(t *testing.T) {
+	s := types.NewSession()
+	s.AddSpec("Login works")
+	s.AddSpec("Logout works")
+
+	res := Import(s, []types.Spec{
+		{ID: 4, Description: "Sessions expire", ParentID: 1},
+		{ID: 1, Description: "login   WORKS"},
+		{ID: 3, Description: "Passwords are hashed", Status: types.SpecStatusCompleted},
+	})
+
+	want := []Mapping{
+		{OldID: 1, NewID: 1, Status: StatusDuplicate, Description: "login   WORKS"},
+		{OldID: 3, NewID: 3, Status: StatusAdded, Description: "Passwords are hashed"},
+		{OldID: 4, NewID: 4, Status: StatusAdded, Description: "Sessions expire"},
+	}
+	if len(res.Mapping) != len(want) {
+		t.Fatalf("mapping = %+v, want %+v", res.Mapping, want)
+	}
+	for i := range want {
+		if res.Mapping[i] != want[i] {
+			t.Errorf("mapping[%d] = %+v, want %+v", i, res.Mapping[i], want[i])
+		}
+	}
+	if res.Added != 2 || res.Duplicates != 1 {
+		t.Errorf("added %d, duplicates %d, want 2 and 1", res.Added, res.Duplicates)
+	}
+	if sp := s.SpecByID(3); sp == nil || sp.Status != types.SpecStatusCompleted {
+		t.Errorf("spec 3 = %+v, want the completed import", sp)
+	}
+	if sp := s.SpecByID(4); sp == nil || sp.ParentID != 1 {
+		t.Errorf("spec 4 = %+v, want its parent remapped to the duplicate's spec", sp)
+	}
+
+	res = Import(s, []types.Spec{{ID: 2, Description: "Tokens refresh"}})
+	if len(res.Mapping) != 1 || res.Mapping[0].NewID != 5 || res.Mapping[0].Status != StatusRemapped {
+		t.Errorf("colliding ID should be remapped to the next ID, got %+v", res.Mapping)
+	}
+}
@@ -0,0 +1,166 @@
+package speclint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+const (
+	// MinWords is the fewest words a spec can have before it is considered vague.
+	MinWords = 3
+	// MaxWords is the most words a spec can have before it is considered too long.
+	MaxWords = 25
+	// DuplicateSimilarity is the word-overlap ratio (0-1) at which two specs are
+	// reported as likely duplicates.
+	DuplicateSimilarity = 0.8
+)
+
+// Issue is a single quality problem found in a spec description.
+type Issue struct {
+	SpecID     int    `json:"spec_id,omitempty"`
+	Rule       string `json:"rule"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// vaguePhrases are descriptions that say nothing testable on their own.
+var vaguePhrases = []string{
+	"make it work",
+	"it works",
+	"fix it",
+	"fix bug",
+	"fix bugs",
+	"handle errors",
+	"handle edge cases",
+	"clean up",
+	"improve",
+	"etc",
+	"and so on",
+	"stuff",
+	"things",
+}
+
+// Check returns quality issues for a single spec description.
+func Check(description string) []Issue {
+	var issues []Issue
+	words := strings.Fields(description)
+	lower := strings.ToLower(description)
+
+	if len(words) < MinWords {
+		issues = append(issues, Issue{
+			Rule:       "too_short",
+			Message:    fmt.Sprintf("spec has %d word(s); it likely does not describe a testable behavior", len(words)),
+			Suggestion: "describe the input, the action, and the expected outcome",
+		})
+	}
+
+	for _, phrase := range vaguePhrases {
+		if containsPhrase(lower, phrase) {
+			issues = append(issues, Issue{
+				Rule:       "vague",
+				Message:    fmt.Sprintf("spec contains vague wording %q", phrase),
+				Suggestion: "replace it with a concrete, observable outcome",
+			})
+			break
+		}
+	}
+
+	if len(words) > MaxWords {
+		issues = append(issues, Issue{
+			Rule:       "too_long",
+			Message:    fmt.Sprintf("spec has %d words (max %d)", len(words), MaxWords),
+			Suggestion: "split it into smaller specs that each cover one behavior",
+		})
+	}
+
+	if n := behaviorCount(lower); n > 1 {
+		issues = append(issues, Issue{
+			Rule:       "multiple_behaviors",
+			Message:    fmt.Sprintf("spec appears to describe %d behaviors", n),
+			Suggestion: "split it so each spec can be driven by a single failing test",
+		})
+	}
+
+	return issues
+}
+
+// Lint checks every active spec and reports likely duplicates between them.
+func Lint(specs []types.Spec) []Issue {
+	var issues []Issue
+	var active []types.Spec
+	for _, spec := range specs {
+		if spec.Status != types.SpecStatusActive {
+			continue
+		}
+		active = append(active, spec)
+		for _, issue := range Check(spec.Description) {
+			issue.SpecID = spec.ID
+			issues = append(issues, issue)
+		}
+	}
+
+	for i := range active {
+		for j := i + 1; j < len(active); j++ {
+			if Similarity(active[i].Description, active[j].Description) >= DuplicateSimilarity {
+				issues = append(issues, Issue{
+					SpecID:     active[j].ID,
+					Rule:       "duplicate",
+					Message:    fmt.Sprintf("spec %d looks like a duplicate of spec %d", active[j].ID, active[i].ID),
+					Suggestion: "remove one of them or make the difference explicit",
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// Similarity returns the word-overlap (Jaccard) ratio between two descriptions,
+// ignoring case and punctuation.
+func Similarity(a, b string) float64 {
+	wa, wb := wordSet(a), wordSet(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	union := len(wa) + len(wb) - shared
+	return float64(shared) / float64(union)
+}
+
+// behaviorCount estimates how many behaviors a description covers by counting
+// clause separators such as "; " and " and also ".
+func behaviorCount(lower string) int {
+	count := 1
+	count += strings.Count(lower, ";")
+	count += strings.Count(lower, " and also ")
+	count += strings.Count(lower, " as well as ")
+	if strings.Count(lower, " and ") >= 2 {
+		count++
+	}
+	return count
+}
+
+// containsPhrase reports whether phrase appears in s on word boundaries.
+func containsPhrase(s, phrase string) bool {
+	padded := " " + strings.Join(strings.FieldsFunc(s, isSeparator), " ") + " "
+	return strings.Contains(padded, " "+phrase+" ")
+}
+
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), isSeparator) {
+		set[w] = true
+	}
+	return set
+}
+
+func isSeparator(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '\'' || r > 127)
+}
@@ -0,0 +1,97 @@
+package speclint
+
+import (
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func hasRule(issues []Issue, rule string) bool {
+	for _, i := range issues {
+		if i.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckGoodSpecHasNoIssues(t *testing.T) {
+	issues := Check("GET /users/999 returns 404 when the user does not exist")
+	if len(issues) != 0 {
+		t.Errorf("Check() = %+v, want no issues", issues)
+	}
+}
+
+func TestCheckFlagsVagueSpecs(t *testing.T) {
+	tests := []struct {
+		desc string
+		rule string
+	}{
+		{"make it work", "vague"},
+		{"login", "too_short"},
+		{"Parser should handle edge cases properly", "vague"},
+	}
+	for _, tt := range tests {
+		if issues := Check(tt.desc); !hasRule(issues, tt.rule) {
+			t.Errorf("Check(%q) = %+v, want rule %q", tt.desc, issues, tt.rule)
+		}
+	}
+}
+
+func TestCheckDoesNotMatchVagueWordInsideOtherWords(t *testing.T) {
+	if issues := Check("Returns improvements list sorted by date"); hasRule(issues, "vague") {
+		t.Errorf("Check() should not flag 'improve' inside 'improvements': %+v", issues)
+	}
+}
+
+func TestCheckFlagsLongMultiBehaviorSpecs(t *testing.T) {
+	long := "User can register with email and password and receives a confirmation email and can then log in; " +
+		"the dashboard shows their name as well as their last login time and an avatar"
+	issues := Check(long)
+	if !hasRule(issues, "too_long") {
+		t.Errorf("Check() should flag too_long, got %+v", issues)
+	}
+	if !hasRule(issues, "multiple_behaviors") {
+		t.Errorf("Check() should flag multiple_behaviors, got %+v", issues)
+	}
+}
+
+func TestLintDetectsDuplicates(t *testing.T) {
+	specs := []types.Spec{
+		{ID: 1, Description: "Returns 404 when user is not found", Status: types.SpecStatusActive},
+		{ID: 2, Description: "returns 404 when the user is not found", Status: types.SpecStatusActive},
+		{ID: 3, Description: "Returns 400 for an invalid email address", Status: types.SpecStatusActive},
+	}
+	issues := Lint(specs)
+
+	found := false
+	for _, i := range issues {
+		if i.Rule == "duplicate" {
+			found = true
+			if i.SpecID != 2 {
+				t.Errorf("duplicate issue SpecID = %d, want 2", i.SpecID)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Lint() should report a duplicate, got %+v", issues)
+	}
+}
+
+func TestLintSkipsCompletedSpecs(t *testing.T) {
+	specs := []types.Spec{
+		{ID: 1, Description: "make it work", Status: types.SpecStatusCompleted},
+	}
+	if issues := Lint(specs); len(issues) != 0 {
+		t.Errorf("Lint() should ignore completed specs, got %+v", issues)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if got := Similarity("a b c", "A, B, C!"); got != 1 {
+		t.Errorf("Similarity() of identical word sets = %v, want 1", got)
+	}
+	if got := Similarity("a b", "c d"); got != 0 {
+		t.Errorf("Similarity() of disjoint word sets = %v, want 0", got)
+	}
+}
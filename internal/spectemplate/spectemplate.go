@@ -0,0 +1,143 @@
+// Package spectemplate stores reusable, parameterized spec lists, such as
+// "returns 400 for missing {field}", and expands them into concrete specs.
+package spectemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FileName is the project file holding the template library. It lives next to
+// the session file, moves with it on 'tdd-ai reset' and 'tdd-ai restore', and
+// can be committed to share templates with the team.
+const FileName = ".tdd-ai.spec-templates.json"
+
+// Library maps template names to their spec patterns.
+type Library map[string][]string
+
+// placeholder matches a {param} in a spec pattern.
+var placeholder = regexp.MustCompile(`\{([a-z][a-z0-9_-]*)\}`)
+
+// validName matches names usable as template names and parameter flags.
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Path returns the library path for a given directory.
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load reads the library in dir. A missing file is an empty library.
+func Load(dir string) (Library, error) {
+	data, err := os.ReadFile(Path(dir))
+	if os.IsNotExist(err) {
+		return Library{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading spec templates: %w", err)
+	}
+	lib := Library{}
+	if err := json.Unmarshal(data, &lib); err != nil {
+		return nil, fmt.Errorf("parsing spec templates %s: %w", Path(dir), err)
+	}
+	return lib, nil
+}
+
+// Save writes the library to dir.
+func Save(dir string, lib Library) error {
+	data, err := json.MarshalIndent(lib, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding spec templates: %w", err)
+	}
+	if err := os.WriteFile(Path(dir), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing spec templates: %w", err)
+	}
+	return nil
+}
+
+// Names returns the template names, sorted.
+func (lib Library) Names() []string {
+	names := make([]string, 0, len(lib))
+	for name := range lib {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks a template name and its patterns before saving.
+func Validate(name string, patterns []string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid template name %q: use lowercase letters, digits, '-' and '_', starting with a letter", name)
+	}
+	if len(patterns) == 0 {
+		return fmt.Errorf("template %q needs at least one spec", name)
+	}
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("template %q has an empty spec", name)
+		}
+	}
+	return nil
+}
+
+// Params returns the parameter names used by patterns, in order of first use.
+func Params(patterns []string) []string {
+	var params []string
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		for _, m := range placeholder.FindAllStringSubmatch(p, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				params = append(params, m[1])
+			}
+		}
+	}
+	return params
+}
+
+// Expand generates concrete specs from patterns. A pattern using several
+// parameters yields one spec per combination of their values, in the order
+// given; a pattern without parameters yields itself. Every parameter the
+// patterns use needs at least one value, and values for parameters they do
+// not use are rejected.
+func Expand(patterns []string, values map[string][]string) ([]string, error) {
+	params := Params(patterns)
+	used := make(map[string]bool, len(params))
+	for _, p := range params {
+		used[p] = true
+		if len(values[p]) == 0 {
+			return nil, fmt.Errorf("missing value for {%s}: pass --%s <value>", p, p)
+		}
+	}
+	var unknown []string
+	for p := range values {
+		if !used[p] {
+			unknown = append(unknown, p)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown parameter(s) %s (template uses: %s)", strings.Join(unknown, ", "), strings.Join(params, ", "))
+	}
+
+	var specs []string
+	for _, pattern := range patterns {
+		expanded := []string{pattern}
+		for _, p := range Params([]string{pattern}) {
+			var next []string
+			for _, partial := range expanded {
+				for _, v := range values[p] {
+					next = append(next, strings.ReplaceAll(partial, "{"+p+"}", v))
+				}
+			}
+			expanded = next
+		}
+		specs = append(specs, expanded...)
+	}
+	return specs, nil
+}
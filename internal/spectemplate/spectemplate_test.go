@@ -0,0 +1,62 @@
+package spectemplate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandGeneratesEveryCombination(t *testing.T) {
+	patterns := []string{"returns 400 for missing {field}", "rejects {field} longer than {max} characters", "logs the request"}
+	got, err := Expand(patterns, map[string][]string{"field": {"email", "password"}, "max": {"255"}})
+	if err != nil {
+		t.Fatalf("Expand() error: %v", err)
+	}
+	want := []string{
+		"returns 400 for missing email",
+		"returns 400 for missing password",
+		"rejects email longer than 255 characters",
+		"rejects password longer than 255 characters",
+		"logs the request",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand() = %q, want %q", got, want)
+	}
+}
+
+func TestExpandRejectsMissingAndUnknownParams(t *testing.T) {
+	patterns := []string{"returns 400 for missing {field}"}
+	if _, err := Expand(patterns, nil); err == nil {
+		t.Error("Expand() should require a value for {field}")
+	}
+	if _, err := Expand(patterns, map[string][]string{"field": {"email"}, "typo": {"x"}}); err == nil {
+		t.Error("Expand() should reject a parameter the template does not use")
+	}
+}
+
+func TestLoadSaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	lib, err := Load(dir)
+	if err != nil || len(lib) != 0 {
+		t.Fatalf("Load() without a file = %v, %v; want an empty library", lib, err)
+	}
+	lib["crud"] = []string{"creates a {resource}"}
+	if err := Save(dir, lib); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil || !reflect.DeepEqual(loaded, lib) {
+		t.Errorf("Load() = %v, %v; want %v", loaded, err, lib)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	if err := Validate("validation-errors", []string{"x"}); err != nil {
+		t.Errorf("Validate() error: %v", err)
+	}
+	if err := Validate("Bad Name", []string{"x"}); err == nil {
+		t.Error("Validate() should reject a name with spaces")
+	}
+	if err := Validate("empty", nil); err == nil {
+		t.Error("Validate() should reject a template without specs")
+	}
+}
@@ -0,0 +1,199 @@
+// Package stats rolls metrics from many session files up into fleet-level
+// numbers for 'tdd-ai stats --aggregate'.
+package stats
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/protect"
+	"github.com/macosta/tdd-ai/internal/types"
+	"github.com/macosta/tdd-ai/internal/verify"
+)
+
+// Project holds the metrics of a single session file.
+type Project struct {
+	Path             string `json:"path"`
+	Phase            string `json:"phase"`
+	CyclesCompleted  int    `json:"cycles_completed"`
+	SpecsTotal       int    `json:"specs_total"`
+	SpecsCompleted   int    `json:"specs_completed"`
+	iterationsSum    int
+	iterationsSpecs  int
+	AvgIterations    float64 `json:"avg_iterations_per_spec"`
+	SpecsVerified    int     `json:"specs_verified"`
+	SpecsCompliant   int     `json:"specs_compliant"`
+	Violations       int     `json:"violations"`
+	ViolationRate    float64 `json:"violation_rate"`
+	ComplianceScore  float64 `json:"compliance_score"`
+	ForcedOverrides  int     `json:"forced_overrides"`
+	BlockedAttempts  int     `json:"blocked_attempts"`
+	ReflectionDebt   int     `json:"reflection_debt"`
+	LastActivityTime string  `json:"last_activity,omitempty"`
+}
+
+// Fleet is the rollup across every project. Averages and rates are weighted
+// by spec, not by project, so large projects count for more.
+type Fleet struct {
+	Sessions        int       `json:"sessions"`
+	CyclesCompleted int       `json:"cycles_completed"`
+	SpecsTotal      int       `json:"specs_total"`
+	SpecsCompleted  int       `json:"specs_completed"`
+	AvgIterations   float64   `json:"avg_iterations_per_spec"`
+	SpecsVerified   int       `json:"specs_verified"`
+	Violations      int       `json:"violations"`
+	ViolationRate   float64   `json:"violation_rate"`
+	ForcedOverrides int       `json:"forced_overrides"`
+	BlockedAttempts int       `json:"blocked_attempts"`
+	ReflectionDebt  int       `json:"reflection_debt"`
+	Projects        []Project `json:"projects"`
+}
+
+// Summarize computes the metrics of one session. The violation rate is the
+// percentage of verified specs with at least one violation.
+func Summarize(path string, s *types.Session) Project {
+	p := Project{
+		Path:            path,
+		Phase:           string(s.Phase),
+		CyclesCompleted: s.Iteration,
+		SpecsTotal:      len(s.Specs),
+	}
+	for _, spec := range s.Specs {
+		if spec.Status != types.SpecStatusCompleted {
+			continue
+		}
+		p.SpecsCompleted++
+		if spec.Iterations > 0 {
+			p.iterationsSum += spec.Iterations
+			p.iterationsSpecs++
+		}
+	}
+	p.AvgIterations = ratio(p.iterationsSum, p.iterationsSpecs)
+
+	result := verify.Analyze(s)
+	p.SpecsVerified = result.SpecsVerified
+	p.SpecsCompliant = result.SpecsCompliant
+	p.Violations = len(result.Violations)
+	p.ViolationRate = 100 * ratio(result.SpecsVerified-result.SpecsCompliant, result.SpecsVerified)
+	p.ComplianceScore = result.Score
+	p.ReflectionDebt = result.ReflectionDebt
+
+	for _, ev := range s.History {
+		switch ev.Action {
+		case "phase_set":
+			p.ForcedOverrides++
+		case "phase_next_blocked":
+			p.BlockedAttempts++
+		case types.HistoryRollupAction:
+			p.ForcedOverrides += ev.Rollup["phase_set"]
+			p.BlockedAttempts += ev.Rollup["phase_next_blocked"]
+		}
+		if ev.Timestamp > p.LastActivityTime {
+			p.LastActivityTime = ev.Timestamp
+		}
+	}
+	return p
+}
+
+// Aggregate rolls the projects up into fleet totals.
+func Aggregate(projects []Project) Fleet {
+	f := Fleet{Sessions: len(projects), Projects: projects}
+	if f.Projects == nil {
+		f.Projects = []Project{}
+	}
+	var iterations, iterationSpecs, violatedSpecs int
+	for _, p := range projects {
+		f.CyclesCompleted += p.CyclesCompleted
+		f.SpecsTotal += p.SpecsTotal
+		f.SpecsCompleted += p.SpecsCompleted
+		f.SpecsVerified += p.SpecsVerified
+		f.Violations += p.Violations
+		f.ForcedOverrides += p.ForcedOverrides
+		f.BlockedAttempts += p.BlockedAttempts
+		f.ReflectionDebt += p.ReflectionDebt
+		iterations += p.iterationsSum
+		iterationSpecs += p.iterationsSpecs
+		violatedSpecs += p.SpecsVerified - p.SpecsCompliant
+	}
+	f.AvgIterations = ratio(iterations, iterationSpecs)
+	f.ViolationRate = 100 * ratio(violatedSpecs, f.SpecsVerified)
+	return f
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// skipDirs are never searched for session files.
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Find returns the files matching pattern, sorted. A leading "~/" is expanded
+// to the home directory and "**" matches any number of directories, as in
+// 'tdd-ai init --protect'.
+func Find(pattern string) ([]string, error) {
+	if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		pattern = filepath.Join(home, rest)
+	}
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+
+	root := globRoot(pattern)
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsPermission(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if protect.Match(pattern, filepath.ToSlash(path)) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// globRoot returns the longest leading directory of pattern without glob
+// characters, where the search starts.
+func globRoot(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	var fixed []string
+	for _, seg := range segments[:len(segments)-1] {
+		if strings.ContainsAny(seg, "*?[") {
+			break
+		}
+		fixed = append(fixed, seg)
+	}
+	root := strings.Join(fixed, "/")
+	switch {
+	case root == "" && strings.HasPrefix(pattern, "/"):
+		return "/"
+	case root == "":
+		return "."
+	}
+	return root
+}
@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func completedSession(iterations ...int) *types.Session {
+	s := types.NewSession()
+	for i, n := range iterations {
+		id := s.AddSpec("spec")
+		s.Specs[i].Iterations = n
+		_ = s.CompleteSpec(id)
+		s.Iteration++
+	}
+	return s
+}
+
+func TestAggregateWeightsBySpec(t *testing.T) {
+	a := Summarize("a", completedSession(1, 1, 1))
+	b := Summarize("b", completedSession(4))
+	b.ForcedOverrides = 2
+
+	if a.AvgIterations != 1 || b.AvgIterations != 4 {
+		t.Fatalf("per-project averages = %v, %v; want 1, 4", a.AvgIterations, b.AvgIterations)
+	}
+
+	f := Aggregate([]Project{a, b})
+	if f.Sessions != 2 || f.CyclesCompleted != 4 || f.SpecsCompleted != 4 {
+		t.Errorf("totals = %d sessions, %d cycles, %d specs; want 2, 4, 4", f.Sessions, f.CyclesCompleted, f.SpecsCompleted)
+	}
+	if f.AvgIterations != 1.75 {
+		t.Errorf("fleet avg iterations = %v, want 1.75 (7 iterations over 4 specs)", f.AvgIterations)
+	}
+	if f.ForcedOverrides != 2 {
+		t.Errorf("fleet forced overrides = %d, want 2", f.ForcedOverrides)
+	}
+	// Specs completed without spec_picked/RED evidence are violations
+	if f.SpecsVerified != 4 || f.ViolationRate != 100 {
+		t.Errorf("verified %d, violation rate %v; want 4, 100", f.SpecsVerified, f.ViolationRate)
+	}
+}
+
+func TestFindMatchesAcrossDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{"one/.tdd-ai.json", "two/nested/.tdd-ai.json", "two/other.json", "node_modules/x/.tdd-ai.json"} {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Find(filepath.ToSlash(dir) + "/**/.tdd-ai.json")
+	if err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+	want := []string{filepath.Join(dir, "one/.tdd-ai.json"), filepath.Join(dir, "two/nested/.tdd-ai.json")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package suggest
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Suggestion is a proposed characterization spec for one exported function or method.
+type Suggestion struct {
+	File     string `json:"file"`
+	Function string `json:"function"`
+	Line     int    `json:"line"`
+	Spec     string `json:"spec"`
+}
+
+// Expand resolves glob patterns and directories into a sorted, de-duplicated
+// list of Go source files, skipping tests.
+func Expand(patterns []string) ([]string, error) {
+	seen := map[string]bool{}
+	var files []string
+	add := func(f string) {
+		if strings.HasSuffix(f, ".go") && !strings.HasSuffix(f, "_test.go") && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", p)
+		}
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(m)
+				continue
+			}
+			entries, err := os.ReadDir(m)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if !e.IsDir() {
+					add(filepath.Join(m, e.Name()))
+				}
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// FromGoFiles parses the given Go files and proposes a characterization spec for
+// each exported function and each exported method on an exported type. Functions
+// returning an error get a second spec for their failure cases.
+func FromGoFiles(files []string) ([]Suggestion, error) {
+	fset := token.NewFileSet()
+	var out []Suggestion
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !fn.Name.IsExported() {
+				continue
+			}
+			name := fn.Name.Name
+			if fn.Recv != nil {
+				recv := receiverName(fn.Recv)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				name = recv + "." + name
+			}
+			line := fset.Position(fn.Pos()).Line
+			slash := filepath.ToSlash(file)
+			out = append(out, Suggestion{
+				File:     slash,
+				Function: name,
+				Line:     line,
+				Spec:     fmt.Sprintf("Characterize current behavior of %s in %s", name, slash),
+			})
+			if returnsError(fn.Type) {
+				out = append(out, Suggestion{
+					File:     slash,
+					Function: name,
+					Line:     line,
+					Spec:     fmt.Sprintf("Characterize error cases of %s in %s", name, slash),
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+// receiverName returns the base type name of a method receiver, without
+// pointer or type parameters.
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	t := recv.List[0].Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func returnsError(ft *ast.FuncType) bool {
+	if ft.Results == nil {
+		return false
+	}
+	for _, r := range ft.Results.List {
+		if id, ok := r.Type.(*ast.Ident); ok && id.Name == "error" {
+			return true
+		}
+	}
+	return false
+}
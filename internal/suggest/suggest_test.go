@@ -0,0 +1,90 @@
+package suggest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const pricingSrc = `package pricing
+
+type Cart struct{}
+
+type ledger struct{}
+
+func (c *Cart) Total() int { return 0 }
+
+func (l ledger) Post() {}
+
+func Discount(code string) (int, error) { return 0, nil }
+
+func round(x int) int { return x }
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFromGoFilesExportedOnly(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "pricing.go", pricingSrc)
+
+	got, err := FromGoFiles([]string{file})
+	if err != nil {
+		t.Fatalf("FromGoFiles() error: %v", err)
+	}
+
+	var names []string
+	for _, s := range got {
+		names = append(names, s.Function)
+	}
+	want := []string{"Cart.Total", "Discount", "Discount"}
+	if len(names) != len(want) {
+		t.Fatalf("functions = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("functions = %v, want %v", names, want)
+			break
+		}
+	}
+	if got[2].Spec != "Characterize error cases of Discount in "+filepath.ToSlash(file) {
+		t.Errorf("unexpected error-case spec: %q", got[2].Spec)
+	}
+	if got[0].Line != 7 {
+		t.Errorf("Cart.Total line = %d, want 7", got[0].Line)
+	}
+}
+
+func TestFromGoFilesParseError(t *testing.T) {
+	file := writeFile(t, t.TempDir(), "bad.go", "package x\nfunc (")
+	if _, err := FromGoFiles([]string{file}); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestExpandSkipsTestsAndExpandsDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.go", "package x")
+	writeFile(t, dir, "a_test.go", "package x")
+	writeFile(t, dir, "notes.txt", "")
+
+	files, err := Expand([]string{dir, filepath.Join(dir, "*.go")})
+	if err != nil {
+		t.Fatalf("Expand() error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "a.go" {
+		t.Errorf("files = %v, want only a.go", files)
+	}
+}
+
+func TestExpandNoMatch(t *testing.T) {
+	if _, err := Expand([]string{filepath.Join(t.TempDir(), "*.go")}); err == nil {
+		t.Error("expected error for pattern with no matches")
+	}
+}
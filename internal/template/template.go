@@ -0,0 +1,108 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileName is the file a template directory must contain.
+const FileName = "tdd-ai.template.json"
+
+// Template is a shared session setup that 'tdd-ai init --from-template'
+// applies to a new session. Every field is optional; flags given on the
+// command line take precedence over the template.
+type Template struct {
+	TestCmd              string              `json:"test_cmd,omitempty"`
+	TestSuites           map[string]string   `json:"test_suites,omitempty"`
+	TestShards           []string            `json:"test_shards,omitempty"`
+	TestAreas            map[string]string   `json:"test_areas,omitempty"`
+	RequireSuites        map[string][]string `json:"require_suites,omitempty"`
+	TestPolicy           []string            `json:"test_policy,omitempty"`
+	Protect              []string            `json:"protect,omitempty"`
+	TestGlobs            []string            `json:"test_globs,omitempty"`
+	OutputLines          int                 `json:"output_lines,omitempty"`
+	MutationCmd          string              `json:"mutation_cmd,omitempty"`
+	MutationThreshold    float64             `json:"mutation_threshold,omitempty"`
+	MaxIterationsPerSpec int                 `json:"max_iterations_per_spec,omitempty"`
+	StaleAfter           string              `json:"stale_after,omitempty"`
+	RefactorTimebox      string              `json:"refactor_timebox,omitempty"`
+	StallAfter           string              `json:"stall_after,omitempty"`
+	Strictness           string              `json:"strictness,omitempty"`
+	NoTestCache          bool                `json:"no_test_cache,omitempty"`
+	RedactPatterns       []string            `json:"redact_patterns,omitempty"`
+	// Reflections replace the default REFACTOR reflection questions.
+	Reflections []string `json:"reflections,omitempty"`
+	// Instructions are project-specific lines appended to guide instructions.
+	Instructions []string `json:"instructions,omitempty"`
+	// Specs are spec descriptions added to the new session.
+	Specs []string `json:"specs,omitempty"`
+}
+
+// Load reads and parses the template file in dir.
+func Load(dir string) (*Template, error) {
+	path := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// IsRemote reports whether source names a git repository rather than a local
+// directory.
+func IsRemote(source string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "git@"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(source, ".git") && !isDir(source)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// SuiteEntries returns the template's test suites as name=command entries,
+// sorted by name, in the form accepted by 'tdd-ai init --test-suite'.
+func (t *Template) SuiteEntries() []string {
+	return joinEntries(t.TestSuites, func(v string) string { return v })
+}
+
+// AreaEntries returns the template's test areas as path="command" entries,
+// sorted by path, in the form accepted by 'tdd-ai init --test-area'.
+func (t *Template) AreaEntries() []string {
+	return joinEntries(t.TestAreas, func(v string) string { return v })
+}
+
+// RequireSuiteEntries returns the template's required suites as
+// phase=suite,... entries, sorted by phase, in the form accepted by
+// 'tdd-ai init --require-suites'.
+func (t *Template) RequireSuiteEntries() []string {
+	return joinEntries(t.RequireSuites, func(v []string) string { return strings.Join(v, ",") })
+}
+
+func joinEntries[V any](m map[string]V, value func(V) string) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	entries := make([]string, len(keys))
+	for i, k := range keys {
+		entries[i] = k + "=" + value(m[k])
+	}
+	return entries
+}
@@ -0,0 +1,61 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"test_cmd": "npm test", "test_suites": {"unit": "npm run unit", "e2e": "npm run e2e"}, "require_suites": {"refactor": ["unit", "e2e"]}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	tpl, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if tpl.TestCmd != "npm test" {
+		t.Errorf("TestCmd = %q, want npm test", tpl.TestCmd)
+	}
+	if got, want := tpl.SuiteEntries(), []string{"e2e=npm run e2e", "unit=npm run unit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SuiteEntries() = %q, want %q", got, want)
+	}
+	if got, want := tpl.RequireSuiteEntries(), []string{"refactor=unit,e2e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RequireSuiteEntries() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir); err == nil {
+		t.Error("Load() should fail without a template file")
+	}
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Load() should fail on malformed JSON")
+	}
+}
+
+func TestIsRemote(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"https://github.com/acme/templates.git", true},
+		{"git@github.com:acme/templates.git", true},
+		{"ssh://git@example.com/templates", true},
+		{"./templates", false},
+		{"/etc/tdd-ai/template", false},
+	}
+	for _, tt := range tests {
+		if got := IsRemote(tt.source); got != tt.want {
+			t.Errorf("IsRemote(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
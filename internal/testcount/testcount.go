@@ -0,0 +1,92 @@
+package testcount
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Counts is the number of tests and assertions reported by a test run.
+// Assertions is 0 when the runner does not report them.
+type Counts struct {
+	Tests      int `json:"tests"`
+	Assertions int `json:"assertions,omitempty"`
+}
+
+var (
+	// PHPUnit: "OK (5 tests, 12 assertions)" or "Tests: 5, Assertions: 12, Failures: 1."
+	phpunitOK      = regexp.MustCompile(`OK \((\d+) tests?, (\d+) assertions?\)`)
+	phpunitSummary = regexp.MustCompile(`Tests: (\d+), Assertions: (\d+)`)
+	// Jest/Vitest: "Tests:       1 failed, 3 passed, 4 total"
+	jestTotal = regexp.MustCompile(`Tests:\s+.*?(\d+) total`)
+	// .NET: "Total tests: 4" or "Failed: 1, Passed: 3, Skipped: 0, Total: 4"
+	dotnetTotal = regexp.MustCompile(`Total(?: tests)?:\s*(\d+)`)
+	// RSpec: "5 examples, 1 failure"
+	rspecExamples = regexp.MustCompile(`(\d+) examples?, \d+ failures?`)
+	// cargo: "test result: FAILED. 3 passed; 1 failed; 0 ignored"
+	cargoResult = regexp.MustCompile(`test result: \w+\. (\d+) passed; (\d+) failed; (\d+) ignored`)
+	// pytest: "==== 3 passed, 1 failed in 0.12s ===="
+	pytestSummary = regexp.MustCompile(`=+ (.*\d+ (?:passed|failed|error|errors).*) in [\d.]+s`)
+	pytestPart    = regexp.MustCompile(`(\d+) (passed|failed|error|errors)`)
+	// mocha: "3 passing", "1 failing"
+	mochaPart = regexp.MustCompile(`(?m)^\s*(\d+) (passing|failing)`)
+)
+
+// Parse extracts test (and, where available, assertion) counts from test runner
+// output. Supports PHPUnit, Jest/Vitest, .NET, RSpec, cargo, pytest, mocha, and
+// verbose Go test output. Returns false when no count can be found.
+func Parse(output string) (Counts, bool) {
+	if m := lastMatch(phpunitOK, output); m != nil {
+		return Counts{Tests: atoi(m[1]), Assertions: atoi(m[2])}, true
+	}
+	if m := lastMatch(phpunitSummary, output); m != nil {
+		return Counts{Tests: atoi(m[1]), Assertions: atoi(m[2])}, true
+	}
+	if m := lastMatch(jestTotal, output); m != nil {
+		return Counts{Tests: atoi(m[1])}, true
+	}
+	if m := lastMatch(rspecExamples, output); m != nil {
+		return Counts{Tests: atoi(m[1])}, true
+	}
+	if all := cargoResult.FindAllStringSubmatch(output, -1); len(all) > 0 {
+		total := 0
+		for _, m := range all {
+			total += atoi(m[1]) + atoi(m[2]) + atoi(m[3])
+		}
+		return Counts{Tests: total}, true
+	}
+	if m := lastMatch(pytestSummary, output); m != nil {
+		total := 0
+		for _, part := range pytestPart.FindAllStringSubmatch(m[1], -1) {
+			total += atoi(part[1])
+		}
+		return Counts{Tests: total}, true
+	}
+	if parts := mochaPart.FindAllStringSubmatch(output, -1); len(parts) > 0 {
+		total := 0
+		for _, part := range parts {
+			total += atoi(part[1])
+		}
+		return Counts{Tests: total}, true
+	}
+	if m := lastMatch(dotnetTotal, output); m != nil {
+		return Counts{Tests: atoi(m[1])}, true
+	}
+	if n := strings.Count(output, "=== RUN "); n > 0 {
+		return Counts{Tests: n}, true
+	}
+	return Counts{}, false
+}
+
+func lastMatch(re *regexp.Regexp, s string) []string {
+	all := re.FindAllStringSubmatch(s, -1)
+	if len(all) == 0 {
+		return nil
+	}
+	return all[len(all)-1]
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
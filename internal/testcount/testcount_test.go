@@ -0,0 +1,32 @@
+package testcount
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   Counts
+		ok     bool
+	}{
+		{"phpunit ok", "OK (5 tests, 12 assertions)", Counts{Tests: 5, Assertions: 12}, true},
+		{"phpunit failures", "Tests: 6, Assertions: 14, Failures: 1.", Counts{Tests: 6, Assertions: 14}, true},
+		{"jest", "Tests:       1 failed, 3 passed, 4 total\nTime: 1s", Counts{Tests: 4}, true},
+		{"rspec", "Finished in 0.1 seconds\n5 examples, 1 failure", Counts{Tests: 5}, true},
+		{"cargo", "test result: FAILED. 3 passed; 1 failed; 0 ignored; 0 measured", Counts{Tests: 4}, true},
+		{"pytest", "===== 3 passed, 1 failed in 0.12s =====", Counts{Tests: 4}, true},
+		{"mocha", "  3 passing (20ms)\n  1 failing", Counts{Tests: 4}, true},
+		{"dotnet", "Failed: 1, Passed: 3, Skipped: 0, Total: 4", Counts{Tests: 4}, true},
+		{"go verbose", "=== RUN   TestA\n--- PASS: TestA\n=== RUN   TestB\n--- FAIL: TestB", Counts{Tests: 2}, true},
+		{"unknown", "ok  \tgithub.com/x/y\t0.01s", Counts{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Parse(tt.output)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Parse() = (%+v, %v), want (%+v, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package testdetect guesses a project's conventional test command from the
+// build files in its root directory.
+package testdetect
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Detection is a test command found in a project, with the file that
+// suggested it.
+type Detection struct {
+	Command string `json:"command"`
+	Source  string `json:"source"`
+}
+
+// npmPlaceholder is the test script 'npm init' writes, which always fails.
+const npmPlaceholder = `echo "Error: no test specified" && exit 1`
+
+// makeTestTarget matches a Makefile rule for the test target.
+var makeTestTarget = regexp.MustCompile(`^test\s*:([^=]|$)`)
+
+// probe inspects dir for one ecosystem and reports the command it suggests.
+type probe func(dir string) (Detection, bool)
+
+// probes run in order; the first match wins. A Makefile test target comes
+// first because projects that have one usually wrap the native runner in it.
+var probes = []probe{
+	detectMakefile,
+	detectPackageJSON,
+	fileProbe("go.mod", "go test ./..."),
+	fileProbe("Cargo.toml", "cargo test"),
+	detectPytest,
+	fileProbe(".rspec", "bundle exec rspec"),
+	fileProbe("mix.exs", "mix test"),
+	fileProbe("pom.xml", "mvn test"),
+	detectGradle,
+	fileProbe("phpunit.xml", "vendor/bin/phpunit"),
+	fileProbe("phpunit.xml.dist", "vendor/bin/phpunit"),
+	detectDotnet,
+}
+
+// Detect returns the test command suggested by the first recognized build file
+// in dir, or false when none is found.
+func Detect(dir string) (Detection, bool) {
+	for _, p := range probes {
+		if d, ok := p(dir); ok {
+			return d, true
+		}
+	}
+	return Detection{}, false
+}
+
+func exists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func fileProbe(name, command string) probe {
+	return func(dir string) (Detection, bool) {
+		if !exists(dir, name) {
+			return Detection{}, false
+		}
+		return Detection{Command: command, Source: name}, true
+	}
+}
+
+func detectMakefile(dir string) (Detection, bool) {
+	for _, name := range []string{"Makefile", "makefile", "GNUmakefile"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(strings.NewReader(string(data)))
+		for sc.Scan() {
+			if makeTestTarget.MatchString(sc.Text()) {
+				return Detection{Command: "make test", Source: name}, true
+			}
+		}
+	}
+	return Detection{}, false
+}
+
+// detectPackageJSON runs the test script with the package manager whose
+// lockfile is present.
+func detectPackageJSON(dir string) (Detection, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return Detection{}, false
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return Detection{}, false
+	}
+	script := strings.TrimSpace(pkg.Scripts["test"])
+	if script == "" || script == npmPlaceholder {
+		return Detection{}, false
+	}
+	command := "npm test"
+	switch {
+	case exists(dir, "pnpm-lock.yaml"):
+		command = "pnpm test"
+	case exists(dir, "yarn.lock"):
+		command = "yarn test"
+	case exists(dir, "bun.lockb"), exists(dir, "bun.lock"):
+		command = "bun run test"
+	}
+	return Detection{Command: command, Source: "package.json"}, true
+}
+
+// detectPytest recognizes pytest's own config files, and pyproject.toml,
+// setup.cfg, or tox.ini when they carry a pytest section.
+func detectPytest(dir string) (Detection, bool) {
+	for _, name := range []string{"pytest.ini", "conftest.py"} {
+		if exists(dir, name) {
+			return Detection{Command: "pytest", Source: name}, true
+		}
+	}
+	for _, c := range []struct{ name, section string }{
+		{"pyproject.toml", "[tool.pytest"},
+		{"setup.cfg", "[tool:pytest]"},
+		{"tox.ini", "[pytest]"},
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, c.name))
+		if err == nil && strings.Contains(string(data), c.section) {
+			return Detection{Command: "pytest", Source: c.name}, true
+		}
+	}
+	return Detection{}, false
+}
+
+func detectGradle(dir string) (Detection, bool) {
+	for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+		if !exists(dir, name) {
+			continue
+		}
+		if exists(dir, "gradlew") {
+			return Detection{Command: "./gradlew test", Source: name}, true
+		}
+		return Detection{Command: "gradle test", Source: name}, true
+	}
+	return Detection{}, false
+}
+
+func detectDotnet(dir string) (Detection, bool) {
+	for _, pattern := range []string{"*.sln", "*.csproj", "*.fsproj"} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) > 0 {
+			return Detection{Command: "dotnet test", Source: filepath.Base(matches[0])}, true
+		}
+	}
+	return Detection{}, false
+}
@@ -0,0 +1,42 @@
+package testdetect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Detection
+		ok    bool
+	}{
+		{"empty", nil, Detection{}, false},
+		{"go", map[string]string{"go.mod": "module m\n"}, Detection{"go test ./...", "go.mod"}, true},
+		{"makefile wins", map[string]string{"go.mod": "module m\n", "Makefile": "test:\n\tgo test ./...\n"}, Detection{"make test", "Makefile"}, true},
+		{"makefile without test target", map[string]string{"Makefile": "TEST := x\nbuild:\n", "Cargo.toml": ""}, Detection{"cargo test", "Cargo.toml"}, true},
+		{"npm", map[string]string{"package.json": `{"scripts": {"test": "jest"}}`}, Detection{"npm test", "package.json"}, true},
+		{"pnpm", map[string]string{"package.json": `{"scripts": {"test": "vitest"}}`, "pnpm-lock.yaml": ""}, Detection{"pnpm test", "package.json"}, true},
+		{"npm placeholder", map[string]string{"package.json": `{"scripts": {"test": "echo \"Error: no test specified\" && exit 1"}}`}, Detection{}, false},
+		{"pyproject with pytest", map[string]string{"pyproject.toml": "[tool.pytest.ini_options]\n"}, Detection{"pytest", "pyproject.toml"}, true},
+		{"pyproject without pytest", map[string]string{"pyproject.toml": "[project]\nname = \"x\"\n"}, Detection{}, false},
+		{"gradle wrapper", map[string]string{"build.gradle.kts": "", "gradlew": ""}, Detection{"./gradlew test", "build.gradle.kts"}, true},
+		{"dotnet", map[string]string{"App.Tests.csproj": "<Project/>"}, Detection{"dotnet test", "App.Tests.csproj"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, ok := Detect(dir)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Detect() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
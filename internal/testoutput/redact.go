@@ -0,0 +1,113 @@
+package testoutput
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// redactPatterns match credentials that commonly leak into test output.
+var redactPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// key=value / key: value pairs with sensitive names
+	{regexp.MustCompile(`(?i)\b([\w-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)[\w-]*)(\s*[:=]\s*)("[^"]*"|'[^']*'|\S+)`), "${1}${2}[REDACTED]"},
+	// Authorization headers
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`), "${1} [REDACTED]"},
+	// Credentials embedded in URLs
+	{regexp.MustCompile(`(://[^/\s:@]+):[^@\s/]+@`), "${1}:[REDACTED]@"},
+	// AWS access key IDs
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[REDACTED]"},
+}
+
+// Redacted replaces every match of a redaction pattern.
+const Redacted = "[REDACTED]"
+
+// Redactor masks secrets in test output: the built-in credential patterns plus
+// a project's own, configured with 'tdd-ai config redact'. A nil Redactor
+// applies only the built-in patterns.
+type Redactor struct {
+	custom []*regexp.Regexp
+}
+
+// NewRedactor compiles the project's redaction patterns.
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.custom = append(r.custom, re)
+	}
+	return r, nil
+}
+
+// Line masks secrets in a single line.
+func (r *Redactor) Line(line string) string {
+	for _, p := range redactPatterns {
+		line = p.re.ReplaceAllString(line, p.repl)
+	}
+	if r != nil {
+		for _, re := range r.custom {
+			line = re.ReplaceAllString(line, Redacted)
+		}
+	}
+	return line
+}
+
+// Text masks secrets in every line of output.
+func (r *Redactor) Text(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = r.Line(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Writer returns a writer that redacts each complete line before passing it
+// to w. Call Flush once writing is done to pass on a final partial line.
+func (r *Redactor) Writer(w io.Writer) *RedactWriter {
+	return &RedactWriter{r: r, w: w}
+}
+
+// RedactWriter is a line-buffering writer returned by Redactor.Writer.
+type RedactWriter struct {
+	r   *Redactor
+	w   io.Writer
+	buf []byte
+}
+
+func (rw *RedactWriter) Write(p []byte) (int, error) {
+	rw.buf = append(rw.buf, p...)
+	for {
+		i := bytes.IndexByte(rw.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if _, err := rw.w.Write([]byte(rw.r.Line(string(rw.buf[:i])) + "\n")); err != nil {
+			return len(p), err
+		}
+		rw.buf = rw.buf[i+1:]
+	}
+}
+
+// Flush redacts and writes any buffered partial line.
+func (rw *RedactWriter) Flush() error {
+	if len(rw.buf) == 0 {
+		return nil
+	}
+	_, err := rw.w.Write([]byte(rw.r.Line(string(rw.buf))))
+	rw.buf = nil
+	return err
+}
+
+// Redact masks secrets such as passwords, tokens, and API keys in a line,
+// using only the built-in patterns.
+func Redact(line string) string {
+	var r *Redactor
+	return r.Line(line)
+}
@@ -0,0 +1,155 @@
+package testoutput
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultLines is how many trailing lines of test output are kept when no
+// explicit limit is configured.
+const DefaultLines = 20
+
+// maxFailingTests caps how many failing test names are kept.
+const maxFailingTests = 20
+
+// Tail returns the last n lines of output, redacted. Trailing blank lines are
+// dropped. A non-positive n keeps DefaultLines.
+func Tail(output string, n int) []string {
+	if n <= 0 {
+		n = DefaultLines
+	}
+	output = strings.TrimRight(output, "\r\n\t ")
+	if output == "" {
+		return nil
+	}
+	lines := strings.Split(output, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	tail := make([]string, len(lines))
+	for i, line := range lines {
+		tail[i] = Redact(strings.TrimRight(line, "\r"))
+	}
+	return tail
+}
+
+// failingTestPatterns extract the name of a failing test from one output line.
+var failingTestPatterns = []*regexp.Regexp{
+	// Go: "--- FAIL: TestName (0.00s)"
+	regexp.MustCompile(`^\s*--- FAIL: (\S+)`),
+	// pytest: "FAILED tests/test_x.py::test_name - AssertionError"
+	regexp.MustCompile(`^FAILED (\S+)`),
+	// Jest/Vitest: "● Suite › test name"
+	regexp.MustCompile(`^\s*● (.+› .+)$`),
+	// RSpec: "rspec ./spec/x_spec.rb:12 # Thing does stuff"
+	regexp.MustCompile(`^rspec \S+ # (.+)$`),
+	// cargo: "test module::name ... FAILED"
+	regexp.MustCompile(`^test (\S+) \.\.\. FAILED`),
+	// .NET: "  Failed Namespace.Class.Method [12 ms]"
+	regexp.MustCompile(`^\s*Failed (\S+) \[`),
+	// PHPUnit: "1) Tests\FooTest::testBar"
+	regexp.MustCompile(`^\d+\) (\S+::\S+)`),
+	// mocha: "  1) Suite name"
+	regexp.MustCompile(`^\s+\d+\) (.+)$`),
+}
+
+// matchFailingTest returns the failing test named on a line and the text that
+// follows the match, or "" when the line names no failing test.
+func matchFailingTest(line string) (name, rest string) {
+	for _, re := range failingTestPatterns {
+		loc := re.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		return strings.TrimSpace(line[loc[2]:loc[3]]), line[loc[1]:]
+	}
+	return "", ""
+}
+
+// fileLineMessage matches assertion messages prefixed by a source location,
+// e.g. "    cart_test.go:12: expected 3, got 2".
+var fileLineMessage = regexp.MustCompile(`^\s*\S+\.\w+:\d+: \S`)
+
+// messageWindow is how many lines around a failing test are searched for its
+// failure message.
+const messageWindow = 10
+
+// FirstFailure returns the name and failure message of the first failing test
+// in test runner output. The message comes from an inline " - message" suffix
+// (pytest), the first detail line after the test (Go, Jest, cargo), or a
+// file:line message just before it (go test -v). test is "" when no failing
+// test is found; message may be "" when none can be identified.
+func FirstFailure(output string) (test, message string) {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		name, rest := matchFailingTest(strings.TrimRight(line, "\r"))
+		if name == "" {
+			continue
+		}
+		if msg, ok := strings.CutPrefix(strings.TrimSpace(rest), "- "); ok {
+			return name, Redact(strings.TrimSpace(msg))
+		}
+		return name, Redact(failureMessage(lines, i))
+	}
+	return "", ""
+}
+
+// failureMessage finds the message for the failing test reported on lines[at].
+func failureMessage(lines []string, at int) string {
+	for j := at + 1; j < len(lines) && j <= at+messageWindow; j++ {
+		line := strings.TrimSpace(lines[j])
+		if name, _ := matchFailingTest(lines[j]); name != "" || strings.HasPrefix(line, "=== RUN") {
+			break
+		}
+		if line == "" || isSummaryLine(line) {
+			continue
+		}
+		return line
+	}
+	for j := at - 1; j >= 0 && j >= at-messageWindow; j-- {
+		if strings.HasPrefix(strings.TrimSpace(lines[j]), "=== RUN") {
+			break
+		}
+		if fileLineMessage.MatchString(lines[j]) {
+			return strings.TrimSpace(lines[j])
+		}
+	}
+	return ""
+}
+
+// isSummaryLine reports runner bookkeeping lines that never carry a message.
+func isSummaryLine(line string) bool {
+	for _, prefix := range []string{"FAIL", "ok ", "===", "---", "PASS", "exit status"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FailingTests extracts the names of failing tests from test runner output,
+// in order of first appearance and without duplicates.
+func FailingTests(output string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		name, _ := matchFailingTest(strings.TrimRight(line, "\r"))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		if len(names) == maxFailingTests {
+			break
+		}
+	}
+	return names
+}
+
+// cachedResult matches a 'go test' package result served from the test cache.
+var cachedResult = regexp.MustCompile(`(?m)^ok\s+\S+\s+\(cached\)`)
+
+// Cached reports whether any package result in 'go test' output was served
+// from Go's test cache rather than run.
+func Cached(output string) bool {
+	return cachedResult.MatchString(output)
+}
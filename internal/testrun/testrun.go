@@ -0,0 +1,139 @@
+package testrun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// DirName is the directory where background test runs keep their state and output.
+const DirName = ".tdd-ai.runs"
+
+// Run status values.
+const (
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+)
+
+// Run is the persisted state of a background test run.
+type Run struct {
+	ID         string `json:"id"`
+	Cmd        string `json:"cmd"`
+	Suite      string `json:"suite,omitempty"`
+	Status     string `json:"status"`
+	Result     string `json:"result,omitempty"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	// Phase and Iteration are the session's when the run started; a result
+	// is only recorded while the session is still there.
+	Phase     types.Phase `json:"phase,omitempty"`
+	Iteration int         `json:"iteration,omitempty"`
+	// Collected is set once the result has been recorded in the session, so
+	// repeated polling does not record it twice.
+	Collected bool `json:"collected,omitempty"`
+	// Stale is set instead when the session had moved on by the time the
+	// result was collected, so it was never recorded.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// ErrInvalidID is returned for a run ID that New could not have generated.
+var ErrInvalidID = errors.New("invalid test run ID")
+
+// ValidID reports whether id has the form New generates: a positive base-36
+// number in lowercase, so it can only name a file inside the runs directory.
+func ValidID(id string) bool {
+	n, err := strconv.ParseInt(id, 36, 64)
+	return err == nil && n > 0 && strconv.FormatInt(n, 36) == id
+}
+
+// Dir returns the runs directory for a given working directory.
+func Dir(dir string) string {
+	return filepath.Join(dir, DirName)
+}
+
+// LogPath returns the path of the captured output for a run.
+func LogPath(dir, id string) string {
+	return filepath.Join(Dir(dir), id+".log")
+}
+
+func statePath(dir, id string) string {
+	return filepath.Join(Dir(dir), id+".json")
+}
+
+// New creates a running Run for cmd with a fresh time-based ID and saves it.
+// Suite names the test suite the command belongs to, if any; phase and
+// iteration are the session's at the start of the run.
+func New(dir, cmd, suite string, phase types.Phase, iteration int, now time.Time) (*Run, error) {
+	r := &Run{
+		ID:        strconv.FormatInt(now.UnixNano(), 36),
+		Cmd:       cmd,
+		Suite:     suite,
+		Status:    StatusRunning,
+		StartedAt: now.UTC().Format(time.RFC3339),
+		Phase:     phase,
+		Iteration: iteration,
+	}
+	if err := os.MkdirAll(Dir(dir), 0755); err != nil {
+		return nil, fmt.Errorf("creating runs directory: %w", err)
+	}
+	if err := Save(dir, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Load reads the state of run id, which must be a valid run ID.
+func Load(dir, id string) (*Run, error) {
+	if !ValidID(id) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidID, id)
+	}
+	data, err := os.ReadFile(statePath(dir, id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("test run %q not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading test run: %w", err)
+	}
+	var r Run
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing test run: %w", err)
+	}
+	if r.ID != id {
+		return nil, fmt.Errorf("parsing test run: state of %q names run %q", id, r.ID)
+	}
+	return &r, nil
+}
+
+// Save writes the run state to disk.
+func Save(dir string, r *Run) error {
+	if !ValidID(r.ID) {
+		return fmt.Errorf("%w %q", ErrInvalidID, r.ID)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding test run: %w", err)
+	}
+	if err := os.WriteFile(statePath(dir, r.ID), data, 0644); err != nil {
+		return fmt.Errorf("writing test run: %w", err)
+	}
+	return nil
+}
+
+// Matches reports whether s is still in the phase and iteration the run
+// started in. Runs saved before these were recorded match any session.
+func (r *Run) Matches(s *types.Session) bool {
+	return r.Phase == "" || (r.Phase == s.Phase && r.Iteration == s.Iteration)
+}
+
+// Finish marks the run as finished with the given result.
+func (r *Run) Finish(result string, now time.Time) {
+	r.Status = StatusFinished
+	r.Result = result
+	r.FinishedAt = now.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,91 @@
+package testrun
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestNewSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	r, err := New(dir, "go test ./...", "", types.PhaseGreen, 2, now)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if r.ID == "" || r.Status != StatusRunning {
+		t.Fatalf("New() = %+v, want running run with ID", r)
+	}
+
+	r.Finish("fail", now.Add(time.Minute))
+	if err := Save(dir, r); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := Load(dir, r.ID)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if loaded.Status != StatusFinished || loaded.Result != "fail" || loaded.Cmd != "go test ./..." {
+		t.Errorf("Load() = %+v, want finished fail run", loaded)
+	}
+	if loaded.FinishedAt != "2026-10-15T12:01:00Z" {
+		t.Errorf("FinishedAt = %q", loaded.FinishedAt)
+	}
+	if loaded.Phase != types.PhaseGreen || loaded.Iteration != 2 {
+		t.Errorf("Load() phase %q iteration %d, want green 2", loaded.Phase, loaded.Iteration)
+	}
+}
+
+func TestLoadRejectsInvalidIDs(t *testing.T) {
+	for _, id := range []string{"", "../../etc/passwd", "K3X9A1", "-k3x9a1", "0", "00k3", "k3x9a1.json", "zzzzzzzzzzzzzzz"} {
+		if _, err := Load(t.TempDir(), id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Load(%q) error = %v, want ErrInvalidID", id, err)
+		}
+	}
+	if err := Save(t.TempDir(), &Run{ID: "../escape"}); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Save() error = %v, want ErrInvalidID", err)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	s := types.NewSession()
+	s.Iteration = 2
+	s.Phase = types.PhaseGreen
+	tests := []struct {
+		name string
+		run  Run
+		want bool
+	}{
+		{"same phase and iteration", Run{Phase: types.PhaseGreen, Iteration: 2}, true},
+		{"other phase", Run{Phase: types.PhaseRed, Iteration: 2}, false},
+		{"other iteration", Run{Phase: types.PhaseGreen, Iteration: 1}, false},
+		{"recorded before phases were", Run{}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.run.Matches(s); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadUnknownRun(t *testing.T) {
+	if _, err := Load(t.TempDir(), "nope"); err == nil {
+		t.Error("Load() should fail for an unknown run")
+	}
+}
+
+func TestLogPathIsInsideRunsDir(t *testing.T) {
+	dir := t.TempDir()
+	r, err := New(dir, "true", "", types.PhaseRed, 0, time.Now())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := os.WriteFile(LogPath(dir, r.ID), []byte("ok\n"), 0644); err != nil {
+		t.Fatalf("writing log inside runs dir: %v", err)
+	}
+}
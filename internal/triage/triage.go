@@ -0,0 +1,98 @@
+package triage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+// maxLineLength caps how much of a matching output line a hint keeps.
+const maxLineLength = 200
+
+// Builtin is the catalog of failure signatures every session recognizes. A
+// project pattern of the same name replaces the built-in one.
+var Builtin = map[string]types.TriagePattern{
+	"port-in-use": {
+		Match: `(?i)address already in use|EADDRINUSE|port \d+ is already (?:in use|allocated)`,
+		Hint:  "A port the tests bind is taken, often by a server left running from an earlier run. Stop it, or have the tests listen on port 0.",
+	},
+	"network": {
+		Match: `(?i)connection refused|connection reset by peer|no such host|i/o timeout|ECONNREFUSED|ECONNRESET|ETIMEDOUT|getaddrinfo ENOTFOUND`,
+		Hint:  "The test depends on the network or an external service. Re-run to rule out flakiness, then fake the dependency so the test is deterministic.",
+	},
+	"snapshot-mismatch": {
+		Match: `(?i)snapshots? (?:failed|obsolete)|does not match (?:stored )?snapshot|toMatchSnapshot|toMatchInlineSnapshot`,
+		Hint:  "A stored snapshot differs from the output. If the change is intended, update the snapshot deliberately and review its diff; otherwise the code regressed.",
+	},
+	"test-timeout": {
+		Match: `panic: test timed out after|Exceeded timeout of \d+ ?ms|Timeout of \d+ms exceeded|Failed: Timeout >`,
+		Hint:  "A test ran past its deadline. Look for a blocked channel, an unreturned promise, or a deadlock rather than raising the timeout.",
+	},
+	"data-race": {
+		Match: `WARNING: DATA RACE`,
+		Hint:  "The race detector found unsynchronized access. Read both stack traces in the report and guard the shared state.",
+	},
+}
+
+// Names returns the names of the built-in and project patterns, sorted.
+func Names(s *types.Session) []string {
+	var names []string
+	for name := range Builtin {
+		names = append(names, name)
+	}
+	for name := range s.TriagePatterns {
+		if _, ok := Builtin[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the project pattern called name, or else the built-in one.
+func Resolve(s *types.Session, name string) (types.TriagePattern, error) {
+	if p, ok := s.TriagePatterns[name]; ok {
+		return p, nil
+	}
+	if p, ok := Builtin[name]; ok {
+		return p, nil
+	}
+	return types.TriagePattern{}, fmt.Errorf("unknown triage pattern %q (available: %s)", name, strings.Join(Names(s), ", "))
+}
+
+// Match returns a hint for every pattern that matches a line of output, in
+// name order. Patterns that do not compile are skipped; 'tdd-ai config triage'
+// rejects them before they are stored.
+func Match(s *types.Session, output string) []types.TriageHint {
+	if strings.TrimSpace(output) == "" {
+		return nil
+	}
+	lines := strings.Split(output, "\n")
+	var hints []types.TriageHint
+	for _, name := range Names(s) {
+		p, _ := Resolve(s, name)
+		re, err := regexp.Compile(p.Match)
+		if err != nil {
+			continue
+		}
+		for _, line := range lines {
+			if re.MatchString(line) {
+				hints = append(hints, types.TriageHint{Pattern: name, Hint: p.Hint, Line: clip(strings.TrimSpace(line))})
+				break
+			}
+		}
+	}
+	return hints
+}
+
+// clip shortens line to maxLineLength bytes, keeping UTF-8 intact.
+func clip(line string) string {
+	if len(line) <= maxLineLength {
+		return line
+	}
+	line = strings.ToValidUTF8(line[:maxLineLength], "")
+	return line + "..."
+}
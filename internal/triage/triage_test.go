@@ -0,0 +1,74 @@
+package triage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/macosta/tdd-ai/internal/types"
+)
+
+func TestBuiltinPatternsAreValid(t *testing.T) {
+	for name, p := range Builtin {
+		if err := p.Validate(); err != nil {
+			t.Errorf("built-in pattern %s: %v", name, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"port in use", "Error: listen EADDRINUSE: address already in use :::3000", []string{"port-in-use"}},
+		{"network", "dial tcp 10.0.0.1:443: connect: connection refused", []string{"network"}},
+		{"snapshot", "› 1 snapshot failed from 1 test suite.", []string{"snapshot-mismatch"}},
+		{"go timeout", "panic: test timed out after 10m0s", []string{"test-timeout"}},
+		{"race", "==================\nWARNING: DATA RACE\nWrite at 0x00c000", []string{"data-race"}},
+		{"several", "WARNING: DATA RACE\nread tcp: i/o timeout", []string{"data-race", "network"}},
+		{"plain assertion", "--- FAIL: TestAdd\n    calc_test.go:9: got 3, want 4", nil},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, h := range Match(types.NewSession(), tt.output) {
+				got = append(got, h.Pattern)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Match() patterns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchUsesProjectPatterns(t *testing.T) {
+	s := types.NewSession()
+	s.TriagePatterns = map[string]types.TriagePattern{
+		"network":   {Match: `ECONNREFUSED`, Hint: "Start the fake API first."},
+		"db-locked": {Match: `database is locked`, Hint: "Use one database per test."},
+	}
+
+	hints := Match(s, "  store_test.go:20: database is locked\nconnect ECONNREFUSED 127.0.0.1:8080\nconnection refused")
+	if len(hints) != 2 {
+		t.Fatalf("Match() = %+v, want db-locked and network", hints)
+	}
+	if hints[0].Pattern != "db-locked" || hints[0].Line != "store_test.go:20: database is locked" {
+		t.Errorf("first hint = %+v, want db-locked with its trimmed line", hints[0])
+	}
+	if hints[1].Hint != "Start the fake API first." || hints[1].Line != "connect ECONNREFUSED 127.0.0.1:8080" {
+		t.Errorf("project pattern should replace the built-in network pattern, got %+v", hints[1])
+	}
+
+	if _, err := Resolve(s, "missing"); err == nil || !strings.Contains(err.Error(), "db-locked") {
+		t.Errorf("Resolve() of an unknown name should list the available patterns, got %v", err)
+	}
+}
+
+func TestMatchClipsLongLines(t *testing.T) {
+	hints := Match(types.NewSession(), "address already in use "+strings.Repeat("x", 500))
+	if len(hints) != 1 || len(hints[0].Line) != maxLineLength+len("...") {
+		t.Errorf("Match() = %+v, want one hint with a clipped line", hints)
+	}
+}
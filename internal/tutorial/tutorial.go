@@ -0,0 +1,182 @@
+package tutorial
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the tutorial progress file, kept apart from any real session.
+const FileName = ".tdd-ai.tutorial.json"
+
+// Step is one command of the scripted mini-cycle.
+type Step struct {
+	// Command is the tdd-ai command expected at this step, without arguments.
+	Command string `json:"command"`
+	// Example is a complete invocation the learner can copy.
+	Example string `json:"example"`
+	// Teach explains why this command comes now.
+	Teach string `json:"teach"`
+	// Outcome is the simulated result of running the command.
+	Outcome string `json:"outcome"`
+}
+
+// Steps is the scripted mini-cycle for a sandbox spec, with simulated test
+// results.
+var Steps = []Step{
+	{
+		Command: "spec add",
+		Example: `tdd-ai spec add "Add returns the sum of two numbers"`,
+		Teach:   "Every cycle starts from a spec: one small, observable behavior to implement.",
+		Outcome: `Spec [1] SPEC-add-returns-sum added: Add returns the sum of two numbers`,
+	},
+	{
+		Command: "spec pick",
+		Example: "tdd-ai spec pick 1",
+		Teach:   "Pick the spec you will work on before writing a test; leaving RED without a pick is blocked.",
+		Outcome: "Now working on spec [1]",
+	},
+	{
+		Command: "test",
+		Example: "tdd-ai test",
+		Teach:   "In RED, write a test for the spec and run it. It must fail, because nothing is implemented yet.",
+		Outcome: "--- FAIL: TestAdd (0.00s)\n    add_test.go:8: Add(2, 3) = 0, want 5\nTest result: FAIL",
+	},
+	{
+		Command: "phase next",
+		Example: "tdd-ai phase next",
+		Teach:   "A failing test is exactly what RED expects, so you may advance to GREEN.",
+		Outcome: "Phase: red -> green",
+	},
+	{
+		Command: "test",
+		Example: "tdd-ai test",
+		Teach:   "In GREEN, write the minimum implementation and run the tests until they pass.",
+		Outcome: "ok  \tcalc\t0.01s\nTest result: PASS",
+	},
+	{
+		Command: "phase next",
+		Example: "tdd-ai phase next",
+		Teach:   "Passing tests are what GREEN expects, so you may advance to REFACTOR.",
+		Outcome: "Phase: green -> refactor",
+	},
+	{
+		Command: "refactor reflect",
+		Example: `tdd-ai refactor reflect 1 --answer "Test names already describe the behavior"`,
+		Teach:   "In REFACTOR, improve the design with tests green, then answer the reflection questions.",
+		Outcome: "Reflection 1 answered (7 of 7 answered)",
+	},
+	{
+		Command: "phase next",
+		Example: "tdd-ai phase next",
+		Teach:   "With reflections answered and tests passing, leaving REFACTOR completes the spec.",
+		Outcome: "Completed spec [1], iteration 1 done\nPhase: refactor -> done",
+	},
+}
+
+// Progress is the learner's position in the tutorial.
+type Progress struct {
+	Step     int `json:"step"`
+	Mistakes int `json:"mistakes"`
+}
+
+// Done reports whether every step has been completed.
+func (p *Progress) Done() bool {
+	return p.Step >= len(Steps)
+}
+
+// Current returns the step the learner is expected to perform next, or nil
+// when the tutorial is finished.
+func (p *Progress) Current() *Step {
+	if p.Done() {
+		return nil
+	}
+	return &Steps[p.Step]
+}
+
+// Attempt is the outcome of checking one command against the script.
+type Attempt struct {
+	Correct bool   `json:"correct"`
+	Outcome string `json:"outcome,omitempty"`
+	// Mistake explains why the command was wrong at this point.
+	Mistake string `json:"mistake,omitempty"`
+}
+
+// Try checks args, a tdd-ai command line without the program name, against
+// the expected step. A correct command advances the tutorial; a wrong one is
+// counted as a mistake and explained.
+func (p *Progress) Try(args []string) Attempt {
+	step := p.Current()
+	if step == nil {
+		return Attempt{Mistake: "The tutorial is finished. Run 'tdd-ai tutorial reset' to start over."}
+	}
+	line := strings.Join(args, " ")
+	if matches(line, step.Command) {
+		p.Step++
+		return Attempt{Correct: true, Outcome: step.Outcome}
+	}
+	p.Mistakes++
+	return Attempt{Mistake: explain(line, p.Step)}
+}
+
+// matches reports whether a command line invokes the given command.
+func matches(line, command string) bool {
+	return line == command || strings.HasPrefix(line, command+" ")
+}
+
+// explain says why line is wrong at step i, pointing back to the step it
+// skips or forward to where it belongs.
+func explain(line string, i int) string {
+	want := Steps[i]
+	for j := i + 1; j < len(Steps); j++ {
+		if matches(line, Steps[j].Command) {
+			return fmt.Sprintf("Too early: '%s' comes later. %s Run '%s' first.", Steps[j].Command, want.Teach, want.Command)
+		}
+	}
+	for j := i - 1; j >= 0; j-- {
+		if matches(line, Steps[j].Command) {
+			return fmt.Sprintf("Already done: '%s' was an earlier step. %s Run '%s' next.", Steps[j].Command, want.Teach, want.Command)
+		}
+	}
+	return fmt.Sprintf("'%s' is not part of this cycle. %s Run '%s' next.", line, want.Teach, want.Command)
+}
+
+// Load reads tutorial progress from dir. A missing file means a fresh start.
+func Load(dir string) (*Progress, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Progress{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading tutorial progress: %w", err)
+	}
+	var p Progress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing tutorial progress: %w", err)
+	}
+	return &p, nil
+}
+
+// Save writes tutorial progress to dir.
+func Save(dir string, p *Progress) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding tutorial progress: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, FileName), data, 0644); err != nil {
+		return fmt.Errorf("writing tutorial progress: %w", err)
+	}
+	return nil
+}
+
+// Reset removes tutorial progress from dir.
+func Reset(dir string) error {
+	err := os.Remove(filepath.Join(dir, FileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing tutorial progress: %w", err)
+	}
+	return nil
+}
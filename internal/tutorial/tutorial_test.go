@@ -0,0 +1,68 @@
+package tutorial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTryFullCycle(t *testing.T) {
+	p := &Progress{}
+	for _, step := range Steps {
+		a := p.Try(strings.Fields(strings.TrimPrefix(step.Example, "tdd-ai ")))
+		if !a.Correct {
+			t.Fatalf("step %q should be accepted, got mistake %q", step.Command, a.Mistake)
+		}
+	}
+	if !p.Done() || p.Mistakes != 0 {
+		t.Errorf("progress = %+v, want done without mistakes", p)
+	}
+	if a := p.Try([]string{"phase", "next"}); a.Correct || !strings.Contains(a.Mistake, "finished") {
+		t.Errorf("Try() after finishing = %+v, want a finished message", a)
+	}
+}
+
+func TestTryExplainsMistakes(t *testing.T) {
+	tests := []struct {
+		name string
+		step int
+		args []string
+		want string
+	}{
+		{"too early", 0, []string{"phase", "next"}, "Too early: 'phase next' comes later"},
+		{"already done", 2, []string{"spec", "add", "x"}, "Already done: 'spec add'"},
+		{"unknown", 1, []string{"status"}, "'status' is not part of this cycle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Progress{Step: tt.step}
+			a := p.Try(tt.args)
+			if a.Correct || !strings.Contains(a.Mistake, tt.want) {
+				t.Errorf("Try(%q) = %+v, want mistake containing %q", tt.args, a, tt.want)
+			}
+			if p.Step != tt.step || p.Mistakes != 1 {
+				t.Errorf("progress = %+v, want step %d with 1 mistake", p, tt.step)
+			}
+		})
+	}
+}
+
+func TestLoadSaveReset(t *testing.T) {
+	dir := t.TempDir()
+	p, err := Load(dir)
+	if err != nil || p.Step != 0 {
+		t.Fatalf("Load() on a fresh dir = %+v, %v; want step 0", p, err)
+	}
+	p.Step, p.Mistakes = 3, 1
+	if err := Save(dir, p); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if got, _ := Load(dir); *got != *p {
+		t.Errorf("Load() = %+v, want %+v", got, p)
+	}
+	if err := Reset(dir); err != nil {
+		t.Fatalf("Reset() error: %v", err)
+	}
+	if got, _ := Load(dir); got.Step != 0 {
+		t.Errorf("Load() after Reset() = %+v, want a fresh start", got)
+	}
+}
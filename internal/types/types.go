@@ -2,6 +2,12 @@ package types
 
 import (
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -41,6 +47,15 @@ type SpecStatus string
 const (
 	SpecStatusActive    SpecStatus = "active"
 	SpecStatusCompleted SpecStatus = "completed"
+	// SpecStatusSuperseded marks a spec that was split into child specs.
+	SpecStatusSuperseded SpecStatus = "superseded"
+)
+
+// Failure categories describe why a test run did not pass.
+const (
+	FailureAssertion      = "assertion"
+	FailureCompile        = "compile"
+	FailureInfrastructure = "infrastructure"
 )
 
 // Mode represents the TDD workflow mode.
@@ -54,30 +69,539 @@ const (
 // Spec is a single requirement to be implemented via TDD.
 type Spec struct {
 	ID          int        `json:"id"`
+	Slug        string     `json:"slug,omitempty"`
 	Description string     `json:"description"`
 	Status      SpecStatus `json:"status"`
+	Iterations  int        `json:"iterations,omitempty"`
+	CreatedAt   string     `json:"created_at,omitempty"`
+	UpdatedAt   string     `json:"updated_at,omitempty"`
+	CompletedAt string     `json:"completed_at,omitempty"`
+	ParentID    int        `json:"parent_id,omitempty"`
+	// DiscoveredFrom is the spec being worked on when this one came up, and
+	// DiscoveredIn the phase it came up in.
+	DiscoveredFrom int         `json:"discovered_from,omitempty"`
+	DiscoveredIn   Phase       `json:"discovered_in,omitempty"`
+	SplitInto      []int       `json:"split_into,omitempty"`
+	Criteria       []Criterion `json:"criteria,omitempty"`
+	Waiver         string      `json:"waiver,omitempty"`
+	Risk           string      `json:"risk,omitempty"`
+	// Area tags the spec with a monorepo test area, so 'tdd-ai test' runs only
+	// that area's command while the spec is current.
+	Area string `json:"area,omitempty"`
+}
+
+// UncheckedCriteria returns the spec's acceptance criteria not yet checked off,
+// ignoring any waiver.
+func (sp Spec) UncheckedCriteria() []Criterion {
+	return unmetCriteria(sp.Criteria)
+}
+
+// UnmetCriteria returns the spec's acceptance criteria not yet checked off.
+// A waived spec has none.
+func (sp Spec) UnmetCriteria() []Criterion {
+	if sp.Waiver != "" {
+		return nil
+	}
+	return unmetCriteria(sp.Criteria)
 }
 
 // ReflectionQuestion is a structured prompt the agent must answer during the refactor phase.
 type ReflectionQuestion struct {
-	ID       int    `json:"id"`
+	ID       int        `json:"id"`
+	Question string     `json:"question"`
+	Answer   string     `json:"answer,omitempty"`
+	Evidence []Evidence `json:"evidence,omitempty"`
+	// FullAnswer is the complete text of a long answer, such as one read from
+	// a file, when Answer holds only an excerpt of it.
+	FullAnswer string `json:"full_answer,omitempty"`
+	// Skipped questions count as resolved for advancing but add reflection
+	// debt; only relaxed sessions may skip.
+	Skipped    bool   `json:"skipped,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+// FullText returns the complete answer: FullAnswer when Answer is an excerpt,
+// otherwise Answer.
+func (r ReflectionQuestion) FullText() string {
+	if r.FullAnswer != "" {
+		return r.FullAnswer
+	}
+	return r.Answer
+}
+
+// Status is "answered", "skipped", or "pending".
+func (r ReflectionQuestion) Status() string {
+	switch {
+	case r.Answer != "":
+		return "answered"
+	case r.Skipped:
+		return "skipped"
+	}
+	return "pending"
+}
+
+// ReflectionDebt is a reflection question skipped in some REFACTOR phase and
+// not answered since.
+type ReflectionDebt struct {
 	Question string `json:"question"`
-	Answer   string `json:"answer,omitempty"`
+	Reason   string `json:"reason"`
+	SpecIDs  []int  `json:"spec_ids,omitempty"`
+	At       string `json:"at"`
+}
+
+// Strictness levels, set with 'tdd-ai init --strictness' or 'tdd-ai config
+// strictness'. Relaxed sessions may skip reflection questions; strict sessions
+// may not leave REFACTOR while any reflection debt is outstanding.
+const (
+	StrictnessRelaxed  = "relaxed"
+	StrictnessStandard = "standard"
+	StrictnessStrict   = "strict"
+)
+
+// Strictnesses lists the valid strictness levels.
+var Strictnesses = []string{StrictnessRelaxed, StrictnessStandard, StrictnessStrict}
+
+// Spec risk levels, set with 'tdd-ai spec add --risk' or 'tdd-ai spec risk'.
+// High-risk specs, such as ones touching auth or payments, must meet the
+// session's high-risk rules before their cycle can finish.
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// Risks lists the valid spec risk levels.
+var Risks = []string{RiskLow, RiskMedium, RiskHigh}
+
+// RiskRules are the extra requirements for finishing a cycle on a high-risk
+// spec. A zero field disables that requirement.
+type RiskRules struct {
+	// IntegrationSuite names the test suite that must pass during REFACTOR.
+	IntegrationSuite string `json:"integration_suite,omitempty"`
+	// RequireReview requires an approving human review of the iteration.
+	RequireReview bool `json:"require_review,omitempty"`
 }
 
+// DefaultHighRiskRules apply unless a policy file sets its own.
+var DefaultHighRiskRules = RiskRules{IntegrationSuite: "integration", RequireReview: true}
+
+// Evidence points a reflection answer at the code it is about.
+type Evidence struct {
+	Path string `json:"path"`
+	Line int    `json:"line,omitempty"`
+}
+
+// String renders the reference as path or path:line.
+func (e Evidence) String() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d", e.Path, e.Line)
+	}
+	return e.Path
+}
+
+// TestEvidence is the tail of a non-passing test run's output, kept so the
+// failure can be explained without re-running the tests.
+type TestEvidence struct {
+	FailingTests   []string     `json:"failing_tests,omitempty"`
+	FirstFailure   string       `json:"first_failure,omitempty"`
+	FailureMessage string       `json:"failure_message,omitempty"`
+	TriageHints    []TriageHint `json:"triage_hints,omitempty"`
+	Output         []string     `json:"output,omitempty"`
+}
+
+// TriagePattern is a known failure signature, set with 'tdd-ai config triage'
+// or built in: a regular expression matched against each line of test output,
+// and what to check when it matches.
+type TriagePattern struct {
+	Match string `json:"match"`
+	Hint  string `json:"hint"`
+}
+
+// Validate reports whether the pattern can be used.
+func (p TriagePattern) Validate() error {
+	if p.Match == "" {
+		return fmt.Errorf("match expression is required")
+	}
+	if _, err := regexp.Compile(p.Match); err != nil {
+		return fmt.Errorf("invalid match expression %q: %w", p.Match, err)
+	}
+	if strings.TrimSpace(p.Hint) == "" {
+		return fmt.Errorf("hint is required")
+	}
+	return nil
+}
+
+// TriageHint is a known failure signature found in a test run's output.
+type TriageHint struct {
+	Pattern string `json:"pattern"`
+	Hint    string `json:"hint"`
+	// Line is the first output line that matched.
+	Line string `json:"line"`
+}
+
+// TestPoint is one recorded test result in the session's trend.
+type TestPoint struct {
+	Result string `json:"result"`
+	At     string `json:"at"`
+}
+
+// TestTrendSize is how many recent test results the session keeps for its trend.
+const TestTrendSize = 20
+
 // Session holds the full state of a TDD session.
 type Session struct {
-	Phase          Phase                `json:"phase"`
-	Mode           Mode                 `json:"mode,omitempty"`
-	AgentMode      bool                 `json:"agent_mode,omitempty"`
-	TestCmd        string               `json:"test_cmd,omitempty"`
-	LastTestResult string               `json:"last_test_result,omitempty"`
-	Specs          []Spec               `json:"specs"`
-	NextID         int                  `json:"next_id"`
-	CurrentSpecID  *int                 `json:"current_spec_id,omitempty"`
-	Iteration      int                  `json:"iteration,omitempty"`
-	Reflections    []ReflectionQuestion `json:"reflections,omitempty"`
-	History        []Event              `json:"history,omitempty"`
+	Phase          Phase  `json:"phase"`
+	PhaseEnteredAt string `json:"phase_entered_at,omitempty"`
+	StartedAt      string `json:"started_at,omitempty"`
+	Mode           Mode   `json:"mode,omitempty"`
+	AgentMode      bool   `json:"agent_mode,omitempty"`
+	TestCmd        string `json:"test_cmd,omitempty"`
+	// TestCmdSource records where TestCmd came from: "flag", "template", or
+	// "detected:<file>" when init found it in the project's build files.
+	TestCmdSource string `json:"test_cmd_source,omitempty"`
+	OutputLines   int    `json:"output_lines,omitempty"`
+	// RedactPatterns are regular expressions masked in test output, on top of
+	// the built-in credential patterns.
+	RedactPatterns []string          `json:"redact_patterns,omitempty"`
+	TestCmds       map[string]string `json:"test_cmds,omitempty"`
+	TestShards     []string          `json:"test_shards,omitempty"`
+	// TestAreas map monorepo path prefixes to the test command run from that
+	// directory, e.g. "services/api" to "go test ./...".
+	TestAreas map[string]string `json:"test_areas,omitempty"`
+	// AreaResults are the results of each area's last run.
+	AreaResults map[string]string `json:"area_results,omitempty"`
+	// TriagePatterns are the project's known failure signatures by name, on
+	// top of the built-in ones; a project pattern replaces a built-in one of
+	// the same name.
+	TriagePatterns map[string]TriagePattern `json:"triage_patterns,omitempty"`
+	// TestNaming, when set, requires the tests written in RED to reference the
+	// spec they were written for.
+	TestNaming *TestNamingRule `json:"test_naming,omitempty"`
+	// NewTestNames are the failing tests first seen in RED since the current
+	// spec was picked: the tests written for it.
+	NewTestNames []string `json:"new_test_names,omitempty"`
+	// Branch is the git branch checked out when the session was initialized.
+	// Changing the session with another branch checked out warns, or fails
+	// when StrictBranch is set.
+	Branch       string `json:"branch,omitempty"`
+	StrictBranch bool   `json:"strict_branch,omitempty"`
+	// TestCounts are the expected test counts by kind of run, and TestDrops
+	// the tests each kind of run is missing; see RecordTestCount.
+	TestCounts map[string]int `json:"test_counts,omitempty"`
+	TestDrops  map[string]int `json:"test_drops,omitempty"`
+	// NoTestCache injects -count=1 into 'go test' commands so Go's test cache
+	// cannot serve stale passes.
+	NoTestCache         bool               `json:"no_test_cache,omitempty"`
+	TestEnv             map[string]string  `json:"test_env,omitempty"`
+	RequiredSuites      map[Phase][]string `json:"required_suites,omitempty"`
+	LastTestResult      string             `json:"last_test_result,omitempty"`
+	SuiteResults        map[string]string  `json:"suite_results,omitempty"`
+	ShardResults        []ShardResult      `json:"shard_results,omitempty"`
+	LastFailureCategory string             `json:"last_failure_category,omitempty"`
+	LastTestCached      bool               `json:"last_test_cached,omitempty"`
+	LastTestOutput      *TestEvidence      `json:"last_test_output,omitempty"`
+	LastTestCount       *int               `json:"last_test_count,omitempty"`
+	TestTrend           []TestPoint        `json:"test_trend,omitempty"`
+	LastAssertionCount  int                `json:"last_assertion_count,omitempty"`
+	BaselineTestCount   *int               `json:"baseline_test_count,omitempty"`
+	DisappearedTests    int                `json:"disappeared_tests,omitempty"`
+	ProtectedPaths      []string           `json:"protected_paths,omitempty"`
+	TestGlobs           []string           `json:"test_globs,omitempty"`
+	TestFileHashes      map[string]string  `json:"test_file_hashes,omitempty"`
+	// PhaseFiles is the working tree at the last phase transition, so the next
+	// one can record which files the phase changed.
+	PhaseFiles           *FileSnapshot          `json:"phase_files,omitempty"`
+	TestFilesEdited      []string               `json:"test_files_edited,omitempty"`
+	TestPolicy           map[string]string      `json:"test_policy,omitempty"`
+	MutationCmd          string                 `json:"mutation_cmd,omitempty"`
+	MutationThreshold    float64                `json:"mutation_threshold,omitempty"`
+	MutationScore        *float64               `json:"mutation_score,omitempty"`
+	MaxIterationsPerSpec int                    `json:"max_iterations_per_spec,omitempty"`
+	StaleAfter           string                 `json:"stale_after,omitempty"`
+	RefactorTimebox      string                 `json:"refactor_timebox,omitempty"`
+	StallAfter           string                 `json:"stall_after,omitempty"`
+	Strictness           string                 `json:"strictness,omitempty"`
+	LastHeartbeat        string                 `json:"last_heartbeat,omitempty"`
+	Summary              *CycleSummary          `json:"summary,omitempty"`
+	Specs                []Spec                 `json:"specs"`
+	NextID               int                    `json:"next_id"`
+	CurrentSpecID        *int                   `json:"current_spec_id,omitempty"`
+	PickGroup            []int                  `json:"pick_group,omitempty"`
+	SpecOrder            []int                  `json:"spec_order,omitempty"`
+	Iteration            int                    `json:"iteration,omitempty"`
+	Reflections          []ReflectionQuestion   `json:"reflections,omitempty"`
+	ReflectionSet        []string               `json:"reflection_set,omitempty"`
+	ReflectionDebt       []ReflectionDebt       `json:"reflection_debt,omitempty"`
+	Instructions         []string               `json:"instructions,omitempty"`
+	Rules                *PhaseRules            `json:"rules,omitempty"`
+	TextProfiles         map[string]TextProfile `json:"text_profiles,omitempty"`
+	RequireReview        bool                   `json:"require_review,omitempty"`
+	HighRiskRules        *RiskRules             `json:"high_risk_rules,omitempty"`
+	Goal                 *Goal                  `json:"goal,omitempty"`
+	Review               *Review                `json:"review,omitempty"`
+	Pair                 *Pair                  `json:"pair,omitempty"`
+	Claims               []Claim                `json:"claims,omitempty"`
+	Lease                *Lease                 `json:"lease,omitempty"`
+	AuditLog             bool                   `json:"audit_log,omitempty"`
+	AuditedEvents        int                    `json:"audited_events,omitempty"`
+	AuditEntries         int                    `json:"audit_entries,omitempty"`
+	AuditHash            string                 `json:"audit_hash,omitempty"`
+	HistoryMaxEvents     int                    `json:"history_max_events,omitempty"`
+	DoneCriteria         *DoneCriteria          `json:"done_criteria,omitempty"`
+	HistoryStrategy      string                 `json:"history_strategy,omitempty"`
+	Environment          *Environment           `json:"environment,omitempty"`
+	History              []Event                `json:"history,omitempty"`
+}
+
+// DoneCriteria are optional gates 'tdd-ai complete' checks before finishing a
+// cycle. A zero value disables the gate.
+type DoneCriteria struct {
+	// AllCriteria requires every spec's acceptance criteria to be checked off;
+	// waivers do not count.
+	AllCriteria    bool    `json:"all_criteria,omitempty"`
+	MinCoverage    float64 `json:"min_coverage,omitempty"`
+	CoverageFile   string  `json:"coverage_file,omitempty"`
+	ZeroViolations bool    `json:"zero_violations,omitempty"`
+	// FreshPassWithin requires the last full-suite test run to have passed
+	// within this duration, e.g. "30m".
+	FreshPassWithin string `json:"fresh_pass_within,omitempty"`
+}
+
+// IsZero reports whether no done gate is enabled.
+func (d *DoneCriteria) IsZero() bool {
+	return d == nil || *d == DoneCriteria{}
+}
+
+// Test naming modes, set with 'tdd-ai config test-naming'.
+const (
+	TestNamingWarn  = "warn"
+	TestNamingBlock = "block"
+)
+
+// DefaultTestNamingPatterns are the conventions a test name may follow to
+// reference its spec when the project sets none.
+var DefaultTestNamingPatterns = []string{"{slug}", "spec{id}"}
+
+// TestNamingRule requires at least one of the tests written in RED to
+// reference the current spec. Patterns are templates in which {id} and {slug}
+// stand for the spec's ID and slug; a test name references the spec when it
+// contains one of them, ignoring case and punctuation, so "{slug}" for
+// SPEC-login-404 matches TestSpecLogin404 and test_spec_login_404_returns.
+type TestNamingRule struct {
+	// Mode is warn, which only reports unreferenced tests when leaving RED, or
+	// block, which refuses to leave RED.
+	Mode     string   `json:"mode"`
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// GetPatterns returns the rule's patterns, defaulting to
+// DefaultTestNamingPatterns.
+func (r *TestNamingRule) GetPatterns() []string {
+	if len(r.Patterns) == 0 {
+		return DefaultTestNamingPatterns
+	}
+	return r.Patterns
+}
+
+// For returns the rule's patterns filled in for spec, e.g. "SPEC-login-404"
+// and "spec12". Patterns using {slug} are skipped for a spec without one.
+func (r *TestNamingRule) For(spec Spec) []string {
+	var names []string
+	for _, p := range r.GetPatterns() {
+		if strings.Contains(p, "{slug}") && spec.Slug == "" {
+			continue
+		}
+		names = append(names, strings.NewReplacer("{id}", strconv.Itoa(spec.ID), "{slug}", spec.Slug).Replace(p))
+	}
+	return names
+}
+
+// References reports whether the test name references spec under one of the
+// rule's patterns.
+func (r *TestNamingRule) References(name string, spec Spec) bool {
+	normalized := normalizeTestName(name)
+	for _, want := range r.For(spec) {
+		if containsToken(normalized, normalizeTestName(want)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTestName lowercases name and drops everything but letters and
+// digits, so naming conventions compare across test frameworks.
+func normalizeTestName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// containsToken reports whether want occurs in name without running into
+// further digits, so "spec1" is not found in "spec12".
+func containsToken(name, want string) bool {
+	if want == "" {
+		return false
+	}
+	endsInDigit := want[len(want)-1] >= '0' && want[len(want)-1] <= '9'
+	for i := 0; ; {
+		j := strings.Index(name[i:], want)
+		if j < 0 {
+			return false
+		}
+		end := i + j + len(want)
+		if !endsInDigit || end == len(name) || name[end] < '0' || name[end] > '9' {
+			return true
+		}
+		i += j + 1
+	}
+}
+
+// ValidateTestNamingPattern reports whether p can be used as a naming
+// pattern: it must reference the spec through {id} or {slug}.
+func ValidateTestNamingPattern(p string) error {
+	if !strings.Contains(p, "{id}") && !strings.Contains(p, "{slug}") {
+		return fmt.Errorf("pattern %q must contain {id} or {slug}", p)
+	}
+	return nil
+}
+
+// ShardResult is the outcome of one shard of the last 'tdd-ai test --shards'
+// run, kept for diagnosing which part of a large suite failed. Runs routed to
+// test areas are recorded the same way, with Area naming the area.
+type ShardResult struct {
+	Shard        int      `json:"shard"`
+	Area         string   `json:"area,omitempty"`
+	Cmd          string   `json:"cmd"`
+	Result       string   `json:"result"`
+	ExitCode     int      `json:"exit_code"`
+	DurationMs   int64    `json:"duration_ms"`
+	FailingTests []string `json:"failing_tests,omitempty"`
+}
+
+// PhaseRules are project rules shown in guide output for each phase, set with
+// 'tdd-ai config rules'. They are appended to the built-in rules, or replace
+// them when Replace is set.
+type PhaseRules struct {
+	Red      []string `json:"red,omitempty"`
+	Green    []string `json:"green,omitempty"`
+	Refactor []string `json:"refactor,omitempty"`
+	Replace  bool     `json:"replace,omitempty"`
+}
+
+// Code fence styles for commands in profiled text output.
+const (
+	CodeFenceNone   = "none"
+	CodeFenceInline = "inline"
+	CodeFenceBlock  = "block"
+)
+
+// TextProfile tunes how guide text is laid out for an agent that parses it,
+// set with 'tdd-ai config profile'. The zero value is the plain text layout.
+type TextProfile struct {
+	// HeadingDepth renders section titles as Markdown headings of this level;
+	// 0 keeps "Title:" lines.
+	HeadingDepth int `json:"heading_depth,omitempty"`
+	// Bullet is the list marker: "-", "*", or "+". Empty means "-".
+	Bullet string `json:"bullet,omitempty"`
+	// CodeFence wraps commands in backticks ("inline") or, for the test
+	// command, a fenced block ("block"). Empty means "none".
+	CodeFence string `json:"code_fence,omitempty"`
+}
+
+// Validate reports the first setting of the profile that is out of range.
+func (p TextProfile) Validate() error {
+	if p.HeadingDepth < 0 || p.HeadingDepth > 6 {
+		return fmt.Errorf("heading depth %d out of range 0-6", p.HeadingDepth)
+	}
+	switch p.Bullet {
+	case "", "-", "*", "+":
+	default:
+		return fmt.Errorf("invalid bullet %q (valid: -, *, +)", p.Bullet)
+	}
+	switch p.CodeFence {
+	case "", CodeFenceNone, CodeFenceInline, CodeFenceBlock:
+	default:
+		return fmt.Errorf("invalid code fence %q (valid: %s, %s, %s)", p.CodeFence, CodeFenceNone, CodeFenceInline, CodeFenceBlock)
+	}
+	return nil
+}
+
+// For returns the project rules for phase p.
+func (r *PhaseRules) For(p Phase) []string {
+	if r == nil {
+		return nil
+	}
+	switch p {
+	case PhaseRed:
+		return r.Red
+	case PhaseGreen:
+		return r.Green
+	case PhaseRefactor:
+		return r.Refactor
+	}
+	return nil
+}
+
+// IsZero reports whether no project rules are set.
+func (r *PhaseRules) IsZero() bool {
+	return r == nil || (len(r.Red) == 0 && len(r.Green) == 0 && len(r.Refactor) == 0 && !r.Replace)
+}
+
+// Rule is one guidance rule with a stable ID, so agents and reviewers can
+// refer to it.
+type Rule struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// CycleSummary is recorded when a session reaches DONE, covering the work since
+// the previous summary, so it can be re-printed without replaying the history.
+type CycleSummary struct {
+	GeneratedAt     string               `json:"generated_at"`
+	StartedAt       string               `json:"started_at,omitempty"`
+	DurationSeconds *int64               `json:"duration_seconds,omitempty"`
+	Iteration       int                  `json:"iteration"`
+	Iterations      int                  `json:"iterations"`
+	Specs           []SummarySpec        `json:"specs_completed"`
+	Reflections     []ReflectionQuestion `json:"reflection_highlights,omitempty"`
+	// FilesByPhase maps each phase left during the cycle to the files it
+	// changed, as recorded on its phase transition events.
+	FilesByPhase map[string]FileTouches `json:"files_by_phase,omitempty"`
+}
+
+// FileSnapshot is the state of a git working tree: the commit checked out and
+// the content hashes of the files that differed from it.
+type FileSnapshot struct {
+	Head  string            `json:"head"`
+	Dirty map[string]string `json:"dirty,omitempty"`
+}
+
+// FileTouches are the files changed during a phase, split into test files and
+// implementation files by the session's test globs.
+type FileTouches struct {
+	Tests []string `json:"tests,omitempty"`
+	Impl  []string `json:"impl,omitempty"`
+}
+
+// SummarySpec is one spec finished within a summarized cycle.
+type SummarySpec struct {
+	ID               int    `json:"id"`
+	Description      string `json:"description"`
+	Iterations       int    `json:"iterations,omitempty"`
+	CycleTimeSeconds *int64 `json:"cycle_time_seconds,omitempty"`
+}
+
+// Environment is a best-effort record of the toolchain a session was started
+// with, so later environment drift can be told apart from real test failures.
+type Environment struct {
+	OS         string            `json:"os"`
+	Arch       string            `json:"arch"`
+	Tools      map[string]string `json:"tools,omitempty"`
+	CapturedAt string            `json:"captured_at"`
 }
 
 // GetMode returns the session mode, defaulting to greenfield if unset.
@@ -88,27 +612,604 @@ func (s *Session) GetMode() Mode {
 	return s.Mode
 }
 
+// DefaultMutationThreshold is the minimum mutation score (percent) expected when
+// a mutation command is configured and no explicit threshold is set.
+const DefaultMutationThreshold = 80.0
+
+// GetMutationThreshold returns the configured mutation score threshold,
+// defaulting to DefaultMutationThreshold if unset.
+func (s *Session) GetMutationThreshold() float64 {
+	if s.MutationThreshold <= 0 {
+		return DefaultMutationThreshold
+	}
+	return s.MutationThreshold
+}
+
+// SuiteCmd returns the command for the named test suite. An empty name selects
+// the default test command.
+func (s *Session) SuiteCmd(name string) (string, error) {
+	if name == "" {
+		if s.TestCmd == "" {
+			return "", fmt.Errorf("no test command configured. Use 'tdd-ai init --test-cmd \"your test command\"' to set one")
+		}
+		return s.TestCmd, nil
+	}
+	if c, ok := s.TestCmds[name]; ok {
+		return c, nil
+	}
+	if len(s.TestCmds) == 0 {
+		return "", fmt.Errorf("unknown test suite %q: no suites configured. Use 'tdd-ai init --test-suite %s=\"command\"'", name, name)
+	}
+	return "", fmt.Errorf("unknown test suite %q (configured: %s)", name, strings.Join(s.SuiteNames(), ", "))
+}
+
+// SuiteNames returns the configured test suite names in sorted order.
+func (s *Session) SuiteNames() []string {
+	names := make([]string, 0, len(s.TestCmds))
+	for name := range s.TestCmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RecordSuiteResult stores the result of a named suite run for the current phase.
+func (s *Session) RecordSuiteResult(name, result string) {
+	if s.SuiteResults == nil {
+		s.SuiteResults = make(map[string]string)
+	}
+	s.SuiteResults[name] = result
+}
+
+// MissingSuites returns the suites required to pass before leaving the given
+// phase that have not passed during it.
+func (s *Session) MissingSuites(p Phase) []string {
+	var missing []string
+	for _, name := range s.RequiredSuites[p] {
+		if s.SuiteResults[name] != "pass" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// TestEnvList returns the session's test environment variables as sorted
+// KEY=VALUE entries, ready to append to a process environment.
+func (s *Session) TestEnvList() []string {
+	env := make([]string, 0, len(s.TestEnv))
+	for k, v := range s.TestEnv {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// RecordTestTrend appends a test result to the trend, keeping only the most
+// recent TestTrendSize results.
+func (s *Session) RecordTestTrend(result string) {
+	s.TestTrend = append(s.TestTrend, TestPoint{Result: result, At: now()})
+	if extra := len(s.TestTrend) - TestTrendSize; extra > 0 {
+		s.TestTrend = append([]TestPoint(nil), s.TestTrend[extra:]...)
+	}
+}
+
+// LastTestAt returns when the last test result was recorded, or "" before the
+// first run.
+func (s *Session) LastTestAt() string {
+	if n := len(s.TestTrend); n > 0 {
+		return s.TestTrend[n-1].At
+	}
+	return ""
+}
+
+// SessionStartedAt returns when the session was created, falling back to its
+// first recorded event for sessions created before start times were tracked.
+func (s *Session) SessionStartedAt() string {
+	if s.StartedAt != "" || len(s.History) == 0 {
+		return s.StartedAt
+	}
+	return s.History[0].Timestamp
+}
+
+// DefaultStaleAfter is how long an active spec may go untouched before it is
+// flagged as stale, when no explicit window is set.
+const DefaultStaleAfter = 48 * time.Hour
+
+// GetStaleAfter returns the configured staleness window, defaulting to
+// DefaultStaleAfter if unset or unparseable.
+func (s *Session) GetStaleAfter() time.Duration {
+	d, err := time.ParseDuration(s.StaleAfter)
+	if err != nil || d <= 0 {
+		return DefaultStaleAfter
+	}
+	return d
+}
+
+// DefaultStallAfter is how long a session may go without events or heartbeats
+// before it is reported as stalled, when no explicit window is set.
+const DefaultStallAfter = 30 * time.Minute
+
+// GetStallAfter returns the configured stall window, defaulting to
+// DefaultStallAfter if unset or unparseable.
+func (s *Session) GetStallAfter() time.Duration {
+	d, err := time.ParseDuration(s.StallAfter)
+	if err != nil || d <= 0 {
+		return DefaultStallAfter
+	}
+	return d
+}
+
+// GetStrictness returns the session's strictness, defaulting to standard.
+func (s *Session) GetStrictness() string {
+	if s.Strictness == "" {
+		return StrictnessStandard
+	}
+	return s.Strictness
+}
+
+// GetHighRiskRules returns the rules for high-risk specs, defaulting to
+// DefaultHighRiskRules.
+func (s *Session) GetHighRiskRules() RiskRules {
+	if s.HighRiskRules == nil {
+		return DefaultHighRiskRules
+	}
+	return *s.HighRiskRules
+}
+
+// Heartbeat records that the agent driving the session is still alive.
+func (s *Session) Heartbeat() {
+	s.LastHeartbeat = now()
+}
+
+// LastActivity returns the time of the latest event or heartbeat, or "" for a
+// session with neither.
+func (s *Session) LastActivity() string {
+	last := s.LastHeartbeat
+	if n := len(s.History); n > 0 && s.History[n-1].Timestamp > last {
+		last = s.History[n-1].Timestamp
+	}
+	return last
+}
+
+// OverridesCount returns how many manual overrides the history records,
+// including those folded into history rollups.
+func (s *Session) OverridesCount() int {
+	n := 0
+	for _, ev := range s.History {
+		switch {
+		case ev.Override, ev.Action == "phase_set":
+			n++
+		case ev.Action == HistoryRollupAction:
+			n += ev.Rollup["phase_set"]
+		}
+	}
+	return n
+}
+
+// Stalled reports whether the session has seen no event or heartbeat for
+// longer than its stall window. A session without any activity is not stalled.
+func (s *Session) Stalled(at time.Time) bool {
+	last, err := time.Parse(time.RFC3339, s.LastActivity())
+	return err == nil && at.Sub(last) > s.GetStallAfter()
+}
+
+// RefactorTimeboxExceeded reports whether the session has been in REFACTOR
+// for longer than its configured timebox. It is always false when no timebox
+// is set.
+func (s *Session) RefactorTimeboxExceeded(at time.Time) bool {
+	if s.Phase != PhaseRefactor {
+		return false
+	}
+	limit, err := time.ParseDuration(s.RefactorTimebox)
+	if err != nil || limit <= 0 {
+		return false
+	}
+	elapsed, ok := s.ElapsedInPhase(at)
+	return ok && elapsed > limit
+}
+
+// LastTouched returns when the spec was last worked on, falling back to its
+// creation time for specs recorded before updates were tracked.
+func (sp Spec) LastTouched() string {
+	if sp.UpdatedAt != "" {
+		return sp.UpdatedAt
+	}
+	return sp.CreatedAt
+}
+
+// StaleSpecs returns active specs untouched for longer than the staleness window,
+// oldest first.
+func (s *Session) StaleSpecs(at time.Time) []Spec {
+	cutoff := at.Add(-s.GetStaleAfter())
+	var stale []Spec
+	for _, spec := range s.Specs {
+		if spec.Status != SpecStatusActive {
+			continue
+		}
+		touched, err := time.Parse(time.RFC3339, spec.LastTouched())
+		if err != nil || !touched.Before(cutoff) {
+			continue
+		}
+		stale = append(stale, spec)
+	}
+	sort.SliceStable(stale, func(i, j int) bool { return stale[i].LastTouched() < stale[j].LastTouched() })
+	return stale
+}
+
+// touchSpecs marks the given specs, and every spec currently being worked on,
+// as updated at the given timestamp.
+func (s *Session) touchSpecs(at string, ids ...int) {
+	for i := range s.Specs {
+		id := s.Specs[i].ID
+		if s.IsCurrentSpec(id) || slices.Contains(ids, id) {
+			s.Specs[i].UpdatedAt = at
+		}
+	}
+}
+
 // NewSession creates a fresh TDD session starting in the red phase.
 func NewSession() *Session {
 	return &Session{
-		Phase:  PhaseRed,
-		Specs:  []Spec{},
-		NextID: 1,
+		Phase:          PhaseRed,
+		PhaseEnteredAt: now(),
+		StartedAt:      now(),
+		Specs:          []Spec{},
+		NextID:         1,
 	}
 }
 
+// SetPhase moves the session to phase p and records when it was entered.
+// Entering RED starts a new set of tests written for the current spec.
+func (s *Session) SetPhase(p Phase) {
+	s.Phase = p
+	s.PhaseEnteredAt = now()
+	if p == PhaseRed {
+		s.NewTestNames = nil
+	}
+}
+
+// ElapsedInPhase returns how long the session has been in its current phase,
+// truncated to whole seconds. ok is false for sessions recorded before phase
+// entry times were tracked.
+func (s *Session) ElapsedInPhase(at time.Time) (d time.Duration, ok bool) {
+	entered, err := time.Parse(time.RFC3339, s.PhaseEnteredAt)
+	if err != nil {
+		return 0, false
+	}
+	d = at.Sub(entered).Truncate(time.Second)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
 // AddSpec adds a new spec to the session and returns the assigned ID.
 func (s *Session) AddSpec(description string) int {
 	id := s.NextID
+	created := now()
 	s.Specs = append(s.Specs, Spec{
 		ID:          id,
+		Slug:        s.uniqueSlug(description),
 		Description: description,
 		Status:      SpecStatusActive,
+		CreatedAt:   created,
+		UpdatedAt:   created,
 	})
 	s.NextID++
 	return id
 }
 
+// SlugPrefix starts every generated spec slug.
+const SlugPrefix = "SPEC-"
+
+// slugMaxWords caps how many description words make it into a slug.
+const slugMaxWords = 3
+
+// slugStopWords are filler words dropped when building a slug.
+var slugStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true,
+	"to": true, "in": true, "on": true, "for": true, "with": true, "when": true,
+	"is": true, "are": true, "be": true, "it": true, "can": true, "should": true,
+	"returns": true, "return": true,
+}
+
+// Slugify builds a slug such as "SPEC-login-404" from a spec description.
+func Slugify(description string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			w := string(word)
+			if !slugStopWords[w] && len(words) < slugMaxWords {
+				words = append(words, w)
+			}
+			word = word[:0]
+		}
+	}
+	for _, r := range strings.ToLower(description) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			word = append(word, r)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	if len(words) == 0 {
+		return SlugPrefix + "spec"
+	}
+	return SlugPrefix + strings.Join(words, "-")
+}
+
+// uniqueSlug returns the slug for description, suffixed with -2, -3, ... when it
+// collides with an existing spec's slug.
+func (s *Session) uniqueSlug(description string) string {
+	base := Slugify(description)
+	slug := base
+	for n := 2; s.findSlug(slug) >= 0; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug
+}
+
+// findSlug returns the index of the spec with the given slug (case-insensitive), or -1.
+func (s *Session) findSlug(slug string) int {
+	for i, spec := range s.Specs {
+		if spec.Slug != "" && strings.EqualFold(spec.Slug, slug) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ResolveSpecRef turns a user-supplied spec reference, either a numeric ID or a
+// slug, into a spec ID. Numeric references are returned as-is.
+func (s *Session) ResolveSpecRef(ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, nil
+	}
+	idx := s.findSlug(ref)
+	if idx < 0 {
+		return 0, fmt.Errorf("spec reference must be a number or a known slug, got %q", ref)
+	}
+	return s.Specs[idx].ID, nil
+}
+
+// SplitSpec replaces an active spec with child specs, one per description. The
+// original is marked superseded and linked to its children. If the split spec was
+// being worked on, the selection is re-targeted to the children.
+func (s *Session) SplitSpec(id int, descriptions []string) ([]int, error) {
+	if len(descriptions) < 2 {
+		return nil, fmt.Errorf("splitting requires at least 2 child specs, got %d", len(descriptions))
+	}
+	idx := -1
+	for i, spec := range s.Specs {
+		if spec.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("spec %d not found", id)
+	}
+	if s.Specs[idx].Status != SpecStatusActive {
+		return nil, fmt.Errorf("spec %d is not active", id)
+	}
+
+	childIDs := make([]int, 0, len(descriptions))
+	for _, desc := range descriptions {
+		childID := s.AddSpec(desc)
+		s.Specs[len(s.Specs)-1].ParentID = id
+		childIDs = append(childIDs, childID)
+	}
+	s.Specs[idx].Status = SpecStatusSuperseded
+	s.Specs[idx].SplitInto = childIDs
+	if i := slices.Index(s.SpecOrder, id); i >= 0 {
+		s.SpecOrder = slices.Replace(slices.Clone(s.SpecOrder), i, i+1, childIDs...)
+	}
+
+	if len(s.PickGroup) > 0 {
+		var group []int
+		for _, gid := range s.PickGroup {
+			if gid == id {
+				group = append(group, childIDs...)
+			} else {
+				group = append(group, gid)
+			}
+		}
+		first := group[0]
+		s.CurrentSpecID = &first
+		s.PickGroup = group
+	} else if s.CurrentSpecID != nil && *s.CurrentSpecID == id {
+		first := childIDs[0]
+		s.CurrentSpecID = &first
+	}
+	return childIDs, nil
+}
+
+// findSpec returns the index of the spec with the given ID, or -1.
+func (s *Session) findSpec(id int) int {
+	for i, spec := range s.Specs {
+		if spec.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// SpecByID returns the spec with the given ID, or nil if there is none.
+func (s *Session) SpecByID(id int) *Spec {
+	if idx := s.findSpec(id); idx >= 0 {
+		return &s.Specs[idx]
+	}
+	return nil
+}
+
+// MarkDiscovered records that spec id came up while working on spec from, in
+// the current phase.
+func (s *Session) MarkDiscovered(id, from int) error {
+	if s.findSpec(from) < 0 {
+		return fmt.Errorf("spec %d not found", from)
+	}
+	idx := s.findSpec(id)
+	if idx < 0 {
+		return fmt.Errorf("spec %d not found", id)
+	}
+	s.Specs[idx].DiscoveredFrom = from
+	s.Specs[idx].DiscoveredIn = s.Phase
+	return nil
+}
+
+// SetSpecRisk sets a spec's risk level.
+func (s *Session) SetSpecRisk(id int, risk string) error {
+	if !slices.Contains(Risks, risk) {
+		return fmt.Errorf("invalid risk %q (valid: %s)", risk, strings.Join(Risks, ", "))
+	}
+	idx := s.findSpec(id)
+	if idx < 0 {
+		return fmt.Errorf("spec %d not found", id)
+	}
+	s.Specs[idx].Risk = risk
+	return nil
+}
+
+// AreaNames returns the configured test areas in sorted order.
+func (s *Session) AreaNames() []string {
+	names := make([]string, 0, len(s.TestAreas))
+	for name := range s.TestAreas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AreaFor returns the test area containing path: the longest configured area
+// equal to path or a parent directory of it. Returns "" when none matches.
+func (s *Session) AreaFor(path string) string {
+	path = strings.Trim(path, "/")
+	best := ""
+	for area := range s.TestAreas {
+		if (path == area || strings.HasPrefix(path, area+"/")) && len(area) > len(best) {
+			best = area
+		}
+	}
+	return best
+}
+
+// ResolveArea is AreaFor, failing when path is in no configured area.
+func (s *Session) ResolveArea(path string) (string, error) {
+	if len(s.TestAreas) == 0 {
+		return "", fmt.Errorf("no test areas configured. Use 'tdd-ai init --test-area %s=\"command\"'", path)
+	}
+	area := s.AreaFor(path)
+	if area == "" {
+		return "", fmt.Errorf("%q is not in a test area (configured: %s)", path, strings.Join(s.AreaNames(), ", "))
+	}
+	return area, nil
+}
+
+// SetSpecArea tags a spec with the test area containing path; an empty path
+// clears the tag.
+func (s *Session) SetSpecArea(id int, path string) error {
+	idx := s.findSpec(id)
+	if idx < 0 {
+		return fmt.Errorf("spec %d not found", id)
+	}
+	area := ""
+	if path != "" {
+		var err error
+		if area, err = s.ResolveArea(path); err != nil {
+			return err
+		}
+	}
+	s.Specs[idx].Area = area
+	return nil
+}
+
+// SpecAreas returns the distinct test areas the specs among ids are tagged
+// with, sorted. Untagged specs are skipped.
+func (s *Session) SpecAreas(ids []int) []string {
+	var areas []string
+	for _, id := range ids {
+		if idx := s.findSpec(id); idx >= 0 && s.Specs[idx].Area != "" && !slices.Contains(areas, s.Specs[idx].Area) {
+			areas = append(areas, s.Specs[idx].Area)
+		}
+	}
+	sort.Strings(areas)
+	return areas
+}
+
+// RecordAreaResult stores the result of an area's last test run.
+func (s *Session) RecordAreaResult(area, result string) {
+	if s.AreaResults == nil {
+		s.AreaResults = make(map[string]string)
+	}
+	s.AreaResults[area] = result
+}
+
+// HighRiskSpecs returns the high-risk specs among ids.
+func (s *Session) HighRiskSpecs(ids []int) []Spec {
+	var high []Spec
+	for _, id := range ids {
+		if idx := s.findSpec(id); idx >= 0 && s.Specs[idx].Risk == RiskHigh {
+			high = append(high, s.Specs[idx])
+		}
+	}
+	return high
+}
+
+// AddSpecCriteria appends acceptance criteria to a spec, continuing its
+// sequential criterion IDs.
+func (s *Session) AddSpecCriteria(id int, criteria []string) error {
+	idx := s.findSpec(id)
+	if idx < 0 {
+		return fmt.Errorf("spec %d not found", id)
+	}
+	next := len(s.Specs[idx].Criteria) + 1
+	for _, c := range criteria {
+		s.Specs[idx].Criteria = append(s.Specs[idx].Criteria, Criterion{ID: next, Description: c})
+		next++
+	}
+	return nil
+}
+
+// CheckSpecCriterion marks a spec's acceptance criterion as met (or unmet).
+func (s *Session) CheckSpecCriterion(id, criterion int, met bool) error {
+	idx := s.findSpec(id)
+	if idx < 0 {
+		return fmt.Errorf("spec %d not found", id)
+	}
+	for i, c := range s.Specs[idx].Criteria {
+		if c.ID == criterion {
+			s.Specs[idx].Criteria[i].Met = met
+			return nil
+		}
+	}
+	return fmt.Errorf("spec %d has no criterion %d", id, criterion)
+}
+
+// WaiveSpecCriteria records why a spec may be completed with acceptance
+// criteria left unchecked.
+func (s *Session) WaiveSpecCriteria(id int, reason string) error {
+	idx := s.findSpec(id)
+	if idx < 0 {
+		return fmt.Errorf("spec %d not found", id)
+	}
+	s.Specs[idx].Waiver = reason
+	return nil
+}
+
+// UnsignedSpecs returns the specs among ids that still have unchecked
+// acceptance criteria. Unknown IDs are ignored.
+func (s *Session) UnsignedSpecs(ids []int) []Spec {
+	var unsigned []Spec
+	for _, id := range ids {
+		if idx := s.findSpec(id); idx >= 0 && len(s.Specs[idx].UnmetCriteria()) > 0 {
+			unsigned = append(unsigned, s.Specs[idx])
+		}
+	}
+	return unsigned
+}
+
 // CompleteSpec marks a spec as completed by ID. Returns an error if not found.
 func (s *Session) CompleteSpec(id int) error {
 	for i, spec := range s.Specs {
@@ -117,6 +1218,7 @@ func (s *Session) CompleteSpec(id int) error {
 				return fmt.Errorf("spec %d is already completed", id)
 			}
 			s.Specs[i].Status = SpecStatusCompleted
+			s.Specs[i].CompletedAt = now()
 			return nil
 		}
 	}
@@ -129,21 +1231,98 @@ func (s *Session) CompleteAllSpecs() int {
 	for i, spec := range s.Specs {
 		if spec.Status == SpecStatusActive {
 			s.Specs[i].Status = SpecStatusCompleted
+			s.Specs[i].CompletedAt = now()
 			count++
 		}
 	}
-	return count
+	return count
+}
+
+// ActiveSpecs returns only specs that are not yet completed, in the
+// implementation sequence.
+func (s *Session) ActiveSpecs() []Spec {
+	var active []Spec
+	for _, spec := range s.Specs {
+		if spec.Status == SpecStatusActive {
+			active = append(active, spec)
+		}
+	}
+	s.sortBySpecOrder(active)
+	return active
+}
+
+// sortBySpecOrder sorts specs into the explicit spec order; specs it does not
+// list keep their relative order after the listed ones.
+func (s *Session) sortBySpecOrder(specs []Spec) {
+	if len(s.SpecOrder) == 0 {
+		return
+	}
+	rank := func(id int) int {
+		if i := slices.Index(s.SpecOrder, id); i >= 0 {
+			return i
+		}
+		return len(s.SpecOrder)
+	}
+	sort.SliceStable(specs, func(i, j int) bool { return rank(specs[i].ID) < rank(specs[j].ID) })
+}
+
+// SetSpecOrder makes ids, which must be distinct active specs, the start of
+// the implementation sequence. Active specs not listed follow in ID order.
+func (s *Session) SetSpecOrder(ids []int) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no spec IDs given")
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("spec %d listed more than once", id)
+		}
+		seen[id] = true
+		if err := s.checkPickable(id); err != nil {
+			return err
+		}
+	}
+	s.SpecOrder = append([]int(nil), ids...)
+	return nil
 }
 
-// ActiveSpecs returns only specs that are not yet completed.
-func (s *Session) ActiveSpecs() []Spec {
-	var active []Spec
-	for _, spec := range s.Specs {
-		if spec.Status == SpecStatusActive {
-			active = append(active, spec)
+// MoveSpec moves active spec id directly before (or, with after, directly
+// after) active spec target in the implementation sequence, and records the
+// full resulting order.
+func (s *Session) MoveSpec(id, target int, after bool) error {
+	if id == target {
+		return fmt.Errorf("cannot move spec %d relative to itself", id)
+	}
+	for _, ref := range []int{id, target} {
+		if err := s.checkPickable(ref); err != nil {
+			return err
 		}
 	}
-	return active
+	var order []int
+	for _, spec := range s.ActiveSpecs() {
+		if spec.ID == id {
+			continue
+		}
+		if spec.ID == target && !after {
+			order = append(order, id)
+		}
+		order = append(order, spec.ID)
+		if spec.ID == target && after {
+			order = append(order, id)
+		}
+	}
+	s.SpecOrder = order
+	return nil
+}
+
+// NextSpec returns the first active spec in the implementation sequence that
+// is not already being worked on, or nil when none remains.
+func (s *Session) NextSpec() *Spec {
+	remaining := s.RemainingSpecs()
+	if len(remaining) == 0 {
+		return nil
+	}
+	return &remaining[0]
 }
 
 // CurrentSpec returns the spec matching CurrentSpecID, or nil if none is set.
@@ -160,39 +1339,166 @@ func (s *Session) CurrentSpec() *Spec {
 }
 
 // SetCurrentSpec sets CurrentSpecID after validating the spec exists and is active.
+// Any existing pick group is cleared.
 func (s *Session) SetCurrentSpec(id int) error {
+	if err := s.checkPickable(id); err != nil {
+		return err
+	}
+	s.CurrentSpecID = &id
+	s.PickGroup = nil
+	return nil
+}
+
+// SetPickGroup selects several active specs to be worked on together in a single
+// RED-GREEN-REFACTOR pass. The first ID becomes the current spec.
+func (s *Session) SetPickGroup(ids []int) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no spec IDs given")
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("spec %d listed more than once", id)
+		}
+		seen[id] = true
+		if err := s.checkPickable(id); err != nil {
+			return err
+		}
+	}
+	if len(ids) == 1 {
+		return s.SetCurrentSpec(ids[0])
+	}
+	first := ids[0]
+	s.CurrentSpecID = &first
+	s.PickGroup = append([]int(nil), ids...)
+	return nil
+}
+
+// checkPickable returns an error if the spec does not exist or is not active.
+func (s *Session) checkPickable(id int) error {
 	for _, spec := range s.Specs {
 		if spec.ID == id {
 			if spec.Status != SpecStatusActive {
 				return fmt.Errorf("spec %d is not active", id)
 			}
-			s.CurrentSpecID = &id
 			return nil
 		}
 	}
 	return fmt.Errorf("spec %d not found", id)
 }
 
-// CompleteCurrentSpec marks the current spec as completed and clears CurrentSpecID.
+// CurrentSpecIDs returns the IDs being worked on in this iteration: the pick group
+// when one is set, otherwise the current spec alone.
+func (s *Session) CurrentSpecIDs() []int {
+	if len(s.PickGroup) > 0 {
+		return s.PickGroup
+	}
+	if s.CurrentSpecID != nil {
+		return []int{*s.CurrentSpecID}
+	}
+	return nil
+}
+
+// PickGroupSpecs returns the specs in the current pick group, in pick order.
+// Returns nil when no batch pick is active.
+func (s *Session) PickGroupSpecs() []Spec {
+	var group []Spec
+	for _, id := range s.PickGroup {
+		for _, spec := range s.Specs {
+			if spec.ID == id {
+				group = append(group, spec)
+				break
+			}
+		}
+	}
+	return group
+}
+
+// IsCurrentSpec reports whether the spec ID is part of the current iteration.
+func (s *Session) IsCurrentSpec(id int) bool {
+	for _, cur := range s.CurrentSpecIDs() {
+		if cur == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ClearCurrentSpec deselects the current spec and any pick group.
+func (s *Session) ClearCurrentSpec() {
+	s.CurrentSpecID = nil
+	s.PickGroup = nil
+}
+
+// CompleteCurrentSpec marks the current spec (or every spec in the pick group) as
+// completed and clears the selection. Members no longer active, such as one
+// finished with 'spec done' or archived during the pass, are skipped.
 func (s *Session) CompleteCurrentSpec() error {
 	if s.CurrentSpecID == nil {
 		return fmt.Errorf("no current spec selected")
 	}
-	if err := s.CompleteSpec(*s.CurrentSpecID); err != nil {
-		return err
+	for _, id := range s.CurrentSpecIDs() {
+		if spec := s.SpecByID(id); spec == nil || spec.Status != SpecStatusActive {
+			continue
+		}
+		if err := s.CompleteSpec(id); err != nil {
+			return err
+		}
 	}
-	s.CurrentSpecID = nil
+	s.ClearCurrentSpec()
 	return nil
 }
 
-// RemainingSpecs returns active specs excluding the current one.
+// RemoveCompletedSpecs removes completed specs from the session and returns
+// them, in their original order.
+func (s *Session) RemoveCompletedSpecs() []Spec {
+	var removed []Spec
+	kept := s.Specs[:0]
+	for _, spec := range s.Specs {
+		if spec.Status == SpecStatusCompleted {
+			removed = append(removed, spec)
+			continue
+		}
+		kept = append(kept, spec)
+	}
+	s.Specs = kept
+	return removed
+}
+
+// StartIteration counts a new RED-GREEN pass for every spec currently being worked on.
+func (s *Session) StartIteration() {
+	for i := range s.Specs {
+		if s.IsCurrentSpec(s.Specs[i].ID) {
+			s.Specs[i].Iterations++
+		}
+	}
+}
+
+// IterationLimitReached returns the current specs that have used up the
+// configured iterations-per-spec limit. Always empty when no limit is set.
+func (s *Session) IterationLimitReached() []Spec {
+	if s.MaxIterationsPerSpec <= 0 {
+		return nil
+	}
+	var over []Spec
+	for _, spec := range s.Specs {
+		if s.IsCurrentSpec(spec.ID) && spec.Iterations >= s.MaxIterationsPerSpec {
+			over = append(over, spec)
+		}
+	}
+	return over
+}
+
+// RemainingSpecs returns active specs excluding the current one (or pick group),
+// in the implementation sequence.
 func (s *Session) RemainingSpecs() []Spec {
 	var remaining []Spec
 	for _, spec := range s.Specs {
-		if spec.Status == SpecStatusActive && (s.CurrentSpecID == nil || spec.ID != *s.CurrentSpecID) {
+		if spec.Status == SpecStatusActive && !s.IsCurrentSpec(spec.ID) {
 			remaining = append(remaining, spec)
 		}
 	}
+	s.sortBySpecOrder(remaining)
 	return remaining
 }
 
@@ -200,7 +1506,7 @@ func (s *Session) RemainingSpecs() []Spec {
 func (s *Session) PendingReflections() []ReflectionQuestion {
 	var pending []ReflectionQuestion
 	for _, r := range s.Reflections {
-		if r.Answer == "" {
+		if r.Answer == "" && !r.Skipped {
 			pending = append(pending, r)
 		}
 	}
@@ -211,59 +1517,638 @@ func (s *Session) PendingReflections() []ReflectionQuestion {
 // or when the reflections slice is empty (backward compatibility).
 func (s *Session) AllReflectionsAnswered() bool {
 	for _, r := range s.Reflections {
-		if r.Answer == "" {
+		if r.Answer == "" && !r.Skipped {
 			return false
 		}
 	}
 	return true
 }
 
-// AnswerReflection sets the answer for a reflection question by ID.
-// Returns an error if the ID is not found.
-func (s *Session) AnswerReflection(id int, answer string) error {
+// AnswerReflection sets the answer and evidence for a reflection question by ID,
+// replacing any earlier ones. Answering a skipped question pays off the debt
+// its skip added. Returns an error if the ID is not found.
+func (s *Session) AnswerReflection(id int, answer string, evidence ...Evidence) error {
 	for i, r := range s.Reflections {
 		if r.ID == id {
+			if r.Skipped {
+				s.dropDebt(r.Question)
+			}
 			s.Reflections[i].Answer = answer
+			s.Reflections[i].FullAnswer = ""
+			s.Reflections[i].Evidence = evidence
+			s.Reflections[i].Skipped = false
+			s.Reflections[i].SkipReason = ""
+			return nil
+		}
+	}
+	return fmt.Errorf("reflection question %d not found", id)
+}
+
+// AnswerReflectionExcerpt is AnswerReflection for a long answer: excerpt is
+// stored as the answer shown wherever answers are listed, and full is kept as
+// FullAnswer when it differs.
+func (s *Session) AnswerReflectionExcerpt(id int, excerpt, full string, evidence ...Evidence) error {
+	if err := s.AnswerReflection(id, excerpt, evidence...); err != nil {
+		return err
+	}
+	if full != excerpt {
+		for i := range s.Reflections {
+			if s.Reflections[i].ID == id {
+				s.Reflections[i].FullAnswer = full
+			}
+		}
+	}
+	return nil
+}
+
+// SkipReflection marks a reflection question as skipped for reason and records
+// it as reflection debt. Returns an error if the ID is not found.
+func (s *Session) SkipReflection(id int, reason string) error {
+	for i, r := range s.Reflections {
+		if r.ID == id {
+			if r.Skipped {
+				return nil
+			}
+			s.Reflections[i].Answer = ""
+			s.Reflections[i].FullAnswer = ""
+			s.Reflections[i].Evidence = nil
+			s.Reflections[i].Skipped = true
+			s.Reflections[i].SkipReason = reason
+			s.ReflectionDebt = append(s.ReflectionDebt, ReflectionDebt{
+				Question: r.Question,
+				Reason:   reason,
+				SpecIDs:  s.CurrentSpecIDs(),
+				At:       now(),
+			})
 			return nil
 		}
 	}
 	return fmt.Errorf("reflection question %d not found", id)
 }
 
+// PayReflectionDebt removes the debt entry at the 1-based position n.
+func (s *Session) PayReflectionDebt(n int) (ReflectionDebt, error) {
+	if n < 1 || n > len(s.ReflectionDebt) {
+		return ReflectionDebt{}, fmt.Errorf("reflection debt %d not found (%d outstanding)", n, len(s.ReflectionDebt))
+	}
+	paid := s.ReflectionDebt[n-1]
+	s.ReflectionDebt = slices.Delete(s.ReflectionDebt, n-1, n)
+	if len(s.ReflectionDebt) == 0 {
+		s.ReflectionDebt = nil
+	}
+	return paid, nil
+}
+
+// dropDebt removes the most recent debt entry for question.
+func (s *Session) dropDebt(question string) {
+	for i := len(s.ReflectionDebt) - 1; i >= 0; i-- {
+		if s.ReflectionDebt[i].Question == question {
+			_, _ = s.PayReflectionDebt(i + 1)
+			return
+		}
+	}
+}
+
+// Goal is the session-level objective with optional definition-of-done criteria.
+type Goal struct {
+	Description string      `json:"description"`
+	Criteria    []Criterion `json:"criteria,omitempty"`
+}
+
+// Criterion is a single definition-of-done item for the session goal, or an
+// acceptance criterion of a spec.
+type Criterion struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Met         bool   `json:"met"`
+}
+
+// SetGoal replaces the session goal and its criteria. Criteria get sequential IDs.
+func (s *Session) SetGoal(description string, criteria []string) {
+	g := &Goal{Description: description}
+	for i, c := range criteria {
+		g.Criteria = append(g.Criteria, Criterion{ID: i + 1, Description: c})
+	}
+	s.Goal = g
+}
+
+// CheckCriterion marks a goal criterion as met (or unmet) by ID.
+func (s *Session) CheckCriterion(id int, met bool) error {
+	if s.Goal == nil {
+		return fmt.Errorf("no goal set")
+	}
+	for i, c := range s.Goal.Criteria {
+		if c.ID == id {
+			s.Goal.Criteria[i].Met = met
+			return nil
+		}
+	}
+	return fmt.Errorf("criterion %d not found", id)
+}
+
+// UnmetCriteria returns the goal criteria not yet checked off.
+func (g *Goal) UnmetCriteria() []Criterion {
+	return unmetCriteria(g.Criteria)
+}
+
+func unmetCriteria(criteria []Criterion) []Criterion {
+	var unmet []Criterion
+	for _, c := range criteria {
+		if !c.Met {
+			unmet = append(unmet, c)
+		}
+	}
+	return unmet
+}
+
+// Review verdicts a human reviewer can record.
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+)
+
+// Review is a human reviewer's verdict on an iteration.
+type Review struct {
+	Verdict   string `json:"verdict"`
+	Comment   string `json:"comment,omitempty"`
+	Iteration int    `json:"iteration"`
+	At        string `json:"at"`
+}
+
+// RecordReview stores the reviewer's verdict for the current iteration,
+// replacing any earlier review.
+func (s *Session) RecordReview(verdict, comment string) error {
+	if verdict != ReviewApproved && verdict != ReviewChangesRequested {
+		return fmt.Errorf("invalid review verdict %q", verdict)
+	}
+	if verdict == ReviewChangesRequested && comment == "" {
+		return fmt.Errorf("a comment is required when requesting changes")
+	}
+	s.Review = &Review{
+		Verdict:   verdict,
+		Comment:   comment,
+		Iteration: s.Iteration,
+		At:        now(),
+	}
+	return nil
+}
+
+// HasApprovedReview reports whether the current iteration has an approving review.
+func (s *Session) HasApprovedReview() bool {
+	return s.Review != nil && s.Review.Verdict == ReviewApproved && s.Review.Iteration == s.Iteration
+}
+
+// AgentIDEnv is the environment variable identifying the agent running the CLI.
+const AgentIDEnv = "TDD_AI_AGENT_ID"
+
+// CurrentAgentID returns the agent identity from the environment, or "" if unset.
+func CurrentAgentID() string {
+	return os.Getenv(AgentIDEnv)
+}
+
+// Lease grants one agent exclusive rights to advance phases until it expires.
+type Lease struct {
+	Holder     string `json:"holder"`
+	AcquiredAt string `json:"acquired_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// Expired reports whether the lease has lapsed at the given time.
+// A lease with an unparseable expiry is treated as expired.
+func (l *Lease) Expired(now time.Time) bool {
+	exp, err := time.Parse(time.RFC3339, l.ExpiresAt)
+	if err != nil {
+		return true
+	}
+	return !now.Before(exp)
+}
+
+// AcquireLease grants or renews a lease for the agent. Returns an error if another
+// agent holds an unexpired lease.
+func (s *Session) AcquireLease(agentID string, ttl time.Duration, now time.Time) error {
+	if agentID == "" {
+		return fmt.Errorf("agent ID required to acquire a lease (set %s)", AgentIDEnv)
+	}
+	if err := s.CheckLease(agentID, now); err != nil {
+		return err
+	}
+	s.Lease = &Lease{
+		Holder:     agentID,
+		AcquiredAt: now.UTC().Format(time.RFC3339),
+		ExpiresAt:  now.Add(ttl).UTC().Format(time.RFC3339),
+	}
+	return nil
+}
+
+// Pair roles. In pair mode the tester owns RED and the implementer owns GREEN.
+const (
+	RoleTester      = "tester"
+	RoleImplementer = "implementer"
+)
+
+// Pair assigns two agent identities to alternating driver roles.
+type Pair struct {
+	Tester      string `json:"tester"`
+	Implementer string `json:"implementer"`
+}
+
+// Driver returns the role and agent ID allowed to act in the given phase.
+// Phases owned by neither role return empty strings.
+func (p *Pair) Driver(phase Phase) (role, agentID string) {
+	switch phase {
+	case PhaseRed:
+		return RoleTester, p.Tester
+	case PhaseGreen:
+		return RoleImplementer, p.Implementer
+	}
+	return "", ""
+}
+
+// RoleOf returns the pair role held by the agent, or "" if it is not paired.
+func (p *Pair) RoleOf(agentID string) string {
+	switch agentID {
+	case p.Tester:
+		return RoleTester
+	case p.Implementer:
+		return RoleImplementer
+	}
+	return ""
+}
+
+// CheckPairRole returns an error if pair mode is on and the agent does not hold
+// the role that drives the current phase.
+func (s *Session) CheckPairRole(agentID string) error {
+	if s.Pair == nil {
+		return nil
+	}
+	role, driver := s.Pair.Driver(s.Phase)
+	if driver == "" || driver == agentID {
+		return nil
+	}
+	if agentID == "" {
+		return fmt.Errorf("pair mode: the %s phase belongs to the %s %q (set %s)", s.Phase, role, driver, AgentIDEnv)
+	}
+	actual := "not part of the pair"
+	if r := s.Pair.RoleOf(agentID); r != "" {
+		actual = "the " + r
+	}
+	return fmt.Errorf("pair mode: the %s phase belongs to the %s %q, but agent %q is %s", s.Phase, role, driver, agentID, actual)
+}
+
+// Claim registers that an agent is editing a file, so concurrent agents keep
+// their hands off it.
+type Claim struct {
+	Path      string `json:"path"`
+	Holder    string `json:"holder"`
+	ClaimedAt string `json:"claimed_at"`
+}
+
+// ClaimHolder returns the agent holding a claim on path, or "" if unclaimed.
+func (s *Session) ClaimHolder(path string) string {
+	for _, c := range s.Claims {
+		if c.Path == path {
+			return c.Holder
+		}
+	}
+	return ""
+}
+
+// ClaimConflicts returns the paths already claimed by an agent other than agentID.
+func (s *Session) ClaimConflicts(agentID string, paths []string) []string {
+	var conflicts []string
+	for _, p := range paths {
+		if holder := s.ClaimHolder(p); holder != "" && holder != agentID {
+			conflicts = append(conflicts, p)
+		}
+	}
+	return conflicts
+}
+
+// ClaimFiles claims paths for agentID. Paths the agent already holds are left
+// as-is. Fails without claiming anything if another agent holds any of them.
+func (s *Session) ClaimFiles(agentID string, paths []string) error {
+	if agentID == "" {
+		return fmt.Errorf("agent ID required to claim files (set %s)", AgentIDEnv)
+	}
+	if conflicts := s.ClaimConflicts(agentID, paths); len(conflicts) > 0 {
+		return fmt.Errorf("%s is claimed by agent %q", conflicts[0], s.ClaimHolder(conflicts[0]))
+	}
+	for _, p := range paths {
+		if s.ClaimHolder(p) == "" {
+			s.Claims = append(s.Claims, Claim{Path: p, Holder: agentID, ClaimedAt: now()})
+		}
+	}
+	return nil
+}
+
+// ReleaseFiles drops agentID's claims on paths, or on every file it holds when
+// paths is empty, and returns how many claims were released.
+func (s *Session) ReleaseFiles(agentID string, paths []string) int {
+	release := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		release[p] = true
+	}
+	var kept []Claim
+	for _, c := range s.Claims {
+		if c.Holder == agentID && (len(paths) == 0 || release[c.Path]) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	released := len(s.Claims) - len(kept)
+	s.Claims = kept
+	return released
+}
+
+// RecordNewTests adds the failing tests of a run in RED that were not failing
+// in the previous run to NewTestNames. Tests failing since before the current
+// spec was picked are never counted, since each run is compared with the last.
+func (s *Session) RecordNewTests(failing, previous []string) {
+	if s.Phase != PhaseRed || s.CurrentSpecID == nil {
+		return
+	}
+	for _, name := range failing {
+		if !slices.Contains(previous, name) && !slices.Contains(s.NewTestNames, name) {
+			s.NewTestNames = append(s.NewTestNames, name)
+		}
+	}
+}
+
+// UnreferencedNewTests returns the tests written for the current spec when
+// the test naming rule is set and none of them references a current spec. It
+// returns nil when no new test names are known.
+func (s *Session) UnreferencedNewTests() []string {
+	if s.TestNaming == nil || len(s.NewTestNames) == 0 {
+		return nil
+	}
+	for _, id := range s.CurrentSpecIDs() {
+		spec := s.SpecByID(id)
+		if spec == nil {
+			continue
+		}
+		for _, name := range s.NewTestNames {
+			if s.TestNaming.References(name, *spec) {
+				return nil
+			}
+		}
+	}
+	return s.NewTestNames
+}
+
+// OffBranch reports whether the session is pinned to a branch other than
+// current. A session with no pinned branch, or an unknown current branch such
+// as a detached HEAD, is never off its branch.
+func (s *Session) OffBranch(current string) bool {
+	return s.Branch != "" && current != "" && current != s.Branch
+}
+
+// NoNewTests reports whether the last test run found no more tests than were
+// present when the current spec was picked. Returns false when either count is unknown.
+func (s *Session) NoNewTests() bool {
+	if s.LastTestCount == nil || s.BaselineTestCount == nil {
+		return false
+	}
+	return *s.LastTestCount <= *s.BaselineTestCount
+}
+
+// RecordTestCount stores the test count of a new run. key names the kind of
+// run, such as a suite or a set of test areas, since only runs of the same kind
+// are comparable. During GREEN and REFACTOR, a run finding fewer tests than
+// expected for its key records the shortfall in TestDrops, and
+// DisappearedTests totals the shortfalls until they are justified or the
+// tests are back. Otherwise the count becomes the one expected. A nil count is
+// unknown and never counts as a drop.
+func (s *Session) RecordTestCount(key string, count *int) {
+	s.LastTestCount = count
+	if count == nil {
+		return
+	}
+	expected, ok := s.TestCounts[key]
+	if ok && *count < expected && (s.Phase == PhaseGreen || s.Phase == PhaseRefactor) {
+		if s.TestDrops == nil {
+			s.TestDrops = make(map[string]int)
+		}
+		s.TestDrops[key] = expected - *count
+	} else {
+		if s.TestCounts == nil {
+			s.TestCounts = make(map[string]int)
+		}
+		s.TestCounts[key] = *count
+		delete(s.TestDrops, key)
+	}
+	s.DisappearedTests = 0
+	for _, drop := range s.TestDrops {
+		s.DisappearedTests += drop
+	}
+}
+
+// JustifyDisappearedTests accepts the missing tests as removed on purpose, so
+// the lower counts become the ones expected, and returns how many there were.
+func (s *Session) JustifyDisappearedTests() int {
+	removed := s.DisappearedTests
+	for key, drop := range s.TestDrops {
+		s.TestCounts[key] -= drop
+	}
+	s.TestDrops = nil
+	s.DisappearedTests = 0
+	return removed
+}
+
+// CheckLease returns an error if an unexpired lease is held by a different agent.
+func (s *Session) CheckLease(agentID string, now time.Time) error {
+	if s.Lease == nil || s.Lease.Expired(now) || s.Lease.Holder == agentID {
+		return nil
+	}
+	return fmt.Errorf("session is leased by agent %q until %s", s.Lease.Holder, s.Lease.ExpiresAt)
+}
+
+// now returns the current UTC time formatted for session timestamps.
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
 // Event records a notable action during the TDD session for audit trail.
 type Event struct {
 	Action    string `json:"action"`
 	From      string `json:"from,omitempty"`
 	To        string `json:"to,omitempty"`
 	Result    string `json:"result,omitempty"`
+	Suite     string `json:"suite,omitempty"`
 	SpecCount int    `json:"spec_count,omitempty"`
 	SpecID    int    `json:"spec_id,omitempty"`
-	Timestamp string `json:"at"`
+	SpecIDs   []int  `json:"spec_ids,omitempty"`
+	// Files on a phase transition are those changed during the phase it left.
+	Files   []string `json:"files,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+	AgentID string   `json:"agent_id,omitempty"`
+	// Override marks an event that bypassed guardrails, such as 'phase set';
+	// Bypassed names the guardrails that would have blocked it.
+	Override bool     `json:"override,omitempty"`
+	Bypassed []string `json:"bypassed,omitempty"`
+	// Cached marks a test run with results served from Go's test cache.
+	Cached bool `json:"cached,omitempty"`
+	// Confirmed records how a bulk action or review verdict was confirmed:
+	// ConfirmedInteractive or ConfirmedForced.
+	Confirmed string `json:"confirmed,omitempty"`
+	// AreaResults are the per-area results of a test run routed to test areas.
+	AreaResults map[string]string `json:"area_results,omitempty"`
+	// TriageHints are the known failure signatures found in a test run.
+	TriageHints []TriageHint `json:"triage_hints,omitempty"`
+	// Rollup counts the events, by action, folded into a history_rollup event.
+	Rollup    map[string]int `json:"rollup,omitempty"`
+	Timestamp string         `json:"at"`
+}
+
+// How a bulk action such as 'spec done --all', or a review verdict, was
+// confirmed.
+const (
+	// ConfirmedInteractive means a person answered a prompt in a terminal.
+	ConfirmedInteractive = "interactive"
+	// ConfirmedForced means a flag such as --yes or --approve skipped the
+	// prompt.
+	ConfirmedForced = "forced"
+)
+
+// CoversSpec reports whether the event refers to the given spec, either directly
+// or as a member of a batch pick.
+func (e Event) CoversSpec(id int) bool {
+	if e.SpecID == id {
+		return true
+	}
+	for _, sid := range e.SpecIDs {
+		if sid == id {
+			return true
+		}
+	}
+	return false
 }
 
-// AddEvent appends an event to the session history.
+// AddEvent appends an event to the session history, stamped with the current
+// agent ID when TDD_AI_AGENT_ID is set.
 func (s *Session) AddEvent(action string, opts ...func(*Event)) {
 	e := Event{
 		Action:    action,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		AgentID:   CurrentAgentID(),
+		Timestamp: now(),
 	}
 	for _, opt := range opts {
 		opt(&e)
 	}
 	s.History = append(s.History, e)
+	s.touchSpecs(e.Timestamp, append([]int{e.SpecID}, e.SpecIDs...)...)
+}
+
+// History budget strategies, applied when the history grows past
+// HistoryMaxEvents.
+const (
+	HistoryTruncateOldest = "truncate-oldest"
+	HistorySummarize      = "summarize"
+	HistoryError          = "error"
+)
+
+// HistoryStrategies lists the valid history budget strategies.
+var HistoryStrategies = []string{HistoryTruncateOldest, HistorySummarize, HistoryError}
+
+// HistoryRollupAction is the action of an event that stands in for one day of
+// summarized history.
+const HistoryRollupAction = "history_rollup"
+
+// EnforceHistoryBudget keeps the history within HistoryMaxEvents using the
+// session's strategy: drop the oldest events (the default), fold the oldest
+// events into one rollup event per day, or fail. A zero budget means no limit.
+func (s *Session) EnforceHistoryBudget() error {
+	limit := s.HistoryMaxEvents
+	if limit <= 0 || len(s.History) <= limit {
+		return nil
+	}
+	switch s.HistoryStrategy {
+	case HistoryError:
+		return fmt.Errorf("history has %d events, over the budget of %d; raise it or change the strategy with 'tdd-ai config history'", len(s.History), limit)
+	case HistorySummarize:
+		s.History = summarizeHistory(s.History, limit)
+	default:
+		s.History = append([]Event(nil), s.History[len(s.History)-limit:]...)
+	}
+	return nil
+}
+
+// summarizeHistory folds the fewest oldest events into per-day rollups that
+// bring the history within limit. If even the rollups alone exceed it, the
+// oldest rollups are dropped.
+func summarizeHistory(history []Event, limit int) []Event {
+	var rollups []Event
+	fold := len(history) - limit
+	for ; fold <= len(history); fold++ {
+		rollups = rollupByDay(history[:fold])
+		if len(rollups)+len(history)-fold <= limit {
+			break
+		}
+	}
+	if fold > len(history) {
+		fold = len(history)
+		rollups = rollups[len(rollups)-limit:]
+	}
+	return append(rollups, history[fold:]...)
+}
+
+// rollupByDay returns one history_rollup event per UTC day of events, stamped
+// with the day's last event time. Earlier rollups are merged, not nested.
+func rollupByDay(events []Event) []Event {
+	var rollups []Event
+	for _, e := range events {
+		day, _, _ := strings.Cut(e.Timestamp, "T")
+		if n := len(rollups); n == 0 || !strings.HasPrefix(rollups[n-1].Timestamp, day) {
+			rollups = append(rollups, Event{Action: HistoryRollupAction, Rollup: map[string]int{}})
+		}
+		r := &rollups[len(rollups)-1]
+		r.Timestamp = e.Timestamp
+		if e.Action == HistoryRollupAction {
+			for action, n := range e.Rollup {
+				r.Rollup[action] += n
+			}
+		} else {
+			r.Rollup[e.Action]++
+		}
+	}
+	return rollups
+}
+
+// Loop describes a pathological pattern in the session history, such as an
+// agent repeatedly hitting the same blocker.
+type Loop struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
 
 // Guidance is the structured output of the guide command.
 type Guidance struct {
-	Phase              Phase                `json:"phase"`
-	Mode               Mode                 `json:"mode"`
-	NextPhase          Phase                `json:"next_phase,omitempty"`
-	TestCmd            string               `json:"test_cmd,omitempty"`
-	Specs              []Spec               `json:"specs"`
-	CurrentSpec        *Spec                `json:"current_spec,omitempty"`
-	Iteration          int                  `json:"iteration,omitempty"`
-	TotalSpecs         int                  `json:"total_specs,omitempty"`
-	ExpectedTestResult string               `json:"expected_test_result,omitempty"`
-	Blockers           []string             `json:"blockers,omitempty"`
-	Reflections        []ReflectionQuestion `json:"reflections,omitempty"`
+	Phase                Phase                `json:"phase"`
+	PreviewFrom          Phase                `json:"preview_from,omitempty"`
+	ElapsedInPhase       string               `json:"elapsed_in_phase,omitempty"`
+	EnteredPhaseAt       string               `json:"entered_phase_at,omitempty"`
+	LastTestAt           string               `json:"last_test_at,omitempty"`
+	SessionStartedAt     string               `json:"session_started_at,omitempty"`
+	TimeboxExceeded      bool                 `json:"timebox_exceeded,omitempty"`
+	Mode                 Mode                 `json:"mode"`
+	NextPhase            Phase                `json:"next_phase,omitempty"`
+	TestCmd              string               `json:"test_cmd,omitempty"`
+	TestSuites           []string             `json:"test_suites,omitempty"`
+	Specs                []Spec               `json:"specs"`
+	CurrentSpec          *Spec                `json:"current_spec,omitempty"`
+	PickGroup            []Spec               `json:"pick_group,omitempty"`
+	Iteration            int                  `json:"iteration,omitempty"`
+	MaxIterationsPerSpec int                  `json:"max_iterations_per_spec,omitempty"`
+	TotalSpecs           int                  `json:"total_specs,omitempty"`
+	ExpectedTestResult   string               `json:"expected_test_result,omitempty"`
+	StaleSpecs           []Spec               `json:"stale_specs,omitempty"`
+	LastTestOutput       *TestEvidence        `json:"last_test_output,omitempty"`
+	Blockers             []string             `json:"blockers,omitempty"`
+	Reflections          []ReflectionQuestion `json:"reflections,omitempty"`
+	MutationScore        *float64             `json:"mutation_score,omitempty"`
+	Goal                 *Goal                `json:"goal,omitempty"`
+	FailureCategory      string               `json:"failure_category,omitempty"`
+	TestCached           bool                 `json:"test_cached,omitempty"`
+	LoopDetected         *Loop                `json:"loop_detected,omitempty"`
+	Rules                []Rule               `json:"rules,omitempty"`
+	Instructions         []string             `json:"instructions,omitempty"`
 }
@@ -1,7 +1,10 @@
 package types
 
 import (
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPhaseIsValid(t *testing.T) {
@@ -356,6 +359,60 @@ func TestAnswerReflectionNotFound(t *testing.T) {
 	}
 }
 
+func TestAnswerReflectionExcerptKeepsFullText(t *testing.T) {
+	s := NewSession()
+	s.Reflections = []ReflectionQuestion{{ID: 1, Question: "Q1"}}
+
+	if err := s.AnswerReflectionExcerpt(1, "short excerpt …", "short excerpt\n\nand the rest of it"); err != nil {
+		t.Fatalf("AnswerReflectionExcerpt(1) unexpected error: %v", err)
+	}
+	if r := s.Reflections[0]; r.Answer != "short excerpt …" || r.FullText() != "short excerpt\n\nand the rest of it" {
+		t.Errorf("Reflections[0] = %+v, want the excerpt as Answer and the full text kept", r)
+	}
+
+	if err := s.AnswerReflection(1, "a plain answer replaces it"); err != nil {
+		t.Fatalf("AnswerReflection(1) unexpected error: %v", err)
+	}
+	if r := s.Reflections[0]; r.FullAnswer != "" || r.FullText() != "a plain answer replaces it" {
+		t.Errorf("Reflections[0] = %+v, want FullAnswer cleared", r)
+	}
+}
+
+func TestSkipReflectionAddsDebtUntilAnswered(t *testing.T) {
+	s := NewSession()
+	s.Reflections = []ReflectionQuestion{
+		{ID: 1, Question: "Q1"},
+		{ID: 2, Question: "Q2"},
+	}
+
+	if err := s.SkipReflection(1, "throwaway spike"); err != nil {
+		t.Fatalf("SkipReflection(1) unexpected error: %v", err)
+	}
+	if len(s.ReflectionDebt) != 1 || s.ReflectionDebt[0].Question != "Q1" {
+		t.Fatalf("ReflectionDebt = %+v, want one entry for Q1", s.ReflectionDebt)
+	}
+	if got := len(s.PendingReflections()); got != 1 {
+		t.Errorf("PendingReflections() = %d, want 1", got)
+	}
+	if s.Reflections[0].Status() != "skipped" {
+		t.Errorf("Status() = %q, want skipped", s.Reflections[0].Status())
+	}
+
+	if err := s.AnswerReflection(1, "answered after all"); err != nil {
+		t.Fatalf("AnswerReflection(1) unexpected error: %v", err)
+	}
+	if s.Reflections[0].Skipped || len(s.ReflectionDebt) != 0 {
+		t.Errorf("answering should clear the skip and its debt, got %+v, debt %+v", s.Reflections[0], s.ReflectionDebt)
+	}
+}
+
+func TestPayReflectionDebtOutOfRange(t *testing.T) {
+	s := NewSession()
+	if _, err := s.PayReflectionDebt(1); err == nil {
+		t.Error("PayReflectionDebt(1) should fail without debt")
+	}
+}
+
 func TestCurrentSpecReturnsMatchingSpec(t *testing.T) {
 	s := NewSession()
 	s.AddSpec("first")
@@ -503,3 +560,890 @@ func TestRemainingSpecsNoCurrent(t *testing.T) {
 		t.Fatalf("RemainingSpecs() with no current = %d, want 2", len(remaining))
 	}
 }
+
+func TestSetPickGroup(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+	s.AddSpec("third")
+
+	if err := s.SetPickGroup([]int{2, 3}); err != nil {
+		t.Fatalf("SetPickGroup() unexpected error: %v", err)
+	}
+	if s.CurrentSpecID == nil || *s.CurrentSpecID != 2 {
+		t.Errorf("CurrentSpecID = %v, want 2", s.CurrentSpecID)
+	}
+	if len(s.PickGroup) != 2 {
+		t.Errorf("PickGroup = %v, want [2 3]", s.PickGroup)
+	}
+
+	remaining := s.RemainingSpecs()
+	if len(remaining) != 1 || remaining[0].ID != 1 {
+		t.Errorf("RemainingSpecs() = %v, want only spec 1", remaining)
+	}
+}
+
+func specIDs(specs []Spec) []int {
+	ids := make([]int, len(specs))
+	for i, spec := range specs {
+		ids[i] = spec.ID
+	}
+	return ids
+}
+
+func TestSpecOrder(t *testing.T) {
+	s := NewSession()
+	for _, desc := range []string{"first", "second", "third", "fourth", "fifth"} {
+		s.AddSpec(desc)
+	}
+
+	if err := s.MoveSpec(5, 2, false); err != nil {
+		t.Fatalf("MoveSpec() error: %v", err)
+	}
+	if got := specIDs(s.ActiveSpecs()); !slices.Equal(got, []int{1, 5, 2, 3, 4}) {
+		t.Errorf("ActiveSpecs() after moving 5 before 2 = %v", got)
+	}
+	if err := s.MoveSpec(1, 4, true); err != nil {
+		t.Fatalf("MoveSpec() error: %v", err)
+	}
+	if got := specIDs(s.ActiveSpecs()); !slices.Equal(got, []int{5, 2, 3, 4, 1}) {
+		t.Errorf("ActiveSpecs() after moving 1 after 4 = %v", got)
+	}
+
+	if err := s.SetSpecOrder([]int{4, 2}); err != nil {
+		t.Fatalf("SetSpecOrder() error: %v", err)
+	}
+	if got := specIDs(s.ActiveSpecs()); !slices.Equal(got, []int{4, 2, 1, 3, 5}) {
+		t.Errorf("unlisted specs should follow in ID order, got %v", got)
+	}
+	if next := s.NextSpec(); next == nil || next.ID != 4 {
+		t.Errorf("NextSpec() = %v, want spec 4", next)
+	}
+	_ = s.SetCurrentSpec(4)
+	if next := s.NextSpec(); next == nil || next.ID != 2 {
+		t.Errorf("NextSpec() should skip the current spec, got %v", next)
+	}
+
+	children, err := s.SplitSpec(2, []string{"second a", "second b"})
+	if err != nil {
+		t.Fatalf("SplitSpec() error: %v", err)
+	}
+	if got := specIDs(s.RemainingSpecs()); !slices.Equal(got, append(children, 1, 3, 5)) {
+		t.Errorf("split children should take the parent's place, got %v", got)
+	}
+
+	_ = s.CompleteSpec(3)
+	for _, ids := range [][]int{{1, 1}, {3}, {99}, {}} {
+		if err := s.SetSpecOrder(ids); err == nil {
+			t.Errorf("SetSpecOrder(%v) should fail", ids)
+		}
+	}
+	if err := s.MoveSpec(1, 1, false); err == nil {
+		t.Error("MoveSpec() should reject moving a spec relative to itself")
+	}
+}
+
+func TestSetPickGroupRejectsDuplicatesAndInactive(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+	_ = s.CompleteSpec(2)
+
+	if err := s.SetPickGroup([]int{1, 1}); err == nil {
+		t.Error("SetPickGroup() should reject duplicate IDs")
+	}
+	if err := s.SetPickGroup([]int{1, 2}); err == nil {
+		t.Error("SetPickGroup() should reject completed specs")
+	}
+	if s.CurrentSpecID != nil {
+		t.Error("CurrentSpecID should stay unset after a rejected pick")
+	}
+}
+
+func TestSetCurrentSpecClearsPickGroup(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+	_ = s.SetPickGroup([]int{1, 2})
+
+	_ = s.SetCurrentSpec(1)
+	if s.PickGroup != nil {
+		t.Errorf("PickGroup = %v, want nil after SetCurrentSpec", s.PickGroup)
+	}
+}
+
+func TestCompleteCurrentSpecCompletesPickGroup(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+	s.AddSpec("third")
+	_ = s.SetPickGroup([]int{1, 3})
+
+	if err := s.CompleteCurrentSpec(); err != nil {
+		t.Fatalf("CompleteCurrentSpec() unexpected error: %v", err)
+	}
+	if s.Specs[0].Status != SpecStatusCompleted || s.Specs[2].Status != SpecStatusCompleted {
+		t.Error("all specs in the pick group should be completed")
+	}
+	if s.Specs[1].Status != SpecStatusActive {
+		t.Error("specs outside the pick group should stay active")
+	}
+	if s.CurrentSpecID != nil || s.PickGroup != nil {
+		t.Error("selection should be cleared after CompleteCurrentSpec")
+	}
+}
+
+func TestCompleteCurrentSpecSkipsFinishedGroupMembers(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("first")
+	s.AddSpec("second")
+	s.AddSpec("third")
+	_ = s.SetPickGroup([]int{1, 2, 3})
+
+	// Mid-pass, spec 2 is finished with 'spec done' and spec 3 is archived.
+	if err := s.CompleteSpec(2); err != nil {
+		t.Fatal(err)
+	}
+	s.Specs = s.Specs[:2]
+
+	if err := s.CompleteCurrentSpec(); err != nil {
+		t.Fatalf("CompleteCurrentSpec() unexpected error: %v", err)
+	}
+	if s.Specs[0].Status != SpecStatusCompleted {
+		t.Error("the remaining group member should be completed")
+	}
+	if s.CurrentSpecID != nil || s.PickGroup != nil {
+		t.Error("selection should be cleared after CompleteCurrentSpec")
+	}
+}
+
+func TestEventCoversSpec(t *testing.T) {
+	single := Event{SpecID: 2}
+	batch := Event{SpecID: 3, SpecIDs: []int{3, 4, 5}}
+
+	if !single.CoversSpec(2) || single.CoversSpec(3) {
+		t.Error("single-spec event should cover only its SpecID")
+	}
+	if !batch.CoversSpec(4) || batch.CoversSpec(6) {
+		t.Error("batch event should cover every ID in SpecIDs")
+	}
+}
+
+func TestAddEventRecordsAgentID(t *testing.T) {
+	t.Setenv(AgentIDEnv, "worker-1")
+	s := NewSession()
+	s.AddEvent("test_run")
+
+	if s.History[0].AgentID != "worker-1" {
+		t.Errorf("event AgentID = %q, want %q", s.History[0].AgentID, "worker-1")
+	}
+}
+
+func TestAcquireLease(t *testing.T) {
+	s := NewSession()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := s.AcquireLease("worker-1", 10*time.Minute, now); err != nil {
+		t.Fatalf("AcquireLease() unexpected error: %v", err)
+	}
+	if s.Lease.Holder != "worker-1" {
+		t.Errorf("Lease.Holder = %q, want %q", s.Lease.Holder, "worker-1")
+	}
+	if s.Lease.ExpiresAt != "2026-01-01T12:10:00Z" {
+		t.Errorf("Lease.ExpiresAt = %q, want %q", s.Lease.ExpiresAt, "2026-01-01T12:10:00Z")
+	}
+
+	if err := s.AcquireLease("worker-2", time.Minute, now.Add(5*time.Minute)); err == nil {
+		t.Error("AcquireLease() should fail while another agent holds the lease")
+	}
+	if err := s.AcquireLease("worker-1", time.Minute, now.Add(5*time.Minute)); err != nil {
+		t.Errorf("holder should be able to renew the lease: %v", err)
+	}
+	if err := s.AcquireLease("worker-2", time.Minute, now.Add(7*time.Minute)); err != nil {
+		t.Errorf("AcquireLease() should succeed after expiry: %v", err)
+	}
+}
+
+func TestAcquireLeaseRequiresAgentID(t *testing.T) {
+	s := NewSession()
+	if err := s.AcquireLease("", time.Minute, time.Now()); err == nil {
+		t.Error("AcquireLease() should require an agent ID")
+	}
+}
+
+func TestCheckLease(t *testing.T) {
+	s := NewSession()
+	now := time.Now()
+	if err := s.CheckLease("anyone", now); err != nil {
+		t.Errorf("CheckLease() with no lease should pass: %v", err)
+	}
+
+	_ = s.AcquireLease("worker-1", time.Minute, now)
+	if err := s.CheckLease("worker-1", now); err != nil {
+		t.Errorf("CheckLease() should pass for the holder: %v", err)
+	}
+	if err := s.CheckLease("worker-2", now); err == nil {
+		t.Error("CheckLease() should fail for another agent")
+	}
+	if err := s.CheckLease("worker-2", now.Add(2*time.Minute)); err != nil {
+		t.Errorf("CheckLease() should pass once the lease expired: %v", err)
+	}
+}
+
+func TestSplitSpec(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("validates email")
+	s.AddSpec("other")
+	_ = s.SetCurrentSpec(1)
+
+	childIDs, err := s.SplitSpec(1, []string{"rejects missing email", "rejects malformed email"})
+	if err != nil {
+		t.Fatalf("SplitSpec() unexpected error: %v", err)
+	}
+	if len(childIDs) != 2 || childIDs[0] != 3 || childIDs[1] != 4 {
+		t.Fatalf("SplitSpec() child IDs = %v, want [3 4]", childIDs)
+	}
+	if s.Specs[0].Status != SpecStatusSuperseded {
+		t.Errorf("original status = %q, want %q", s.Specs[0].Status, SpecStatusSuperseded)
+	}
+	if len(s.Specs[0].SplitInto) != 2 {
+		t.Errorf("original SplitInto = %v, want [3 4]", s.Specs[0].SplitInto)
+	}
+	if s.Specs[2].ParentID != 1 || s.Specs[3].ParentID != 1 {
+		t.Error("children should link back to the original spec")
+	}
+	if s.CurrentSpecID == nil || *s.CurrentSpecID != 3 {
+		t.Errorf("CurrentSpecID = %v, want 3 (re-targeted to first child)", s.CurrentSpecID)
+	}
+	if len(s.ActiveSpecs()) != 3 {
+		t.Errorf("ActiveSpecs() = %d, want 3 (superseded spec excluded)", len(s.ActiveSpecs()))
+	}
+}
+
+func TestSplitSpecRetargetsPickGroup(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("a")
+	s.AddSpec("b")
+	_ = s.SetPickGroup([]int{1, 2})
+
+	if _, err := s.SplitSpec(2, []string{"b1", "b2"}); err != nil {
+		t.Fatalf("SplitSpec() unexpected error: %v", err)
+	}
+	if len(s.PickGroup) != 3 || s.PickGroup[1] != 3 || s.PickGroup[2] != 4 {
+		t.Errorf("PickGroup = %v, want [1 3 4]", s.PickGroup)
+	}
+}
+
+func TestSplitSpecErrors(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("a")
+	_ = s.CompleteSpec(1)
+
+	if _, err := s.SplitSpec(1, []string{"x", "y"}); err == nil {
+		t.Error("SplitSpec() should reject completed specs")
+	}
+	if _, err := s.SplitSpec(9, []string{"x", "y"}); err == nil {
+		t.Error("SplitSpec() should reject unknown specs")
+	}
+	if _, err := s.SplitSpec(1, []string{"x"}); err == nil {
+		t.Error("SplitSpec() should require at least two children")
+	}
+}
+
+func TestSetGoalAndCheckCriterion(t *testing.T) {
+	s := NewSession()
+	s.SetGoal("Ship password reset", []string{"email sent", "token expires"})
+
+	if s.Goal.Description != "Ship password reset" {
+		t.Errorf("Goal.Description = %q", s.Goal.Description)
+	}
+	if len(s.Goal.Criteria) != 2 || s.Goal.Criteria[1].ID != 2 {
+		t.Fatalf("Goal.Criteria = %+v, want 2 sequential criteria", s.Goal.Criteria)
+	}
+
+	if err := s.CheckCriterion(2, true); err != nil {
+		t.Fatalf("CheckCriterion() unexpected error: %v", err)
+	}
+	unmet := s.Goal.UnmetCriteria()
+	if len(unmet) != 1 || unmet[0].ID != 1 {
+		t.Errorf("UnmetCriteria() = %+v, want only criterion 1", unmet)
+	}
+	if err := s.CheckCriterion(9, true); err == nil {
+		t.Error("CheckCriterion() should fail for unknown criterion")
+	}
+}
+
+func TestCheckCriterionWithoutGoal(t *testing.T) {
+	s := NewSession()
+	if err := s.CheckCriterion(1, true); err == nil {
+		t.Error("CheckCriterion() should fail when no goal is set")
+	}
+}
+
+func TestRecordReview(t *testing.T) {
+	s := NewSession()
+	s.Iteration = 2
+
+	if err := s.RecordReview(ReviewChangesRequested, ""); err == nil {
+		t.Error("RecordReview() should require a comment when requesting changes")
+	}
+	if err := s.RecordReview("maybe", ""); err == nil {
+		t.Error("RecordReview() should reject unknown verdicts")
+	}
+
+	if err := s.RecordReview(ReviewApproved, ""); err != nil {
+		t.Fatalf("RecordReview() unexpected error: %v", err)
+	}
+	if s.Review.Iteration != 2 {
+		t.Errorf("Review.Iteration = %d, want 2", s.Review.Iteration)
+	}
+	if !s.HasApprovedReview() {
+		t.Error("HasApprovedReview() should be true after approval")
+	}
+
+	s.Iteration++
+	if s.HasApprovedReview() {
+		t.Error("HasApprovedReview() should not carry over to the next iteration")
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{"Login returns 404 when user is missing", "SPEC-login-404-user"},
+		{"Login 404", "SPEC-login-404"},
+		{"  Handles UTF-8 & punctuation!  ", "SPEC-handles-utf-8"},
+		{"the a an", "SPEC-spec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := Slugify(tt.desc); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.desc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSpecAssignsUniqueSlugs(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("Login 404")
+	s.AddSpec("login 404")
+	s.AddSpec("Login 404")
+
+	want := []string{"SPEC-login-404", "SPEC-login-404-2", "SPEC-login-404-3"}
+	for i, spec := range s.Specs {
+		if spec.Slug != want[i] {
+			t.Errorf("Specs[%d].Slug = %q, want %q", i, spec.Slug, want[i])
+		}
+	}
+}
+
+func TestResolveSpecRef(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("Login 404")
+	s.AddSpec("Logout clears session")
+
+	tests := []struct {
+		ref     string
+		want    int
+		wantErr bool
+	}{
+		{"2", 2, false},
+		{"SPEC-logout-clears-session", 2, false},
+		{"spec-login-404", 1, false},
+		{"SPEC-unknown", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := s.ResolveSpecRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveSpecRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveSpecRef(%q) = %d, want %d", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimFiles(t *testing.T) {
+	s := NewSession()
+
+	if err := s.ClaimFiles("", []string{"a.go"}); err == nil {
+		t.Error("ClaimFiles() should require an agent ID")
+	}
+	if err := s.ClaimFiles("worker-1", []string{"a.go", "b.go"}); err != nil {
+		t.Fatalf("ClaimFiles() unexpected error: %v", err)
+	}
+	if err := s.ClaimFiles("worker-1", []string{"a.go"}); err != nil {
+		t.Errorf("re-claiming own file should succeed: %v", err)
+	}
+	if len(s.Claims) != 2 {
+		t.Errorf("len(Claims) = %d, want 2", len(s.Claims))
+	}
+
+	if err := s.ClaimFiles("worker-2", []string{"c.go", "b.go"}); err == nil {
+		t.Error("ClaimFiles() should fail when another agent holds a path")
+	}
+	if s.ClaimHolder("c.go") != "" {
+		t.Error("a failed claim should not claim any path")
+	}
+	if got := s.ClaimConflicts("worker-2", []string{"a.go", "c.go"}); len(got) != 1 || got[0] != "a.go" {
+		t.Errorf("ClaimConflicts() = %v, want [a.go]", got)
+	}
+}
+
+func TestReleaseFiles(t *testing.T) {
+	s := NewSession()
+	_ = s.ClaimFiles("worker-1", []string{"a.go", "b.go"})
+	_ = s.ClaimFiles("worker-2", []string{"c.go"})
+
+	if n := s.ReleaseFiles("worker-2", []string{"a.go"}); n != 0 {
+		t.Errorf("releasing another agent's claim released %d, want 0", n)
+	}
+	if n := s.ReleaseFiles("worker-1", []string{"a.go"}); n != 1 {
+		t.Errorf("ReleaseFiles() = %d, want 1", n)
+	}
+	if n := s.ReleaseFiles("worker-1", nil); n != 1 {
+		t.Errorf("releasing all remaining claims = %d, want 1", n)
+	}
+	if len(s.Claims) != 1 || s.Claims[0].Holder != "worker-2" {
+		t.Errorf("Claims = %+v, want only worker-2's claim", s.Claims)
+	}
+}
+
+func TestIterationLimitReached(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("feature")
+	s.AddSpec("other")
+	_ = s.SetCurrentSpec(1)
+
+	s.StartIteration()
+	s.StartIteration()
+	if got := s.Specs[0].Iterations; got != 2 {
+		t.Errorf("Iterations = %d, want 2", got)
+	}
+	if s.Specs[1].Iterations != 0 {
+		t.Error("StartIteration() should only count the current spec")
+	}
+	if over := s.IterationLimitReached(); over != nil {
+		t.Errorf("IterationLimitReached() without a limit = %v, want nil", over)
+	}
+
+	s.MaxIterationsPerSpec = 3
+	if over := s.IterationLimitReached(); len(over) != 0 {
+		t.Errorf("IterationLimitReached() below the limit = %v, want none", over)
+	}
+	s.StartIteration()
+	if over := s.IterationLimitReached(); len(over) != 1 || over[0].ID != 1 {
+		t.Errorf("IterationLimitReached() = %v, want spec 1", over)
+	}
+}
+
+func TestStaleSpecs(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("old")
+	s.AddSpec("older")
+	s.AddSpec("fresh")
+	s.AddSpec("done")
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	s.Specs[0].UpdatedAt = "2026-03-07T12:00:00Z"
+	s.Specs[1].UpdatedAt = ""
+	s.Specs[1].CreatedAt = "2026-03-01T12:00:00Z"
+	s.Specs[2].UpdatedAt = "2026-03-10T11:00:00Z"
+	s.Specs[3].UpdatedAt = "2026-03-01T12:00:00Z"
+	_ = s.CompleteSpec(4)
+
+	stale := s.StaleSpecs(at)
+	if len(stale) != 2 || stale[0].ID != 2 || stale[1].ID != 1 {
+		t.Errorf("StaleSpecs() = %+v, want specs 2 and 1, oldest first", stale)
+	}
+
+	s.StaleAfter = "192h"
+	if stale := s.StaleSpecs(at); len(stale) != 1 || stale[0].ID != 2 {
+		t.Errorf("StaleSpecs() with an 8-day window = %+v, want only spec 2", stale)
+	}
+}
+
+func TestAddEventTouchesCurrentSpecs(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("picked")
+	s.AddSpec("idle")
+	_ = s.SetCurrentSpec(1)
+	s.Specs[0].UpdatedAt = "2026-01-01T00:00:00Z"
+	s.Specs[1].UpdatedAt = "2026-01-01T00:00:00Z"
+
+	s.AddEvent("test_run")
+
+	if s.Specs[0].UpdatedAt == "2026-01-01T00:00:00Z" {
+		t.Error("AddEvent() should touch the current spec")
+	}
+	if s.Specs[1].UpdatedAt != "2026-01-01T00:00:00Z" {
+		t.Error("AddEvent() should not touch specs that are not being worked on")
+	}
+}
+
+func TestCheckPairRole(t *testing.T) {
+	s := NewSession()
+	if err := s.CheckPairRole("anyone"); err != nil {
+		t.Errorf("CheckPairRole() without pair mode = %v, want nil", err)
+	}
+
+	s.Pair = &Pair{Tester: "alice", Implementer: "bob"}
+	tests := []struct {
+		phase   Phase
+		agent   string
+		wantErr bool
+	}{
+		{PhaseRed, "alice", false},
+		{PhaseRed, "bob", true},
+		{PhaseRed, "", true},
+		{PhaseGreen, "bob", false},
+		{PhaseGreen, "alice", true},
+		{PhaseGreen, "mallory", true},
+		{PhaseRefactor, "alice", false},
+		{PhaseRefactor, "bob", false},
+	}
+	for _, tt := range tests {
+		s.Phase = tt.phase
+		err := s.CheckPairRole(tt.agent)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckPairRole(%q) in %s = %v, wantErr %v", tt.agent, tt.phase, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSuiteCmd(t *testing.T) {
+	s := NewSession()
+	if _, err := s.SuiteCmd(""); err == nil {
+		t.Error("SuiteCmd(\"\") without a test command should fail")
+	}
+	s.TestCmd = "go test ./..."
+	s.TestCmds = map[string]string{"unit": "go test -short ./...", "integration": "go test -run Integration ./..."}
+
+	if got, _ := s.SuiteCmd(""); got != "go test ./..." {
+		t.Errorf("SuiteCmd(\"\") = %q, want the default test command", got)
+	}
+	if got, _ := s.SuiteCmd("unit"); got != "go test -short ./..." {
+		t.Errorf("SuiteCmd(unit) = %q", got)
+	}
+	if _, err := s.SuiteCmd("e2e"); err == nil || !strings.Contains(err.Error(), "integration, unit") {
+		t.Errorf("SuiteCmd(e2e) error = %v, want it to list configured suites", err)
+	}
+}
+
+func TestMissingSuites(t *testing.T) {
+	s := NewSession()
+	s.RequiredSuites = map[Phase][]string{PhaseRefactor: {"unit", "integration"}}
+
+	s.RecordSuiteResult("unit", "pass")
+	s.RecordSuiteResult("integration", "fail")
+	if got := s.MissingSuites(PhaseRefactor); len(got) != 1 || got[0] != "integration" {
+		t.Errorf("MissingSuites(refactor) = %v, want [integration]", got)
+	}
+	if got := s.MissingSuites(PhaseGreen); got != nil {
+		t.Errorf("MissingSuites(green) = %v, want none", got)
+	}
+}
+
+func TestElapsedInPhase(t *testing.T) {
+	s := NewSession()
+	s.SetPhase(PhaseGreen)
+	entered, err := time.Parse(time.RFC3339, s.PhaseEnteredAt)
+	if err != nil {
+		t.Fatalf("PhaseEnteredAt not RFC 3339: %q", s.PhaseEnteredAt)
+	}
+
+	d, ok := s.ElapsedInPhase(entered.Add(23*time.Minute + 500*time.Millisecond))
+	if !ok || d != 23*time.Minute {
+		t.Errorf("ElapsedInPhase() = %v, %v; want 23m0s, true", d, ok)
+	}
+
+	s.PhaseEnteredAt = ""
+	if _, ok := s.ElapsedInPhase(time.Now()); ok {
+		t.Error("ElapsedInPhase() should report unknown for sessions without an entry time")
+	}
+}
+
+func TestSessionTimestamps(t *testing.T) {
+	s := NewSession()
+	if s.SessionStartedAt() == "" || s.SessionStartedAt() != s.StartedAt {
+		t.Errorf("SessionStartedAt() = %q, want the creation time %q", s.SessionStartedAt(), s.StartedAt)
+	}
+	if s.LastTestAt() != "" {
+		t.Errorf("LastTestAt() = %q, want empty before any run", s.LastTestAt())
+	}
+
+	s.TestTrend = []TestPoint{{Result: "fail", At: "2026-01-02T10:00:00Z"}, {Result: "pass", At: "2026-01-02T10:05:00Z"}}
+	if got := s.LastTestAt(); got != "2026-01-02T10:05:00Z" {
+		t.Errorf("LastTestAt() = %q, want the latest trend point", got)
+	}
+
+	s.StartedAt = ""
+	s.History = []Event{{Action: "init", Timestamp: "2026-01-02T09:00:00Z"}}
+	if got := s.SessionStartedAt(); got != "2026-01-02T09:00:00Z" {
+		t.Errorf("SessionStartedAt() = %q, want the first event for older sessions", got)
+	}
+}
+
+func TestRefactorTimeboxExceeded(t *testing.T) {
+	s := NewSession()
+	s.SetPhase(PhaseRefactor)
+	entered, _ := time.Parse(time.RFC3339, s.PhaseEnteredAt)
+
+	if s.RefactorTimeboxExceeded(entered.Add(time.Hour)) {
+		t.Error("no timebox configured should never be exceeded")
+	}
+	s.RefactorTimebox = "15m"
+	if s.RefactorTimeboxExceeded(entered.Add(10 * time.Minute)) {
+		t.Error("10m into a 15m timebox should not be exceeded")
+	}
+	if !s.RefactorTimeboxExceeded(entered.Add(16 * time.Minute)) {
+		t.Error("16m into a 15m timebox should be exceeded")
+	}
+	s.Phase = PhaseGreen
+	if s.RefactorTimeboxExceeded(entered.Add(16 * time.Minute)) {
+		t.Error("timebox only applies to REFACTOR")
+	}
+}
+
+func TestRecordTestCountTracksDrops(t *testing.T) {
+	count := func(n int) *int { return &n }
+	s := NewSession()
+	s.Phase = PhaseRed
+	s.RecordTestCount("", count(5))
+	s.RecordTestCount("", count(4))
+	if s.DisappearedTests != 0 {
+		t.Errorf("drops in RED should not count, got %d", s.DisappearedTests)
+	}
+
+	s.Phase = PhaseGreen
+	s.RecordTestCount("", count(2))
+	s.RecordTestCount("", nil)
+	s.RecordTestCount("", count(1))
+	if s.DisappearedTests != 3 {
+		t.Errorf("DisappearedTests = %d, want 3 (4 expected, 1 found)", s.DisappearedTests)
+	}
+	if s.LastTestCount == nil || *s.LastTestCount != 1 {
+		t.Errorf("LastTestCount = %v, want 1", s.LastTestCount)
+	}
+
+	s.RecordTestCount("", count(3))
+	if s.DisappearedTests != 1 {
+		t.Errorf("restoring tests should shrink the drop, not add to it, got %d", s.DisappearedTests)
+	}
+	if removed := s.JustifyDisappearedTests(); removed != 1 || s.DisappearedTests != 0 {
+		t.Errorf("JustifyDisappearedTests() = %d, leaving %d; want 1, leaving 0", removed, s.DisappearedTests)
+	}
+	s.RecordTestCount("", count(3))
+	if s.DisappearedTests != 0 {
+		t.Errorf("the justified count should be the one expected, got %d missing", s.DisappearedTests)
+	}
+}
+
+func TestRecordTestCountComparesRunsOfTheSameKind(t *testing.T) {
+	count := func(n int) *int { return &n }
+	s := NewSession()
+	s.Phase = PhaseGreen
+	s.RecordTestCount("", count(2))
+	s.RecordTestCount("suite:unit", count(1))
+	s.RecordTestCount("", count(2))
+	if s.DisappearedTests != 0 {
+		t.Errorf("a smaller suite run should not count as a drop, got %d", s.DisappearedTests)
+	}
+
+	s.RecordTestCount("suite:unit", count(0))
+	if s.DisappearedTests != 1 || s.TestDrops["suite:unit"] != 1 {
+		t.Errorf("DisappearedTests = %d, TestDrops = %v; want the unit suite missing 1", s.DisappearedTests, s.TestDrops)
+	}
+}
+
+func TestTestNamingReferences(t *testing.T) {
+	spec := Spec{ID: 12, Slug: "SPEC-login-404"}
+	tests := []struct {
+		name     string
+		patterns []string
+		want     bool
+	}{
+		{"TestSpecLogin404", nil, true},
+		{"test_spec_login_404_returns_not_found", nil, true},
+		{"Login › SPEC-login-404 shows an error", nil, true},
+		{"TestSpec12Redirects", nil, true},
+		{"TestSpec123", nil, false},
+		{"TestLoginReturns404", nil, false},
+		{"TestLoginReturns404", []string{"login{id}"}, false},
+		{"TestIssue12_login", []string{"issue{id}"}, true},
+	}
+	for _, tt := range tests {
+		r := &TestNamingRule{Mode: TestNamingBlock, Patterns: tt.patterns}
+		if got := r.References(tt.name, spec); got != tt.want {
+			t.Errorf("References(%q, %v) = %v, want %v", tt.name, tt.patterns, got, tt.want)
+		}
+	}
+	if (&TestNamingRule{}).References("TestSpec", Spec{ID: 1}) {
+		t.Error("{slug} should not match a spec without a slug")
+	}
+}
+
+func TestUnreferencedNewTests(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("login returns 404")
+	_ = s.SetCurrentSpec(1)
+
+	s.RecordNewTests([]string{"TestOld", "TestLoginMissing"}, []string{"TestOld"})
+	if got := strings.Join(s.NewTestNames, ","); got != "TestLoginMissing" {
+		t.Fatalf("NewTestNames = %q, want only the test not failing before", got)
+	}
+	if s.UnreferencedNewTests() != nil {
+		t.Error("without a naming rule nothing should be reported")
+	}
+
+	s.TestNaming = &TestNamingRule{Mode: TestNamingWarn}
+	if got := s.UnreferencedNewTests(); len(got) != 1 || got[0] != "TestLoginMissing" {
+		t.Errorf("UnreferencedNewTests() = %v, want [TestLoginMissing]", got)
+	}
+	s.RecordNewTests([]string{"TestLoginMissing", "TestSpecLogin404Missing"}, []string{"TestOld", "TestLoginMissing"})
+	if got := s.UnreferencedNewTests(); got != nil {
+		t.Errorf("a test naming the spec should satisfy the rule, got %v", got)
+	}
+
+	s.SetPhase(PhaseGreen)
+	s.RecordNewTests([]string{"TestLater"}, nil)
+	s.SetPhase(PhaseRed)
+	if len(s.NewTestNames) != 0 {
+		t.Errorf("entering RED should start a new set of tests, got %v", s.NewTestNames)
+	}
+}
+
+func TestRecordTestTrendKeepsRecentResults(t *testing.T) {
+	s := NewSession()
+	for i := 0; i < TestTrendSize+5; i++ {
+		s.RecordTestTrend("fail")
+	}
+	s.RecordTestTrend("pass")
+	if len(s.TestTrend) != TestTrendSize {
+		t.Fatalf("len(TestTrend) = %d, want %d", len(s.TestTrend), TestTrendSize)
+	}
+	last := s.TestTrend[len(s.TestTrend)-1]
+	if last.Result != "pass" || last.At == "" {
+		t.Errorf("last point = %+v, want a timestamped pass", last)
+	}
+}
+
+func TestEnforceHistoryBudget(t *testing.T) {
+	history := func() []Event {
+		var events []Event
+		for _, at := range []string{"2026-01-01T09:00:00Z", "2026-01-01T10:00:00Z", "2026-01-02T09:00:00Z", "2026-01-02T10:00:00Z", "2026-01-03T09:00:00Z", "2026-01-03T10:00:00Z"} {
+			events = append(events, Event{Action: "phase_next", Timestamp: at})
+		}
+		events[1].Action = "phase_set"
+		return events
+	}
+
+	s := NewSession()
+	s.History = history()
+	s.HistoryMaxEvents = 4
+	if err := s.EnforceHistoryBudget(); err != nil {
+		t.Fatalf("EnforceHistoryBudget() error: %v", err)
+	}
+	if len(s.History) != 4 || s.History[0].Timestamp != "2026-01-02T09:00:00Z" {
+		t.Errorf("truncate-oldest kept %+v, want the newest 4 events", s.History)
+	}
+
+	s.History = history()
+	s.HistoryStrategy = HistorySummarize
+	if err := s.EnforceHistoryBudget(); err != nil {
+		t.Fatalf("EnforceHistoryBudget() error: %v", err)
+	}
+	if len(s.History) != 4 {
+		t.Fatalf("summarize left %d events, want 4", len(s.History))
+	}
+	rollup := s.History[0]
+	if rollup.Action != HistoryRollupAction || rollup.Rollup["phase_next"] != 1 || rollup.Rollup["phase_set"] != 1 {
+		t.Errorf("first event = %+v, want a rollup of the first day", rollup)
+	}
+	if rollup.Timestamp != "2026-01-01T10:00:00Z" {
+		t.Errorf("rollup timestamp = %q, want the day's last event", rollup.Timestamp)
+	}
+
+	s.HistoryMaxEvents = 2
+	if err := s.EnforceHistoryBudget(); err != nil {
+		t.Fatalf("EnforceHistoryBudget() error: %v", err)
+	}
+	if len(s.History) != 2 || s.History[0].Timestamp != "2026-01-02T10:00:00Z" || s.History[1].Rollup["phase_next"] != 2 {
+		t.Errorf("with more days than budget, the oldest rollups should be dropped, got %+v", s.History)
+	}
+
+	s.History = history()
+	s.HistoryStrategy = HistoryError
+	if err := s.EnforceHistoryBudget(); err == nil {
+		t.Error("error strategy should fail over budget")
+	}
+	if len(s.History) != 6 {
+		t.Errorf("error strategy should leave history alone, got %d events", len(s.History))
+	}
+}
+
+func TestStalledUsesLatestEventOrHeartbeat(t *testing.T) {
+	s := NewSession()
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if s.Stalled(at) {
+		t.Error("a session without activity should not be stalled")
+	}
+
+	s.History = []Event{{Action: "spec_add", Timestamp: "2026-01-01T11:00:00Z"}}
+	if !s.Stalled(at) {
+		t.Errorf("no activity for 1h should exceed the default %s window", DefaultStallAfter)
+	}
+
+	s.LastHeartbeat = "2026-01-01T11:45:00Z"
+	if s.LastActivity() != s.LastHeartbeat || s.Stalled(at) {
+		t.Errorf("a recent heartbeat should count as activity, last activity %q", s.LastActivity())
+	}
+
+	s.StallAfter = "10m"
+	if !s.Stalled(at) {
+		t.Error("15m without activity should exceed a 10m window")
+	}
+}
+
+func TestSpecCriteriaSignOff(t *testing.T) {
+	s := NewSession()
+	s.AddSpec("password reset")
+	s.AddSpec("logout")
+	if err := s.AddSpecCriteria(1, []string{"email is sent", "token expires"}); err != nil {
+		t.Fatalf("AddSpecCriteria() error: %v", err)
+	}
+	if got := s.UnsignedSpecs([]int{1, 2}); len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("UnsignedSpecs() = %+v, want spec 1", got)
+	}
+
+	if err := s.CheckSpecCriterion(1, 3, true); err == nil {
+		t.Error("checking an unknown criterion should fail")
+	}
+	_ = s.CheckSpecCriterion(1, 1, true)
+	if unmet := s.SpecByID(1).UnmetCriteria(); len(unmet) != 1 || unmet[0].ID != 2 {
+		t.Errorf("UnmetCriteria() = %+v, want criterion 2", unmet)
+	}
+
+	_ = s.WaiveSpecCriteria(1, "covered by integration suite")
+	if got := s.UnsignedSpecs([]int{1}); len(got) != 0 {
+		t.Errorf("waived spec should be signed off, got %+v", got)
+	}
+}
+
+func TestOffBranch(t *testing.T) {
+	s := NewSession()
+	if s.OffBranch("feature") {
+		t.Error("a session pinned to no branch is never off its branch")
+	}
+	s.Branch = "main"
+	tests := map[string]bool{"main": false, "feature": true, "": false}
+	for current, want := range tests {
+		if got := s.OffBranch(current); got != want {
+			t.Errorf("OffBranch(%q) = %v, want %v", current, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package verify
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/macosta/tdd-ai/internal/types"
 )
@@ -16,10 +17,13 @@ type Violation struct {
 // Result holds the outcome of a TDD compliance analysis.
 type Result struct {
 	Violations     []Violation `json:"violations"`
+	Warnings       []Violation `json:"warnings,omitempty"`
 	SpecsVerified  int         `json:"specs_verified"`
 	SpecsCompliant int         `json:"specs_compliant"`
 	Score          float64     `json:"score"`
 	Compliant      bool        `json:"compliant"`
+	OverridesCount int         `json:"overrides_count"`
+	ReflectionDebt int         `json:"reflection_debt"`
 }
 
 // Analyze checks a session's history for TDD compliance violations.
@@ -29,9 +33,45 @@ func Analyze(s *types.Session) Result {
 	// Check for phase_set usage (global violation)
 	for _, ev := range s.History {
 		if ev.Action == "phase_set" {
+			msg := fmt.Sprintf("phase_set used (%s -> %s) — bypasses TDD guardrails", ev.From, ev.To)
+			if ev.Reason != "" {
+				msg += fmt.Sprintf(" (reason: %s)", ev.Reason)
+			}
+			violations = append(violations, Violation{Rule: "no_phase_set", Message: msg})
+		}
+	}
+
+	// Test files edited during GREEN, caught when leaving the phase
+	for _, ev := range s.History {
+		if ev.Action == "test_files_edited" {
 			violations = append(violations, Violation{
-				Rule:    "no_phase_set",
-				Message: fmt.Sprintf("phase_set used (%s -> %s) — bypasses TDD guardrails", ev.From, ev.To),
+				Rule:    "tests_edited_in_green",
+				Message: fmt.Sprintf("test files edited during GREEN: %s", strings.Join(ev.Files, ", ")),
+			})
+		}
+	}
+
+	// Agents touching files claimed by another agent (warning only)
+	var warnings []Violation
+	for _, ev := range s.History {
+		if ev.Action == "claim_conflict" {
+			agent := ev.AgentID
+			if agent == "" {
+				agent = "unknown agent"
+			}
+			warnings = append(warnings, Violation{
+				Rule:    "claimed_file_touched",
+				Message: fmt.Sprintf("%s touched %s claimed by %s", agent, strings.Join(ev.Files, ", "), ev.Result),
+			})
+		}
+	}
+
+	// Review approvals given without the interactive walkthrough (warning only)
+	for _, ev := range s.History {
+		if ev.Action == "review" && ev.Result == types.ReviewApproved && ev.Confirmed != types.ConfirmedInteractive {
+			warnings = append(warnings, Violation{
+				Rule:    "review_not_interactive",
+				Message: fmt.Sprintf("review approved at %s without the interactive walkthrough", ev.Timestamp),
 			})
 		}
 	}
@@ -59,10 +99,13 @@ func Analyze(s *types.Session) Result {
 
 	return Result{
 		Violations:     violations,
+		Warnings:       warnings,
 		SpecsVerified:  len(completedSpecs),
 		SpecsCompliant: specsCompliant,
 		Score:          score,
 		Compliant:      len(violations) == 0,
+		OverridesCount: s.OverridesCount(),
+		ReflectionDebt: len(s.ReflectionDebt),
 	}
 }
 
@@ -84,7 +127,7 @@ func analyzeSpec(s *types.Session, specID int) []Violation {
 	// Check for spec_picked event
 	hasPicked := false
 	for _, ev := range s.History {
-		if ev.Action == "spec_picked" && ev.SpecID == specID {
+		if ev.Action == "spec_picked" && ev.CoversSpec(specID) {
 			hasPicked = true
 			break
 		}
@@ -103,7 +146,7 @@ func analyzeSpec(s *types.Session, specID int) []Violation {
 		hasRedFail := false
 		afterPick := false
 		for _, ev := range s.History {
-			if ev.Action == "spec_picked" && ev.SpecID == specID {
+			if ev.Action == "spec_picked" && ev.CoversSpec(specID) {
 				afterPick = true
 				continue
 			}
@@ -112,7 +155,7 @@ func analyzeSpec(s *types.Session, specID int) []Violation {
 				break
 			}
 			// Stop scanning if we hit the next spec_picked or phase transition past green
-			if afterPick && ev.Action == "spec_picked" && ev.SpecID != specID {
+			if afterPick && ev.Action == "spec_picked" && !ev.CoversSpec(specID) {
 				break
 			}
 		}
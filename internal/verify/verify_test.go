@@ -1,6 +1,7 @@
 package verify
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/macosta/tdd-ai/internal/types"
@@ -146,6 +147,19 @@ func TestAnalyzeDetectsPhaseSetUsage(t *testing.T) {
 	}
 }
 
+func TestAnalyzeDetectsTestsEditedInGreen(t *testing.T) {
+	s := buildCompliantSession()
+	s.AddEvent("test_files_edited", func(e *types.Event) {
+		e.Files = []string{"calc_test.go"}
+	})
+
+	result := Analyze(s)
+
+	if result.Compliant || len(result.Violations) != 1 || result.Violations[0].Rule != "tests_edited_in_green" {
+		t.Errorf("should have a tests_edited_in_green violation, got: %+v", result.Violations)
+	}
+}
+
 func TestAnalyzeReturnsComplianceScore(t *testing.T) {
 	s := types.NewSession()
 	s.Phase = types.PhaseDone
@@ -223,3 +237,76 @@ func TestAnalyzeEmptyHistoryGraceful(t *testing.T) {
 		t.Errorf("SpecsVerified = %d, want 0", result.SpecsVerified)
 	}
 }
+
+func TestAnalyzeBatchPickCoversAllSpecs(t *testing.T) {
+	s := types.NewSession()
+	s.Phase = types.PhaseDone
+	s.AddSpec("feature A")
+	s.AddSpec("feature B")
+	_ = s.CompleteSpec(1)
+	_ = s.CompleteSpec(2)
+
+	s.AddEvent("spec_picked", func(e *types.Event) {
+		e.SpecID = 1
+		e.SpecIDs = []int{1, 2}
+	})
+	s.AddEvent("test_run", func(e *types.Event) { e.Result = "fail" })
+	s.AddEvent("phase_next", func(e *types.Event) {
+		e.From = "red"
+		e.To = "green"
+		e.Result = "fail"
+	})
+
+	result := Analyze(s)
+
+	if !result.Compliant {
+		t.Errorf("batch-picked specs should be compliant, got: %+v", result.Violations)
+	}
+	if result.SpecsCompliant != 2 {
+		t.Errorf("SpecsCompliant = %d, want 2", result.SpecsCompliant)
+	}
+}
+
+func TestAnalyzeWarnsOnClaimConflicts(t *testing.T) {
+	s := types.NewSession()
+	s.AddEvent("claim_conflict", func(e *types.Event) {
+		e.AgentID = "worker-2"
+		e.Files = []string{"auth.go"}
+		e.Result = "worker-1"
+	})
+
+	result := Analyze(s)
+
+	if !result.Compliant {
+		t.Errorf("claim conflicts should not affect compliance, got: %+v", result.Violations)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Rule != "claimed_file_touched" {
+		t.Fatalf("Warnings = %+v, want one claimed_file_touched warning", result.Warnings)
+	}
+	if !strings.Contains(result.Warnings[0].Message, "worker-2 touched auth.go claimed by worker-1") {
+		t.Errorf("unexpected warning message: %q", result.Warnings[0].Message)
+	}
+}
+
+func TestAnalyzeWarnsOnNonInteractiveApproval(t *testing.T) {
+	s := types.NewSession()
+	s.AddEvent("review", func(e *types.Event) {
+		e.Result = types.ReviewApproved
+		e.Confirmed = types.ConfirmedInteractive
+	})
+	if result := Analyze(s); len(result.Warnings) != 0 {
+		t.Fatalf("Warnings = %+v, want none for a walkthrough approval", result.Warnings)
+	}
+
+	s.AddEvent("review", func(e *types.Event) {
+		e.Result = types.ReviewApproved
+		e.Confirmed = types.ConfirmedForced
+	})
+	result := Analyze(s)
+	if len(result.Warnings) != 1 || result.Warnings[0].Rule != "review_not_interactive" {
+		t.Fatalf("Warnings = %+v, want one review_not_interactive warning", result.Warnings)
+	}
+	if !result.Compliant {
+		t.Errorf("a flagged approval should not affect compliance, got: %+v", result.Violations)
+	}
+}